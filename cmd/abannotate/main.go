@@ -0,0 +1,362 @@
+package main
+
+import (
+	"crypto/sha1"
+	_ "embed"
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"hash/fnv"
+	"log"
+	"math/rand"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"AkuAI/pkg/abio"
+)
+
+//go:embed index.html
+var indexHTML []byte
+
+type ResultItem struct {
+	QueryID     string `json:"query_id,omitempty"`
+	Query       string `json:"query"`
+	Mode        string `json:"mode"`
+	Response    string `json:"response"`
+	Error       string `json:"error,omitempty"`
+	Combination string `json:"combination,omitempty"`
+}
+
+type RunSummary struct {
+	RunID   string       `json:"run_id"`
+	Results []ResultItem `json:"results"`
+}
+
+// Item is one response to rate, identified by a hash of (query, mode) so IDs
+// survive restarts.
+type Item struct {
+	ID       string  `json:"id"`
+	QueryID  string  `json:"query_id,omitempty"`
+	Query    string  `json:"query"`
+	Mode     string  `json:"mode,omitempty"` // empty in blind mode
+	Label    string  `json:"label"`          // "A"/"B" in blind mode, the mode otherwise
+	Response string  `json:"response"`
+	Rating   *Scores `json:"rating,omitempty"`
+}
+
+// Scores are the abjudge rubric: Likert 1..5 and binary 0/1.
+type Scores struct {
+	Relevance    int `json:"relevance"`
+	Accuracy     int `json:"accuracy"`
+	Completeness int `json:"completeness"`
+	Usefulness   int `json:"usefulness"`
+	JSONValid    int `json:"json_valid"`
+}
+
+func (s Scores) validate() error {
+	for _, v := range []int{s.Relevance, s.Completeness, s.Usefulness} {
+		if v < 1 || v > 5 {
+			return errors.New("relevance, completeness and usefulness must be 1-5")
+		}
+	}
+	if (s.Accuracy != 0 && s.Accuracy != 1) || (s.JSONValid != 0 && s.JSONValid != 1) {
+		return errors.New("accuracy and json_valid must be 0 or 1")
+	}
+	return nil
+}
+
+type item struct {
+	id, queryID, query, mode, response string
+}
+
+// store holds the ratings and rewrites the CSV after every change, in the
+// schema abjudge imports (query,mode,rater,relevance,...).
+type store struct {
+	mu      sync.Mutex
+	path    string
+	items   []item
+	byID    map[string]int
+	ratings map[string]map[string]Scores // rater -> item id -> scores
+}
+
+func itemID(query, mode string) string {
+	h := sha1.Sum([]byte(query + "\x00" + mode))
+	return hex.EncodeToString(h[:5])
+}
+
+func (s *store) load() error {
+	f, err := os.Open(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	rows, err := csv.NewReader(f).ReadAll()
+	if err != nil {
+		return err
+	}
+	for i, row := range rows {
+		if i == 0 || len(row) < 8 {
+			continue
+		}
+		var sc Scores
+		vals := []*int{&sc.Relevance, &sc.Accuracy, &sc.Completeness, &sc.Usefulness, &sc.JSONValid}
+		for j, p := range vals {
+			*p, _ = strconv.Atoi(strings.TrimSpace(row[3+j]))
+		}
+		id := itemID(row[0], row[1])
+		if _, ok := s.byID[id]; !ok || sc.validate() != nil {
+			continue
+		}
+		s.set(row[2], id, sc)
+	}
+	return nil
+}
+
+func (s *store) set(rater, id string, sc Scores) {
+	if s.ratings[rater] == nil {
+		s.ratings[rater] = map[string]Scores{}
+	}
+	s.ratings[rater][id] = sc
+}
+
+func (s *store) save() error {
+	raters := make([]string, 0, len(s.ratings))
+	for r := range s.ratings {
+		raters = append(raters, r)
+	}
+	sort.Strings(raters)
+	tmp := s.path + ".tmp"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	w := csv.NewWriter(f)
+	_ = w.Write([]string{"query", "mode", "rater", "relevance", "accuracy", "completeness", "usefulness", "json_valid"})
+	for _, it := range s.items {
+		for _, r := range raters {
+			sc, ok := s.ratings[r][it.id]
+			if !ok {
+				continue
+			}
+			_ = w.Write([]string{it.query, it.mode, r, strconv.Itoa(sc.Relevance), strconv.Itoa(sc.Accuracy),
+				strconv.Itoa(sc.Completeness), strconv.Itoa(sc.Usefulness), strconv.Itoa(sc.JSONValid)})
+		}
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp, s.path)
+}
+
+// blindOrder shuffles the responses of each query per rater, so "A" is not
+// always the same arm and raters cannot compare notes on labels.
+func blindOrder(rater, query string, n int) []int {
+	h := fnv.New64a()
+	h.Write([]byte(rater + "\x00" + query))
+	return rand.New(rand.NewSource(int64(h.Sum64()))).Perm(n)
+}
+
+func (s *store) list(rater string, blind bool) []Item {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := make([]Item, 0, len(s.items))
+	for i := 0; i < len(s.items); {
+		j := i
+		for j < len(s.items) && s.items[j].query == s.items[i].query {
+			j++
+		}
+		group := s.items[i:j]
+		order := make([]int, len(group))
+		for k := range order {
+			order[k] = k
+		}
+		if blind {
+			order = blindOrder(rater, group[0].query, len(group))
+		}
+		for pos, k := range order {
+			it := group[k]
+			v := Item{ID: it.id, QueryID: it.queryID, Query: it.query, Response: it.response, Label: it.mode, Mode: it.mode}
+			if blind {
+				v.Label, v.Mode = string(rune('A'+pos)), ""
+			}
+			if sc, ok := s.ratings[rater][it.id]; ok {
+				v.Rating = &sc
+			}
+			out = append(out, v)
+		}
+		i = j
+	}
+	return out
+}
+
+func (s *store) progress() map[string]map[string]int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := map[string]map[string]int{}
+	for r, m := range s.ratings {
+		out[r] = map[string]int{"rated": len(m), "total": len(s.items)}
+	}
+	return out
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func main() {
+	path := strings.TrimSpace(os.Getenv("ABTEST_RESULTS"))
+	if path == "" {
+		var err error
+		if path, err = abio.LatestResultsJSON(abio.ResultsDir); err != nil {
+			log.Fatal(err)
+		}
+	}
+	b, err := os.ReadFile(path)
+	if err != nil {
+		log.Fatal(err)
+	}
+	var summary RunSummary
+	if err := json.Unmarshal(b, &summary); err != nil {
+		log.Fatalf("invalid results %s: %v", path, err)
+	}
+
+	// The ratings schema has no combination column: rate one model and
+	// temperature of a sweep at a time (ABANNOTATE_COMBINATION=<model>@<temp>),
+	// otherwise the first result per (query, mode) wins.
+	combo := strings.TrimSpace(os.Getenv("ABANNOTATE_COMBINATION"))
+	st := &store{byID: map[string]int{}, ratings: map[string]map[string]Scores{}}
+	dropped := 0
+	for _, r := range summary.Results {
+		if pair, _, _ := strings.Cut(r.Combination, "/"); combo != "" && pair != combo {
+			continue
+		}
+		id := itemID(r.Query, r.Mode)
+		if _, dup := st.byID[id]; dup {
+			dropped++
+			continue
+		}
+		resp := r.Response
+		if resp == "" && r.Error != "" {
+			resp = "(error) " + r.Error
+		}
+		st.byID[id] = len(st.items)
+		st.items = append(st.items, item{id: id, queryID: r.QueryID, query: r.Query, mode: r.Mode, response: resp})
+	}
+	if dropped > 0 {
+		log.Printf("[abannotate] ⚠️ %d results share a (query, mode) with an earlier one and were skipped; set ABANNOTATE_COMBINATION", dropped)
+	}
+	if len(st.items) == 0 {
+		log.Fatalf("no results to annotate in %s", path)
+	}
+	// keep the responses of one query together
+	sort.SliceStable(st.items, func(i, j int) bool { return st.items[i].query < st.items[j].query })
+	for i, it := range st.items {
+		st.byID[it.id] = i
+	}
+
+	st.path = strings.TrimSpace(os.Getenv("ABANNOTATE_RATINGS"))
+	if st.path == "" {
+		base := strings.TrimSuffix(filepath.Base(path), ".json")
+		st.path = filepath.Join(filepath.Dir(path), "ratings-annotate-"+strings.TrimPrefix(base, "abtest-")+".csv")
+	}
+	if err := st.load(); err != nil {
+		log.Fatalf("load %s: %v", st.path, err)
+	}
+
+	blind := os.Getenv("ABANNOTATE_BLIND") == "1"
+	var allowed map[string]bool
+	if names := strings.TrimSpace(os.Getenv("ABANNOTATE_RATERS")); names != "" {
+		allowed = map[string]bool{}
+		for _, n := range strings.Split(names, ",") {
+			allowed[strings.TrimSpace(n)] = true
+		}
+	}
+	rater := func(w http.ResponseWriter, name string) (string, bool) {
+		name = strings.TrimSpace(name)
+		if name == "" || strings.ContainsAny(name, ",\n\r") || (allowed != nil && !allowed[name]) {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"msg": "unknown or missing rater"})
+			return "", false
+		}
+		return name, true
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /{$}", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		_, _ = w.Write(indexHTML)
+	})
+	mux.HandleFunc("GET /api/config", func(w http.ResponseWriter, r *http.Request) {
+		raters := []string{}
+		for n := range allowed {
+			raters = append(raters, n)
+		}
+		sort.Strings(raters)
+		writeJSON(w, http.StatusOK, map[string]any{"run_id": summary.RunID, "results": path, "blind": blind, "raters": raters, "total": len(st.items)})
+	})
+	mux.HandleFunc("GET /api/items", func(w http.ResponseWriter, r *http.Request) {
+		name, ok := rater(w, r.URL.Query().Get("rater"))
+		if !ok {
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]any{"items": st.list(name, blind)})
+	})
+	mux.HandleFunc("POST /api/ratings", func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Rater  string `json:"rater"`
+			ItemID string `json:"item_id"`
+			Scores
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"msg": "invalid body"})
+			return
+		}
+		name, ok := rater(w, body.Rater)
+		if !ok {
+			return
+		}
+		if err := body.Scores.validate(); err != nil {
+			writeJSON(w, http.StatusUnprocessableEntity, map[string]string{"msg": err.Error()})
+			return
+		}
+		st.mu.Lock()
+		defer st.mu.Unlock()
+		if _, ok := st.byID[body.ItemID]; !ok {
+			writeJSON(w, http.StatusNotFound, map[string]string{"msg": "item not found"})
+			return
+		}
+		st.set(name, body.ItemID, body.Scores)
+		if err := st.save(); err != nil {
+			log.Printf("[abannotate] ❌ save %s: %v", st.path, err)
+			writeJSON(w, http.StatusInternalServerError, map[string]string{"msg": "failed to save"})
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]any{"rated": len(st.ratings[name]), "total": len(st.items)})
+	})
+	mux.HandleFunc("GET /api/progress", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, map[string]any{"raters": st.progress()})
+	})
+
+	addr := strings.TrimSpace(os.Getenv("ABANNOTATE_ADDR"))
+	if addr == "" {
+		addr = "127.0.0.1:8090"
+	}
+	log.Printf("[abannotate] %d responses from %s (blind=%v); ratings -> %s", len(st.items), path, blind, st.path)
+	log.Printf("[abannotate] open http://%s/", addr)
+	log.Fatal(http.ListenAndServe(addr, mux))
+}
@@ -0,0 +1,149 @@
+package main
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	mrand "math/rand"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// BlindKey maps the opaque codes of a blinded template back to modes. Seal is
+// a SHA-256 over the mapping, checked at import so an edited key is rejected.
+type BlindKey struct {
+	Template  string               `json:"template"`
+	CreatedAt string               `json:"created_at"`
+	Codes     map[string]BlindItem `json:"codes"`
+	Seal      string               `json:"seal"`
+}
+
+type BlindItem struct {
+	Query string `json:"query"`
+	Mode  string `json:"mode"`
+}
+
+func (k *BlindKey) seal() string {
+	codes := make([]string, 0, len(k.Codes))
+	for c := range k.Codes {
+		codes = append(codes, c)
+	}
+	sort.Strings(codes)
+	h := sha256.New()
+	fmt.Fprintf(h, "%s\n", k.Template)
+	for _, c := range codes {
+		fmt.Fprintf(h, "%s\x00%s\x00%s\n", c, k.Codes[c].Query, k.Codes[c].Mode)
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// blindCode is random (not derived from the seed) so ABJUDGE_SEED cannot be
+// used to recover the arms.
+func blindCode() string {
+	b := make([]byte, 4)
+	_, _ = rand.Read(b)
+	return "X" + strings.ToUpper(hex.EncodeToString(b))
+}
+
+type templateRow struct {
+	query, code, rater, response string
+}
+
+// blindTemplate replaces each (query, mode) with a code and shuffles the rows
+// of every rater independently.
+func blindTemplate(items [][2]string, responses map[[2]string]string, raters []string, r *mrand.Rand, templateName string) ([]templateRow, *BlindKey) {
+	key := &BlindKey{Template: templateName, CreatedAt: time.Now().Format(time.RFC3339), Codes: map[string]BlindItem{}}
+	codes := make([]string, len(items))
+	for i, it := range items {
+		c := blindCode()
+		for _, dup := key.Codes[c]; dup; _, dup = key.Codes[c] {
+			c = blindCode()
+		}
+		codes[i] = c
+		key.Codes[c] = BlindItem{Query: it[0], Mode: it[1]}
+	}
+	key.Seal = key.seal()
+
+	var rows []templateRow
+	for _, rater := range raters {
+		for _, i := range r.Perm(len(items)) {
+			rows = append(rows, templateRow{query: items[i][0], code: codes[i], rater: rater, response: responses[items[i]]})
+		}
+	}
+	return rows, key
+}
+
+func writeBlindKey(path string, key *BlindKey) error {
+	b, err := json.MarshalIndent(key, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, b, 0o400)
+}
+
+func readBlindKey(path string) (*BlindKey, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var k BlindKey
+	if err := json.Unmarshal(b, &k); err != nil {
+		return nil, fmt.Errorf("invalid blind key %s: %w", path, err)
+	}
+	if k.Seal == "" || k.Seal != k.seal() {
+		return nil, fmt.Errorf("blind key %s has been modified (seal mismatch)", path)
+	}
+	return &k, nil
+}
+
+// findBlindKey returns ABJUDGE_BLIND_KEY or else the ratings-key-*.json next
+// to the import file or in dir that knows code.
+func findBlindKey(importPath, dir, code string) (*BlindKey, string, error) {
+	if p := strings.TrimSpace(os.Getenv("ABJUDGE_BLIND_KEY")); p != "" {
+		k, err := readBlindKey(p)
+		return k, p, err
+	}
+	var candidates []string
+	for _, d := range []string{filepath.Dir(importPath), dir} {
+		m, _ := filepath.Glob(filepath.Join(d, "ratings-key-*.json"))
+		candidates = append(candidates, m...)
+	}
+	for _, p := range candidates {
+		if k, err := readBlindKey(p); err == nil {
+			if _, ok := k.Codes[code]; ok {
+				return k, p, nil
+			}
+		}
+	}
+	return nil, "", errors.New("blinded ratings: no matching ratings-key-*.json found; set ABJUDGE_BLIND_KEY")
+}
+
+// unblind restores the modes of imported rows whose mode column holds codes.
+// It returns false when the rows were not blinded.
+func unblind(rows []RatingRow, importPath, dir string) (bool, error) {
+	if len(rows) == 0 || rows[0].Mode == "baseline" || rows[0].Mode == "engineered" {
+		return false, nil
+	}
+	key, path, err := findBlindKey(importPath, dir, rows[0].Mode)
+	if err != nil {
+		return false, err
+	}
+	for i, rw := range rows {
+		it, ok := key.Codes[rw.Mode]
+		if !ok {
+			return false, fmt.Errorf("blinded ratings: code %q is not in %s", rw.Mode, path)
+		}
+		if it.Query != rw.Query {
+			return false, fmt.Errorf("blinded ratings: code %q belongs to a different query", rw.Mode)
+		}
+		rows[i].Mode = it.Mode
+	}
+	fmt.Println("[abjudge] unblinded ratings with key", path)
+	return true, nil
+}
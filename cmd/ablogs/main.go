@@ -0,0 +1,377 @@
+package main
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"AkuAI/pkg/abio"
+	"AkuAI/pkg/stats"
+)
+
+// LogEntry is one line of an abtest prompt log (promptlog-*.jsonl). AskCampus
+// logs the question, AskCampusWithChat the latest user question.
+type LogEntry struct {
+	Line                  int     `json:"-"`
+	Timestamp             string  `json:"timestamp"`
+	RunID                 string  `json:"run_id"`
+	Mode                  string  `json:"mode"`
+	Function              string  `json:"function"`
+	Model                 string  `json:"model"`
+	Temperature           float64 `json:"temperature"`
+	Question              string  `json:"question"`
+	LatestUserQuestion    string  `json:"latest_user_question"`
+	ContextHash           string  `json:"context_hash"`
+	PromptTemplateID      string  `json:"prompt_template_id"`
+	PromptTemplateVersion string  `json:"prompt_template_version"`
+	PromptChars           int     `json:"prompt_chars"`
+	Prompt                string  `json:"prompt"`             // ABTEST_LOG_FULL only
+	SystemInstruction     string  `json:"system_instruction"` // ABTEST_LOG_FULL only
+}
+
+func (e LogEntry) question() string {
+	if e.Question != "" {
+		return e.Question
+	}
+	return e.LatestUserQuestion
+}
+
+// promptChars is prompt_chars, else the length of the logged prompt, else -1
+// (logs written before prompt_chars without ABTEST_LOG_FULL).
+func (e LogEntry) promptChars() int {
+	switch {
+	case e.PromptChars > 0:
+		return e.PromptChars
+	case e.Prompt != "":
+		return utf8.RuneCountInString(e.Prompt)
+	case e.SystemInstruction != "":
+		return utf8.RuneCountInString(e.SystemInstruction + e.LatestUserQuestion)
+	}
+	return -1
+}
+
+type ResultItem struct {
+	QueryID          string  `json:"query_id,omitempty"`
+	Query            string  `json:"query"`
+	Mode             string  `json:"mode"`
+	Model            string  `json:"model"`
+	Temperature      float64 `json:"temperature"`
+	ContextHash      string  `json:"context_hash,omitempty"`
+	PromptTemplateID string  `json:"prompt_template_id,omitempty"`
+	Error            string  `json:"error,omitempty"`
+}
+
+type RunSummary struct {
+	RunID     string       `json:"run_id"`
+	PromptLog string       `json:"prompt_log_file,omitempty"`
+	Results   []ResultItem `json:"results"`
+}
+
+// HashMismatch is a result whose context_hash differs from the one logged
+// when its prompt was built.
+type HashMismatch struct {
+	QueryID   string `json:"query_id,omitempty"`
+	Query     string `json:"query"`
+	Mode      string `json:"mode"`
+	ResultKey string `json:"result_context_hash"`
+	LogKey    string `json:"log_context_hash"`
+	LogLine   int    `json:"log_line"`
+}
+
+// PromptLengths is the prompt size distribution (characters) of one template.
+type PromptLengths struct {
+	Template string  `json:"prompt_template_id"`
+	N        int     `json:"n"`
+	Unknown  int     `json:"unknown"` // entries without prompt_chars or a full prompt
+	Min      float64 `json:"min"`
+	Mean     float64 `json:"mean"`
+	P50      float64 `json:"p50"`
+	P95      float64 `json:"p95"`
+	Max      float64 `json:"max"`
+}
+
+// Divergence is a question whose event context changed within one run.
+type Divergence struct {
+	RunID    string   `json:"run_id"`
+	Question string   `json:"question"`
+	Hashes   []string `json:"context_hashes"` // in order of first appearance
+	Lines    []int    `json:"first_lines"`
+}
+
+type Report struct {
+	GeneratedAt    string          `json:"generated_at"`
+	LogFile        string          `json:"log_file"`
+	ResultsFile    string          `json:"results_file,omitempty"`
+	Entries        int             `json:"entries"`
+	BadLines       int             `json:"bad_lines"`
+	Runs           []string        `json:"runs"`
+	Checked        int             `json:"results_checked"`
+	Matched        int             `json:"results_matched"`
+	Unlogged       int             `json:"results_unlogged"` // no log entry (mocked, cached or failed before the prompt)
+	Orphans        int             `json:"log_entries_without_result"`
+	Mismatches     []HashMismatch  `json:"hash_mismatches"`
+	PromptLengths  []PromptLengths `json:"prompt_lengths"`
+	Divergences    []Divergence    `json:"divergences"`
+	DivergedRunIDs []string        `json:"diverged_runs"`
+}
+
+var emptyHash = func() string { h := sha256.Sum256(nil); return hex.EncodeToString(h[:]) }()
+
+// sameContext treats "" in results and the hash of an empty context in logs
+// as the same (non-UIB prompts).
+func sameContext(result, logged string) bool {
+	norm := func(h string) string {
+		if h == emptyHash {
+			return ""
+		}
+		return h
+	}
+	return norm(result) == norm(logged)
+}
+
+func readLog(path string) ([]LogEntry, int, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer f.Close()
+	var entries []LogEntry
+	bad := 0
+	sc := bufio.NewScanner(f)
+	sc.Buffer(make([]byte, 1024*1024), 64*1024*1024) // full logs carry the context snapshot
+	line := 0
+	for sc.Scan() {
+		line++
+		if strings.TrimSpace(sc.Text()) == "" {
+			continue
+		}
+		var e LogEntry
+		if err := json.Unmarshal(sc.Bytes(), &e); err != nil {
+			bad++
+			continue
+		}
+		e.Line = line
+		entries = append(entries, e)
+	}
+	return entries, bad, sc.Err()
+}
+
+func latestPromptLog(dir string) (string, error) {
+	matches, _ := filepath.Glob(filepath.Join(dir, "promptlog-*.jsonl"))
+	var latest string
+	var latestT time.Time
+	for _, m := range matches {
+		if info, err := os.Stat(m); err == nil && (latest == "" || info.ModTime().After(latestT)) {
+			latest, latestT = m, info.ModTime()
+		}
+	}
+	if latest == "" {
+		return "", fmt.Errorf("no promptlog-*.jsonl found in %s", dir)
+	}
+	return latest, nil
+}
+
+func entryKey(mode, question, model string, temperature float64) string {
+	return fmt.Sprintf("%s\x00%s\x00%s\x00%g", mode, strings.TrimSpace(question), model, temperature)
+}
+
+// checkHashes pairs every result with the next unused log entry for the same
+// mode, question, model and temperature (abtest logs in run order) and
+// compares their context hashes.
+func checkHashes(rep *Report, results []ResultItem, entries []LogEntry) {
+	queue := map[string][]LogEntry{}
+	for _, e := range entries {
+		k := entryKey(e.Mode, e.question(), e.Model, e.Temperature)
+		queue[k] = append(queue[k], e)
+	}
+	for _, r := range results {
+		rep.Checked++
+		k := entryKey(r.Mode, r.Query, r.Model, r.Temperature)
+		q := queue[k]
+		if len(q) == 0 {
+			rep.Unlogged++
+			continue
+		}
+		e := q[0]
+		queue[k] = q[1:]
+		rep.Matched++
+		if !sameContext(r.ContextHash, e.ContextHash) {
+			rep.Mismatches = append(rep.Mismatches, HashMismatch{QueryID: r.QueryID, Query: r.Query, Mode: r.Mode, ResultKey: r.ContextHash, LogKey: e.ContextHash, LogLine: e.Line})
+		}
+	}
+	for _, q := range queue {
+		rep.Orphans += len(q)
+	}
+}
+
+func promptLengths(entries []LogEntry) []PromptLengths {
+	lens := map[string][]float64{}
+	unknown := map[string]int{}
+	for _, e := range entries {
+		t := e.PromptTemplateID
+		if t == "" {
+			t = "(none)"
+		}
+		if n := e.promptChars(); n >= 0 {
+			lens[t] = append(lens[t], float64(n))
+		} else {
+			unknown[t]++
+			if _, ok := lens[t]; !ok {
+				lens[t] = nil // keep templates whose entries all lack a length
+			}
+		}
+	}
+	out := make([]PromptLengths, 0, len(lens))
+	for t, xs := range lens {
+		pl := PromptLengths{Template: t, N: len(xs), Unknown: unknown[t]}
+		if len(xs) > 0 {
+			pl.Min, pl.Max = stats.Percentile(xs, 0), stats.Percentile(xs, 100)
+			pl.Mean, pl.P50, pl.P95 = stats.Mean(xs), stats.Percentile(xs, 50), stats.Percentile(xs, 95)
+		}
+		out = append(out, pl)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Template < out[j].Template })
+	return out
+}
+
+// divergences finds questions whose context hash changed within a run, i.e.
+// the event data was reloaded or edited while the run was in progress.
+func divergences(entries []LogEntry) []Divergence {
+	type key struct{ run, q string }
+	seen := map[key]*Divergence{}
+	var order []key
+	for _, e := range entries {
+		k := key{e.RunID, strings.TrimSpace(e.question())}
+		d, ok := seen[k]
+		if !ok {
+			d = &Divergence{RunID: e.RunID, Question: k.q}
+			seen[k] = d
+			order = append(order, k)
+		}
+		known := false
+		for _, h := range d.Hashes {
+			if sameContext(h, e.ContextHash) {
+				known = true
+				break
+			}
+		}
+		if !known {
+			d.Hashes = append(d.Hashes, e.ContextHash)
+			d.Lines = append(d.Lines, e.Line)
+		}
+	}
+	var out []Divergence
+	for _, k := range order {
+		if d := seen[k]; len(d.Hashes) > 1 {
+			out = append(out, *d)
+		}
+	}
+	return out
+}
+
+func main() {
+	// Results are optional: without them only lengths and divergence are checked
+	resultsPath := strings.TrimSpace(os.Getenv("ABTEST_RESULTS"))
+	if resultsPath == "" {
+		resultsPath, _ = abio.LatestResultsJSON(abio.ResultsDir)
+	}
+	var summary RunSummary
+	if resultsPath != "" {
+		if err := abio.ReadJSON(resultsPath, &summary); err != nil {
+			fmt.Println("error:", err)
+			os.Exit(1)
+		}
+	}
+
+	logPath := strings.TrimSpace(os.Getenv("ABLOGS_LOG"))
+	if logPath == "" {
+		logPath = summary.PromptLog
+	}
+	if logPath == "" {
+		var err error
+		if logPath, err = latestPromptLog(filepath.Join(abio.ResultsDir, "prompt_logs")); err != nil {
+			fmt.Println("error:", err)
+			os.Exit(1)
+		}
+	}
+	entries, bad, err := readLog(logPath)
+	if err != nil {
+		fmt.Println("error:", err)
+		os.Exit(1)
+	}
+	fmt.Printf("[ablogs] %s: %d entries (%d unreadable lines)\n", logPath, len(entries), bad)
+
+	rep := Report{GeneratedAt: time.Now().Format(time.RFC3339), LogFile: logPath, Entries: len(entries), BadLines: bad}
+	runs := map[string]bool{}
+	for _, e := range entries {
+		if !runs[e.RunID] {
+			runs[e.RunID] = true
+			rep.Runs = append(rep.Runs, e.RunID)
+		}
+	}
+
+	// A log file set via ABTEST_PROMPT_LOG_FILE can hold several runs; only
+	// the results' run is compared with the results.
+	if resultsPath != "" {
+		rep.ResultsFile = resultsPath
+		var runEntries []LogEntry
+		for _, e := range entries {
+			if summary.RunID == "" || e.RunID == summary.RunID {
+				runEntries = append(runEntries, e)
+			}
+		}
+		checkHashes(&rep, summary.Results, runEntries)
+		fmt.Printf("[ablogs] %s: %d results, %d matched to log entries, %d unlogged, %d log entries without result\n",
+			resultsPath, rep.Checked, rep.Matched, rep.Unlogged, rep.Orphans)
+		for _, m := range rep.Mismatches {
+			fmt.Printf("  ❌ context_hash mismatch %s [%s] %q (log line %d)\n", m.QueryID, m.Mode, m.Query, m.LogLine)
+		}
+		if len(rep.Mismatches) == 0 && rep.Matched > 0 {
+			fmt.Println("  ✅ context hashes consistent between log and results")
+		}
+	}
+
+	rep.PromptLengths = promptLengths(entries)
+	fmt.Println("[ablogs] prompt length (chars) per template:")
+	for _, pl := range rep.PromptLengths {
+		fmt.Printf("  %s -> n=%d, min=%.0f, mean=%.0f, p50=%.0f, p95=%.0f, max=%.0f", pl.Template, pl.N, pl.Min, pl.Mean, pl.P50, pl.P95, pl.Max)
+		if pl.Unknown > 0 {
+			fmt.Printf(" (%d entries without length)", pl.Unknown)
+		}
+		fmt.Println()
+	}
+
+	rep.Divergences = divergences(entries)
+	diverged := map[string]bool{}
+	for _, d := range rep.Divergences {
+		if !diverged[d.RunID] {
+			diverged[d.RunID] = true
+			rep.DivergedRunIDs = append(rep.DivergedRunIDs, d.RunID)
+		}
+		fmt.Printf("  ⚠️ run %s: context for %q changed mid-run (%d versions, first at lines %v)\n", d.RunID, d.Question, len(d.Hashes), d.Lines)
+	}
+	if len(rep.Divergences) == 0 {
+		fmt.Println("[ablogs] no context divergence within runs")
+	}
+
+	outDir := abio.ResultsDir
+	_ = os.MkdirAll(outDir, 0o755)
+	jsonPath := filepath.Join(outDir, fmt.Sprintf("ablogs-%s.json", time.Now().Format("20060102-150405")))
+	if err := abio.WriteJSON(jsonPath, rep); err != nil {
+		fmt.Println("error:", err)
+		os.Exit(1)
+	}
+	fmt.Println("[ablogs] saved:", jsonPath)
+
+	if os.Getenv("ABLOGS_STRICT") == "1" && (len(rep.Mismatches) > 0 || len(rep.Divergences) > 0) {
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,351 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"AkuAI/models"
+	"AkuAI/pkg/database"
+	svc "AkuAI/pkg/services"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// ReplayRow scores one stored bot reply against the current event data.
+type ReplayRow struct {
+	MessageID          uint     `json:"message_id"`
+	ConversationID     uint     `json:"conversation_id"`
+	CreatedAt          string   `json:"created_at"`
+	Query              string   `json:"query"`
+	Mode               string   `json:"mode"`
+	Model              string   `json:"model"`
+	PromptTemplateID   string   `json:"prompt_template_id"`
+	TemplateVersion    string   `json:"prompt_template_version"`
+	Cached             bool     `json:"cached"`
+	Feedback           int8     `json:"feedback"`
+	Campus             string   `json:"campus"`
+	RelevantEventIDs   []string `json:"relevant_event_ids"`
+	MentionedEventIDs  []string `json:"mentioned_event_ids"`
+	UnknownEventIDs    []string `json:"unknown_event_ids"` // cited markers that no dataset contains anymore
+	Precision          float64  `json:"precision"`
+	Recall             float64  `json:"recall"`
+	F1                 float64  `json:"f1"`
+	FabContact         bool     `json:"fabricated_contact"`
+	FabLink            bool     `json:"fabricated_link"`
+	StoredContextHash  string   `json:"stored_context_hash"`
+	CurrentContextHash string   `json:"current_context_hash"`
+	ContextDrift       bool     `json:"context_drift"`   // event context for the query changed since the reply
+	DetectionDrift     bool     `json:"detection_drift"` // query is (no longer) routed to the event prompt
+	VersionDrift       bool     `json:"version_drift"`   // reply used an older prompt template version
+}
+
+// DriftSummary aggregates replay rows for one (prompt_template_id, mode) group.
+type DriftSummary struct {
+	Group              string  `json:"group"`
+	N                  int     `json:"n"`
+	AvgPrecision       float64 `json:"avg_precision"`
+	AvgRecall          float64 `json:"avg_recall"`
+	AvgF1              float64 `json:"avg_f1"`
+	ContextDriftRate   float64 `json:"context_drift_rate"`
+	DetectionDriftRate float64 `json:"detection_drift_rate"`
+	VersionDriftRate   float64 `json:"version_drift_rate"`
+	UnknownEventRate   float64 `json:"unknown_event_rate"`
+	FabricatedRate     float64 `json:"fabricated_rate"`
+	ThumbsDown         int     `json:"thumbs_down"`
+}
+
+type ReplayReport struct {
+	GeneratedAt     string         `json:"generated_at"`
+	Since           string         `json:"since"`
+	TemplateVersion string         `json:"current_template_version"`
+	Campuses        []string       `json:"campuses"`
+	Summary         []DriftSummary `json:"summary"`
+	Rows            []ReplayRow    `json:"rows"`
+}
+
+var (
+	markerRe = regexp.MustCompile(`\bEV-[A-Z0-9]+(?:-[A-Z0-9]+)*\b`)
+	emailRe  = regexp.MustCompile(`[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}`)
+)
+
+func envInt(key string, def int) int {
+	if s := strings.TrimSpace(os.Getenv(key)); s != "" {
+		if n, err := strconv.Atoi(s); err == nil && n >= 0 {
+			return n
+		}
+	}
+	return def
+}
+
+func shaHex(s string) string {
+	h := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(h[:])
+}
+
+func f1Score(precision, recall float64) float64 {
+	if precision+recall == 0 {
+		return 0
+	}
+	return 2 * (precision * recall) / (precision + recall)
+}
+
+// previousQuestion returns the user message a bot reply answers.
+func previousQuestion(db *gorm.DB, bot models.Message) (string, error) {
+	var user models.Message
+	err := db.Where("conversation_id = ? AND sender = ? AND id < ?", bot.ConversationID, "user", bot.ID).
+		Order("id DESC").First(&user).Error
+	return user.Text, err
+}
+
+// eventExists reports whether any campus dataset still has an event with id.
+func eventExists(campuses *svc.CampusDataService, id string) bool {
+	for _, name := range campuses.Campuses() {
+		if ds := campuses.Dataset(name); ds != nil {
+			if _, err := ds.GetEventByID(id); err == nil {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func replay(campuses *svc.CampusDataService, bot models.Message, question string) ReplayRow {
+	row := ReplayRow{
+		MessageID:         bot.ID,
+		ConversationID:    bot.ConversationID,
+		CreatedAt:         bot.CreatedAt.Format(time.RFC3339),
+		Query:             question,
+		Mode:              bot.PromptMode,
+		Model:             bot.ModelName,
+		PromptTemplateID:  bot.PromptTemplateID,
+		TemplateVersion:   bot.PromptTemplateVersion,
+		Cached:            bot.Cached,
+		Feedback:          bot.Feedback,
+		StoredContextHash: bot.ContextHash,
+		RelevantEventIDs:  []string{},
+		UnknownEventIDs:   []string{},
+	}
+
+	// Re-run retrieval the way the chat prompt builds its event context
+	campus, ds := campuses.ForQuery(question)
+	row.Campus = campus
+	detected := ds != nil && ds.AnalyzeQueryForUIB(question)
+	allowedContacts := map[string]bool{}
+	if detected {
+		events := ds.GetRelevantEventsForQuery(question)
+		for _, ev := range events {
+			row.RelevantEventIDs = append(row.RelevantEventIDs, ev.ID)
+			if c := strings.ToLower(strings.TrimSpace(ev.Contact)); c != "" {
+				allowedContacts[c] = true
+			}
+		}
+		sort.Strings(row.RelevantEventIDs)
+		row.CurrentContextHash = shaHex(ds.EventContext(question, events))
+	}
+
+	row.MentionedEventIDs = svc.MentionedEventIDs(bot.Text)
+	seen := map[string]bool{}
+	for _, id := range markerRe.FindAllString(bot.Text, -1) {
+		if !seen[id] && !eventExists(campuses, id) {
+			row.UnknownEventIDs = append(row.UnknownEventIDs, id)
+		}
+		seen[id] = true
+	}
+
+	rel := map[string]bool{}
+	for _, id := range row.RelevantEventIDs {
+		rel[id] = true
+	}
+	tp := 0
+	for _, id := range row.MentionedEventIDs {
+		if rel[id] {
+			tp++
+		}
+	}
+	row.Precision, row.Recall = 1.0, 1.0
+	if n := len(row.MentionedEventIDs) + len(row.UnknownEventIDs); n > 0 {
+		row.Precision = float64(tp) / float64(n)
+	}
+	if len(rel) > 0 {
+		row.Recall = float64(tp) / float64(len(rel))
+	}
+	row.F1 = f1Score(row.Precision, row.Recall)
+
+	for _, e := range emailRe.FindAllString(bot.Text, -1) {
+		if !allowedContacts[strings.ToLower(e)] {
+			row.FabContact = true
+			break
+		}
+	}
+	lower := strings.ToLower(bot.Text)
+	row.FabLink = strings.Contains(lower, "http://") || strings.Contains(lower, "https://")
+
+	// Drift against what the reply was generated with; replies without
+	// stored metadata (cached, local or pre-dating it) are not compared.
+	if bot.PromptTemplateID != "" {
+		row.ContextDrift = bot.ContextHash != row.CurrentContextHash
+		row.DetectionDrift = strings.HasSuffix(bot.PromptTemplateID, "_uib_v1") != detected
+		row.VersionDrift = bot.PromptTemplateVersion != svc.PromptTemplateVersion
+	}
+	return row
+}
+
+func summarize(rows []ReplayRow) []DriftSummary {
+	groups := map[string][]ReplayRow{}
+	for _, r := range rows {
+		tpl := r.PromptTemplateID
+		if tpl == "" {
+			tpl = "unknown"
+		}
+		mode := r.Mode
+		if mode == "" {
+			mode = "default"
+		}
+		groups["all"] = append(groups["all"], r)
+		groups[tpl+"/"+mode] = append(groups[tpl+"/"+mode], r)
+	}
+	out := make([]DriftSummary, 0, len(groups))
+	for key, rs := range groups {
+		s := DriftSummary{Group: key, N: len(rs)}
+		var ctx, det, ver, unk, fab int
+		for _, r := range rs {
+			s.AvgPrecision += r.Precision
+			s.AvgRecall += r.Recall
+			s.AvgF1 += r.F1
+			if r.ContextDrift {
+				ctx++
+			}
+			if r.DetectionDrift {
+				det++
+			}
+			if r.VersionDrift {
+				ver++
+			}
+			if len(r.UnknownEventIDs) > 0 {
+				unk++
+			}
+			if r.FabContact || r.FabLink {
+				fab++
+			}
+			if r.Feedback < 0 {
+				s.ThumbsDown++
+			}
+		}
+		n := float64(len(rs))
+		s.AvgPrecision /= n
+		s.AvgRecall /= n
+		s.AvgF1 /= n
+		s.ContextDriftRate = float64(ctx) / n
+		s.DetectionDriftRate = float64(det) / n
+		s.VersionDriftRate = float64(ver) / n
+		s.UnknownEventRate = float64(unk) / n
+		s.FabricatedRate = float64(fab) / n
+		out = append(out, s)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if (out[i].Group == "all") != (out[j].Group == "all") {
+			return out[i].Group == "all"
+		}
+		return out[i].Group < out[j].Group
+	})
+	return out
+}
+
+func main() {
+	days := envInt("ABREPLAY_DAYS", 7)
+	limit := envInt("ABREPLAY_LIMIT", 500)
+	template := strings.TrimSpace(os.Getenv("ABREPLAY_TEMPLATE"))
+	mode := strings.TrimSpace(os.Getenv("ABREPLAY_MODE"))
+	since := time.Now().AddDate(0, 0, -days)
+
+	db, err := database.Open(&gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		fmt.Println("error:", err)
+		os.Exit(1)
+	}
+
+	campuses, err := svc.NewCampusDataService()
+	if err != nil {
+		fmt.Println("error:", err)
+		os.Exit(1)
+	}
+
+	q := db.Where("sender = ? AND created_at >= ?", "bot", since)
+	if template != "" {
+		q = q.Where("prompt_template_id = ?", template)
+	}
+	if mode != "" {
+		q = q.Where("prompt_mode = ?", mode)
+	}
+	if limit > 0 {
+		q = q.Limit(limit)
+	}
+	var bots []models.Message
+	if err := q.Order("id DESC").Find(&bots).Error; err != nil {
+		fmt.Println("error:", err)
+		os.Exit(1)
+	}
+	fmt.Printf("[replay] %d bot replies since %s\n", len(bots), since.Format("2006-01-02"))
+
+	rows := make([]ReplayRow, 0, len(bots))
+	for _, bot := range bots {
+		question, err := previousQuestion(db, bot)
+		if err != nil || strings.TrimSpace(question) == "" {
+			continue
+		}
+		rows = append(rows, replay(campuses, bot, question))
+	}
+
+	report := ReplayReport{
+		GeneratedAt:     time.Now().Format(time.RFC3339),
+		Since:           since.Format(time.RFC3339),
+		TemplateVersion: svc.PromptTemplateVersion,
+		Campuses:        campuses.Campuses(),
+		Summary:         summarize(rows),
+		Rows:            rows,
+	}
+	for _, s := range report.Summary {
+		fmt.Printf("%s -> n=%d, avg_precision=%.2f, avg_recall=%.2f, avg_f1=%.2f, context_drift=%.2f, detection_drift=%.2f, version_drift=%.2f, unknown_event=%.2f, fabricated=%.2f, thumbs_down=%d\n",
+			s.Group, s.N, s.AvgPrecision, s.AvgRecall, s.AvgF1, s.ContextDriftRate, s.DetectionDriftRate, s.VersionDriftRate, s.UnknownEventRate, s.FabricatedRate, s.ThumbsDown)
+	}
+
+	outDir := "cmd/abtest/results"
+	_ = os.MkdirAll(outDir, 0o755)
+	stamp := time.Now().Format("20060102-150405")
+	jsonPath := filepath.Join(outDir, fmt.Sprintf("abreplay-%s.json", stamp))
+	b, _ := json.MarshalIndent(report, "", "  ")
+	if err := os.WriteFile(jsonPath, b, 0o644); err != nil {
+		fmt.Println("error:", err)
+		os.Exit(1)
+	}
+	fmt.Println("[replay] saved:", jsonPath)
+
+	csvPath := filepath.Join(outDir, fmt.Sprintf("abreplay-%s.csv", stamp))
+	f, err := os.Create(csvPath)
+	if err != nil {
+		fmt.Println("error:", err)
+		os.Exit(1)
+	}
+	w := csv.NewWriter(f)
+	_ = w.Write([]string{"message_id", "created_at", "query", "mode", "prompt_template_id", "model", "campus", "precision", "recall", "f1", "context_drift", "detection_drift", "version_drift", "unknown_event_ids", "fabricated_contact", "fabricated_link", "feedback"})
+	for _, r := range rows {
+		_ = w.Write([]string{strconv.FormatUint(uint64(r.MessageID), 10), r.CreatedAt, r.Query, r.Mode, r.PromptTemplateID, r.Model, r.Campus,
+			fmt.Sprintf("%.2f", r.Precision), fmt.Sprintf("%.2f", r.Recall), fmt.Sprintf("%.2f", r.F1),
+			fmt.Sprintf("%t", r.ContextDrift), fmt.Sprintf("%t", r.DetectionDrift), fmt.Sprintf("%t", r.VersionDrift),
+			strings.Join(r.UnknownEventIDs, ";"), fmt.Sprintf("%t", r.FabContact), fmt.Sprintf("%t", r.FabLink), strconv.Itoa(int(r.Feedback))})
+	}
+	w.Flush()
+	_ = f.Close()
+	fmt.Println("[replay] saved:", csvPath)
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"AkuAI/pkg/stats"
+)
+
+// Usage is the token count reported by Gemini (usageMetadata) for one result.
+type Usage struct {
+	PromptTokens int `json:"prompt_tokens"`
+	OutputTokens int `json:"output_tokens"`
+}
+
+// costConfig prices responses. Without usage metadata tokens are estimated
+// from characters; the prompt estimate only covers the query and the context
+// snapshot (when recorded), so estimated input cost is a lower bound.
+type costConfig struct {
+	CharsPerToken float64
+	InputPer1M    float64 // USD per 1M prompt tokens
+	OutputPer1M   float64 // USD per 1M output tokens
+}
+
+func costConfigFromEnv() costConfig {
+	c := costConfig{CharsPerToken: 4, InputPer1M: 0.10, OutputPer1M: 0.40} // gemini-2.0-flash list price
+	read := func(key string, dst *float64) {
+		if v, err := strconv.ParseFloat(strings.TrimSpace(os.Getenv(key)), 64); err == nil && v >= 0 {
+			*dst = v
+		}
+	}
+	read("ABSCORE_CHARS_PER_TOKEN", &c.CharsPerToken)
+	read("ABSCORE_PRICE_INPUT_PER_1M", &c.InputPer1M)
+	read("ABSCORE_PRICE_OUTPUT_PER_1M", &c.OutputPer1M)
+	if c.CharsPerToken <= 0 {
+		c.CharsPerToken = 4
+	}
+	return c
+}
+
+// tokens returns the prompt and output tokens of r and whether they come from
+// usage metadata (true) or the chars/token heuristic (false).
+func (c costConfig) tokens(r ResultItem) (in, out int, measured bool) {
+	if r.Usage != nil && (r.Usage.PromptTokens > 0 || r.Usage.OutputTokens > 0) {
+		return r.Usage.PromptTokens, r.Usage.OutputTokens, true
+	}
+	est := func(s string) int {
+		n := utf8.RuneCountInString(s)
+		if n == 0 {
+			return 0
+		}
+		return int(float64(n)/c.CharsPerToken + 0.5)
+	}
+	return est(r.Query) + est(r.ContextSnapshot), est(r.Response), false
+}
+
+func (c costConfig) cost(in, out int) float64 {
+	return float64(in)/1e6*c.InputPer1M + float64(out)/1e6*c.OutputPer1M
+}
+
+type latencyCost struct {
+	Mode                      string
+	N, Measured               int // results, and how many had usage metadata
+	MeanMs, P95Ms             float64
+	InputTokens, OutputTokens int
+	CostUSD, AvgF1            float64
+	CostPerF1                 float64 // USD per F1 point per response; 0 when avg F1 is 0
+}
+
+// summarizeLatencyCost aggregates latency (errored results excluded) and
+// estimated cost per mode.
+func summarizeLatencyCost(rows []ScoreRow) []latencyCost {
+	byMode := map[string]*latencyCost{}
+	durs := map[string][]float64{}
+	for _, r := range rows {
+		m, ok := byMode[r.Mode]
+		if !ok {
+			m = &latencyCost{Mode: r.Mode}
+			byMode[r.Mode] = m
+		}
+		m.N++
+		if r.TokensMeasured {
+			m.Measured++
+		}
+		m.InputTokens += r.InputTokens
+		m.OutputTokens += r.OutputTokens
+		m.CostUSD += r.CostUSD
+		m.AvgF1 += r.F1
+		if !r.Errored {
+			durs[r.Mode] = append(durs[r.Mode], float64(r.DurationMs))
+		}
+	}
+	out := make([]latencyCost, 0, len(byMode))
+	for mode, m := range byMode {
+		m.AvgF1 /= float64(m.N)
+		m.MeanMs = stats.Mean(durs[mode])
+		m.P95Ms = stats.Percentile(durs[mode], 95)
+		if m.AvgF1 > 0 {
+			m.CostPerF1 = m.CostUSD / float64(m.N) / m.AvgF1
+		}
+		out = append(out, *m)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Mode < out[j].Mode })
+	return out
+}
+
+func (l latencyCost) tokenSource() string {
+	switch l.Measured {
+	case 0:
+		return "estimate"
+	case l.N:
+		return "usage"
+	}
+	return "mixed"
+}
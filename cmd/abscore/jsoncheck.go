@@ -0,0 +1,153 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// jsonSchema is the expected_json of a query: the top-level type and the keys
+// every object (or every array item) must have.
+type jsonSchema struct {
+	Type     string   `json:"type"` // array | object | "" (any)
+	Required []string `json:"required"`
+	MinItems int      `json:"min_items"`
+}
+
+var (
+	codeBlockRe  = regexp.MustCompile("(?s)```[a-zA-Z]*[ \t]*\n?(.*?)```")
+	jsonAskedRe  = regexp.MustCompile(`(?i)\bjson\b`)
+	anyJSONShape = &jsonSchema{}
+)
+
+// schemaFor returns the schema a response to r must satisfy, or nil when the
+// query does not ask for JSON.
+func schemaFor(r ResultItem) *jsonSchema {
+	if r.ExpectedJSON != nil {
+		return r.ExpectedJSON
+	}
+	if jsonAskedRe.MatchString(r.Query) {
+		return anyJSONShape
+	}
+	return nil
+}
+
+// jsonCandidates returns the fenced code blocks of resp, or else the span
+// from the first '{' or '[' to the last '}' or ']'.
+func jsonCandidates(resp string) []string {
+	var out []string
+	for _, m := range codeBlockRe.FindAllStringSubmatch(resp, -1) {
+		if s := strings.TrimSpace(m[1]); s != "" {
+			out = append(out, s)
+		}
+	}
+	if len(out) > 0 {
+		return out
+	}
+	start := strings.IndexAny(resp, "{[")
+	end := strings.LastIndexAny(resp, "}]")
+	if start >= 0 && end > start {
+		out = append(out, resp[start:end+1])
+	}
+	return out
+}
+
+// validateJSONResponse parses the JSON in resp and checks it against schema.
+// The first candidate that parses decides; reason explains a failure.
+func validateJSONResponse(resp string, schema *jsonSchema) (bool, string) {
+	cands := jsonCandidates(resp)
+	if len(cands) == 0 {
+		return false, "json: no JSON found"
+	}
+	var parseErr error
+	for _, c := range cands {
+		var v any
+		if err := json.Unmarshal([]byte(c), &v); err != nil {
+			parseErr = err
+			continue
+		}
+		if err := schema.check(v); err != nil {
+			return false, "json: " + err.Error()
+		}
+		return true, ""
+	}
+	return false, fmt.Sprintf("json: parse error: %v", parseErr)
+}
+
+func (s *jsonSchema) check(v any) error {
+	switch s.Type {
+	case "array":
+		arr, ok := v.([]any)
+		if !ok {
+			// accept {"webinars": [...]}: an object wrapping a single array
+			if obj, isObj := v.(map[string]any); isObj && len(obj) == 1 {
+				for _, inner := range obj {
+					arr, ok = inner.([]any)
+				}
+			}
+		}
+		if !ok {
+			return fmt.Errorf("expected an array, got %s", jsonKind(v))
+		}
+		if len(arr) < s.MinItems {
+			return fmt.Errorf("expected at least %d items, got %d", s.MinItems, len(arr))
+		}
+		for i, it := range arr {
+			if err := s.requireKeys(it); err != nil {
+				return fmt.Errorf("item %d: %w", i+1, err)
+			}
+		}
+	case "object":
+		return s.requireKeys(v)
+	}
+	return nil
+}
+
+func (s *jsonSchema) requireKeys(v any) error {
+	if len(s.Required) == 0 {
+		return nil
+	}
+	obj, ok := v.(map[string]any)
+	if !ok {
+		return fmt.Errorf("expected an object, got %s", jsonKind(v))
+	}
+	var missing []string
+	for _, k := range s.Required {
+		if _, ok := obj[k]; !ok {
+			missing = append(missing, k)
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("missing %s", strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+func jsonKind(v any) string {
+	switch v.(type) {
+	case []any:
+		return "array"
+	case map[string]any:
+		return "object"
+	case string:
+		return "string"
+	case float64:
+		return "number"
+	case bool:
+		return "boolean"
+	}
+	return "null"
+}
+
+// jsonCell is "1"/"0" for queries that ask for JSON and empty otherwise, the
+// same encoding as abjudge's json_valid.
+func jsonCell(r ScoreRow) string {
+	switch {
+	case !r.JSONChecked:
+		return ""
+	case r.JSONValid:
+		return "1"
+	}
+	return "0"
+}
@@ -2,19 +2,23 @@ package main
 
 import (
 	"encoding/csv"
-	"encoding/json"
 	"fmt"
-	"math"
 	"os"
 	"path/filepath"
+	"slices"
 	"sort"
+	"strconv"
 	"strings"
 	"time"
+	"unicode"
 
+	"AkuAI/pkg/abio"
 	svc "AkuAI/pkg/services"
+	"AkuAI/pkg/stats"
 )
 
 type ResultItem struct {
+	QueryID    string `json:"query_id,omitempty"`
 	Query      string `json:"query"`
 	Mode       string `json:"mode"`
 	Response   string `json:"response"`
@@ -26,16 +30,40 @@ type ResultItem struct {
 	PromptTemplateID      string   `json:"prompt_template_id,omitempty"`
 	PromptTemplateVersion string   `json:"prompt_template_version,omitempty"`
 	ContextHash           string   `json:"context_hash,omitempty"`
+	ContextSnapshot       string   `json:"context_snapshot,omitempty"`
+	DatasetHash           string   `json:"dataset_hash,omitempty"`
 	RelevantEventIDs      []string `json:"relevant_event_ids,omitempty"`
+	Usage                 *Usage   `json:"usage,omitempty"`
+	// Parameter sweep (ABTEST_SWEEP); older results have neither
+	Temperature float64 `json:"temperature"`
+	Combination string  `json:"combination,omitempty"`
+	// Golden expectations from queries.json
+	ExpectedEventIDs []string    `json:"expected_event_ids,omitempty"`
+	GoldenAnswer     string      `json:"golden_answer,omitempty"`
+	ExpectedJSON     *jsonSchema `json:"expected_json,omitempty"`
+}
+
+// combination identifies the grid cell of a result; results from before
+// sweeps were recorded are grouped by mode.
+func (r ResultItem) combination() string {
+	if r.Combination != "" {
+		return r.Combination
+	}
+	return r.Mode
 }
 
 type RunSummary struct {
-	Results []ResultItem `json:"results"`
+	DatasetHash string       `json:"dataset_hash,omitempty"`
+	Results     []ResultItem `json:"results"`
 }
 
 type ScoreRow struct {
 	Query           string
 	Mode            string
+	Combination     string
+	Pair            string // model and temperature; baseline and engineered are paired within it
+	DurationMs      int64
+	Errored         bool
 	Coverage        float64
 	FormatOK        bool
 	Notes           string
@@ -44,6 +72,16 @@ type ScoreRow struct {
 	FabContact      bool
 	FabLink         bool
 	UsedPlaceholder bool
+	Golden          bool    // scored against expected_event_ids
+	GoldenOverlap   float64 // token F1 with golden_answer, -1 when there is none
+	RecallK         float64 // recall@k of the mention order, -1 without relevant events
+	NDCG            float64 // nDCG@k of the mention order, -1 without relevant events
+	JSONChecked     bool    // the query asks for JSON output
+	JSONValid       bool    // the response parses and matches expected_json
+	InputTokens     int
+	OutputTokens    int
+	TokensMeasured  bool // from usage metadata rather than the chars/token estimate
+	CostUSD         float64
 }
 
 // Extract predicted event titles present in a response by matching known titles
@@ -122,45 +160,6 @@ func relevantTitles(s *svc.UIBEventService, q string) map[string]bool {
 	return relSet
 }
 
-func latestResultsJSON() (string, error) {
-	dir := filepath.Clean("cmd/abtest/results")
-	entries, err := os.ReadDir(dir)
-	if err != nil {
-		return "", err
-	}
-	type f struct {
-		name string
-		t    time.Time
-	}
-	list := []f{}
-	for _, e := range entries {
-		if e.IsDir() {
-			continue
-		}
-		if strings.HasSuffix(strings.ToLower(e.Name()), ".json") && strings.HasPrefix(e.Name(), "abtest-") {
-			info, _ := e.Info()
-			list = append(list, f{name: filepath.Join(dir, e.Name()), t: info.ModTime()})
-		}
-	}
-	if len(list) == 0 {
-		return "", fmt.Errorf("no results json found in %s", dir)
-	}
-	sort.Slice(list, func(i, j int) bool { return list[i].t.After(list[j].t) })
-	return list[0].name, nil
-}
-
-func readSummary(path string) (RunSummary, error) {
-	b, err := os.ReadFile(path)
-	if err != nil {
-		return RunSummary{}, err
-	}
-	var s RunSummary
-	if err := json.Unmarshal(b, &s); err != nil {
-		return RunSummary{}, err
-	}
-	return s, nil
-}
-
 func containsAllTitles(resp string, titles []string) (int, []string) {
 	hit := 0
 	missing := make([]string, 0)
@@ -409,86 +408,130 @@ func precisionAndFabrication(s *svc.UIBEventService, q, resp string) (precision
 	return
 }
 
+// goldenOverlap is the token-level F1 between a response and the golden
+// answer, ignoring case and punctuation.
+func goldenOverlap(resp, golden string) float64 {
+	tokens := func(s string) map[string]int {
+		m := map[string]int{}
+		for _, t := range strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+			return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+		}) {
+			m[t]++
+		}
+		return m
+	}
+	got, want := tokens(resp), tokens(golden)
+	common, nGot, nWant := 0, 0, 0
+	for t, c := range want {
+		common += min(c, got[t])
+		nWant += c
+	}
+	for _, c := range got {
+		nGot += c
+	}
+	if common == 0 {
+		return 0
+	}
+	return f1Score(float64(common)/float64(nGot), float64(common)/float64(nWant))
+}
+
 func groupByQuery(rows []ScoreRow) map[string]map[string]ScoreRow {
 	m := map[string]map[string]ScoreRow{}
 	for _, r := range rows {
-		if _, ok := m[r.Query]; !ok {
-			m[r.Query] = map[string]ScoreRow{}
+		k := r.Query + "\x00" + r.Pair
+		if _, ok := m[k]; !ok {
+			m[k] = map[string]ScoreRow{}
 		}
-		m[r.Query][r.Mode] = r
+		m[k][r.Mode] = r
 	}
 	return m
 }
 
-func wilcoxonSignedRank(baseline, engineered []float64) (Wplus float64, z float64, p float64, n int) {
-	type pair struct {
-		d float64
-		a float64
-	}
-	arr := []pair{}
-	for i := range baseline {
-		d := engineered[i] - baseline[i]
-		if d == 0 {
-			continue
+type comboScore struct {
+	Combination                     string
+	N, FormatOK, Fabricated, Errors int
+	Precision, Coverage, F1         float64
+	RecallK, NDCG                   float64 // averaged over results with relevant events, -1 if none
+	rankN                           int
+	AvgDurationMs                   float64
+}
+
+// scoreCombinations averages the metrics per grid cell, best F1 first (ties:
+// fewer fabrications, then lower latency).
+func scoreCombinations(rows []ScoreRow) []comboScore {
+	byCombo := map[string]*comboScore{}
+	order := []string{}
+	for _, r := range rows {
+		c, ok := byCombo[r.Combination]
+		if !ok {
+			c = &comboScore{Combination: r.Combination}
+			byCombo[r.Combination] = c
+			order = append(order, r.Combination)
+		}
+		c.N++
+		c.Precision += r.Precision
+		c.Coverage += r.Coverage
+		c.F1 += r.F1
+		if r.RecallK >= 0 {
+			c.RecallK += r.RecallK
+			c.NDCG += r.NDCG
+			c.rankN++
+		}
+		c.AvgDurationMs += float64(r.DurationMs)
+		if r.FormatOK {
+			c.FormatOK++
+		}
+		if r.FabContact || r.FabLink {
+			c.Fabricated++
+		}
+		if r.Errored {
+			c.Errors++
+		}
+	}
+	out := make([]comboScore, 0, len(order))
+	for _, k := range order {
+		c := *byCombo[k]
+		n := float64(c.N)
+		c.Precision, c.Coverage, c.F1, c.AvgDurationMs = c.Precision/n, c.Coverage/n, c.F1/n, c.AvgDurationMs/n
+		if c.rankN > 0 {
+			c.RecallK, c.NDCG = c.RecallK/float64(c.rankN), c.NDCG/float64(c.rankN)
+		} else {
+			c.RecallK, c.NDCG = -1, -1
 		}
-		arr = append(arr, pair{d: d, a: math.Abs(d)})
+		out = append(out, c)
 	}
-	sort.Slice(arr, func(i, j int) bool { return arr[i].a < arr[j].a })
-	// rank, average ties simplification
-	ranks := make([]float64, len(arr))
-	i := 0
-	for i < len(arr) {
-		j := i + 1
-		for j < len(arr) && arr[j].a == arr[i].a {
-			j++
+	sort.SliceStable(out, func(i, j int) bool {
+		a, b := out[i], out[j]
+		if a.F1 != b.F1 {
+			return a.F1 > b.F1
 		}
-		rank := (float64(i+1) + float64(j)) / 2.0
-		for k := i; k < j; k++ {
-			ranks[k] = rank
+		if a.Fabricated != b.Fabricated {
+			return a.Fabricated < b.Fabricated
 		}
-		i = j
-	}
-	Wplus = 0
-	for idx, pr := range arr {
-		if pr.d > 0 {
-			Wplus += ranks[idx]
-		}
-	}
-	n = len(arr)
-	if n == 0 {
-		return 0, 0, 1, 0
-	}
-	mu := float64(n*(n+1)) / 4.0
-	sigma := math.Sqrt(float64(n*(n+1)*(2*n+1)) / 24.0)
-	z = (Wplus - mu) / sigma
-	// two-sided p using normal CDF
-	cdf := 0.5 * (1 + math.Erf(z/math.Sqrt2))
-	p = 2 * (1 - math.Abs(cdf-0.5)*2)
-	return
+		return a.AvgDurationMs < b.AvgDurationMs
+	})
+	return out
 }
 
-func mcnemarTest(baselineFail, engineeredFail []bool) (b, c int, chi2, p float64) {
-	// b: baseline fail and engineered pass; c: baseline pass and engineered fail
-	for i := range baselineFail {
-		bf := baselineFail[i]
-		ef := engineeredFail[i]
-		if bf && !ef {
-			b++
-		}
-		if !bf && ef {
-			c++
+// warnDatasetDrift reports results that were produced against a different
+// revision of the event dataset than the one scoring uses. Results from
+// before dataset hashes were recorded are not checked.
+func warnDatasetDrift(summary RunSummary, uib *svc.UIBEventService) {
+	current := uib.Hash()
+	if current == "" {
+		return
+	}
+	stale := 0
+	for _, r := range summary.Results {
+		if r.DatasetHash != "" && r.DatasetHash != current {
+			stale++
 		}
 	}
-	denom := float64(b + c)
-	if denom == 0 {
-		return b, c, 0, 1
+	if summary.DatasetHash != "" && summary.DatasetHash != current {
+		fmt.Printf("[warn] results were produced against dataset %.12s, scoring against %.12s; coverage and precision may be off\n", summary.DatasetHash, current)
+	} else if stale > 0 {
+		fmt.Printf("[warn] %d results were produced against a different dataset revision than %.12s\n", stale, current)
 	}
-	chi2 = (math.Pow(math.Abs(float64(b-c))-1, 2)) / denom
-	// df=1, p = 2*(1-Phi(sqrt(chi2)))
-	z := math.Sqrt(chi2)
-	cdf := 0.5 * (1 + math.Erf(z/math.Sqrt2))
-	p = 2 * (1 - cdf)
-	return
 }
 
 func main() {
@@ -496,7 +539,7 @@ func main() {
 	path := strings.TrimSpace(os.Getenv("ABTEST_RESULTS"))
 	var err error
 	if path == "" {
-		path, err = latestResultsJSON()
+		path, err = abio.LatestResultsJSON(abio.ResultsDir)
 		if err != nil {
 			fmt.Println("error:", err)
 			os.Exit(1)
@@ -504,24 +547,33 @@ func main() {
 	}
 	fmt.Println("[score] using results:", path)
 
-	summary, err := readSummary(path)
-	if err != nil {
+	var summary RunSummary
+	if err := abio.ReadJSON(path, &summary); err != nil {
 		fmt.Println("error:", err)
 		os.Exit(1)
 	}
 
 	uib, _ := svc.NewUIBEventService()
+	warnDatasetDrift(summary, uib)
 	title2id := buildTitleToIDMap(uib)
+	pricing := costConfigFromEnv()
+	k := rankingK()
 	rows := make([]ScoreRow, 0, len(summary.Results))
 	for _, r := range summary.Results {
 		cov, _, notes := evalWithUIBService(uib, r.Query, r.Response)
 		prec, usedPH, fabC, fabL := precisionAndFabrication(uib, r.Query, r.Response)
 		f1 := f1Score(prec, cov)
-		// Prefer ID-based recall if relevant_event_ids are present
-		if len(r.RelevantEventIDs) > 0 {
+		// Prefer ID-based recall if relevant_event_ids are present; golden
+		// expected_event_ids from queries.json win over both and also decide precision
+		relIDs := r.RelevantEventIDs
+		golden := len(r.ExpectedEventIDs) > 0
+		if golden {
+			relIDs = r.ExpectedEventIDs
+		}
+		if len(relIDs) > 0 {
 			predIDs := predictedIDsFromResponse(uib, r.Response, title2id)
 			relSet := map[string]bool{}
-			for _, id := range r.RelevantEventIDs {
+			for _, id := range relIDs {
 				id = strings.TrimSpace(id)
 				if id != "" {
 					relSet[id] = true
@@ -535,17 +587,48 @@ func main() {
 			}
 			if len(relSet) > 0 {
 				cov = float64(tp) / float64(len(relSet))
+				if golden {
+					prec = 1.0
+					if len(predIDs) > 0 {
+						prec = float64(tp) / float64(len(predIDs))
+					}
+				}
 				f1 = f1Score(prec, cov)
 			}
 		}
+		// Order-aware metrics over the same relevant set, as event IDs
+		rankRel := relIDs
+		if len(rankRel) == 0 {
+			for t := range relevantTitles(uib, r.Query) {
+				if id, ok := title2id[strings.ToLower(t)]; ok {
+					rankRel = append(rankRel, id)
+				}
+			}
+		}
+		recallK, ndcg := rankingMetrics(rankedIDsFromResponse(r.Response, title2id), rankRel, k)
+		overlap := -1.0
+		if strings.TrimSpace(r.GoldenAnswer) != "" {
+			overlap = goldenOverlap(r.Response, r.GoldenAnswer)
+		}
 		fmtOK, fmtReasons := checkFormatComplianceWithService(uib, r.Query, r.Response)
+		var jsonChecked, jsonValid bool
+		if schema := schemaFor(r); schema != nil {
+			var reason string
+			jsonChecked = true
+			if jsonValid, reason = validateJSONResponse(r.Response, schema); !jsonValid {
+				fmtReasons = append(fmtReasons, reason)
+			}
+		}
 		if len(fmtReasons) > 0 {
 			if notes != "" {
 				notes += " | "
 			}
 			notes += strings.Join(fmtReasons, "; ")
 		}
-		rows = append(rows, ScoreRow{Query: r.Query, Mode: r.Mode, Coverage: cov, Precision: prec, F1: f1, FormatOK: fmtOK, Notes: notes, FabContact: fabC, FabLink: fabL, UsedPlaceholder: usedPH})
+		inTok, outTok, measured := pricing.tokens(r)
+		rows = append(rows, ScoreRow{Query: r.Query, Mode: r.Mode, Combination: r.combination(), Pair: fmt.Sprintf("%s@%g", r.Model, r.Temperature),
+			DurationMs: r.DurationMs, Errored: r.Error != "", Coverage: cov, Precision: prec, F1: f1, FormatOK: fmtOK, Notes: notes, FabContact: fabC, FabLink: fabL, UsedPlaceholder: usedPH,
+			Golden: golden, GoldenOverlap: overlap, RecallK: recallK, NDCG: ndcg, JSONChecked: jsonChecked, JSONValid: jsonValid, InputTokens: inTok, OutputTokens: outTok, TokensMeasured: measured, CostUSD: pricing.cost(inTok, outTok)})
 	}
 
 	// Aggregate
@@ -557,6 +640,14 @@ func main() {
 		fmtOK   int
 		fabC    int
 		fabL    int
+		golden  int
+		ovSum   float64
+		ovCnt   int
+		recSum  float64
+		ndcgSum float64
+		rankCnt int
+		jsonChk int
+		jsonOK  int
 	}{}
 	for _, rw := range rows {
 		k := rw.Mode
@@ -574,6 +665,24 @@ func main() {
 		if rw.FabLink {
 			v.fabL++
 		}
+		if rw.Golden {
+			v.golden++
+		}
+		if rw.GoldenOverlap >= 0 {
+			v.ovSum += rw.GoldenOverlap
+			v.ovCnt++
+		}
+		if rw.JSONChecked {
+			v.jsonChk++
+			if rw.JSONValid {
+				v.jsonOK++
+			}
+		}
+		if rw.RecallK >= 0 {
+			v.recSum += rw.RecallK
+			v.ndcgSum += rw.NDCG
+			v.rankCnt++
+		}
 		agg[k] = v
 	}
 
@@ -587,35 +696,121 @@ func main() {
 		}
 		fmt.Printf("%s -> avg_precision=%.2f, avg_coverage=%.2f, avg_f1=%.2f, format_pass=%d/%d, fabricated_contact=%d/%d, fabricated_link=%d/%d\n",
 			mode, avgPrec, avgCov, avgF1, v.fmtOK, v.cnt, v.fabC, v.cnt, v.fabL, v.cnt)
+		if v.jsonChk > 0 {
+			fmt.Printf("%s -> json_valid=%d/%d\n", mode, v.jsonOK, v.jsonChk)
+		}
+		if v.rankCnt > 0 {
+			fmt.Printf("%s -> recall@%d=%.2f, ndcg@%d=%.2f (n=%d)\n", mode, k, v.recSum/float64(v.rankCnt), k, v.ndcgSum/float64(v.rankCnt), v.rankCnt)
+		}
+		if v.golden > 0 || v.ovCnt > 0 {
+			avgOv := 0.0
+			if v.ovCnt > 0 {
+				avgOv = v.ovSum / float64(v.ovCnt)
+			}
+			fmt.Printf("%s -> golden_event_ids=%d/%d, golden_answer_overlap=%.2f (n=%d)\n", mode, v.golden, v.cnt, avgOv, v.ovCnt)
+		}
 	}
 
-	// Paired tests (use F1 as numeric, fabricated_any as binary)
+	// Latency and estimated cost per mode
+	latency := summarizeLatencyCost(rows)
+	for _, l := range latency {
+		fmt.Printf("%s -> latency mean=%.0fms p95=%.0fms, tokens in=%d out=%d (%s), est_cost=$%.6f, cost_per_f1_point=$%.6f\n",
+			l.Mode, l.MeanMs, l.P95Ms, l.InputTokens, l.OutputTokens, l.tokenSource(), l.CostUSD, l.CostPerF1)
+	}
+
+	// Paired tests (F1 and latency as numeric, fabricated_any and json_invalid as binary)
 	byQ := groupByQuery(rows)
 	f1Base := []float64{}
 	f1Eng := []float64{}
 	fabBase := []bool{}
 	fabEng := []bool{}
-	for q, m := range byQ {
+	durBase := []float64{}
+	durEng := []float64{}
+	jsonBadBase := []bool{}
+	jsonBadEng := []bool{}
+	for _, m := range byQ {
 		rb, okb := m["baseline"]
 		re, oke := m["engineered"]
 		if !okb || !oke {
-			_ = q
 			continue
 		}
 		f1Base = append(f1Base, rb.F1)
 		f1Eng = append(f1Eng, re.F1)
 		fabBase = append(fabBase, rb.FabContact || rb.FabLink)
 		fabEng = append(fabEng, re.FabContact || re.FabLink)
+		if rb.JSONChecked && re.JSONChecked {
+			jsonBadBase = append(jsonBadBase, !rb.JSONValid)
+			jsonBadEng = append(jsonBadEng, !re.JSONValid)
+		}
+		if !rb.Errored && !re.Errored {
+			durBase = append(durBase, float64(rb.DurationMs))
+			durEng = append(durEng, float64(re.DurationMs))
+		}
+	}
+	Wplus, z, pz, n := stats.WilcoxonSignedRank(f1Base, f1Eng)
+	b, c, chi2, pm := stats.McNemarTest(fabBase, fabEng)
+	Wlat, zlat, plat, nlat := stats.WilcoxonSignedRank(durBase, durEng)
+	bJ, cJ, chiJ, pJ := stats.McNemarTest(jsonBadBase, jsonBadEng)
+	method, err := stats.ParseCorrection(os.Getenv("ABSCORE_CORRECTION"))
+	if err != nil {
+		fmt.Println("error: ABSCORE_CORRECTION:", err)
+		os.Exit(1)
 	}
-	Wplus, z, pz, n := wilcoxonSignedRank(f1Base, f1Eng)
-	b, c, chi2, pm := mcnemarTest(fabBase, fabEng)
-	fmt.Printf("Wilcoxon on F1: W+=%.2f, n=%d, z=%.3f, p≈%.4f\n", Wplus, n, z, pz)
-	fmt.Printf("McNemar on fabricated_any: b=%d, c=%d, chi2=%.3f, p≈%.4f\n", b, c, chi2, pm)
+	adj := stats.AdjustPValues([]float64{pz, pm, plat, pJ}, method)
+	fmt.Printf("Wilcoxon on F1: W+=%.2f, n=%d, z=%.3f, p≈%.4f, p_adj≈%.4f\n", Wplus, n, z, pz, adj[0])
+	fmt.Printf("McNemar on fabricated_any: b=%d, c=%d, chi2=%.3f, p≈%.4f, p_adj≈%.4f\n", b, c, chi2, pm, adj[1])
+	fmt.Printf("Wilcoxon on latency: W+=%.2f, n=%d, z=%.3f, p≈%.4f, p_adj≈%.4f\n", Wlat, nlat, zlat, plat, adj[2])
+	fmt.Printf("McNemar on json_invalid: b=%d, c=%d, chi2=%.3f, p≈%.4f, p_adj≈%.4f (n=%d pairs)\n", bJ, cJ, chiJ, pJ, adj[3], len(jsonBadBase))
+	fmt.Printf("(p_adj: %s across %d tests)\n", stats.CorrectionLabel(method), len(adj))
 
-	// Write CSV (summary rows)
-	outDir := "cmd/abtest/results"
+	outDir := abio.ResultsDir
 	_ = os.MkdirAll(outDir, 0o755)
 	stamp := time.Now().Format("20060102-150405")
+
+	// Significance tests with raw and adjusted p-values
+	latencyOut := map[string]any{}
+	for _, l := range latency {
+		latencyOut[l.Mode] = map[string]any{"n": l.N, "mean_ms": l.MeanMs, "p95_ms": l.P95Ms, "input_tokens": l.InputTokens, "output_tokens": l.OutputTokens,
+			"token_source": l.tokenSource(), "est_cost_usd": l.CostUSD, "avg_f1": l.AvgF1, "cost_per_f1_point_usd": l.CostPerF1}
+	}
+	tests := map[string]any{
+		"wilcoxon_f1":            map[string]any{"W+": Wplus, "n": n, "z": z, "p": pz, "p_adj": adj[0]},
+		"mcnemar_fabricated_any": map[string]any{"b": b, "c": c, "chi2": chi2, "p": pm, "p_adj": adj[1]},
+		"wilcoxon_latency":       map[string]any{"W+": Wlat, "n": nlat, "z": zlat, "p": plat, "p_adj": adj[2]},
+		"mcnemar_json_invalid":   map[string]any{"b": bJ, "c": cJ, "chi2": chiJ, "p": pJ, "p_adj": adj[3], "pairs": len(jsonBadBase)},
+		"correction":             map[string]any{"method": method, "family_size": len(adj)},
+		"latency_cost":           latencyOut,
+	}
+	testsPath := filepath.Join(outDir, fmt.Sprintf("score-tests-%s.json", stamp))
+	_ = abio.WriteJSON(testsPath, tests)
+	var mdCost strings.Builder
+	for _, l := range latency {
+		fmt.Fprintf(&mdCost, "| %s | %d | %.0f | %.0f | %d | %d | %s | %.6f | %.2f | %.6f |\n",
+			l.Mode, l.N, l.MeanMs, l.P95Ms, l.InputTokens, l.OutputTokens, l.tokenSource(), l.CostUSD, l.AvgF1, l.CostPerF1)
+	}
+	md := fmt.Sprintf(`# Paired Tests (Baseline vs Engineered)
+
+Koreksi perbandingan berganda: %s (%d uji); p_adj = p terkoreksi.
+
+- Wilcoxon F1: W+=%.2f, n=%d, z=%.3f, p=%.4f, p_adj=%.4f
+- McNemar fabricated_any: b=%d, c=%d, chi2=%.3f, p=%.4f, p_adj=%.4f
+- Wilcoxon latensi: W+=%.2f, n=%d, z=%.3f, p=%.4f, p_adj=%.4f
+- McNemar json_invalid: b=%d, c=%d, chi2=%.3f, p=%.4f, p_adj=%.4f (%d pasangan)
+
+# Latensi dan Biaya
+
+| mode | n | mean_ms | p95_ms | input_tokens | output_tokens | token_source | est_cost_usd | avg_f1 | cost_per_f1_point_usd |
+|---|---|---|---|---|---|---|---|---|---|
+%s
+Harga: $%.2f / 1M token input, $%.2f / 1M token output; estimasi %.1f karakter per token bila usage metadata tidak tersedia.
+`, stats.CorrectionLabel(method), len(adj), Wplus, n, z, pz, adj[0], b, c, chi2, pm, adj[1], Wlat, nlat, zlat, plat, adj[2],
+		bJ, cJ, chiJ, pJ, adj[3], len(jsonBadBase), mdCost.String(), pricing.InputPer1M, pricing.OutputPer1M, pricing.CharsPerToken)
+	mdPath := filepath.Join(outDir, fmt.Sprintf("score-tests-%s.md", stamp))
+	_ = os.WriteFile(mdPath, []byte(md), 0o644)
+	fmt.Println("[score] saved:", testsPath)
+	fmt.Println("[score] saved:", mdPath)
+
+	// Write CSV (summary rows)
 	csvPath := filepath.Join(outDir, fmt.Sprintf("score-%s.csv", stamp))
 	f, err := os.Create(csvPath)
 	if err != nil {
@@ -623,14 +818,40 @@ func main() {
 		os.Exit(1)
 	}
 	w := csv.NewWriter(f)
-	_ = w.Write([]string{"query", "mode", "coverage", "precision", "f1", "format_ok", "fabricated_contact", "fabricated_link", "used_placeholder", "notes"})
+	_ = w.Write([]string{"query", "mode", "combination", "coverage", "precision", "f1", "format_ok", "fabricated_contact", "fabricated_link", "used_placeholder", "golden", "golden_overlap",
+		fmt.Sprintf("recall_at_%d", k), fmt.Sprintf("ndcg_at_%d", k), "json_valid", "duration_ms", "input_tokens", "output_tokens", "est_cost_usd", "notes"})
 	for _, rw := range rows {
-		_ = w.Write([]string{rw.Query, rw.Mode, fmt.Sprintf("%.2f", rw.Coverage), fmt.Sprintf("%.2f", rw.Precision), fmt.Sprintf("%.2f", rw.F1), fmt.Sprintf("%t", rw.FormatOK), fmt.Sprintf("%t", rw.FabContact), fmt.Sprintf("%t", rw.FabLink), fmt.Sprintf("%t", rw.UsedPlaceholder), rw.Notes})
+		_ = w.Write([]string{rw.Query, rw.Mode, rw.Combination, fmt.Sprintf("%.2f", rw.Coverage), fmt.Sprintf("%.2f", rw.Precision), fmt.Sprintf("%.2f", rw.F1), fmt.Sprintf("%t", rw.FormatOK), fmt.Sprintf("%t", rw.FabContact), fmt.Sprintf("%t", rw.FabLink), fmt.Sprintf("%t", rw.UsedPlaceholder), fmt.Sprintf("%t", rw.Golden), metricCell(rw.GoldenOverlap),
+			metricCell(rw.RecallK), metricCell(rw.NDCG), jsonCell(rw), strconv.FormatInt(rw.DurationMs, 10), strconv.Itoa(rw.InputTokens), strconv.Itoa(rw.OutputTokens), fmt.Sprintf("%.8f", rw.CostUSD), rw.Notes})
 	}
 	w.Flush()
 	_ = f.Close()
 	fmt.Println("[score] saved:", csvPath)
 
+	// Per-combination summary for parameter sweeps
+	if len(summary.Results) > 0 && summary.Results[0].Combination != "" {
+		combos := scoreCombinations(rows)
+		combosPath := filepath.Join(outDir, fmt.Sprintf("score-combos-%s.csv", stamp))
+		fc, err := os.Create(combosPath)
+		if err != nil {
+			fmt.Println("error:", err)
+			os.Exit(1)
+		}
+		wc := csv.NewWriter(fc)
+		_ = wc.Write([]string{"rank", "combination", "n", "avg_precision", "avg_coverage", "avg_f1", fmt.Sprintf("avg_recall_at_%d", k), fmt.Sprintf("avg_ndcg_at_%d", k), "format_pass", "fabricated_any", "errors", "avg_duration_ms"})
+		fmt.Println("[score] per combination (best first):")
+		for i, c := range combos {
+			fmt.Printf("  %2d. %s -> avg_f1=%.2f, avg_precision=%.2f, avg_coverage=%.2f, format_pass=%d/%d, fabricated_any=%d/%d, errors=%d, avg_duration=%.0fms\n",
+				i+1, c.Combination, c.F1, c.Precision, c.Coverage, c.FormatOK, c.N, c.Fabricated, c.N, c.Errors, c.AvgDurationMs)
+			_ = wc.Write([]string{strconv.Itoa(i + 1), c.Combination, strconv.Itoa(c.N), fmt.Sprintf("%.4f", c.Precision), fmt.Sprintf("%.4f", c.Coverage), fmt.Sprintf("%.4f", c.F1),
+				metricCell(c.RecallK), metricCell(c.NDCG), strconv.Itoa(c.FormatOK), strconv.Itoa(c.Fabricated), strconv.Itoa(c.Errors), fmt.Sprintf("%.0f", c.AvgDurationMs)})
+		}
+		wc.Flush()
+		_ = fc.Close()
+		fmt.Println("[score] best combination:", combos[0].Combination)
+		fmt.Println("[score] saved:", combosPath)
+	}
+
 	// Per-item TP/FP/FN labeling CSV
 	itemsPath := filepath.Join(outDir, fmt.Sprintf("score-items-%s.csv", stamp))
 	fi, err := os.Create(itemsPath)
@@ -639,30 +860,38 @@ func main() {
 		os.Exit(1)
 	}
 	wi := csv.NewWriter(fi)
-	_ = wi.Write([]string{"query", "mode", "title", "label"})
+	_ = wi.Write([]string{"query", "mode", "combination", "title", "label"})
 	// aggregate fabricated event rate and counts
 	type aggC struct{ TP, FP, FN int }
 	aggByMode := map[string]*aggC{}
-	for _, r := range rows {
+	for i, r := range rows {
 		if _, ok := aggByMode[r.Mode]; !ok {
 			aggByMode[r.Mode] = &aggC{}
 		}
-		pred := predictedTitles(uib, findResponse(summary.Results, r.Query, r.Mode))
+		pred := predictedTitles(uib, summary.Results[i].Response)
 		rel := relevantTitles(uib, r.Query)
+		if ids := summary.Results[i].ExpectedEventIDs; len(ids) > 0 {
+			rel = map[string]bool{}
+			for _, ev := range uib.GetAllEvents() {
+				if slices.Contains(ids, ev.ID) {
+					rel[ev.Title] = true
+				}
+			}
+		}
 		// TP/FP
 		for t := range pred {
 			if rel[t] {
-				_ = wi.Write([]string{r.Query, r.Mode, t, "TP"})
+				_ = wi.Write([]string{r.Query, r.Mode, r.Combination, t, "TP"})
 				aggByMode[r.Mode].TP++
 			} else {
-				_ = wi.Write([]string{r.Query, r.Mode, t, "FP"})
+				_ = wi.Write([]string{r.Query, r.Mode, r.Combination, t, "FP"})
 				aggByMode[r.Mode].FP++
 			}
 		}
 		// FN
 		for t := range rel {
 			if !pred[t] {
-				_ = wi.Write([]string{r.Query, r.Mode, t, "FN"})
+				_ = wi.Write([]string{r.Query, r.Mode, r.Combination, t, "FN"})
 				aggByMode[r.Mode].FN++
 			}
 		}
@@ -681,13 +910,3 @@ func main() {
 		fmt.Printf("%s -> TP=%d, FP=%d, FN=%d, fabricated_event_rate=%.2f\n", mode, c.TP, c.FP, c.FN, rate)
 	}
 }
-
-// helper to find response text per query/mode pair
-func findResponse(results []ResultItem, q, mode string) string {
-	for _, r := range results {
-		if r.Query == q && r.Mode == mode {
-			return r.Response
-		}
-	}
-	return ""
-}
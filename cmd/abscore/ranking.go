@@ -0,0 +1,89 @@
+package main
+
+import (
+	"math"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+var markerRe = regexp.MustCompile(`\bEV-[A-Z0-9]+(?:-[A-Z0-9]+)*\b`)
+
+// rankingK is the cutoff for recall@k and nDCG@k (ABSCORE_K, default 5).
+func rankingK() int {
+	if k, err := strconv.Atoi(strings.TrimSpace(os.Getenv("ABSCORE_K"))); err == nil && k > 0 {
+		return k
+	}
+	return 5
+}
+
+// rankedIDsFromResponse lists the events a response mentions in the order
+// they first appear, by title or by citation marker ([EV-...]). Unknown
+// markers are kept so that fabricated citations take up ranks.
+func rankedIDsFromResponse(resp string, title2id map[string]string) []string {
+	first := map[string]int{}
+	note := func(id string, pos int) {
+		if p, ok := first[id]; !ok || pos < p {
+			first[id] = pos
+		}
+	}
+	respL := strings.ToLower(resp)
+	for titleL, id := range title2id {
+		if i := strings.Index(respL, titleL); titleL != "" && i >= 0 {
+			note(id, i)
+		}
+	}
+	for _, loc := range markerRe.FindAllStringIndex(resp, -1) {
+		note(resp[loc[0]:loc[1]], loc[0])
+	}
+	ids := make([]string, 0, len(first))
+	for id := range first {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		if first[ids[i]] != first[ids[j]] {
+			return first[ids[i]] < first[ids[j]]
+		}
+		return ids[i] < ids[j]
+	})
+	return ids
+}
+
+// rankingMetrics computes recall@k and binary-relevance nDCG@k of ranked
+// against relevant. Both are -1 when there is no relevant event.
+func rankingMetrics(ranked, relevant []string, k int) (recallK, ndcg float64) {
+	rel := map[string]bool{}
+	for _, id := range relevant {
+		if id = strings.TrimSpace(id); id != "" {
+			rel[id] = true
+		}
+	}
+	if len(rel) == 0 {
+		return -1, -1
+	}
+	hits, dcg := 0, 0.0
+	for i, id := range ranked {
+		if i == k {
+			break
+		}
+		if rel[id] {
+			hits++
+			dcg += 1 / math.Log2(float64(i+2))
+		}
+	}
+	idcg := 0.0
+	for i := 0; i < min(k, len(rel)); i++ {
+		idcg += 1 / math.Log2(float64(i+2))
+	}
+	return float64(hits) / float64(len(rel)), dcg / idcg
+}
+
+// metricCell formats metrics that are -1 when undefined as an empty cell.
+func metricCell(v float64) string {
+	if v < 0 {
+		return ""
+	}
+	return strconv.FormatFloat(v, 'f', 2, 64)
+}
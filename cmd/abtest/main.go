@@ -3,10 +3,10 @@ package main
 import (
 	"context"
 	"crypto/sha256"
-	"encoding/csv"
 	"encoding/hex"
 	"encoding/json"
 	"errors"
+	"flag"
 	"fmt"
 	"math/rand"
 	"os"
@@ -15,6 +15,7 @@ import (
 	"strings"
 	"time"
 
+	"AkuAI/pkg/abio"
 	"AkuAI/pkg/config"
 	svc "AkuAI/pkg/services"
 )
@@ -29,41 +30,66 @@ const (
 	ctxLogFull ctxKey = "abtest_log_full"
 )
 
+// QueryItem is one entry of queries.json. Tags (month, intent, difficulty)
+// drive ABTEST_SAMPLE; expected_event_ids and golden_answer, when present, are
+// what abscore scores against instead of the service-derived relevance, and
+// expected_json is the structure abscore validates JSON answers against.
 type QueryItem struct {
-	Q string `json:"q"`
+	ID               string            `json:"id,omitempty"`
+	Q                string            `json:"q"`
+	Tags             map[string]string `json:"tags,omitempty"`
+	ExpectedEventIDs []string          `json:"expected_event_ids,omitempty"`
+	GoldenAnswer     string            `json:"golden_answer,omitempty"`
+	ExpectedJSON     json.RawMessage   `json:"expected_json,omitempty"`
 }
 
 type ResultItem struct {
-	Query                 string   `json:"query"`
-	Mode                  string   `json:"mode"` // baseline | engineered
-	Response              string   `json:"response"`
-	Error                 string   `json:"error,omitempty"`
-	DurationMs            int64    `json:"duration_ms"`
-	Model                 string   `json:"model"`
-	Timestamp             string   `json:"timestamp"`
-	PromptTemplateID      string   `json:"prompt_template_id,omitempty"`
-	PromptTemplateVersion string   `json:"prompt_template_version,omitempty"`
-	ContextHash           string   `json:"context_hash,omitempty"`
-	ContextSnapshot       string   `json:"context_snapshot,omitempty"`
-	RelevantEventIDs      []string `json:"relevant_event_ids,omitempty"`
+	QueryID               string     `json:"query_id,omitempty"`
+	Query                 string     `json:"query"`
+	Mode                  string     `json:"mode"` // baseline | engineered
+	Response              string     `json:"response"`
+	Error                 string     `json:"error,omitempty"`
+	DurationMs            int64      `json:"duration_ms"`
+	Model                 string     `json:"model"`
+	Timestamp             string     `json:"timestamp"`
+	PromptTemplateID      string     `json:"prompt_template_id,omitempty"`
+	PromptTemplateVersion string     `json:"prompt_template_version,omitempty"`
+	ContextHash           string     `json:"context_hash,omitempty"`
+	ContextSnapshot       string     `json:"context_snapshot,omitempty"`
+	DatasetHash           string     `json:"dataset_hash,omitempty"` // set when the prompt had event context
+	RelevantEventIDs      []string   `json:"relevant_event_ids,omitempty"`
+	Temperature           float64    `json:"temperature"`
+	Combination           string     `json:"combination"`
+	Usage                 *svc.Usage `json:"usage,omitempty"` // Gemini usageMetadata; nil when none was reported
+	// copied from queries.json so results can be scored on their own
+	Tags             map[string]string `json:"tags,omitempty"`
+	ExpectedEventIDs []string          `json:"expected_event_ids,omitempty"`
+	GoldenAnswer     string            `json:"golden_answer,omitempty"`
+	ExpectedJSON     json.RawMessage   `json:"expected_json,omitempty"`
 }
 
 type RunSummary struct {
-	RunID        string       `json:"run_id"`
-	RandomSeed   int64        `json:"random_seed"`
-	StartedAt    string       `json:"started_at"`
-	EndedAt      string       `json:"ended_at"`
-	Env          string       `json:"env"`
-	GeminiOn     bool         `json:"gemini_enabled"`
-	Model        string       `json:"model"`
-	Temperature  float64      `json:"temperature"`
-	ABTestOnly   string       `json:"abtest_only,omitempty"`
-	PromptLog    string       `json:"prompt_log_file,omitempty"`
-	TotalQueries int          `json:"total_queries"`
-	Results      []ResultItem `json:"results"`
+	RunID        string        `json:"run_id"`
+	RandomSeed   int64         `json:"random_seed"`
+	StartedAt    string        `json:"started_at"`
+	EndedAt      string        `json:"ended_at"`
+	Env          string        `json:"env"`
+	GeminiOn     bool          `json:"gemini_enabled"`
+	Model        string        `json:"model"`
+	Temperature  float64       `json:"temperature"`
+	DatasetVer   string        `json:"dataset_version,omitempty"`
+	DatasetHash  string        `json:"dataset_hash,omitempty"`
+	Combinations []Combination `json:"combinations"`
+	ABTestOnly   string        `json:"abtest_only,omitempty"`
+	Sample       string        `json:"sample,omitempty"`
+	Shuffle      bool          `json:"shuffle,omitempty"`
+	PromptLog    string        `json:"prompt_log_file,omitempty"`
+	TotalQueries int           `json:"total_queries"`
+	Results      []ResultItem  `json:"results"`
 }
 
-func mustReadQueries() ([]string, error) {
+// mustReadQueries returns the queries and the path of the queries.json used.
+func mustReadQueries() ([]QueryItem, string, error) {
 	// Try multiple relative locations to be robust when called via `go run ./core/cmd/abtest`
 	candidates := []string{
 		"core/cmd/abtest/queries.json",
@@ -73,10 +99,11 @@ func mustReadQueries() ([]string, error) {
 	}
 
 	var data []byte
+	var path string
 	var err error
 	for _, p := range candidates {
 		if b, e := os.ReadFile(p); e == nil {
-			data = b
+			data, path = b, p
 			err = nil
 			break
 		} else {
@@ -84,131 +111,89 @@ func mustReadQueries() ([]string, error) {
 		}
 	}
 	if data == nil {
-		return nil, fmt.Errorf("cannot read queries.json: %w", err)
-	}
-
-	// queries.json can be either ["q1", "q2", ...] or [{"q": "..."}, ...]
-	var arrAny []any
-	if e := json.Unmarshal(data, &arrAny); e != nil {
-		return nil, fmt.Errorf("invalid queries.json: %w", e)
-	}
-	out := make([]string, 0, len(arrAny))
-	for _, v := range arrAny {
-		switch t := v.(type) {
-		case string:
-			out = append(out, strings.TrimSpace(t))
-		case map[string]any:
-			if qv, ok := t["q"].(string); ok {
-				out = append(out, strings.TrimSpace(qv))
+		return nil, "", fmt.Errorf("cannot read queries.json: %w", err)
+	}
+
+	// queries.json can be either ["q1", "q2", ...] or [{"q": "...", "tags": {...}}, ...]
+	var raw []json.RawMessage
+	if e := json.Unmarshal(data, &raw); e != nil {
+		return nil, "", fmt.Errorf("invalid queries.json: %w", e)
+	}
+	out := make([]QueryItem, 0, len(raw))
+	for i, v := range raw {
+		var it QueryItem
+		if e := json.Unmarshal(v, &it.Q); e != nil {
+			if e := json.Unmarshal(v, &it); e != nil {
+				return nil, "", fmt.Errorf("invalid queries.json entry %d: %w", i+1, e)
 			}
 		}
+		it.Q = strings.TrimSpace(it.Q)
+		if it.Q == "" {
+			continue
+		}
+		if it.ID == "" {
+			it.ID = fmt.Sprintf("q%03d", i+1)
+		}
+		out = append(out, it)
 	}
 	if len(out) == 0 {
-		return nil, errors.New("queries.json is empty or malformed")
+		return nil, "", errors.New("queries.json is empty or malformed")
 	}
-	return out, nil
+	return out, path, nil
 }
 
 func ensureDir(p string) error {
 	return os.MkdirAll(p, 0o755)
 }
 
-func writeJSON(path string, v any) error {
-	b, err := json.MarshalIndent(v, "", "  ")
-	if err != nil {
-		return err
-	}
-	return os.WriteFile(path, b, 0o644)
-}
-
 func writeCSV(path string, items []ResultItem) error {
-	f, err := os.Create(path)
-	if err != nil {
-		return err
-	}
-	defer f.Close()
-	w := csv.NewWriter(f)
-	defer w.Flush()
-	// header
-	_ = w.Write([]string{"query", "mode", "duration_ms", "model", "error", "response"})
+	rows := make([][]string, 0, len(items))
 	for _, it := range items {
-		_ = w.Write([]string{
+		rows = append(rows, []string{
 			it.Query,
 			it.Mode,
+			it.Combination,
+			strconv.FormatFloat(it.Temperature, 'f', -1, 64),
 			fmt.Sprintf("%d", it.DurationMs),
 			it.Model,
 			it.Error,
 			it.Response,
 		})
 	}
-	return nil
+	return abio.WriteCSV(path, []string{"query", "mode", "combination", "temperature", "duration_ms", "model", "error", "response"}, rows)
 }
 
 func main() {
+	verify := flag.String("verify", "", "check a results file (or \"latest\") against its manifest instead of running")
+	flag.Parse()
+
 	// Trigger config init() to load env
 	_ = config.AppEnv
 
+	if *verify != "" {
+		if err := verifyRun(*verify); err != nil {
+			fmt.Println("error:", err)
+			os.Exit(1)
+		}
+		return
+	}
+
 	if !config.IsGeminiEnabled {
 		fmt.Println("[warn] IS_GEMINI_ENABLED=0 – runner will use mock responses. Enable real API for valid A/B results.")
 	}
+	if config.MockLLMFixtures != "" {
+		fmt.Println("[warn] MOCK_LLM_FIXTURES is set – responses come from fixtures in", config.MockLLMFixtures)
+	}
 	if config.GeminiAPIKey == "" {
 		fmt.Println("[warn] GEMINI_API_KEY is empty – real API calls will fail. Set it in core/.env")
 	}
 
-	queries, err := mustReadQueries()
+	queries, queriesPath, err := mustReadQueries()
 	if err != nil {
 		fmt.Println("error:", err)
 		os.Exit(1)
 	}
 
-	// Optional: run only selected queries by index or substring via ABTEST_ONLY
-	// Example: ABTEST_ONLY="10,11,14" or ABTEST_ONLY="minggu depan,bulan 11"
-	if only := strings.TrimSpace(os.Getenv("ABTEST_ONLY")); only != "" {
-		tokens := strings.Split(only, ",")
-		wantedIdx := map[int]bool{}
-		subs := make([]string, 0)
-		for _, t := range tokens {
-			v := strings.ToLower(strings.TrimSpace(t))
-			if v == "" {
-				continue
-			}
-			if n, err := strconv.Atoi(v); err == nil {
-				if n >= 1 && n <= len(queries) {
-					wantedIdx[n-1] = true
-				}
-			} else {
-				subs = append(subs, v)
-			}
-		}
-		filtered := make([]string, 0)
-		seen := map[int]bool{}
-		for i := range queries {
-			if wantedIdx[i] {
-				filtered = append(filtered, queries[i])
-				seen[i] = true
-			}
-		}
-		if len(subs) > 0 {
-			for i, q := range queries {
-				if seen[i] {
-					continue
-				}
-				ql := strings.ToLower(q)
-				for _, sub := range subs {
-					if strings.Contains(ql, sub) {
-						filtered = append(filtered, q)
-						seen[i] = true
-						break
-					}
-				}
-			}
-		}
-		if len(filtered) > 0 {
-			queries = filtered
-			fmt.Printf("[filter] ABTEST_ONLY active -> running %d selected queries\n", len(queries))
-		}
-	}
-
 	// Services
 	gem := svc.NewGeminiService()
 	uib, _ := svc.NewUIBEventService()
@@ -230,10 +215,18 @@ func main() {
 	}
 
 	started := time.Now()
-	// Reproducibility seed & run id
+	// Reproducibility seed & run id. ABTEST_SEED replays the sample and order
+	// of an earlier run.
 	seed := started.UnixNano()
-	r := rand.New(rand.NewSource(seed))
-	runID := fmt.Sprintf("abrun-%s-%06d", started.Format("20060102-150405"), r.Intn(1000000))
+	if s := strings.TrimSpace(os.Getenv("ABTEST_SEED")); s != "" {
+		v, err := strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			fmt.Println("error: invalid ABTEST_SEED:", s)
+			os.Exit(1)
+		}
+		seed = v
+	}
+	runID := fmt.Sprintf("abrun-%s-%06d", started.Format("20060102-150405"), rand.New(rand.NewSource(seed)).Intn(1000000))
 
 	// Prompt log path (JSONL). Can override via ABTEST_PROMPT_LOG_FILE
 	promptLogDir := filepath.Join("cmd", "abtest", "results", "prompt_logs")
@@ -244,35 +237,56 @@ func main() {
 	}
 	logFull := strings.TrimSpace(os.Getenv("ABTEST_LOG_FULL"))
 
-	results := make([]ResultItem, 0, len(queries)*2)
+	// Parameter grid; by default the configured model and temperature with
+	// both templates, i.e. the classic baseline vs engineered run.
+	combos, err := parseSweep(os.Getenv("ABTEST_SWEEP"), os.Getenv("ABTEST_TEMPERATURES"))
+	if err != nil {
+		fmt.Println("error:", err)
+		os.Exit(1)
+	}
+	if len(combos) > 2 {
+		fmt.Printf("[sweep] %d combinations\n", len(combos))
+	}
 
-	for _, q := range queries {
-		// Baseline with simple quota-aware retry
-		rb := runModeOnce(gem, uib, q, "baseline", timeoutSec, runID, promptLogPath, logFull)
-		if isQuotaError(rb.Error) {
-			delay := parseRetryDelay(rb.Error)
-			fmt.Printf("   ↪ quota hit; sleeping %ds then retry baseline...\n", delay)
-			time.Sleep(time.Duration(delay) * time.Second)
-			rb = runModeOnce(gem, uib, q, "baseline", timeoutSec, runID, promptLogPath, logFull)
-		}
-		results = append(results, rb)
-		fmt.Printf("[baseline] %s -> %dms error=%v\n", truncate(q, 64), rb.DurationMs, rb.Error != "")
-		time.Sleep(time.Duration(sleepMs) * time.Millisecond)
+	// Which queries run and in which order: ABTEST_ONLY (indexes, IDs or
+	// substrings), then an optional stratified sample by tags, e.g.
+	// ABTEST_SAMPLE="intent:2" (two queries per intent); ABTEST_SHUFFLE=1
+	// shuffles the queries and the combination order per query.
+	opt := planOptions{
+		Seed:    seed,
+		Only:    strings.TrimSpace(os.Getenv("ABTEST_ONLY")),
+		Sample:  strings.TrimSpace(os.Getenv("ABTEST_SAMPLE")),
+		Shuffle: strings.TrimSpace(os.Getenv("ABTEST_SHUFFLE")) == "1",
+	}
+	plan, err := buildPlan(queries, combos, opt)
+	if err != nil {
+		fmt.Println("error:", err)
+		os.Exit(1)
+	}
+	queryCount := len(plan) / max(len(combos), 1)
+	if opt.Only != "" || opt.Sample != "" {
+		fmt.Printf("[filter] ABTEST_ONLY=%q ABTEST_SAMPLE=%q -> %d queries\n", opt.Only, opt.Sample, queryCount)
+	}
+	fmt.Printf("[plan] seed=%d shuffle=%v calls=%d\n", seed, opt.Shuffle, len(plan))
+
+	results := make([]ResultItem, 0, len(plan))
 
-		// Engineered with simple quota-aware retry
-		re := runModeOnce(gem, uib, q, "engineered", timeoutSec, runID, promptLogPath, logFull)
-		if isQuotaError(re.Error) {
-			delay := parseRetryDelay(re.Error)
-			fmt.Printf("   ↪ quota hit; sleeping %ds then retry engineered...\n", delay)
+	for _, step := range plan {
+		q, cb := step.Query, step.Combo
+		// simple quota-aware retry
+		res := runModeOnce(gem, uib, q, cb, timeoutSec, runID, promptLogPath, logFull)
+		if isQuotaError(res.Error) {
+			delay := parseRetryDelay(res.Error)
+			fmt.Printf("   ↪ quota hit; sleeping %ds then retry %s...\n", delay, cb.ID)
 			time.Sleep(time.Duration(delay) * time.Second)
-			re = runModeOnce(gem, uib, q, "engineered", timeoutSec, runID, promptLogPath, logFull)
+			res = runModeOnce(gem, uib, q, cb, timeoutSec, runID, promptLogPath, logFull)
 		}
-		results = append(results, re)
-		fmt.Printf("[engineered] %s -> %dms error=%v\n", truncate(q, 64), re.DurationMs, re.Error != "")
+		results = append(results, res)
+		fmt.Printf("[%s] %s -> %dms error=%v\n", cb.ID, truncate(q.Q, 64), res.DurationMs, res.Error != "")
 		time.Sleep(time.Duration(sleepMs) * time.Millisecond)
 	}
 
-	outDir := "cmd/abtest/results"
+	outDir := abio.ResultsDir
 	if err := ensureDir(outDir); err != nil {
 		fmt.Println("failed to create results dir:", err)
 		os.Exit(1)
@@ -289,13 +303,20 @@ func main() {
 		Env:          config.AppEnv,
 		GeminiOn:     config.IsGeminiEnabled,
 		Model:        config.GeminiModel,
-		Temperature:  0.4,
-		ABTestOnly:   strings.TrimSpace(os.Getenv("ABTEST_ONLY")),
+		Temperature:  config.GeminiTemperature,
+		DatasetHash:  uib.Hash(),
+		Combinations: combos,
+		ABTestOnly:   opt.Only,
+		Sample:       opt.Sample,
+		Shuffle:      opt.Shuffle,
 		PromptLog:    promptLogPath,
-		TotalQueries: len(queries),
+		TotalQueries: queryCount,
 		Results:      results,
 	}
-	if err := writeJSON(jsonPath, summary); err != nil {
+	if uib != nil {
+		summary.DatasetVer = uib.Version()
+	}
+	if err := abio.WriteJSON(jsonPath, summary); err != nil {
 		fmt.Println("failed to write JSON:", err)
 		os.Exit(1)
 	}
@@ -304,9 +325,16 @@ func main() {
 		os.Exit(1)
 	}
 
+	manifest, err := writeManifest(jsonPath, queriesPath, summary, opt)
+	if err != nil {
+		fmt.Println("failed to write manifest:", err)
+		os.Exit(1)
+	}
+
 	fmt.Println("\nSaved:")
 	fmt.Println(" -", jsonPath)
 	fmt.Println(" -", csvPath)
+	fmt.Println(" -", manifest)
 }
 
 func truncate(s string, n int) string {
@@ -316,7 +344,11 @@ func truncate(s string, n int) string {
 	return s[:n-3] + "..."
 }
 
-func runModeOnce(gem *svc.GeminiService, uib *svc.UIBEventService, q, mode string, timeoutSec int, runID, promptLogPath, logFull string) ResultItem {
+// runModeOnce asks q with one combination. Its model and temperature are
+// pinned through a generation override, so the model fallback never answers
+// in the combination's name.
+func runModeOnce(gem *svc.GeminiService, uib *svc.UIBEventService, item QueryItem, cb Combination, timeoutSec int, runID, promptLogPath, logFull string) ResultItem {
+	q, mode := item.Q, cb.Template
 	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(timeoutSec)*time.Second)
 	defer cancel()
 	// attach abtest metadata for prompt logging
@@ -328,6 +360,9 @@ func runModeOnce(gem *svc.GeminiService, uib *svc.UIBEventService, q, mode strin
 	if strings.TrimSpace(logFull) != "" {
 		ctx = context.WithValue(ctx, ctxLogFull, logFull)
 	}
+	temperature := cb.Temperature
+	ctx = svc.WithGenerationOverride(ctx, svc.GenerationOverride{Model: cb.Model, Temperature: &temperature})
+	ctx, info := svc.WithGenerationInfo(ctx)
 	t0 := time.Now()
 	var resp string
 	var err error
@@ -354,9 +389,10 @@ func runModeOnce(gem *svc.GeminiService, uib *svc.UIBEventService, q, mode strin
 			tmplID = "askcampus_chat_generic_v1"
 		}
 	}
-	tmplVer := "2025.10.26"
+	tmplVer := svc.PromptTemplateVersion
 	var ctxHash string
 	var ctxSnap string
+	var dsHash string
 	var relIDs []string
 	if uib != nil && uib.AnalyzeQueryForUIB(q) {
 		evs := uib.GetRelevantEventsForQuery(q)
@@ -364,25 +400,37 @@ func runModeOnce(gem *svc.GeminiService, uib *svc.UIBEventService, q, mode strin
 		for _, ev := range evs {
 			relIDs = append(relIDs, ev.ID)
 		}
-		ctxStr := uib.FormatEventsForGemini(evs)
+		ctxStr := uib.EventContext(q, evs)
 		h := sha256.Sum256([]byte(ctxStr))
 		ctxHash = hex.EncodeToString(h[:])
+		dsHash = uib.Hash()
 		if strings.EqualFold(strings.TrimSpace(os.Getenv("ABTEST_INCLUDE_CONTEXT_SNAPSHOT")), "1") {
 			ctxSnap = ctxStr
 		}
 	}
 	r := ResultItem{
+		QueryID:               item.ID,
 		Query:                 q,
 		Mode:                  mode,
 		Response:              strings.TrimSpace(resp),
 		DurationMs:            dur.Milliseconds(),
-		Model:                 config.GeminiModel,
+		Model:                 cb.Model,
 		Timestamp:             time.Now().Format(time.RFC3339),
 		PromptTemplateID:      tmplID,
 		PromptTemplateVersion: tmplVer,
 		ContextHash:           ctxHash,
 		ContextSnapshot:       ctxSnap,
+		DatasetHash:           dsHash,
 		RelevantEventIDs:      relIDs,
+		Temperature:           temperature,
+		Combination:           cb.ID,
+		Tags:                  item.Tags,
+		ExpectedEventIDs:      item.ExpectedEventIDs,
+		GoldenAnswer:          item.GoldenAnswer,
+		ExpectedJSON:          item.ExpectedJSON,
+	}
+	if u := info.Usage(); u != (svc.Usage{}) {
+		r.Usage = &u
 	}
 	if err != nil {
 		r.Error = err.Error()
@@ -0,0 +1,298 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/rand"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"AkuAI/pkg/abio"
+)
+
+// datasetPath is the event dataset the engineered prompts draw from.
+const datasetPath = "data/uib_events.json"
+
+// manifestEnv is the configuration recorded in a manifest. Secrets such as
+// GEMINI_API_KEY are deliberately left out.
+var manifestEnv = []string{
+	"APP_ENV", "IS_GEMINI_ENABLED", "GEMINI_MODEL", "GEMINI_TEMPERATURE", "MOCK_LLM_FIXTURES",
+	"ABTEST_SEED", "ABTEST_SHUFFLE", "ABTEST_ONLY", "ABTEST_SAMPLE", "ABTEST_SWEEP", "ABTEST_TEMPERATURES",
+	"ABTEST_TIMEOUT_SEC", "ABTEST_SLEEP_MS", "ABTEST_FORCE_REAL", "ABTEST_INCLUDE_CONTEXT_SNAPSHOT", "ABTEST_LOG_FULL",
+}
+
+// planOptions is everything besides queries.json and the combinations that
+// decides which queries run and in which order.
+type planOptions struct {
+	Seed    int64  `json:"seed"`
+	Only    string `json:"only,omitempty"`
+	Sample  string `json:"sample,omitempty"`
+	Shuffle bool   `json:"shuffle"`
+}
+
+type planStep struct {
+	Query QueryItem
+	Combo Combination
+}
+
+// buildPlan selects the queries (ABTEST_ONLY, then ABTEST_SAMPLE) and orders
+// the (query, combination) calls. All randomness comes from opt.Seed, so the
+// same queries.json, combinations and options always give the same plan.
+// Without Shuffle the order of queries.json and of the combinations is kept.
+func buildPlan(queries []QueryItem, combos []Combination, opt planOptions) ([]planStep, error) {
+	r := rand.New(rand.NewSource(opt.Seed))
+	queries = filterOnly(queries, opt.Only)
+	if opt.Sample != "" {
+		sampled, err := sampleStratified(queries, opt.Sample, r)
+		if err != nil {
+			return nil, err
+		}
+		queries = sampled
+	}
+	if opt.Shuffle {
+		queries = append([]QueryItem(nil), queries...)
+		r.Shuffle(len(queries), func(a, b int) { queries[a], queries[b] = queries[b], queries[a] })
+	}
+	plan := make([]planStep, 0, len(queries)*len(combos))
+	for _, q := range queries {
+		order := append([]Combination(nil), combos...)
+		if opt.Shuffle {
+			r.Shuffle(len(order), func(a, b int) { order[a], order[b] = order[b], order[a] })
+		}
+		for _, cb := range order {
+			plan = append(plan, planStep{Query: q, Combo: cb})
+		}
+	}
+	return plan, nil
+}
+
+// filterOnly keeps the queries selected by ABTEST_ONLY: 1-based indexes,
+// query IDs or substrings, e.g. "10,11,14" or "minggu depan,bulan 11". When
+// nothing matches, all queries are kept.
+func filterOnly(queries []QueryItem, only string) []QueryItem {
+	if strings.TrimSpace(only) == "" {
+		return queries
+	}
+	wantedIdx := map[int]bool{}
+	subs := make([]string, 0)
+	for _, t := range strings.Split(only, ",") {
+		v := strings.ToLower(strings.TrimSpace(t))
+		if v == "" {
+			continue
+		}
+		if n, err := strconv.Atoi(v); err == nil {
+			if n >= 1 && n <= len(queries) {
+				wantedIdx[n-1] = true
+			}
+		} else {
+			subs = append(subs, v)
+		}
+	}
+	filtered := make([]QueryItem, 0)
+	seen := map[int]bool{}
+	for i := range queries {
+		if wantedIdx[i] {
+			filtered = append(filtered, queries[i])
+			seen[i] = true
+		}
+	}
+	for i, q := range queries {
+		if seen[i] {
+			continue
+		}
+		ql := strings.ToLower(q.Q)
+		for _, sub := range subs {
+			if strings.Contains(ql, sub) || strings.EqualFold(q.ID, sub) {
+				filtered = append(filtered, q)
+				seen[i] = true
+				break
+			}
+		}
+	}
+	if len(filtered) == 0 {
+		return queries
+	}
+	return filtered
+}
+
+// planHash fingerprints the call order as query ID and combination per call.
+func planHash(steps [][2]string) string {
+	h := sha256.New()
+	for _, s := range steps {
+		fmt.Fprintf(h, "%s\x00%s\n", s[0], s[1])
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func planKeys(plan []planStep) [][2]string {
+	out := make([][2]string, len(plan))
+	for i, s := range plan {
+		out[i] = [2]string{s.Query.ID, s.Combo.ID}
+	}
+	return out
+}
+
+func resultKeys(results []ResultItem) [][2]string {
+	out := make([][2]string, len(results))
+	for i, r := range results {
+		out[i] = [2]string{r.QueryID, r.Combination}
+	}
+	return out
+}
+
+// Manifest pins what a results file was produced from. It is written next to
+// the results as manifest-<stamp>.json and checked by `abtest --verify`.
+type Manifest struct {
+	RunID         string            `json:"run_id"`
+	Results       string            `json:"results"`
+	ResultsSHA256 string            `json:"results_sha256"`
+	QueriesFile   string            `json:"queries_file"`
+	QueriesSHA256 string            `json:"queries_sha256"`
+	DatasetFile   string            `json:"dataset_file"`
+	DatasetSHA256 string            `json:"dataset_sha256"`
+	GitCommit     string            `json:"git_commit,omitempty"`
+	GitDirty      bool              `json:"git_dirty,omitempty"`
+	Plan          planOptions       `json:"plan"`
+	PlanSHA256    string            `json:"plan_sha256"`
+	Config        map[string]string `json:"config"`
+}
+
+func manifestPath(resultsPath string) string {
+	base := strings.TrimSuffix(filepath.Base(resultsPath), filepath.Ext(resultsPath))
+	return filepath.Join(filepath.Dir(resultsPath), strings.Replace(base, "abtest-", "manifest-", 1)+".json")
+}
+
+func hashFile(path string) (string, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	h := sha256.Sum256(b)
+	return hex.EncodeToString(h[:]), nil
+}
+
+// gitCommit returns HEAD and whether the work tree has changes; empty when git
+// or the repository is unavailable.
+func gitCommit() (string, bool) {
+	out, err := exec.Command("git", "rev-parse", "HEAD").Output()
+	if err != nil {
+		return "", false
+	}
+	status, _ := exec.Command("git", "status", "--porcelain", "--untracked-files=no").Output()
+	return strings.TrimSpace(string(out)), len(strings.TrimSpace(string(status))) > 0
+}
+
+func configSnapshot() map[string]string {
+	cfg := map[string]string{}
+	for _, k := range manifestEnv {
+		if v, ok := os.LookupEnv(k); ok {
+			cfg[k] = v
+		}
+	}
+	return cfg
+}
+
+func writeManifest(resultsPath, queriesPath string, summary RunSummary, opt planOptions) (string, error) {
+	m := Manifest{
+		RunID:       summary.RunID,
+		Results:     filepath.Base(resultsPath),
+		QueriesFile: queriesPath,
+		DatasetFile: datasetPath,
+		Plan:        opt,
+		PlanSHA256:  planHash(resultKeys(summary.Results)),
+		Config:      configSnapshot(),
+	}
+	var err error
+	if m.ResultsSHA256, err = hashFile(resultsPath); err != nil {
+		return "", err
+	}
+	if m.QueriesSHA256, err = hashFile(queriesPath); err != nil {
+		return "", err
+	}
+	if m.DatasetSHA256, err = hashFile(datasetPath); err != nil {
+		return "", err
+	}
+	m.GitCommit, m.GitDirty = gitCommit()
+	path := manifestPath(resultsPath)
+	return path, abio.WriteJSON(path, m)
+}
+
+// verifyRun checks a results file against its manifest: the file is
+// unchanged, queries.json and the dataset still hash the same, and the seed
+// reproduces the recorded call order. A different git commit is only reported.
+func verifyRun(resultsPath string) error {
+	if resultsPath == "latest" {
+		p, err := abio.LatestResultsJSON(abio.ResultsDir)
+		if err != nil {
+			return err
+		}
+		resultsPath = p
+	}
+	mPath := manifestPath(resultsPath)
+	var m Manifest
+	if err := abio.ReadJSON(mPath, &m); err != nil {
+		return fmt.Errorf("cannot read manifest %s: %w", mPath, err)
+	}
+	var summary RunSummary
+	if err := abio.ReadJSON(resultsPath, &summary); err != nil {
+		return fmt.Errorf("cannot read results %s: %w", resultsPath, err)
+	}
+	fmt.Println("[verify]", resultsPath, "against", mPath)
+
+	failed := 0
+	check := func(name string, ok bool, detail string) {
+		mark := "ok"
+		if !ok {
+			mark = "MISMATCH"
+			failed++
+		}
+		fmt.Printf("  %-10s %-8s %s\n", name, mark, detail)
+	}
+
+	sum, err := hashFile(resultsPath)
+	if err != nil {
+		return err
+	}
+	check("results", sum == m.ResultsSHA256, short(sum)+" vs "+short(m.ResultsSHA256))
+	check("run", summary.RunID == m.RunID && summary.RandomSeed == m.Plan.Seed,
+		fmt.Sprintf("%s seed=%d", summary.RunID, summary.RandomSeed))
+
+	queries, queriesPath, err := mustReadQueries()
+	if err != nil {
+		return err
+	}
+	qSum, _ := hashFile(queriesPath)
+	check("queries", qSum == m.QueriesSHA256, queriesPath+" "+short(qSum)+" vs "+short(m.QueriesSHA256))
+	dSum, _ := hashFile(datasetPath)
+	check("dataset", dSum == m.DatasetSHA256, datasetPath+" "+short(dSum)+" vs "+short(m.DatasetSHA256))
+
+	got := resultKeys(summary.Results)
+	check("plan", planHash(got) == m.PlanSHA256, fmt.Sprintf("%d calls recorded", len(got)))
+	plan, err := buildPlan(queries, summary.Combinations, m.Plan)
+	if err != nil {
+		return err
+	}
+	want := planKeys(plan)
+	check("replay", planHash(want) == planHash(got), fmt.Sprintf("seed %d gives %d calls", m.Plan.Seed, len(want)))
+
+	if head, dirty := gitCommit(); head != "" && m.GitCommit != "" && (head != m.GitCommit || dirty != m.GitDirty) {
+		fmt.Printf("  %-10s %-8s run at %s (dirty=%v), tree at %s (dirty=%v)\n", "git", "note", short(m.GitCommit), m.GitDirty, short(head), dirty)
+	}
+	if failed > 0 {
+		return errors.New("results do not match their manifest")
+	}
+	fmt.Println("[verify] results match their manifest")
+	return nil
+}
+
+func short(sum string) string {
+	if len(sum) > 12 {
+		return sum[:12]
+	}
+	return sum
+}
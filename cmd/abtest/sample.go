@@ -0,0 +1,77 @@
+package main
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// sampleStratified draws queries per tag stratum. spec is a comma-separated
+// list of entries:
+//
+//	tag:N        N queries for every value of tag (e.g. intent:2)
+//	tag=value:N  N queries tagged tag=value (e.g. difficulty=hard:5)
+//
+// Entries are filled in order and a query is drawn at most once; a stratum
+// with fewer queries contributes all of them. The result keeps the order of
+// queries.json so runs stay comparable.
+func sampleStratified(queries []QueryItem, spec string, r *rand.Rand) ([]QueryItem, error) {
+	picked := map[int]bool{}
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		i := strings.LastIndexByte(entry, ':')
+		if i < 0 {
+			return nil, fmt.Errorf("ABTEST_SAMPLE: %q needs a count (tag:N or tag=value:N)", entry)
+		}
+		n, err := strconv.Atoi(strings.TrimSpace(entry[i+1:]))
+		if err != nil || n < 1 {
+			return nil, fmt.Errorf("ABTEST_SAMPLE: invalid count in %q", entry)
+		}
+		tag, value, exact := strings.Cut(strings.TrimSpace(entry[:i]), "=")
+		tag, value = strings.TrimSpace(tag), strings.TrimSpace(value)
+
+		strata := map[string][]int{}
+		for idx, q := range queries {
+			v, ok := q.Tags[tag]
+			if !ok || (exact && !strings.EqualFold(v, value)) {
+				continue
+			}
+			strata[v] = append(strata[v], idx)
+		}
+		if len(strata) == 0 {
+			return nil, fmt.Errorf("ABTEST_SAMPLE: no query is tagged %s", strings.TrimSpace(entry[:i]))
+		}
+		// deterministic stratum order so a seed reproduces the sample
+		values := make([]string, 0, len(strata))
+		for v := range strata {
+			values = append(values, v)
+		}
+		sort.Strings(values)
+		for _, v := range values {
+			idx := strata[v]
+			r.Shuffle(len(idx), func(a, b int) { idx[a], idx[b] = idx[b], idx[a] })
+			taken := 0
+			for _, k := range idx {
+				if taken == n {
+					break
+				}
+				if !picked[k] {
+					picked[k] = true
+					taken++
+				}
+			}
+		}
+	}
+	out := make([]QueryItem, 0, len(picked))
+	for idx, q := range queries {
+		if picked[idx] {
+			out = append(out, q)
+		}
+	}
+	return out, nil
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"AkuAI/pkg/config"
+)
+
+// Combination is one cell of the parameter grid: every query runs once per
+// combination.
+type Combination struct {
+	ID          string  `json:"id"`
+	Model       string  `json:"model"`
+	Temperature float64 `json:"temperature"`
+	Template    string  `json:"template"` // baseline | engineered
+}
+
+// parseSweep builds the grid models × temperatures × templates from
+// ABTEST_SWEEP, e.g.
+//
+//	models=gemini-2.0-flash,gemini-2.5-flash;temperatures=0,0.4,0.8;templates=engineered
+//
+// A missing dimension keeps its default: GEMINI_MODEL, the ABTEST_TEMPERATURES
+// list (or GEMINI_TEMPERATURE) and both templates.
+func parseSweep(spec, temperatures string) ([]Combination, error) {
+	models := []string{config.GeminiModel}
+	temps := []float64{config.GeminiTemperature}
+	templates := []string{"baseline", "engineered"}
+
+	if ts := splitList(temperatures); len(ts) > 0 {
+		v, err := parseTemperatures(ts)
+		if err != nil {
+			return nil, fmt.Errorf("ABTEST_TEMPERATURES: %w", err)
+		}
+		temps = v
+	}
+	for _, part := range strings.Split(spec, ";") {
+		if strings.TrimSpace(part) == "" {
+			continue
+		}
+		key, val, ok := strings.Cut(part, "=")
+		values := splitList(val)
+		if !ok || len(values) == 0 {
+			return nil, fmt.Errorf("ABTEST_SWEEP: invalid dimension %q", part)
+		}
+		switch strings.ToLower(strings.TrimSpace(key)) {
+		case "models", "model":
+			models = values
+		case "temperatures", "temperature", "temps":
+			v, err := parseTemperatures(values)
+			if err != nil {
+				return nil, fmt.Errorf("ABTEST_SWEEP: %w", err)
+			}
+			temps = v
+		case "templates", "template", "modes":
+			for _, t := range values {
+				if t != "baseline" && t != "engineered" {
+					return nil, fmt.Errorf("ABTEST_SWEEP: unknown template %q (baseline | engineered)", t)
+				}
+			}
+			templates = values
+		default:
+			return nil, fmt.Errorf("ABTEST_SWEEP: unknown dimension %q", key)
+		}
+	}
+
+	combos := make([]Combination, 0, len(models)*len(temps)*len(templates))
+	for _, m := range models {
+		for _, t := range temps {
+			for _, tmpl := range templates {
+				combos = append(combos, Combination{
+					ID:          fmt.Sprintf("%s@%g/%s", m, t, tmpl),
+					Model:       m,
+					Temperature: t,
+					Template:    tmpl,
+				})
+			}
+		}
+	}
+	return combos, nil
+}
+
+func splitList(s string) []string {
+	var out []string
+	for _, v := range strings.Split(s, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+func parseTemperatures(values []string) ([]float64, error) {
+	out := make([]float64, 0, len(values))
+	for _, v := range values {
+		t, err := strconv.ParseFloat(v, 64)
+		if err != nil || t < 0 || t > 2 {
+			return nil, fmt.Errorf("invalid temperature %q", v)
+		}
+		out = append(out, t)
+	}
+	return out, nil
+}
@@ -0,0 +1,124 @@
+// Command backup writes a disaster-recovery archive of an AkuAI server and
+// restores it into a fresh database:
+//
+//	go run ./cmd/backup create [-o archive.tar.gz] [-no-password-hashes]
+//	go run ./cmd/backup restore archive.tar.gz
+//
+// The database and paths come from core/.env as for the server.
+package main
+
+import (
+	"AkuAI/pkg/backup"
+	"AkuAI/pkg/config"
+	"AkuAI/pkg/database"
+	"AkuAI/pkg/fieldcrypt"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+const usage = `usage: backup create [-o archive.tar.gz] [-no-password-hashes]
+       backup restore archive.tar.gz`
+
+// paths are the files of this server; the default dataset path matches
+// pkg/services.
+func paths() backup.Paths {
+	return backup.Paths{DefaultDataset: "data/uib_events.json", CampusDataDir: config.CampusDataDir, UploadsDir: "uploads"}
+}
+
+func create(db *gorm.DB, args []string) error {
+	fs := flag.NewFlagSet("create", flag.ExitOnError)
+	out := fs.String("o", "", "archive to write (default backups/akuai-backup-<timestamp>.tar.gz)")
+	noHashes := fs.Bool("no-password-hashes", false, "leave the users' password hashes out of the archive")
+	fs.Parse(args)
+	if *out == "" {
+		*out = filepath.Join("backups", fmt.Sprintf("akuai-backup-%s.tar.gz", time.Now().Format("20060102-150405")))
+	}
+	if err := os.MkdirAll(filepath.Dir(*out), 0o700); err != nil {
+		return err
+	}
+	// The archive holds chat content in plain text, so only the owner may read it.
+	f, err := os.OpenFile(*out, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+	if err != nil {
+		return err
+	}
+	m, err := backup.Create(db, f, backup.Options{Paths: paths(), PasswordHashes: !*noHashes})
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(*out)
+		return err
+	}
+	fmt.Printf("[backup] ✅ wrote %s (schema %s)\n", *out, m.Schema)
+	for _, name := range []string{"users", "conversations", "messages", "event_registrations", "events", "uploads"} {
+		fmt.Printf("  %-20s %d\n", name, m.Counts[name])
+	}
+	return nil
+}
+
+func restore(db *gorm.DB, args []string) error {
+	if len(args) != 1 {
+		return errors.New(usage)
+	}
+	f, err := os.Open(args[0])
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	res, err := backup.Restore(db, f, paths())
+	if err != nil {
+		return err
+	}
+	fmt.Printf("[backup] ✅ restored %s from %s (schema %s)\n", args[0], res.Manifest.CreatedAt.Format(time.RFC3339), res.Manifest.Schema)
+	for _, name := range []string{"users", "conversations", "messages", "event_registrations", "events"} {
+		fmt.Printf("  %-20s %d\n", name, res.Restored[name])
+	}
+	if !res.Manifest.PasswordHashes {
+		fmt.Println("[backup] ⚠️ the archive has no password hashes: users can't sign in until their password is set again")
+	}
+	if len(res.MissingUploads) > 0 {
+		fmt.Printf("[backup] ⚠️ %d uploaded files are missing or changed; copy them into %s:\n", len(res.MissingUploads), paths().UploadsDir)
+		for _, p := range res.MissingUploads {
+			fmt.Println("  " + p)
+		}
+	}
+	return nil
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Println(usage)
+		os.Exit(2)
+	}
+	db, err := database.Open(&gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		fmt.Println("error:", err)
+		os.Exit(1)
+	}
+	// Sealed texts and titles are opened on create and sealed again with
+	// the restoring server's keys on restore.
+	if err := fieldcrypt.Configure(config.FieldEncryptionKeys); err != nil {
+		fmt.Println("error: FIELD_ENCRYPTION_KEYS:", err)
+		os.Exit(1)
+	}
+
+	switch os.Args[1] {
+	case "create":
+		err = create(db, os.Args[2:])
+	case "restore":
+		err = restore(db, os.Args[2:])
+	default:
+		err = errors.New(usage)
+	}
+	if err != nil {
+		fmt.Println("error:", err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+
+	"AkuAI/pkg/intenteval"
+	svc "AkuAI/pkg/services"
+)
+
+func envFloat(key string, def float64) float64 {
+	if v, err := strconv.ParseFloat(strings.TrimSpace(os.Getenv(key)), 64); err == nil {
+		return v
+	}
+	return def
+}
+
+func main() {
+	corpus := strings.TrimSpace(os.Getenv("INTENTEVAL_CORPUS"))
+	if corpus == "" {
+		corpus = intenteval.DefaultCorpus
+	}
+	minPrecision := envFloat("INTENTEVAL_MIN_PRECISION", 0)
+	minRecall := envFloat("INTENTEVAL_MIN_RECALL", 0)
+
+	cases, err := intenteval.Load(corpus)
+	if err != nil {
+		fmt.Println("error:", err)
+		os.Exit(1)
+	}
+	campuses, err := svc.NewCampusDataService()
+	if err != nil {
+		fmt.Println("error:", err)
+		os.Exit(1)
+	}
+
+	// The classifier logs its decisions; keep the report readable.
+	log.SetOutput(io.Discard)
+	report := intenteval.Evaluate(cases, func(q string) bool {
+		_, ds := campuses.ForQuery(q)
+		return ds != nil && ds.AnalyzeQueryForUIB(q)
+	})
+	log.SetOutput(os.Stderr)
+
+	fmt.Printf("[intenteval] %d queries from %s\n", report.N(), corpus)
+	fmt.Printf("precision=%.3f recall=%.3f f1=%.3f accuracy=%.3f (tp=%d fp=%d fn=%d tn=%d)\n",
+		report.Precision, report.Recall, report.F1, report.Accuracy, report.TP, report.FP, report.FN, report.TN)
+	for _, t := range report.ByTag {
+		fmt.Printf("  %-24s n=%-3d tp=%-3d fp=%-3d fn=%-3d tn=%-3d\n", t.Tag, t.N(), t.TP, t.FP, t.FN, t.TN)
+	}
+	for _, m := range report.Misses {
+		kind := "false negative"
+		if m.Got {
+			kind = "false positive"
+		}
+		fmt.Printf("  %s [%s] %q\n", kind, m.Tag, m.Query)
+	}
+
+	if path := strings.TrimSpace(os.Getenv("INTENTEVAL_JSON")); path != "" {
+		b, _ := json.MarshalIndent(report, "", "  ")
+		if err := os.WriteFile(path, b, 0o644); err != nil {
+			fmt.Println("error:", err)
+			os.Exit(1)
+		}
+		fmt.Println("[intenteval] report written to", path)
+	}
+
+	if report.Precision < minPrecision || report.Recall < minRecall {
+		fmt.Printf("[intenteval] FAIL: precision %.3f (min %.3f), recall %.3f (min %.3f)\n",
+			report.Precision, minPrecision, report.Recall, minRecall)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,212 @@
+package controllers
+
+import (
+	"AkuAI/middleware"
+	"AkuAI/models"
+	"AkuAI/pkg/apierror"
+	"AkuAI/pkg/audit"
+	"AkuAI/pkg/lockout"
+	"AkuAI/pkg/metrics"
+	"AkuAI/pkg/retention"
+	tokenstore "AkuAI/pkg/token"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+func slotSettings() gin.H {
+	conc, queueLen, maxWait := middleware.SlotQueueConfig()
+	return gin.H{
+		"concurrency":      conc,
+		"max_queue":        queueLen,
+		"max_wait_seconds": int(maxWait.Seconds()),
+	}
+}
+
+// GetSlotSettings returns the per-user concurrency and wait-queue limits.
+func GetSlotSettings() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.JSON(http.StatusOK, slotSettings())
+	}
+}
+
+// UpdateSlotSettings adjusts the per-user wait queue at runtime.
+func UpdateSlotSettings(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var body struct {
+			MaxQueue       *int `json:"max_queue"`
+			MaxWaitSeconds *int `json:"max_wait_seconds"`
+		}
+		if err := c.ShouldBindJSON(&body); err != nil {
+			apierror.Respond(c, http.StatusBadRequest, "invalid request")
+			return
+		}
+
+		before := slotSettings()
+		_, queueLen, maxWait := middleware.SlotQueueConfig()
+		if body.MaxQueue != nil {
+			if *body.MaxQueue < 0 {
+				apierror.Respond(c, http.StatusBadRequest, "max_queue must be >= 0")
+				return
+			}
+			queueLen = *body.MaxQueue
+		}
+		if body.MaxWaitSeconds != nil {
+			if *body.MaxWaitSeconds < 1 {
+				apierror.Respond(c, http.StatusBadRequest, "max_wait_seconds must be >= 1")
+				return
+			}
+			maxWait = time.Duration(*body.MaxWaitSeconds) * time.Second
+		}
+		middleware.SetSlotQueueConfig(queueLen, maxWait)
+		log.Printf("[admin] user=%s updated slot queue max_queue=%d max_wait=%v",
+			c.GetString(middleware.ContextUserIDKey), queueLen, maxWait)
+
+		after := slotSettings()
+		recordAudit(c, db, audit.Entry{Action: audit.ActionSlotsUpdate, TargetType: "settings", TargetID: "slots", Before: before, After: after})
+		c.JSON(http.StatusOK, after)
+	}
+}
+
+// GetRetention returns the active retention policy, the last run and recent audit events.
+func GetRetention(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		engine := retention.Default()
+		if engine == nil {
+			apierror.Respond(c, http.StatusServiceUnavailable, "retention engine not initialised")
+			return
+		}
+		var events []models.RetentionEvent
+		if err := db.Order("id DESC").Limit(100).Find(&events).Error; err != nil {
+			apierror.Respond(c, http.StatusInternalServerError, "db error")
+			return
+		}
+		p := engine.Policy()
+		c.JSON(http.StatusOK, gin.H{
+			"policy": gin.H{
+				"archive_after_days": int(p.ArchiveAfterInactive.Hours() / 24),
+				"delete_after_days":  int(p.DeleteAfter.Hours() / 24),
+				"trash_days":         int(p.TrashRetention.Hours() / 24),
+				"dry_run":            p.DryRun,
+			},
+			"last_run": engine.LastResult(),
+			"events":   events,
+		})
+	}
+}
+
+// RunRetention applies the retention policy now. ?dry_run=1 only records what would change.
+func RunRetention(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		engine := retention.Default()
+		if engine == nil {
+			apierror.Respond(c, http.StatusServiceUnavailable, "retention engine not initialised")
+			return
+		}
+		dryRun := c.Query("dry_run") == "1" || engine.Policy().DryRun
+		log.Printf("[admin] user=%s triggered retention run dryRun=%v", c.GetString(middleware.ContextUserIDKey), dryRun)
+		res, err := engine.Run(c.Request.Context(), dryRun)
+		recordAudit(c, db, audit.Entry{Action: audit.ActionRetentionRun, TargetType: "retention_run", TargetID: res.RunID, After: res})
+		if err != nil {
+			apierror.RespondDetails(c, http.StatusInternalServerError, "retention run failed: "+err.Error(), gin.H{"result": res})
+			return
+		}
+		c.JSON(http.StatusOK, res)
+	}
+}
+
+// ListLockouts returns the accounts and IPs with failed logins, locked ones
+// first.
+func ListLockouts() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"lockouts": lockout.Default().Active()})
+	}
+}
+
+// ResetLockout unlocks an account and/or IP and forgets their failed logins:
+// {"account": "<email>", "ip": "<address>"}.
+func ResetLockout(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var body struct {
+			Account string `json:"account"`
+			IP      string `json:"ip"`
+		}
+		if err := c.ShouldBindJSON(&body); err != nil || (body.Account == "" && body.IP == "") {
+			apierror.Respond(c, http.StatusBadRequest, "account or ip is required")
+			return
+		}
+		guard := lockout.Default()
+		reset := []string{}
+		for _, k := range [][2]string{{lockout.KindAccount, body.Account}, {lockout.KindIP, body.IP}} {
+			if k[1] == "" || !guard.Reset(k[0], k[1]) {
+				continue
+			}
+			reset = append(reset, k[0])
+			recordAudit(c, db, audit.Entry{Action: audit.ActionLockoutReset, TargetType: k[0], TargetID: k[1]})
+		}
+		if len(reset) == 0 {
+			apierror.Respond(c, http.StatusNotFound, "no failed logins recorded for that account or ip")
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"msg": "Lockout reset", "reset": reset})
+	}
+}
+
+// ListSigningKeys lists the JWT signing keys, JWKS style but without the
+// secrets: the current key and the retired ones that still verify tokens.
+func ListSigningKeys() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"keys": tokenstore.Keys().Keys()})
+	}
+}
+
+// RotateSigningKey signs new tokens with a fresh key. Tokens signed by the
+// previous key stay valid until they expire, so nobody is logged out.
+func RotateSigningKey(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		keys := tokenstore.Keys()
+		prev := keys.Keys()
+		key, err := keys.Rotate()
+		if err != nil {
+			log.Printf("[admin] ❌ JWT key rotation failed: %v", err)
+			apierror.Respond(c, http.StatusInternalServerError, "key rotation failed")
+			return
+		}
+		var before any
+		if len(prev) > 0 {
+			before = prev[0]
+		}
+		recordAudit(c, db, audit.Entry{Action: audit.ActionJWTKeyRotate, TargetType: "jwt_key", TargetID: key.KeyID, Before: before, After: key})
+		c.JSON(http.StatusOK, gin.H{"key": key, "keys": keys.Keys()})
+	}
+}
+
+// ListModerationEvents returns the most recent flagged or blocked messages;
+// tenant admins only see their users'. ?action=flag|block narrows the list.
+func ListModerationEvents(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		query := db.Order("id DESC").Limit(100)
+		if t := currentTenant(c); !t.IsOperator() {
+			query = query.Where("user_id IN (?)", db.Model(&models.User{}).Select("id").Where("tenant_id = ?", t.ID))
+		}
+		if action := c.Query("action"); action != "" {
+			query = query.Where("action = ?", action)
+		}
+		var events []models.ModerationEvent
+		if err := query.Find(&events).Error; err != nil {
+			apierror.Respond(c, http.StatusInternalServerError, "db error")
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"events": events})
+	}
+}
+
+// GetMetrics returns a snapshot of all registered metrics.
+func GetMetrics() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.JSON(http.StatusOK, metrics.Snapshot())
+	}
+}
@@ -0,0 +1,84 @@
+package controllers
+
+import (
+	"AkuAI/middleware"
+	"AkuAI/models"
+	"AkuAI/pkg/analytics"
+	"AkuAI/pkg/apierror"
+	svc "AkuAI/pkg/services"
+	"context"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+var (
+	labelerOnce sync.Once
+	labeler     *svc.GeminiService
+)
+
+// queryLabel classifies a user message before it is saved. The label is
+// cached, so the prompt routing for the same message reuses it.
+func queryLabel(ctx context.Context, text string) string {
+	labelerOnce.Do(func() { labeler = svc.NewGeminiService() })
+	return string(labeler.ClassifyQuery(ctx, text))
+}
+
+// analyticsSince reads ?days= (default 30, max 365).
+func analyticsSince(c *gin.Context) time.Time {
+	days, err := strconv.Atoi(c.DefaultQuery("days", "30"))
+	if err != nil || days < 1 {
+		days = 30
+	}
+	if days > 365 {
+		days = 365
+	}
+	now := time.Now()
+	return time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location()).AddDate(0, 0, -(days - 1))
+}
+
+// MyAnalytics reports the current user's activity, optionally for a single
+// conversation (?conversation_id=).
+func MyAnalytics(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		uid, _ := strconv.ParseUint(c.GetString(middleware.ContextUserIDKey), 10, 64)
+		scope := analytics.Scope{UserID: uint(uid), Since: analyticsSince(c)}
+
+		if cidStr := c.Query("conversation_id"); cidStr != "" {
+			cid, _ := strconv.ParseUint(cidStr, 10, 64)
+			var conv models.Conversation
+			if err := db.Where("id = ? AND user_id = ?", cid, uid).First(&conv).Error; err != nil {
+				apierror.Respond(c, http.StatusNotFound, "conversation not found")
+				return
+			}
+			scope.ConversationID = conv.ID
+		}
+
+		rep, err := analytics.Build(db, scope)
+		if err != nil {
+			apierror.Respond(c, http.StatusInternalServerError, "db error")
+			return
+		}
+		c.JSON(http.StatusOK, rep)
+	}
+}
+
+// GlobalAnalytics aggregates activity and topic distribution across all users.
+func GlobalAnalytics(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		scope := analytics.Scope{Since: analyticsSince(c)}
+		if t := currentTenant(c); !t.IsOperator() {
+			scope.Tenant = &t.ID
+		}
+		rep, err := analytics.Build(db, scope)
+		if err != nil {
+			apierror.Respond(c, http.StatusInternalServerError, "db error")
+			return
+		}
+		c.JSON(http.StatusOK, rep)
+	}
+}
@@ -0,0 +1,160 @@
+package controllers
+
+import (
+	"AkuAI/middleware"
+	"AkuAI/models"
+	"AkuAI/pkg/announce"
+	"AkuAI/pkg/apierror"
+	"AkuAI/pkg/audit"
+	"AkuAI/pkg/wshub"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+func announcementJSON(a models.Announcement) gin.H {
+	return gin.H(announce.Payload(a))
+}
+
+func announcementStatus(a models.Announcement, now time.Time) string {
+	switch {
+	case a.StartsAt.After(now):
+		return "scheduled"
+	case a.ExpiresAt != nil && !a.ExpiresAt.After(now):
+		return "expired"
+	}
+	return "active"
+}
+
+// userAnnouncements returns the announcements to show at the top of the
+// user's reply, marking them seen.
+func userAnnouncements(db *gorm.DB, uid uint) []gin.H {
+	list, err := announce.Pending(db, uid, time.Now())
+	if err != nil {
+		log.Printf("[announce] ⚠️ failed to load announcements for user %d: %v", uid, err)
+		return nil
+	}
+	out := make([]gin.H, 0, len(list))
+	for _, a := range list {
+		out = append(out, announcementJSON(a))
+	}
+	return out
+}
+
+// withAnnouncements adds the user's unseen announcements to a chat reply
+// payload.
+func withAnnouncements(db *gorm.DB, uid uint, payload gin.H) gin.H {
+	if list := userAnnouncements(db, uid); len(list) > 0 {
+		payload["announcements"] = list
+	}
+	return payload
+}
+
+// CreateAnnouncement publishes an announcement: {"body", "title",
+// "starts_at", "expires_at"} with RFC 3339 times. Without starts_at it starts
+// at once; without expires_at it never expires.
+func CreateAnnouncement(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		uid, _ := strconv.ParseUint(c.GetString(middleware.ContextUserIDKey), 10, 64)
+
+		var body struct {
+			Title     string     `json:"title"`
+			Body      string     `json:"body"`
+			StartsAt  *time.Time `json:"starts_at"`
+			ExpiresAt *time.Time `json:"expires_at"`
+		}
+		if err := c.ShouldBindJSON(&body); err != nil || strings.TrimSpace(body.Body) == "" {
+			apierror.Respond(c, http.StatusBadRequest, "body is required")
+			return
+		}
+		now := time.Now()
+		a := models.Announcement{
+			Title:     strings.TrimSpace(body.Title),
+			Body:      strings.TrimSpace(body.Body),
+			StartsAt:  now,
+			ExpiresAt: body.ExpiresAt,
+			CreatedBy: uint(uid),
+		}
+		if body.StartsAt != nil {
+			a.StartsAt = *body.StartsAt
+		}
+		if a.ExpiresAt != nil && !a.ExpiresAt.After(a.StartsAt) {
+			apierror.Respond(c, http.StatusBadRequest, "expires_at must be after starts_at")
+			return
+		}
+		if err := db.Create(&a).Error; err != nil {
+			apierror.Respond(c, http.StatusInternalServerError, "db error")
+			return
+		}
+		log.Printf("[announce] 📝 user %d created announcement %d starting %s", uid, a.ID, a.StartsAt.Format(time.RFC3339))
+		if !a.StartsAt.After(now) {
+			if _, err := announce.BroadcastDue(db, wshub.Default(), now); err != nil {
+				log.Printf("[announce] ❌ broadcast failed: %v", err)
+			}
+		}
+
+		out := announcementJSON(a)
+		out["status"] = announcementStatus(a, now)
+		recordAudit(c, db, audit.Entry{Action: audit.ActionAnnouncementCreate, TargetType: "announcement", TargetID: strconv.Itoa(int(a.ID)), After: out})
+		c.JSON(http.StatusCreated, gin.H{"announcement": out})
+	}
+}
+
+// ListAnnouncements returns every announcement, newest first, with its status
+// and how many users have seen it.
+func ListAnnouncements(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var list []models.Announcement
+		if err := db.Order("id DESC").Find(&list).Error; err != nil {
+			apierror.Respond(c, http.StatusInternalServerError, "db error")
+			return
+		}
+		var counts []struct {
+			AnnouncementID uint
+			N              int
+		}
+		db.Model(&models.AnnouncementReceipt{}).Select("announcement_id, COUNT(*) AS n").Group("announcement_id").Scan(&counts)
+		seen := make(map[uint]int, len(counts))
+		for _, ct := range counts {
+			seen[ct.AnnouncementID] = ct.N
+		}
+		now := time.Now()
+		out := make([]gin.H, 0, len(list))
+		for _, a := range list {
+			item := announcementJSON(a)
+			item["status"] = announcementStatus(a, now)
+			item["seen_by"] = seen[a.ID]
+			item["broadcast_at"] = a.BroadcastAt
+			out = append(out, item)
+		}
+		c.JSON(http.StatusOK, gin.H{"announcements": out})
+	}
+}
+
+// DeleteAnnouncement withdraws an announcement; users who have not seen it
+// yet no longer get it.
+func DeleteAnnouncement(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil || id <= 0 {
+			apierror.Respond(c, http.StatusBadRequest, "invalid id")
+			return
+		}
+		var a models.Announcement
+		if err := db.First(&a, id).Error; err != nil {
+			apierror.Respond(c, http.StatusNotFound, "announcement not found")
+			return
+		}
+		if err := db.Delete(&a).Error; err != nil {
+			apierror.Respond(c, http.StatusInternalServerError, "db error")
+			return
+		}
+		recordAudit(c, db, audit.Entry{Action: audit.ActionAnnouncementDelete, TargetType: "announcement", TargetID: strconv.Itoa(id), Before: announcementJSON(a)})
+		c.JSON(http.StatusOK, gin.H{"msg": "Announcement deleted"})
+	}
+}
@@ -0,0 +1,120 @@
+package controllers
+
+import (
+	"AkuAI/middleware"
+	"AkuAI/models"
+	"AkuAI/pkg/apierror"
+	"AkuAI/pkg/apikey"
+	"AkuAI/pkg/audit"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+func apiKeyJSON(k models.APIKey) gin.H {
+	return gin.H{
+		"id":           k.ID,
+		"name":         k.Name,
+		"prefix":       k.Prefix,
+		"scopes":       k.ScopeList(),
+		"created_at":   k.CreatedAt,
+		"expires_at":   k.ExpiresAt,
+		"revoked_at":   k.RevokedAt,
+		"last_used_at": k.LastUsedAt,
+		"active":       k.Active(time.Now()),
+	}
+}
+
+// CreateAPIKey issues a key for another campus system: {"name", "scopes",
+// "expires_at"}. The key itself is only returned in this response.
+func CreateAPIKey(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		uid, _ := strconv.ParseUint(c.GetString(middleware.ContextUserIDKey), 10, 64)
+
+		var body struct {
+			Name      string     `json:"name"`
+			Scopes    []string   `json:"scopes"`
+			ExpiresAt *time.Time `json:"expires_at"`
+		}
+		if err := c.ShouldBindJSON(&body); err != nil || strings.TrimSpace(body.Name) == "" {
+			apierror.Respond(c, http.StatusBadRequest, "name is required")
+			return
+		}
+		scopes, err := apikey.NormalizeScopes(body.Scopes)
+		if err != nil {
+			apierror.Respond(c, http.StatusBadRequest, err.Error())
+			return
+		}
+		if body.ExpiresAt != nil && !body.ExpiresAt.After(time.Now()) {
+			apierror.Respond(c, http.StatusBadRequest, "expires_at must be in the future")
+			return
+		}
+		key, prefix, hash, err := apikey.Generate()
+		if err != nil {
+			apierror.Respond(c, http.StatusInternalServerError, "failed to generate key")
+			return
+		}
+		k := models.APIKey{
+			Name:      strings.TrimSpace(body.Name),
+			Prefix:    prefix,
+			KeyHash:   hash,
+			Scopes:    scopes,
+			CreatedBy: uint(uid),
+			ExpiresAt: body.ExpiresAt,
+		}
+		if err := db.Create(&k).Error; err != nil {
+			apierror.Respond(c, http.StatusInternalServerError, "db error")
+			return
+		}
+		log.Printf("[apikey] 🔑 user %d issued key %d (%s) scopes=%s", uid, k.ID, k.Prefix, k.Scopes)
+
+		out := apiKeyJSON(k)
+		recordAudit(c, db, audit.Entry{Action: audit.ActionAPIKeyCreate, TargetType: "api_key", TargetID: strconv.Itoa(int(k.ID)), After: out})
+		out["key"] = key
+		c.JSON(http.StatusCreated, gin.H{"api_key": out, "msg": "Store the key now; it is not shown again"})
+	}
+}
+
+// ListAPIKeys returns every key, newest first, without the secrets.
+func ListAPIKeys(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var keys []models.APIKey
+		if err := db.Order("id DESC").Find(&keys).Error; err != nil {
+			apierror.Respond(c, http.StatusInternalServerError, "db error")
+			return
+		}
+		out := make([]gin.H, 0, len(keys))
+		for _, k := range keys {
+			out = append(out, apiKeyJSON(k))
+		}
+		c.JSON(http.StatusOK, gin.H{"api_keys": out, "scopes": apikey.KnownScopes()})
+	}
+}
+
+// RevokeAPIKey stops a key from working; it stays listed as revoked.
+func RevokeAPIKey(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil || id <= 0 {
+			apierror.Respond(c, http.StatusBadRequest, "invalid id")
+			return
+		}
+		res := db.Model(&models.APIKey{}).Where("id = ? AND revoked_at IS NULL", id).Update("revoked_at", time.Now())
+		if res.Error != nil {
+			apierror.Respond(c, http.StatusInternalServerError, "db error")
+			return
+		}
+		if res.RowsAffected == 0 {
+			apierror.Respond(c, http.StatusNotFound, "active API key not found")
+			return
+		}
+		log.Printf("[apikey] 🚫 key %d revoked", id)
+		recordAudit(c, db, audit.Entry{Action: audit.ActionAPIKeyRevoke, TargetType: "api_key", TargetID: strconv.Itoa(id)})
+		c.JSON(http.StatusOK, gin.H{"msg": "API key revoked"})
+	}
+}
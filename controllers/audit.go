@@ -0,0 +1,117 @@
+package controllers
+
+import (
+	"AkuAI/middleware"
+	"AkuAI/pkg/apierror"
+	"AkuAI/pkg/audit"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// recordAudit stores e with the signed-in user as actor (unless e names one)
+// and the client's IP and user agent.
+func recordAudit(c *gin.Context, db *gorm.DB, e audit.Entry) {
+	if e.ActorID == 0 {
+		uid, _ := strconv.ParseUint(c.GetString(middleware.ContextUserIDKey), 10, 64)
+		e.ActorID = uint(uid)
+	}
+	e.IP = c.ClientIP()
+	e.UserAgent = c.Request.UserAgent()
+	audit.Record(db, e)
+}
+
+// auditTime parses an RFC 3339 time or a YYYY-MM-DD date.
+func auditTime(v string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, v); err == nil {
+		return t, nil
+	}
+	return time.Parse("2006-01-02", v)
+}
+
+// ListAuditLogs returns audit entries, newest first. Filters: ?action= (exact,
+// or a prefix ending in "." such as "admin."), ?actor_id=, ?target_type=,
+// ?target_id=, ?from= and ?to= (RFC 3339 or YYYY-MM-DD, to exclusive).
+// ?limit= (default 100, at most 500) and ?before=<next_before> page through.
+func ListAuditLogs(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		f := audit.Filter{
+			Action:     c.Query("action"),
+			TargetType: c.Query("target_type"),
+			TargetID:   c.Query("target_id"),
+			Limit:      100,
+		}
+		for _, p := range []struct {
+			name string
+			dst  *uint
+		}{{"actor_id", &f.ActorID}, {"before", &f.BeforeID}} {
+			if v := c.Query(p.name); v != "" {
+				n, err := strconv.ParseUint(v, 10, 64)
+				if err != nil {
+					apierror.Respond(c, http.StatusBadRequest, p.name+" must be a positive integer")
+					return
+				}
+				*p.dst = uint(n)
+			}
+		}
+		for _, p := range []struct {
+			name string
+			dst  *time.Time
+		}{{"from", &f.From}, {"to", &f.To}} {
+			if v := c.Query(p.name); v != "" {
+				t, err := auditTime(v)
+				if err != nil {
+					apierror.Respond(c, http.StatusBadRequest, p.name+" must be RFC 3339 or YYYY-MM-DD")
+					return
+				}
+				*p.dst = t
+			}
+		}
+		if v := c.Query("limit"); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil || n < 1 || n > 500 {
+				apierror.Respond(c, http.StatusBadRequest, "limit must be between 1 and 500")
+				return
+			}
+			f.Limit = n
+		}
+
+		logs, err := audit.Query(db, f)
+		if err != nil {
+			apierror.Respond(c, http.StatusInternalServerError, "db error")
+			return
+		}
+		out := make([]gin.H, 0, len(logs))
+		for _, l := range logs {
+			out = append(out, gin.H{
+				"id":          l.ID,
+				"action":      l.Action,
+				"actor_id":    l.ActorID,
+				"target_type": l.TargetType,
+				"target_id":   l.TargetID,
+				"ip":          l.IP,
+				"user_agent":  l.UserAgent,
+				"before":      rawSnapshot(l.Before),
+				"after":       rawSnapshot(l.After),
+				"created_at":  l.CreatedAt,
+			})
+		}
+		var next any
+		if len(logs) == f.Limit {
+			next = logs[len(logs)-1].ID
+		}
+		c.JSON(http.StatusOK, gin.H{"entries": out, "next_before": next})
+	}
+}
+
+// rawSnapshot embeds a stored JSON snapshot as-is; "" becomes null.
+func rawSnapshot(s string) json.RawMessage {
+	if s == "" {
+		return nil
+	}
+	return json.RawMessage(s)
+}
@@ -3,72 +3,60 @@ package controllers
 import (
 	"AkuAI/middleware"
 	"AkuAI/models"
-	"AkuAI/pkg/config"
+	"AkuAI/pkg/apierror"
+	"AkuAI/pkg/audit"
+	"AkuAI/pkg/lockout"
+	"AkuAI/pkg/retention"
 	tokenstore "AkuAI/pkg/token"
-	utils "AkuAI/pkg/utills"
+	"fmt"
+	"log"
+	"math"
 	"net/http"
 	"strconv"
 	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
-	"github.com/golang-jwt/jwt/v5"
-	"github.com/google/uuid"
 	"gorm.io/gorm"
 )
 
 func Register(db *gorm.DB) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		var body struct {
-			Email           string `json:"email"`
-			Username        string `json:"username"`
-			Password        string `json:"password"`
-			ConfirmPassword string `json:"confirm_password"`
+			Email           string `json:"email" binding:"required,email,max=120"`
+			Username        string `json:"username" binding:"notblank,max=80"`
+			Password        string `json:"password" binding:"required,password"`
+			ConfirmPassword string `json:"confirm_password" binding:"required,eqfield=Password"`
 		}
-		if err := c.ShouldBindJSON(&body); err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"msg": "invalid request"})
+		if !apierror.BindJSON(c, &body) {
 			return
 		}
 
 		email := strings.TrimSpace(strings.ToLower(body.Email))
 		username := strings.TrimSpace(body.Username)
 		password := body.Password
-		confirm := body.ConfirmPassword
-
-		if email == "" || username == "" || password == "" || confirm == "" {
-			c.JSON(http.StatusBadRequest, gin.H{"msg": "Email, username, password, and confirm password are required"})
-			return
-		}
-
-		if password != confirm {
-			c.JSON(http.StatusBadRequest, gin.H{"msg": "Passwords do not match"})
-			return
-		}
-
-		if !utils.HasLetter(password) || !utils.HasNumber(password) {
-			c.JSON(http.StatusBadRequest, gin.H{"msg": "Password must contain at least one letter and one number"})
-			return
-		}
 
+		tenantID := currentTenant(c).ID
 		var exists models.User
-		if err := db.Where("email = ? OR username = ?", email, username).First(&exists).Error; err == nil {
-			c.JSON(http.StatusConflict, gin.H{"msg": "Email or username already exists"})
+		if err := db.Where("tenant_id = ? AND (email = ? OR username = ?)", tenantID, email, username).First(&exists).Error; err == nil {
+			apierror.Respond(c, http.StatusConflict, "Email or username already exists")
 			return
 		} else if err != gorm.ErrRecordNotFound {
-			c.JSON(http.StatusInternalServerError, gin.H{"msg": "db error"})
+			apierror.Respond(c, http.StatusInternalServerError, "db error")
 			return
 		}
 
 		user := models.User{
+			TenantID: tenantID,
 			Email:    email,
 			Username: username,
 		}
 		if err := user.SetPassword(password); err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"msg": "failed to set password"})
+			apierror.Respond(c, http.StatusInternalServerError, "failed to set password")
 			return
 		}
 		if err := db.Create(&user).Error; err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"msg": "failed to create user"})
+			apierror.Respond(c, http.StatusInternalServerError, "failed to create user")
 			return
 		}
 
@@ -79,55 +67,82 @@ func Register(db *gorm.DB) gin.HandlerFunc {
 func Login(db *gorm.DB) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		var body struct {
-			Email    string `json:"email"`
-			Password string `json:"password"`
+			Email        string `json:"email" binding:"notblank"`
+			Password     string `json:"password" binding:"required"`
+			CaptchaToken string `json:"captcha_token"`
 		}
-		if err := c.ShouldBindJSON(&body); err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"msg": "invalid request"})
+		if !apierror.BindJSON(c, &body) {
 			return
 		}
 		email := strings.TrimSpace(strings.ToLower(body.Email))
 		password := body.Password
-
-		if email == "" || password == "" {
-			c.JSON(http.StatusBadRequest, gin.H{"msg": "Email and password are required"})
+		tenantID := currentTenant(c).ID
+		// The same email may sign up with several tenants; each account
+		// locks on its own.
+		account := email
+		if tenantID != "" {
+			account = tenantID + "/" + email
+		}
+
+		guard := lockout.Default()
+		ip := c.ClientIP()
+		if wait := guard.Locked(account, ip); wait > 0 {
+			secs := int(math.Ceil(wait.Seconds()))
+			c.Header("Retry-After", strconv.Itoa(secs))
+			apierror.RespondCode(c, http.StatusTooManyRequests, apierror.CodeAccountLocked,
+				fmt.Sprintf("Too many failed logins, try again in %d seconds", secs))
 			return
 		}
-
-		var user models.User
-		if err := db.Where("email = ?", email).First(&user).Error; err != nil {
-			c.JSON(http.StatusUnauthorized, gin.H{"msg": "Invalid credentials"})
-			return
+		if guard.CaptchaRequired(account) {
+			ok, err := guard.VerifyCaptcha(c.Request.Context(), body.CaptchaToken, ip)
+			if err != nil {
+				log.Printf("[auth] ⚠️ CAPTCHA verification failed: %v", err)
+			}
+			if !ok {
+				apierror.RespondCode(c, http.StatusUnauthorized, apierror.CodeCaptchaRequired, "Complete the CAPTCHA to log in")
+				return
+			}
 		}
 
-		if !user.CheckPassword(password) {
-			c.JSON(http.StatusUnauthorized, gin.H{"msg": "Invalid credentials"})
+		var user models.User
+		if err := db.Where("tenant_id = ? AND email = ?", tenantID, email).First(&user).Error; err != nil || !user.CheckPassword(password) {
+			e := audit.Entry{Action: audit.ActionLoginFailed, After: gin.H{"email": email}}
+			if user.ID != 0 {
+				e.TargetType, e.TargetID = "user", strconv.Itoa(int(user.ID))
+			}
+			recordAudit(c, db, e)
+			for _, l := range guard.Fail(account, ip) {
+				log.Printf("[auth] 🔒 %s %s locked until %s", l.Kind, l.Key, l.LockedUntil.Format(time.RFC3339))
+				recordAudit(c, db, audit.Entry{Action: audit.ActionLoginLockout, TargetType: l.Kind, TargetID: l.Key, After: l})
+			}
+			apierror.RespondDetails(c, http.StatusUnauthorized, "Invalid credentials", gin.H{"captcha_required": guard.CaptchaRequired(account)})
 			return
 		}
+		guard.Success(account)
 
-		jti := uuid.NewString()
-		claims := jwt.MapClaims{
-			"sub": strconv.Itoa(int(user.ID)),
-			"exp": time.Now().Add(24 * time.Hour).Unix(),
-			"jti": jti,
-		}
-		token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-		tokenStr, err := token.SignedString([]byte(config.JWTSecret))
+		tokenStr, claims, err := tokenstore.Keys().IssueFor(strconv.Itoa(int(user.ID)), tenantID)
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"msg": "failed to create token"})
+			apierror.Respond(c, http.StatusInternalServerError, "failed to create token")
 			return
 		}
+		recordAudit(c, db, audit.Entry{Action: audit.ActionLogin, ActorID: user.ID, TargetType: "user", TargetID: strconv.Itoa(int(user.ID))})
 
-		c.JSON(http.StatusOK, gin.H{"access_token": tokenStr, "username": user.Username})
+		c.JSON(http.StatusOK, gin.H{"access_token": tokenStr, "username": user.Username, "expires_at": claims.ExpiresAt})
 	}
 }
 
-func Logout() gin.HandlerFunc {
+func Logout(db *gorm.DB) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		jti, _ := c.Get(middleware.ContextJTIKey)
 		if s, ok := jti.(string); ok && s != "" {
 			tokenstore.RevokeToken(s)
 		}
+		if n, err := retention.PurgeIncognito(db, currentUserID(c)); err != nil {
+			log.Printf("[auth] ⚠️ failed to purge incognito conversations of user %d: %v", currentUserID(c), err)
+		} else if n > 0 {
+			log.Printf("[auth] 🕶️ purged %d incognito conversations of user %d", n, currentUserID(c))
+		}
+		recordAudit(c, db, audit.Entry{Action: audit.ActionLogout, TargetType: "user", TargetID: c.GetString(middleware.ContextUserIDKey)})
 		c.JSON(http.StatusOK, gin.H{"msg": "logged out"})
 	}
 }
@@ -0,0 +1,268 @@
+package controllers
+
+import (
+	"AkuAI/models"
+	"AkuAI/pkg/apierror"
+	"errors"
+	"net/http"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// ownBotMessage loads bot message :message_id of the signed-in user's
+// conversation :conversation_id, answering 404 otherwise.
+func ownBotMessage(c *gin.Context, db *gorm.DB) (models.Message, bool) {
+	var msg models.Message
+	err := db.Joins("JOIN conversations ON conversations.id = messages.conversation_id AND conversations.deleted_at IS NULL").
+		Where("messages.id = ? AND messages.conversation_id = ? AND messages.sender = ? AND conversations.user_id = ?",
+			c.Param("message_id"), c.Param("conversation_id"), "bot", currentUserID(c)).
+		First(&msg).Error
+	if err != nil {
+		apierror.Respond(c, http.StatusNotFound, "message not found")
+		return msg, false
+	}
+	return msg, true
+}
+
+// validEmoji accepts a single emoji, including skin tones, flags and
+// ZWJ sequences, but not text.
+func validEmoji(s string) bool {
+	if s == "" || len(s) > 32 || utf8.RuneCountInString(s) > 8 {
+		return false
+	}
+	first, _ := utf8.DecodeRuneInString(s)
+	if !unicode.Is(unicode.So, first) && !unicode.Is(unicode.Regional_Indicator, first) {
+		return false
+	}
+	for _, r := range s {
+		if r < 0x80 || unicode.IsSpace(r) {
+			return false
+		}
+	}
+	return true
+}
+
+func messageReactions(db *gorm.DB, uid, messageID uint) []string {
+	emoji := make([]string, 0)
+	db.Model(&models.MessageReaction{}).Where("user_id = ? AND message_id = ?", uid, messageID).
+		Order("id").Pluck("emoji", &emoji)
+	return emoji
+}
+
+// markMessages adds whether uid bookmarked each message, and uid's
+// reactions, to rendered messages.
+func markMessages(db *gorm.DB, uid uint, msgs []gin.H) {
+	if len(msgs) == 0 {
+		return
+	}
+	ids := make([]uint, 0, len(msgs))
+	for _, m := range msgs {
+		ids = append(ids, m["id"].(uint))
+	}
+	var marked []uint
+	db.Model(&models.MessageBookmark{}).Where("user_id = ? AND message_id IN ?", uid, ids).Pluck("message_id", &marked)
+	bookmarked := make(map[uint]bool, len(marked))
+	for _, id := range marked {
+		bookmarked[id] = true
+	}
+	var reactions []models.MessageReaction
+	db.Where("user_id = ? AND message_id IN ?", uid, ids).Order("id").Find(&reactions)
+	byMessage := make(map[uint][]string)
+	for _, r := range reactions {
+		byMessage[r.MessageID] = append(byMessage[r.MessageID], r.Emoji)
+	}
+	for _, m := range msgs {
+		id := m["id"].(uint)
+		m["bookmarked"] = bookmarked[id]
+		m["reactions"] = append([]string{}, byMessage[id]...)
+	}
+}
+
+// BookmarkMessage saves a bot reply to the signed-in user's bookmarks, with
+// an optional {"note"}. Bookmarking again updates the note.
+func BookmarkMessage(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var body struct {
+			Note string `json:"note" binding:"max=500"`
+		}
+		if c.Request.ContentLength != 0 && !apierror.BindJSON(c, &body) {
+			return
+		}
+		msg, ok := ownBotMessage(c, db)
+		if !ok {
+			return
+		}
+		uid := currentUserID(c)
+		b := models.MessageBookmark{UserID: uid, MessageID: msg.ID}
+		err := db.Where(&b).First(&b).Error
+		switch {
+		case err == nil:
+			err = db.Model(&b).Update("note", strings.TrimSpace(body.Note)).Error
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			b.Note = strings.TrimSpace(body.Note)
+			err = db.Create(&b).Error
+		}
+		if err != nil {
+			apierror.Respond(c, http.StatusInternalServerError, "failed to save bookmark")
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"id": b.ID, "message_id": msg.ID, "bookmarked": true, "note": b.Note})
+	}
+}
+
+// UnbookmarkMessage removes a bot reply from the signed-in user's bookmarks.
+func UnbookmarkMessage(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		msg, ok := ownBotMessage(c, db)
+		if !ok {
+			return
+		}
+		if err := db.Where("user_id = ? AND message_id = ?", currentUserID(c), msg.ID).Delete(&models.MessageBookmark{}).Error; err != nil {
+			apierror.Respond(c, http.StatusInternalServerError, "failed to remove bookmark")
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"message_id": msg.ID, "bookmarked": false})
+	}
+}
+
+// AddReaction puts an emoji {"emoji": "👍"} on a bot reply; adding the same
+// emoji twice keeps one.
+func AddReaction(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var body struct {
+			Emoji string `json:"emoji" binding:"notblank"`
+		}
+		if !apierror.BindJSON(c, &body) {
+			return
+		}
+		emoji := strings.TrimSpace(body.Emoji)
+		if !validEmoji(emoji) {
+			apierror.Respond(c, http.StatusBadRequest, "emoji must be a single emoji")
+			return
+		}
+		msg, ok := ownBotMessage(c, db)
+		if !ok {
+			return
+		}
+		uid := currentUserID(c)
+		r := models.MessageReaction{UserID: uid, MessageID: msg.ID, Emoji: emoji}
+		if err := db.Where(&r).FirstOrCreate(&r).Error; err != nil {
+			apierror.Respond(c, http.StatusInternalServerError, "failed to save reaction")
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"message_id": msg.ID, "reactions": messageReactions(db, uid, msg.ID)})
+	}
+}
+
+// RemoveReaction takes the emoji :emoji off a bot reply.
+func RemoveReaction(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		msg, ok := ownBotMessage(c, db)
+		if !ok {
+			return
+		}
+		uid := currentUserID(c)
+		if err := db.Where("user_id = ? AND message_id = ? AND emoji = ?", uid, msg.ID, c.Param("emoji")).
+			Delete(&models.MessageReaction{}).Error; err != nil {
+			apierror.Respond(c, http.StatusInternalServerError, "failed to remove reaction")
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"message_id": msg.ID, "reactions": messageReactions(db, uid, msg.ID)})
+	}
+}
+
+// ListBookmarks returns the signed-in user's bookmarks across conversations,
+// newest first. ?q= searches the reply, the note and the conversation
+// title, in Go when they are sealed; ?limit= and ?before=<next_before>
+// page through.
+func ListBookmarks(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		uid := currentUserID(c)
+		limit, before, ok := parseMessagePage(c)
+		if !ok {
+			return
+		}
+		q := db.Model(&models.MessageBookmark{}).
+			Joins("JOIN messages ON messages.id = message_bookmarks.message_id AND messages.deleted_at IS NULL").
+			Joins("JOIN conversations ON conversations.id = messages.conversation_id AND conversations.deleted_at IS NULL").
+			Where("message_bookmarks.user_id = ? AND conversations.user_id = ?", uid, uid)
+		s := strings.ToLower(strings.TrimSpace(c.Query("q")))
+		if s != "" && !searchInApp() {
+			like := "%" + s + "%"
+			q = q.Where("LOWER(messages.text) LIKE ? OR LOWER(message_bookmarks.note) LIKE ? OR LOWER(conversations.title) LIKE ?", like, like, like)
+		}
+		if before > 0 {
+			q = q.Where("message_bookmarks.id < ?", before)
+		}
+		var rows []struct {
+			models.MessageBookmark
+			ConversationID    uint
+			ConversationTitle string `gorm:"serializer:encrypted"`
+			MessageText       string `gorm:"serializer:encrypted"`
+		}
+		q = q.Select("message_bookmarks.*, conversations.id AS conversation_id, conversations.title AS conversation_title, messages.text AS message_text").
+			Order("message_bookmarks.id DESC")
+		if s == "" || !searchInApp() {
+			q = q.Limit(limit + 1)
+		}
+		if err := q.Scan(&rows).Error; err != nil {
+			apierror.Respond(c, http.StatusInternalServerError, "db error")
+			return
+		}
+		if s != "" && searchInApp() {
+			// The user's bookmarks are opened and matched here; the page is
+			// cut from the matches.
+			kept := rows[:0]
+			for _, r := range rows {
+				if containsFold(r.MessageText, s) || containsFold(r.Note, s) || containsFold(r.ConversationTitle, s) {
+					kept = append(kept, r)
+					if len(kept) > limit {
+						break
+					}
+				}
+			}
+			rows = kept
+		}
+		hasMore := len(rows) > limit
+		if hasMore {
+			rows = rows[:limit]
+		}
+
+		ids := make([]uint, 0, len(rows))
+		for _, r := range rows {
+			ids = append(ids, r.MessageID)
+		}
+		var msgs []models.Message
+		if len(ids) > 0 {
+			if err := db.Preload("Citations").Where("id IN ?", ids).Find(&msgs).Error; err != nil {
+				apierror.Respond(c, http.StatusInternalServerError, "db error")
+				return
+			}
+		}
+		byID := make(map[uint]models.Message, len(msgs))
+		for _, m := range msgs {
+			byID[m.ID] = m
+		}
+
+		out := make([]gin.H, 0, len(rows))
+		for _, r := range rows {
+			out = append(out, gin.H{
+				"id":                 r.ID,
+				"note":               r.Note,
+				"created_at":         r.CreatedAt,
+				"conversation_id":    r.ConversationID,
+				"conversation_title": r.ConversationTitle,
+				"message":            messageJSON(byID[r.MessageID]),
+			})
+		}
+		var next any
+		if hasMore && len(rows) > 0 {
+			next = rows[len(rows)-1].ID
+		}
+		c.JSON(http.StatusOK, gin.H{"bookmarks": out, "has_more": hasMore, "next_before": next})
+	}
+}
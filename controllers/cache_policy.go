@@ -0,0 +1,124 @@
+package controllers
+
+import (
+	"AkuAI/pkg/cache"
+	"AkuAI/pkg/config"
+	"AkuAI/pkg/metrics"
+	svc "AkuAI/pkg/services"
+	"AkuAI/pkg/tenant"
+	"context"
+	"slices"
+	"strings"
+	"unicode"
+)
+
+// Scopes of chat cache keys: a reply is shared by every user or kept to the
+// one who asked.
+const (
+	cacheScopeGlobal = "global"
+	cacheScopeUser   = "user"
+)
+
+var (
+	chatCacheHits = map[string]*metrics.Counter{
+		cacheScopeGlobal: metrics.NewCounter("chat_cache_global_hits_total"),
+		cacheScopeUser:   metrics.NewCounter("chat_cache_user_hits_total"),
+	}
+	chatCacheMisses = map[string]*metrics.Counter{
+		cacheScopeGlobal: metrics.NewCounter("chat_cache_global_misses_total"),
+		cacheScopeUser:   metrics.NewCounter("chat_cache_user_misses_total"),
+	}
+)
+
+func init() {
+	metrics.RegisterFunc("chat_cache_policy", func() any {
+		rates := map[string]any{"policy": config.ChatCacheKeyPolicy}
+		for _, scope := range []string{cacheScopeGlobal, cacheScopeUser} {
+			hits, misses := chatCacheHits[scope].Value(), chatCacheMisses[scope].Value()
+			rate := 0.0
+			if hits+misses > 0 {
+				rate = float64(hits) / float64(hits+misses)
+			}
+			rates[scope] = map[string]any{"hits": hits, "misses": misses, "hit_rate": rate}
+		}
+		return rates
+	})
+}
+
+// personalWords mark questions about the asker ("jadwal saya"), whose
+// answers aren't shared even on a factual topic.
+var personalWords = map[string]bool{
+	"saya": true, "aku": true, "ku": true, "gue": true, "gua": true, "gw": true, "sy": true,
+	"my": true, "me": true, "mine": true,
+}
+
+func personalQuery(message string) bool {
+	for _, w := range strings.FieldsFunc(strings.ToLower(message), func(r rune) bool { return !unicode.IsLetter(r) }) {
+		if personalWords[w] {
+			return true
+		}
+	}
+	return false
+}
+
+// chatCacheScope picks the scope of the reply to message under
+// CHAT_CACHE_KEY_POLICY. With "topic", first-turn questions the topic
+// classifier puts on a CHAT_CACHE_GLOBAL_TOPICS topic are global unless they
+// are about the asker or the prompt carries their memory or reply
+// preferences; everything else is per user.
+func chatCacheScope(ctx context.Context, message string, history []svc.ChatMessage) string {
+	if config.ChatCacheKeyPolicy != "topic" || len(history) > 1 || svc.HasUserMemory(ctx) || svc.PreferencesKey(ctx) != "" || personalQuery(message) {
+		return cacheScopeUser
+	}
+	label := svc.NewGeminiServiceFor(ctx).ClassifyQuery(ctx, message)
+	if !slices.Contains(config.ChatCacheGlobalTopics, string(label)) {
+		return cacheScopeUser
+	}
+	return cacheScopeGlobal
+}
+
+// skipReplyCaches reports whether a reply must neither be served from nor
+// stored in the reply caches: one generated under a generation override or
+// in an incognito conversation.
+func skipReplyCaches(ctx context.Context) bool {
+	return svc.HasGenerationOverride(ctx) || svc.IsIncognito(ctx)
+}
+
+// chatCacheKey is the reply cache key of message in scope; per-user keys
+// include uidStr and the reply preferences in ctx, if not the defaults, and
+// global keys the tenant, whose event data and branding may differ.
+func chatCacheKey(ctx context.Context, prefix, scope, uidStr, message string) string {
+	if scope == cacheScopeGlobal {
+		if id := tenant.ID(ctx); id != "" {
+			return cache.NamespacedKey(prefix, scope, id, message)
+		}
+		return cache.NamespacedKey(prefix, scope, message)
+	}
+	if prefs := svc.PreferencesKey(ctx); prefs != "" {
+		return cache.NamespacedKey(prefix, uidStr, prefs, message)
+	}
+	return cache.NamespacedKey(prefix, uidStr, message)
+}
+
+// chatCacheLookup returns the cached reply under key and counts the hit or
+// miss for scope.
+func chatCacheLookup(key, scope string) (string, *cache.CachedResponse, bool) {
+	text, ok, info := cache.Default().GetChatResponseWithInfo(key)
+	if ok {
+		chatCacheHits[scope].Inc()
+	} else {
+		chatCacheMisses[scope].Inc()
+	}
+	return text, info, ok
+}
+
+// chatCached reports whether a reply to message is cached in either scope,
+// before the scope of the request is known.
+func chatCached(ctx context.Context, prefix, uidStr, message string) bool {
+	for _, scope := range []string{cacheScopeUser, cacheScopeGlobal} {
+		if _, ok := cache.Default().GetChatResponse(chatCacheKey(ctx, prefix, scope, uidStr, message)); ok {
+			return true
+		}
+	}
+	return false
+}
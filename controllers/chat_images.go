@@ -0,0 +1,158 @@
+package controllers
+
+import (
+	"AkuAI/pkg/config"
+	svc "AkuAI/pkg/services"
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// imageEmitter writes one image event to the client (SSE or WebSocket).
+type imageEmitter func(event string, payload gin.H)
+
+// chatImageIntent decides whether a chat turn should be followed by an image
+// search: either the client asked (request_images) or the message does.
+func chatImageIntent(ctx context.Context, gsvc *svc.GeminiService, message string, requested bool) (svc.ImageIntent, string, bool) {
+	intent := svc.ImageIntent{}
+	if config.ImageIntentEnabled {
+		intent = gsvc.DetectImageIntent(ctx, message)
+	}
+	switch {
+	case requested:
+		return intent, "request", true
+	case intent.Requested:
+		return intent, "intent", true
+	default:
+		return intent, "", false
+	}
+}
+
+// imageSearchTerm picks the search term from the user's own words, adding the
+// university discussed in the conversation when the term does not name one.
+func imageSearchTerm(intent svc.ImageIntent, userMessage, detectedUniversity string) string {
+	source := intent.Term
+	if strings.TrimSpace(source) == "" {
+		source = userMessage
+	}
+	term := svc.ExtractImageSearchTerm(source)
+	switch {
+	case term == "":
+		term = detectedUniversity
+	case detectedUniversity != "" && svc.ExtractUniversityName(term) == "" &&
+		!strings.Contains(strings.ToLower(term), strings.ToLower(detectedUniversity)):
+		term = detectedUniversity + " " + term
+	}
+	if term == "" {
+		term = "kampus"
+	}
+	return term
+}
+
+// streamChatImages searches images after a chat reply and emits the
+// images_* progress events followed by a single image_results event.
+func streamChatImages(ctx, searchParent context.Context, gsvc *svc.GeminiService, history []svc.ChatMessage, botText string,
+	intent svc.ImageIntent, trigger, logTag string, emit imageEmitter) {
+	detectedUniversity := ""
+	if du, err := gsvc.DetectUniversityName(ctx, history, botText); err != nil {
+		log.Printf("[%s] ⚠️ failed to detect university: %v", logTag, err)
+	} else {
+		detectedUniversity = strings.TrimSpace(du)
+	}
+
+	userMessage := ""
+	if len(history) > 0 {
+		userMessage = history[len(history)-1].Text
+	}
+	primaryQuery := imageSearchTerm(intent, userMessage, detectedUniversity)
+
+	messageText := "Mencari gambar kampus..."
+	if primaryQuery != "kampus" {
+		messageText = fmt.Sprintf("Mencari gambar %s...", primaryQuery)
+	}
+
+	emit("images_searching", gin.H{
+		"message":             messageText,
+		"query":               primaryQuery,
+		"detected_university": detectedUniversity,
+		"trigger":             trigger,
+	})
+
+	results := func(status string, images []svc.ImageSearchResult, query string) {
+		emit("image_results", gin.H{
+			"status":  status,
+			"images":  images,
+			"count":   len(images),
+			"query":   query,
+			"trigger": trigger,
+		})
+	}
+
+	imageService := svc.NewGoogleImageService()
+	if !imageService.IsEnabled() {
+		emit("images_disabled", gin.H{
+			"message": "Fitur pencarian gambar belum dikonfigurasi",
+			"query":   primaryQuery,
+		})
+		results("disabled", nil, primaryQuery)
+		return
+	}
+
+	searchCtx, searchCancel := context.WithTimeout(searchParent, 30*time.Second)
+	defer searchCancel()
+
+	activeQuery := primaryQuery
+	fallbackUsed := false
+
+	images, err := imageService.SearchImagesForChat(searchCtx, primaryQuery)
+	if (err != nil || len(images) == 0) && primaryQuery != "kampus" {
+		fallbackUsed = true
+		log.Printf("[%s] ℹ️ primary image query '%s' returned err=%v, attempting fallback 'kampus'", logTag, primaryQuery, err)
+		if fallbackImages, fallbackErr := imageService.SearchImagesForChat(searchCtx, "kampus"); fallbackErr == nil && len(fallbackImages) > 0 {
+			images = fallbackImages
+			err = nil
+			activeQuery = "kampus"
+		} else {
+			if fallbackErr != nil {
+				err = fallbackErr
+			}
+			images = fallbackImages
+			activeQuery = "kampus"
+		}
+	}
+
+	switch {
+	case err != nil:
+		emit("images_error", gin.H{
+			"error":               fmt.Sprintf("Gagal mencari gambar untuk '%s': %v", activeQuery, err),
+			"query":               activeQuery,
+			"primary_query":       primaryQuery,
+			"detected_university": detectedUniversity,
+			"fallback":            fallbackUsed,
+		})
+		results("error", nil, activeQuery)
+	case len(images) > 0:
+		emit("images_found", gin.H{
+			"images":              images,
+			"count":               len(images),
+			"query":               activeQuery,
+			"primary_query":       primaryQuery,
+			"detected_university": detectedUniversity,
+			"fallback":            fallbackUsed,
+		})
+		results("found", images, activeQuery)
+	default:
+		emit("images_empty", gin.H{
+			"message":             fmt.Sprintf("Tidak ada gambar ditemukan untuk '%s'", activeQuery),
+			"query":               activeQuery,
+			"primary_query":       primaryQuery,
+			"detected_university": detectedUniversity,
+			"fallback":            fallbackUsed,
+		})
+		results("empty", nil, activeQuery)
+	}
+}
@@ -0,0 +1,139 @@
+package controllers
+
+import (
+	"AkuAI/models"
+	svc "AkuAI/pkg/services"
+	"AkuAI/pkg/webhook"
+	"context"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// saveBotMessage stores a bot reply together with the event and document
+// citations referenced by its [EV-xxx] and [DOC-x-y] markers and its confidence score. Low-confidence
+// replies are saved with the uncertainty disclaimer prepended. Replies in
+// incognito conversations send no message.completed webhook.
+func saveBotMessage(ctx context.Context, db *gorm.DB, convID uint, question, text, mode string, info *svc.GenerationInfo) (models.Message, error) {
+	return saveBotMessageWithStatus(ctx, db, convID, question, text, mode, models.MessageCompleted, info)
+}
+
+// saveBotMessageWithStatus is saveBotMessage for replies that may have been
+// cut short (models.MessageStopped, models.MessageError).
+func saveBotMessageWithStatus(ctx context.Context, db *gorm.DB, convID uint, question, text, mode, status string, info *svc.GenerationInfo) (models.Message, error) {
+	msg := buildBotMessage(ctx, convID, question, text, mode, info)
+	msg.Status = status
+	if err := db.Create(&msg).Error; err != nil {
+		return msg, err
+	}
+	if status == models.MessageCompleted {
+		var conv models.Conversation
+		if err := db.Select("id", "user_id", "incognito").First(&conv, convID).Error; err == nil && !conv.Incognito {
+			emitMessageCompleted(db, conv.UserID, msg)
+		}
+	}
+	return msg, nil
+}
+
+// emitMessageCompleted sends the message.completed webhook for a finished
+// bot reply.
+func emitMessageCompleted(db *gorm.DB, userID uint, msg models.Message) {
+	webhook.Emit(db, webhook.EventMessageCompleted, userID, gin.H{
+		"message_id":      msg.ID,
+		"conversation_id": msg.ConversationID,
+		"user_id":         userID,
+		"text":            msg.Text,
+		"prompt_mode":     msg.PromptMode,
+		"confidence":      msg.Confidence,
+		"low_confidence":  msg.LowConfidence,
+		"timestamp":       msg.Timestamp,
+	})
+}
+
+// buildBotMessage post-processes a reply for the preferences in ctx and
+// scores it and resolves its citations, without saving it.
+func buildBotMessage(ctx context.Context, convID uint, question, text, mode string, info *svc.GenerationInfo) models.Message {
+	text = replyPipeline(ctx).Apply(text)
+	conf := svc.EstimateConfidence(question, text, info)
+	clean, citations := svc.ResolveCitations(text)
+	if conf.Low {
+		log.Printf("[conversation] ⚠️ low confidence %.2f (%s) for conversation %d", conf.Score, strings.Join(conf.Reasons, ","), convID)
+		clean = svc.UncertaintyDisclaimer + clean
+	}
+	msg := models.Message{ConversationID: convID, Sender: "bot", Text: clean, Timestamp: time.Now(), Status: models.MessageCompleted,
+		Confidence: &conf.Score, LowConfidence: conf.Low, PromptMode: mode,
+		ModelName: info.Model(), FinishReason: info.FinishReason(), Cached: info.Cached(), Parts: info.Parts(),
+		PromptTokens: info.Usage().PromptTokens, OutputTokens: info.Usage().OutputTokens, Route: string(info.Route()),
+		PromptTemplateID: info.TemplateID(), ContextHash: info.ContextHash(), DatasetHash: info.DatasetHash()}
+	if msg.PromptTemplateID != "" {
+		msg.PromptTemplateVersion = svc.PromptTemplateVersion
+	}
+	for _, ct := range citations {
+		msg.Citations = append(msg.Citations, models.MessageCitation{EventID: ct.EventID, DocumentID: ct.DocumentID, Marker: ct.Marker, Title: ct.Title, Line: ct.Line})
+	}
+	return msg
+}
+
+func citationsJSON(citations []models.MessageCitation) []gin.H {
+	out := make([]gin.H, 0, len(citations))
+	for _, ct := range citations {
+		item := gin.H{"marker": ct.Marker, "title": ct.Title, "line": ct.Line}
+		if ct.DocumentID != 0 {
+			item["document_id"] = ct.DocumentID
+		} else {
+			item["event_id"] = ct.EventID
+		}
+		out = append(out, item)
+	}
+	return out
+}
+
+func messageJSON(m models.Message) gin.H {
+	return gin.H{
+		"id":             m.ID,
+		"sender":         m.Sender,
+		"text":           m.Text,
+		"timestamp":      m.Timestamp,
+		"citations":      citationsJSON(m.Citations),
+		"confidence":     m.Confidence,
+		"low_confidence": m.LowConfidence,
+		"prompt_mode":    m.PromptMode,
+		"feedback":       m.Feedback,
+		"status":         m.Status,
+		"generation":     generationJSON(m),
+	}
+}
+
+// generationJSON exposes the generation metadata of bot messages, nil for
+// user messages.
+func generationJSON(m models.Message) gin.H {
+	if m.Sender != "bot" {
+		return nil
+	}
+	return gin.H{
+		"model":                   m.ModelName,
+		"prompt_template_id":      m.PromptTemplateID,
+		"prompt_template_version": m.PromptTemplateVersion,
+		"context_hash":            m.ContextHash,
+		"dataset_hash":            m.DatasetHash,
+		"latency_ms":              m.LatencyMs,
+		"finish_reason":           m.FinishReason,
+		"cached":                  m.Cached,
+		"parts":                   m.Parts,
+		"multi_part":              m.Parts > 1,
+		"usage":                   gin.H{"prompt_tokens": m.PromptTokens, "output_tokens": m.OutputTokens},
+		"route":                   m.Route,
+	}
+}
+
+// confidenceJSON is the payload of the streaming "confidence" event.
+func confidenceJSON(m models.Message) gin.H {
+	out := gin.H{"message_id": m.ID, "confidence": m.Confidence, "low_confidence": m.LowConfidence}
+	if m.LowConfidence {
+		out["disclaimer"] = strings.TrimSpace(svc.UncertaintyDisclaimer)
+	}
+	return out
+}
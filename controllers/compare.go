@@ -0,0 +1,147 @@
+package controllers
+
+import (
+	"AkuAI/pkg/apierror"
+	svc "AkuAI/pkg/services"
+	"context"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// compareArm is one side of a prompt comparison.
+type compareArm struct {
+	Response   string   `json:"response"`
+	Error      string   `json:"error,omitempty"`
+	DurationMs int64    `json:"duration_ms"`
+	TemplateID string   `json:"template_id"`
+	EventIDs   []string `json:"event_ids"`
+}
+
+// eventIDDiff compares the events mentioned by the two replies with each
+// other and with the events retrieval considered relevant.
+type eventIDDiff struct {
+	Both                []string `json:"both"`
+	BaselineOnly        []string `json:"baseline_only"`
+	EngineeredOnly      []string `json:"engineered_only"`
+	Relevant            []string `json:"relevant"`
+	MissingInBaseline   []string `json:"missing_in_baseline"`
+	MissingInEngineered []string `json:"missing_in_engineered"`
+	Jaccard             float64  `json:"jaccard"`
+}
+
+// ComparePromptModes runs the baseline and engineered prompts for the same
+// question concurrently and returns both replies, their timing and template
+// IDs, and a diff of the event IDs each one mentions. Nothing is saved.
+func ComparePromptModes() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var body struct {
+			Message string `json:"message"`
+			// Optional: override default timeout seconds
+			Timeout int `json:"timeout_sec"`
+		}
+		if err := c.ShouldBindJSON(&body); err != nil || strings.TrimSpace(body.Message) == "" {
+			apierror.Respond(c, http.StatusBadRequest, "message is required")
+			return
+		}
+		tSec := body.Timeout
+		if tSec <= 0 || tSec > 120 {
+			tSec = 60
+		}
+
+		ctx, cancel := context.WithTimeout(c.Request.Context(), time.Duration(tSec)*time.Second)
+		defer cancel()
+
+		gsvc := svc.NewGeminiServiceFor(ctx)
+		history := []svc.ChatMessage{{Role: "user", Text: body.Message}}
+
+		run := func(ask func(context.Context, []svc.ChatMessage) (string, error), function string) compareArm {
+			start := time.Now()
+			text, err := ask(ctx, history)
+			arm := compareArm{
+				Response:   strings.TrimSpace(text),
+				DurationMs: time.Since(start).Milliseconds(),
+				TemplateID: gsvc.PromptTemplateID(function, body.Message),
+			}
+			if err != nil {
+				arm.Error = err.Error()
+			}
+			arm.EventIDs = svc.MentionedEventIDs(arm.Response)
+			return arm
+		}
+
+		start := time.Now()
+		var baseline, engineered compareArm
+		var wg sync.WaitGroup
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			engineered = run(gsvc.AskCampusWithUIBContext, "AskCampusWithUIBContext")
+		}()
+		go func() {
+			defer wg.Done()
+			baseline = run(gsvc.AskCampusWithChat, "AskCampusWithChat")
+		}()
+		wg.Wait()
+
+		relevant := gsvc.RelevantEventIDs(body.Message)
+		resp := gin.H{
+			"question":         body.Message,
+			"template_version": svc.PromptTemplateVersion,
+			"baseline":         baseline,
+			"engineered":       engineered,
+			"t_total_ms":       time.Since(start).Milliseconds(),
+			"diff":             diffEventIDs(baseline.EventIDs, engineered.EventIDs, relevant),
+		}
+		c.JSON(http.StatusOK, resp)
+	}
+}
+
+func diffEventIDs(baseline, engineered, relevant []string) eventIDDiff {
+	inB, inE := toSet(baseline), toSet(engineered)
+	d := eventIDDiff{
+		Both:                []string{},
+		BaselineOnly:        []string{},
+		EngineeredOnly:      []string{},
+		Relevant:            relevant,
+		MissingInBaseline:   []string{},
+		MissingInEngineered: []string{},
+	}
+	for _, id := range baseline {
+		if inE[id] {
+			d.Both = append(d.Both, id)
+		} else {
+			d.BaselineOnly = append(d.BaselineOnly, id)
+		}
+	}
+	for _, id := range engineered {
+		if !inB[id] {
+			d.EngineeredOnly = append(d.EngineeredOnly, id)
+		}
+	}
+	for _, id := range relevant {
+		if !inB[id] {
+			d.MissingInBaseline = append(d.MissingInBaseline, id)
+		}
+		if !inE[id] {
+			d.MissingInEngineered = append(d.MissingInEngineered, id)
+		}
+	}
+	if union := len(d.Both) + len(d.BaselineOnly) + len(d.EngineeredOnly); union > 0 {
+		d.Jaccard = float64(len(d.Both)) / float64(union)
+	} else {
+		d.Jaccard = 1
+	}
+	return d
+}
+
+func toSet(ids []string) map[string]bool {
+	set := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		set[id] = true
+	}
+	return set
+}
@@ -0,0 +1,130 @@
+package controllers
+
+import (
+	"AkuAI/middleware"
+	"AkuAI/models"
+	"AkuAI/pkg/apierror"
+	"AkuAI/pkg/i18n"
+	svc "AkuAI/pkg/services"
+	"context"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// ContinueMessage finishes the conversation's last bot reply when it was
+// stopped or failed part-way: the partial text is sent back to the model,
+// which continues it, and the message is updated in place as completed.
+func ContinueMessage(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		uidStr := c.GetString(middleware.ContextUserIDKey)
+		uid, _ := strconv.ParseUint(uidStr, 10, 64)
+
+		var conv models.Conversation
+		if err := db.Where("id = ? AND user_id = ?", c.Param("conversation_id"), uid).First(&conv).Error; err != nil {
+			apierror.Respond(c, http.StatusNotFound, "conversation not found")
+			return
+		}
+		var partial models.Message
+		if err := db.Where("conversation_id = ? AND sender = ?", conv.ID, "bot").Order("id DESC").First(&partial).Error; err != nil {
+			apierror.Respond(c, http.StatusNotFound, "conversation has no reply to continue")
+			return
+		}
+		if partial.Status == models.MessageCompleted {
+			apierror.RespondDetails(c, http.StatusConflict, "the last reply is already complete", gin.H{"message_id": partial.ID})
+			return
+		}
+
+		release, err := middleware.TryAcquireUserSlot(c.Request.Context(), uidStr)
+		if err != nil {
+			middleware.AbortSlotBusy(c, err)
+			return
+		}
+		defer release()
+
+		var earlier []models.Message
+		if err := db.Where("conversation_id = ? AND id < ?", conv.ID, partial.ID).Order("id").Find(&earlier).Error; err != nil {
+			apierror.Respond(c, http.StatusInternalServerError, "db error")
+			return
+		}
+		question := ""
+		var history []svc.ChatMessage
+		for _, m := range earlier {
+			role := "user"
+			if m.Sender == "bot" {
+				role = "model"
+			} else {
+				question = m.Text
+			}
+			history = append(history, svc.ChatMessage{Role: role, Text: m.Text})
+		}
+		partialText := strings.TrimPrefix(partial.Text, svc.UncertaintyDisclaimer)
+		if i18n.Is(i18n.NoAnswer, strings.TrimSpace(partial.Text)) {
+			partialText = ""
+		}
+		if partialText != "" {
+			history = append(history, svc.ChatMessage{Role: "model", Text: partialText},
+				svc.ChatMessage{Role: "user", Text: svc.ContinueInstruction})
+		} else {
+			history = append(history, svc.ChatMessage{Role: "user", Text: question})
+		}
+
+		ctx, cancel := context.WithTimeout(svc.WithIncognito(c.Request.Context(), conv.Incognito), 60*time.Second)
+		defer cancel()
+		ctx, info := svc.WithGenerationInfo(ctx)
+		gsvc := svc.NewGeminiServiceFor(ctx)
+		var rest string
+		if partial.PromptMode == "engineered" {
+			rest, err = gsvc.AskCampusWithUIBContext(ctx, history)
+		}
+		if partial.PromptMode != "engineered" || err != nil || strings.TrimSpace(rest) == "" {
+			rest, err = gsvc.AskCampusWithChat(ctx, history)
+		}
+		if err != nil || strings.TrimSpace(rest) == "" {
+			log.Printf("[conversation] ⚠️ continue failed for message %d: %v", partial.ID, err)
+			apierror.RespondDetails(c, http.StatusBadGateway, "failed to continue the reply, try again later", gin.H{"message_id": partial.ID})
+			return
+		}
+
+		msg := buildBotMessage(ctx, conv.ID, question, joinContinuation(partialText, rest), partial.PromptMode, info)
+		msg.ID, msg.CreatedAt, msg.Timestamp = partial.ID, partial.CreatedAt, partial.Timestamp
+		msg.Feedback, msg.LatencyMs = partial.Feedback, partial.LatencyMs
+		err = db.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Where("message_id = ?", msg.ID).Delete(&models.MessageCitation{}).Error; err != nil {
+				return err
+			}
+			return tx.Save(&msg).Error
+		})
+		if err != nil {
+			apierror.Respond(c, http.StatusInternalServerError, "failed to save the reply")
+			return
+		}
+		if !conv.Incognito {
+			emitMessageCompleted(db, conv.UserID, msg)
+		}
+		state, _ := observeServiceState(ctx, info)
+		c.JSON(http.StatusOK, gin.H{"conversation_id": conv.ID, "message": messageJSON(msg), "service_state": state})
+	}
+}
+
+// joinContinuation appends the continued text to the partial reply. Models
+// sometimes restart the answer instead of continuing it; then the new text
+// replaces the partial one.
+func joinContinuation(partial, rest string) string {
+	rest = strings.TrimSpace(rest)
+	partial = strings.TrimRight(partial, " ")
+	switch {
+	case partial == "":
+		return rest
+	case strings.HasPrefix(rest, strings.TrimSpace(partial)):
+		return rest
+	case strings.HasSuffix(partial, "\n"):
+		return partial + rest
+	}
+	return partial + " " + rest
+}
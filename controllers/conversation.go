@@ -3,11 +3,15 @@ package controllers
 import (
 	"AkuAI/middleware"
 	"AkuAI/models"
-	"AkuAI/pkg/cache"
+	"AkuAI/pkg/apierror"
+	"AkuAI/pkg/audit"
 	"AkuAI/pkg/config"
+	"AkuAI/pkg/i18n"
+	"AkuAI/pkg/jobs"
 	svc "AkuAI/pkg/services"
+	"AkuAI/pkg/sse"
 	"context"
-	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
@@ -20,298 +24,368 @@ import (
 	"gorm.io/gorm"
 )
 
-func CreateOrAddMessage(db *gorm.DB) gin.HandlerFunc {
+// chatRequest is the body of the chat endpoints.
+type chatRequest struct {
+	Message        string `json:"message" binding:"notblank"`
+	ConversationID *uint  `json:"conversation_id" binding:"omitempty,min=1"`
+	RequestImages  bool   `json:"request_images"`
+	Mode           string `json:"mode"` // baseline | engineered
+	// short | normal | detailed, or ringkas | lengkap; over the user's preference
+	Verbosity string `json:"verbosity" binding:"omitempty,oneof=short normal detailed ringkas lengkap"`
+	// starts a new conversation in privacy mode; ignored for existing ones
+	Incognito bool `json:"incognito"`
+}
+
+// CreateOrAddMessage answers a chat message. With ?async=1 the reply is
+// generated by a job whose poll_url is under apiPrefix, the only mount point
+// of the jobs routes, whichever alias the message came in on.
+func CreateOrAddMessage(db *gorm.DB, apiPrefix string) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		userIDStr, _ := c.Get(middleware.ContextUserIDKey)
 		uidStr := userIDStr.(string)
 		uid, _ := strconv.Atoi(uidStr)
 
-		var body struct {
-			Message        string `json:"message"`
-			ConversationID *uint  `json:"conversation_id"`
-			RequestImages  bool   `json:"request_images"`
-			Mode           string `json:"mode"` // baseline | engineered
-		}
-		if err := c.ShouldBindJSON(&body); err != nil || strings.TrimSpace(body.Message) == "" {
-			c.JSON(http.StatusBadRequest, gin.H{"msg": "message is required"})
+		var body chatRequest
+		if !apierror.BindJSON(c, &body) {
 			return
 		}
+		requestVerbosity(c, body.Verbosity)
 
-		// Determine effective prompt mode
-		effMode := strings.ToLower(strings.TrimSpace(body.Mode))
-		if effMode == "" {
-			effMode = strings.ToLower(strings.TrimSpace(c.GetHeader("X-Prompt-Mode")))
-		}
-		if effMode == "" {
-			effMode = config.PromptMode
-		}
-		if effMode == "both" {
-			// Single-response endpoint defaults to engineered when config allows both
-			effMode = "engineered"
-		}
-		if effMode != "baseline" && effMode != "engineered" {
-			effMode = "engineered"
+		// Explicit prompt mode; otherwise assigned per conversation below
+		requestedMode := requestedPromptMode(c, body.Mode)
+
+		// The slot is taken before anything is written, so a 429 leaves no
+		// message behind and a retry isn't a duplicate. Async jobs take it
+		// when they run.
+		async := c.Query("async") == "1"
+		if !async {
+			release, err := middleware.TryAcquireUserSlot(c.Request.Context(), uidStr)
+			if err != nil {
+				middleware.AbortSlotBusy(c, err)
+				return
+			}
+			defer release()
 		}
 
 		bypass := strings.EqualFold(strings.TrimSpace(c.GetHeader("X-Bypass-Duplicate")), "1") ||
 			strings.EqualFold(strings.TrimSpace(c.GetHeader("X-Bypass-Duplicate")), "true")
-		cacheKeyDup := cache.KeyFromStrings("chat-final", uidStr, strings.ToLower(strings.TrimSpace(body.Message)))
-		_, cacheHit := cache.Default().GetChatResponse(cacheKeyDup)
+		cacheHit := chatCached(c.Request.Context(), "chat-final", uidStr, strings.ToLower(strings.TrimSpace(body.Message)))
 		if !bypass && !cacheHit {
 			if !middleware.DuplicateGuard(uidStr, body.Message) {
-				c.JSON(http.StatusConflict, gin.H{"msg": "duplicate message"})
+				apierror.Respond(c, http.StatusConflict, "duplicate message")
 				return
 			}
 		}
 
-		var conv models.Conversation
-		if body.ConversationID != nil {
-			if err := db.Preload("Messages").Where("id = ? AND user_id = ?", *body.ConversationID, uid).First(&conv).Error; err != nil {
-				c.JSON(http.StatusNotFound, gin.H{"msg": "conversation not found"})
-				return
-			}
-		} else {
-			title := body.Message
-			if len(title) > 30 {
-				title = title[:30] + "..."
-			}
-			conv = models.Conversation{UserID: uint(uid), Title: title}
-			if err := db.Create(&conv).Error; err != nil {
-				c.JSON(http.StatusInternalServerError, gin.H{"msg": "failed to create conversation"})
-				return
-			}
+		conv, err := openConversation(db, uint(uid), currentTenant(c).ID, body.ConversationID, body.Message, body.Incognito)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			apierror.Respond(c, http.StatusNotFound, "conversation not found")
+			return
+		}
+		if err != nil {
+			apierror.Respond(c, http.StatusInternalServerError, "failed to create conversation")
+			return
 		}
+		c.Request = c.Request.WithContext(svc.WithIncognito(c.Request.Context(), conv.Incognito))
 
-		msgUser := models.Message{ConversationID: conv.ID, Sender: "user", Text: body.Message, Timestamp: time.Now()}
+		msgUser := models.Message{ConversationID: conv.ID, Sender: "user", Text: body.Message, Timestamp: time.Now(), Label: queryLabel(c.Request.Context(), body.Message)}
 		if err := db.Create(&msgUser).Error; err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"msg": "failed to save message"})
+			apierror.Respond(c, http.StatusInternalServerError, "failed to save message")
 			return
 		}
+		effMode := assignPromptArm(db, &conv, requestedMode)
+		memSection := personalSection(db, conv, msgUser)
 
-		var history []svc.ChatMessage
-		if len(conv.Messages) > 0 {
-			msgs := append([]models.Message(nil), conv.Messages...)
-			sort.SliceStable(msgs, func(i, j int) bool { return msgs[i].Timestamp.Before(msgs[j].Timestamp) })
-			for _, m := range msgs {
-				role := "user"
-				if strings.ToLower(m.Sender) == "bot" {
-					role = "model"
+		history := chatHistory(conv, body.Message)
+
+		if async {
+			convID, incognito := conv.ID, conv.Incognito
+			prefs := svc.PreferencesFrom(c.Request.Context())
+			job, err := jobs.Default().Submit(uidStr, "chat", func(ctx context.Context) (any, error) {
+				release, ok := middleware.AcquireUserSlotNow(uidStr)
+				if !ok {
+					return nil, jobs.ErrRequeue
+				}
+				defer release()
+				ctx = svc.WithIncognito(svc.WithPreferences(ctx, prefs), incognito)
+				genCtx, info := svc.WithGenerationInfo(svc.WithUserMemory(ctx, memSection))
+				botReply := generateChatReply(genCtx, uidStr, effMode, body.Message, history)
+				if _, err := saveBotMessage(genCtx, db, convID, body.Message, botReply, effMode, info); err != nil {
+					return nil, fmt.Errorf("failed to save bot reply: %w", err)
+				}
+				payload, err := conversationPayload(db, convID)
+				if err != nil {
+					return nil, err
 				}
-				history = append(history, svc.ChatMessage{Role: role, Text: m.Text})
+				return withAnnouncements(db, uint(uid), payload), nil
+			})
+			if err != nil {
+				apierror.RespondDetails(c, http.StatusServiceUnavailable, "job queue is full, try again later", gin.H{"conversation_id": conv.ID})
+				return
 			}
+			c.JSON(http.StatusAccepted, gin.H{
+				"job_id":          job.ID,
+				"status":          job.Status,
+				"conversation_id": conv.ID,
+				"poll_url":        apiPrefix + "/jobs/" + job.ID,
+			})
+			return
 		}
-		history = append(history, svc.ChatMessage{Role: "user", Text: body.Message})
 
-		release := middleware.AcquireUserSlot(uidStr)
-		defer release()
 		ctx, cancel := context.WithTimeout(c.Request.Context(), 60*time.Second)
 		defer cancel()
 
-		botReply := ""
-		// Create cache key - include mode to avoid cross-contamination
-		cachePrefix := "chat-final"
-		message := strings.ToLower(strings.TrimSpace(body.Message))
+		ctx, info := svc.WithGenerationInfo(svc.WithUserMemory(ctx, memSection))
+		botReply := generateChatReply(ctx, uidStr, effMode, body.Message, history)
 
-		// Check if this is UIB-related for cache key differentiation
-		geminiService := svc.NewGeminiService()
-		if geminiService != nil {
-			// Add version identifier to ensure new UIB logic is used
-			if effMode == "engineered" {
-				cachePrefix = "chat-engineered-v1"
-			} else {
-				cachePrefix = "chat-baseline-v1"
+		if _, err := saveBotMessage(ctx, db, conv.ID, body.Message, botReply, effMode, info); err != nil {
+			apierror.Respond(c, http.StatusInternalServerError, "failed to save bot reply")
+			return
+		}
+
+		payload, err := conversationPayload(db, conv.ID)
+		if err != nil {
+			apierror.Respond(c, http.StatusInternalServerError, "failed to load messages")
+			return
+		}
+		payload["model"], payload["attempts"] = info.Model(), info.Attempts()
+		payload["service_state"], _ = observeServiceState(c.Request.Context(), info)
+		c.JSON(http.StatusCreated, withAnnouncements(db, uint(uid), payload))
+	}
+}
+
+// openConversation loads the user's conversation convID with its messages,
+// or starts one of tenantID titled after message, incognito if asked, when
+// convID is nil. A conversation of another user is gorm.ErrRecordNotFound.
+func openConversation(db *gorm.DB, uid uint, tenantID string, convID *uint, message string, incognito bool) (models.Conversation, error) {
+	var conv models.Conversation
+	if convID != nil {
+		if err := db.Preload("Messages").Where("id = ? AND user_id = ?", *convID, uid).First(&conv).Error; err != nil {
+			return conv, gorm.ErrRecordNotFound
+		}
+		unarchiveOnActivity(db, &conv)
+		return conv, nil
+	}
+	title := message
+	if len(title) > 30 {
+		title = title[:30] + "..."
+	}
+	conv = models.Conversation{UserID: uid, TenantID: tenantID, Title: title, Incognito: incognito}
+	return conv, db.Create(&conv).Error
+}
+
+// chatHistory is the model history of conv followed by the new user message.
+func chatHistory(conv models.Conversation, message string) []svc.ChatMessage {
+	var history []svc.ChatMessage
+	if len(conv.Messages) > 0 {
+		msgs := append([]models.Message(nil), conv.Messages...)
+		sort.SliceStable(msgs, func(i, j int) bool { return msgs[i].Timestamp.Before(msgs[j].Timestamp) })
+		for _, m := range msgs {
+			role := "user"
+			if strings.ToLower(m.Sender) == "bot" {
+				role = "model"
 			}
+			history = append(history, svc.ChatMessage{Role: role, Text: m.Text})
 		}
+	}
+	return append(history, svc.ChatMessage{Role: "user", Text: message})
+}
 
-		key := cache.KeyFromStrings(cachePrefix, uidStr, message)
-		if cachedText, ok, cacheInfo := cache.Default().GetChatResponseWithInfo(key); ok {
-			botReply = cachedText
-			log.Printf("[conversation] 🟢 SERVING FROM CACHE - User: %s, Message: %.50s..., Cache Age: %v",
-				uidStr, body.Message, time.Since(cacheInfo.CachedAt).Round(time.Second))
+// generateChatReply answers the last user turn of history, serving from the
+// chat cache when possible and falling back to the local responder.
+func generateChatReply(ctx context.Context, uidStr, effMode, userMessage string, history []svc.ChatMessage) string {
+	botReply := ""
+	// Create cache key - include mode to avoid cross-contamination
+	cachePrefix := "chat-final"
+	message := strings.ToLower(strings.TrimSpace(userMessage))
+
+	// Check if this is UIB-related for cache key differentiation
+	geminiService := svc.NewGeminiServiceFor(ctx)
+	if geminiService != nil {
+		// Add version identifier to ensure new UIB logic is used
+		if effMode == "engineered" {
+			cachePrefix = "chat-engineered-v1"
+		} else {
+			cachePrefix = "chat-baseline-v1"
 		}
-		if strings.TrimSpace(botReply) == "" {
-			log.Printf("[conversation] 🔵 GENERATING NEW RESPONSE (%s) - User: %s, Message: %.50s...", effMode, uidStr, body.Message)
+	}
 
-			if effMode == "engineered" {
-				// Engineered path prioritizes UIB-enhanced prompt
-				if resp, err := geminiService.AskCampusWithUIBContext(ctx, history); err == nil && strings.TrimSpace(resp) != "" {
-					botReply = resp
-					log.Printf("[conversation] ✅ Engineered response generated successfully")
-				} else {
-					log.Printf("[conversation] ⚠️ Engineered failed (%v), trying regular", err)
-					if resp, err := geminiService.AskCampusWithChat(ctx, history); err == nil && strings.TrimSpace(resp) != "" {
-						botReply = resp
-						log.Printf("[conversation] ✅ Regular response generated successfully")
-					}
-				}
-			} else { // baseline
+	scope := chatCacheScope(ctx, userMessage, history)
+	key := chatCacheKey(ctx, cachePrefix, scope, uidStr, message)
+	// Replies generated with a per-request generation override or in an
+	// incognito conversation are neither served from nor stored in the caches.
+	overridden := skipReplyCaches(ctx)
+	if overridden {
+		log.Printf("[conversation] generation override or incognito, skipping caches - User: %s", uidStr)
+	} else if cachedText, cacheInfo, ok := chatCacheLookup(key, scope); ok {
+		botReply = cachedText
+		svc.MarkCached(ctx)
+		log.Printf("[conversation] 🟢 SERVING FROM CACHE (%s) - User: %s, Message: %.50s..., Cache Age: %v",
+			scope, uidStr, userMessage, time.Since(cacheInfo.CachedAt).Round(time.Second))
+	} else if text, ok := semanticLookup(ctx, uidStr, effMode, userMessage, history); ok {
+		botReply = text
+		log.Printf("[conversation] 🟢 SERVING FROM SEMANTIC CACHE - User: %s, Message: %.50s...", uidStr, userMessage)
+	}
+	if strings.TrimSpace(botReply) == "" {
+		log.Printf("[conversation] 🔵 GENERATING NEW RESPONSE (%s) - User: %s, Message: %.50s...", effMode, uidStr, svc.Redact(ctx, userMessage))
+
+		if effMode == "engineered" {
+			// Engineered path prioritizes UIB-enhanced prompt
+			if resp, err := geminiService.AskCampusWithUIBContext(ctx, history); err == nil && strings.TrimSpace(resp) != "" {
+				botReply = resp
+				log.Printf("[conversation] ✅ Engineered response generated successfully")
+			} else {
+				log.Printf("[conversation] ⚠️ Engineered failed (%v), trying regular", err)
 				if resp, err := geminiService.AskCampusWithChat(ctx, history); err == nil && strings.TrimSpace(resp) != "" {
 					botReply = resp
-					log.Printf("[conversation] ✅ Baseline response generated successfully")
-				} else {
-					// Fallback to local mock
-					log.Printf("[conversation] ⚠️ Baseline failed (%v), using mock", err)
+					log.Printf("[conversation] ✅ Regular response generated successfully")
 				}
 			}
+		} else { // baseline
+			if resp, err := geminiService.AskCampusWithChat(ctx, history); err == nil && strings.TrimSpace(resp) != "" {
+				botReply = resp
+				log.Printf("[conversation] ✅ Baseline response generated successfully")
+			} else {
+				// Fallback to local mock
+				log.Printf("[conversation] ⚠️ Baseline failed (%v), using mock", err)
+			}
 		}
-		if strings.TrimSpace(botReply) == "" {
-			botReply = svc.AskCampusWithChatLocal(ctx, history)
-		}
-		if strings.TrimSpace(botReply) != "" {
-			cache.Default().SetChatResponse(key, botReply, cache.StatusCompleted, time.Duration(config.ChatCacheTTLSeconds)*time.Second)
-		}
-
-		msgBot := models.Message{ConversationID: conv.ID, Sender: "bot", Text: botReply, Timestamp: time.Now()}
-		if err := db.Create(&msgBot).Error; err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"msg": "failed to save bot reply"})
-			return
-		}
+	}
+	if strings.TrimSpace(botReply) == "" {
+		botReply = svc.AskCampusWithChatLocal(ctx, history)
+		svc.MarkLocal(ctx)
+	}
+	if strings.TrimSpace(botReply) != "" && !overridden {
+		cacheChatReply(ctx, key, uidStr, effMode, userMessage, history, botReply)
+	}
 
-		if err := db.Preload("Messages").First(&conv, conv.ID).Error; err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"msg": "failed to load messages"})
-			return
-		}
+	return botReply
+}
 
-		var messages []gin.H
-		for _, m := range conv.Messages {
-			messages = append(messages, gin.H{"id": m.ID, "sender": m.Sender, "text": m.Text, "timestamp": m.Timestamp})
-		}
+func conversationPayload(db *gorm.DB, convID uint) (gin.H, error) {
+	var conv models.Conversation
+	if err := db.Preload("Messages.Citations").First(&conv, convID).Error; err != nil {
+		return nil, err
+	}
 
-		c.JSON(http.StatusCreated, gin.H{"conversation_id": conv.ID, "messages": messages})
+	var messages []gin.H
+	for _, m := range conv.Messages {
+		messages = append(messages, messageJSON(m))
 	}
+	return gin.H{"conversation_id": conv.ID, "incognito": conv.Incognito, "messages": messages}, nil
 }
 
 func CreateOrAddMessageStream(db *gorm.DB) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		c.Writer.Header().Set("Content-Type", "text/event-stream")
-		c.Writer.Header().Set("Cache-Control", "no-cache")
-		c.Writer.Header().Set("Connection", "keep-alive")
-		c.Writer.Header().Set("X-Accel-Buffering", "no")
-
-		flusher, ok := c.Writer.(http.Flusher)
-		if !ok {
-			c.String(http.StatusInternalServerError, "streaming unsupported")
-			return
-		}
-
 		userIDStr, _ := c.Get(middleware.ContextUserIDKey)
 		uidStr := userIDStr.(string)
 		uid, _ := strconv.Atoi(uidStr)
 
-		release := middleware.AcquireUserSlot(uidStr)
-		defer release()
-
-		var body struct {
-			Message        string `json:"message"`
-			ConversationID *uint  `json:"conversation_id"`
-			RequestImages  bool   `json:"request_images"`
-			Mode           string `json:"mode"` // baseline | engineered
-		}
-		if err := c.ShouldBindJSON(&body); err != nil || strings.TrimSpace(body.Message) == "" {
-			c.Status(http.StatusBadRequest)
+		var body chatRequest
+		if !apierror.BindJSON(c, &body) {
 			return
 		}
+		requestVerbosity(c, body.Verbosity)
 
-		// Determine effective prompt mode
-		effMode := strings.ToLower(strings.TrimSpace(body.Mode))
-		if effMode == "" {
-			effMode = strings.ToLower(strings.TrimSpace(c.GetHeader("X-Prompt-Mode")))
-		}
-		if effMode == "" {
-			effMode = config.PromptMode
-		}
-		if effMode == "both" {
-			effMode = "engineered"
-		}
-		if effMode != "baseline" && effMode != "engineered" {
-			effMode = "engineered"
+		// Explicit prompt mode; otherwise assigned per conversation below
+		requestedMode := requestedPromptMode(c, body.Mode)
+
+		release, err := middleware.TryAcquireUserSlot(c.Request.Context(), uidStr)
+		if err != nil {
+			middleware.AbortSlotBusy(c, err)
+			return
 		}
+		defer release()
 
 		bypass := strings.EqualFold(strings.TrimSpace(c.GetHeader("X-Bypass-Duplicate")), "1") ||
 			strings.EqualFold(strings.TrimSpace(c.GetHeader("X-Bypass-Duplicate")), "true")
 		baseDupPrefix := "chat-engineered-v1"
-		if effMode == "baseline" {
+		if requestedMode == "baseline" {
 			baseDupPrefix = "chat-baseline-v1"
 		}
-		cacheKeyDup := cache.KeyFromStrings(baseDupPrefix, uidStr, strings.ToLower(strings.TrimSpace(body.Message)))
-		_, cacheHit := cache.Default().GetChatResponse(cacheKeyDup)
+		cacheHit := chatCached(c.Request.Context(), baseDupPrefix, uidStr, strings.ToLower(strings.TrimSpace(body.Message)))
 		if !bypass && !cacheHit {
 			if !middleware.DuplicateGuard(uidStr, body.Message) {
-				c.Status(http.StatusConflict)
+				apierror.Respond(c, http.StatusConflict, "duplicate message")
 				return
 			}
 		}
 
-		var conv models.Conversation
-		if body.ConversationID != nil {
-			if err := db.Preload("Messages").Where("id = ? AND user_id = ?", *body.ConversationID, uid).First(&conv).Error; err != nil {
-				c.Status(http.StatusNotFound)
-				return
-			}
-		} else {
-			title := body.Message
-			if len(title) > 30 {
-				title = title[:30] + "..."
-			}
-			conv = models.Conversation{UserID: uint(uid), Title: title}
-			if err := db.Create(&conv).Error; err != nil {
-				c.Status(http.StatusInternalServerError)
-				return
-			}
+		conv, err := openConversation(db, uint(uid), currentTenant(c).ID, body.ConversationID, body.Message, body.Incognito)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			apierror.Respond(c, http.StatusNotFound, "conversation not found")
+			return
 		}
+		if err != nil {
+			apierror.Respond(c, http.StatusInternalServerError, "failed to create conversation")
+			return
+		}
+		c.Request = c.Request.WithContext(svc.WithIncognito(c.Request.Context(), conv.Incognito))
 
-		msgUser := models.Message{ConversationID: conv.ID, Sender: "user", Text: body.Message, Timestamp: time.Now()}
-		if err := db.Create(&msgUser).Error; err != nil {
-			c.Status(http.StatusInternalServerError)
+		// Errors up to here are JSON; the stream starts once the request is
+		// known to go through.
+		sw, err := sse.NewWriter(c.Writer)
+		if err != nil {
+			apierror.Respond(c, http.StatusInternalServerError, err.Error())
 			return
 		}
 
-		fmt.Fprintf(c.Writer, "event: user_saved\n")
-		fmt.Fprintf(c.Writer, "data: {\"conversation_id\": %d}\n\n", conv.ID)
-		flusher.Flush()
+		msgUser := models.Message{ConversationID: conv.ID, Sender: "user", Text: body.Message, Timestamp: time.Now(), Label: queryLabel(c.Request.Context(), body.Message)}
+		if err := db.Create(&msgUser).Error; err != nil {
+			apierror.Respond(c, http.StatusInternalServerError, "failed to save message")
+			return
+		}
+		effMode := assignPromptArm(db, &conv, requestedMode)
+		if effMode == "baseline" {
+			baseDupPrefix = "chat-baseline-v1"
+		} else {
+			baseDupPrefix = "chat-engineered-v1"
+		}
 
-		var history []svc.ChatMessage
-		if len(conv.Messages) > 0 {
-			msgs := append([]models.Message(nil), conv.Messages...)
-			sort.SliceStable(msgs, func(i, j int) bool { return msgs[i].Timestamp.Before(msgs[j].Timestamp) })
-			for _, m := range msgs {
-				role := "user"
-				if strings.ToLower(m.Sender) == "bot" {
-					role = "model"
-				}
-				history = append(history, svc.ChatMessage{Role: role, Text: m.Text})
-			}
+		// From here on every event is kept for replay, so a client that drops
+		// the connection can resume with Last-Event-ID via /conversations/stream/resume.
+		stream := sse.Default().Open(uidStr)
+		defer sse.Default().Finish(stream)
+		sw.Attach(stream, time.Duration(config.SSERetryMs)*time.Millisecond)
+		defer sw.Heartbeat(time.Duration(config.SSEHeartbeatSeconds) * time.Second)()
+		_ = sw.Send("user_saved", gin.H{"conversation_id": conv.ID, "stream_id": stream.ID})
+		for _, a := range userAnnouncements(db, uint(uid)) {
+			_ = sw.Send("announcement", a)
 		}
-		history = append(history, svc.ChatMessage{Role: "user", Text: body.Message})
 
-		gsvc := svc.NewGeminiService()
+		history := chatHistory(conv, body.Message)
+
+		gsvc := svc.NewGeminiServiceFor(c.Request.Context())
 		var full strings.Builder
 		gotDelta := false
+		post := replyPipeline(c.Request.Context()).Stream(func(s string) { _ = sw.Send("delta", s) })
 		onDelta := func(chunk string) {
-			esc := strings.ReplaceAll(chunk, "\n", "\\n")
-			fmt.Fprintf(c.Writer, "event: delta\n")
-			fmt.Fprintf(c.Writer, "data: %s\n\n", esc)
-			flusher.Flush()
+			post.Write(chunk)
 			full.WriteString(chunk)
 			gotDelta = true
 		}
 
-		ctx, cancel := context.WithTimeout(c.Request.Context(), 75*time.Second)
+		// The answer is generated (and saved) even if the client disconnects, so
+		// a resumed stream can still deliver it.
+		ctx, cancel := context.WithTimeout(context.WithoutCancel(c.Request.Context()), 75*time.Second)
 		defer cancel()
+		ctx, info := svc.WithGenerationInfo(svc.WithUserMemory(ctx, personalSection(db, conv, msgUser)))
+
+		scope := chatCacheScope(ctx, body.Message, history)
+		cacheKey := chatCacheKey(ctx, baseDupPrefix, scope, uidStr, strings.ToLower(strings.TrimSpace(body.Message)))
+		overridden := skipReplyCaches(ctx)
+		if !overridden {
+			if s, _, ok := chatCacheLookup(cacheKey, scope); ok {
+				svc.MarkCached(ctx)
+				replayText(s, onDelta, nil)
+				gotDelta = true
+			}
+		}
 
-		cacheKey := cache.KeyFromStrings(baseDupPrefix, uidStr, strings.ToLower(strings.TrimSpace(body.Message)))
-		if v, ok := cache.Default().Get(cacheKey); ok {
-			if s, ok2 := v.(string); ok2 && s != "" {
-				runes := []rune(s)
-				chunk := 28
-				for i := 0; i < len(runes); i += chunk {
-					end := i + chunk
-					if end > len(runes) {
-						end = len(runes)
-					}
-					onDelta(string(runes[i:end]))
-					time.Sleep(12 * time.Millisecond)
-				}
+		if !gotDelta && !overridden {
+			if s, ok := semanticLookup(ctx, uidStr, effMode, body.Message, history); ok {
+				log.Printf("[conversation] 🟢 STREAMING FROM SEMANTIC CACHE - User: %s, Message: %.50s...", uidStr, body.Message)
+				replayText(s, onDelta, nil)
 				gotDelta = true
 			}
 		}
@@ -320,155 +394,111 @@ func CreateOrAddMessageStream(db *gorm.DB) gin.HandlerFunc {
 			if effMode == "engineered" {
 				// Use UIB-enhanced method instead of regular StreamCampusWithChat
 				if response, err := gsvc.AskCampusWithUIBContext(ctx, history); err == nil && response != "" {
-					// Simulate streaming for UIB response
-					runes := []rune(response)
-					chunk := 28
-					for i := 0; i < len(runes); i += chunk {
-						end := i + chunk
-						if end > len(runes) {
-							end = len(runes)
-						}
-						onDelta(string(runes[i:end]))
-						time.Sleep(12 * time.Millisecond)
-					}
+					replayText(response, onDelta, nil)
 					gotDelta = true
 				} else {
-					svc.StreamCampusWithChatLocal(c.Request.Context(), history, onDelta)
+					svc.StreamCampusWithChatLocal(ctx, history, onDelta)
+					svc.MarkLocal(ctx)
 				}
 			} else {
 				// baseline: use regular chat method and simulate streaming
 				if response, err := gsvc.AskCampusWithChat(ctx, history); err == nil && response != "" {
-					runes := []rune(response)
-					chunk := 28
-					for i := 0; i < len(runes); i += chunk {
-						end := i + chunk
-						if end > len(runes) {
-							end = len(runes)
-						}
-						onDelta(string(runes[i:end]))
-						time.Sleep(12 * time.Millisecond)
-					}
+					replayText(response, onDelta, nil)
 					gotDelta = true
 				} else {
-					svc.StreamCampusWithChatLocal(c.Request.Context(), history, onDelta)
+					svc.StreamCampusWithChatLocal(ctx, history, onDelta)
+					svc.MarkLocal(ctx)
 				}
 			}
 		}
 
 		if !gotDelta {
-			svc.StreamCampusWithChatLocal(c.Request.Context(), history, onDelta)
+			svc.StreamCampusWithChatLocal(ctx, history, onDelta)
+			svc.MarkLocal(ctx)
 		}
+		post.Flush()
 
+		// A reply cut off by the generation timeout is kept as partial.
+		status := models.MessageCompleted
+		if ctx.Err() != nil {
+			status = models.MessageError
+		}
 		botText := strings.TrimSpace(full.String())
 		if botText == "" {
-			botText = "Maaf, belum ada jawaban."
-			msgBot := models.Message{ConversationID: conv.ID, Sender: "bot", Text: botText, Timestamp: time.Now()}
+			botText = i18n.T(i18n.FromContext(ctx), i18n.NoAnswer)
+			msgBot := models.Message{ConversationID: conv.ID, Sender: "bot", Text: botText, Timestamp: time.Now(), Status: models.MessageError}
 			_ = db.Create(&msgBot).Error
+			status = models.MessageError
 		} else {
-			msgBot := models.Message{ConversationID: conv.ID, Sender: "bot", Text: botText, Timestamp: time.Now()}
-			_ = db.Create(&msgBot).Error
-			cache.Default().SetChatResponse(cacheKey, botText, cache.StatusCompleted, time.Duration(config.ChatCacheTTLSeconds)*time.Second)
-		}
-
-		// Handle image search if requested
-		if body.RequestImages {
-			detectedUniversity := ""
-			if du, err := gsvc.DetectUniversityName(ctx, history, botText); err != nil {
-				log.Printf("[conversation] ⚠️ failed to detect university: %v", err)
-			} else {
-				detectedUniversity = strings.TrimSpace(du)
+			msgBot, err := saveBotMessageWithStatus(ctx, db, conv.ID, body.Message, botText, effMode, status, info)
+			if status == models.MessageCompleted && !overridden {
+				cacheChatReply(ctx, cacheKey, uidStr, effMode, body.Message, history, botText)
 			}
-
-			primaryQuery := detectedUniversity
-			if primaryQuery == "" {
-				primaryQuery = "kampus"
+			if err == nil {
+				_ = sw.Send("confidence", confidenceJSON(msgBot))
+				if len(msgBot.Citations) > 0 {
+					_ = sw.Send("citations", gin.H{"message_id": msgBot.ID, "citations": citationsJSON(msgBot.Citations)})
+				}
 			}
+		}
 
-			messageText := "Mencari gambar kampus..."
-			if primaryQuery != "kampus" {
-				messageText = fmt.Sprintf("Mencari gambar %s...", primaryQuery)
-			}
+		// Image search: requested explicitly or detected from the message
+		if intent, trigger, ok := chatImageIntent(ctx, gsvc, body.Message, body.RequestImages); ok {
+			streamChatImages(ctx, ctx, gsvc, history, botText, intent, trigger, "conversation", func(event string, payload gin.H) {
+				_ = sw.Send(event, payload)
+			})
+		}
 
-			fmt.Fprintf(c.Writer, "event: images_searching\n")
-			fmt.Fprintf(c.Writer, "data: {\"message\": %s, \"query\": %s, \"detected_university\": %s}\n\n",
-				strconv.Quote(messageText),
-				strconv.Quote(primaryQuery),
-				strconv.Quote(detectedUniversity),
-			)
-			flusher.Flush()
-
-			googleImageService := svc.NewGoogleImageService()
-			if googleImageService.IsEnabled() {
-				searchCtx, searchCancel := context.WithTimeout(ctx, 30*time.Second)
-				defer searchCancel()
-
-				activeQuery := primaryQuery
-				fallbackUsed := false
-
-				images, err := googleImageService.SearchImagesForChat(searchCtx, primaryQuery)
-				if (err != nil || len(images) == 0) && primaryQuery != "kampus" {
-					fallbackUsed = true
-					log.Printf("[conversation] ℹ️ primary image query '%s' returned err=%v, attempting fallback 'kampus'", primaryQuery, err)
-					if fallbackImages, fallbackErr := googleImageService.SearchImagesForChat(searchCtx, "kampus"); fallbackErr == nil && len(fallbackImages) > 0 {
-						images = fallbackImages
-						err = nil
-						activeQuery = "kampus"
-					} else {
-						if fallbackErr != nil {
-							err = fallbackErr
-						}
-						images = fallbackImages
-						activeQuery = "kampus"
-					}
-				}
+		state, change := observeServiceState(ctx, info)
+		if change != nil {
+			_ = sw.Send("system", change)
+		}
+		_ = sw.Send("done", gin.H{"ok": true, "mode": effMode, "status": status, "parts": info.Parts(),
+			"model": info.Model(), "attempts": info.Attempts(), "service_state": state})
+	}
+}
 
-				if err != nil {
-					errorMessage := fmt.Sprintf("Gagal mencari gambar untuk '%s': %v", activeQuery, err)
-					fmt.Fprintf(c.Writer, "event: images_error\n")
-					fmt.Fprintf(c.Writer, "data: {\"error\": %s, \"query\": %s, \"primary_query\": %s, \"detected_university\": %s, \"fallback\": %t}\n\n",
-						strconv.Quote(errorMessage),
-						strconv.Quote(activeQuery),
-						strconv.Quote(primaryQuery),
-						strconv.Quote(detectedUniversity),
-						fallbackUsed,
-					)
-					flusher.Flush()
-				} else if len(images) > 0 {
-					imagesJSON, _ := json.Marshal(images)
-					fmt.Fprintf(c.Writer, "event: images_found\n")
-					fmt.Fprintf(c.Writer, "data: {\"images\": %s, \"count\": %d, \"query\": %s, \"primary_query\": %s, \"detected_university\": %s, \"fallback\": %t}\n\n",
-						string(imagesJSON),
-						len(images),
-						strconv.Quote(activeQuery),
-						strconv.Quote(primaryQuery),
-						strconv.Quote(detectedUniversity),
-						fallbackUsed,
-					)
-					flusher.Flush()
-				} else {
-					emptyMessage := fmt.Sprintf("Tidak ada gambar ditemukan untuk '%s'", activeQuery)
-					fmt.Fprintf(c.Writer, "event: images_empty\n")
-					fmt.Fprintf(c.Writer, "data: {\"message\": %s, \"query\": %s, \"primary_query\": %s, \"detected_university\": %s, \"fallback\": %t}\n\n",
-						strconv.Quote(emptyMessage),
-						strconv.Quote(activeQuery),
-						strconv.Quote(primaryQuery),
-						strconv.Quote(detectedUniversity),
-						fallbackUsed,
-					)
-					flusher.Flush()
-				}
-			} else {
-				fmt.Fprintf(c.Writer, "event: images_disabled\n")
-				fmt.Fprintf(c.Writer, "data: {\"message\": \"Fitur pencarian gambar belum dikonfigurasi\", \"query\": %s}\n\n",
-					strconv.Quote(primaryQuery),
-				)
-				flusher.Flush()
-			}
+// replayText streams an already complete answer (cache hits and non-streaming
+// model calls) in small chunks so clients render it like a live reply. The
+// chunks go through the same stream normalizer as live deltas. stopped may be
+// nil.
+func replayText(text string, emit func(string), stopped func() bool) {
+	runes := []rune(text)
+	const chunk = 28
+	for i := 0; i < len(runes); i += chunk {
+		if stopped != nil && stopped() {
+			return
 		}
+		emit(string(runes[i:min(i+chunk, len(runes))]))
+		time.Sleep(12 * time.Millisecond)
+	}
+}
 
-		fmt.Fprintf(c.Writer, "event: done\n")
-		fmt.Fprintf(c.Writer, "data: {\"ok\": true, \"mode\": %s}\n\n", strconv.Quote(effMode))
-		flusher.Flush()
+// ResumeStream replays a conversation stream after the event named by the
+// Last-Event-ID header (or last_event_id query) and follows it until done.
+func ResumeStream() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		lastID := c.GetHeader("Last-Event-ID")
+		if lastID == "" {
+			lastID = c.Query("last_event_id")
+		}
+		streamID, seq, ok := sse.ParseEventID(lastID)
+		if !ok {
+			apierror.Respond(c, http.StatusBadRequest, "Last-Event-ID must be <stream_id>:<seq>")
+			return
+		}
+		stream, ok := sse.Default().Get(streamID)
+		if !ok || stream.Owner != c.GetString(middleware.ContextUserIDKey) {
+			apierror.Respond(c, http.StatusGone, "stream expired, reload the conversation instead")
+			return
+		}
+		sw, err := sse.NewWriter(c.Writer)
+		if err != nil {
+			c.String(http.StatusInternalServerError, err.Error())
+			return
+		}
+		defer sw.Heartbeat(time.Duration(config.SSEHeartbeatSeconds) * time.Second)()
+		sw.Replay(stream, seq, c.Request.Context().Done())
 	}
 }
 
@@ -480,52 +510,82 @@ func ListConversations(db *gorm.DB) gin.HandlerFunc {
 
 		q := strings.TrimSpace(c.Query("q"))
 
+		// archived=1 lists only archived conversations, archived=all lists both
+		query := db.Where("user_id = ?", uid)
+		switch strings.ToLower(strings.TrimSpace(c.Query("archived"))) {
+		case "all":
+		case "1", "true":
+			query = query.Where("archived = ?", true)
+		default:
+			query = query.Where("archived = ?", false)
+		}
+		// folder_id=<id> lists one folder, folder_id=none the unfiled conversations
+		switch v := strings.TrimSpace(c.Query("folder_id")); v {
+		case "":
+		case "none":
+			query = query.Where("folder_id IS NULL")
+		default:
+			fid, err := strconv.Atoi(v)
+			if err != nil || fid < 1 {
+				apierror.Respond(c, http.StatusBadRequest, "folder_id must be a folder id or none")
+				return
+			}
+			query = query.Where("folder_id = ?", fid)
+		}
+		if q != "" && !searchInApp() {
+			like := "%" + strings.ToLower(q) + "%"
+			query = query.Where("LOWER(title) LIKE ? OR EXISTS (?)", like,
+				db.Model(&models.Message{}).Select("1").
+					Where("messages.conversation_id = conversations.id AND LOWER(messages.text) LIKE ?", like))
+		}
+
 		var convs []models.Conversation
-		if err := db.Preload("Messages").Where("user_id = ?", uid).Find(&convs).Error; err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"msg": "db error"})
+		if err := query.Find(&convs).Error; err != nil {
+			apierror.Respond(c, http.StatusInternalServerError, "db error")
 			return
 		}
-
-		filtered := convs[:0]
-		if q == "" {
-			filtered = convs
-		} else {
-			p := strings.ToLower(q)
-			for _, conv := range convs {
-				if strings.Contains(strings.ToLower(conv.Title), p) {
-					filtered = append(filtered, conv)
-					continue
-				}
-				matched := false
-				for _, m := range conv.Messages {
-					if strings.Contains(strings.ToLower(m.Text), p) {
-						matched = true
-						break
-					}
-				}
-				if matched {
-					filtered = append(filtered, conv)
-				}
+		if q != "" && searchInApp() {
+			var err error
+			if convs, err = conversationsMatching(db, convs, strings.ToLower(q)); err != nil {
+				apierror.Respond(c, http.StatusInternalServerError, "db error")
+				return
 			}
 		}
 
-		sort.SliceStable(filtered, func(i, j int) bool {
-			li := latestTimestamp(filtered[i].Messages)
-			lj := latestTimestamp(filtered[j].Messages)
-			return lj.Before(li)
+		ids := make([]uint, len(convs))
+		for i, conv := range convs {
+			ids[i] = conv.ID
+		}
+		stats, err := messageStats(db, ids)
+		if err != nil {
+			apierror.Respond(c, http.StatusInternalServerError, "db error")
+			return
+		}
+
+		// Pinned first, then by last activity
+		sort.SliceStable(convs, func(i, j int) bool {
+			if convs[i].Pinned != convs[j].Pinned {
+				return convs[i].Pinned
+			}
+			return stats[convs[j].ID].LastAt.Before(stats[convs[i].ID].LastAt)
 		})
 
-		result := make([]gin.H, 0, len(filtered))
-		for _, conv := range filtered {
+		result := make([]gin.H, 0, len(convs))
+		for _, conv := range convs {
+			st := stats[conv.ID]
 			createdAt := interface{}(nil)
-			if len(conv.Messages) > 0 {
-				createdAt = conv.Messages[0].Timestamp
+			if st.Count > 0 {
+				createdAt = st.FirstAt
 			}
 			result = append(result, gin.H{
 				"id":             conv.ID,
 				"title":          conv.Title,
 				"created_at":     createdAt,
-				"messages_count": len(conv.Messages),
+				"messages_count": st.Count,
+				"archived":       conv.Archived,
+				"pinned":         conv.Pinned,
+				"folder_id":      conv.FolderID,
+				"incognito":      conv.Incognito,
 			})
 		}
 
@@ -533,16 +593,8 @@ func ListConversations(db *gorm.DB) gin.HandlerFunc {
 	}
 }
 
-func latestTimestamp(msgs []models.Message) time.Time {
-	var t time.Time
-	for _, m := range msgs {
-		if m.Timestamp.After(t) {
-			t = m.Timestamp
-		}
-	}
-	return t
-}
-
+// GetConversation returns a conversation with all its messages, or with the
+// newest page of them when limit (and optionally before) is given.
 func GetConversation(db *gorm.DB) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		userIDStr, _ := c.Get(middleware.ContextUserIDKey)
@@ -553,25 +605,52 @@ func GetConversation(db *gorm.DB) gin.HandlerFunc {
 		cid, _ := strconv.Atoi(convIDStr)
 
 		var conv models.Conversation
-		if err := db.Preload("Messages").Where("id = ? AND user_id = ?", cid, uid).First(&conv).Error; err != nil {
-			c.JSON(http.StatusNotFound, gin.H{"msg": "conversation not found"})
+		if err := db.Where("id = ? AND user_id = ?", cid, uid).First(&conv).Error; err != nil {
+			apierror.Respond(c, http.StatusNotFound, "conversation not found")
+			return
+		}
+		var count int64
+		if err := db.Model(&models.Message{}).Where("conversation_id = ?", conv.ID).Count(&count).Error; err != nil {
+			apierror.Respond(c, http.StatusInternalServerError, "db error")
+			return
+		}
+
+		if c.Query("limit") != "" || c.Query("before") != "" {
+			limit, before, ok := parseMessagePage(c)
+			if !ok {
+				return
+			}
+			msgs, hasMore, err := messagePage(db, conv.ID, limit, before)
+			if err != nil {
+				apierror.Respond(c, http.StatusInternalServerError, "db error")
+				return
+			}
+			payload := pageJSON(msgs, hasMore)
+			markMessages(db, uint(uid), payload["messages"].([]gin.H))
+			payload["conversation_id"] = conv.ID
+			payload["title"] = conv.Title
+			payload["incognito"] = conv.Incognito
+			payload["messages_count"] = count
+			c.JSON(http.StatusOK, payload)
 			return
 		}
 
+		if err := db.Preload("Citations").Where("conversation_id = ?", conv.ID).Find(&conv.Messages).Error; err != nil {
+			apierror.Respond(c, http.StatusInternalServerError, "db error")
+			return
+		}
 		var messages []gin.H
 		for _, m := range conv.Messages {
-			messages = append(messages, gin.H{
-				"id":        m.ID,
-				"sender":    m.Sender,
-				"text":      m.Text,
-				"timestamp": m.Timestamp,
-			})
+			messages = append(messages, messageJSON(m))
 		}
+		markMessages(db, uint(uid), messages)
 
 		c.JSON(http.StatusOK, gin.H{
 			"conversation_id": conv.ID,
 			"title":           conv.Title,
+			"incognito":       conv.Incognito,
 			"messages":        messages,
+			"messages_count":  count,
 		})
 	}
 }
@@ -587,81 +666,137 @@ func DeleteConversation(db *gorm.DB) gin.HandlerFunc {
 
 		var conv models.Conversation
 		if err := db.Where("id = ? AND user_id = ?", cid, uid).First(&conv).Error; err != nil {
-			c.JSON(http.StatusNotFound, gin.H{"msg": "conversation not found"})
+			apierror.Respond(c, http.StatusNotFound, "conversation not found")
 			return
 		}
 
+		// Soft delete: the conversation stays in the trash until the retention job purges it
 		if err := db.Delete(&conv).Error; err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"msg": "failed to delete conversation"})
+			apierror.Respond(c, http.StatusInternalServerError, "failed to delete conversation")
 			return
 		}
-		c.JSON(http.StatusOK, gin.H{"msg": "conversation deleted"})
+		recordAudit(c, db, audit.Entry{Action: audit.ActionConversationDelete, TargetType: "conversation", TargetID: strconv.Itoa(int(conv.ID)),
+			Before: gin.H{"archived": conv.Archived}})
+		c.JSON(http.StatusOK, gin.H{"msg": "conversation moved to trash", "conversation_id": conv.ID, "restorable_days": config.TrashRetentionDays})
 	}
 }
 
-func DeleteAllConversations(db *gorm.DB) gin.HandlerFunc {
+// ListTrash lists the user's soft-deleted conversations that can still be restored.
+func ListTrash(db *gorm.DB) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		userIDStr, _ := c.Get(middleware.ContextUserIDKey)
 		uidStr := userIDStr.(string)
 		uid, _ := strconv.Atoi(uidStr)
 
-		if err := db.Transaction(func(tx *gorm.DB) error {
-			if err := tx.Where("user_id = ?", uid).Delete(&models.Conversation{}).Error; err != nil {
-				return err
-			}
-			return nil
-		}); err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"msg": "failed to delete all conversations"})
+		var convs []models.Conversation
+		if err := db.Unscoped().
+			Where("user_id = ? AND deleted_at IS NOT NULL", uid).
+			Order("deleted_at DESC").
+			Find(&convs).Error; err != nil {
+			apierror.Respond(c, http.StatusInternalServerError, "db error")
 			return
 		}
 
-		c.JSON(http.StatusOK, gin.H{"msg": "all conversations deleted"})
+		trashTTL := time.Duration(config.TrashRetentionDays) * 24 * time.Hour
+		result := make([]gin.H, 0, len(convs))
+		for _, conv := range convs {
+			item := gin.H{
+				"id":         conv.ID,
+				"title":      conv.Title,
+				"deleted_at": conv.DeletedAt.Time,
+			}
+			if trashTTL > 0 {
+				item["purge_at"] = conv.DeletedAt.Time.Add(trashTTL)
+			}
+			result = append(result, item)
+		}
+
+		c.JSON(http.StatusOK, result)
 	}
 }
 
-// ComparePromptModes returns both baseline and engineered responses for the same prompt without saving to DB.
-func ComparePromptModes() gin.HandlerFunc {
+// RestoreConversation brings a conversation back from the trash.
+func RestoreConversation(db *gorm.DB) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		var body struct {
-			Message string `json:"message"`
-			// Optional: override default timeout seconds
-			Timeout int `json:"timeout_sec"`
-		}
-		if err := c.ShouldBindJSON(&body); err != nil || strings.TrimSpace(body.Message) == "" {
-			c.JSON(http.StatusBadRequest, gin.H{"msg": "message is required"})
+		userIDStr, _ := c.Get(middleware.ContextUserIDKey)
+		uidStr := userIDStr.(string)
+		uid, _ := strconv.Atoi(uidStr)
+
+		convIDStr := c.Param("conversation_id")
+		cid, _ := strconv.Atoi(convIDStr)
+
+		var conv models.Conversation
+		if err := db.Unscoped().Where("id = ? AND user_id = ? AND deleted_at IS NOT NULL", cid, uid).First(&conv).Error; err != nil {
+			apierror.Respond(c, http.StatusNotFound, "conversation not found in trash")
 			return
 		}
-		tSec := body.Timeout
-		if tSec <= 0 || tSec > 120 {
-			tSec = 60
-		}
 
-		ctx, cancel := context.WithTimeout(c.Request.Context(), time.Duration(tSec)*time.Second)
-		defer cancel()
+		if err := db.Unscoped().Model(&conv).Update("deleted_at", nil).Error; err != nil {
+			apierror.Respond(c, http.StatusInternalServerError, "failed to restore conversation")
+			return
+		}
+		recordAudit(c, db, audit.Entry{Action: audit.ActionConversationRestore, TargetType: "conversation", TargetID: strconv.Itoa(int(conv.ID)),
+			Before: gin.H{"deleted_at": conv.DeletedAt.Time}})
+		c.JSON(http.StatusOK, gin.H{"msg": "conversation restored", "conversation_id": conv.ID})
+	}
+}
 
-		gsvc := svc.NewGeminiService()
-		history := []svc.ChatMessage{{Role: "user", Text: body.Message}}
+// ArchiveConversation archives (archive=true) or restores a conversation.
+func ArchiveConversation(db *gorm.DB, archive bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userIDStr, _ := c.Get(middleware.ContextUserIDKey)
+		uidStr := userIDStr.(string)
+		uid, _ := strconv.Atoi(uidStr)
 
-		startEngineered := time.Now()
-		engineered, errE := gsvc.AskCampusWithUIBContext(ctx, history)
-		durEngineered := time.Since(startEngineered)
+		convIDStr := c.Param("conversation_id")
+		cid, _ := strconv.Atoi(convIDStr)
 
-		startBaseline := time.Now()
-		baseline, errB := gsvc.AskCampusWithChat(ctx, history)
-		durBaseline := time.Since(startBaseline)
+		var conv models.Conversation
+		if err := db.Where("id = ? AND user_id = ?", cid, uid).First(&conv).Error; err != nil {
+			apierror.Respond(c, http.StatusNotFound, "conversation not found")
+			return
+		}
 
-		resp := gin.H{
-			"baseline":        strings.TrimSpace(baseline),
-			"engineered":      strings.TrimSpace(engineered),
-			"t_engineered_ms": durEngineered.Milliseconds(),
-			"t_baseline_ms":   durBaseline.Milliseconds(),
+		updates := map[string]any{"archived": archive, "archived_at": nil}
+		if archive {
+			updates["archived_at"] = time.Now()
 		}
-		if errE != nil {
-			resp["engineered_error"] = errE.Error()
+		if err := db.Model(&conv).Updates(updates).Error; err != nil {
+			apierror.Respond(c, http.StatusInternalServerError, "failed to update conversation")
+			return
 		}
-		if errB != nil {
-			resp["baseline_error"] = errB.Error()
+		c.JSON(http.StatusOK, gin.H{"conversation_id": conv.ID, "archived": archive})
+	}
+}
+
+// unarchiveOnActivity brings an archived conversation back when the user writes to it.
+func unarchiveOnActivity(db *gorm.DB, conv *models.Conversation) {
+	if !conv.Archived {
+		return
+	}
+	if err := db.Model(conv).Updates(map[string]any{"archived": false, "archived_at": nil}).Error; err != nil {
+		log.Printf("[conversation] ⚠️ failed to unarchive conversation %d: %v", conv.ID, err)
+	}
+}
+
+func DeleteAllConversations(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userIDStr, _ := c.Get(middleware.ContextUserIDKey)
+		uidStr := userIDStr.(string)
+		uid, _ := strconv.Atoi(uidStr)
+
+		var deleted int64
+		if err := db.Transaction(func(tx *gorm.DB) error {
+			res := tx.Where("user_id = ?", uid).Delete(&models.Conversation{})
+			deleted = res.RowsAffected
+			return res.Error
+		}); err != nil {
+			apierror.Respond(c, http.StatusInternalServerError, "failed to delete all conversations")
+			return
 		}
-		c.JSON(http.StatusOK, resp)
+		recordAudit(c, db, audit.Entry{Action: audit.ActionConversationDeleteAll, TargetType: "user", TargetID: uidStr,
+			After: gin.H{"conversations_deleted": deleted}})
+
+		c.JSON(http.StatusOK, gin.H{"msg": "all conversations moved to trash", "restorable_days": config.TrashRetentionDays})
 	}
 }
@@ -0,0 +1,67 @@
+package controllers
+
+import (
+	"AkuAI/models"
+	"AkuAI/pkg/apierror"
+	"AkuAI/pkg/audit"
+	"AkuAI/pkg/export"
+	"AkuAI/pkg/i18n"
+	"AkuAI/pkg/mail"
+	svc "AkuAI/pkg/services"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// EmailConversation mails the transcript of the signed-in user's
+// conversation :conversation_id, as a Markdown attachment, to their account
+// email.
+func EmailConversation(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		uid := currentUserID(c)
+		var conv models.Conversation
+		if err := db.Where("id = ? AND user_id = ?", c.Param("conversation_id"), uid).First(&conv).Error; err != nil {
+			apierror.Respond(c, http.StatusNotFound, "conversation not found")
+			return
+		}
+		m := mail.Default()
+		if m == nil {
+			apierror.Respond(c, http.StatusServiceUnavailable, "email is not configured")
+			return
+		}
+		var user models.User
+		if err := db.First(&user, uid).Error; err != nil {
+			apierror.Respond(c, http.StatusInternalServerError, "db error")
+			return
+		}
+		if err := db.Where("conversation_id = ?", conv.ID).Order("timestamp ASC, id ASC").Find(&conv.Messages).Error; err != nil {
+			apierror.Respond(c, http.StatusInternalServerError, "db error")
+			return
+		}
+
+		file := mail.Attachment{
+			Filename:    export.Filename(conv),
+			ContentType: "text/markdown; charset=UTF-8",
+			Data:        export.Transcript(conv, svc.EventLocation(), time.Now()),
+		}
+		locale := i18n.FromContext(c.Request.Context())
+		title := conv.Title
+		if title == "" {
+			title = i18n.T(locale, i18n.TranscriptTitle)
+		}
+		subject := i18n.T(locale, i18n.TranscriptSubject, title)
+		body := i18n.T(locale, i18n.TranscriptMail, user.Username, title)
+		if err := m.SendWithAttachments(user.Email, subject, body, file); err != nil {
+			log.Printf("[transcript] ⚠️ email of conversation %d to user %d failed: %v", conv.ID, uid, err)
+			apierror.Respond(c, http.StatusBadGateway, "the email could not be sent, try again later")
+			return
+		}
+		recordAudit(c, db, audit.Entry{Action: audit.ActionConversationEmail, TargetType: "conversation", TargetID: strconv.Itoa(int(conv.ID)),
+			After: gin.H{"messages": len(conv.Messages), "bytes": len(file.Data)}})
+		c.JSON(http.StatusOK, gin.H{"msg": "transcript sent", "email": user.Email, "filename": file.Filename})
+	}
+}
@@ -0,0 +1,253 @@
+package controllers
+
+import (
+	"AkuAI/models"
+	"AkuAI/pkg/apierror"
+	"AkuAI/pkg/audit"
+	"AkuAI/pkg/config"
+	"AkuAI/pkg/digest"
+	"AkuAI/pkg/mail"
+	"AkuAI/pkg/messaging"
+	svc "AkuAI/pkg/services"
+	"AkuAI/pkg/wshub"
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// digestRecommendations caps the recommended events of a digest, and
+// digestOthers the other events listed after them.
+const (
+	digestRecommendations = 5
+	digestOthers          = 5
+)
+
+// digestContent is one user's digest.
+type digestContent struct {
+	Title  string `json:"title"`
+	Text   string `json:"text"`
+	Events int    `json:"events"`
+}
+
+// DigestSchedule is the weekly send time set by DIGEST_WEEKDAY and
+// DIGEST_HOUR.
+func DigestSchedule() digest.Schedule {
+	return digest.Schedule{
+		Weekday:  time.Weekday(config.DigestWeekday),
+		Hour:     config.DigestHour,
+		Interval: digest.DefaultInterval,
+	}
+}
+
+// composeDigest writes uid's digest of the DIGEST_DAYS from from: the events
+// recommended for them, then the other events in that window. Events is 0
+// when nothing happens in the window.
+func composeDigest(db *gorm.DB, uid uint, from time.Time) (digestContent, error) {
+	from = svc.StartOfEventDay(from)
+	until := from.AddDate(0, 0, config.DigestDays)
+	d := digestContent{Title: "Ringkasan acara " + from.Format("2006-01-02")}
+	p, campus, err := recommendProfile(db, uid)
+	if err != nil {
+		return d, err
+	}
+	campuses := messagingCampusesOf(userTenant(db, uid))
+	if campuses == nil {
+		return d, fmt.Errorf("event data unavailable")
+	}
+	ds := campuses.Default()
+	if other := campuses.Dataset(campus); campus != "" && other != nil {
+		ds = other
+	}
+	inWindow := func(ev models.UIBEvent) bool {
+		t, ok := svc.EventDay(ev)
+		return ok && !t.Before(from) && t.Before(until)
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "📬 **Ringkasan acara %s – %s**", from.Format("02/01"), until.AddDate(0, 0, -1).Format("02/01/2006"))
+	picked := map[string]bool{}
+	n := 0
+	for _, r := range ds.RecommendEvents(p, from, 0) {
+		if n == digestRecommendations {
+			break
+		}
+		if !inWindow(r.Event) {
+			continue
+		}
+		if n == 0 {
+			b.WriteString("\n\n⭐ **Rekomendasi untuk kamu**")
+		}
+		n++
+		picked[r.Event.ID] = true
+		fmt.Fprintf(&b, "\n\n%d. %s", n, messaging.FormatEvent(r.Event))
+		if len(r.Reasons) > 0 {
+			b.WriteString("\n💡 " + strings.Join(r.Reasons, "; "))
+		}
+	}
+	var others []models.UIBEvent
+	for _, ev := range ds.GetAllEvents() {
+		if inWindow(ev) && !picked[ev.ID] {
+			others = append(others, ev)
+		}
+	}
+	if len(others) > 0 {
+		b.WriteString("\n\n" + messaging.FormatEvents("📅 **Acara lainnya**", others, digestOthers))
+	}
+	d.Events = n + len(others)
+	b.WriteString("\n\nTanyakan saja di percakapan ini untuk detail atau cara mendaftar.")
+	d.Text = b.String()
+	return d, nil
+}
+
+// postDigest posts user's digest as a new conversation started by the bot,
+// tells their open tabs, and emails it when they asked for that. It posts
+// nothing when no events fall in the window.
+func postDigest(db *gorm.DB, user models.User, from time.Time) (bool, error) {
+	d, err := composeDigest(db, user.ID, from)
+	if err != nil || d.Events == 0 {
+		return false, err
+	}
+	conv := models.Conversation{UserID: user.ID, TenantID: user.TenantID, Title: d.Title}
+	err = db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&conv).Error; err != nil {
+			return err
+		}
+		return tx.Create(&models.Message{ConversationID: conv.ID, Sender: "bot", Text: d.Text,
+			Timestamp: time.Now(), PromptMode: "digest", Status: models.MessageCompleted}).Error
+	})
+	if err != nil {
+		return false, err
+	}
+	uidStr := strconv.Itoa(int(user.ID))
+	wshub.Default().SendUser(uidStr, gin.H{"type": "digest", "conversation_id": conv.ID, "title": conv.Title})
+	if user.DigestEmail {
+		if m := mail.Default(); m != nil {
+			if err := m.Send(user.Email, d.Title, strings.ReplaceAll(d.Text, "**", "")); err != nil {
+				log.Printf("[digest] ⚠️ email to user %d failed: %v", user.ID, err)
+			}
+		}
+	}
+	return true, nil
+}
+
+// SendDigest is the digest.Send posting digests of the events from from, or
+// from the day of sending when from is zero.
+func SendDigest(db *gorm.DB, from time.Time) digest.Send {
+	return func(ctx context.Context, user models.User) (bool, error) {
+		day := from
+		if day.IsZero() {
+			day = time.Now()
+		}
+		return postDigest(db, user, day)
+	}
+}
+
+func digestFrom(c *gin.Context) (time.Time, bool) {
+	v := c.Query("from")
+	if v == "" {
+		return time.Now(), true
+	}
+	d, err := time.Parse("2006-01-02", v)
+	if err != nil {
+		apierror.Respond(c, http.StatusBadRequest, "from must be YYYY-MM-DD")
+		return d, false
+	}
+	return d, true
+}
+
+func digestSettingsJSON(user models.User) gin.H {
+	out := gin.H{
+		"enabled":         user.DigestEnabled,
+		"email":           user.DigestEmail,
+		"email_available": config.SMTPHost != "",
+		"last_sent_at":    user.DigestSentAt,
+		"next_at":         nil,
+	}
+	if user.DigestEnabled && config.DigestEnabled {
+		out["next_at"] = DigestSchedule().Next(time.Now())
+	}
+	return out
+}
+
+// Digest shows (GET) or changes (PUT {"enabled", "email"}) the signed-in
+// user's weekly event digest. The first digest after opting in comes at the
+// next scheduled send.
+func Digest(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var user models.User
+		if err := db.First(&user, currentUserID(c)).Error; err != nil {
+			apierror.Respond(c, http.StatusNotFound, "User not found")
+			return
+		}
+		if c.Request.Method == http.MethodPut {
+			var body struct {
+				Enabled *bool `json:"enabled"`
+				Email   *bool `json:"email"`
+			}
+			if !apierror.BindJSON(c, &body) {
+				return
+			}
+			updates := map[string]any{}
+			if body.Enabled != nil {
+				updates["digest_enabled"] = *body.Enabled
+				if *body.Enabled && !user.DigestEnabled {
+					updates["digest_sent_at"] = time.Now()
+				}
+			}
+			if body.Email != nil {
+				updates["digest_email"] = *body.Email
+			}
+			if len(updates) > 0 {
+				if err := db.Model(&user).Updates(updates).Error; err != nil {
+					apierror.Respond(c, http.StatusInternalServerError, "failed to update digest settings")
+					return
+				}
+				db.First(&user, user.ID)
+			}
+		}
+		c.JSON(http.StatusOK, digestSettingsJSON(user))
+	}
+}
+
+// PreviewDigest returns the signed-in user's digest as it would be sent for
+// ?from=YYYY-MM-DD (default today), without posting it.
+func PreviewDigest(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		from, ok := digestFrom(c)
+		if !ok {
+			return
+		}
+		d, err := composeDigest(db, currentUserID(c), from)
+		if err != nil {
+			apierror.Respond(c, http.StatusInternalServerError, "failed to compose digest")
+			return
+		}
+		c.JSON(http.StatusOK, d)
+	}
+}
+
+// RunDigest sends the digest, for the events from ?from= (default today), to
+// every opted-in user now, whatever the schedule.
+func RunDigest(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		from, ok := digestFrom(c)
+		if !ok {
+			return
+		}
+		res, err := digest.RunDue(c.Request.Context(), db, time.Now(), SendDigest(db, from))
+		if err != nil {
+			apierror.Respond(c, http.StatusInternalServerError, "digest run failed")
+			return
+		}
+		recordAudit(c, db, audit.Entry{Action: audit.ActionDigestRun, TargetType: "digest",
+			After: gin.H{"from": from.Format("2006-01-02"), "result": res}})
+		c.JSON(http.StatusOK, res)
+	}
+}
@@ -0,0 +1,163 @@
+package controllers
+
+import (
+	"AkuAI/middleware"
+	"AkuAI/models"
+	"AkuAI/pkg/apierror"
+	"AkuAI/pkg/audit"
+	"AkuAI/pkg/config"
+	"AkuAI/pkg/knowledge"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// UploadDocument ingests a Markdown, text or PDF FAQ document (multipart
+// field "file", optional "title"), chunks it and refreshes the knowledge index.
+func UploadDocument(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		uid, _ := strconv.ParseUint(c.GetString(middleware.ContextUserIDKey), 10, 64)
+
+		file, header, err := c.Request.FormFile("file")
+		if err != nil {
+			apierror.Respond(c, http.StatusBadRequest, "No document file provided")
+			return
+		}
+		defer file.Close()
+
+		if !knowledge.SupportedExt(header.Filename) {
+			apierror.Respond(c, http.StatusBadRequest, knowledge.ErrUnsupportedType.Error())
+			return
+		}
+		limit := int64(config.DocumentMaxUploadMB) << 20
+		if header.Size > limit {
+			apierror.Respond(c, http.StatusRequestEntityTooLarge, "Document exceeds "+strconv.Itoa(config.DocumentMaxUploadMB)+"MB")
+			return
+		}
+		data, err := io.ReadAll(io.LimitReader(file, limit+1))
+		if err != nil || int64(len(data)) > limit {
+			apierror.Respond(c, http.StatusBadRequest, "Failed to read document")
+			return
+		}
+
+		text, err := knowledge.ExtractText(header.Filename, data)
+		if err != nil {
+			apierror.Respond(c, http.StatusUnprocessableEntity, err.Error())
+			return
+		}
+		chunks := knowledge.ChunkText(text, config.KnowledgeChunkChars)
+
+		title := strings.TrimSpace(c.PostForm("title"))
+		if title == "" {
+			title = strings.TrimSuffix(filepath.Base(header.Filename), filepath.Ext(header.Filename))
+		}
+		doc := models.Document{
+			Title:       title,
+			Filename:    filepath.Base(header.Filename),
+			ContentType: strings.TrimPrefix(strings.ToLower(filepath.Ext(header.Filename)), "."),
+			Size:        int64(len(data)),
+			UploadedBy:  uint(uid),
+		}
+		for i, ch := range chunks {
+			doc.Chunks = append(doc.Chunks, models.DocumentChunk{Seq: i, Heading: ch.Heading, Text: ch.Text})
+		}
+		if err := db.Create(&doc).Error; err != nil {
+			apierror.Respond(c, http.StatusInternalServerError, "db error")
+			return
+		}
+		log.Printf("[documents] 📄 user %d uploaded %q (%d chunks)", uid, doc.Filename, len(chunks))
+		reloadKnowledge()
+
+		out := documentJSON(doc, len(chunks))
+		recordAudit(c, db, audit.Entry{Action: audit.ActionDocumentUpload, TargetType: "document", TargetID: strconv.Itoa(int(doc.ID)), After: out})
+		c.JSON(http.StatusCreated, gin.H{"document": out})
+	}
+}
+
+// ListDocuments returns the uploaded documents with their chunk counts.
+func ListDocuments(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var docs []models.Document
+		if err := db.Order("id DESC").Find(&docs).Error; err != nil {
+			apierror.Respond(c, http.StatusInternalServerError, "db error")
+			return
+		}
+		var counts []struct {
+			DocumentID uint
+			N          int
+		}
+		db.Model(&models.DocumentChunk{}).Select("document_id, COUNT(*) AS n").Group("document_id").Scan(&counts)
+		byDoc := make(map[uint]int, len(counts))
+		for _, ct := range counts {
+			byDoc[ct.DocumentID] = ct.N
+		}
+		out := make([]gin.H, 0, len(docs))
+		for _, d := range docs {
+			out = append(out, documentJSON(d, byDoc[d.ID]))
+		}
+		c.JSON(http.StatusOK, gin.H{"documents": out})
+	}
+}
+
+// DeleteDocument removes a document and its chunks from the knowledge base.
+func DeleteDocument(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil || id <= 0 {
+			apierror.Respond(c, http.StatusBadRequest, "invalid id")
+			return
+		}
+		var doc models.Document
+		var chunks int64
+		err = db.Transaction(func(tx *gorm.DB) error {
+			if err := tx.First(&doc, id).Error; err != nil {
+				return err
+			}
+			if err := tx.Delete(&doc).Error; err != nil {
+				return err
+			}
+			res := tx.Where("document_id = ?", id).Delete(&models.DocumentChunk{})
+			chunks = res.RowsAffected
+			return res.Error
+		})
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			apierror.Respond(c, http.StatusNotFound, "Document not found")
+			return
+		}
+		if err != nil {
+			apierror.Respond(c, http.StatusInternalServerError, "db error")
+			return
+		}
+		reloadKnowledge()
+		recordAudit(c, db, audit.Entry{Action: audit.ActionDocumentDelete, TargetType: "document", TargetID: strconv.Itoa(id), Before: documentJSON(doc, int(chunks))})
+		c.JSON(http.StatusOK, gin.H{"msg": "Document deleted"})
+	}
+}
+
+func reloadKnowledge() {
+	if idx := knowledge.Default(); idx != nil {
+		if err := idx.Reload(); err != nil {
+			log.Printf("[documents] ⚠️ knowledge reload failed: %v", err)
+		}
+	}
+}
+
+func documentJSON(d models.Document, chunks int) gin.H {
+	return gin.H{
+		"id":           d.ID,
+		"title":        d.Title,
+		"filename":     d.Filename,
+		"content_type": d.ContentType,
+		"size":         d.Size,
+		"chunks":       chunks,
+		"uploaded_by":  d.UploadedBy,
+		"created_at":   d.CreatedAt,
+	}
+}
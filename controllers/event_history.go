@@ -0,0 +1,90 @@
+package controllers
+
+import (
+	"AkuAI/models"
+	"AkuAI/pkg/apierror"
+	svc "AkuAI/pkg/services"
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// recordEventRevisions stores changes made by actorID of tenant tenantID
+// through source. Like audit records, failures are only logged.
+func recordEventRevisions(db *gorm.DB, tenantID string, actorID uint, source string, changes []svc.EventChange) {
+	if len(changes) == 0 {
+		return
+	}
+	rows := make([]models.EventRevision, 0, len(changes))
+	for _, ch := range changes {
+		fields, _ := json.Marshal(ch.Fields)
+		rows = append(rows, models.EventRevision{TenantID: tenantID, Campus: ch.Campus, EventID: ch.EventID, ActorID: actorID,
+			Source: source, Action: ch.Action, Changes: string(fields)})
+	}
+	if err := db.Create(&rows).Error; err != nil {
+		log.Printf("[uib-history] ⚠️ failed to record %d event revisions: %v", len(rows), err)
+	}
+}
+
+// EventHistory returns the revisions of event :id of ?campus=, newest
+// first, with who made them and the changed fields, for admins. ?limit=
+// caps the page (default 50, at most 200) and ?before= a revision ID pages
+// back. Events that no longer exist keep their history.
+func (ctrl *UIBController) EventHistory(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ds := ctrl.dataset(c)
+		if ds == nil {
+			return
+		}
+		limit := 50
+		if v := c.Query("limit"); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil || n < 1 || n > 200 {
+				apierror.Respond(c, http.StatusBadRequest, "limit must be between 1 and 200")
+				return
+			}
+			limit = n
+		}
+		q := db.Where("tenant_id = ? AND campus = ? AND event_id = ?", currentTenant(c).ID, ds.Institution(), c.Param("id"))
+		if v := c.Query("before"); v != "" {
+			before, err := strconv.ParseUint(v, 10, 64)
+			if err != nil {
+				apierror.Respond(c, http.StatusBadRequest, "before must be a revision ID")
+				return
+			}
+			q = q.Where("id < ?", before)
+		}
+		var revs []models.EventRevision
+		if err := q.Order("id DESC").Limit(limit).Find(&revs).Error; err != nil {
+			apierror.Respond(c, http.StatusInternalServerError, "db error")
+			return
+		}
+
+		ids := make([]uint, 0, len(revs))
+		for _, r := range revs {
+			ids = append(ids, r.ActorID)
+		}
+		var users []models.User
+		if len(ids) > 0 {
+			db.Select("id", "username").Where("id IN ?", ids).Find(&users)
+		}
+		names := make(map[uint]string, len(users))
+		for _, u := range users {
+			names[u.ID] = u.Username
+		}
+		out := make([]gin.H, 0, len(revs))
+		for _, r := range revs {
+			fields := []svc.FieldChange{}
+			_ = json.Unmarshal([]byte(r.Changes), &fields)
+			out = append(out, gin.H{"id": r.ID, "action": r.Action, "source": r.Source, "actor_id": r.ActorID,
+				"actor": names[r.ActorID], "created_at": r.CreatedAt, "changes": fields})
+		}
+		_, exists := ds.GetEventByID(c.Param("id"))
+		c.JSON(http.StatusOK, gin.H{"event_id": c.Param("id"), "campus": ds.Institution(), "exists": exists == nil,
+			"revisions": out})
+	}
+}
@@ -0,0 +1,94 @@
+package controllers
+
+import (
+	"AkuAI/pkg/apierror"
+	"AkuAI/pkg/audit"
+	"AkuAI/pkg/config"
+	"AkuAI/pkg/eventimport"
+	"AkuAI/pkg/webhook"
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// ImportEvents upserts events by ID from a CSV or XLSX upload (multipart
+// "file") into the dataset of ?campus=, for admins. "mapping" is an optional
+// JSON object from column header to event field; "dry_run=true" validates
+// and reports without writing. A file with invalid rows is rejected whole
+// with a 422 and the row errors.
+func (ctrl *UIBController) ImportEvents(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ds := ctrl.dataset(c)
+		if ds == nil {
+			return
+		}
+		if !ownsDataset(currentTenant(c), ds) {
+			apierror.Respond(c, http.StatusForbidden, "this dataset is shared by the whole deployment")
+			return
+		}
+		file, header, err := c.Request.FormFile("file")
+		if err != nil {
+			apierror.Respond(c, http.StatusBadRequest, "No CSV or XLSX file provided")
+			return
+		}
+		defer file.Close()
+		limit := int64(config.DocumentMaxUploadMB) << 20
+		data, err := io.ReadAll(io.LimitReader(file, limit+1))
+		if err != nil || int64(len(data)) > limit {
+			apierror.Respond(c, http.StatusRequestEntityTooLarge, "File exceeds "+strconv.Itoa(config.DocumentMaxUploadMB)+"MB")
+			return
+		}
+		var mapping map[string]string
+		if m := c.PostForm("mapping"); m != "" {
+			if err := json.Unmarshal([]byte(m), &mapping); err != nil {
+				apierror.Respond(c, http.StatusBadRequest, "mapping must be a JSON object of column header to field")
+				return
+			}
+		}
+		dryRun := c.PostForm("dry_run") == "true" || c.Query("dry_run") == "true"
+
+		table, err := eventimport.Read(header.Filename, data)
+		if errors.Is(err, eventimport.ErrUnsupportedType) {
+			apierror.Respond(c, http.StatusBadRequest, err.Error())
+			return
+		} else if err != nil {
+			apierror.Respond(c, http.StatusUnprocessableEntity, err.Error())
+			return
+		}
+		rows, ignored, err := eventimport.Map(table, mapping)
+		if err != nil {
+			apierror.RespondDetails(c, http.StatusUnprocessableEntity, err.Error(), gin.H{"fields": eventimport.Fields})
+			return
+		}
+		report, err := ds.ImportEvents(rows, dryRun)
+		if err != nil {
+			log.Printf("[uib-import] ❌ import into %s failed: %v", ds.Source(), err)
+			apierror.Respond(c, http.StatusInternalServerError, "Failed to import events")
+			return
+		}
+		if ignored == nil {
+			ignored = []string{}
+		}
+		status := http.StatusOK
+		if len(report.Errors) > 0 {
+			status = http.StatusUnprocessableEntity
+		} else if !dryRun {
+			recordEventRevisions(db, currentTenant(c).ID, currentUserID(c), "import", report.Changes)
+			recordAudit(c, db, audit.Entry{Action: audit.ActionEventImport, TargetType: "event_dataset", TargetID: ds.Source(),
+				After: gin.H{"file": header.Filename, "created": report.Created, "updated": report.Updated}})
+			for _, id := range report.Created {
+				if ev, err := ds.GetEventByID(id); err == nil {
+					webhook.Emit(db, webhook.EventEventCreated, 0, gin.H{"campus": ds.Institution(), "event": ev})
+				}
+			}
+			log.Printf("[uib-import] ✅ %s: %d created, %d updated from %s", ds.Source(), len(report.Created), len(report.Updated), header.Filename)
+		}
+		c.JSON(status, gin.H{"report": report, "ignored_columns": ignored, "campus": ds.Institution()})
+	}
+}
@@ -0,0 +1,196 @@
+package controllers
+
+import (
+	"AkuAI/models"
+	"AkuAI/pkg/apierror"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type folderRequest struct {
+	Name string `json:"name" binding:"notblank,max=100"`
+}
+
+func folderJSON(f models.Folder, count int64) gin.H {
+	return gin.H{"id": f.ID, "name": f.Name, "conversations_count": count, "created_at": f.CreatedAt}
+}
+
+// ownFolder loads the signed-in user's folder :id, answering 404 otherwise.
+func ownFolder(c *gin.Context, db *gorm.DB) (models.Folder, bool) {
+	var f models.Folder
+	id, _ := strconv.Atoi(c.Param("id"))
+	if err := db.Where("id = ? AND user_id = ?", id, currentUserID(c)).First(&f).Error; err != nil {
+		apierror.Respond(c, http.StatusNotFound, "folder not found")
+		return f, false
+	}
+	return f, true
+}
+
+// folderNameTaken reports whether the user has another folder named name.
+func folderNameTaken(db *gorm.DB, uid, exceptID uint, name string) bool {
+	var n int64
+	db.Model(&models.Folder{}).Where("user_id = ? AND LOWER(name) = ? AND id <> ?", uid, strings.ToLower(name), exceptID).Count(&n)
+	return n > 0
+}
+
+// ListFolders returns the signed-in user's folders by name, with how many
+// conversations each holds.
+func ListFolders(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		uid := currentUserID(c)
+		var folders []models.Folder
+		if err := db.Where("user_id = ?", uid).Order("name").Find(&folders).Error; err != nil {
+			apierror.Respond(c, http.StatusInternalServerError, "db error")
+			return
+		}
+		var rows []struct {
+			FolderID uint
+			N        int64
+		}
+		if err := db.Model(&models.Conversation{}).Select("folder_id, COUNT(*) AS n").
+			Where("user_id = ? AND folder_id IS NOT NULL", uid).Group("folder_id").Scan(&rows).Error; err != nil {
+			apierror.Respond(c, http.StatusInternalServerError, "db error")
+			return
+		}
+		counts := make(map[uint]int64, len(rows))
+		for _, r := range rows {
+			counts[r.FolderID] = r.N
+		}
+		out := make([]gin.H, 0, len(folders))
+		for _, f := range folders {
+			out = append(out, folderJSON(f, counts[f.ID]))
+		}
+		c.JSON(http.StatusOK, out)
+	}
+}
+
+// CreateFolder adds a folder {"name"}; names are unique per user, ignoring case.
+func CreateFolder(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var body folderRequest
+		if !apierror.BindJSON(c, &body) {
+			return
+		}
+		uid := currentUserID(c)
+		name := strings.TrimSpace(body.Name)
+		if folderNameTaken(db, uid, 0, name) {
+			apierror.Respond(c, http.StatusConflict, "folder already exists")
+			return
+		}
+		f := models.Folder{UserID: uid, Name: name}
+		if err := db.Create(&f).Error; err != nil {
+			apierror.Respond(c, http.StatusInternalServerError, "failed to create folder")
+			return
+		}
+		c.JSON(http.StatusCreated, folderJSON(f, 0))
+	}
+}
+
+// RenameFolder renames one of the signed-in user's folders.
+func RenameFolder(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		f, ok := ownFolder(c, db)
+		if !ok {
+			return
+		}
+		var body folderRequest
+		if !apierror.BindJSON(c, &body) {
+			return
+		}
+		name := strings.TrimSpace(body.Name)
+		if folderNameTaken(db, f.UserID, f.ID, name) {
+			apierror.Respond(c, http.StatusConflict, "folder already exists")
+			return
+		}
+		if err := db.Model(&f).Update("name", name).Error; err != nil {
+			apierror.Respond(c, http.StatusInternalServerError, "failed to rename folder")
+			return
+		}
+		var n int64
+		db.Model(&models.Conversation{}).Where("folder_id = ?", f.ID).Count(&n)
+		c.JSON(http.StatusOK, folderJSON(f, n))
+	}
+}
+
+// DeleteFolder removes a folder; its conversations stay, unfiled.
+func DeleteFolder(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		f, ok := ownFolder(c, db)
+		if !ok {
+			return
+		}
+		var unfiled int64
+		if err := db.Transaction(func(tx *gorm.DB) error {
+			res := tx.Unscoped().Model(&models.Conversation{}).Where("folder_id = ?", f.ID).Update("folder_id", nil)
+			if res.Error != nil {
+				return res.Error
+			}
+			unfiled = res.RowsAffected
+			return tx.Delete(&f).Error
+		}); err != nil {
+			apierror.Respond(c, http.StatusInternalServerError, "failed to delete folder")
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"msg": "folder deleted", "id": f.ID, "unfiled": unfiled})
+	}
+}
+
+// PinConversation pins (pin=true) or unpins a conversation. Pinned
+// conversations are listed first.
+func PinConversation(db *gorm.DB, pin bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		cid, _ := strconv.Atoi(c.Param("conversation_id"))
+		var conv models.Conversation
+		if err := db.Where("id = ? AND user_id = ?", cid, currentUserID(c)).First(&conv).Error; err != nil {
+			apierror.Respond(c, http.StatusNotFound, "conversation not found")
+			return
+		}
+		updates := map[string]any{"pinned": pin, "pinned_at": nil}
+		if pin {
+			updates["pinned_at"] = time.Now()
+		}
+		if err := db.Model(&conv).Updates(updates).Error; err != nil {
+			apierror.Respond(c, http.StatusInternalServerError, "failed to update conversation")
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"conversation_id": conv.ID, "pinned": pin})
+	}
+}
+
+// MoveConversation files a conversation into one of the user's folders
+// {"folder_id": 3}, or unfiles it with {"folder_id": null}.
+func MoveConversation(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var body struct {
+			FolderID *uint `json:"folder_id" binding:"omitempty,min=1"`
+		}
+		if !apierror.BindJSON(c, &body) {
+			return
+		}
+		uid := currentUserID(c)
+		cid, _ := strconv.Atoi(c.Param("conversation_id"))
+		var conv models.Conversation
+		if err := db.Where("id = ? AND user_id = ?", cid, uid).First(&conv).Error; err != nil {
+			apierror.Respond(c, http.StatusNotFound, "conversation not found")
+			return
+		}
+		if body.FolderID != nil {
+			var n int64
+			db.Model(&models.Folder{}).Where("id = ? AND user_id = ?", *body.FolderID, uid).Count(&n)
+			if n == 0 {
+				apierror.Respond(c, http.StatusNotFound, "folder not found")
+				return
+			}
+		}
+		if err := db.Model(&conv).Update("folder_id", body.FolderID).Error; err != nil {
+			apierror.Respond(c, http.StatusInternalServerError, "failed to update conversation")
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"conversation_id": conv.ID, "folder_id": body.FolderID})
+	}
+}
@@ -0,0 +1,61 @@
+package controllers
+
+import (
+	"AkuAI/models"
+	"AkuAI/pkg/analytics"
+	"AkuAI/pkg/apierror"
+	"AkuAI/pkg/audit"
+	"AkuAI/pkg/config"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// ListFrustratedConversations returns the conversations flagged for review
+// after a run of frustrated user messages, with their last user messages;
+// tenant admins only see their tenant's.
+func ListFrustratedConversations(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var scope *string
+		if t := currentTenant(c); !t.IsOperator() {
+			scope = &t.ID
+		}
+		flagged, err := analytics.Flagged(db, scope, 100)
+		if err != nil {
+			apierror.Respond(c, http.StatusInternalServerError, "db error")
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"alert_count": config.FrustrationAlertCount, "conversations": flagged})
+	}
+}
+
+// ReviewFrustratedConversation clears the flag of a conversation, which
+// flags again on the next run of frustrated messages.
+func ReviewFrustratedConversation(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := strconv.ParseUint(c.Param("conversation_id"), 10, 64)
+		if err != nil {
+			apierror.Respond(c, http.StatusNotFound, "no flagged conversation")
+			return
+		}
+		q := db.Model(&models.Conversation{}).Where("id = ? AND frustration_flagged_at IS NOT NULL", id)
+		if t := currentTenant(c); !t.IsOperator() {
+			q = q.Where("tenant_id = ?", t.ID)
+		}
+		res := q.UpdateColumn("frustration_flagged_at", nil)
+		if res.Error != nil {
+			apierror.Respond(c, http.StatusInternalServerError, "db error")
+			return
+		}
+		if res.RowsAffected == 0 {
+			apierror.Respond(c, http.StatusNotFound, "no flagged conversation")
+			return
+		}
+		log.Printf("[admin] ✅ user %d reviewed frustrated conversation %d", currentUserID(c), id)
+		recordAudit(c, db, audit.Entry{Action: audit.ActionFrustrationReview, TargetType: "conversation", TargetID: strconv.FormatUint(id, 10)})
+		c.JSON(http.StatusOK, gin.H{"conversation_id": id, "reviewed": true})
+	}
+}
@@ -0,0 +1,397 @@
+package controllers
+
+import (
+	"AkuAI/middleware"
+	"AkuAI/models"
+	"AkuAI/pkg/apikey"
+	"AkuAI/pkg/grpcserver"
+	svc "AkuAI/pkg/services"
+	"AkuAI/pkg/tenant"
+	tokenstore "AkuAI/pkg/token"
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"google.golang.org/protobuf/types/dynamicpb"
+	"gorm.io/gorm"
+)
+
+// RegisterGRPC adds ChatService and UIBEventService of
+// proto/akuai/v1/akuai.proto to srv. They share the chat pipeline and event
+// datasets of the HTTP API.
+func RegisterGRPC(srv *grpcserver.Server, health *grpcserver.Health, db *gorm.DB) error {
+	if err := srv.Register("akuai.v1.ChatService", map[string]grpcserver.Handler{
+		"Ask":       grpcAsk(db),
+		"StreamAsk": grpcStreamAsk(db),
+	}); err != nil {
+		return err
+	}
+	health.Set("akuai.v1.ChatService", grpcserver.StatusServing)
+
+	ctrl, err := NewUIBController()
+	if err != nil {
+		health.Set("akuai.v1.UIBEventService", grpcserver.StatusNotServing)
+		return err
+	}
+	if err := srv.Register("akuai.v1.UIBEventService", map[string]grpcserver.Handler{
+		"List":   ctrl.grpcList(db),
+		"Search": ctrl.grpcSearch(db),
+		"Get":    ctrl.grpcGet(db),
+	}); err != nil {
+		return err
+	}
+	health.Set("akuai.v1.UIBEventService", grpcserver.StatusServing)
+	return nil
+}
+
+// grpcUser returns the user of the bearer token in the authorization
+// metadata, and the tenant it was issued for.
+func grpcUser(s *grpcserver.Stream) (string, *tenant.Tenant, error) {
+	parts := strings.Fields(s.Metadata("authorization"))
+	if len(parts) != 2 || !strings.EqualFold(parts[0], "bearer") {
+		return "", nil, grpcserver.Errorf(grpcserver.Unauthenticated, "missing bearer token")
+	}
+	claims, err := tokenstore.Keys().Verify(parts[1])
+	if errors.Is(err, tokenstore.ErrRevoked) {
+		return "", nil, grpcserver.Errorf(grpcserver.Unauthenticated, "token has been revoked")
+	}
+	if err != nil {
+		return "", nil, grpcserver.Errorf(grpcserver.Unauthenticated, "invalid token")
+	}
+	t, ok := tenant.Default().Get(claims.TenantID)
+	if !ok {
+		return "", nil, grpcserver.Errorf(grpcserver.Unauthenticated, "unknown tenant")
+	}
+	return claims.UserID, t, nil
+}
+
+// grpcAuthorizeUIB accepts a user token, for the events of its tenant, or
+// an API key with the uib:read scope in the x-api-key metadata, for the
+// operator's.
+func grpcAuthorizeUIB(s *grpcserver.Stream, db *gorm.DB) (*tenant.Tenant, error) {
+	key := strings.TrimSpace(s.Metadata("x-api-key"))
+	if key == "" {
+		_, t, err := grpcUser(s)
+		return t, err
+	}
+	_, err := middleware.VerifyAPIKey(db, key, apikey.ScopeUIBRead)
+	var scopeErr *middleware.APIKeyScopeError
+	if errors.As(err, &scopeErr) {
+		return nil, grpcserver.Errorf(grpcserver.PermissionDenied, "%v", err)
+	}
+	if err != nil {
+		return nil, grpcserver.Errorf(grpcserver.Unauthenticated, "%v", err)
+	}
+	return tenant.Operator, nil
+}
+
+// grpcChatTurn is the part of Ask and StreamAsk before generation: the
+// saved user message in its conversation and what the reply needs.
+type grpcChatTurn struct {
+	uidStr  string
+	uid     uint
+	tenant  *tenant.Tenant
+	message string
+	conv    models.Conversation
+	mode    string
+	memory  string
+	prefs   models.UserPreferences
+	history []svc.ChatMessage
+	release func()
+}
+
+func startGRPCChatTurn(s *grpcserver.Stream, db *gorm.DB) (*grpcChatTurn, error) {
+	uidStr, tn, err := grpcUser(s)
+	if err != nil {
+		return nil, err
+	}
+	uid, _ := strconv.Atoi(uidStr)
+	req, err := s.RecvOne()
+	if err != nil {
+		return nil, err
+	}
+	t := &grpcChatTurn{uidStr: uidStr, uid: uint(uid), tenant: tn, message: grpcserver.GetString(req, "message")}
+	if strings.TrimSpace(t.message) == "" {
+		return nil, grpcserver.Errorf(grpcserver.InvalidArgument, "message is required")
+	}
+	mode := strings.ToLower(strings.TrimSpace(grpcserver.GetString(req, "mode")))
+	if mode != "" && mode != "baseline" && mode != "engineered" {
+		return nil, grpcserver.Errorf(grpcserver.InvalidArgument, "mode must be baseline or engineered")
+	}
+	verbosity, ok := models.ParseVerbosity(grpcserver.GetString(req, "verbosity"))
+	if !ok {
+		return nil, grpcserver.Errorf(grpcserver.InvalidArgument, "verbosity must be short, normal or detailed")
+	}
+	if !middleware.DuplicateGuard(uidStr, t.message) {
+		return nil, grpcserver.Errorf(grpcserver.AlreadyExists, "duplicate message")
+	}
+
+	var convID *uint
+	if id := grpcserver.GetUint(req, "conversation_id"); id != 0 {
+		v := uint(id)
+		convID = &v
+	}
+	t.conv, err = openConversation(db, t.uid, tn.ID, convID, t.message, grpcserver.GetBool(req, "incognito"))
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, grpcserver.Errorf(grpcserver.NotFound, "conversation not found")
+	}
+	if err != nil {
+		return nil, grpcserver.Errorf(grpcserver.Internal, "failed to create conversation: %v", err)
+	}
+	msgUser := models.Message{ConversationID: t.conv.ID, Sender: "user", Text: t.message, Timestamp: time.Now(), Label: queryLabel(s.Context(), t.message)}
+	if err := db.Create(&msgUser).Error; err != nil {
+		return nil, grpcserver.Errorf(grpcserver.Internal, "failed to save message: %v", err)
+	}
+	t.mode = assignPromptArm(db, &t.conv, mode)
+	t.memory = personalSection(db, t.conv, msgUser)
+	t.prefs = middleware.UserPreferences(db, t.uid)
+	if verbosity != "" {
+		t.prefs.Verbosity = verbosity
+	}
+	t.history = chatHistory(t.conv, t.message)
+
+	t.release, err = middleware.TryAcquireUserSlot(s.Context(), uidStr)
+	if err != nil {
+		return nil, grpcserver.Errorf(grpcserver.ResourceExhausted, "%v", err)
+	}
+	return t, nil
+}
+
+// grpcAsk is ChatService.Ask.
+func grpcAsk(db *gorm.DB) grpcserver.Handler {
+	return func(s *grpcserver.Stream) error {
+		t, err := startGRPCChatTurn(s, db)
+		if err != nil {
+			return err
+		}
+		defer t.release()
+		ctx, cancel := context.WithTimeout(tenant.WithTenant(s.Context(), t.tenant), 60*time.Second)
+		defer cancel()
+		ctx = svc.WithIncognito(svc.WithPreferences(ctx, t.prefs), t.conv.Incognito)
+		ctx, info := svc.WithGenerationInfo(svc.WithUserMemory(ctx, t.memory))
+		reply := generateChatReply(ctx, t.uidStr, t.mode, t.message, t.history)
+		msg, err := saveBotMessage(ctx, db, t.conv.ID, t.message, reply, t.mode, info)
+		if err != nil {
+			return grpcserver.Errorf(grpcserver.Internal, "failed to save bot reply: %v", err)
+		}
+		return s.Send(grpcReply(s.NewResponse(), msg, t.mode))
+	}
+}
+
+func grpcReply(resp *dynamicpb.Message, msg models.Message, mode string) *dynamicpb.Message {
+	grpcserver.Set(resp, "conversation_id", uint64(msg.ConversationID))
+	grpcserver.Set(resp, "message_id", uint64(msg.ID))
+	grpcserver.Set(resp, "reply", msg.Text)
+	grpcserver.Set(resp, "mode", mode)
+	if msg.Confidence != nil {
+		grpcserver.Set(resp, "confidence", *msg.Confidence)
+	}
+	grpcserver.Set(resp, "low_confidence", msg.LowConfidence)
+	return resp
+}
+
+// grpcStreamAsk is ChatService.StreamAsk. Like the SSE endpoint, the reply is
+// generated and saved even if the client goes away.
+func grpcStreamAsk(db *gorm.DB) grpcserver.Handler {
+	return func(s *grpcserver.Stream) error {
+		t, err := startGRPCChatTurn(s, db)
+		if err != nil {
+			return err
+		}
+		defer t.release()
+		event := func(typ string) *dynamicpb.Message {
+			m := s.NewResponse()
+			grpcserver.Set(m, "type", typ)
+			grpcserver.Set(m, "conversation_id", uint64(t.conv.ID))
+			return m
+		}
+		if err := s.Send(event("started")); err != nil {
+			return err
+		}
+
+		ctx, cancel := context.WithTimeout(tenant.WithTenant(context.WithoutCancel(s.Context()), t.tenant), 75*time.Second)
+		defer cancel()
+		ctx = svc.WithIncognito(svc.WithPreferences(ctx, t.prefs), t.conv.Incognito)
+		ctx, info := svc.WithGenerationInfo(svc.WithUserMemory(ctx, t.memory))
+		reply := generateChatReply(ctx, t.uidStr, t.mode, t.message, t.history)
+
+		var sendErr error
+		post := replyPipeline(ctx).Stream(func(chunk string) {
+			if sendErr == nil {
+				m := event("delta")
+				grpcserver.Set(m, "text", chunk)
+				sendErr = s.Send(m)
+			}
+		})
+		replayText(reply, post.Write, func() bool { return sendErr != nil })
+		post.Flush()
+
+		status := models.MessageCompleted
+		if ctx.Err() != nil {
+			status = models.MessageError
+		}
+		msg, err := saveBotMessageWithStatus(ctx, db, t.conv.ID, t.message, reply, t.mode, status, info)
+		if err != nil {
+			return grpcserver.Errorf(grpcserver.Internal, "failed to save bot reply: %v", err)
+		}
+		if sendErr != nil {
+			return sendErr
+		}
+		done := event("done")
+		grpcserver.Set(done, "message_id", uint64(msg.ID))
+		grpcserver.Set(done, "mode", t.mode)
+		grpcserver.Set(done, "status", status)
+		return s.Send(done)
+	}
+}
+
+// grpcDataset is the campus of campuses named in the request, UIB when
+// empty.
+func grpcDataset(campuses *svc.CampusDataService, req *dynamicpb.Message) (*svc.UIBEventService, error) {
+	name := grpcserver.GetString(req, "campus")
+	if name == "" {
+		return campuses.Default(), nil
+	}
+	if ds := campuses.Dataset(name); ds != nil {
+		return ds, nil
+	}
+	return nil, grpcserver.Errorf(grpcserver.NotFound, "no event data for campus %s (have %s)", name, strings.Join(campuses.Campuses(), ", "))
+}
+
+// grpcEventRequest authorizes a UIBEventService call and reads its request,
+// the datasets of the caller's tenant and the campus.
+func (ctrl *UIBController) grpcEventRequest(s *grpcserver.Stream, db *gorm.DB) (*dynamicpb.Message, *svc.CampusDataService, *svc.UIBEventService, error) {
+	t, err := grpcAuthorizeUIB(s, db)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	req, err := s.RecvOne()
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	campuses := ctrl.campusesOf(t)
+	ds, err := grpcDataset(campuses, req)
+	return req, campuses, ds, err
+}
+
+func grpcEvent(m *dynamicpb.Message, e models.UIBEvent) {
+	for name, v := range map[string]string{
+		"id": e.ID, "type": e.Type, "title": e.Title, "date": e.Date, "time": e.Time, "location": e.Location,
+		"platform": e.Platform, "institution": e.Institution, "department": e.Department, "description": e.Description,
+		"speaker": e.Speaker, "requirements": e.Requirements, "registration_fee": e.RegistrationFee, "contact": e.Contact,
+		"registration_link": e.RegistrationLink, "registration_deadline": e.RegistrationDeadline,
+		"faculty": svc.EventFaculty(e),
+	} {
+		grpcserver.Set(m, name, v)
+	}
+	if e.StartsAt != nil {
+		grpcserver.Set(m, "starts_at", e.StartsAt.Format(time.RFC3339))
+	}
+	if e.EndsAt != nil {
+		grpcserver.Set(m, "ends_at", e.EndsAt.Format(time.RFC3339))
+	}
+}
+
+func grpcEventList(s *grpcserver.Stream, ds *svc.UIBEventService, events []models.UIBEvent) error {
+	resp := s.NewResponse()
+	for _, e := range events {
+		grpcEvent(grpcserver.Append(resp, "events"), e)
+	}
+	grpcserver.Set(resp, "institution", ds.Institution())
+	return s.Send(resp)
+}
+
+// grpcEventType returns the normalized "type" field of req, "" when unset.
+func grpcEventType(req *dynamicpb.Message) (string, error) {
+	typ := grpcserver.GetString(req, "type")
+	if typ == "" {
+		return "", nil
+	}
+	t, ok := models.NormalizeEventType(typ)
+	if !ok {
+		return "", grpcserver.Errorf(grpcserver.InvalidArgument, "type must be one of %s", strings.Join(models.EventTypes, ", "))
+	}
+	return t, nil
+}
+
+// grpcList is UIBEventService.List: all events, or those of a month, a type
+// or upcoming ones, like GET /uib/events and its variants.
+func (ctrl *UIBController) grpcList(db *gorm.DB) grpcserver.Handler {
+	return func(s *grpcserver.Stream) error {
+		req, _, ds, err := ctrl.grpcEventRequest(s, db)
+		if err != nil {
+			return err
+		}
+		typ, err := grpcEventType(req)
+		if err != nil {
+			return err
+		}
+		var events []models.UIBEvent
+		switch month := grpcserver.GetString(req, "month"); {
+		case grpcserver.GetBool(req, "upcoming"):
+			events = ds.GetUpcomingEvents()
+		case month != "" || typ != "":
+			events = ds.SearchEvents(models.EventSearchCriteria{EventType: typ, Month: month})
+		default:
+			events = ds.GetAllEvents()
+		}
+		return grpcEventList(s, ds, events)
+	}
+}
+
+// grpcSearch is UIBEventService.Search: a natural-language query like
+// POST /uib/query, or the filters of GET /uib/events/search.
+func (ctrl *UIBController) grpcSearch(db *gorm.DB) grpcserver.Handler {
+	return func(s *grpcserver.Stream) error {
+		req, campuses, ds, err := ctrl.grpcEventRequest(s, db)
+		if err != nil {
+			return err
+		}
+		if q := strings.TrimSpace(grpcserver.GetString(req, "query")); q != "" {
+			if grpcserver.GetString(req, "campus") == "" {
+				if _, named := campuses.ForQuery(q); named != nil {
+					ds = named
+				}
+			}
+			var events []models.UIBEvent
+			if ds.AnalyzeQueryForUIB(q) {
+				events = ds.GetRelevantEventsForQuery(q)
+			}
+			return grpcEventList(s, ds, events)
+		}
+		typ, err := grpcEventType(req)
+		if err != nil {
+			return err
+		}
+		return grpcEventList(s, ds, ds.SearchEvents(models.EventSearchCriteria{
+			EventType:  typ,
+			Month:      grpcserver.GetString(req, "month"),
+			Department: grpcserver.GetString(req, "department"),
+			Faculty:    grpcserver.GetString(req, "faculty"),
+			FreeOnly:   grpcserver.GetBool(req, "free_only"),
+		}))
+	}
+}
+
+// grpcGet is UIBEventService.Get.
+func (ctrl *UIBController) grpcGet(db *gorm.DB) grpcserver.Handler {
+	return func(s *grpcserver.Stream) error {
+		req, _, ds, err := ctrl.grpcEventRequest(s, db)
+		if err != nil {
+			return err
+		}
+		id := grpcserver.GetString(req, "id")
+		if id == "" {
+			return grpcserver.Errorf(grpcserver.InvalidArgument, "id is required")
+		}
+		e, err := ds.GetEventByID(id)
+		if err != nil {
+			return grpcserver.Errorf(grpcserver.NotFound, "event not found: %v", err)
+		}
+		resp := s.NewResponse()
+		grpcEvent(resp, *e)
+		return s.Send(resp)
+	}
+}
@@ -0,0 +1,186 @@
+package controllers
+
+import (
+	"AkuAI/middleware"
+	"AkuAI/models"
+	"AkuAI/pkg/apierror"
+	"AkuAI/pkg/audit"
+	"AkuAI/pkg/config"
+	svc "AkuAI/pkg/services"
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+func guestKey(c *gin.Context) string {
+	return "guest:" + c.GetString(middleware.ContextGuestIDKey)
+}
+
+// openGuestConversation is openConversation for the guest gid of tenant
+// tenantID, whose conversations have no user.
+func openGuestConversation(db *gorm.DB, gid, tenantID string, convID *uint, message string) (models.Conversation, error) {
+	var conv models.Conversation
+	if convID != nil {
+		if err := db.Preload("Messages").Where("id = ? AND guest_id = ? AND tenant_id = ?", *convID, gid, tenantID).First(&conv).Error; err != nil {
+			return conv, gorm.ErrRecordNotFound
+		}
+		return conv, nil
+	}
+	title := message
+	if len(title) > 30 {
+		title = title[:30] + "..."
+	}
+	conv = models.Conversation{GuestID: &gid, TenantID: tenantID, Title: title}
+	return conv, db.Create(&conv).Error
+}
+
+// GuestChat is CreateOrAddMessage for guests without an account. Guest
+// conversations are capped at GUEST_MAX_MESSAGES questions, get no personal
+// memory, and are purged after GUEST_CONVERSATION_TTL_HOURS unless claimed
+// with POST /auth/claim.
+func GuestChat(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		gid := c.GetString(middleware.ContextGuestIDKey)
+		key := guestKey(c)
+
+		var body chatRequest
+		if !apierror.BindJSON(c, &body) {
+			return
+		}
+		requestVerbosity(c, body.Verbosity)
+		requestedMode := requestedPromptMode(c, body.Mode)
+		if !middleware.DuplicateGuard(key, body.Message) {
+			apierror.Respond(c, http.StatusConflict, "duplicate message")
+			return
+		}
+
+		conv, err := openGuestConversation(db, gid, currentTenant(c).ID, body.ConversationID, body.Message)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			apierror.Respond(c, http.StatusNotFound, "conversation not found")
+			return
+		}
+		if err != nil {
+			apierror.Respond(c, http.StatusInternalServerError, "failed to create conversation")
+			return
+		}
+		asked := 0
+		for _, m := range conv.Messages {
+			if m.Sender == "user" {
+				asked++
+			}
+		}
+		if asked >= config.GuestMaxMessages {
+			apierror.RespondCode(c, http.StatusForbidden, apierror.CodeGuestLimit,
+				"guest conversations are limited to "+strconv.Itoa(config.GuestMaxMessages)+" questions; sign up to keep chatting")
+			return
+		}
+
+		release, err := middleware.TryAcquireUserSlot(c.Request.Context(), key)
+		if err != nil {
+			middleware.AbortSlotBusy(c, err)
+			return
+		}
+		defer release()
+
+		msgUser := models.Message{ConversationID: conv.ID, Sender: "user", Text: body.Message, Timestamp: time.Now(), Label: queryLabel(c.Request.Context(), body.Message)}
+		if err := db.Create(&msgUser).Error; err != nil {
+			apierror.Respond(c, http.StatusInternalServerError, "failed to save message")
+			return
+		}
+		effMode := assignPromptArm(db, &conv, requestedMode)
+		history := chatHistory(conv, body.Message)
+
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 60*time.Second)
+		defer cancel()
+		ctx, info := svc.WithGenerationInfo(ctx)
+		botReply := generateChatReply(ctx, key, effMode, body.Message, history)
+		if _, err := saveBotMessage(ctx, db, conv.ID, body.Message, botReply, effMode, info); err != nil {
+			apierror.Respond(c, http.StatusInternalServerError, "failed to save bot reply")
+			return
+		}
+
+		payload, err := conversationPayload(db, conv.ID)
+		if err != nil {
+			apierror.Respond(c, http.StatusInternalServerError, "failed to load messages")
+			return
+		}
+		payload["model"], payload["attempts"] = info.Model(), info.Attempts()
+		payload["service_state"], _ = observeServiceState(c.Request.Context(), info)
+		payload["guest"] = true
+		payload["questions_left"] = config.GuestMaxMessages - asked - 1
+		c.JSON(http.StatusCreated, payload)
+	}
+}
+
+// ListGuestConversations returns the guest's conversations, newest first.
+func ListGuestConversations(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var convs []models.Conversation
+		if err := db.Where("guest_id = ? AND tenant_id = ?", c.GetString(middleware.ContextGuestIDKey), currentTenant(c).ID).
+			Order("updated_at DESC").Find(&convs).Error; err != nil {
+			apierror.Respond(c, http.StatusInternalServerError, "db error")
+			return
+		}
+		result := make([]gin.H, 0, len(convs))
+		for _, conv := range convs {
+			result = append(result, gin.H{"id": conv.ID, "title": conv.Title, "created_at": conv.CreatedAt})
+		}
+		c.JSON(http.StatusOK, result)
+	}
+}
+
+// GetGuestConversation returns one of the guest's conversations with its
+// messages.
+func GetGuestConversation(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		cid, _ := strconv.Atoi(c.Param("conversation_id"))
+		var conv models.Conversation
+		if err := db.Where("id = ? AND guest_id = ? AND tenant_id = ?", cid, c.GetString(middleware.ContextGuestIDKey), currentTenant(c).ID).First(&conv).Error; err != nil {
+			apierror.Respond(c, http.StatusNotFound, "conversation not found")
+			return
+		}
+		payload, err := conversationPayload(db, conv.ID)
+		if err != nil {
+			apierror.Respond(c, http.StatusInternalServerError, "db error")
+			return
+		}
+		payload["title"] = conv.Title
+		c.JSON(http.StatusOK, payload)
+	}
+}
+
+// ClaimGuestConversations moves the conversations of the request's guest
+// cookie into the signed-in account, typically right after registering,
+// and clears the cookie.
+func ClaimGuestConversations(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		gid := middleware.GuestID(c)
+		if gid == "" {
+			apierror.Respond(c, http.StatusBadRequest, "no guest session to claim")
+			return
+		}
+		uid := currentUserID(c)
+
+		ids := make([]uint, 0)
+		if err := db.Model(&models.Conversation{}).Where("guest_id = ? AND tenant_id = ?", gid, currentTenant(c).ID).Pluck("id", &ids).Error; err != nil {
+			apierror.Respond(c, http.StatusInternalServerError, "db error")
+			return
+		}
+		if len(ids) > 0 {
+			if err := db.Model(&models.Conversation{}).Where("id IN ? AND guest_id = ?", ids, gid).
+				Updates(map[string]any{"user_id": uid, "guest_id": nil}).Error; err != nil {
+				apierror.Respond(c, http.StatusInternalServerError, "failed to claim conversations")
+				return
+			}
+			recordAudit(c, db, audit.Entry{Action: audit.ActionGuestClaim, TargetType: "conversation",
+				After: gin.H{"conversation_ids": ids}})
+		}
+		middleware.SetGuestCookie(c, "", -1)
+		c.JSON(http.StatusOK, gin.H{"claimed": len(ids), "conversation_ids": ids})
+	}
+}
@@ -141,6 +141,7 @@ func (ctrl *ImageController) HealthCheck(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{
 		"service": "google-images",
 		"status":  status,
+		"quota":   services.GoogleQuotaUsage(),
 		"message": "Image search service status",
 	})
 }
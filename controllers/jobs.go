@@ -0,0 +1,96 @@
+package controllers
+
+import (
+	"AkuAI/middleware"
+	"AkuAI/pkg/apierror"
+	"AkuAI/pkg/jobs"
+	"AkuAI/pkg/wshub"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+)
+
+// GetJob returns the status, and once finished the result, of an async job.
+func GetJob() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		uidStr := c.GetString(middleware.ContextUserIDKey)
+		job, ok := jobs.Default().Get(c.Param("id"), uidStr)
+		if !ok {
+			apierror.Respond(c, http.StatusNotFound, "job not found")
+			return
+		}
+		c.JSON(http.StatusOK, job)
+	}
+}
+
+// JobsWS pushes a job_done event for every async job of the user that
+// finishes while the socket is open, and every event the server sends the
+// user through the hub (announcements, notifications).
+func JobsWS() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userIDStr, ok := wsUserID(c)
+		if !ok {
+			return
+		}
+		hc, ok := registerWS(c, userIDStr, "events", true)
+		if !ok {
+			return
+		}
+		defer wshub.Default().Unregister(hc)
+
+		conn, err := upgrader.Upgrade(c.Writer, c.Request, nil)
+		if err != nil {
+			log.Printf("[ws] upgrade error: %v", err)
+			return
+		}
+		defer conn.Close()
+
+		events, unsubscribe := jobs.Default().Subscribe(userIDStr)
+		defer unsubscribe()
+
+		conn.SetReadLimit(4096)
+		_ = conn.SetReadDeadline(time.Now().Add(60 * time.Second))
+		conn.SetPongHandler(func(string) error {
+			return conn.SetReadDeadline(time.Now().Add(60 * time.Second))
+		})
+
+		closed := make(chan struct{})
+		go func() {
+			defer close(closed)
+			for {
+				if _, _, err := conn.ReadMessage(); err != nil {
+					return
+				}
+				if !allowWSMessage(conn, userIDStr) {
+					return
+				}
+			}
+		}()
+
+		ping := time.NewTicker(30 * time.Second)
+		defer ping.Stop()
+
+		_ = conn.WriteJSON(gin.H{"type": "subscribed"})
+		for {
+			select {
+			case <-closed:
+				return
+			case <-ping.C:
+				if err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(5*time.Second)); err != nil {
+					return
+				}
+			case job := <-events:
+				if err := conn.WriteJSON(gin.H{"type": "job_done", "job": job}); err != nil {
+					return
+				}
+			case msg := <-hc.Events():
+				if err := conn.WriteJSON(msg); err != nil {
+					return
+				}
+			}
+		}
+	}
+}
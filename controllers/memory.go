@@ -0,0 +1,137 @@
+package controllers
+
+import (
+	"AkuAI/middleware"
+	"AkuAI/models"
+	"AkuAI/pkg/apierror"
+	"AkuAI/pkg/memory"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// personalSection is the memory section plus, for "acara apa yang cocok
+// untuk saya?", the user's event recommendations and, for "apakah ada acara
+// yang bentrok?", the schedule conflicts.
+func personalSection(db *gorm.DB, conv models.Conversation, msg models.Message) string {
+	return memorySection(db, conv, msg) + recommendationSection(db, conv.UserID, msg) + conflictSection(msg)
+}
+
+// memorySection learns from a saved user message, unless conv is
+// incognito, and returns the memory section for the reply's prompt, or ""
+// when the user has memory off.
+func memorySection(db *gorm.DB, conv models.Conversation, msg models.Message) string {
+	uid := conv.UserID
+	var user models.User
+	if err := db.Select("id", "memory_enabled").First(&user, uid).Error; err != nil || !user.MemoryEnabled {
+		return ""
+	}
+	if conv.Incognito {
+		// nothing is remembered from incognito conversations
+	} else if learned, err := memory.Learn(db, uid, msg.ID, msg.Text); err != nil {
+		log.Printf("[memory] ⚠️ failed to store facts for user %d: %v", uid, err)
+	} else if len(learned) > 0 {
+		log.Printf("[memory] 🧠 learned %d facts for user %d", len(learned), uid)
+	}
+	facts, err := memory.Facts(db, uid)
+	if err != nil {
+		log.Printf("[memory] ⚠️ failed to load facts for user %d: %v", uid, err)
+		return ""
+	}
+	return memory.Section(facts)
+}
+
+func memoryJSON(db *gorm.DB, user models.User) (gin.H, error) {
+	facts, err := memory.Facts(db, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	items := make([]gin.H, 0, len(facts))
+	for _, f := range facts {
+		items = append(items, gin.H{"id": f.ID, "kind": f.Kind, "value": f.Value, "created_at": f.CreatedAt})
+	}
+	return gin.H{"enabled": user.MemoryEnabled, "facts": items}, nil
+}
+
+// Memory shows (GET) or switches (PUT {"enabled": bool}) what the assistant
+// remembers about the current user. Turning memory off deletes every fact.
+func Memory(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userIDStr, _ := c.Get(middleware.ContextUserIDKey)
+		uidStr, _ := userIDStr.(string)
+		uid, _ := strconv.Atoi(uidStr)
+
+		var user models.User
+		if err := db.First(&user, uid).Error; err != nil {
+			apierror.Respond(c, http.StatusNotFound, "User not found")
+			return
+		}
+
+		if c.Request.Method == http.MethodPut {
+			var body struct {
+				Enabled *bool `json:"enabled"`
+			}
+			if err := c.ShouldBindJSON(&body); err != nil || body.Enabled == nil {
+				apierror.Respond(c, http.StatusBadRequest, "enabled is required")
+				return
+			}
+			err := db.Transaction(func(tx *gorm.DB) error {
+				if err := tx.Model(&user).Update("memory_enabled", *body.Enabled).Error; err != nil {
+					return err
+				}
+				if !*body.Enabled {
+					return memory.Forget(tx, user.ID)
+				}
+				return nil
+			})
+			if err != nil {
+				apierror.Respond(c, http.StatusInternalServerError, "Failed to update memory")
+				return
+			}
+		}
+
+		out, err := memoryJSON(db, user)
+		if err != nil {
+			apierror.Respond(c, http.StatusInternalServerError, "Failed to load memory")
+			return
+		}
+		c.JSON(http.StatusOK, out)
+	}
+}
+
+// ForgetMemory deletes one remembered fact (/profile/memory/:id) or all of
+// them (/profile/memory); memory stays on.
+func ForgetMemory(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userIDStr, _ := c.Get(middleware.ContextUserIDKey)
+		uidStr, _ := userIDStr.(string)
+		uid, _ := strconv.Atoi(uidStr)
+
+		if c.Param("id") == "" {
+			if err := memory.Forget(db, uint(uid)); err != nil {
+				apierror.Respond(c, http.StatusInternalServerError, "Failed to delete memory")
+				return
+			}
+			c.JSON(http.StatusOK, gin.H{"msg": "Memory cleared"})
+			return
+		}
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil || id <= 0 {
+			apierror.Respond(c, http.StatusBadRequest, "invalid id")
+			return
+		}
+		res := db.Unscoped().Where("id = ? AND user_id = ?", id, uid).Delete(&models.UserMemory{})
+		if res.Error != nil {
+			apierror.Respond(c, http.StatusInternalServerError, "Failed to delete fact")
+			return
+		}
+		if res.RowsAffected == 0 {
+			apierror.Respond(c, http.StatusNotFound, "fact not found")
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"msg": "Fact deleted"})
+	}
+}
@@ -0,0 +1,149 @@
+package controllers
+
+import (
+	"AkuAI/middleware"
+	"AkuAI/models"
+	"AkuAI/pkg/apierror"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+const (
+	defaultMessagePageSize = 50
+	maxMessagePageSize     = 200
+)
+
+// messageStat summarises a conversation's messages without loading them.
+type messageStat struct {
+	Count   int64
+	FirstAt time.Time
+	LastAt  time.Time
+}
+
+// messageStats returns message counts and first/last timestamps for convIDs
+// with two aggregate queries, whatever the number of conversations.
+func messageStats(db *gorm.DB, convIDs []uint) (map[uint]messageStat, error) {
+	stats := make(map[uint]messageStat, len(convIDs))
+	if len(convIDs) == 0 {
+		return stats, nil
+	}
+	var rows []struct {
+		ConversationID uint
+		N              int64
+		FirstID        uint
+		LastID         uint
+	}
+	if err := db.Model(&models.Message{}).
+		Select("conversation_id, COUNT(*) AS n, MIN(id) AS first_id, MAX(id) AS last_id").
+		Where("conversation_id IN ?", convIDs).
+		Group("conversation_id").Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+	ids := make([]uint, 0, 2*len(rows))
+	for _, r := range rows {
+		ids = append(ids, r.FirstID, r.LastID)
+	}
+	var msgs []models.Message
+	if len(ids) > 0 {
+		if err := db.Select("id", "timestamp").Where("id IN ?", ids).Find(&msgs).Error; err != nil {
+			return nil, err
+		}
+	}
+	at := make(map[uint]time.Time, len(msgs))
+	for _, m := range msgs {
+		at[m.ID] = m.Timestamp
+	}
+	for _, r := range rows {
+		stats[r.ConversationID] = messageStat{Count: r.N, FirstAt: at[r.FirstID], LastAt: at[r.LastID]}
+	}
+	return stats, nil
+}
+
+// parseMessagePage reads the limit and before (message ID cursor) query
+// parameters; ok is false after a 400 has been written.
+func parseMessagePage(c *gin.Context) (limit int, before uint, ok bool) {
+	limit = defaultMessagePageSize
+	if s := c.Query("limit"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil || n < 1 {
+			apierror.Respond(c, http.StatusBadRequest, "limit must be a positive integer")
+			return 0, 0, false
+		}
+		limit = min(n, maxMessagePageSize)
+	}
+	if s := c.Query("before"); s != "" {
+		n, err := strconv.ParseUint(s, 10, 64)
+		if err != nil {
+			apierror.Respond(c, http.StatusBadRequest, "before must be a message id")
+			return 0, 0, false
+		}
+		before = uint(n)
+	}
+	return limit, before, true
+}
+
+// messagePage returns up to limit messages of a conversation older than the
+// before cursor (0 = newest), oldest first, and whether older ones remain.
+func messagePage(db *gorm.DB, convID uint, limit int, before uint) ([]models.Message, bool, error) {
+	q := db.Preload("Citations").Where("conversation_id = ?", convID)
+	if before > 0 {
+		q = q.Where("id < ?", before)
+	}
+	var msgs []models.Message
+	if err := q.Order("id DESC").Limit(limit + 1).Find(&msgs).Error; err != nil {
+		return nil, false, err
+	}
+	hasMore := len(msgs) > limit
+	if hasMore {
+		msgs = msgs[:limit]
+	}
+	for i, j := 0, len(msgs)-1; i < j; i, j = i+1, j-1 {
+		msgs[i], msgs[j] = msgs[j], msgs[i]
+	}
+	return msgs, hasMore, nil
+}
+
+// pageJSON renders a message page with its cursor for the next (older) page.
+func pageJSON(msgs []models.Message, hasMore bool) gin.H {
+	out := make([]gin.H, 0, len(msgs))
+	for _, m := range msgs {
+		out = append(out, messageJSON(m))
+	}
+	var next any
+	if hasMore && len(msgs) > 0 {
+		next = msgs[0].ID
+	}
+	return gin.H{"messages": out, "has_more": hasMore, "next_before": next}
+}
+
+// ListMessages pages through a conversation's messages, newest page first:
+// follow next_before until has_more is false.
+func ListMessages(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		uid, _ := strconv.ParseUint(c.GetString(middleware.ContextUserIDKey), 10, 64)
+		limit, before, ok := parseMessagePage(c)
+		if !ok {
+			return
+		}
+
+		var conv models.Conversation
+		if err := db.Select("id").Where("id = ? AND user_id = ?", c.Param("conversation_id"), uid).First(&conv).Error; err != nil {
+			apierror.Respond(c, http.StatusNotFound, "conversation not found")
+			return
+		}
+
+		msgs, hasMore, err := messagePage(db, conv.ID, limit, before)
+		if err != nil {
+			apierror.Respond(c, http.StatusInternalServerError, "db error")
+			return
+		}
+		payload := pageJSON(msgs, hasMore)
+		markMessages(db, uint(uid), payload["messages"].([]gin.H))
+		payload["conversation_id"] = conv.ID
+		c.JSON(http.StatusOK, payload)
+	}
+}
@@ -0,0 +1,394 @@
+package controllers
+
+import (
+	"AkuAI/middleware"
+	"AkuAI/models"
+	"AkuAI/pkg/apierror"
+	"AkuAI/pkg/audit"
+	"AkuAI/pkg/config"
+	"AkuAI/pkg/messaging"
+	"AkuAI/pkg/moderation"
+	svc "AkuAI/pkg/services"
+	"AkuAI/pkg/tenant"
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+const (
+	chatLinkCodeTTL      = 10 * time.Minute
+	chatLinkCodeAlphabet = "ABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+	messagingReplyBudget = 90 * time.Second
+	messagingEventLimit  = 10
+)
+
+const messagingHelp = "Halo! Saya AkuAI, asisten acara kampus.\n\n" +
+	"**/link <kode>** hubungkan chat ini ke akun AkuAI (kode dari menu Profil)\n" +
+	"**/events [bulan | jenis acara]** daftar acara (jenis: webinar, sertifikasi, seminar, workshop, bootcamp, lomba, kuliah_umum)\n" +
+	"**/new** mulai percakapan baru\n" +
+	"**/unlink** putuskan chat ini dari akun\n\n" +
+	"Setelah terhubung, kirim pertanyaan apa saja."
+
+const messagingNotLinked = "Chat ini belum terhubung ke akun AkuAI. Buat kode di aplikasi AkuAI (Profil → Hubungkan chat), " +
+	"lalu kirim **/link <kode>** di sini. Daftar acara tetap bisa dilihat dengan **/events**."
+
+// TelegramWebhook receives updates from the Telegram Bot API. The update is
+// acknowledged at once and answered in the background.
+func TelegramWebhook(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if config.TelegramBotToken == "" {
+			apierror.Respond(c, http.StatusNotFound, "Telegram bot is not configured")
+			return
+		}
+		bot := messaging.NewTelegram(config.TelegramBotToken, config.TelegramWebhookSecret, config.TelegramAPIBase)
+		if !bot.VerifySecret(c.GetHeader("X-Telegram-Bot-Api-Secret-Token")) {
+			apierror.Respond(c, http.StatusUnauthorized, "invalid secret token")
+			return
+		}
+		body, err := io.ReadAll(io.LimitReader(c.Request.Body, 1<<20))
+		if err != nil {
+			apierror.Respond(c, http.StatusBadRequest, "unreadable update")
+			return
+		}
+		if in, ok := bot.ParseUpdate(body); ok {
+			go handleChatMessage(db, bot, in)
+		}
+		c.JSON(http.StatusOK, gin.H{"ok": true})
+	}
+}
+
+func whatsAppBot() *messaging.WhatsApp {
+	if config.WhatsAppToken == "" || config.WhatsAppPhoneNumberID == "" {
+		return nil
+	}
+	return messaging.NewWhatsApp(config.WhatsAppToken, config.WhatsAppPhoneNumberID, config.WhatsAppVerifyToken,
+		config.WhatsAppAppSecret, config.WhatsAppAPIBase)
+}
+
+// WhatsAppVerify answers the Cloud API webhook subscription check.
+func WhatsAppVerify() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		bot := whatsAppBot()
+		if bot == nil {
+			apierror.Respond(c, http.StatusNotFound, "WhatsApp is not configured")
+			return
+		}
+		if !bot.VerifySubscription(c.Query("hub.mode"), c.Query("hub.verify_token")) {
+			apierror.Respond(c, http.StatusForbidden, "verification failed")
+			return
+		}
+		c.String(http.StatusOK, c.Query("hub.challenge"))
+	}
+}
+
+// WhatsAppWebhook receives Cloud API message updates, answered in the
+// background like Telegram ones.
+func WhatsAppWebhook(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		bot := whatsAppBot()
+		if bot == nil {
+			apierror.Respond(c, http.StatusNotFound, "WhatsApp is not configured")
+			return
+		}
+		body, err := io.ReadAll(io.LimitReader(c.Request.Body, 1<<20))
+		if err != nil {
+			apierror.Respond(c, http.StatusBadRequest, "unreadable update")
+			return
+		}
+		if !bot.VerifySignature(body, c.GetHeader("X-Hub-Signature-256")) {
+			apierror.Respond(c, http.StatusUnauthorized, "invalid signature")
+			return
+		}
+		for _, in := range bot.ParseWebhook(body) {
+			go handleChatMessage(db, bot, in)
+		}
+		c.JSON(http.StatusOK, gin.H{"ok": true})
+	}
+}
+
+// handleChatMessage runs a command or answers a message of a linked chat
+// through the chat pipeline, and sends the reply.
+func handleChatMessage(db *gorm.DB, bot messaging.Sender, in messaging.Incoming) {
+	ctx, cancel := context.WithTimeout(context.Background(), messagingReplyBudget)
+	defer cancel()
+
+	text := strings.TrimSpace(in.Text)
+	var reply string
+	if strings.HasPrefix(text, "/") {
+		fields := strings.Fields(text)
+		cmd, _, _ := strings.Cut(strings.ToLower(fields[0]), "@") // "/events@AkuAIBot" in groups
+		arg := strings.Join(fields[1:], " ")
+		switch {
+		case cmd == "/link" || (cmd == "/start" && arg != ""): // t.me/<bot>?start=<code>
+			reply = linkChat(db, in, arg)
+		case cmd == "/unlink":
+			reply = unlinkChat(db, in)
+		case cmd == "/new":
+			reply = newChatConversation(db, in)
+		case cmd == "/events":
+			reply = chatEventListing(arg)
+		default:
+			reply = messagingHelp
+		}
+	} else {
+		var link models.ChatLink
+		if err := db.Where("platform = ? AND external_id = ?", in.Platform, in.ChatID).First(&link).Error; err != nil {
+			reply = messagingNotLinked
+		} else {
+			reply = answerLinkedChat(ctx, db, link, text)
+		}
+	}
+	if err := bot.Send(ctx, in.ChatID, reply); err != nil {
+		log.Printf("[messaging] ❌ %s reply to %s failed: %v", in.Platform, in.ChatID, err)
+	}
+}
+
+// answerLinkedChat saves text in the chat's conversation, like a message to
+// POST /conversations, and returns the saved reply.
+func answerLinkedChat(ctx context.Context, db *gorm.DB, link models.ChatLink, text string) string {
+	uidStr := strconv.Itoa(int(link.UserID))
+	if v := middleware.ModerateMessage(ctx, db, uidStr, "messaging/"+link.Platform, text, false); v.Action == moderation.Block {
+		return moderation.Refusal(v)
+	}
+	release, err := middleware.TryAcquireUserSlot(ctx, uidStr)
+	if err != nil {
+		return "Saya masih menjawab pesan Anda sebelumnya. Coba lagi sebentar lagi."
+	}
+	defer release()
+
+	tn := userTenant(db, link.UserID)
+	ctx = tenant.WithTenant(ctx, tn)
+	conv, err := openConversation(db, link.UserID, tn.ID, link.ConversationID, text, false)
+	if errors.Is(err, gorm.ErrRecordNotFound) { // deleted in the app
+		conv, err = openConversation(db, link.UserID, tn.ID, nil, text, false)
+	}
+	if err != nil {
+		log.Printf("[messaging] ❌ conversation for %s chat %s: %v", link.Platform, link.ExternalID, err)
+		return "Maaf, terjadi kesalahan. Coba lagi nanti."
+	}
+	if link.ConversationID == nil || *link.ConversationID != conv.ID {
+		db.Model(&link).Update("conversation_id", conv.ID)
+	}
+	msgUser := models.Message{ConversationID: conv.ID, Sender: "user", Text: text, Timestamp: time.Now(), Label: queryLabel(ctx, text)}
+	if err := db.Create(&msgUser).Error; err != nil {
+		log.Printf("[messaging] ❌ failed to save message: %v", err)
+		return "Maaf, terjadi kesalahan. Coba lagi nanti."
+	}
+	mode := assignPromptArm(db, &conv, "")
+	memory := personalSection(db, conv, msgUser)
+	history := chatHistory(conv, text)
+
+	ctx = svc.WithPreferences(ctx, middleware.UserPreferences(db, link.UserID))
+	ctx, info := svc.WithGenerationInfo(svc.WithUserMemory(ctx, memory))
+	reply := generateChatReply(ctx, uidStr, mode, text, history)
+	msg, err := saveBotMessage(ctx, db, conv.ID, text, reply, mode, info)
+	if err != nil {
+		log.Printf("[messaging] ❌ failed to save bot reply: %v", err)
+	}
+	return msg.Text
+}
+
+func linkChat(db *gorm.DB, in messaging.Incoming, code string) string {
+	code = strings.ToUpper(strings.TrimSpace(code))
+	if code == "" {
+		return "Kirim **/link <kode>** dengan kode dari aplikasi AkuAI."
+	}
+	var lc models.ChatLinkCode
+	if err := db.Where("code = ? AND expires_at > ?", code, time.Now()).First(&lc).Error; err != nil {
+		return "Kode tidak valid atau sudah kedaluwarsa. Buat kode baru di aplikasi AkuAI."
+	}
+	// Codes are single-use; whoever deletes it first links the chat.
+	if res := db.Delete(&lc); res.Error != nil || res.RowsAffected != 1 {
+		return "Kode tidak valid atau sudah kedaluwarsa. Buat kode baru di aplikasi AkuAI."
+	}
+
+	var link models.ChatLink
+	err := db.Where("platform = ? AND external_id = ?", in.Platform, in.ChatID).First(&link).Error
+	var before any
+	switch {
+	case err == nil:
+		before = chatLinkJSON(link)
+		err = db.Model(&link).Updates(map[string]any{"user_id": lc.UserID, "display_name": in.Name, "conversation_id": nil}).Error
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		link = models.ChatLink{Platform: in.Platform, ExternalID: in.ChatID, UserID: lc.UserID, DisplayName: in.Name}
+		err = db.Create(&link).Error
+	}
+	if err != nil {
+		log.Printf("[messaging] ❌ failed to link %s chat %s: %v", in.Platform, in.ChatID, err)
+		return "Maaf, chat gagal dihubungkan. Coba lagi nanti."
+	}
+	link.UserID = lc.UserID
+	audit.Record(db, audit.Entry{Action: audit.ActionChatLink, ActorID: lc.UserID, TargetType: "chat_link",
+		TargetID: strconv.Itoa(int(link.ID)), Before: before, After: chatLinkJSON(link)})
+	log.Printf("[messaging] 🔗 %s chat %s linked to user %d", in.Platform, in.ChatID, lc.UserID)
+
+	var user models.User
+	db.Select("username").First(&user, lc.UserID)
+	return fmt.Sprintf("✅ Chat ini terhubung ke akun **%s**. Silakan kirim pertanyaan Anda.", user.Username)
+}
+
+func unlinkChat(db *gorm.DB, in messaging.Incoming) string {
+	var link models.ChatLink
+	if err := db.Where("platform = ? AND external_id = ?", in.Platform, in.ChatID).First(&link).Error; err != nil {
+		return "Chat ini tidak terhubung ke akun mana pun."
+	}
+	if err := db.Delete(&link).Error; err != nil {
+		return "Maaf, terjadi kesalahan. Coba lagi nanti."
+	}
+	audit.Record(db, audit.Entry{Action: audit.ActionChatUnlink, ActorID: link.UserID, TargetType: "chat_link",
+		TargetID: strconv.Itoa(int(link.ID)), Before: chatLinkJSON(link)})
+	return "Chat ini sudah diputus dari akun AkuAI Anda."
+}
+
+func newChatConversation(db *gorm.DB, in messaging.Incoming) string {
+	res := db.Model(&models.ChatLink{}).Where("platform = ? AND external_id = ?", in.Platform, in.ChatID).
+		Update("conversation_id", nil)
+	if res.Error != nil || res.RowsAffected == 0 {
+		return messagingNotLinked
+	}
+	return "Percakapan baru dimulai."
+}
+
+var (
+	messagingCampusesOnce sync.Once
+	messagingCampuses     *svc.CampusDataService
+)
+
+// messagingDataset is the UIB dataset answering bot commands, or nil when
+// the event data can't be loaded.
+func messagingDataset() *svc.UIBEventService {
+	messagingCampusesOnce.Do(func() {
+		var err error
+		if messagingCampuses, err = svc.NewCampusDataService(); err != nil {
+			log.Printf("[messaging] ⚠️ event data unavailable: %v", err)
+		}
+	})
+	if messagingCampuses == nil {
+		return nil
+	}
+	return messagingCampuses.Default()
+}
+
+// messagingCampusesOf is the event data of tenant t for work done outside
+// its requests, or nil when it can't be loaded.
+func messagingCampusesOf(t *tenant.Tenant) *svc.CampusDataService {
+	if hasOwnDatasets(t) {
+		return campusData(t)
+	}
+	messagingDataset()
+	return messagingCampuses
+}
+
+const messagingNoData = "Maaf, data acara sedang tidak tersedia."
+
+// chatEventListing lists the events of a month or type, or the upcoming
+// ones.
+func chatEventListing(arg string) string {
+	ds := messagingDataset()
+	if ds == nil {
+		return messagingNoData
+	}
+	arg = strings.ToLower(strings.TrimSpace(arg))
+	switch arg {
+	case "":
+		return messaging.FormatEvents("📅 **Acara mendatang**", ds.GetUpcomingEvents(), messagingEventLimit)
+	}
+	if t, ok := models.NormalizeEventType(arg); ok {
+		label := svc.EventTypeLabel(t)
+		return messaging.FormatEvents("📅 **"+strings.ToUpper(label[:1])+label[1:]+"**", ds.GetEventsByType(t), messagingEventLimit)
+	}
+	return messaging.FormatEvents("📅 **Acara "+arg+"**", ds.GetEventsByMonth(arg), messagingEventLimit)
+}
+
+func chatLinkJSON(l models.ChatLink) gin.H {
+	return gin.H{
+		"id":              l.ID,
+		"platform":        l.Platform,
+		"chat_id":         l.ExternalID,
+		"display_name":    l.DisplayName,
+		"conversation_id": l.ConversationID,
+		"created_at":      l.CreatedAt,
+	}
+}
+
+// CreateChatLinkCode issues a code the signed-in user sends to the bot as
+// "/link <code>" to link a Telegram or WhatsApp chat to their account.
+func CreateChatLinkCode(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		uid := currentUserID(c)
+		b := make([]byte, 8)
+		if _, err := rand.Read(b); err != nil {
+			apierror.Respond(c, http.StatusInternalServerError, "failed to generate code")
+			return
+		}
+		for i := range b {
+			b[i] = chatLinkCodeAlphabet[int(b[i])%len(chatLinkCodeAlphabet)]
+		}
+		lc := models.ChatLinkCode{Code: string(b), UserID: uid, ExpiresAt: time.Now().Add(chatLinkCodeTTL)}
+		err := db.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Where("user_id = ?", uid).Delete(&models.ChatLinkCode{}).Error; err != nil {
+				return err
+			}
+			return tx.Create(&lc).Error
+		})
+		if err != nil {
+			apierror.Respond(c, http.StatusInternalServerError, "db error")
+			return
+		}
+		c.JSON(http.StatusCreated, gin.H{
+			"code":       lc.Code,
+			"command":    "/link " + lc.Code,
+			"expires_at": lc.ExpiresAt,
+			"platforms":  gin.H{"telegram": config.TelegramBotToken != "", "whatsapp": whatsAppBot() != nil},
+		})
+	}
+}
+
+// ListChatLinks returns the chats linked to the signed-in user.
+func ListChatLinks(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var links []models.ChatLink
+		if err := db.Where("user_id = ?", currentUserID(c)).Order("id DESC").Find(&links).Error; err != nil {
+			apierror.Respond(c, http.StatusInternalServerError, "db error")
+			return
+		}
+		out := make([]gin.H, 0, len(links))
+		for _, l := range links {
+			out = append(out, chatLinkJSON(l))
+		}
+		c.JSON(http.StatusOK, gin.H{"links": out})
+	}
+}
+
+// DeleteChatLink unlinks one of the signed-in user's chats.
+func DeleteChatLink(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil || id <= 0 {
+			apierror.Respond(c, http.StatusBadRequest, "invalid id")
+			return
+		}
+		var link models.ChatLink
+		if err := db.Where("id = ? AND user_id = ?", id, currentUserID(c)).First(&link).Error; err != nil {
+			apierror.Respond(c, http.StatusNotFound, "chat link not found")
+			return
+		}
+		if err := db.Delete(&link).Error; err != nil {
+			apierror.Respond(c, http.StatusInternalServerError, "db error")
+			return
+		}
+		recordAudit(c, db, audit.Entry{Action: audit.ActionChatUnlink, TargetType: "chat_link", TargetID: strconv.Itoa(id), Before: chatLinkJSON(link)})
+		c.JSON(http.StatusOK, gin.H{"msg": "chat unlinked"})
+	}
+}
@@ -0,0 +1,101 @@
+package controllers
+
+import (
+	"AkuAI/middleware"
+	"AkuAI/models"
+	"AkuAI/pkg/apierror"
+	"AkuAI/pkg/audit"
+	"AkuAI/pkg/i18n"
+	"AkuAI/pkg/postprocess"
+	svc "AkuAI/pkg/services"
+	"context"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// replyPipeline is the post-processing of replies for the preferences in
+// ctx: the default steps, plus Markdown and emoji stripping when the user
+// turned those off.
+func replyPipeline(ctx context.Context) *postprocess.Pipeline {
+	p := svc.PreferencesFrom(ctx)
+	var extra []postprocess.Step
+	if !p.Markdown {
+		extra = append(extra, postprocess.PlainText)
+	}
+	if !p.Emoji {
+		extra = append(extra, postprocess.NoEmoji)
+	}
+	return postprocess.Default().With(extra...)
+}
+
+// requestVerbosity applies the verbosity a chat request asks for to the
+// request context, over the user's preference.
+func requestVerbosity(c *gin.Context, v string) {
+	v, _ = models.ParseVerbosity(v)
+	c.Request = c.Request.WithContext(svc.WithVerbosity(c.Request.Context(), v))
+}
+
+func preferencesJSON(p models.UserPreferences) gin.H {
+	return gin.H{"language": p.Language, "verbosity": p.Verbosity, "markdown": p.Markdown, "emoji": p.Emoji}
+}
+
+// Preferences shows (GET) or changes (PUT) how the current user wants
+// replies: language ("" follows Accept-Language), verbosity (short, normal,
+// detailed), and whether they may use Markdown and emoji. PUT changes only
+// the fields it sends.
+func Preferences(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		uid := currentUserID(c)
+		prefs := middleware.UserPreferences(db, uid)
+		if c.Request.Method == http.MethodGet {
+			c.JSON(http.StatusOK, preferencesJSON(prefs))
+			return
+		}
+
+		var body struct {
+			Language  *string `json:"language"`
+			Verbosity *string `json:"verbosity"`
+			Markdown  *bool   `json:"markdown"`
+			Emoji     *bool   `json:"emoji"`
+		}
+		if !apierror.BindJSON(c, &body) {
+			return
+		}
+		if body.Language != nil && *body.Language != "" && !i18n.Supports(*body.Language) {
+			apierror.Respond(c, http.StatusBadRequest, "language must be id or en")
+			return
+		}
+		if body.Verbosity != nil {
+			v, ok := models.ParseVerbosity(*body.Verbosity)
+			if !ok || v == "" {
+				apierror.Respond(c, http.StatusBadRequest, "verbosity must be short, normal or detailed")
+				return
+			}
+			*body.Verbosity = v
+		}
+
+		before := preferencesJSON(prefs)
+		if body.Language != nil {
+			prefs.Language = *body.Language
+		}
+		if body.Verbosity != nil {
+			prefs.Verbosity = *body.Verbosity
+		}
+		if body.Markdown != nil {
+			prefs.Markdown = *body.Markdown
+		}
+		if body.Emoji != nil {
+			prefs.Emoji = *body.Emoji
+		}
+		if err := db.Save(&prefs).Error; err != nil {
+			apierror.Respond(c, http.StatusInternalServerError, "failed to update preferences")
+			return
+		}
+		after := preferencesJSON(prefs)
+		recordAudit(c, db, audit.Entry{Action: audit.ActionPreferencesUpdate, TargetType: "user", TargetID: strconv.Itoa(int(uid)), Before: before, After: after})
+		c.JSON(http.StatusOK, after)
+	}
+}
@@ -3,12 +3,15 @@ package controllers
 import (
 	"AkuAI/middleware"
 	"AkuAI/models"
+	"AkuAI/pkg/apierror"
+	"AkuAI/pkg/audit"
 	"AkuAI/pkg/services"
-	utils "AkuAI/pkg/utills"
+	"errors"
 	"log"
 	"net/http"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
@@ -22,31 +25,32 @@ func Profile(db *gorm.DB) gin.HandlerFunc {
 
 		var user models.User
 		if err := db.First(&user, uid).Error; err != nil {
-			c.JSON(http.StatusNotFound, gin.H{"msg": "User not found"})
+			apierror.Respond(c, http.StatusNotFound, "User not found")
 			return
 		}
 
 		if c.Request.Method == http.MethodGet {
-			storage := services.NewObjectStorageService()
-			imageURL := storage.GenerateImageURL(user.ProfileImageURL)
+			imageURL, expiresAt := profileImageURL(user)
 
 			c.JSON(http.StatusOK, gin.H{
-				"id":                user.ID,
-				"email":             user.Email,
-				"username":          user.Username,
-				"profile_image_url": imageURL,
-				"has_profile_image": user.ProfileImageURL != "",
+				"id":                       user.ID,
+				"email":                    user.Email,
+				"username":                 user.Username,
+				"profile_image_url":        imageURL,
+				"profile_image_expires_at": expiresAt,
+				"profile_image_public":     user.ProfileImagePublic,
+				"has_profile_image":        user.ProfileImageURL != "",
+				"memory_enabled":           user.MemoryEnabled,
 			})
 			return
 		}
 
 		var body struct {
-			Email    string `json:"email"`
-			Username string `json:"username"`
-			Password string `json:"password"`
+			Email    string `json:"email" binding:"omitempty,email,max=120"`
+			Username string `json:"username" binding:"max=80"`
+			Password string `json:"password" binding:"omitempty,password"`
 		}
-		if err := c.ShouldBindJSON(&body); err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"msg": "invalid request"})
+		if !apierror.BindJSON(c, &body) {
 			return
 		}
 
@@ -62,40 +66,41 @@ func Profile(db *gorm.DB) gin.HandlerFunc {
 
 		if newEmail != user.Email {
 			var t models.User
-			if err := db.Where("email = ?", newEmail).First(&t).Error; err == nil {
-				c.JSON(http.StatusConflict, gin.H{"msg": "Email already exists"})
+			if err := db.Where("tenant_id = ? AND email = ?", user.TenantID, newEmail).First(&t).Error; err == nil {
+				apierror.Respond(c, http.StatusConflict, "Email already exists")
 				return
 			}
 		}
 
 		if newUsername != user.Username {
 			var t models.User
-			if err := db.Where("username = ?", newUsername).First(&t).Error; err == nil {
-				c.JSON(http.StatusConflict, gin.H{"msg": "Username already exists"})
+			if err := db.Where("tenant_id = ? AND username = ?", user.TenantID, newUsername).First(&t).Error; err == nil {
+				apierror.Respond(c, http.StatusConflict, "Username already exists")
 				return
 			}
 		}
 
+		before := userSnapshot(user)
 		user.Email = newEmail
 		user.Username = newUsername
 		if newPassword != "" {
-			if !utils.HasLetter(newPassword) || !utils.HasNumber(newPassword) {
-				c.JSON(http.StatusBadRequest, gin.H{"msg": "New password must contain at least one letter and one number"})
-				return
-			}
 			if err := user.SetPassword(newPassword); err != nil {
-				c.JSON(http.StatusInternalServerError, gin.H{"msg": "failed to set password"})
+				apierror.Respond(c, http.StatusInternalServerError, "failed to set password")
 				return
 			}
 		}
 		if err := db.Save(&user).Error; err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"msg": "failed to update profile"})
+			apierror.Respond(c, http.StatusInternalServerError, "failed to update profile")
 			return
 		}
+		after := userSnapshot(user)
+		after["password_changed"] = newPassword != ""
+		recordAudit(c, db, audit.Entry{Action: audit.ActionProfileUpdate, TargetType: "user", TargetID: strconv.Itoa(int(user.ID)), Before: before, After: after})
 
+		imageURL, _ := profileImageURL(user)
 		c.JSON(http.StatusOK, gin.H{
 			"msg":               "Profile updated successfully",
-			"profile_image_url": user.ProfileImageURL,
+			"profile_image_url": imageURL,
 		})
 	}
 }
@@ -107,23 +112,22 @@ func ProfileImageUploadToken(db *gorm.DB) gin.HandlerFunc {
 		uid, _ := strconv.Atoi(uidStr)
 
 		var body struct {
-			FileExtension string `json:"file_extension"`
+			FileExtension string `json:"file_extension" binding:"required"`
 		}
-		if err := c.ShouldBindJSON(&body); err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"msg": "file_extension is required"})
+		if !apierror.BindJSON(c, &body) {
 			return
 		}
 
 		ext := strings.ToLower(body.FileExtension)
 		if ext != ".jpg" && ext != ".jpeg" && ext != ".png" && ext != ".gif" && ext != ".webp" {
-			c.JSON(http.StatusBadRequest, gin.H{"msg": "Invalid file extension. Allowed: .jpg, .jpeg, .png, .gif, .webp"})
+			apierror.Respond(c, http.StatusBadRequest, "Invalid file extension. Allowed: .jpg, .jpeg, .png, .gif, .webp")
 			return
 		}
 
 		storage := services.NewObjectStorageService()
 		response, err := storage.GenerateUploadToken(uint(uid), ext)
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"msg": "Failed to generate upload token"})
+			apierror.Respond(c, http.StatusInternalServerError, "Failed to generate upload token")
 			return
 		}
 
@@ -143,14 +147,14 @@ func ProfileImageUpload(db *gorm.DB) gin.HandlerFunc {
 		}
 		if token == "" {
 			log.Printf("[PROFILE_IMAGE_UPLOAD] Missing upload token for user %d", uid)
-			c.JSON(http.StatusBadRequest, gin.H{"msg": "upload_token is required"})
+			apierror.Respond(c, http.StatusBadRequest, "upload_token is required")
 			return
 		}
 
 		file, header, err := c.Request.FormFile("image")
 		if err != nil {
 			log.Printf("[PROFILE_IMAGE_UPLOAD] Failed to get image file for user %d: %v", uid, err)
-			c.JSON(http.StatusBadRequest, gin.H{"msg": "No image file provided"})
+			apierror.Respond(c, http.StatusBadRequest, "No image file provided")
 			return
 		}
 		defer file.Close()
@@ -161,32 +165,51 @@ func ProfileImageUpload(db *gorm.DB) gin.HandlerFunc {
 		response, err := storage.SaveUploadedImage(uint(uid), file, header, token)
 		if err != nil {
 			log.Printf("[PROFILE_IMAGE_UPLOAD] Failed to save image for user %d: %v", uid, err)
-			c.JSON(http.StatusBadRequest, gin.H{"msg": err.Error()})
+			status := http.StatusBadRequest
+			switch {
+			case errors.Is(err, services.ErrImageTooLarge), errors.Is(err, services.ErrStorageQuota):
+				status = http.StatusRequestEntityTooLarge
+			case errors.Is(err, services.ErrImageType):
+				status = http.StatusUnsupportedMediaType
+			case errors.Is(err, services.ErrImageInfected):
+				status = http.StatusUnprocessableEntity
+			case errors.Is(err, services.ErrScannerUnavailable):
+				status = http.StatusServiceUnavailable
+			}
+			apierror.Respond(c, status, err.Error())
 			return
 		}
 
 		var user models.User
 		if err := db.First(&user, uid).Error; err != nil {
-			c.JSON(http.StatusNotFound, gin.H{"msg": "User not found"})
+			apierror.Respond(c, http.StatusNotFound, "User not found")
 			return
 		}
 
-		if user.ProfileImageURL != "" {
-			if oldPath := extractImagePath(user.ProfileImageURL); oldPath != "" {
-				storage.DeleteImage(oldPath)
+		// The replaced image counts against the quota until it is deleted.
+		if oldPath := user.ProfileImageURL; oldPath != "" {
+			if p := extractImagePath(oldPath); p != "" {
+				oldPath = p
 			}
+			storage.DeleteImage(oldPath)
 		}
 
+		before := userSnapshot(user)
 		user.ProfileImageURL = response.FilePath
+		user.ProfileImagePublic = c.PostForm("visibility") == "public"
 		if err := db.Save(&user).Error; err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"msg": "Failed to update profile"})
+			apierror.Respond(c, http.StatusInternalServerError, "Failed to update profile")
 			return
 		}
+		recordAudit(c, db, audit.Entry{Action: audit.ActionProfileImageUpload, TargetType: "user", TargetID: strconv.Itoa(int(user.ID)), Before: before, After: userSnapshot(user)})
 
+		imageURL, expiresAt := profileImageURL(user)
 		c.JSON(http.StatusOK, gin.H{
-			"msg":       "Profile image uploaded successfully",
-			"image_url": response.PublicURL,
-			"file_size": response.FileSize,
+			"msg":        "Profile image uploaded successfully",
+			"image_url":  imageURL,
+			"expires_at": expiresAt,
+			"public":     user.ProfileImagePublic,
+			"file_size":  response.FileSize,
 		})
 	}
 }
@@ -199,20 +222,98 @@ func ProfileImageURL(db *gorm.DB) gin.HandlerFunc {
 
 		var user models.User
 		if err := db.First(&user, uid).Error; err != nil {
-			c.JSON(http.StatusNotFound, gin.H{"msg": "User not found"})
+			apierror.Respond(c, http.StatusNotFound, "User not found")
 			return
 		}
 
-		storage := services.NewObjectStorageService()
-		imageURL := storage.GenerateImageURL(user.ProfileImageURL)
+		imageURL, expiresAt := profileImageURL(user)
 
 		c.JSON(http.StatusOK, gin.H{
-			"image_url": imageURL,
-			"has_image": user.ProfileImageURL != "",
+			"image_url":  imageURL,
+			"expires_at": expiresAt,
+			"public":     user.ProfileImagePublic,
+			"has_image":  user.ProfileImageURL != "",
 		})
 	}
 }
 
+// ProfileImageVisibility makes the profile image public (a plain URL) or
+// private (signed URLs that expire).
+func ProfileImageVisibility(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		uid, _ := strconv.Atoi(c.GetString(middleware.ContextUserIDKey))
+		var body struct {
+			Public *bool `json:"public" binding:"required"`
+		}
+		if !apierror.BindJSON(c, &body) {
+			return
+		}
+
+		var user models.User
+		if err := db.First(&user, uid).Error; err != nil {
+			apierror.Respond(c, http.StatusNotFound, "User not found")
+			return
+		}
+		if user.ProfileImageURL == "" {
+			apierror.Respond(c, http.StatusBadRequest, "No profile image")
+			return
+		}
+
+		before := userSnapshot(user)
+		user.ProfileImagePublic = *body.Public
+		if err := db.Model(&user).Update("profile_image_public", user.ProfileImagePublic).Error; err != nil {
+			apierror.Respond(c, http.StatusInternalServerError, "Failed to update profile")
+			return
+		}
+		recordAudit(c, db, audit.Entry{Action: audit.ActionProfileUpdate, TargetType: "user", TargetID: strconv.Itoa(int(user.ID)), Before: before, After: userSnapshot(user)})
+
+		imageURL, expiresAt := profileImageURL(user)
+		c.JSON(http.StatusOK, gin.H{"image_url": imageURL, "expires_at": expiresAt, "public": user.ProfileImagePublic})
+	}
+}
+
+// ServeProfileImage serves /uploads/profiles/<user>/<file>: with a valid
+// signature, or without one when it is the user's current image and public.
+func ServeProfileImage(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		relPath := strings.TrimPrefix(c.Param("path"), "/")
+		public := false
+		if c.Query("signature") == "" {
+			owner, _, _ := strings.Cut(relPath, "/")
+			var user models.User
+			if id, err := strconv.Atoi(owner); err == nil && db.Select("profile_image_url", "profile_image_public").First(&user, id).Error == nil {
+				public = user.ProfileImagePublic && user.ProfileImageURL == relPath
+			}
+		}
+
+		storage := services.NewObjectStorageService()
+		full, err := storage.OpenImage(relPath, c.Query("expires"), c.Query("signature"), public)
+		if errors.Is(err, services.ErrInvalidSignature) {
+			apierror.Respond(c, http.StatusForbidden, err.Error())
+			return
+		} else if err != nil {
+			apierror.Respond(c, http.StatusNotFound, "image not found")
+			return
+		}
+		if public {
+			c.Header("Cache-Control", "public, max-age=3600")
+		} else {
+			c.Header("Cache-Control", "private, max-age=300")
+		}
+		c.File(full)
+	}
+}
+
+// profileImageURL is the URL of the user's profile image and, for a private
+// image, when it expires.
+func profileImageURL(user models.User) (string, *time.Time) {
+	url, expires := services.NewObjectStorageService().GenerateImageURL(user.ProfileImageURL, user.ProfileImagePublic)
+	if expires.IsZero() {
+		return url, nil
+	}
+	return url, &expires
+}
+
 func DeleteProfileImage(db *gorm.DB) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		userIDStr, _ := c.Get(middleware.ContextUserIDKey)
@@ -221,31 +322,39 @@ func DeleteProfileImage(db *gorm.DB) gin.HandlerFunc {
 
 		var user models.User
 		if err := db.First(&user, uid).Error; err != nil {
-			c.JSON(http.StatusNotFound, gin.H{"msg": "User not found"})
+			apierror.Respond(c, http.StatusNotFound, "User not found")
 			return
 		}
 
 		if user.ProfileImageURL == "" {
-			c.JSON(http.StatusBadRequest, gin.H{"msg": "No profile image to delete"})
+			apierror.Respond(c, http.StatusBadRequest, "No profile image to delete")
 			return
 		}
 
 		storage := services.NewObjectStorageService()
 		if err := storage.DeleteImage(user.ProfileImageURL); err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"msg": "Failed to delete image file"})
+			apierror.Respond(c, http.StatusInternalServerError, "Failed to delete image file")
 			return
 		}
 
+		before := userSnapshot(user)
 		user.ProfileImageURL = ""
+		user.ProfileImagePublic = false
 		if err := db.Save(&user).Error; err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"msg": "Failed to update profile"})
+			apierror.Respond(c, http.StatusInternalServerError, "Failed to update profile")
 			return
 		}
+		recordAudit(c, db, audit.Entry{Action: audit.ActionProfileImageDelete, TargetType: "user", TargetID: strconv.Itoa(int(user.ID)), Before: before, After: userSnapshot(user)})
 
 		c.JSON(http.StatusOK, gin.H{"msg": "Profile image deleted successfully"})
 	}
 }
 
+// userSnapshot is the audited state of a user; never the password hash.
+func userSnapshot(u models.User) gin.H {
+	return gin.H{"email": u.Email, "username": u.Username, "profile_image_url": u.ProfileImageURL, "profile_image_public": u.ProfileImagePublic}
+}
+
 func extractImagePath(imageURL string) string {
 	parts := strings.Split(imageURL, "/uploads/profiles/")
 	if len(parts) < 2 {
@@ -0,0 +1,102 @@
+package controllers
+
+import (
+	"AkuAI/middleware"
+	"AkuAI/models"
+	"AkuAI/pkg/analytics"
+	"AkuAI/pkg/apierror"
+	"AkuAI/pkg/config"
+	"log"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// requestedPromptMode returns the mode a request asked for in its body or the
+// X-Prompt-Mode header, or "" when it left the choice to the server.
+func requestedPromptMode(c *gin.Context, bodyMode string) string {
+	mode := strings.ToLower(strings.TrimSpace(bodyMode))
+	if mode == "" {
+		mode = strings.ToLower(strings.TrimSpace(c.GetHeader("X-Prompt-Mode")))
+	}
+	if mode != "baseline" && mode != "engineered" {
+		return ""
+	}
+	return mode
+}
+
+// assignPromptArm picks the prompt mode for a message in conv. An explicit
+// request mode wins. Otherwise, while PROMPT_AB_BASELINE_PERCENT is set, a
+// conversation is put in an arm on its first server-chosen message and keeps
+// it; without a split PROMPT_MODE applies ("both" means engineered here).
+func assignPromptArm(db *gorm.DB, conv *models.Conversation, requested string) string {
+	if requested != "" {
+		return requested
+	}
+	if conv.PromptArm != "" {
+		return conv.PromptArm
+	}
+	if pct := config.PromptABBaselinePercent; pct > 0 {
+		arm := "engineered"
+		if rand.Intn(100) < pct {
+			arm = "baseline"
+		}
+		if err := db.Model(conv).Update("prompt_arm", arm).Error; err != nil {
+			log.Printf("[prompt-ab] ⚠️ failed to record arm for conversation %d: %v", conv.ID, err)
+		}
+		conv.PromptArm = arm
+		log.Printf("[prompt-ab] 🎲 conversation %d assigned to %s", conv.ID, arm)
+		return arm
+	}
+	if config.PromptMode == "baseline" {
+		return "baseline"
+	}
+	return "engineered"
+}
+
+// SetMessageFeedback records a thumbs up (1), thumbs down (-1) or clears the
+// rating (0) of a bot reply in one of the user's conversations.
+func SetMessageFeedback(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		uid, _ := strconv.ParseUint(c.GetString(middleware.ContextUserIDKey), 10, 64)
+		var body struct {
+			Rating *int `json:"rating"`
+		}
+		if err := c.ShouldBindJSON(&body); err != nil || body.Rating == nil || *body.Rating < -1 || *body.Rating > 1 {
+			apierror.Respond(c, http.StatusBadRequest, "rating must be 1, -1 or 0")
+			return
+		}
+
+		var msg models.Message
+		err := db.Joins("JOIN conversations ON conversations.id = messages.conversation_id AND conversations.deleted_at IS NULL").
+			Where("messages.id = ? AND messages.conversation_id = ? AND messages.sender = ? AND conversations.user_id = ?",
+				c.Param("message_id"), c.Param("conversation_id"), "bot", uid).
+			First(&msg).Error
+		if err != nil {
+			apierror.Respond(c, http.StatusNotFound, "message not found")
+			return
+		}
+		if err := db.Model(&msg).Update("feedback", *body.Rating).Error; err != nil {
+			apierror.Respond(c, http.StatusInternalServerError, "db error")
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"message_id": msg.ID, "feedback": *body.Rating})
+	}
+}
+
+// PromptABReport compares the baseline and engineered arms of the online
+// prompt A/B test (admin).
+func PromptABReport(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		arms, err := analytics.PromptArms(db, analyticsSince(c))
+		if err != nil {
+			apierror.Respond(c, http.StatusInternalServerError, "db error")
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"baseline_percent": config.PromptABBaselinePercent, "arms": arms})
+	}
+}
@@ -0,0 +1,161 @@
+package controllers
+
+import (
+	"AkuAI/models"
+	"AkuAI/pkg/apierror"
+	"AkuAI/pkg/audit"
+	"AkuAI/pkg/cache"
+	"AkuAI/pkg/prompts"
+	svc "AkuAI/pkg/services"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+func promptVersionJSON(v models.PromptVersion) gin.H {
+	b := prompts.BlocksOf(v)
+	if b.Rules == nil {
+		b.Rules = []string{}
+	}
+	return gin.H{"version": v.Version, "campus": v.Campus, "tone": b.Tone, "rules": b.Rules, "note": v.Note,
+		"author_id": v.AuthorID, "created_at": v.CreatedAt}
+}
+
+// promptCampus returns the dataset ?campus= names among the request
+// tenant's and its institution, the key its prompt versions are saved
+// under. Without ?campus= both are empty: the versions for every campus.
+// Writes 404 or 503 and returns false otherwise.
+func promptCampus(c *gin.Context) (*svc.UIBEventService, string, bool) {
+	name := c.Query("campus")
+	if name == "" {
+		return nil, "", true
+	}
+	campuses := messagingCampusesOf(currentTenant(c))
+	if campuses == nil {
+		apierror.Respond(c, http.StatusServiceUnavailable, "event data unavailable")
+		return nil, "", false
+	}
+	ds := campuses.Dataset(name)
+	if ds == nil {
+		apierror.RespondDetails(c, http.StatusNotFound, "No event data for campus "+name, gin.H{"campuses": campuses.Campuses()})
+		return nil, "", false
+	}
+	return ds, ds.Institution(), true
+}
+
+// dropPromptReplies drops the cached answers a prompt change for campus
+// affects, so the next question is answered under the new prompt.
+func dropPromptReplies(campus string) {
+	tags := svc.PromptTags(campus)
+	exact := cache.Default().InvalidateTags(tags...)
+	semantic := chatSemanticCache().InvalidateTags(tags...)
+	log.Printf("[prompts] %d cached and %d semantic replies dropped after a prompt change for %q", exact, semantic, campus)
+}
+
+// GetPrompt returns the system-instruction blocks of event answers for
+// ?campus= (all campuses when absent) in the request's tenant: the version
+// in effect for that scope (null for the built-in blocks), the built-in
+// defaults, the last 50 versions and, for a campus, a preview of the whole
+// instruction.
+func GetPrompt(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ds, campus, ok := promptCampus(c)
+		if !ok {
+			return
+		}
+		tenantID := currentTenant(c).ID
+		history, err := prompts.History(db, tenantID, campus, 50)
+		if err != nil {
+			apierror.Respond(c, http.StatusInternalServerError, "db error")
+			return
+		}
+		out := gin.H{"campus": campus, "current": nil, "defaults": prompts.Defaults}
+		versions := make([]gin.H, 0, len(history))
+		for _, v := range history {
+			versions = append(versions, promptVersionJSON(v))
+		}
+		if len(versions) > 0 {
+			out["current"] = versions[0]
+		}
+		out["history"] = versions
+		if ds != nil {
+			out["preview"] = svc.EventInstructionPreview(c.Request.Context(), ds)
+		}
+		c.JSON(http.StatusOK, out)
+	}
+}
+
+// savePrompt stores b as the next version for campus and answers with it.
+func savePrompt(c *gin.Context, db *gorm.DB, campus string, b prompts.Blocks, note string) {
+	tenantID := currentTenant(c).ID
+	v, err := prompts.Save(db, tenantID, campus, b, currentUserID(c), note)
+	if err != nil {
+		apierror.Respond(c, http.StatusInternalServerError, "failed to save prompt")
+		return
+	}
+	dropPromptReplies(campus)
+	out := promptVersionJSON(v)
+	log.Printf("[prompts] 📝 user %d saved version %d of the prompt for %q/%q", v.AuthorID, v.Version, tenantID, campus)
+	recordAudit(c, db, audit.Entry{Action: audit.ActionPromptSave, TargetType: "prompt",
+		TargetID: strings.TrimPrefix(tenantID+"/"+campus, "/"), After: out})
+	c.JSON(http.StatusCreated, gin.H{"prompt": out})
+}
+
+// SavePrompt saves a new version of the blocks for ?campus= (all campuses
+// when absent): {"tone", "rules", "note"}. An empty tone or rule list keeps
+// the built-in one, so {} goes back to the defaults. It applies to the next
+// question.
+func SavePrompt(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		_, campus, ok := promptCampus(c)
+		if !ok {
+			return
+		}
+		var body struct {
+			Tone  string   `json:"tone" binding:"max=4000"`
+			Rules []string `json:"rules" binding:"max=40,dive,max=1000"`
+			Note  string   `json:"note" binding:"max=200"`
+		}
+		if !apierror.BindJSON(c, &body) {
+			return
+		}
+		b := prompts.Blocks{Tone: strings.TrimSpace(body.Tone)}
+		for _, r := range body.Rules {
+			if r = strings.TrimSpace(r); r != "" {
+				b.Rules = append(b.Rules, r)
+			}
+		}
+		savePrompt(c, db, campus, b, strings.TrimSpace(body.Note))
+	}
+}
+
+// RestorePrompt saves version :version of the blocks for ?campus= again as
+// the newest, putting it back in effect.
+func RestorePrompt(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		_, campus, ok := promptCampus(c)
+		if !ok {
+			return
+		}
+		n, err := strconv.Atoi(c.Param("version"))
+		if err != nil {
+			apierror.Respond(c, http.StatusBadRequest, "version must be a number")
+			return
+		}
+		old, err := prompts.Find(db, currentTenant(c).ID, campus, n)
+		if errors.Is(err, prompts.ErrNoVersion) {
+			apierror.Respond(c, http.StatusNotFound, "prompt version not found")
+			return
+		} else if err != nil {
+			apierror.Respond(c, http.StatusInternalServerError, "db error")
+			return
+		}
+		savePrompt(c, db, campus, prompts.BlocksOf(old), fmt.Sprintf("restored version %d", n))
+	}
+}
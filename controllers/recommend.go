@@ -0,0 +1,144 @@
+package controllers
+
+import (
+	"AkuAI/models"
+	"AkuAI/pkg/apierror"
+	"AkuAI/pkg/memory"
+	"AkuAI/pkg/services"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// recommendHistory is how many of the user's latest questions feed the
+// recommendations.
+const recommendHistory = 30
+
+// recommendProfile collects the user's remembered facts and latest questions,
+// and the campus they said they study at ("" when unknown).
+func recommendProfile(db *gorm.DB, uid uint) (services.RecommendProfile, string, error) {
+	var p services.RecommendProfile
+	var campus string
+	facts, err := memory.Facts(db, uid)
+	if err != nil {
+		return p, "", err
+	}
+	for _, f := range facts {
+		switch f.Kind {
+		case models.MemoryProgram:
+			p.Program = f.Value
+		case models.MemoryInterest:
+			p.Interests = append(p.Interests, f.Value)
+		case models.MemoryCampus:
+			campus = f.Value
+		}
+	}
+
+	var past []models.Message
+	err = db.Model(&models.Message{}).Select("messages.text", "messages.topic").
+		Joins("JOIN conversations ON conversations.id = messages.conversation_id AND conversations.incognito = ?", false).
+		Where("conversations.user_id = ? AND messages.sender = ?", uid, "user").
+		Order("messages.id DESC").Limit(recommendHistory).Find(&past).Error
+	if err != nil {
+		return p, "", err
+	}
+	for _, m := range past {
+		if services.IsRecommendationQuery(m.Text) {
+			continue
+		}
+		p.PastQueries = append(p.PastQueries, m.Text)
+		if m.Topic != "" && m.Topic != "general" {
+			p.PastTopics = append(p.PastTopics, m.Topic)
+		}
+	}
+	return p, campus, nil
+}
+
+// recommendationSection returns the recommendations for the prompt when msg
+// asks which events suit the user ("acara apa yang cocok untuk saya?").
+func recommendationSection(db *gorm.DB, uid uint, msg models.Message) string {
+	if !services.IsRecommendationQuery(msg.Text) {
+		return ""
+	}
+	p, campus, err := recommendProfile(db, uid)
+	if err != nil {
+		log.Printf("[recommend] ⚠️ failed to load profile of user %d: %v", uid, err)
+		return ""
+	}
+	return services.ChatRecommendations(p, campus, msg.Text)
+}
+
+// conflictSection returns the schedule conflicts for the prompt when msg
+// asks whether events clash ("apakah ada acara yang bentrok?").
+func conflictSection(msg models.Message) string {
+	if !services.IsConflictQuery(msg.Text) {
+		return ""
+	}
+	return services.ChatConflicts(msg.Text)
+}
+
+// RecommendedEvents ranks upcoming events for the current user by their
+// remembered program and interests and their past questions, with the
+// reasons per event. ?from=YYYY-MM-DD replaces today, ?limit= caps the list
+// (default 5, at most 20).
+func (ctrl *UIBController) RecommendedEvents(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		uid := currentUserID(c)
+		if uid == 0 {
+			apierror.Respond(c, http.StatusForbidden, "Recommendations need a user token, not an API key")
+			return
+		}
+
+		from := time.Now()
+		if v := c.Query("from"); v != "" {
+			d, err := time.Parse("2006-01-02", v)
+			if err != nil {
+				apierror.Respond(c, http.StatusBadRequest, "from must be YYYY-MM-DD")
+				return
+			}
+			from = d
+		}
+		limit := 5
+		if v := c.Query("limit"); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil || n < 1 || n > 20 {
+				apierror.Respond(c, http.StatusBadRequest, "limit must be between 1 and 20")
+				return
+			}
+			limit = n
+		}
+
+		p, campus, err := recommendProfile(db, uid)
+		if err != nil {
+			apierror.Respond(c, http.StatusInternalServerError, "Failed to load user profile")
+			return
+		}
+		uib := ctrl.campusesFor(c.Request.Context()).Dataset(campus)
+		if uib == nil || c.Query("campus") != "" {
+			if uib = ctrl.dataset(c); uib == nil {
+				return
+			}
+		}
+		recs := uib.RecommendEvents(p, from, limit)
+		if recs == nil {
+			recs = []services.Recommendation{}
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"success": true,
+			"data":    recs,
+			"total":   len(recs),
+			"profile": gin.H{
+				"program":      p.Program,
+				"interests":    p.Interests,
+				"campus":       uib.Institution(),
+				"past_queries": len(p.PastQueries),
+			},
+			"message": "Recommended UIB events retrieved successfully",
+		})
+	}
+}
@@ -0,0 +1,375 @@
+package controllers
+
+import (
+	"AkuAI/models"
+	"AkuAI/pkg/apierror"
+	"AkuAI/pkg/audit"
+	"AkuAI/pkg/certificate"
+	"AkuAI/pkg/config"
+	"AkuAI/pkg/registration"
+	"AkuAI/pkg/services"
+	"AkuAI/pkg/webhook"
+	"AkuAI/pkg/wshub"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// registrationEvent loads event :id of the requested campus, answering 404
+// when there is none. Its capacity is its own, else EVENT_DEFAULT_CAPACITY.
+func (ctrl *UIBController) registrationEvent(c *gin.Context) (*models.UIBEvent, registration.Event, bool) {
+	ds := ctrl.dataset(c)
+	if ds == nil {
+		return nil, registration.Event{}, false
+	}
+	ev, err := ds.GetEventByID(c.Param("id"))
+	if err != nil {
+		apierror.Respond(c, http.StatusNotFound, "Event not found: "+err.Error())
+		return nil, registration.Event{}, false
+	}
+	capacity := ev.Capacity
+	if capacity == 0 {
+		capacity = config.EventDefaultCapacity
+	}
+	return ev, registration.Event{Tenant: currentTenant(c).ID, Campus: ds.Institution(), ID: ev.ID, Capacity: capacity}, true
+}
+
+// registrationUser is the signed-in user, answering 403 for API keys, which
+// belong to no one who could attend.
+func registrationUser(c *gin.Context) (uint, bool) {
+	uid := currentUserID(c)
+	if uid == 0 {
+		apierror.Respond(c, http.StatusForbidden, "Registrations need a user token, not an API key")
+	}
+	return uid, uid != 0
+}
+
+func registrationJSON(db *gorm.DB, r models.EventRegistration) gin.H {
+	return gin.H{
+		"id":           r.ID,
+		"campus":       r.Campus,
+		"event_id":     r.EventID,
+		"status":       r.Status,
+		"position":     registration.Position(db, r),
+		"queued_at":    r.QueuedAt,
+		"confirmed_at": r.ConfirmedAt,
+		"cancelled_at": r.CancelledAt,
+	}
+}
+
+func seatsJSON(db *gorm.DB, e registration.Event) gin.H {
+	confirmed, waitlisted, _ := registration.Counts(db, e)
+	seats := gin.H{"capacity": e.Capacity, "confirmed": confirmed, "waitlisted": waitlisted}
+	if e.Capacity != 0 {
+		seats["available"] = max(int64(e.Capacity)-confirmed, 0)
+	}
+	return seats
+}
+
+// notifyRegistration emits the webhook of r's status and tells the user's
+// open tabs.
+func notifyRegistration(db *gorm.DB, ev *models.UIBEvent, r models.EventRegistration, event string, promoted bool) {
+	data := gin.H{"registration_id": r.ID, "user_id": r.UserID, "campus": r.Campus, "event_id": r.EventID,
+		"title": ev.Title, "status": r.Status}
+	if promoted {
+		data["promoted"] = true
+	}
+	webhook.Emit(db, event, r.UserID, data)
+	msg := gin.H{"type": "registration", "event_id": r.EventID, "title": ev.Title, "status": r.Status, "promoted": promoted}
+	if r.Status == models.RegistrationWaitlisted {
+		msg["position"] = registration.Position(db, r)
+	}
+	wshub.Default().SendUser(strconv.Itoa(int(r.UserID)), msg)
+}
+
+// RegisterForEvent registers the signed-in user for event :id: a confirmed
+// seat while the event has capacity left, otherwise a place on its waiting
+// list. Registering again returns the active registration.
+func (ctrl *UIBController) RegisterForEvent(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		uid, ok := registrationUser(c)
+		if !ok {
+			return
+		}
+		ev, e, ok := ctrl.registrationEvent(c)
+		if !ok {
+			return
+		}
+		r, created, err := registration.Register(db, e, uid)
+		if err != nil {
+			log.Printf("[registration] ❌ register user %d for %s/%s: %v", uid, e.Campus, e.ID, err)
+			apierror.Respond(c, http.StatusInternalServerError, "Failed to register for event")
+			return
+		}
+		status := http.StatusOK
+		if created {
+			status = http.StatusCreated
+			recordAudit(c, db, audit.Entry{Action: audit.ActionRegistrationCreate, TargetType: "event_registration",
+				TargetID: strconv.Itoa(int(r.ID)), After: gin.H{"campus": r.Campus, "event_id": r.EventID, "status": r.Status}})
+			event := webhook.EventRegistrationConfirmed
+			if r.Status == models.RegistrationWaitlisted {
+				event = webhook.EventRegistrationWaitlisted
+			}
+			notifyRegistration(db, ev, r, event, false)
+		}
+		c.JSON(status, gin.H{"registration": registrationJSON(db, r), "seats": seatsJSON(db, e)})
+	}
+}
+
+// CancelEventRegistration cancels the signed-in user's registration for
+// event :id. A freed seat goes to the first on the waiting list, who is
+// notified.
+func (ctrl *UIBController) CancelEventRegistration(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		uid, ok := registrationUser(c)
+		if !ok {
+			return
+		}
+		ev, e, ok := ctrl.registrationEvent(c)
+		if !ok {
+			return
+		}
+		r, promoted, err := registration.Cancel(db, e, uid)
+		if errors.Is(err, registration.ErrNotRegistered) {
+			apierror.Respond(c, http.StatusNotFound, "Not registered for this event")
+			return
+		} else if err != nil {
+			log.Printf("[registration] ❌ cancel user %d for %s/%s: %v", uid, e.Campus, e.ID, err)
+			apierror.Respond(c, http.StatusInternalServerError, "Failed to cancel registration")
+			return
+		}
+		recordAudit(c, db, audit.Entry{Action: audit.ActionRegistrationCancel, TargetType: "event_registration",
+			TargetID: strconv.Itoa(int(r.ID)), After: gin.H{"campus": r.Campus, "event_id": r.EventID, "promoted": len(promoted)}})
+		webhook.Emit(db, webhook.EventRegistrationCancelled, uid, gin.H{"registration_id": r.ID, "user_id": uid,
+			"campus": r.Campus, "event_id": r.EventID, "title": ev.Title})
+		for _, p := range promoted {
+			log.Printf("[registration] ⬆️ user %d promoted from the waiting list of %s/%s", p.UserID, e.Campus, e.ID)
+			notifyRegistration(db, ev, p, webhook.EventRegistrationConfirmed, true)
+		}
+		c.JSON(http.StatusOK, gin.H{"registration": registrationJSON(db, r), "promoted": len(promoted), "seats": seatsJSON(db, e)})
+	}
+}
+
+// GetEventRegistration returns the signed-in user's registration for event
+// :id, with their place on the waiting list, and the event's seats.
+func (ctrl *UIBController) GetEventRegistration(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		uid, ok := registrationUser(c)
+		if !ok {
+			return
+		}
+		_, e, ok := ctrl.registrationEvent(c)
+		if !ok {
+			return
+		}
+		var r models.EventRegistration
+		err := db.Where("tenant_id = ? AND campus = ? AND event_id = ? AND user_id = ?", e.Tenant, e.Campus, e.ID, uid).First(&r).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			apierror.Respond(c, http.StatusNotFound, "Not registered for this event")
+			return
+		} else if err != nil {
+			apierror.Respond(c, http.StatusInternalServerError, "db error")
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"registration": registrationJSON(db, r), "seats": seatsJSON(db, e)})
+	}
+}
+
+// ListRegistrations returns the signed-in user's active registrations,
+// newest first; ?all=true includes cancelled ones.
+func ListRegistrations(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		uid, ok := registrationUser(c)
+		if !ok {
+			return
+		}
+		q := db.Where("user_id = ?", uid)
+		if c.Query("all") != "true" {
+			q = q.Where("status <> ?", models.RegistrationCancelled)
+		}
+		var rows []models.EventRegistration
+		if err := q.Order("id DESC").Find(&rows).Error; err != nil {
+			apierror.Respond(c, http.StatusInternalServerError, "db error")
+			return
+		}
+		out := make([]gin.H, 0, len(rows))
+		for _, r := range rows {
+			out = append(out, registrationJSON(db, r))
+		}
+		c.JSON(http.StatusOK, gin.H{"registrations": out})
+	}
+}
+
+// EventRoster returns the confirmed participants of event :id and its
+// waiting list in order, for admins.
+func (ctrl *UIBController) EventRoster(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ev, e, ok := ctrl.registrationEvent(c)
+		if !ok {
+			return
+		}
+		confirmed, waitlisted, err := registration.Roster(db, e)
+		if err != nil {
+			apierror.Respond(c, http.StatusInternalServerError, "db error")
+			return
+		}
+		ids := make([]uint, 0, len(confirmed)+len(waitlisted))
+		for _, r := range append(append([]models.EventRegistration{}, confirmed...), waitlisted...) {
+			ids = append(ids, r.UserID)
+		}
+		var users []models.User
+		if len(ids) > 0 {
+			db.Select("id", "username", "email").Where("id IN ?", ids).Find(&users)
+		}
+		byID := make(map[uint]models.User, len(users))
+		for _, u := range users {
+			byID[u.ID] = u
+		}
+		entries := func(rows []models.EventRegistration, waiting bool) []gin.H {
+			out := make([]gin.H, 0, len(rows))
+			for i, r := range rows {
+				u := byID[r.UserID]
+				entry := gin.H{"registration_id": r.ID, "user_id": r.UserID, "username": u.Username, "email": u.Email,
+					"queued_at": r.QueuedAt, "confirmed_at": r.ConfirmedAt}
+				if waiting {
+					entry["position"] = i + 1
+				}
+				out = append(out, entry)
+			}
+			return out
+		}
+		c.JSON(http.StatusOK, gin.H{
+			"event":      gin.H{"id": ev.ID, "title": ev.Title, "campus": e.Campus},
+			"seats":      seatsJSON(db, e),
+			"confirmed":  entries(confirmed, false),
+			"waitlisted": entries(waitlisted, true),
+		})
+	}
+}
+
+// CheckInRegistration marks confirmed registration :id as attended, for
+// admins. Checked-in participants can download a certificate of attendance.
+func CheckInRegistration(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var r models.EventRegistration
+		if err := db.Where("tenant_id = ?", currentTenant(c).ID).First(&r, c.Param("id")).Error; err != nil {
+			apierror.Respond(c, http.StatusNotFound, "registration not found")
+			return
+		}
+		if r.Status != models.RegistrationConfirmed {
+			apierror.Respond(c, http.StatusConflict, "only confirmed registrations can check in")
+			return
+		}
+		if r.CheckedInAt == nil {
+			now := time.Now()
+			if err := db.Model(&r).Update("checked_in_at", now).Error; err != nil {
+				apierror.Respond(c, http.StatusInternalServerError, "db error")
+				return
+			}
+			r.CheckedInAt = &now
+			recordAudit(c, db, audit.Entry{Action: audit.ActionRegistrationCheckIn, TargetType: "event_registration",
+				TargetID: strconv.Itoa(int(r.ID)), After: gin.H{"campus": r.Campus, "event_id": r.EventID, "user_id": r.UserID}})
+		}
+		out := registrationJSON(db, r)
+		out["checked_in_at"] = r.CheckedInAt
+		c.JSON(http.StatusOK, out)
+	}
+}
+
+// RegistrationCertificate returns a signed, expiring download URL for the
+// certificate of attendance of the signed-in user's registration :id. The
+// PDF is generated and stored on first request; it needs a check-in.
+func (ctrl *UIBController) RegistrationCertificate(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		uid, ok := registrationUser(c)
+		if !ok {
+			return
+		}
+		var r models.EventRegistration
+		if err := db.Where("id = ? AND user_id = ?", c.Param("id"), uid).First(&r).Error; err != nil {
+			apierror.Respond(c, http.StatusNotFound, "registration not found")
+			return
+		}
+		if r.Status != models.RegistrationConfirmed || r.CheckedInAt == nil {
+			apierror.Respond(c, http.StatusConflict, "certificates are available after checking in at the event")
+			return
+		}
+		storage := services.NewObjectStorageService()
+		if !storage.HasCertificate(r.CertificatePath) {
+			path, err := ctrl.generateCertificate(db, storage, r)
+			if err != nil {
+				log.Printf("[registration] ❌ certificate for registration %d: %v", r.ID, err)
+				apierror.Respond(c, http.StatusInternalServerError, "Failed to generate certificate")
+				return
+			}
+			r.CertificatePath = path
+		}
+		url, expires := storage.SignedCertificateURL(r.CertificatePath, time.Duration(config.CertificateURLTTLMinutes)*time.Minute)
+		c.JSON(http.StatusOK, gin.H{"registration_id": r.ID, "url": url, "expires_at": expires})
+	}
+}
+
+// generateCertificate renders and stores the certificate of r and records
+// its path.
+func (ctrl *UIBController) generateCertificate(db *gorm.DB, storage *services.ObjectStorageService, r models.EventRegistration) (string, error) {
+	ds := ctrl.campusesOf(tenantByID(r.TenantID)).Dataset(r.Campus)
+	if ds == nil {
+		return "", fmt.Errorf("no event data for campus %s", r.Campus)
+	}
+	ev, err := ds.GetEventByID(r.EventID)
+	if err != nil {
+		return "", err
+	}
+	var user models.User
+	if err := db.Select("id", "username").First(&user, r.UserID).Error; err != nil {
+		return "", err
+	}
+	cert := certificate.Certificate{
+		Number:      fmt.Sprintf("%s-%06d", strings.ToUpper(ev.ID), r.ID),
+		Name:        user.Username,
+		Event:       ev.Title,
+		Date:        services.IndonesianDate(ev.Date),
+		Organizer:   r.Campus,
+		Signer:      config.CertificateSigner,
+		SignerTitle: config.CertificateSignerTitle,
+	}
+	if cert.Signer == "" {
+		cert.Signer, cert.SignerTitle = ev.Department, "Penyelenggara"
+	}
+	if config.CertificateSignatureImage != "" {
+		if cert.Signature, err = certificate.LoadSignature(config.CertificateSignatureImage); err != nil {
+			log.Printf("[registration] ⚠️ signature image %s unavailable: %v", config.CertificateSignatureImage, err)
+		}
+	}
+	pdf, err := certificate.Render(cert)
+	if err != nil {
+		return "", err
+	}
+	path, err := storage.SaveCertificate(r.UserID, r.ID, pdf)
+	if err != nil {
+		return "", err
+	}
+	return path, db.Model(&r).Update("certificate_path", path).Error
+}
+
+// DownloadCertificate serves a stored certificate to a signed URL from
+// RegistrationCertificate; it needs no token.
+func DownloadCertificate(c *gin.Context) {
+	storage := services.NewObjectStorageService()
+	full, err := storage.OpenCertificate(strings.TrimPrefix(c.Param("path"), "/"), c.Query("expires"), c.Query("signature"))
+	if errors.Is(err, services.ErrInvalidSignature) {
+		apierror.Respond(c, http.StatusForbidden, err.Error())
+		return
+	} else if err != nil {
+		apierror.Respond(c, http.StatusNotFound, "certificate not found")
+		return
+	}
+	c.FileAttachment(full, "sertifikat.pdf")
+}
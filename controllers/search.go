@@ -0,0 +1,59 @@
+package controllers
+
+import (
+	"AkuAI/models"
+	"AkuAI/pkg/fieldcrypt"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// searchInApp reports whether ?q= searches must open the rows and match in
+// Go: with FIELD_ENCRYPTION_KEYS set, message texts and titles are sealed
+// and a LIKE in the database would only see ciphertext.
+func searchInApp() bool {
+	return fieldcrypt.Default().Enabled()
+}
+
+// containsFold reports whether text contains the lower-cased query q,
+// ignoring case.
+func containsFold(text, q string) bool {
+	return strings.Contains(strings.ToLower(text), q)
+}
+
+// conversationsMatching keeps the conversations whose title or any message
+// contains the lower-cased query q, reading the messages of the one user's
+// conversations in batches.
+func conversationsMatching(db *gorm.DB, convs []models.Conversation, q string) ([]models.Conversation, error) {
+	hit := make(map[uint]bool, len(convs))
+	var rest []uint
+	for _, conv := range convs {
+		if containsFold(conv.Title, q) {
+			hit[conv.ID] = true
+		} else {
+			rest = append(rest, conv.ID)
+		}
+	}
+	if len(rest) > 0 {
+		var batch []models.Message
+		err := db.Select("id", "conversation_id", "text").Where("conversation_id IN ?", rest).
+			FindInBatches(&batch, 500, func(*gorm.DB, int) error {
+				for _, m := range batch {
+					if containsFold(m.Text, q) {
+						hit[m.ConversationID] = true
+					}
+				}
+				return nil
+			}).Error
+		if err != nil {
+			return nil, err
+		}
+	}
+	kept := convs[:0]
+	for _, conv := range convs {
+		if hit[conv.ID] {
+			kept = append(kept, conv)
+		}
+	}
+	return kept, nil
+}
@@ -0,0 +1,105 @@
+package controllers
+
+import (
+	"AkuAI/pkg/cache"
+	"AkuAI/pkg/config"
+	"AkuAI/pkg/metrics"
+	svc "AkuAI/pkg/services"
+	"AkuAI/pkg/tenant"
+	"context"
+	"log"
+	"sync"
+	"time"
+)
+
+var (
+	semanticOnce  sync.Once
+	semanticStore *cache.SemanticCache
+)
+
+// Answers cached before event data changed may cite stale events; those
+// tagged with the changed events or their months are dropped.
+func init() {
+	svc.OnEventsChanged(func(changes []svc.EventChange) {
+		tags := svc.EventChangeTags(changes)
+		exact := cache.Default().InvalidateTags(tags...)
+		semantic := chatSemanticCache().InvalidateTags(tags...)
+		log.Printf("[conversation] %d cached and %d semantic replies dropped after %d event changes (%d tags)",
+			exact, semantic, len(changes), len(tags))
+	})
+}
+
+// cacheChatReply stores a completed reply in the chat and semantic caches,
+// tagged with the events it rests on.
+func cacheChatReply(ctx context.Context, key, uidStr, effMode, message string, history []svc.ChatMessage, reply string) {
+	tags := svc.EventCacheTags(message, reply)
+	cache.Default().SetChatResponse(key, reply, cache.StatusCompleted, time.Duration(config.ChatCacheTTLSeconds)*time.Second, tags...)
+	semanticRemember(ctx, uidStr, effMode, message, history, reply, tags)
+}
+
+// chatSemanticCache returns nil when SEMANTIC_CACHE_ENABLED is off; the
+// cache methods are nil-safe.
+func chatSemanticCache() *cache.SemanticCache {
+	if !config.SemanticCacheEnabled {
+		return nil
+	}
+	semanticOnce.Do(func() {
+		var embedder cache.Embedder = cache.LocalEmbedder{}
+		if config.SemanticCacheEmbedder == "gemini" {
+			embedder = svc.NewGeminiEmbedder()
+		}
+		semanticStore = cache.NewSemanticCache(embedder, config.SemanticCacheThreshold, config.SemanticCacheMaxEntries)
+		metrics.RegisterFunc("semantic_cache_entries", func() any { return semanticStore.Len() })
+		log.Printf("[conversation] semantic cache ready (embedder=%s threshold=%.2f)", config.SemanticCacheEmbedder, config.SemanticCacheThreshold)
+	})
+	return semanticStore
+}
+
+// semanticScopes lists the scopes a question may be answered from, most
+// specific first. UIB factual questions asked without prior context may also
+// share answers across the users of a tenant, unless the reply is personalised by the user's
+// memory or reply preferences. Every scope ends with the question's
+// svc.SemanticFacets, so questions about another month, event type or
+// campus never match.
+func semanticScopes(ctx context.Context, uidStr, effMode, message string, history []svc.ChatMessage) []string {
+	facets := "|" + svc.SemanticFacets(message)
+	prefs := svc.PreferencesKey(ctx)
+	user := "user:" + uidStr + ":" + effMode
+	if prefs != "" {
+		user += ":" + prefs
+	}
+	scopes := []string{user + facets}
+	if config.SemanticCacheGlobalUIB && len(history) <= 1 && isUIBEventQuery(message) && !svc.HasUserMemory(ctx) && prefs == "" {
+		shared := "uib:" + effMode
+		if id := tenant.ID(ctx); id != "" {
+			shared += ":" + id
+		}
+		scopes = append(scopes, shared+facets)
+	}
+	return scopes
+}
+
+func semanticLookup(ctx context.Context, uidStr, effMode, message string, history []svc.ChatMessage) (string, bool) {
+	sc := chatSemanticCache()
+	if sc == nil {
+		return "", false
+	}
+	for _, scope := range semanticScopes(ctx, uidStr, effMode, message, history) {
+		if text, _, ok := sc.Lookup(ctx, scope, message); ok {
+			svc.MarkCached(ctx)
+			return text, true
+		}
+	}
+	return "", false
+}
+
+func semanticRemember(ctx context.Context, uidStr, effMode, message string, history []svc.ChatMessage, reply string, tags []string) {
+	sc := chatSemanticCache()
+	if sc == nil {
+		return
+	}
+	ttl := time.Duration(config.ChatCacheTTLSeconds) * time.Second
+	for _, scope := range semanticScopes(ctx, uidStr, effMode, message, history) {
+		sc.Store(ctx, scope, message, reply, ttl, tags...)
+	}
+}
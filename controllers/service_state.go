@@ -0,0 +1,30 @@
+package controllers
+
+import (
+	"AkuAI/pkg/i18n"
+	svc "AkuAI/pkg/services"
+	"AkuAI/pkg/wshub"
+	"context"
+	"log"
+	"maps"
+
+	"github.com/gin-gonic/gin"
+)
+
+// observeServiceState moves the service state to the one a reply shows. On
+// a change it pushes a system event to every listening WebSocket, with the
+// message in the default locale, and returns it in the locale of ctx, so a
+// chat stream can send it too.
+func observeServiceState(ctx context.Context, info *svc.GenerationInfo) (svc.ServiceState, gin.H) {
+	state, change := svc.ObserveReply(info)
+	if change == nil {
+		return state, nil
+	}
+	log.Printf("[health] 🚦 service state %s → %s", change.Previous, change.State)
+	event := gin.H{"type": "system", "event": "service_state", "state": change.State, "previous": change.Previous,
+		"since": change.Since, "message": change.Message}
+	wshub.Default().Broadcast(event)
+	own := maps.Clone(event)
+	own["message"] = svc.StateMessage(i18n.FromContext(ctx), change.State)
+	return state, own
+}
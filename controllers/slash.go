@@ -0,0 +1,188 @@
+package controllers
+
+import (
+	"AkuAI/pkg/apierror"
+	"AkuAI/pkg/config"
+	"AkuAI/pkg/messaging"
+	"AkuAI/pkg/postprocess"
+	svc "AkuAI/pkg/services"
+	"context"
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+const slashHelp = "**/uib events [bulan | jenis acara]** daftar acara (jenis: webinar, sertifikasi, seminar, workshop, bootcamp, lomba, kuliah_umum)\n" +
+	"**/uib event <id>** detail satu acara\n" +
+	"**/uib search <kata kunci>** cari acara\n" +
+	"**/uib ask <pertanyaan>** (atau langsung **/uib <pertanyaan>**) jawaban AkuAI dari data acara"
+
+const slashNotAllowed = "Perintah ini hanya tersedia di channel staf."
+
+// uibSlashReply answers "/uib <text>" from the event data. Questions for
+// the model are not answered here: deferred is then true and the question
+// goes to staffAnswer.
+func uibSlashReply(text string) (reply string, deferred bool) {
+	sub, arg, _ := strings.Cut(strings.TrimSpace(text), " ")
+	arg = strings.TrimSpace(arg)
+	switch strings.ToLower(sub) {
+	case "", "help":
+		return slashHelp, false
+	case "events":
+		return chatEventListing(arg), false
+	case "event":
+		ds := messagingDataset()
+		if ds == nil {
+			return messagingNoData, false
+		}
+		ev, err := ds.GetEventByID(arg)
+		if err != nil {
+			return "Acara " + arg + " tidak ditemukan.", false
+		}
+		return messaging.FormatEvent(*ev), false
+	case "search":
+		ds := messagingDataset()
+		if ds == nil {
+			return messagingNoData, false
+		}
+		if arg == "" {
+			return "Kirim **/uib search <kata kunci>**.", false
+		}
+		return messaging.FormatEvents("🔎 **Hasil pencarian: "+arg+"**", ds.GetRelevantEventsForQuery(arg), messagingEventLimit), false
+	}
+	return "", true
+}
+
+// staffQuestion is the question of a deferred "/uib ask ..." or "/uib ...".
+func staffQuestion(text string) string {
+	if sub, arg, ok := strings.Cut(strings.TrimSpace(text), " "); ok && strings.EqualFold(sub, "ask") {
+		return strings.TrimSpace(arg)
+	}
+	return strings.TrimSpace(text)
+}
+
+// staffAnswer answers a staff question with the engineered UIB context
+// prompt. Staff channels have no conversation, so nothing is saved.
+func staffAnswer(ctx context.Context, platform, question string) string {
+	ctx, _ = svc.WithGenerationInfo(ctx)
+	reply := generateChatReply(ctx, "staff:"+platform, "engineered", question, []svc.ChatMessage{{Role: "user", Text: question}})
+	clean, _ := svc.ResolveCitations(postprocess.Default().Apply(reply))
+	return "**" + question + "**\n\n" + clean
+}
+
+func slashAllowed(channels []string, channel string) bool {
+	if len(channels) == 0 {
+		return true
+	}
+	for _, ch := range channels {
+		if ch == channel {
+			return true
+		}
+	}
+	return false
+}
+
+// SlackCommand answers the /uib slash command of a Slack app. Event
+// listings are returned at once; questions are acknowledged and answered
+// through the command's response_url.
+func SlackCommand() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if config.SlackSigningSecret == "" {
+			apierror.Respond(c, http.StatusNotFound, "Slack is not configured")
+			return
+		}
+		slack := messaging.NewSlack(config.SlackSigningSecret)
+		body, err := io.ReadAll(io.LimitReader(c.Request.Body, 1<<20))
+		if err != nil || !slack.Verify(body, c.GetHeader("X-Slack-Request-Timestamp"), c.GetHeader("X-Slack-Signature")) {
+			apierror.Respond(c, http.StatusUnauthorized, "invalid signature")
+			return
+		}
+		cmd, responseURL, err := slack.ParseCommand(body)
+		if err != nil {
+			apierror.Respond(c, http.StatusBadRequest, "malformed command")
+			return
+		}
+		if !slashAllowed(config.SlackAllowedChannels, cmd.ChannelID) {
+			c.JSON(http.StatusOK, messaging.SlackMessage(slashNotAllowed, true))
+			return
+		}
+		log.Printf("[staffbot] slack %s %q by %s in %s", cmd.Command, cmd.Text, cmd.UserName, cmd.ChannelID)
+		reply, deferred := uibSlashReply(cmd.Text)
+		if !deferred {
+			c.JSON(http.StatusOK, messaging.SlackMessage(reply, false))
+			return
+		}
+		question := staffQuestion(cmd.Text)
+		go func() {
+			ctx, cancel := context.WithTimeout(context.Background(), messagingReplyBudget)
+			defer cancel()
+			if err := slack.Respond(ctx, responseURL, staffAnswer(ctx, cmd.Platform, question)); err != nil {
+				log.Printf("[staffbot] ❌ slack answer failed: %v", err)
+			}
+		}()
+		c.JSON(http.StatusOK, messaging.SlackMessage("⏳ Mencari jawaban untuk: "+question, true))
+	}
+}
+
+// DiscordInteraction is the interactions endpoint of a Discord application
+// with a /uib command. Like SlackCommand, questions are deferred and
+// answered by editing the response.
+func DiscordInteraction() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if config.DiscordPublicKey == "" {
+			apierror.Respond(c, http.StatusNotFound, "Discord is not configured")
+			return
+		}
+		discord, err := messaging.NewDiscord(config.DiscordApplicationID, config.DiscordPublicKey, config.DiscordAPIBase)
+		if err != nil {
+			log.Printf("[staffbot] ❌ %v", err)
+			apierror.Respond(c, http.StatusInternalServerError, "Discord is misconfigured")
+			return
+		}
+		body, err := io.ReadAll(io.LimitReader(c.Request.Body, 1<<20))
+		if err != nil || !discord.Verify(body, c.GetHeader("X-Signature-Timestamp"), c.GetHeader("X-Signature-Ed25519")) {
+			// Discord checks that bad signatures are refused with 401.
+			apierror.Respond(c, http.StatusUnauthorized, "invalid request signature")
+			return
+		}
+		var in messaging.Interaction
+		if err := json.Unmarshal(body, &in); err != nil {
+			apierror.Respond(c, http.StatusBadRequest, "malformed interaction")
+			return
+		}
+		switch in.Type {
+		case messaging.DiscordPing:
+			c.JSON(http.StatusOK, gin.H{"type": messaging.DiscordPong})
+			return
+		case messaging.DiscordApplicationCommand:
+		default:
+			apierror.Respond(c, http.StatusBadRequest, "unsupported interaction type")
+			return
+		}
+
+		cmd := in.Command()
+		if !slashAllowed(config.DiscordAllowedChannels, cmd.ChannelID) {
+			c.JSON(http.StatusOK, messaging.DiscordMessage(slashNotAllowed))
+			return
+		}
+		log.Printf("[staffbot] discord %s %q by %s in %s", cmd.Command, cmd.Text, cmd.UserName, cmd.ChannelID)
+		reply, deferred := uibSlashReply(cmd.Text)
+		if !deferred {
+			c.JSON(http.StatusOK, messaging.DiscordMessage(reply))
+			return
+		}
+		question := staffQuestion(cmd.Text)
+		go func() {
+			ctx, cancel := context.WithTimeout(context.Background(), messagingReplyBudget)
+			defer cancel()
+			if err := discord.FollowUp(ctx, in.Token, staffAnswer(ctx, cmd.Platform, question)); err != nil {
+				log.Printf("[staffbot] ❌ discord answer failed: %v", err)
+			}
+		}()
+		c.JSON(http.StatusOK, gin.H{"type": messaging.DiscordDeferredChannelMessage})
+	}
+}
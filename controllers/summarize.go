@@ -0,0 +1,81 @@
+package controllers
+
+import (
+	"AkuAI/middleware"
+	"AkuAI/models"
+	"AkuAI/pkg/apierror"
+	"AkuAI/pkg/i18n"
+	svc "AkuAI/pkg/services"
+	"context"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// SummarizeLastAnswer condenses the conversation's last completed bot
+// reply: the reply goes back to the model with SummarizeInstruction at short
+// verbosity, and the request and the summary are added to the conversation
+// as a new turn.
+func SummarizeLastAnswer(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		uidStr := c.GetString(middleware.ContextUserIDKey)
+		uid := currentUserID(c)
+
+		var conv models.Conversation
+		if err := db.Where("id = ? AND user_id = ?", c.Param("conversation_id"), uid).First(&conv).Error; err != nil {
+			apierror.Respond(c, http.StatusNotFound, "conversation not found")
+			return
+		}
+		var answer models.Message
+		err := db.Where("conversation_id = ? AND sender = ? AND status = ?", conv.ID, "bot", models.MessageCompleted).
+			Order("id DESC").First(&answer).Error
+		if err != nil || i18n.Is(i18n.NoAnswer, strings.TrimSpace(answer.Text)) {
+			apierror.Respond(c, http.StatusNotFound, "conversation has no reply to summarize")
+			return
+		}
+		var question models.Message
+		db.Where("conversation_id = ? AND sender = ? AND id < ?", conv.ID, "user", answer.ID).Order("id DESC").Limit(1).Find(&question)
+
+		release, err := middleware.TryAcquireUserSlot(c.Request.Context(), uidStr)
+		if err != nil {
+			middleware.AbortSlotBusy(c, err)
+			return
+		}
+		defer release()
+
+		var history []svc.ChatMessage
+		if question.Text != "" {
+			history = append(history, svc.ChatMessage{Role: "user", Text: question.Text})
+		}
+		history = append(history, svc.ChatMessage{Role: "model", Text: strings.TrimPrefix(answer.Text, svc.UncertaintyDisclaimer)},
+			svc.ChatMessage{Role: "user", Text: svc.SummarizeInstruction})
+
+		ctx := svc.WithIncognito(svc.WithVerbosity(c.Request.Context(), models.VerbosityShort), conv.Incognito)
+		ctx, cancel := context.WithTimeout(ctx, 60*time.Second)
+		defer cancel()
+		ctx, info := svc.WithGenerationInfo(ctx)
+		summary, err := svc.NewGeminiServiceFor(ctx).AskCampusWithChat(ctx, history)
+		if err != nil || strings.TrimSpace(summary) == "" {
+			log.Printf("[conversation] ⚠️ summary of message %d failed: %v", answer.ID, err)
+			apierror.Respond(c, http.StatusBadGateway, "failed to summarize the reply, try again later")
+			return
+		}
+
+		msgUser := models.Message{ConversationID: conv.ID, Sender: "user", Text: i18n.T(i18n.FromContext(ctx), i18n.SummarizeRequest), Timestamp: time.Now()}
+		if err := db.Create(&msgUser).Error; err != nil {
+			apierror.Respond(c, http.StatusInternalServerError, "failed to save message")
+			return
+		}
+		msg, err := saveBotMessage(ctx, db, conv.ID, question.Text, summary, answer.PromptMode, info)
+		if err != nil {
+			apierror.Respond(c, http.StatusInternalServerError, "failed to save bot reply")
+			return
+		}
+		state, _ := observeServiceState(ctx, info)
+		c.JSON(http.StatusCreated, gin.H{"conversation_id": conv.ID, "summarized_message_id": answer.ID, "message": messageJSON(msg), "service_state": state})
+	}
+}
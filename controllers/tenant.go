@@ -0,0 +1,91 @@
+package controllers
+
+import (
+	"AkuAI/models"
+	svc "AkuAI/pkg/services"
+	"AkuAI/pkg/tenant"
+	"log"
+	"net/http"
+	"path/filepath"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// currentTenant is the tenant the request was resolved to by
+// middleware.Tenant; queries over more than the signed-in user's own rows
+// filter on its ID.
+func currentTenant(c *gin.Context) *tenant.Tenant {
+	return tenant.FromContext(c.Request.Context())
+}
+
+// tenantByID returns the tenant of a row's tenant_id. A tenant since
+// removed from TENANTS_FILE keeps its ID, with the deployment's settings.
+func tenantByID(id string) *tenant.Tenant {
+	if t, ok := tenant.Default().Get(id); ok {
+		return t
+	}
+	return &tenant.Tenant{ID: id}
+}
+
+// userTenant is the tenant of user uid, for work done outside their
+// requests: chat bots and the digest.
+func userTenant(db *gorm.DB, uid uint) *tenant.Tenant {
+	var id string
+	if err := db.Model(&models.User{}).Select("tenant_id").Where("id = ?", uid).Scan(&id).Error; err != nil {
+		log.Printf("[tenant] ⚠️ failed to load the tenant of user %d: %v", uid, err)
+	}
+	return tenantByID(id)
+}
+
+// tenantCampuses caches the event datasets of tenants with their own, by
+// dataset and directory, since loading them reads every file.
+var tenantCampuses = struct {
+	sync.Mutex
+	loaded map[[2]string]*svc.CampusDataService
+}{loaded: map[[2]string]*svc.CampusDataService{}}
+
+// campusData returns the event datasets of t; nil, with the error logged,
+// when they can't be loaded.
+func campusData(t *tenant.Tenant) *svc.CampusDataService {
+	key := [2]string{t.Dataset, t.CampusDataDir}
+	tenantCampuses.Lock()
+	defer tenantCampuses.Unlock()
+	if c, ok := tenantCampuses.loaded[key]; ok {
+		return c
+	}
+	c, err := svc.NewCampusDataServiceFor(t)
+	if err != nil {
+		log.Printf("[tenant] ⚠️ event data of tenant %q unavailable: %v", t.ID, err)
+		return nil
+	}
+	tenantCampuses.loaded[key] = c
+	return c
+}
+
+// hasOwnDatasets reports whether t replaces any of the deployment's event
+// datasets.
+func hasOwnDatasets(t *tenant.Tenant) bool {
+	return t.Dataset != "" || t.CampusDataDir != ""
+}
+
+// ownsDataset reports whether the admins of t may change ds: the
+// operator's may change any, a tenant's only the datasets it brings.
+func ownsDataset(t *tenant.Tenant, ds *svc.UIBEventService) bool {
+	if t.IsOperator() {
+		return true
+	}
+	p := filepath.Clean(ds.Path())
+	return (t.Dataset != "" && p == filepath.Clean(t.Dataset)) ||
+		(t.CampusDataDir != "" && filepath.Dir(p) == filepath.Clean(t.CampusDataDir))
+}
+
+// GetTenant returns the name and branding of the request's tenant, so one
+// frontend build can serve every tenant.
+func GetTenant() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		t := currentTenant(c)
+		c.JSON(http.StatusOK, gin.H{"id": t.ID, "name": t.Name, "branding": t.Brand()})
+	}
+}
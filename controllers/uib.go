@@ -1,32 +1,110 @@
 package controllers
 
 import (
+	"context"
+	"fmt"
 	"net/http"
+	"strconv"
+	"strings"
 
 	"AkuAI/models"
+	"AkuAI/pkg/apierror"
+	"AkuAI/pkg/i18n"
 	"AkuAI/pkg/services"
+	"AkuAI/pkg/tenant"
 
 	"github.com/gin-gonic/gin"
 )
 
 type UIBController struct {
-	uibService *services.UIBEventService
+	campuses *services.CampusDataService // the deployment's
 }
 
+// NewUIBController loads the deployment's event datasets and those of
+// every tenant with its own, failing when any can't be loaded.
 func NewUIBController() (*UIBController, error) {
-	uibService, err := services.NewUIBEventService()
+	campuses, err := services.NewCampusDataService()
 	if err != nil {
 		return nil, err
 	}
+	for _, t := range tenant.Default().Tenants() {
+		if hasOwnDatasets(t) && campusData(t) == nil {
+			return nil, fmt.Errorf("event data of tenant %s can't be loaded", t.ID)
+		}
+	}
 
 	return &UIBController{
-		uibService: uibService,
+		campuses: campuses,
 	}, nil
 }
 
+// campusesFor returns the event datasets of the tenant of ctx.
+func (ctrl *UIBController) campusesFor(ctx context.Context) *services.CampusDataService {
+	return ctrl.campusesOf(tenant.FromContext(ctx))
+}
+
+// campusesOf returns the event datasets of t.
+func (ctrl *UIBController) campusesOf(t *tenant.Tenant) *services.CampusDataService {
+	if hasOwnDatasets(t) {
+		return campusData(t)
+	}
+	return ctrl.campuses
+}
+
+// dataset returns the campus selected by ?campus= (name or alias), the UIB
+// dataset when it is absent, or writes 404 and returns nil.
+func (ctrl *UIBController) dataset(c *gin.Context) *services.UIBEventService {
+	campuses := ctrl.campusesFor(c.Request.Context())
+	name := c.Query("campus")
+	if name == "" {
+		return campuses.Default()
+	}
+	if ds := campuses.Dataset(name); ds != nil {
+		return ds
+	}
+	apierror.RespondDetails(c, http.StatusNotFound, "No event data for campus "+name, gin.H{"campuses": campuses.Campuses()})
+	return nil
+}
+
+// queryDataset picks the campus for a natural-language query: ?campus= when
+// given, else the campus the query names.
+func (ctrl *UIBController) queryDataset(c *gin.Context, query string) *services.UIBEventService {
+	if c.Query("campus") != "" {
+		return ctrl.dataset(c)
+	}
+	_, ds := ctrl.campusesFor(c.Request.Context()).ForQuery(query)
+	return ds
+}
+
+// ListCampuses returns the institutions with loaded event data
+func (ctrl *UIBController) ListCampuses(c *gin.Context) {
+	campuses := ctrl.campusesFor(c.Request.Context())
+	out := make([]gin.H, 0)
+	for _, name := range campuses.Campuses() {
+		ds := campuses.Dataset(name)
+		out = append(out, gin.H{
+			"institution":  name,
+			"short_name":   ds.ShortName(),
+			"total_events": len(ds.GetAllEvents()),
+			"data_source":  ds.Source(),
+			"last_updated": ds.LastUpdated(),
+			"default":      ds == campuses.Default(),
+		})
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    out,
+		"total":   len(out),
+	})
+}
+
 // GetAllEvents returns all UIB events
 func (ctrl *UIBController) GetAllEvents(c *gin.Context) {
-	events := ctrl.uibService.GetAllEvents()
+	uib := ctrl.dataset(c)
+	if uib == nil {
+		return
+	}
+	events := uib.GetAllEvents()
 
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
@@ -40,14 +118,15 @@ func (ctrl *UIBController) GetAllEvents(c *gin.Context) {
 func (ctrl *UIBController) GetEventsByMonth(c *gin.Context) {
 	month := c.Param("month")
 	if month == "" {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"success": false,
-			"message": "Month parameter is required",
-		})
+		apierror.Respond(c, http.StatusBadRequest, "Month parameter is required")
 		return
 	}
 
-	events := ctrl.uibService.GetEventsByMonth(month)
+	uib := ctrl.dataset(c)
+	if uib == nil {
+		return
+	}
+	events := uib.GetEventsByMonth(month)
 
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
@@ -58,27 +137,26 @@ func (ctrl *UIBController) GetEventsByMonth(c *gin.Context) {
 	})
 }
 
-// GetEventsByType returns events by type (certification or webinar)
+// GetEventsByType returns events by type (one of models.EventTypes or an
+// Indonesian alias such as "sertifikasi" or "lomba")
 func (ctrl *UIBController) GetEventsByType(c *gin.Context) {
-	eventType := c.Param("type")
-	if eventType == "" {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"success": false,
-			"message": "Event type parameter is required",
-		})
+	if c.Param("type") == "" {
+		apierror.Respond(c, http.StatusBadRequest, "Event type parameter is required")
 		return
 	}
 
 	// Validate event type
-	if eventType != "certification" && eventType != "webinar" {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"success": false,
-			"message": "Event type must be 'certification' or 'webinar'",
-		})
+	eventType, ok := models.NormalizeEventType(c.Param("type"))
+	if !ok {
+		apierror.Respond(c, http.StatusBadRequest, "Event type must be one of: "+strings.Join(models.EventTypes, ", "))
 		return
 	}
 
-	events := ctrl.uibService.GetEventsByType(eventType)
+	uib := ctrl.dataset(c)
+	if uib == nil {
+		return
+	}
+	events := uib.GetEventsByType(eventType)
 
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
@@ -93,19 +171,17 @@ func (ctrl *UIBController) GetEventsByType(c *gin.Context) {
 func (ctrl *UIBController) GetEventByID(c *gin.Context) {
 	eventID := c.Param("id")
 	if eventID == "" {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"success": false,
-			"message": "Event ID parameter is required",
-		})
+		apierror.Respond(c, http.StatusBadRequest, "Event ID parameter is required")
 		return
 	}
 
-	event, err := ctrl.uibService.GetEventByID(eventID)
+	uib := ctrl.dataset(c)
+	if uib == nil {
+		return
+	}
+	event, err := uib.GetEventByID(eventID)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{
-			"success": false,
-			"message": "Event not found: " + err.Error(),
-		})
+		apierror.Respond(c, http.StatusNotFound, "Event not found: "+err.Error())
 		return
 	}
 
@@ -118,7 +194,11 @@ func (ctrl *UIBController) GetEventByID(c *gin.Context) {
 
 // GetUpcomingEvents returns upcoming events
 func (ctrl *UIBController) GetUpcomingEvents(c *gin.Context) {
-	events := ctrl.uibService.GetUpcomingEvents()
+	uib := ctrl.dataset(c)
+	if uib == nil {
+		return
+	}
+	events := uib.GetUpcomingEvents()
 
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
@@ -131,57 +211,227 @@ func (ctrl *UIBController) GetUpcomingEvents(c *gin.Context) {
 // SearchEvents searches events based on query parameters
 func (ctrl *UIBController) SearchEvents(c *gin.Context) {
 	// Get search parameters
-	eventType := c.Query("type")          // certification, webinar, or empty for all
+	eventType := c.Query("type")          // one of models.EventTypes, or empty for all
 	month := c.Query("month")             // october, november, december, or empty for all
 	department := c.Query("department")   // department filter
+	faculty := c.Query("faculty")         // faculty filter
 	freeOnly := c.Query("free") == "true" // filter for free events only
+	groupBy := c.Query("group_by")        // department, faculty, or empty
+	if !validGroupBy(c, groupBy) {
+		return
+	}
+	minFee, ok := feeBound(c, "min_fee") // rupiah price range
+	if !ok {
+		return
+	}
+	maxFee, ok := feeBound(c, "max_fee")
+	if !ok {
+		return
+	}
+	if maxFee != 0 && minFee > maxFee {
+		apierror.Respond(c, http.StatusBadRequest, "min_fee must not exceed max_fee")
+		return
+	}
+
+	if eventType != "" {
+		t, ok := models.NormalizeEventType(eventType)
+		if !ok {
+			apierror.Respond(c, http.StatusBadRequest, "type must be one of: "+strings.Join(models.EventTypes, ", "))
+			return
+		}
+		eventType = t
+	}
 
 	criteria := models.EventSearchCriteria{
 		EventType:  eventType,
 		Month:      month,
 		Department: department,
+		Faculty:    faculty,
 		FreeOnly:   freeOnly,
+		MinFee:     minFee,
+		MaxFee:     maxFee,
 	}
 
-	events := ctrl.uibService.SearchEvents(criteria)
+	uib := ctrl.dataset(c)
+	if uib == nil {
+		return
+	}
+	events := uib.SearchEvents(criteria)
 
-	c.JSON(http.StatusOK, gin.H{
+	resp := gin.H{
 		"success":  true,
 		"data":     events,
 		"total":    len(events),
 		"criteria": criteria,
 		"message":  "UIB events search completed successfully",
-	})
+	}
+	if groupBy != "" {
+		resp["groups"] = groupEvents(events, func(e models.UIBEvent) string {
+			if groupBy == "faculty" {
+				return services.EventFaculty(e)
+			}
+			return e.Department
+		})
+	}
+	c.JSON(http.StatusOK, resp)
 }
 
-// GetEventSummaries returns summarized view of all events
+// GetEventSummaries returns summarized view of all events, or those of a
+// department or faculty
 func (ctrl *UIBController) GetEventSummaries(c *gin.Context) {
-	summaries := ctrl.uibService.GetEventSummaries()
+	groupBy := c.Query("group_by")
+	if !validGroupBy(c, groupBy) {
+		return
+	}
+	uib := ctrl.dataset(c)
+	if uib == nil {
+		return
+	}
+	summaries := uib.SummarizeEvents(uib.SearchEvents(models.EventSearchCriteria{
+		Department: c.Query("department"),
+		Faculty:    c.Query("faculty"),
+	}))
 
-	c.JSON(http.StatusOK, gin.H{
+	resp := gin.H{
 		"success": true,
 		"data":    summaries,
 		"total":   len(summaries),
 		"message": "UIB event summaries retrieved successfully",
+	}
+	if groupBy != "" {
+		resp["groups"] = groupEvents(summaries, func(s models.EventSummary) string {
+			if groupBy == "faculty" {
+				return s.Faculty
+			}
+			return s.Department
+		})
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// GetEventConflicts returns the pairs of events whose times overlap among
+// ?ids=a,b,c, or among the upcoming events without ids
+func (ctrl *UIBController) GetEventConflicts(c *gin.Context) {
+	uib := ctrl.dataset(c)
+	if uib == nil {
+		return
+	}
+	var events []models.UIBEvent
+	missing := []string{}
+	if ids := splitIDs(c.Query("ids")); len(ids) > 0 {
+		events, missing = uib.EventsByID(ids)
+		if missing == nil {
+			missing = []string{}
+		}
+	} else {
+		events = uib.GetUpcomingEvents()
+	}
+	conflicts := services.FindConflicts(events)
+	if conflicts == nil {
+		conflicts = []services.Conflict{}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    conflicts,
+		"total":   len(conflicts),
+		"checked": len(events),
+		"missing": missing,
+		"message": "UIB event conflicts checked successfully",
 	})
 }
 
+func splitIDs(s string) []string {
+	var ids []string
+	for _, id := range strings.Split(s, ",") {
+		if id = strings.TrimSpace(id); id != "" {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+// GetDepartments returns the departments with event counts, and the
+// faculties they belong to, for faceted browsing
+func (ctrl *UIBController) GetDepartments(c *gin.Context) {
+	uib := ctrl.dataset(c)
+	if uib == nil {
+		return
+	}
+	departments := uib.Departments()
+
+	c.JSON(http.StatusOK, gin.H{
+		"success":   true,
+		"data":      departments,
+		"faculties": uib.Faculties(),
+		"total":     len(departments),
+		"message":   "UIB departments retrieved successfully",
+	})
+}
+
+// feeBound reads a price bound in rupiah from the query, 0 when unset.
+func feeBound(c *gin.Context, name string) (int64, bool) {
+	v := c.Query(name)
+	if v == "" {
+		return 0, true
+	}
+	n, err := strconv.ParseInt(v, 10, 64)
+	if err != nil || n < 0 {
+		apierror.Respond(c, http.StatusBadRequest, name+" must be a non-negative amount in rupiah")
+		return 0, false
+	}
+	return n, true
+}
+
+func validGroupBy(c *gin.Context, groupBy string) bool {
+	if groupBy != "" && groupBy != "department" && groupBy != "faculty" {
+		apierror.Respond(c, http.StatusBadRequest, "group_by must be 'department' or 'faculty'")
+		return false
+	}
+	return true
+}
+
+type eventGroup[T any] struct {
+	Key   string `json:"key"`
+	Total int    `json:"total"`
+	Data  []T    `json:"data"`
+}
+
+// groupEvents groups items by key in order of first appearance. Items
+// without a faculty are grouped under "".
+func groupEvents[T any](items []T, key func(T) string) []eventGroup[T] {
+	index := map[string]int{}
+	var groups []eventGroup[T]
+	for _, item := range items {
+		k := key(item)
+		i, ok := index[k]
+		if !ok {
+			i = len(groups)
+			index[k] = i
+			groups = append(groups, eventGroup[T]{Key: k})
+		}
+		groups[i].Total++
+		groups[i].Data = append(groups[i].Data, item)
+	}
+	return groups
+}
+
 // QueryUIBEvents searches events based on natural language query
 func (ctrl *UIBController) QueryUIBEvents(c *gin.Context) {
 	var request struct {
-		Query string `json:"query" binding:"required"`
+		Query string `json:"query" binding:"notblank,max=500"`
 	}
 
-	if err := c.ShouldBindJSON(&request); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"success": false,
-			"message": "Invalid request: " + err.Error(),
-		})
+	if !apierror.BindJSON(c, &request) {
 		return
 	}
 
 	// Check if query is UIB-related
-	isUIBRelated := ctrl.uibService.AnalyzeQueryForUIB(request.Query)
+	uib := ctrl.queryDataset(c, request.Query)
+	if c.Writer.Written() {
+		return
+	}
+	isUIBRelated := uib != nil && uib.AnalyzeQueryForUIB(request.Query)
 
 	if !isUIBRelated {
 		c.JSON(http.StatusOK, gin.H{
@@ -195,7 +445,7 @@ func (ctrl *UIBController) QueryUIBEvents(c *gin.Context) {
 	}
 
 	// Get relevant events
-	events := ctrl.uibService.GetRelevantEventsForQuery(request.Query)
+	events := uib.GetRelevantEventsForQuery(request.Query)
 
 	c.JSON(http.StatusOK, gin.H{
 		"success":        true,
@@ -216,18 +466,27 @@ func (ctrl *UIBController) GetUIBContext(c *gin.Context) {
 	// Try to bind JSON, but it's optional
 	c.ShouldBindJSON(&request)
 
+	uib := ctrl.queryDataset(c, request.Query)
+	if c.Writer.Written() {
+		return
+	}
+	if uib == nil {
+		apierror.RespondDetails(c, http.StatusNotFound, "No event data for the campus in this query", gin.H{"campuses": ctrl.campusesFor(c.Request.Context()).Campuses()})
+		return
+	}
+
 	var events []models.UIBEvent
 
 	if request.Query != "" {
 		// Get events relevant to query
-		events = ctrl.uibService.GetRelevantEventsForQuery(request.Query)
+		events = uib.GetRelevantEventsForQuery(request.Query)
 	} else {
 		// Get all upcoming events
-		events = ctrl.uibService.GetUpcomingEvents()
+		events = uib.GetUpcomingEvents()
 	}
 
-	// Format for AI context
-	formattedContext := ctrl.uibService.FormatEventsForGemini(events)
+	// Format for AI context, within the same budget as the chat prompts
+	formattedContext := uib.EventContext(request.Query, events)
 
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
@@ -243,28 +502,38 @@ func (ctrl *UIBController) GetUIBContext(c *gin.Context) {
 
 // HealthCheck returns service health status
 func (ctrl *UIBController) HealthCheck(c *gin.Context) {
-	allEvents := ctrl.uibService.GetAllEvents()
+	uib := ctrl.dataset(c)
+	if uib == nil {
+		return
+	}
+	allEvents := uib.GetAllEvents()
+	state, since := services.CurrentServiceState()
 
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
 		"service": "UIB Event Service",
 		"status":  "healthy",
+		"chat":    gin.H{"state": state, "since": since, "message": services.StateMessage(i18n.FromContext(c.Request.Context()), state)},
 		"data": gin.H{
 			"total_events": len(allEvents),
-			"data_source":  "uib_events.json",
-			"last_updated": "2025-10-04",
-			"institution":  "Universitas Internasional Batam (UIB)",
+			"data_source":  uib.Source(),
+			"last_updated": uib.LastUpdated(),
+			"version":      uib.Version(),
+			"hash":         uib.Hash(),
+			"institution":  uib.Institution(),
+			"campuses":     ctrl.campusesFor(c.Request.Context()).Campuses(),
 		},
 		"endpoints": []string{
-			"GET /api/uib/events",
-			"GET /api/uib/events/month/:month",
-			"GET /api/uib/events/type/:type",
-			"GET /api/uib/events/:id",
-			"GET /api/uib/events/upcoming",
-			"GET /api/uib/events/search",
-			"GET /api/uib/events/summaries",
-			"POST /api/uib/query",
-			"POST /api/uib/context",
+			"GET /api/v1/uib/campuses",
+			"GET /api/v1/uib/events",
+			"GET /api/v1/uib/events/month/:month",
+			"GET /api/v1/uib/events/type/:type",
+			"GET /api/v1/uib/events/:id",
+			"GET /api/v1/uib/events/upcoming",
+			"GET /api/v1/uib/events/search",
+			"GET /api/v1/uib/events/summaries",
+			"POST /api/v1/uib/query",
+			"POST /api/v1/uib/context",
 		},
 		"message": "UIB Event Service is running properly",
 	})
@@ -0,0 +1,15 @@
+package controllers
+
+import (
+	"AkuAI/middleware"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// currentUserID is the signed-in user set by middleware.AuthMiddleware, or 0
+// for requests made with an API key.
+func currentUserID(c *gin.Context) uint {
+	uid, _ := strconv.ParseUint(c.GetString(middleware.ContextUserIDKey), 10, 64)
+	return uint(uid)
+}
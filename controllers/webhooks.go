@@ -0,0 +1,276 @@
+package controllers
+
+import (
+	"AkuAI/models"
+	"AkuAI/pkg/apierror"
+	"AkuAI/pkg/audit"
+	"AkuAI/pkg/config"
+	"AkuAI/pkg/webhook"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type webhookBody struct {
+	URL    string   `json:"url" binding:"required,max=500"`
+	Events []string `json:"events" binding:"required,min=1"`
+}
+
+func webhookJSON(w models.Webhook) gin.H {
+	return gin.H{
+		"id":         w.ID,
+		"user_id":    w.UserID,
+		"url":        w.URL,
+		"events":     w.EventList(),
+		"active":     w.Active,
+		"created_at": w.CreatedAt,
+	}
+}
+
+func deliveryJSON(d models.WebhookDelivery) gin.H {
+	return gin.H{
+		"id":              d.ID,
+		"webhook_id":      d.WebhookID,
+		"event":           d.Event,
+		"status":          d.Status,
+		"attempts":        d.Attempts,
+		"response_code":   d.ResponseCode,
+		"error":           d.Error,
+		"next_attempt_at": d.NextAttemptAt,
+		"delivered_at":    d.DeliveredAt,
+		"created_at":      d.CreatedAt,
+	}
+}
+
+// createWebhook registers a webhook for owner (nil for an admin webhook).
+// The secret is only returned in this response.
+func createWebhook(c *gin.Context, db *gorm.DB, owner *uint) {
+	var body webhookBody
+	if !apierror.BindJSON(c, &body) {
+		return
+	}
+	url := strings.TrimSpace(body.URL)
+	if err := webhook.ValidateURL(url, config.WebhookAllowPrivate); err != nil {
+		apierror.RespondDetails(c, http.StatusBadRequest, err.Error(), map[string]any{"field": "url"})
+		return
+	}
+	events := make([]string, 0, len(body.Events))
+	for _, e := range body.Events {
+		e = strings.TrimSpace(e)
+		if !webhook.KnownEvent(e) {
+			apierror.RespondDetails(c, http.StatusBadRequest, "unknown event "+e, map[string]any{"field": "events", "events": webhook.Events()})
+			return
+		}
+		events = append(events, e)
+	}
+	secret, err := webhook.NewSecret()
+	if err != nil {
+		apierror.Respond(c, http.StatusInternalServerError, "failed to generate secret")
+		return
+	}
+	w := models.Webhook{UserID: owner, URL: url, Secret: secret, Events: strings.Join(events, ","), Active: true}
+	if err := db.Create(&w).Error; err != nil {
+		apierror.Respond(c, http.StatusInternalServerError, "db error")
+		return
+	}
+	log.Printf("[webhook] 🪝 webhook %d registered for %s events=%s", w.ID, url, w.Events)
+
+	out := webhookJSON(w)
+	recordAudit(c, db, audit.Entry{Action: audit.ActionWebhookCreate, TargetType: "webhook", TargetID: strconv.Itoa(int(w.ID)), After: out})
+	out["secret"] = secret
+	c.JSON(http.StatusCreated, gin.H{"webhook": out, "msg": "Store the secret now; it is not shown again"})
+}
+
+// deleteWebhook removes a webhook of owner (nil for admin webhooks, which
+// may delete any).
+func deleteWebhook(c *gin.Context, db *gorm.DB, owner *uint) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id <= 0 {
+		apierror.Respond(c, http.StatusBadRequest, "invalid id")
+		return
+	}
+	var w models.Webhook
+	q := db.Where("id = ?", id)
+	if owner != nil {
+		q = q.Where("user_id = ?", *owner)
+	}
+	if err := q.First(&w).Error; err != nil {
+		apierror.Respond(c, http.StatusNotFound, "webhook not found")
+		return
+	}
+	if err := db.Delete(&w).Error; err != nil {
+		apierror.Respond(c, http.StatusInternalServerError, "db error")
+		return
+	}
+	recordAudit(c, db, audit.Entry{Action: audit.ActionWebhookDelete, TargetType: "webhook", TargetID: strconv.Itoa(id), Before: webhookJSON(w)})
+	c.JSON(http.StatusOK, gin.H{"msg": "webhook deleted"})
+}
+
+// ListWebhooks returns the signed-in user's webhooks.
+func ListWebhooks(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var hooks []models.Webhook
+		if err := db.Where("user_id = ?", currentUserID(c)).Order("id DESC").Find(&hooks).Error; err != nil {
+			apierror.Respond(c, http.StatusInternalServerError, "db error")
+			return
+		}
+		out := make([]gin.H, 0, len(hooks))
+		for _, w := range hooks {
+			out = append(out, webhookJSON(w))
+		}
+		c.JSON(http.StatusOK, gin.H{"webhooks": out, "events": webhook.Events()})
+	}
+}
+
+// CreateWebhook registers a webhook for the signed-in user: {"url",
+// "events"}. It receives the events about that user.
+func CreateWebhook(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		uid := currentUserID(c)
+		createWebhook(c, db, &uid)
+	}
+}
+
+// DeleteWebhook removes one of the signed-in user's webhooks.
+func DeleteWebhook(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		uid := currentUserID(c)
+		deleteWebhook(c, db, &uid)
+	}
+}
+
+// ListWebhookDeliveries returns the delivery log of one of the signed-in
+// user's webhooks, newest first.
+func ListWebhookDeliveries(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil || id <= 0 {
+			apierror.Respond(c, http.StatusBadRequest, "invalid id")
+			return
+		}
+		var w models.Webhook
+		if err := db.Where("id = ? AND user_id = ?", id, currentUserID(c)).First(&w).Error; err != nil {
+			apierror.Respond(c, http.StatusNotFound, "webhook not found")
+			return
+		}
+		listDeliveries(c, db, uint(id))
+	}
+}
+
+// listDeliveries writes deliveries, newest first, of webhookID (0 for all).
+// Filters: ?status=, ?event=; ?limit= (default 50, at most 200) and
+// ?before=<next_before> page through.
+func listDeliveries(c *gin.Context, db *gorm.DB, webhookID uint) {
+	q := db.Model(&models.WebhookDelivery{})
+	if webhookID != 0 {
+		q = q.Where("webhook_id = ?", webhookID)
+	}
+	if v := c.Query("status"); v != "" {
+		q = q.Where("status = ?", v)
+	}
+	if v := c.Query("event"); v != "" {
+		q = q.Where("event = ?", v)
+	}
+	if v := c.Query("before"); v != "" {
+		n, err := strconv.ParseUint(v, 10, 64)
+		if err != nil {
+			apierror.Respond(c, http.StatusBadRequest, "before must be a positive integer")
+			return
+		}
+		q = q.Where("id < ?", n)
+	}
+	limit := 50
+	if v := c.Query("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > 200 {
+			apierror.Respond(c, http.StatusBadRequest, "limit must be between 1 and 200")
+			return
+		}
+		limit = n
+	}
+	var rows []models.WebhookDelivery
+	if err := q.Order("id DESC").Limit(limit).Find(&rows).Error; err != nil {
+		apierror.Respond(c, http.StatusInternalServerError, "db error")
+		return
+	}
+	out := make([]gin.H, 0, len(rows))
+	for _, d := range rows {
+		out = append(out, deliveryJSON(d))
+	}
+	resp := gin.H{"deliveries": out}
+	if len(rows) == limit {
+		resp["next_before"] = rows[len(rows)-1].ID
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// AdminListWebhooks returns every webhook, users' and admin ones.
+func AdminListWebhooks(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var hooks []models.Webhook
+		if err := db.Order("id DESC").Find(&hooks).Error; err != nil {
+			apierror.Respond(c, http.StatusInternalServerError, "db error")
+			return
+		}
+		out := make([]gin.H, 0, len(hooks))
+		for _, w := range hooks {
+			out = append(out, webhookJSON(w))
+		}
+		c.JSON(http.StatusOK, gin.H{"webhooks": out, "events": webhook.Events()})
+	}
+}
+
+// AdminCreateWebhook registers an admin webhook, which receives every
+// event it subscribes to.
+func AdminCreateWebhook(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) { createWebhook(c, db, nil) }
+}
+
+// AdminDeleteWebhook removes any webhook.
+func AdminDeleteWebhook(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) { deleteWebhook(c, db, nil) }
+}
+
+// AdminListWebhookDeliveries returns the delivery log of every webhook, or
+// of ?webhook_id=.
+func AdminListWebhookDeliveries(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var id uint64
+		if v := c.Query("webhook_id"); v != "" {
+			n, err := strconv.ParseUint(v, 10, 64)
+			if err != nil {
+				apierror.Respond(c, http.StatusBadRequest, "webhook_id must be a positive integer")
+				return
+			}
+			id = n
+		}
+		listDeliveries(c, db, uint(id))
+	}
+}
+
+// RedeliverWebhook queues a delivery to be sent again.
+func RedeliverWebhook(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil || id <= 0 {
+			apierror.Respond(c, http.StatusBadRequest, "invalid id")
+			return
+		}
+		d, err := webhook.Redeliver(db, uint(id))
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			apierror.Respond(c, http.StatusNotFound, "delivery not found")
+			return
+		}
+		if err != nil {
+			apierror.Respond(c, http.StatusInternalServerError, "db error")
+			return
+		}
+		recordAudit(c, db, audit.Entry{Action: audit.ActionWebhookRedeliver, TargetType: "webhook_delivery", TargetID: strconv.Itoa(id), After: gin.H{"delivery_id": d.ID}})
+		c.JSON(http.StatusAccepted, gin.H{"delivery": deliveryJSON(d)})
+	}
+}
@@ -3,22 +3,27 @@ package controllers
 import (
 	"AkuAI/middleware"
 	"AkuAI/models"
+	"AkuAI/pkg/apierror"
 	"AkuAI/pkg/cache"
 	"AkuAI/pkg/config"
+	"AkuAI/pkg/i18n"
+	"AkuAI/pkg/moderation"
 	svc "AkuAI/pkg/services"
+	"AkuAI/pkg/tenant"
 	tokenstore "AkuAI/pkg/token"
-	utils "AkuAI/pkg/utills"
+	"AkuAI/pkg/wshub"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
+	"net"
 	"net/http"
 	"strconv"
 	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
-	"github.com/golang-jwt/jwt/v5"
 	"github.com/gorilla/websocket"
 	"gorm.io/gorm"
 )
@@ -36,46 +41,81 @@ type wsStartPayload struct {
 	Message        string `json:"message"`
 	ConversationID *uint  `json:"conversation_id"`
 	RequestImages  bool   `json:"request_images,omitempty"`
+	Verbosity      string `json:"verbosity,omitempty"`
+	Incognito      bool   `json:"incognito,omitempty"`
+}
+
+// wsUserID authenticates a WebSocket handshake from the ?token= query and
+// writes a 401 response when it fails.
+func wsUserID(c *gin.Context) (string, bool) {
+	tokenStr := strings.TrimSpace(c.Query("token"))
+	if tokenStr == "" {
+		apierror.Respond(c, http.StatusUnauthorized, "missing token query")
+		return "", false
+	}
+
+	claims, err := tokenstore.Keys().Verify(tokenStr)
+	if errors.Is(err, tokenstore.ErrRevoked) {
+		apierror.Respond(c, http.StatusUnauthorized, "Token has been revoked (logout)")
+		return "", false
+	}
+	if err != nil {
+		apierror.Respond(c, http.StatusUnauthorized, "invalid token")
+		return "", false
+	}
+	if claims.TenantID != tenant.ID(c.Request.Context()) {
+		apierror.Respond(c, http.StatusUnauthorized, "token belongs to another tenant")
+		return "", false
+	}
+	return claims.UserID, true
+}
+
+// registerWS adds the connection to the hub before the upgrade and writes a
+// 429 response when the user already holds too many or reconnects too fast.
+func registerWS(c *gin.Context, userID, kind string, listen bool) (*wshub.Conn, bool) {
+	hc, err := wshub.Default().Register(userID, kind, listen)
+	if err == nil {
+		return hc, true
+	}
+	log.Printf("[ws] ⛔ user %s: %v", userID, err)
+	var backoff *wshub.BackoffError
+	if errors.As(err, &backoff) {
+		retryMs := backoff.RetryAfter.Milliseconds()
+		c.Header("Retry-After", strconv.FormatInt((retryMs+999)/1000, 10))
+		apierror.RespondDetails(c, http.StatusTooManyRequests, err.Error(), gin.H{"retry_after_ms": retryMs})
+		return nil, false
+	}
+	apierror.RespondDetails(c, http.StatusTooManyRequests, err.Error(), gin.H{"max_connections": config.WSMaxConnsPerUser})
+	return nil, false
+}
+
+// allowWSMessage counts a client message against the user's message rate.
+// Over the limit it closes the socket with a policy-violation close frame;
+// WriteControl is safe to call while another goroutine streams the reply.
+func allowWSMessage(conn *websocket.Conn, userID string) bool {
+	retry, ok := wshub.Default().AllowMessage(userID)
+	if ok {
+		return true
+	}
+	log.Printf("[ws] ⛔ user %s over the message rate, closing", userID)
+	reason := fmt.Sprintf("too many messages, retry in %ds", int(retry.Seconds()+0.999))
+	_ = conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.ClosePolicyViolation, reason), time.Now().Add(time.Second))
+	return false
 }
 
 func ChatWS(db *gorm.DB) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		tokenStr := strings.TrimSpace(c.Query("token"))
-		if tokenStr == "" {
-			c.JSON(http.StatusUnauthorized, gin.H{"msg": "missing token query"})
-			return
-		}
-
-		token, err := jwt.Parse(tokenStr, func(t *jwt.Token) (interface{}, error) {
-			if _, ok := t.Method.(*jwt.SigningMethodHMAC); !ok {
-				return nil, jwt.ErrTokenUnverifiable
-			}
-			return []byte(config.JWTSecret), nil
-		})
-		if err != nil || !token.Valid {
-			c.JSON(http.StatusUnauthorized, gin.H{"msg": "invalid token"})
-			return
-		}
-		claims, ok := token.Claims.(jwt.MapClaims)
+		userIDStr, ok := wsUserID(c)
 		if !ok {
-			c.JSON(http.StatusUnauthorized, gin.H{"msg": "invalid token claims"})
-			return
-		}
-		jtiVal, _ := claims["jti"].(string)
-		if tokenstore.IsRevoked(jtiVal) {
-			c.JSON(http.StatusUnauthorized, gin.H{"msg": "Token has been revoked (logout)"})
 			return
 		}
-		var userIDStr string
-		if sub, ok := claims["sub"].(string); ok {
-			userIDStr = sub
-		} else if subf, ok := claims["sub"].(float64); ok {
-			userIDStr = strconv.Itoa(int(subf))
-		}
-		if userIDStr == "" {
-			c.JSON(http.StatusUnauthorized, gin.H{"msg": "invalid subject in token"})
+		wsUID, _ := strconv.Atoi(userIDStr)
+		middleware.ApplyPreferences(c, middleware.UserPreferences(db, uint(wsUID)))
+		hc, ok := registerWS(c, userIDStr, "chat", false)
+		if !ok {
 			return
 		}
+		defer wshub.Default().Unregister(hc)
 
 		conn, err := upgrader.Upgrade(c.Writer, c.Request, nil)
 		if err != nil {
@@ -95,11 +135,26 @@ func ChatWS(db *gorm.DB) gin.HandlerFunc {
 			log.Printf("[ws] read message error: %v", err)
 			return
 		}
+		if !allowWSMessage(conn, userIDStr) {
+			return
+		}
 		var start wsStartPayload
 		if err := json.Unmarshal(msgBytes, &start); err != nil || strings.ToLower(start.Type) != "start" || strings.TrimSpace(start.Message) == "" {
 			_ = conn.WriteJSON(gin.H{"type": "error", "error": "invalid start payload"})
 			return
 		}
+		verbosity, ok := models.ParseVerbosity(start.Verbosity)
+		if !ok {
+			_ = conn.WriteJSON(gin.H{"type": "error", "error": "verbosity must be short, normal or detailed"})
+			return
+		}
+		c.Request = c.Request.WithContext(svc.WithVerbosity(c.Request.Context(), verbosity))
+
+		incognito := middleware.IncognitoChat(db, userIDStr, start.ConversationID, start.Incognito)
+		if v := middleware.ModerateMessage(c.Request.Context(), db, userIDStr, c.FullPath(), start.Message, incognito); v.Action == moderation.Block {
+			_ = conn.WriteJSON(gin.H{"type": "refusal", "category": v.Category, "message": moderation.Refusal(v)})
+			return
+		}
 
 		uid64, _ := strconv.ParseUint(userIDStr, 10, 64)
 		uid := uint(uid64)
@@ -110,28 +165,42 @@ func ChatWS(db *gorm.DB) gin.HandlerFunc {
 				_ = conn.WriteJSON(gin.H{"type": "error", "error": "conversation not found"})
 				return
 			}
+			unarchiveOnActivity(db, &conv)
 		} else {
 			title := start.Message
 			if len(title) > 30 {
 				title = title[:30] + "..."
 			}
-			conv = models.Conversation{UserID: uid, Title: title}
+			conv = models.Conversation{UserID: uid, TenantID: tenant.ID(c.Request.Context()), Title: title, Incognito: start.Incognito}
 			if err := db.Create(&conv).Error; err != nil {
 				_ = conn.WriteJSON(gin.H{"type": "error", "error": "failed to create conversation"})
 				return
 			}
 		}
+		c.Request = c.Request.WithContext(svc.WithIncognito(c.Request.Context(), conv.Incognito))
 
-		release := middleware.AcquireUserSlot(userIDStr)
+		release, err := middleware.TryAcquireUserSlot(c.Request.Context(), userIDStr)
+		if err != nil {
+			position := 0
+			var busy *middleware.SlotBusyError
+			if errors.As(err, &busy) {
+				position = busy.Position
+			}
+			_ = conn.WriteJSON(gin.H{"type": "error", "error": "too many concurrent requests", "queue_position": position})
+			return
+		}
 		defer release()
 
-		msgUser := models.Message{ConversationID: conv.ID, Sender: "user", Text: start.Message, Timestamp: time.Now()}
+		msgUser := models.Message{ConversationID: conv.ID, Sender: "user", Text: start.Message, Timestamp: time.Now(), Label: queryLabel(c.Request.Context(), start.Message)}
 		if err := db.Create(&msgUser).Error; err != nil {
 			_ = conn.WriteJSON(gin.H{"type": "error", "error": "failed to save message"})
 			return
 		}
 
 		_ = conn.WriteJSON(gin.H{"type": "user_saved", "conversation_id": conv.ID})
+		for _, a := range userAnnouncements(db, uid) {
+			_ = conn.WriteJSON(gin.H{"type": "announcement", "announcement": a})
+		}
 
 		var history []svc.ChatMessage
 		if len(conv.Messages) > 0 {
@@ -152,47 +221,58 @@ func ChatWS(db *gorm.DB) gin.HandlerFunc {
 		}
 		history = append(history, svc.ChatMessage{Role: "user", Text: start.Message})
 
-		gsvc := svc.NewGeminiService()
+		gsvc := svc.NewGeminiServiceFor(c.Request.Context())
 		var full strings.Builder
 
-		writeDelta := func(chunk string) {
-			_ = conn.WriteJSON(gin.H{"type": "delta", "data": chunk})
-		}
+		post := replyPipeline(c.Request.Context()).Stream(func(s string) {
+			_ = conn.WriteJSON(gin.H{"type": "delta", "data": s})
+		})
+		writeDelta := post.Write
 
 		parentCtx, cancelTimeout := context.WithTimeout(c.Request.Context(), 75*time.Second)
 		ctx, cancel := context.WithCancel(parentCtx)
+		ctx, info := svc.WithGenerationInfo(svc.WithUserMemory(ctx, personalSection(db, conv, msgUser)))
 		defer func() {
 			cancel()
 			cancelTimeout()
 		}()
 
 		stopCh := make(chan struct{})
-		readErrCh := make(chan error, 1)
+		stop := func() {
+			select {
+			case <-stopCh:
+			default:
+				close(stopCh)
+			}
+		}
 		go func() {
-			defer close(readErrCh)
 			for {
 				if err := conn.SetReadDeadline(time.Now().Add(60 * time.Second)); err != nil {
-					readErrCh <- err
 					return
 				}
 				mt, msg, err := conn.ReadMessage()
 				if err != nil {
-					readErrCh <- err
+					// A dropped connection ends the reply like "stop" does, so the
+					// partial text is saved and can be continued later.
+					var ne net.Error
+					if !errors.As(err, &ne) || !ne.Timeout() {
+						stop()
+					}
 					return
 				}
 				if mt != websocket.TextMessage && mt != websocket.BinaryMessage {
 					continue
 				}
+				if !allowWSMessage(conn, userIDStr) {
+					stop()
+					return
+				}
 				var obj struct {
 					Type string `json:"type"`
 				}
 				_ = json.Unmarshal(msg, &obj)
 				if strings.ToLower(strings.TrimSpace(obj.Type)) == "stop" {
-					select {
-					case <-stopCh:
-					default:
-						close(stopCh)
-					}
+					stop()
 					return
 				}
 			}
@@ -208,43 +288,26 @@ func ChatWS(db *gorm.DB) gin.HandlerFunc {
 		}
 
 		uibQuery := isUIBEventQuery(start.Message)
-		ck := cache.KeyFromStrings("chat-final", userIDStr, strings.ToLower(strings.TrimSpace(start.Message)))
+		scope := chatCacheScope(ctx, start.Message, history)
+		ck := chatCacheKey(ctx, "chat-final", scope, userIDStr, strings.ToLower(strings.TrimSpace(start.Message)))
+		overridden := skipReplyCaches(ctx)
 		if uibQuery {
 			cache.Default().InvalidateChatResponse(ck)
-		} else if cachedText, ok, cacheInfo := cache.Default().GetChatResponseWithInfo(ck); ok {
-			log.Printf("[ws] 🟢 SERVING FROM CACHE - User: %s, Message: %.50s..., Cache Age: %v",
-				userIDStr, start.Message, time.Since(cacheInfo.CachedAt).Round(time.Second))
-
-			normalizedCached := utils.NormalizeWhitespace(cachedText)
-			runes := []rune(normalizedCached)
-			chunk := 32
-			for i := 0; i < len(runes); i += chunk {
-				if isStopped() {
-					break
-				}
-				end := i + chunk
-				if end > len(runes) {
-					end = len(runes)
-				}
-
-				if end < len(runes) && end > i {
-					for j := end; j >= i+chunk-5 && j < len(runes); j-- {
-						if runes[j] == ' ' || runes[j] == '\n' || runes[j] == '.' || runes[j] == ',' || runes[j] == ':' {
-							end = j + 1
-							break
-						}
-					}
-				}
-
-				chunkText := string(runes[i:end])
-				full.WriteString(chunkText)
-				writeDelta(chunkText)
-				time.Sleep(12 * time.Millisecond)
-			}
+		} else if overridden {
+			log.Printf("[ws] generation override or incognito, skipping cache - User: %s", userIDStr)
+		} else if cachedText, cacheInfo, ok := chatCacheLookup(ck, scope); ok {
+			log.Printf("[ws] 🟢 SERVING FROM CACHE (%s) - User: %s, Message: %.50s..., Cache Age: %v",
+				scope, userIDStr, start.Message, time.Since(cacheInfo.CachedAt).Round(time.Second))
+			svc.MarkCached(ctx)
+
+			replayText(cachedText, func(s string) {
+				full.WriteString(s)
+				writeDelta(s)
+			}, isStopped)
 		}
 
 		if full.Len() == 0 && !isStopped() {
-			log.Printf("[ws] 🔵 GENERATING NEW RESPONSE - User: %s, Message: %.50s...", userIDStr, start.Message)
+			log.Printf("[ws] 🔵 GENERATING NEW RESPONSE - User: %s, Message: %.50s...", userIDStr, svc.Redact(ctx, start.Message))
 
 			if _, err := gsvc.StreamCampusWithChat(ctx, history, func(s string) {
 				if isStopped() {
@@ -256,21 +319,9 @@ func ChatWS(db *gorm.DB) gin.HandlerFunc {
 				log.Printf("[ws] stream failed: %v", err)
 				if resp, err2 := gsvc.AskCampusWithChat(ctx, history); err2 == nil && strings.TrimSpace(resp) != "" && !isStopped() {
 					full.WriteString(resp)
-					runes := []rune(resp)
-					chunk := 28
-					for i := 0; i < len(runes); i += chunk {
-						if isStopped() {
-							break
-						}
-						end := i + chunk
-						if end > len(runes) {
-							end = len(runes)
-						}
-						chunkText := string(runes[i:end])
-						writeDelta(chunkText)
-						time.Sleep(15 * time.Millisecond)
-					}
+					replayText(resp, writeDelta, isStopped)
 				} else if !isStopped() {
+					svc.MarkLocal(ctx)
 					svc.StreamCampusWithChatLocal(ctx, history, func(s string) {
 						if isStopped() {
 							return
@@ -286,7 +337,8 @@ func ChatWS(db *gorm.DB) gin.HandlerFunc {
 			cancel()
 		}
 
-		botText := utils.NormalizeWhitespace(full.String())
+		post.Flush()
+		botText := strings.TrimSpace(full.String())
 
 		if isStopped() {
 			cache.Default().InvalidateChatResponse(ck)
@@ -295,109 +347,49 @@ func ChatWS(db *gorm.DB) gin.HandlerFunc {
 				_ = conn.WriteJSON(gin.H{"type": "done", "ok": true, "stopped": true})
 				return
 			}
-			_ = db.Create(&models.Message{ConversationID: conv.ID, Sender: "bot", Text: botText, Timestamp: time.Now()}).Error
-			_ = conn.WriteJSON(gin.H{"type": "done", "ok": true, "stopped": true})
+			msgBot, _ := saveBotMessageWithStatus(ctx, db, conv.ID, start.Message, botText, "", models.MessageStopped, info)
+			_ = conn.WriteJSON(gin.H{"type": "done", "ok": true, "stopped": true, "message_id": msgBot.ID, "status": models.MessageStopped})
 			return
 		}
 
+		status := models.MessageCompleted
+		if parentCtx.Err() != nil {
+			status = models.MessageError
+		}
 		if botText == "" {
-			botText = "Maaf, belum ada jawaban."
-			_ = db.Create(&models.Message{ConversationID: conv.ID, Sender: "bot", Text: botText, Timestamp: time.Now()}).Error
+			botText = i18n.T(i18n.FromContext(ctx), i18n.NoAnswer)
+			_ = db.Create(&models.Message{ConversationID: conv.ID, Sender: "bot", Text: botText, Timestamp: time.Now(), Status: models.MessageError}).Error
+			status = models.MessageError
 		} else {
-			_ = db.Create(&models.Message{ConversationID: conv.ID, Sender: "bot", Text: botText, Timestamp: time.Now()}).Error
-			cache.Default().SetChatResponse(ck, botText, cache.StatusCompleted, time.Duration(config.ChatCacheTTLSeconds)*time.Second)
-		}
-
-		// Handle image search if requested
-		if start.RequestImages {
-			detectedUniversity := ""
-			if du, err := gsvc.DetectUniversityName(ctx, history, botText); err != nil {
-				log.Printf("[ws] ⚠️ failed to detect university: %v", err)
-			} else {
-				detectedUniversity = strings.TrimSpace(du)
-			}
-
-			primaryQuery := detectedUniversity
-			if primaryQuery == "" {
-				primaryQuery = "kampus"
+			msgBot, err := saveBotMessageWithStatus(ctx, db, conv.ID, start.Message, botText, "", status, info)
+			if status == models.MessageCompleted && !overridden {
+				cache.Default().SetChatResponse(ck, botText, cache.StatusCompleted, time.Duration(config.ChatCacheTTLSeconds)*time.Second,
+					svc.EventCacheTags(start.Message, botText)...)
 			}
-
-			messageText := "Mencari gambar kampus..."
-			if primaryQuery != "kampus" {
-				messageText = fmt.Sprintf("Mencari gambar %s...", primaryQuery)
+			if err == nil {
+				conf := confidenceJSON(msgBot)
+				conf["type"] = "confidence"
+				_ = conn.WriteJSON(conf)
+				if len(msgBot.Citations) > 0 {
+					_ = conn.WriteJSON(gin.H{"type": "citations", "message_id": msgBot.ID, "citations": citationsJSON(msgBot.Citations)})
+				}
 			}
+		}
 
-			_ = conn.WriteJSON(gin.H{
-				"type":                "images_searching",
-				"message":             messageText,
-				"query":               primaryQuery,
-				"detected_university": detectedUniversity,
+		// Image search: requested explicitly or detected from the message
+		if intent, trigger, ok := chatImageIntent(ctx, gsvc, start.Message, start.RequestImages); ok {
+			streamChatImages(ctx, context.Background(), gsvc, history, botText, intent, trigger, "ws", func(event string, payload gin.H) {
+				payload["type"] = event
+				_ = conn.WriteJSON(payload)
 			})
-
-			imageService := svc.NewGoogleImageService()
-			if imageService.IsEnabled() {
-				searchCtx, searchCancel := context.WithTimeout(context.Background(), 30*time.Second)
-				defer searchCancel()
-
-				activeQuery := primaryQuery
-				fallbackUsed := false
-
-				images, err := imageService.SearchImagesForChat(searchCtx, primaryQuery)
-				if (err != nil || len(images) == 0) && primaryQuery != "kampus" {
-					fallbackUsed = true
-					log.Printf("[ws] ℹ️ primary image query '%s' returned err=%v, attempting fallback 'kampus'", primaryQuery, err)
-					if fallbackImages, fallbackErr := imageService.SearchImagesForChat(searchCtx, "kampus"); fallbackErr == nil && len(fallbackImages) > 0 {
-						images = fallbackImages
-						err = nil
-						activeQuery = "kampus"
-					} else {
-						if fallbackErr != nil {
-							err = fallbackErr
-						}
-						images = fallbackImages
-						activeQuery = "kampus"
-					}
-				}
-
-				if err != nil {
-					_ = conn.WriteJSON(gin.H{
-						"type":                "images_error",
-						"error":               fmt.Sprintf("Gagal mencari gambar untuk '%s': %v", activeQuery, err),
-						"query":               activeQuery,
-						"primary_query":       primaryQuery,
-						"detected_university": detectedUniversity,
-						"fallback":            fallbackUsed,
-					})
-				} else if len(images) > 0 {
-					_ = conn.WriteJSON(gin.H{
-						"type":                "images_found",
-						"images":              images,
-						"count":               len(images),
-						"query":               activeQuery,
-						"primary_query":       primaryQuery,
-						"detected_university": detectedUniversity,
-						"fallback":            fallbackUsed,
-					})
-				} else {
-					_ = conn.WriteJSON(gin.H{
-						"type":                "images_empty",
-						"message":             fmt.Sprintf("Tidak ada gambar ditemukan untuk '%s'", activeQuery),
-						"query":               activeQuery,
-						"primary_query":       primaryQuery,
-						"detected_university": detectedUniversity,
-						"fallback":            fallbackUsed,
-					})
-				}
-			} else {
-				_ = conn.WriteJSON(gin.H{
-					"type":    "images_disabled",
-					"message": "Fitur pencarian gambar belum dikonfigurasi",
-					"query":   primaryQuery,
-				})
-			}
 		}
 
-		_ = conn.WriteJSON(gin.H{"type": "done", "ok": true})
+		state, change := observeServiceState(ctx, info)
+		if change != nil {
+			_ = conn.WriteJSON(change)
+		}
+		_ = conn.WriteJSON(gin.H{"type": "done", "ok": true, "status": status, "parts": info.Parts(),
+			"model": info.Model(), "attempts": info.Attempts(), "service_state": state})
 	}
 }
 
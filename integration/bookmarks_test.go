@@ -0,0 +1,97 @@
+package integration
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestBookmarksAndReactions(t *testing.T) {
+	srv, db := newServer(t)
+	c := &client{t: t, base: srv.URL}
+	name := fmt.Sprintf("marks%d", time.Now().UnixNano())
+	c.mustJSON("POST", "/register", gin.H{"email": name + "@example.com", "username": name, "password": "Secret123!", "confirm_password": "Secret123!"}, http.StatusCreated, nil)
+	var login struct {
+		AccessToken string `json:"access_token"`
+	}
+	c.mustJSON("POST", "/login", gin.H{"email": name + "@example.com", "password": "Secret123!"}, http.StatusOK, &login)
+	c.token = login.AccessToken
+	db.Exec("UPDATE users SET is_admin = ? WHERE username = ?", true, name)
+
+	var conv conversationResp
+	c.mustJSON("POST", "/conversations", gin.H{"message": "Apa saja webinar UIB bulan November?"}, http.StatusCreated, &conv)
+	var full struct {
+		Messages []struct {
+			ID         uint     `json:"id"`
+			Sender     string   `json:"sender"`
+			Bookmarked bool     `json:"bookmarked"`
+			Reactions  []string `json:"reactions"`
+		} `json:"messages"`
+	}
+	c.mustJSON("GET", fmt.Sprintf("/conversations/%d", conv.ConversationID), nil, http.StatusOK, &full)
+	user, bot := full.Messages[0].ID, full.Messages[1].ID
+	msgPath := func(id uint) string { return fmt.Sprintf("/conversations/%d/messages/%d", conv.ConversationID, id) }
+
+	c.mustJSON("PUT", msgPath(user)+"/bookmark", nil, http.StatusNotFound, nil)
+	c.mustJSON("PUT", msgPath(bot)+"/bookmark", nil, http.StatusOK, nil)
+	c.mustJSON("PUT", msgPath(bot)+"/bookmark", gin.H{"note": "jadwal penting"}, http.StatusOK, nil)
+	c.mustJSON("POST", msgPath(bot)+"/reactions", gin.H{"emoji": "hello"}, http.StatusBadRequest, nil)
+	var reacted struct {
+		Reactions []string `json:"reactions"`
+	}
+	for _, e := range []string{"👍", "🎉", "👍"} {
+		c.mustJSON("POST", msgPath(bot)+"/reactions", gin.H{"emoji": e}, http.StatusOK, &reacted)
+	}
+	if len(reacted.Reactions) != 2 {
+		t.Fatalf("reactions = %v", reacted.Reactions)
+	}
+	c.mustJSON("DELETE", msgPath(bot)+"/reactions/"+url.PathEscape("🎉"), nil, http.StatusOK, &reacted)
+	if len(reacted.Reactions) != 1 || reacted.Reactions[0] != "👍" {
+		t.Fatalf("reactions after delete = %v", reacted.Reactions)
+	}
+	c.mustJSON("GET", fmt.Sprintf("/conversations/%d", conv.ConversationID), nil, http.StatusOK, &full)
+	if m := full.Messages[1]; !m.Bookmarked || len(m.Reactions) != 1 {
+		t.Fatalf("marked message = %+v", m)
+	}
+
+	var page struct {
+		Bookmarks []struct {
+			Note           string `json:"note"`
+			ConversationID uint   `json:"conversation_id"`
+			Message        struct {
+				ID uint `json:"id"`
+			} `json:"message"`
+		} `json:"bookmarks"`
+	}
+	c.mustJSON("GET", "/bookmarks?q=PENTING", nil, http.StatusOK, &page)
+	if len(page.Bookmarks) != 1 || page.Bookmarks[0].Message.ID != bot || page.Bookmarks[0].ConversationID != conv.ConversationID {
+		t.Fatalf("bookmarks = %+v", page)
+	}
+	c.mustJSON("GET", "/bookmarks?q=tidak-ada", nil, http.StatusOK, &page)
+	if len(page.Bookmarks) != 0 {
+		t.Fatalf("search matched %+v", page)
+	}
+
+	var report struct {
+		Bookmarks  int64 `json:"bookmarks"`
+		TopAnswers []struct {
+			MessageID uint  `json:"message_id"`
+			Bookmarks int64 `json:"bookmarks"`
+			Reactions int64 `json:"reactions"`
+		} `json:"top_answers"`
+	}
+	c.mustJSON("GET", "/analytics/global", nil, http.StatusOK, &report)
+	if report.Bookmarks < 1 || len(report.TopAnswers) == 0 || report.TopAnswers[0].Reactions < 1 {
+		t.Fatalf("analytics = %+v", report)
+	}
+
+	c.mustJSON("DELETE", msgPath(bot)+"/bookmark", nil, http.StatusOK, nil)
+	c.mustJSON("GET", "/bookmarks", nil, http.StatusOK, &page)
+	if len(page.Bookmarks) != 0 {
+		t.Fatalf("bookmarks after delete = %+v", page)
+	}
+}
@@ -0,0 +1,105 @@
+package integration
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"AkuAI/pkg/config"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestChatCacheKeyPolicy(t *testing.T) {
+	srv, _ := newServer(t)
+	signIn := func(name string) *client {
+		c := &client{t: t, base: srv.URL}
+		c.mustJSON("POST", "/register", gin.H{"email": name + "@example.com", "username": name, "password": "Secret123!", "confirm_password": "Secret123!"}, http.StatusCreated, nil)
+		var login struct {
+			AccessToken string `json:"access_token"`
+		}
+		c.mustJSON("POST", "/login", gin.H{"email": name + "@example.com", "password": "Secret123!"}, http.StatusOK, &login)
+		c.token = login.AccessToken
+		return c
+	}
+	suffix := time.Now().UnixNano()
+	first := signIn(fmt.Sprintf("policya%d", suffix))
+	second := signIn(fmt.Sprintf("policyb%d", suffix))
+
+	ask := func(c *client, message string) bool {
+		var conv struct {
+			Messages []struct {
+				Generation *struct {
+					Cached bool `json:"cached"`
+				} `json:"generation"`
+			} `json:"messages"`
+		}
+		c.mustJSON("POST", "/conversations", gin.H{"message": message, "mode": "engineered"}, http.StatusCreated, &conv)
+		if len(conv.Messages) != 2 || conv.Messages[1].Generation == nil {
+			t.Fatalf("chat %q: %+v", message, conv)
+		}
+		return conv.Messages[1].Generation.Cached
+	}
+
+	factual := fmt.Sprintf("Ada lomba apa di bulan Desember %d?", suffix%1000)
+	if ask(first, factual) {
+		t.Fatal("first factual question served from cache")
+	}
+	if !ask(second, factual) {
+		t.Error("factual question of another user not served from the shared cache")
+	}
+
+	personal := fmt.Sprintf("Lomba saya di bulan Desember %d?", suffix%1000)
+	ask(first, personal)
+	if ask(second, personal) {
+		t.Error("personal question served from another user's cache")
+	}
+}
+
+func TestSemanticCacheFacets(t *testing.T) {
+	srv, _ := newServer(t)
+	config.SemanticCacheEnabled = true
+	t.Cleanup(func() { config.SemanticCacheEnabled = false })
+	signIn := func(name string) *client {
+		c := &client{t: t, base: srv.URL}
+		c.mustJSON("POST", "/register", gin.H{"email": name + "@example.com", "username": name, "password": "Secret123!", "confirm_password": "Secret123!"}, http.StatusCreated, nil)
+		var login struct {
+			AccessToken string `json:"access_token"`
+		}
+		c.mustJSON("POST", "/login", gin.H{"email": name + "@example.com", "password": "Secret123!"}, http.StatusOK, &login)
+		c.token = login.AccessToken
+		return c
+	}
+	suffix := time.Now().UnixNano()
+	first := signIn(fmt.Sprintf("facetsa%d", suffix))
+	second := signIn(fmt.Sprintf("facetsb%d", suffix))
+
+	ask := func(c *client, message string) bool {
+		var conv struct {
+			Messages []struct {
+				Generation *struct {
+					Cached bool `json:"cached"`
+				} `json:"generation"`
+			} `json:"messages"`
+		}
+		c.mustJSON("POST", "/conversations", gin.H{"message": message, "mode": "engineered"}, http.StatusCreated, &conv)
+		if len(conv.Messages) != 2 || conv.Messages[1].Generation == nil {
+			t.Fatalf("chat %q: %+v", message, conv)
+		}
+		return conv.Messages[1].Generation.Cached
+	}
+
+	// The two questions embed about 0.91 apart, above the 0.85 threshold.
+	const question = "Tolong sebutkan semua webinar dan sertifikasi yang ada di kampus UIB untuk bulan %s kode %d"
+	code := suffix % 1000
+	if ask(first, fmt.Sprintf(question, "November", code)) {
+		t.Fatal("first question served from cache")
+	}
+	if !ask(second, fmt.Sprintf(question+" ya", "November", code)) {
+		t.Error("rephrased question not served from the semantic cache")
+	}
+	if ask(second, fmt.Sprintf(question, "Desember", code)) {
+		t.Error("December question served the cached November answer")
+	}
+}
@@ -0,0 +1,96 @@
+package integration
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestWeeklyDigest(t *testing.T) {
+	srv, db := newServer(t)
+	signIn := func(name string) *client {
+		c := &client{t: t, base: srv.URL}
+		c.mustJSON("POST", "/register", gin.H{"email": name + "@example.com", "username": name, "password": "Secret123!", "confirm_password": "Secret123!"}, http.StatusCreated, nil)
+		var login struct {
+			AccessToken string `json:"access_token"`
+		}
+		c.mustJSON("POST", "/login", gin.H{"email": name + "@example.com", "password": "Secret123!"}, http.StatusOK, &login)
+		c.token = login.AccessToken
+		return c
+	}
+	suffix := time.Now().UnixNano()
+	user := signIn(fmt.Sprintf("digest%d", suffix))
+	other := signIn(fmt.Sprintf("nodigest%d", suffix))
+	adminName := fmt.Sprintf("digestadmin%d", suffix)
+	admin := signIn(adminName)
+	db.Exec("UPDATE users SET is_admin = ? WHERE username = ?", true, adminName)
+
+	var settings struct {
+		Enabled bool       `json:"enabled"`
+		Email   bool       `json:"email"`
+		NextAt  *time.Time `json:"next_at"`
+	}
+	user.mustJSON("GET", "/profile/digest", nil, http.StatusOK, &settings)
+	if settings.Enabled || settings.NextAt != nil {
+		t.Fatalf("default settings = %+v", settings)
+	}
+	user.mustJSON("PUT", "/profile/digest", gin.H{"enabled": true}, http.StatusOK, &settings)
+	if !settings.Enabled || settings.Email || settings.NextAt == nil || !settings.NextAt.After(time.Now()) {
+		t.Fatalf("opted-in settings = %+v", settings)
+	}
+
+	var preview struct {
+		Title  string `json:"title"`
+		Text   string `json:"text"`
+		Events int    `json:"events"`
+	}
+	user.mustJSON("GET", "/profile/digest/preview?from=2025-10-01", nil, http.StatusOK, &preview)
+	if preview.Events == 0 || preview.Title != "Ringkasan acara 2025-10-01" || !strings.Contains(preview.Text, "2025-10-05") {
+		t.Fatalf("preview = %+v", preview)
+	}
+	user.mustJSON("GET", "/profile/digest/preview?from=oktober", nil, http.StatusBadRequest, nil)
+	user.mustJSON("POST", "/admin/digest/run", nil, http.StatusForbidden, nil)
+
+	var res struct {
+		Sent    int `json:"sent"`
+		Skipped int `json:"skipped"`
+	}
+	admin.mustJSON("POST", "/admin/digest/run?from=2025-10-01", nil, http.StatusOK, &res)
+	if res.Sent != 1 {
+		t.Fatalf("run = %+v", res)
+	}
+
+	var convs []struct {
+		ID    uint   `json:"id"`
+		Title string `json:"title"`
+	}
+	user.mustJSON("GET", "/conversations", nil, http.StatusOK, &convs)
+	if len(convs) != 1 || convs[0].Title != preview.Title {
+		t.Fatalf("conversations = %+v", convs)
+	}
+	var conv struct {
+		Messages []struct {
+			Sender string `json:"sender"`
+			Text   string `json:"text"`
+		} `json:"messages"`
+	}
+	user.mustJSON("GET", fmt.Sprintf("/conversations/%d", convs[0].ID), nil, http.StatusOK, &conv)
+	if len(conv.Messages) != 1 || conv.Messages[0].Sender != "bot" || conv.Messages[0].Text != preview.Text {
+		t.Fatalf("digest conversation = %+v", conv)
+	}
+	other.mustJSON("GET", "/conversations", nil, http.StatusOK, &convs)
+	if len(convs) != 0 {
+		t.Fatalf("user who did not opt in got %+v", convs)
+	}
+
+	// Opting out stops the digest.
+	user.mustJSON("PUT", "/profile/digest", gin.H{"enabled": false}, http.StatusOK, nil)
+	admin.mustJSON("POST", "/admin/digest/run?from=2025-10-01", nil, http.StatusOK, &res)
+	if res.Sent != 0 {
+		t.Fatalf("run after opting out = %+v", res)
+	}
+}
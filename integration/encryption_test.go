@@ -0,0 +1,210 @@
+package integration
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"AkuAI/pkg/config"
+	"AkuAI/pkg/fieldcrypt"
+	"AkuAI/pkg/moderation"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestEncryptionAtRest(t *testing.T) {
+	srv, db := newServer(t)
+	key := func(id string, b byte) string {
+		return id + ":" + base64.StdEncoding.EncodeToString([]byte(strings.Repeat(string(b), 32)))
+	}
+	if err := fieldcrypt.Configure(key("k1", 'a')); err != nil {
+		t.Fatal(err)
+	}
+	columns := []fieldcrypt.Column{
+		{Table: "messages", Name: "text"},
+		{Table: "conversations", Name: "title"},
+		{Table: "webhook_deliveries", Name: "payload"},
+		{Table: "moderation_events", Name: "excerpt"},
+	}
+	t.Cleanup(func() {
+		// The tests after this one run without keys, so everything sealed
+		// here, other tests' rows included, goes back into plain text.
+		k := fieldcrypt.Default()
+		for _, col := range columns {
+			var rows []struct {
+				ID    uint
+				Value string
+			}
+			db.Table(col.Table).Select("id, "+col.Name+" AS value").Where(col.Name+" LIKE ?", "enc:v1:%").Scan(&rows)
+			for _, r := range rows {
+				plain, err := k.Decrypt(r.Value)
+				if err != nil {
+					t.Errorf("%s of row %d: %v", col, r.ID, err)
+					continue
+				}
+				db.Table(col.Table).Where("id = ?", r.ID).UpdateColumn(col.Name, plain)
+			}
+		}
+		fieldcrypt.Use(&fieldcrypt.Keyring{})
+	})
+
+	name := fmt.Sprintf("sealed%d", time.Now().UnixNano())
+	c := &client{t: t, base: srv.URL}
+	c.mustJSON("POST", "/register", gin.H{"email": name + "@example.com", "username": name, "password": "Secret123!", "confirm_password": "Secret123!"}, http.StatusCreated, nil)
+	var login struct {
+		AccessToken string `json:"access_token"`
+	}
+	c.mustJSON("POST", "/login", gin.H{"email": name + "@example.com", "password": "Secret123!"}, http.StatusOK, &login)
+	c.token = login.AccessToken
+
+	question := "Apa saja webinar UIB bulan November?"
+	var conv conversationResp
+	c.mustJSON("POST", "/conversations", gin.H{"message": question}, http.StatusCreated, &conv)
+	if len(conv.Messages) != 2 || conv.Messages[0].Text != question {
+		t.Fatalf("conversation = %+v", conv)
+	}
+
+	stored := func() (text, title string) {
+		db.Raw("SELECT text FROM messages WHERE conversation_id = ? ORDER BY id LIMIT 1", conv.ConversationID).Scan(&text)
+		db.Raw("SELECT title FROM conversations WHERE id = ?", conv.ConversationID).Scan(&title)
+		return text, title
+	}
+	text, title := stored()
+	if !strings.HasPrefix(text, "enc:v1:k1:") || !strings.HasPrefix(title, "enc:v1:k1:") || strings.Contains(text, "webinar") {
+		t.Fatalf("stored in the clear: text %q, title %q", text, title)
+	}
+
+	var full struct {
+		Title    string `json:"title"`
+		Messages []struct {
+			ID   uint   `json:"id"`
+			Text string `json:"text"`
+		} `json:"messages"`
+	}
+	c.mustJSON("GET", fmt.Sprintf("/conversations/%d", conv.ConversationID), nil, http.StatusOK, &full)
+	if full.Title != question[:30]+"..." || full.Messages[0].Text != question {
+		t.Fatalf("conversation read back as %+v", full)
+	}
+	c.mustJSON("PUT", fmt.Sprintf("/conversations/%d/messages/%d/bookmark", conv.ConversationID, full.Messages[1].ID), nil, http.StatusOK, nil)
+	var page struct {
+		Bookmarks []struct {
+			ConversationTitle string `json:"conversation_title"`
+		} `json:"bookmarks"`
+	}
+	c.mustJSON("GET", "/bookmarks", nil, http.StatusOK, &page)
+	if len(page.Bookmarks) != 1 || page.Bookmarks[0].ConversationTitle != full.Title {
+		t.Fatalf("bookmarks = %+v", page)
+	}
+
+	// Searches open the sealed rows instead of matching ciphertext.
+	var found []struct {
+		ID uint `json:"id"`
+	}
+	c.mustJSON("GET", "/conversations?q=WEBINAR", nil, http.StatusOK, &found)
+	if len(found) != 1 || found[0].ID != conv.ConversationID {
+		t.Fatalf("search by message text = %+v", found)
+	}
+	c.mustJSON("GET", "/conversations?q=enc:v1", nil, http.StatusOK, &found)
+	if len(found) != 0 {
+		t.Fatalf("search matched ciphertext: %+v", found)
+	}
+	c.mustJSON("GET", "/bookmarks?q=november", nil, http.StatusOK, &page)
+	if len(page.Bookmarks) != 1 {
+		t.Fatalf("bookmark search = %+v", page)
+	}
+	c.mustJSON("GET", "/bookmarks?q=tidak-ada-di-mana-pun", nil, http.StatusOK, &page)
+	if len(page.Bookmarks) != 0 {
+		t.Fatalf("bookmark search for a missing word = %+v", page)
+	}
+
+	// Nothing copies chat content into a column that is not sealed: not the
+	// webhook payload, the moderation excerpt or the audit log.
+	config.WebhookAllowPrivate = true
+	moderation.Init(moderation.New(moderation.DefaultBlock, moderation.DefaultFlag, nil))
+	var hook struct {
+		Webhook struct {
+			ID uint `json:"id"`
+		} `json:"webhook"`
+	}
+	c.mustJSON("POST", "/webhooks", gin.H{"url": "http://127.0.0.1:9/hook", "events": []string{"message.completed"}}, http.StatusCreated, &hook)
+	defer func() {
+		config.WebhookAllowPrivate = false
+		moderation.Init(nil)
+		// Nothing listens on the hook; keep its deliveries from other tests' runs.
+		db.Exec("DELETE FROM webhook_deliveries WHERE webhook_id = ?", hook.Webhook.ID)
+	}()
+	marker := fmt.Sprintf("zirkonia%d", time.Now().UnixNano()%1000000)
+	var flagged conversationResp
+	c.mustJSON("POST", "/conversations", gin.H{"message": marker + " bangsat, jadwal sertifikasi kapan?"}, http.StatusCreated, &flagged)
+	if len(flagged.Messages) != 2 {
+		t.Fatalf("flagged conversation = %+v", flagged)
+	}
+	c.mustJSON("DELETE", fmt.Sprintf("/conversations/%d", flagged.ConversationID), nil, http.StatusOK, nil)
+	var events int64
+	db.Table("moderation_events").Where("excerpt <> ''").Count(&events)
+	var payloads []string
+	db.Raw("SELECT payload FROM webhook_deliveries WHERE webhook_id = ?", hook.Webhook.ID).Scan(&payloads)
+	if events == 0 || len(payloads) == 0 {
+		t.Fatalf("moderation events = %d, webhook deliveries = %d, want both", events, len(payloads))
+	}
+	for _, p := range payloads {
+		if !strings.HasPrefix(p, "enc:v1:k1:") {
+			t.Errorf("webhook payload stored in the clear: %.80q", p)
+		}
+	}
+	tables, err := db.Migrator().GetTables()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, table := range tables {
+		var rows []map[string]any
+		db.Table(table).Find(&rows)
+		for _, row := range rows {
+			for col, v := range row {
+				s := fmt.Sprint(v)
+				if b, ok := v.([]byte); ok {
+					s = string(b)
+				}
+				if strings.Contains(s, marker) {
+					t.Errorf("%s.%s holds chat content in the clear: %.80q", table, col, s)
+				}
+			}
+		}
+	}
+
+	// Rotate: k2 seals from now on, k1 still opens the old rows until they
+	// are re-encrypted.
+	if err := fieldcrypt.Configure(key("k2", 'b') + "," + key("k1", 'a')); err != nil {
+		t.Fatal(err)
+	}
+	c.mustJSON("GET", fmt.Sprintf("/conversations/%d", conv.ConversationID), nil, http.StatusOK, &full)
+	if full.Messages[0].Text != question {
+		t.Fatalf("read with a rotated key: %+v", full)
+	}
+	k := fieldcrypt.Default()
+	if n, err := fieldcrypt.Reencrypt(db, k, columns[0], true); err != nil || n < 2 {
+		t.Fatalf("dry run = %d, %v", n, err)
+	}
+	if text, _ := stored(); !strings.HasPrefix(text, "enc:v1:k1:") {
+		t.Fatalf("dry run rewrote %q", text)
+	}
+	for _, col := range columns {
+		if _, err := fieldcrypt.Reencrypt(db, k, col, false); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if text, title := stored(); !strings.HasPrefix(text, "enc:v1:k2:") || !strings.HasPrefix(title, "enc:v1:k2:") {
+		t.Fatalf("after re-encryption: text %q, title %q", text, title)
+	}
+
+	if err := fieldcrypt.Configure(key("k2", 'b')); err != nil {
+		t.Fatal(err)
+	}
+	c.mustJSON("GET", fmt.Sprintf("/conversations/%d", conv.ConversationID), nil, http.StatusOK, &full)
+	if full.Messages[0].Text != question {
+		t.Fatalf("read after dropping k1: %+v", full)
+	}
+}
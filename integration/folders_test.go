@@ -0,0 +1,77 @@
+package integration
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestPinsAndFolders(t *testing.T) {
+	srv, _ := newServer(t)
+	c := &client{t: t, base: srv.URL}
+	name := fmt.Sprintf("folders%d", time.Now().UnixNano())
+	c.mustJSON("POST", "/register", gin.H{"email": name + "@example.com", "username": name, "password": "Secret123!", "confirm_password": "Secret123!"}, http.StatusCreated, nil)
+	var login struct {
+		AccessToken string `json:"access_token"`
+	}
+	c.mustJSON("POST", "/login", gin.H{"email": name + "@example.com", "password": "Secret123!"}, http.StatusOK, &login)
+	c.token = login.AccessToken
+
+	var ids []uint
+	for _, q := range []string{"Apa saja webinar UIB bulan November?", "Berapa biaya seminar?", "Kapan workshop berikutnya?"} {
+		var conv conversationResp
+		c.mustJSON("POST", "/conversations", gin.H{"message": q}, http.StatusCreated, &conv)
+		ids = append(ids, conv.ConversationID)
+	}
+	type listed struct {
+		ID       uint  `json:"id"`
+		Pinned   bool  `json:"pinned"`
+		FolderID *uint `json:"folder_id"`
+	}
+	list := func(query string) []listed {
+		var out []listed
+		c.mustJSON("GET", "/conversations"+query, nil, http.StatusOK, &out)
+		return out
+	}
+
+	// The oldest conversation floats to the top once pinned.
+	c.mustJSON("POST", fmt.Sprintf("/conversations/%d/pin", ids[0]), nil, http.StatusOK, nil)
+	if got := list(""); len(got) != 3 || got[0].ID != ids[0] || !got[0].Pinned || got[1].ID != ids[2] {
+		t.Fatalf("pinned list = %+v", got)
+	}
+
+	var folder struct {
+		ID uint `json:"id"`
+	}
+	c.mustJSON("POST", "/folders", gin.H{"name": "Webinar"}, http.StatusCreated, &folder)
+	c.mustJSON("POST", "/folders", gin.H{"name": "webinar"}, http.StatusConflict, nil)
+	c.mustJSON("PUT", fmt.Sprintf("/conversations/%d/folder", ids[1]), gin.H{"folder_id": folder.ID}, http.StatusOK, nil)
+	c.mustJSON("PUT", fmt.Sprintf("/conversations/%d/folder", ids[2]), gin.H{"folder_id": folder.ID + 100}, http.StatusNotFound, nil)
+	if got := list(fmt.Sprintf("?folder_id=%d", folder.ID)); len(got) != 1 || got[0].ID != ids[1] || *got[0].FolderID != folder.ID {
+		t.Fatalf("folder list = %+v", got)
+	}
+	if got := list("?folder_id=none"); len(got) != 2 {
+		t.Fatalf("unfiled list = %+v", got)
+	}
+	var folders []struct {
+		Name  string `json:"name"`
+		Count int    `json:"conversations_count"`
+	}
+	c.mustJSON("PUT", fmt.Sprintf("/folders/%d", folder.ID), gin.H{"name": "Acara"}, http.StatusOK, nil)
+	c.mustJSON("GET", "/folders", nil, http.StatusOK, &folders)
+	if len(folders) != 1 || folders[0].Name != "Acara" || folders[0].Count != 1 {
+		t.Fatalf("folders = %+v", folders)
+	}
+
+	c.mustJSON("DELETE", fmt.Sprintf("/folders/%d", folder.ID), nil, http.StatusOK, nil)
+	if got := list("?folder_id=none"); len(got) != 3 {
+		t.Fatalf("after folder delete = %+v", got)
+	}
+	c.mustJSON("POST", fmt.Sprintf("/conversations/%d/unpin", ids[0]), nil, http.StatusOK, nil)
+	if got := list(""); got[0].ID != ids[2] || got[2].Pinned {
+		t.Fatalf("unpinned list = %+v", got)
+	}
+}
@@ -0,0 +1,72 @@
+package integration
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"AkuAI/models"
+	"AkuAI/pkg/analytics"
+	"AkuAI/pkg/config"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestFrustrationFlag(t *testing.T) {
+	srv, db := newServer(t)
+	if err := analytics.Register(db); err != nil {
+		t.Fatal(err)
+	}
+	alertCount := config.FrustrationAlertCount
+	config.FrustrationAlertCount = 2
+	t.Cleanup(func() { config.FrustrationAlertCount = alertCount })
+
+	signIn := func(name string) *client {
+		c := &client{t: t, base: srv.URL}
+		c.mustJSON("POST", "/register", gin.H{"email": name + "@example.com", "username": name, "password": "Secret123!", "confirm_password": "Secret123!"}, http.StatusCreated, nil)
+		var login struct {
+			AccessToken string `json:"access_token"`
+		}
+		c.mustJSON("POST", "/login", gin.H{"email": name + "@example.com", "password": "Secret123!"}, http.StatusOK, &login)
+		c.token = login.AccessToken
+		return c
+	}
+	suffix := time.Now().UnixNano()
+	user := signIn(fmt.Sprintf("grumpy%d", suffix))
+	adminName := fmt.Sprintf("careadmin%d", suffix)
+	admin := signIn(adminName)
+	db.Exec("UPDATE users SET is_admin = ? WHERE username = ?", true, adminName)
+
+	var conv conversationResp
+	user.mustJSON("POST", "/conversations", gin.H{"message": "Ada webinar apa bulan November?"}, http.StatusCreated, &conv)
+	ask := func(text string) {
+		user.mustJSON("POST", "/conversations", gin.H{"message": text, "conversation_id": conv.ConversationID}, http.StatusCreated, nil)
+	}
+	ask("Jawabannya gak nyambung")
+	var stored models.Conversation
+	db.First(&stored, conv.ConversationID)
+	if stored.FrustrationFlaggedAt != nil {
+		t.Fatal("flagged after one frustrated message")
+	}
+	ask("Masih salah, percuma")
+
+	type flagged struct {
+		Conversations []analytics.FlaggedConversation `json:"conversations"`
+	}
+	var list flagged
+	admin.mustJSON("GET", "/admin/frustration", nil, http.StatusOK, &list)
+	if len(list.Conversations) != 1 || list.Conversations[0].ConversationID != conv.ConversationID ||
+		list.Conversations[0].Frustrated != 2 || len(list.Conversations[0].Recent) != 3 || list.Conversations[0].Recent[2].Sentiment != "neutral" {
+		t.Fatalf("flagged = %+v", list.Conversations)
+	}
+	user.mustJSON("GET", "/admin/frustration", nil, http.StatusForbidden, nil)
+
+	path := fmt.Sprintf("/admin/frustration/%d/review", conv.ConversationID)
+	admin.mustJSON("POST", path, nil, http.StatusOK, nil)
+	admin.mustJSON("POST", path, nil, http.StatusNotFound, nil)
+	admin.mustJSON("GET", "/admin/frustration", nil, http.StatusOK, &list)
+	if len(list.Conversations) != 0 {
+		t.Fatalf("still flagged after review: %+v", list.Conversations)
+	}
+}
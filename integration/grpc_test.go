@@ -0,0 +1,151 @@
+package integration
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"AkuAI/controllers"
+	"AkuAI/pkg/grpcserver"
+
+	"github.com/gin-gonic/gin"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+type grpcClient struct {
+	t     *testing.T
+	base  string
+	token string
+	http  *http.Client
+}
+
+func (g *grpcClient) message(name string) *dynamicpb.Message {
+	d, err := grpcserver.Files.FindDescriptorByName(protoreflect.FullName(name))
+	if err != nil {
+		g.t.Fatal(err)
+	}
+	return dynamicpb.NewMessage(d.(protoreflect.MessageDescriptor))
+}
+
+// call returns the response messages of type out and the grpc-status.
+func (g *grpcClient) call(method string, req proto.Message, out string) ([]*dynamicpb.Message, string) {
+	g.t.Helper()
+	b, _ := proto.Marshal(req)
+	frame := make([]byte, 5, 5+len(b))
+	binary.BigEndian.PutUint32(frame[1:], uint32(len(b)))
+	hreq, _ := http.NewRequest(http.MethodPost, g.base+method, bytes.NewReader(append(frame, b...)))
+	hreq.Header.Set("Content-Type", "application/grpc")
+	if g.token != "" {
+		hreq.Header.Set("Authorization", "Bearer "+g.token)
+	}
+	resp, err := g.http.Do(hreq)
+	if err != nil {
+		g.t.Fatalf("%s: %v", method, err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	var msgs []*dynamicpb.Message
+	for len(body) >= 5 {
+		n := binary.BigEndian.Uint32(body[1:5])
+		m := g.message(out)
+		if err := proto.Unmarshal(body[5:5+n], m); err != nil {
+			g.t.Fatal(err)
+		}
+		msgs = append(msgs, m)
+		body = body[5+n:]
+	}
+	return msgs, resp.Trailer.Get("Grpc-Status")
+}
+
+func TestGRPCFlows(t *testing.T) {
+	srv, db := newServer(t)
+	c := &client{t: t, base: srv.URL}
+	name := fmt.Sprintf("grpc%d", time.Now().UnixNano())
+	c.mustJSON("POST", "/register", gin.H{"email": name + "@example.com", "username": name, "password": "Secret123!", "confirm_password": "Secret123!"}, http.StatusCreated, nil)
+	var login struct {
+		AccessToken string `json:"access_token"`
+	}
+	c.mustJSON("POST", "/login", gin.H{"email": name + "@example.com", "password": "Secret123!"}, http.StatusOK, &login)
+
+	health := grpcserver.NewHealth()
+	gs := grpcserver.New(health)
+	if err := controllers.RegisterGRPC(gs, health, db); err != nil {
+		t.Fatalf("register gRPC: %v", err)
+	}
+	ts := httptest.NewUnstartedServer(gs)
+	var p http.Protocols
+	p.SetUnencryptedHTTP2(true)
+	ts.Config.Protocols = &p
+	ts.Start()
+	defer ts.Close()
+	g := &grpcClient{t: t, base: ts.URL, http: &http.Client{Transport: &http.Transport{Protocols: &p}}}
+
+	ask := g.message("akuai.v1.AskRequest")
+	grpcserver.Set(ask, "message", "Ada webinar apa di bulan November?")
+	grpcserver.Set(ask, "mode", "engineered")
+	if _, status := g.call("/akuai.v1.ChatService/Ask", ask, "akuai.v1.AskResponse"); status != "16" {
+		t.Fatalf("Ask without token: status %s, want 16", status)
+	}
+	g.token = login.AccessToken
+
+	out, status := g.call("/akuai.v1.ChatService/Ask", ask, "akuai.v1.AskResponse")
+	if status != "0" || len(out) != 1 {
+		t.Fatalf("Ask: status %s, %d messages", status, len(out))
+	}
+	convID := grpcserver.GetUint(out[0], "conversation_id")
+	if reply := grpcserver.GetString(out[0], "reply"); !strings.Contains(reply, "Webinar Transformasi Digital") {
+		t.Errorf("Ask: reply %q does not come from the webinar-november fixture", reply)
+	}
+
+	stream := g.message("akuai.v1.AskRequest")
+	grpcserver.Set(stream, "message", "Jadwal sertifikasi oktober?")
+	grpcserver.Set(stream, "conversation_id", convID)
+	events, status := g.call("/akuai.v1.ChatService/StreamAsk", stream, "akuai.v1.AskEvent")
+	if status != "0" || len(events) < 3 {
+		t.Fatalf("StreamAsk: status %s, %d events", status, len(events))
+	}
+	var text strings.Builder
+	for _, e := range events[1 : len(events)-1] {
+		text.WriteString(grpcserver.GetString(e, "text"))
+	}
+	first, last := events[0], events[len(events)-1]
+	if grpcserver.GetString(first, "type") != "started" || grpcserver.GetUint(first, "conversation_id") != convID ||
+		grpcserver.GetString(last, "type") != "done" || grpcserver.GetUint(last, "message_id") == 0 || text.Len() == 0 {
+		t.Errorf("StreamAsk: started %v, done %v, %d chars", first, last, text.Len())
+	}
+	var count int64
+	db.Table("messages").Where("conversation_id = ?", convID).Count(&count)
+	if count != 4 {
+		t.Errorf("conversation %d has %d messages, want 4", convID, count)
+	}
+
+	list := g.message("akuai.v1.ListEventsRequest")
+	grpcserver.Set(list, "type", "webinar")
+	out, status = g.call("/akuai.v1.UIBEventService/List", list, "akuai.v1.ListEventsResponse")
+	if status != "0" {
+		t.Fatalf("List: status %s", status)
+	}
+	evs := out[0].Get(out[0].Descriptor().Fields().ByName("events")).List()
+	if evs.Len() == 0 {
+		t.Fatal("List: no webinars")
+	}
+	id := evs.Get(0).Message().Interface().(*dynamicpb.Message)
+	get := g.message("akuai.v1.GetEventRequest")
+	grpcserver.Set(get, "id", grpcserver.GetString(id, "id"))
+	out, status = g.call("/akuai.v1.UIBEventService/Get", get, "akuai.v1.Event")
+	if status != "0" || grpcserver.GetString(out[0], "type") != "webinar" {
+		t.Errorf("Get: status %s", status)
+	}
+	grpcserver.Set(get, "id", "no-such-event")
+	if _, status = g.call("/akuai.v1.UIBEventService/Get", get, "akuai.v1.Event"); status != "5" {
+		t.Errorf("Get missing: status %s, want 5", status)
+	}
+}
@@ -0,0 +1,124 @@
+package integration
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/cookiejar"
+	"testing"
+	"time"
+
+	"AkuAI/pkg/config"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestGuestChatAndClaim(t *testing.T) {
+	srv, _ := newServer(t)
+	config.GuestMaxMessages = 2
+	t.Cleanup(func() { config.GuestChatEnabled, config.GuestMaxMessages = false, 10 })
+
+	// guest sends a request with its own cookie jar, and a token when set.
+	type guest struct {
+		http  *http.Client
+		token string
+	}
+	newGuest := func() *guest {
+		jar, _ := cookiejar.New(nil)
+		return &guest{http: &http.Client{Jar: jar}}
+	}
+	do := func(g *guest, method, path string, body any, want int, out any) {
+		t.Helper()
+		var rd io.Reader
+		if body != nil {
+			b, _ := json.Marshal(body)
+			rd = bytes.NewReader(b)
+		}
+		req, _ := http.NewRequest(method, srv.URL+"/api/v1"+path, rd)
+		req.Header.Set("Content-Type", "application/json")
+		if g.token != "" {
+			req.Header.Set("Authorization", "Bearer "+g.token)
+		}
+		resp, err := g.http.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		data, _ := io.ReadAll(resp.Body)
+		if resp.StatusCode != want {
+			t.Fatalf("%s %s = %d, want %d: %s", method, path, resp.StatusCode, want, data)
+		}
+		if out != nil {
+			if err := json.Unmarshal(data, out); err != nil {
+				t.Fatalf("%s %s: decode %s: %v", method, path, data, err)
+			}
+		}
+	}
+
+	alice := newGuest()
+	do(alice, "POST", "/guest/conversations", gin.H{"message": "Apa saja webinar UIB bulan November?"}, http.StatusNotFound, nil)
+	config.GuestChatEnabled = true
+
+	var conv struct {
+		conversationResp
+		QuestionsLeft int `json:"questions_left"`
+	}
+	do(alice, "POST", "/guest/conversations", gin.H{"message": "Apa saja webinar UIB bulan November?"}, http.StatusCreated, &conv)
+	if len(conv.Messages) != 2 || conv.Messages[1].Sender != "bot" || conv.QuestionsLeft != 1 {
+		t.Fatalf("unexpected guest conversation %+v", conv)
+	}
+	id := conv.ConversationID
+	do(alice, "POST", "/guest/conversations", gin.H{"message": "Berapa biaya pendaftarannya?", "conversation_id": id}, http.StatusCreated, &conv)
+	var apiErr struct {
+		Code string `json:"code"`
+	}
+	do(alice, "POST", "/guest/conversations", gin.H{"message": "Di mana lokasinya?", "conversation_id": id}, http.StatusForbidden, &apiErr)
+	if apiErr.Code != "guest_limit_reached" {
+		t.Fatalf("limit code = %q", apiErr.Code)
+	}
+
+	bob := newGuest()
+	do(bob, "GET", fmt.Sprintf("/guest/conversations/%d", id), nil, http.StatusNotFound, nil)
+	do(bob, "POST", "/guest/conversations", gin.H{"message": "Di mana lokasinya?", "conversation_id": id}, http.StatusNotFound, nil)
+	var list []struct {
+		ID uint `json:"id"`
+	}
+	do(alice, "GET", "/guest/conversations", nil, http.StatusOK, &list)
+	if len(list) != 1 || list[0].ID != id {
+		t.Fatalf("guest list = %+v", list)
+	}
+
+	// A user's conversation list never shows unclaimed guest conversations.
+	name := fmt.Sprintf("guest%d", time.Now().UnixNano())
+	do(alice, "POST", "/register", gin.H{"email": name + "@example.com", "username": name, "password": "Secret123!", "confirm_password": "Secret123!"}, http.StatusCreated, nil)
+	var login struct {
+		AccessToken string `json:"access_token"`
+	}
+	do(alice, "POST", "/login", gin.H{"email": name + "@example.com", "password": "Secret123!"}, http.StatusOK, &login)
+	alice.token = login.AccessToken
+	do(alice, "GET", "/conversations", nil, http.StatusOK, &list)
+	if len(list) != 0 {
+		t.Fatalf("conversations before claim = %+v", list)
+	}
+
+	var claim struct {
+		Claimed         int    `json:"claimed"`
+		ConversationIDs []uint `json:"conversation_ids"`
+	}
+	do(alice, "POST", "/auth/claim", nil, http.StatusOK, &claim)
+	if claim.Claimed != 1 || claim.ConversationIDs[0] != id {
+		t.Fatalf("claim = %+v", claim)
+	}
+	do(alice, "GET", "/conversations", nil, http.StatusOK, &list)
+	if len(list) != 1 || list[0].ID != id {
+		t.Fatalf("conversations after claim = %+v", list)
+	}
+	do(alice, "GET", fmt.Sprintf("/conversations/%d", id), nil, http.StatusOK, &conv)
+	if len(conv.Messages) != 4 {
+		t.Fatalf("claimed conversation has %d messages, want 4", len(conv.Messages))
+	}
+	// The cookie was cleared, so there is nothing left to claim.
+	do(alice, "POST", "/auth/claim", nil, http.StatusBadRequest, nil)
+}
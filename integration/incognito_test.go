@@ -0,0 +1,100 @@
+package integration
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"AkuAI/models"
+	"AkuAI/pkg/moderation"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestIncognitoConversation(t *testing.T) {
+	srv, db := newServer(t)
+	signIn := func(name string) *client {
+		c := &client{t: t, base: srv.URL}
+		c.mustJSON("POST", "/register", gin.H{"email": name + "@example.com", "username": name, "password": "Secret123!", "confirm_password": "Secret123!"}, http.StatusCreated, nil)
+		var login struct {
+			AccessToken string `json:"access_token"`
+		}
+		c.mustJSON("POST", "/login", gin.H{"email": name + "@example.com", "password": "Secret123!"}, http.StatusOK, &login)
+		c.token = login.AccessToken
+		return c
+	}
+	suffix := time.Now().UnixNano()
+	other := signIn(fmt.Sprintf("incognitoa%d", suffix))
+	c := signIn(fmt.Sprintf("incognitob%d", suffix))
+
+	type conversation struct {
+		ConversationID uint `json:"conversation_id"`
+		Incognito      bool `json:"incognito"`
+		Messages       []struct {
+			Generation *struct {
+				Cached bool `json:"cached"`
+			} `json:"generation"`
+		} `json:"messages"`
+	}
+	// The factual question is in the shared reply cache once other asked it.
+	question := fmt.Sprintf("Ada lomba apa di bulan November %d?", suffix%1000)
+	other.mustJSON("POST", "/conversations", gin.H{"message": question, "mode": "engineered"}, http.StatusCreated, nil)
+
+	var normal conversation
+	c.mustJSON("POST", "/conversations", gin.H{"message": "Kapan pendaftaran wisuda dibuka?"}, http.StatusCreated, &normal)
+	if normal.Incognito {
+		t.Fatal("conversation incognito without asking")
+	}
+
+	var private conversation
+	c.mustJSON("POST", "/conversations", gin.H{"message": question, "mode": "engineered", "incognito": true}, http.StatusCreated, &private)
+	if !private.Incognito || len(private.Messages) != 2 || private.Messages[1].Generation == nil {
+		t.Fatalf("incognito chat: %+v", private)
+	}
+	if private.Messages[1].Generation.Cached {
+		t.Error("incognito reply served from the reply cache")
+	}
+
+	var stats struct {
+		MessagesPerDay []struct{} `json:"messages_per_day"`
+	}
+	c.mustJSON("GET", fmt.Sprintf("/analytics/me?conversation_id=%d", private.ConversationID), nil, http.StatusOK, &stats)
+	if len(stats.MessagesPerDay) != 0 {
+		t.Errorf("analytics count messages of an incognito conversation: %+v", stats)
+	}
+
+	// A flagged message in an incognito chat leaves no text behind in the
+	// moderation log or its events.
+	moderation.Init(moderation.New(moderation.DefaultBlock, moderation.DefaultFlag, nil))
+	defer moderation.Init(nil)
+	var logs bytes.Buffer
+	log.SetOutput(io.MultiWriter(&logs, os.Stderr))
+	flagged := fmt.Sprintf("bangsat, kapan wisuda %d?", suffix)
+	c.mustJSON("POST", "/conversations", gin.H{"message": flagged, "conversation_id": private.ConversationID}, http.StatusCreated, nil)
+	log.SetOutput(os.Stderr)
+	var events []models.ModerationEvent
+	db.Where("user_id = (SELECT id FROM users WHERE username = ?)", fmt.Sprintf("incognitob%d", suffix)).Find(&events)
+	if len(events) != 1 || events[0].Excerpt != "" || events[0].Matched != "" {
+		t.Errorf("moderation events of an incognito chat = %+v", events)
+	}
+	if strings.Contains(logs.String(), "bangsat") {
+		t.Error("incognito message text in the moderation log")
+	}
+
+	c.mustJSON("POST", "/logout", nil, http.StatusOK, nil)
+	var left int64
+	db.Unscoped().Model(&models.Conversation{}).Where("id = ?", private.ConversationID).Count(&left)
+	if left != 0 {
+		t.Error("incognito conversation kept after logout")
+	}
+	db.Model(&models.Conversation{}).Where("id = ?", normal.ConversationID).Count(&left)
+	if left != 1 {
+		t.Error("regular conversation purged on logout")
+	}
+}
@@ -0,0 +1,339 @@
+// Package integration runs the HTTP and WebSocket flows end to end against
+// the real router and a throwaway database, with Gemini replaced by the
+// fixture MockLLM and image search served from the mock catalog.
+//
+// By default the suite runs against an in-memory SQLite database. Point it
+// at MySQL or Postgres with INTEGRATION_DB_DRIVER and INTEGRATION_DB_DSN:
+//
+//	INTEGRATION_DB_DRIVER=mysql \
+//	INTEGRATION_DB_DSN="root:pw@tcp(127.0.0.1:3307)/akuai_test?charset=utf8mb4&parseTime=True&loc=Local" \
+//	APP_ENV=production JWT_SECRET_KEY=test go test ./integration/...
+package integration
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"AkuAI/models"
+	"AkuAI/pkg/config"
+	"AkuAI/pkg/database"
+	"AkuAI/pkg/migrations"
+	"AkuAI/routes"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func newServer(t *testing.T) (*httptest.Server, *gorm.DB) {
+	t.Helper()
+	// data/ and testdata/ paths are relative to the repository root
+	t.Chdir("..")
+	driver, dsn := os.Getenv("INTEGRATION_DB_DRIVER"), os.Getenv("INTEGRATION_DB_DSN")
+	if driver == "" {
+		driver = "sqlite"
+	}
+	if dsn == "" {
+		if driver != "sqlite" {
+			t.Skipf("INTEGRATION_DB_DSN not set for %s", driver)
+		}
+		dsn = "file::memory:?cache=shared"
+	}
+	db, err := database.OpenWith(driver, dsn, &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		t.Fatalf("open database: %v", err)
+	}
+	if err := migrations.Up(db); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+
+	// Services are built per request, so overriding config here is enough to
+	// keep every call offline.
+	config.MockLLMFixtures = "testdata/mock_llm"
+	config.IsGoogleAPIEnabled = false
+	config.SemanticCacheEnabled = false
+	config.ModerationGemini = false
+	config.TopicClassifierGemini = false
+	config.ImageIntentGemini = false
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	routes.RegisterRoutes(r, db)
+	srv := httptest.NewServer(r)
+	t.Cleanup(srv.Close)
+	return srv, db
+}
+
+type client struct {
+	t      *testing.T
+	base   string
+	token  string
+	tenant string // sent as X-Tenant-ID
+}
+
+func (c *client) do(method, path string, body any) (int, []byte) {
+	c.t.Helper()
+	var rd io.Reader
+	if body != nil {
+		b, _ := json.Marshal(body)
+		rd = bytes.NewReader(b)
+	}
+	req, _ := http.NewRequest(method, c.base+"/api/v1"+path, rd)
+	req.Header.Set("Content-Type", "application/json")
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+	if c.tenant != "" {
+		req.Header.Set("X-Tenant-ID", c.tenant)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		c.t.Fatalf("%s %s: %v", method, path, err)
+	}
+	defer resp.Body.Close()
+	data, _ := io.ReadAll(resp.Body)
+	return resp.StatusCode, data
+}
+
+func (c *client) mustJSON(method, path string, body any, want int, out any) {
+	c.t.Helper()
+	status, data := c.do(method, path, body)
+	if status != want {
+		c.t.Fatalf("%s %s = %d, want %d: %s", method, path, status, want, data)
+	}
+	if out != nil {
+		if err := json.Unmarshal(data, out); err != nil {
+			c.t.Fatalf("%s %s: decode %s: %v", method, path, data, err)
+		}
+	}
+}
+
+type conversationResp struct {
+	ConversationID uint `json:"conversation_id"`
+	Messages       []struct {
+		Sender     string `json:"sender"`
+		Text       string `json:"text"`
+		Generation *struct {
+			Model string `json:"model"`
+		} `json:"generation"`
+	} `json:"messages"`
+}
+
+// readSSE counts events by type, joins the delta text and returns the ID of
+// the first delta.
+func readSSE(t *testing.T, r io.Reader) (map[string]int, string, string) {
+	t.Helper()
+	events := map[string]int{}
+	var text strings.Builder
+	firstDelta := ""
+	id, event := "", ""
+	sc := bufio.NewScanner(r)
+	for sc.Scan() {
+		line := sc.Text()
+		switch {
+		case strings.HasPrefix(line, "id: "):
+			id = strings.TrimPrefix(line, "id: ")
+		case strings.HasPrefix(line, "event: "):
+			event = strings.TrimPrefix(line, "event: ")
+			events[event]++
+		case strings.HasPrefix(line, "data: ") && event == "delta":
+			var chunk string
+			if err := json.Unmarshal([]byte(strings.TrimPrefix(line, "data: ")), &chunk); err != nil {
+				t.Fatalf("delta %q is not a JSON string: %v", line, err)
+			}
+			text.WriteString(chunk)
+			if firstDelta == "" {
+				firstDelta = id
+			}
+		}
+	}
+	return events, text.String(), firstDelta
+}
+
+func TestChatFlows(t *testing.T) {
+	srv, db := newServer(t)
+	// Replies asserted below must be generated, not shared by earlier tests.
+	policy := config.ChatCacheKeyPolicy
+	config.ChatCacheKeyPolicy = "user"
+	t.Cleanup(func() { config.ChatCacheKeyPolicy = policy })
+	c := &client{t: t, base: srv.URL}
+
+	// register -> login
+	name := fmt.Sprintf("it%d", time.Now().UnixNano())
+	c.mustJSON("POST", "/register", gin.H{"email": name + "@example.com", "username": name, "password": "Secret123!", "confirm_password": "Secret123!"}, http.StatusCreated, nil)
+	var login struct {
+		AccessToken string `json:"access_token"`
+	}
+	c.mustJSON("POST", "/login", gin.H{"email": name + "@example.com", "password": "Secret123!"}, http.StatusOK, &login)
+	if login.AccessToken == "" {
+		t.Fatal("login returned no access_token")
+	}
+	c.token = login.AccessToken
+
+	// chat
+	var conv conversationResp
+	c.mustJSON("POST", "/conversations", gin.H{"message": "Ada webinar apa di bulan November?", "mode": "engineered"}, http.StatusCreated, &conv)
+	if n := len(conv.Messages); n != 2 {
+		t.Fatalf("chat: got %d messages, want 2", n)
+	}
+	bot := conv.Messages[1]
+	if !strings.Contains(bot.Text, "Webinar Transformasi Digital") {
+		t.Errorf("chat: reply %q does not come from the webinar-november fixture", bot.Text)
+	}
+	if bot.Generation == nil || bot.Generation.Model != "mock" {
+		t.Errorf("chat: generation = %+v, want model mock", bot.Generation)
+	}
+
+	// stream (SSE) into the same conversation
+	b, _ := json.Marshal(gin.H{"message": "Jadwal sertifikasi oktober?", "conversation_id": conv.ConversationID, "mode": "engineered"})
+	req, _ := http.NewRequest("POST", srv.URL+"/api/v1/conversations/stream", bytes.NewReader(b))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+c.token)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("stream: %v", err)
+	}
+	events, streamed, firstDeltaID := readSSE(t, resp.Body)
+	resp.Body.Close()
+	if events["user_saved"] != 1 || events["delta"] == 0 || events["done"] != 1 {
+		t.Errorf("stream: events = %v, want user_saved, delta and done", events)
+	}
+	if !strings.Contains(streamed, "Microsoft Office Specialist") {
+		t.Errorf("stream: deltas %q do not come from the sertifikasi-oktober fixture", streamed)
+	}
+
+	// resume after the first delta replays the rest of the answer
+	req, _ = http.NewRequest("GET", srv.URL+"/api/v1/conversations/stream/resume", nil)
+	req.Header.Set("Authorization", "Bearer "+c.token)
+	req.Header.Set("Last-Event-ID", firstDeltaID)
+	resp, err = http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("resume: %v", err)
+	}
+	resumed, rest, _ := readSSE(t, resp.Body)
+	resp.Body.Close()
+	if resumed["user_saved"] != 0 || resumed["done"] != 1 || !strings.HasSuffix(streamed, rest) || rest == streamed {
+		t.Errorf("resume: events = %v, text %q, want the deltas after %s", resumed, rest, firstDeltaID)
+	}
+
+	// errors before the stream starts are JSON envelopes
+	var streamErr struct {
+		Code string `json:"code"`
+	}
+	status, data := c.do("POST", "/conversations/stream", gin.H{"message": "Halo", "conversation_id": 999999})
+	if err := json.Unmarshal(data, &streamErr); err != nil || status != http.StatusNotFound || streamErr.Code != "not_found" {
+		t.Errorf("stream to unknown conversation = %d %s, want 404 not_found", status, data)
+	}
+	status, data = (&client{t: t, base: srv.URL}).do("GET", "/ws/chat", nil)
+	if err := json.Unmarshal(data, &streamErr); err != nil || status != http.StatusUnauthorized || streamErr.Code != "unauthorized" {
+		t.Errorf("ws without token = %d %s, want 401 unauthorized", status, data)
+	}
+
+	// ws
+	wsURL := "ws" + strings.TrimPrefix(srv.URL, "http") + "/api/v1/ws/chat?token=" + c.token
+	ws, _, err := websocket.DefaultDialer.Dial(wsURL, nil)
+	if err != nil {
+		t.Fatalf("ws dial: %v", err)
+	}
+	defer ws.Close()
+	if err := ws.WriteJSON(gin.H{"type": "start", "message": "Kapan jadwal wisuda?", "conversation_id": conv.ConversationID}); err != nil {
+		t.Fatalf("ws start: %v", err)
+	}
+	_ = ws.SetReadDeadline(time.Now().Add(30 * time.Second))
+	var wsText strings.Builder
+	for {
+		var msg struct {
+			Type  string `json:"type"`
+			Data  string `json:"data"`
+			Error string `json:"error"`
+		}
+		if err := ws.ReadJSON(&msg); err != nil {
+			t.Fatalf("ws read: %v", err)
+		}
+		if msg.Type == "error" {
+			t.Fatalf("ws error: %s", msg.Error)
+		}
+		if msg.Type == "delta" {
+			wsText.WriteString(msg.Data)
+		}
+		if msg.Type == "done" {
+			break
+		}
+	}
+	if !strings.Contains(wsText.String(), "tidak tersedia dalam data") {
+		t.Errorf("ws: reply %q is not the fixture default", wsText.String())
+	}
+
+	// the conversation now holds all three turns
+	var full conversationResp
+	c.mustJSON("GET", fmt.Sprintf("/conversations/%d", conv.ConversationID), nil, http.StatusOK, &full)
+	if n := len(full.Messages); n != 6 {
+		t.Errorf("conversation: got %d messages, want 6", n)
+	}
+
+	// list (searching message text) and paginate
+	var list []struct {
+		ID            uint  `json:"id"`
+		MessagesCount int64 `json:"messages_count"`
+	}
+	c.mustJSON("GET", "/conversations?q=WISUDA", nil, http.StatusOK, &list)
+	if len(list) != 1 || list[0].ID != conv.ConversationID || list[0].MessagesCount != 6 {
+		t.Errorf("list: got %+v, want conversation %d with 6 messages", list, conv.ConversationID)
+	}
+	type page struct {
+		Messages   []struct{ ID uint } `json:"messages"`
+		HasMore    bool                `json:"has_more"`
+		NextBefore *uint               `json:"next_before"`
+	}
+	var p1, p2 page
+	c.mustJSON("GET", fmt.Sprintf("/conversations/%d/messages?limit=4", conv.ConversationID), nil, http.StatusOK, &p1)
+	if len(p1.Messages) != 4 || !p1.HasMore || p1.NextBefore == nil || *p1.NextBefore != p1.Messages[0].ID {
+		t.Fatalf("messages page 1: %+v", p1)
+	}
+	c.mustJSON("GET", fmt.Sprintf("/conversations/%d/messages?limit=4&before=%d", conv.ConversationID, *p1.NextBefore), nil, http.StatusOK, &p2)
+	if len(p2.Messages) != 2 || p2.HasMore || p2.Messages[1].ID >= p1.Messages[0].ID {
+		t.Errorf("messages page 2: %+v", p2)
+	}
+
+	// a reply cut short is finished in place by /continue
+	var last models.Message
+	db.Where("conversation_id = ? AND sender = ?", conv.ConversationID, "bot").Order("id DESC").First(&last)
+	c.mustJSON("POST", fmt.Sprintf("/conversations/%d/continue", conv.ConversationID), nil, http.StatusConflict, nil)
+	db.Model(&last).Updates(map[string]any{"text": "Wisuda UIB dijadwalkan", "status": models.MessageStopped})
+	var cont struct {
+		Message struct {
+			ID     uint   `json:"id"`
+			Text   string `json:"text"`
+			Status string `json:"status"`
+		} `json:"message"`
+	}
+	c.mustJSON("POST", fmt.Sprintf("/conversations/%d/continue", conv.ConversationID), nil, http.StatusOK, &cont)
+	if cont.Message.ID != last.ID || cont.Message.Status != models.MessageCompleted ||
+		!strings.HasPrefix(cont.Message.Text, "Wisuda UIB dijadwalkan ") || len(cont.Message.Text) <= len("Wisuda UIB dijadwalkan ") {
+		t.Errorf("continue: got %+v", cont.Message)
+	}
+
+	// logout revokes the token
+	c.mustJSON("POST", "/logout", nil, http.StatusOK, nil)
+	status, data = c.do("GET", "/conversations", nil)
+	if status != http.StatusUnauthorized {
+		t.Errorf("after logout: GET /conversations = %d, want 401", status)
+	}
+	var revoked struct {
+		Code string `json:"code"`
+		Msg  string `json:"msg"`
+	}
+	if err := json.Unmarshal(data, &revoked); err != nil || revoked.Code != "unauthorized" || revoked.Msg == "" {
+		t.Errorf("after logout: error body %s", data)
+	}
+}
@@ -0,0 +1,56 @@
+package integration
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestAsyncJobPollURL(t *testing.T) {
+	srv, _ := newServer(t)
+	name := fmt.Sprintf("poller%d", time.Now().UnixNano())
+	c := &client{t: t, base: srv.URL}
+	c.mustJSON("POST", "/register", gin.H{"email": name + "@example.com", "username": name, "password": "Secret123!", "confirm_password": "Secret123!"}, http.StatusCreated, nil)
+	var login struct {
+		AccessToken string `json:"access_token"`
+	}
+	c.mustJSON("POST", "/login", gin.H{"email": name + "@example.com", "password": "Secret123!"}, http.StatusOK, &login)
+	c.token = login.AccessToken
+
+	// The legacy alias queues the job too, but the jobs routes only live
+	// under /api/v1, so that is where poll_url points.
+	for i, path := range []string{"/api/v1/conversations?async=1", "/conversations?async=1"} {
+		b, _ := json.Marshal(gin.H{"message": fmt.Sprintf("Ada webinar apa bulan November? %d", i)})
+		req, _ := http.NewRequest("POST", srv.URL+path, bytes.NewReader(b))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer "+c.token)
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var queued struct {
+			PollURL string `json:"poll_url"`
+		}
+		_ = json.NewDecoder(resp.Body).Decode(&queued)
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusAccepted || queued.PollURL == "" {
+			t.Fatalf("%s = %d %+v", path, resp.StatusCode, queued)
+		}
+
+		req, _ = http.NewRequest("GET", srv.URL+queued.PollURL, nil)
+		req.Header.Set("Authorization", "Bearer "+c.token)
+		resp, err = http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			t.Errorf("%s: GET poll_url %s = %d", path, queued.PollURL, resp.StatusCode)
+		}
+	}
+}
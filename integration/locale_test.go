@@ -0,0 +1,69 @@
+package integration
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestLocale(t *testing.T) {
+	srv, _ := newServer(t)
+	name := fmt.Sprintf("locale%d", time.Now().UnixNano())
+	c := &client{t: t, base: srv.URL}
+	c.mustJSON("POST", "/register", gin.H{"email": name + "@example.com", "username": name, "password": "Secret123!", "confirm_password": "Secret123!"}, http.StatusCreated, nil)
+	var login struct {
+		AccessToken string `json:"access_token"`
+	}
+	c.mustJSON("POST", "/login", gin.H{"email": name + "@example.com", "password": "Secret123!"}, http.StatusOK, &login)
+	c.token = login.AccessToken
+
+	// notFound fetches a missing conversation with Accept-Language header.
+	notFound := func(header string) (string, string) {
+		t.Helper()
+		req, _ := http.NewRequest("GET", srv.URL+"/api/v1/conversations/999999", nil)
+		req.Header.Set("Authorization", "Bearer "+c.token)
+		if header != "" {
+			req.Header.Set("Accept-Language", header)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		data, _ := io.ReadAll(resp.Body)
+		var body struct {
+			Message string `json:"message"`
+			Msg     string `json:"msg"`
+		}
+		if err := json.Unmarshal(data, &body); err != nil || resp.StatusCode != http.StatusNotFound || body.Msg != body.Message {
+			t.Fatalf("status %d: %s", resp.StatusCode, data)
+		}
+		return body.Message, resp.Header.Get("Content-Language")
+	}
+
+	if msg, lang := notFound(""); msg != "conversation not found" || lang != "" {
+		t.Fatalf("no preference: %q, Content-Language %q", msg, lang)
+	}
+	if msg, lang := notFound("id-ID,id;q=0.9,en;q=0.8"); msg != "percakapan tidak ditemukan" || lang != "id" {
+		t.Fatalf("id: %q, Content-Language %q", msg, lang)
+	}
+	if msg, lang := notFound("en-US"); msg != "conversation not found" || lang != "en" {
+		t.Fatalf("en: %q, Content-Language %q", msg, lang)
+	}
+
+	// The preferred language wins over the header.
+	c.mustJSON("PUT", "/profile/preferences", gin.H{"language": "fr"}, http.StatusBadRequest, nil)
+	c.mustJSON("PUT", "/profile/preferences", gin.H{"language": "en"}, http.StatusOK, nil)
+	if msg, lang := notFound("id"); msg != "conversation not found" || lang != "en" {
+		t.Fatalf("preferred en over header id: %q, Content-Language %q", msg, lang)
+	}
+	c.mustJSON("PUT", "/profile/preferences", gin.H{"language": ""}, http.StatusOK, nil)
+	if msg, _ := notFound("id"); msg != "percakapan tidak ditemukan" {
+		t.Fatalf("cleared preferred language: %q", msg)
+	}
+}
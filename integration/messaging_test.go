@@ -0,0 +1,300 @@
+package integration
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"AkuAI/models"
+	"AkuAI/pkg/config"
+
+	"github.com/gin-gonic/gin"
+)
+
+// sentMessage is a message the gateway sent through the fake platform API.
+type sentMessage struct {
+	path string
+	body map[string]any
+}
+
+func TestMessagingGateway(t *testing.T) {
+	srv, db := newServer(t)
+
+	sent := make(chan sentMessage, 16)
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]any
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		sent <- sentMessage{path: r.URL.Path, body: body}
+		w.Write([]byte(`{"ok":true}`))
+	}))
+	defer api.Close()
+	config.TelegramBotToken, config.TelegramWebhookSecret, config.TelegramAPIBase = "tg-token", "tg-secret", api.URL
+	config.WhatsAppToken, config.WhatsAppPhoneNumberID, config.WhatsAppVerifyToken, config.WhatsAppAppSecret, config.WhatsAppAPIBase =
+		"wa-token", "1001", "wa-verify", "wa-app-secret", api.URL
+	t.Cleanup(func() {
+		config.TelegramBotToken, config.TelegramWebhookSecret, config.TelegramAPIBase = "", "", ""
+		config.WhatsAppToken, config.WhatsAppPhoneNumberID, config.WhatsAppVerifyToken, config.WhatsAppAppSecret, config.WhatsAppAPIBase = "", "", "", "", ""
+	})
+
+	post := func(path string, body []byte, header, value string) int {
+		req, _ := http.NewRequest(http.MethodPost, srv.URL+"/api/v1"+path, bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set(header, value)
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+	telegram := func(text string) string {
+		t.Helper()
+		update, _ := json.Marshal(gin.H{"update_id": 1, "message": gin.H{"text": text, "chat": gin.H{"id": 4242}, "from": gin.H{"first_name": "Budi"}}})
+		if status := post("/messaging/telegram/webhook", update, "X-Telegram-Bot-Api-Secret-Token", "tg-secret"); status != http.StatusOK {
+			t.Fatalf("telegram update %q = %d", text, status)
+		}
+		select {
+		case m := <-sent:
+			if m.path != "/bottg-token/sendMessage" || m.body["chat_id"] != "4242" || m.body["parse_mode"] != "HTML" {
+				t.Fatalf("unexpected send %s %v", m.path, m.body)
+			}
+			return m.body["text"].(string)
+		case <-time.After(10 * time.Second):
+			t.Fatalf("no reply to %q", text)
+		}
+		return ""
+	}
+
+	if status := post("/messaging/telegram/webhook", []byte(`{}`), "X-Telegram-Bot-Api-Secret-Token", "wrong"); status != http.StatusUnauthorized {
+		t.Fatalf("wrong secret token = %d, want 401", status)
+	}
+	config.TelegramWebhookSecret = ""
+	if status := post("/messaging/telegram/webhook", []byte(`{}`), "X-Telegram-Bot-Api-Secret-Token", ""); status != http.StatusUnauthorized {
+		t.Fatalf("update without a configured secret = %d, want 401", status)
+	}
+	config.TelegramWebhookSecret = "tg-secret"
+	if reply := telegram("halo"); !strings.Contains(reply, "belum terhubung") {
+		t.Fatalf("unlinked chat got %q", reply)
+	}
+	if reply := telegram("/events november"); !strings.Contains(reply, "<b>Acara november</b>") || !strings.Contains(reply, "1. <b>") {
+		t.Fatalf("/events november = %q", reply)
+	}
+
+	c := &client{t: t, base: srv.URL}
+	name := fmt.Sprintf("chat%d", time.Now().UnixNano())
+	c.mustJSON("POST", "/register", gin.H{"email": name + "@example.com", "username": name, "password": "Secret123!", "confirm_password": "Secret123!"}, http.StatusCreated, nil)
+	var login struct {
+		AccessToken string `json:"access_token"`
+	}
+	c.mustJSON("POST", "/login", gin.H{"email": name + "@example.com", "password": "Secret123!"}, http.StatusOK, &login)
+	c.token = login.AccessToken
+	var code struct {
+		Code string `json:"code"`
+	}
+	c.mustJSON("POST", "/messaging/link-code", nil, http.StatusCreated, &code)
+
+	if reply := telegram("/link nope"); !strings.Contains(reply, "tidak valid") {
+		t.Fatalf("bad code got %q", reply)
+	}
+	if reply := telegram("/link " + strings.ToLower(code.Code)); !strings.Contains(reply, name) {
+		t.Fatalf("/link got %q", reply)
+	}
+	if reply := telegram("/link " + code.Code); !strings.Contains(reply, "tidak valid") {
+		t.Fatalf("code reused: %q", reply)
+	}
+	var links struct {
+		Links []struct {
+			ID       uint   `json:"id"`
+			Platform string `json:"platform"`
+			ChatID   string `json:"chat_id"`
+		} `json:"links"`
+	}
+	c.mustJSON("GET", "/messaging/links", nil, http.StatusOK, &links)
+	if len(links.Links) != 1 || links.Links[0].Platform != "telegram" || links.Links[0].ChatID != "4242" {
+		t.Fatalf("links = %+v", links.Links)
+	}
+
+	if reply := telegram("Apa saja webinar UIB bulan November?"); reply == "" {
+		t.Fatal("empty reply")
+	}
+	telegram("Ada yang gratis?")
+	var link models.ChatLink
+	db.First(&link, links.Links[0].ID)
+	var count int64
+	if link.ConversationID == nil {
+		t.Fatal("chat has no conversation")
+	}
+	db.Model(&models.Message{}).Where("conversation_id = ?", *link.ConversationID).Count(&count)
+	if count != 4 {
+		t.Fatalf("conversation has %d messages, want 4", count)
+	}
+
+	// WhatsApp: subscription check, signature and a linked-chat reply.
+	resp, err := http.Get(srv.URL + "/api/v1/messaging/whatsapp/webhook?hub.mode=subscribe&hub.verify_token=wa-verify&hub.challenge=abc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	challenge, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || string(challenge) != "abc" {
+		t.Fatalf("subscription check = %d %q", resp.StatusCode, challenge)
+	}
+	c.mustJSON("POST", "/messaging/link-code", nil, http.StatusCreated, &code)
+	update, _ := json.Marshal(gin.H{"entry": []gin.H{{"changes": []gin.H{{"value": gin.H{
+		"contacts": []gin.H{{"wa_id": "6281234", "profile": gin.H{"name": "Budi"}}},
+		"messages": []gin.H{{"from": "6281234", "type": "text", "text": gin.H{"body": "/link " + code.Code}}},
+	}}}}}})
+	if status := post("/messaging/whatsapp/webhook", update, "X-Hub-Signature-256", "sha256=00"); status != http.StatusUnauthorized {
+		t.Fatalf("bad signature = %d, want 401", status)
+	}
+	mac := hmac.New(sha256.New, []byte("wa-app-secret"))
+	mac.Write(update)
+	if status := post("/messaging/whatsapp/webhook", update, "X-Hub-Signature-256", "sha256="+hex.EncodeToString(mac.Sum(nil))); status != http.StatusOK {
+		t.Fatalf("whatsapp update = %d", status)
+	}
+	select {
+	case m := <-sent:
+		text, _ := m.body["text"].(map[string]any)
+		if m.path != "/1001/messages" || m.body["to"] != "6281234" || !strings.Contains(fmt.Sprint(text["body"]), "*"+name+"*") {
+			t.Fatalf("unexpected WhatsApp send %s %v", m.path, m.body)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("no WhatsApp reply")
+	}
+
+	c.mustJSON("DELETE", fmt.Sprintf("/messaging/links/%d", links.Links[0].ID), nil, http.StatusOK, nil)
+	if reply := telegram("halo lagi"); !strings.Contains(reply, "belum terhubung") {
+		t.Fatalf("unlinked chat got %q", reply)
+	}
+}
+
+func TestStaffSlashCommands(t *testing.T) {
+	srv, _ := newServer(t)
+
+	sent := make(chan sentMessage, 4)
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]any
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		sent <- sentMessage{path: r.Method + " " + r.URL.Path, body: body}
+		w.Write([]byte(`{}`))
+	}))
+	defer api.Close()
+	pub, priv, _ := ed25519.GenerateKey(nil)
+	config.SlackSigningSecret, config.SlackAllowedChannels = "slack-secret", []string{"CSTAFF"}
+	config.DiscordApplicationID, config.DiscordPublicKey, config.DiscordAPIBase = "app1", hex.EncodeToString(pub), api.URL
+	t.Cleanup(func() {
+		config.SlackSigningSecret, config.SlackAllowedChannels = "", nil
+		config.DiscordApplicationID, config.DiscordPublicKey, config.DiscordAPIBase = "", "", ""
+	})
+	waitSent := func() sentMessage {
+		t.Helper()
+		select {
+		case m := <-sent:
+			return m
+		case <-time.After(10 * time.Second):
+			t.Fatal("no deferred answer")
+		}
+		return sentMessage{}
+	}
+
+	slack := func(channel, text string, sign bool) (int, map[string]any) {
+		t.Helper()
+		body := url.Values{"command": {"/uib"}, "text": {text}, "channel_id": {channel}, "user_name": {"staf"},
+			"response_url": {api.URL + "/slack/response"}}.Encode()
+		ts := strconv.FormatInt(time.Now().Unix(), 10)
+		mac := hmac.New(sha256.New, []byte("slack-secret"))
+		mac.Write([]byte("v0:" + ts + ":" + body))
+		sig := "v0=" + hex.EncodeToString(mac.Sum(nil))
+		if !sign {
+			sig = "v0=00"
+		}
+		req, _ := http.NewRequest(http.MethodPost, srv.URL+"/api/v1/messaging/slack/command", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		req.Header.Set("X-Slack-Request-Timestamp", ts)
+		req.Header.Set("X-Slack-Signature", sig)
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		var out map[string]any
+		_ = json.NewDecoder(resp.Body).Decode(&out)
+		return resp.StatusCode, out
+	}
+
+	if status, _ := slack("CSTAFF", "events november", false); status != http.StatusUnauthorized {
+		t.Fatalf("unsigned Slack command = %d, want 401", status)
+	}
+	if _, out := slack("CRANDOM", "events november", true); out["response_type"] != "ephemeral" {
+		t.Fatalf("command outside staff channels = %v", out)
+	}
+	_, out := slack("CSTAFF", "events november", true)
+	if text, _ := out["text"].(string); out["response_type"] != "in_channel" || !strings.Contains(text, "*Acara november*") || !strings.Contains(text, "1. *") {
+		t.Fatalf("/uib events november = %v", out)
+	}
+	if _, out := slack("CSTAFF", "ask Apa saja webinar UIB bulan November?", true); out["response_type"] != "ephemeral" {
+		t.Fatalf("/uib ask acknowledgement = %v", out)
+	}
+	if m := waitSent(); m.path != "POST /slack/response" || m.body["response_type"] != "in_channel" || !strings.Contains(fmt.Sprint(m.body["text"]), "webinar") {
+		t.Fatalf("Slack answer = %s %v", m.path, m.body)
+	}
+
+	discord := func(interaction any) (int, map[string]any) {
+		t.Helper()
+		body, _ := json.Marshal(interaction)
+		ts := strconv.FormatInt(time.Now().Unix(), 10)
+		req, _ := http.NewRequest(http.MethodPost, srv.URL+"/api/v1/messaging/discord/interactions", bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-Signature-Timestamp", ts)
+		req.Header.Set("X-Signature-Ed25519", hex.EncodeToString(ed25519.Sign(priv, append([]byte(ts), body...))))
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		var out map[string]any
+		_ = json.NewDecoder(resp.Body).Decode(&out)
+		return resp.StatusCode, out
+	}
+	if status, out := discord(gin.H{"type": 1}); status != http.StatusOK || out["type"] != float64(1) {
+		t.Fatalf("PING = %d %v", status, out)
+	}
+	_, out = discord(gin.H{"type": 2, "token": "itok", "channel_id": "D1", "data": gin.H{"name": "uib", "options": []gin.H{
+		{"name": "events", "type": 1, "options": []gin.H{{"name": "month", "type": 3, "value": "november"}}}}}})
+	data, _ := out["data"].(map[string]any)
+	if out["type"] != float64(4) || !strings.Contains(fmt.Sprint(data["content"]), "**Acara november**") {
+		t.Fatalf("/uib events month:november = %v", out)
+	}
+	_, out = discord(gin.H{"type": 2, "token": "itok", "channel_id": "D1", "data": gin.H{"name": "uib", "options": []gin.H{
+		{"name": "ask", "type": 1, "options": []gin.H{{"name": "question", "type": 3, "value": "Apa saja webinar UIB bulan November?"}}}}}})
+	if out["type"] != float64(5) {
+		t.Fatalf("/uib ask = %v, want a deferred response", out)
+	}
+	if m := waitSent(); m.path != "PATCH /webhooks/app1/itok/messages/@original" || m.body["content"] == "" {
+		t.Fatalf("Discord answer = %s %v", m.path, m.body)
+	}
+
+	req, _ := http.NewRequest(http.MethodPost, srv.URL+"/api/v1/messaging/discord/interactions", strings.NewReader(`{"type":1}`))
+	req.Header.Set("X-Signature-Timestamp", "1")
+	req.Header.Set("X-Signature-Ed25519", strings.Repeat("00", 64))
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("bad Discord signature = %d, want 401", resp.StatusCode)
+	}
+}
@@ -0,0 +1,94 @@
+package integration
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestReplyPreferences(t *testing.T) {
+	srv, _ := newServer(t)
+	name := fmt.Sprintf("prefs%d", time.Now().UnixNano())
+	c := &client{t: t, base: srv.URL}
+	c.mustJSON("POST", "/register", gin.H{"email": name + "@example.com", "username": name, "password": "Secret123!", "confirm_password": "Secret123!"}, http.StatusCreated, nil)
+	var login struct {
+		AccessToken string `json:"access_token"`
+	}
+	c.mustJSON("POST", "/login", gin.H{"email": name + "@example.com", "password": "Secret123!"}, http.StatusOK, &login)
+	c.token = login.AccessToken
+
+	type prefs struct {
+		Language  string `json:"language"`
+		Verbosity string `json:"verbosity"`
+		Markdown  bool   `json:"markdown"`
+		Emoji     bool   `json:"emoji"`
+	}
+	var p prefs
+	c.mustJSON("GET", "/profile/preferences", nil, http.StatusOK, &p)
+	if p != (prefs{Verbosity: "normal", Markdown: true, Emoji: true}) {
+		t.Fatalf("defaults = %+v", p)
+	}
+	c.mustJSON("PUT", "/profile/preferences", gin.H{"verbosity": "long"}, http.StatusBadRequest, nil)
+	c.mustJSON("PUT", "/profile/preferences", gin.H{"language": "de"}, http.StatusBadRequest, nil)
+
+	var conv conversationResp
+	c.mustJSON("POST", "/conversations", gin.H{"message": "Ada acara minggu ini?"}, http.StatusCreated, &conv)
+	if bot := conv.Messages[1].Text; !strings.Contains(bot, "**Webinar") || !strings.Contains(bot, "📅") {
+		t.Fatalf("default reply lost its formatting: %q", bot)
+	}
+
+	c.mustJSON("PUT", "/profile/preferences", gin.H{"verbosity": "short", "markdown": false, "emoji": false}, http.StatusOK, &p)
+	if p != (prefs{Verbosity: "short"}) {
+		t.Fatalf("updated = %+v", p)
+	}
+	c.mustJSON("POST", "/conversations", gin.H{"message": "Apa saja acara minggu ini?"}, http.StatusCreated, &conv)
+	bot := conv.Messages[1].Text
+	for _, s := range []string{"**", "##", "📅", "🎉", "]("} {
+		if strings.Contains(bot, s) {
+			t.Fatalf("plain reply still has %q: %q", s, bot)
+		}
+	}
+	if !strings.Contains(bot, "Webinar Transformasi Digital") || !strings.Contains(bot, "situs UIB (https://uib.ac.id)") {
+		t.Fatalf("plain reply = %q", bot)
+	}
+}
+
+func TestVerbosityAndSummary(t *testing.T) {
+	srv, _ := newServer(t)
+	name := fmt.Sprintf("ringkas%d", time.Now().UnixNano())
+	c := &client{t: t, base: srv.URL}
+	c.mustJSON("POST", "/register", gin.H{"email": name + "@example.com", "username": name, "password": "Secret123!", "confirm_password": "Secret123!"}, http.StatusCreated, nil)
+	var login struct {
+		AccessToken string `json:"access_token"`
+	}
+	c.mustJSON("POST", "/login", gin.H{"email": name + "@example.com", "password": "Secret123!"}, http.StatusOK, &login)
+	c.token = login.AccessToken
+
+	c.mustJSON("POST", "/conversations", gin.H{"message": "Ada webinar november?", "verbosity": "panjang"}, http.StatusBadRequest, nil)
+	var conv conversationResp
+	c.mustJSON("POST", "/conversations", gin.H{"message": "Ada webinar november?", "verbosity": "lengkap"}, http.StatusCreated, &conv)
+
+	c.mustJSON("POST", "/conversations/999999/summarize", nil, http.StatusNotFound, nil)
+	var out struct {
+		ConversationID      uint `json:"conversation_id"`
+		SummarizedMessageID uint `json:"summarized_message_id"`
+		Message             struct {
+			Sender string `json:"sender"`
+			Text   string `json:"text"`
+		} `json:"message"`
+	}
+	path := fmt.Sprintf("/conversations/%d/summarize", conv.ConversationID)
+	c.mustJSON("POST", path, nil, http.StatusCreated, &out)
+	if out.SummarizedMessageID == 0 || out.Message.Sender != "bot" || !strings.HasPrefix(out.Message.Text, "Ringkasnya:") {
+		t.Fatalf("summary = %+v", out)
+	}
+	var after conversationResp
+	c.mustJSON("GET", fmt.Sprintf("/conversations/%d", conv.ConversationID), nil, http.StatusOK, &after)
+	if n := len(after.Messages); n != 4 || after.Messages[2].Text != "Ringkas jawaban terakhir." {
+		t.Fatalf("conversation after summary: %d messages %+v", n, after.Messages)
+	}
+}
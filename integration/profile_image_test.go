@@ -0,0 +1,96 @@
+package integration
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestProfileImageSignedURLs(t *testing.T) {
+	srv, _ := newServer(t)
+	c := &client{t: t, base: srv.URL}
+	name := fmt.Sprintf("avatar%d", time.Now().UnixNano())
+	c.mustJSON("POST", "/register", gin.H{"email": name + "@example.com", "username": name, "password": "Secret123!", "confirm_password": "Secret123!"}, http.StatusCreated, nil)
+	var login struct {
+		AccessToken string `json:"access_token"`
+	}
+	c.mustJSON("POST", "/login", gin.H{"email": name + "@example.com", "password": "Secret123!"}, http.StatusOK, &login)
+	c.token = login.AccessToken
+
+	var token struct {
+		UploadToken string `json:"upload_token"`
+	}
+	c.mustJSON("POST", "/profile/image/token", gin.H{"file_extension": ".png"}, http.StatusOK, &token)
+	var form bytes.Buffer
+	mw := multipart.NewWriter(&form)
+	mw.WriteField("upload_token", token.UploadToken)
+	part, _ := mw.CreateFormFile("image", "me.png")
+	part.Write(append([]byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR"), make([]byte, 64)...))
+	mw.Close()
+	req, _ := http.NewRequest("POST", srv.URL+"/api/v1/profile/image/upload", &form)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	req.Header.Set("Authorization", "Bearer "+c.token)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("upload = %d", resp.StatusCode)
+	}
+
+	var img struct {
+		ImageURL  string     `json:"image_url"`
+		ExpiresAt *time.Time `json:"expires_at"`
+		Public    bool       `json:"public"`
+	}
+	c.mustJSON("GET", "/profile/image", nil, http.StatusOK, &img)
+	if img.Public || img.ExpiresAt == nil {
+		t.Fatalf("new image = %+v, want private", img)
+	}
+	u, err := url.Parse(img.ImageURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(filepath.Join("uploads", "profiles", filepath.Base(filepath.Dir(u.Path)))) })
+
+	get := func(rawURL string) int {
+		t.Helper()
+		resp, err := http.Get(rawURL)
+		if err != nil {
+			t.Fatal(err)
+		}
+		io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+	if status := get(srv.URL + u.RequestURI()); status != http.StatusOK {
+		t.Fatalf("signed url = %d", status)
+	}
+	if status := get(srv.URL + u.Path); status != http.StatusForbidden {
+		t.Fatalf("unsigned private url = %d", status)
+	}
+	q := u.Query()
+	q.Set("expires", strconv.FormatInt(time.Now().Add(24*time.Hour).Unix(), 10))
+	if status := get(srv.URL + u.Path + "?" + q.Encode()); status != http.StatusForbidden {
+		t.Fatalf("tampered url = %d", status)
+	}
+
+	c.mustJSON("PUT", "/profile/image/visibility", gin.H{"public": true}, http.StatusOK, &img)
+	if !img.Public || img.ExpiresAt != nil {
+		t.Fatalf("public image = %+v", img)
+	}
+	if status := get(srv.URL + u.Path); status != http.StatusOK {
+		t.Fatalf("public url = %d", status)
+	}
+}
@@ -0,0 +1,84 @@
+package integration
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"AkuAI/pkg/prompts"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestPromptVersions(t *testing.T) {
+	srv, db := newServer(t)
+	prompts.Init(db)
+
+	signIn := func(name string) *client {
+		c := &client{t: t, base: srv.URL}
+		c.mustJSON("POST", "/register", gin.H{"email": name + "@example.com", "username": name, "password": "Secret123!", "confirm_password": "Secret123!"}, http.StatusCreated, nil)
+		var login struct {
+			AccessToken string `json:"access_token"`
+		}
+		c.mustJSON("POST", "/login", gin.H{"email": name + "@example.com", "password": "Secret123!"}, http.StatusOK, &login)
+		c.token = login.AccessToken
+		return c
+	}
+	suffix := time.Now().UnixNano()
+	user := signIn(fmt.Sprintf("promptuser%d", suffix))
+	adminName := fmt.Sprintf("promptadmin%d", suffix)
+	admin := signIn(adminName)
+	db.Exec("UPDATE users SET is_admin = ? WHERE username = ?", true, adminName)
+
+	type version struct {
+		Version int      `json:"version"`
+		Tone    string   `json:"tone"`
+		Rules   []string `json:"rules"`
+		Note    string   `json:"note"`
+	}
+	type state struct {
+		Campus   string         `json:"campus"`
+		Current  *version       `json:"current"`
+		Defaults prompts.Blocks `json:"defaults"`
+		History  []version      `json:"history"`
+		Preview  string         `json:"preview"`
+	}
+	var before state
+	admin.mustJSON("GET", "/admin/prompts?campus=UIB", nil, http.StatusOK, &before)
+	if before.Current != nil || before.Defaults.Tone == "" || !strings.Contains(before.Preview, "INSTRUKSI PENTING:") {
+		t.Fatalf("before any save = %+v", before)
+	}
+
+	var saved struct {
+		Prompt version `json:"prompt"`
+	}
+	admin.mustJSON("PUT", "/admin/prompts?campus=UIB", gin.H{"tone": "Kamu adalah asisten kampus yang santai.", "rules": []string{" Jawab singkat ", ""}, "note": "santai"}, http.StatusCreated, &saved)
+	if saved.Prompt.Version != 1 || len(saved.Prompt.Rules) != 1 || saved.Prompt.Rules[0] != "Jawab singkat" {
+		t.Fatalf("first save = %+v", saved.Prompt)
+	}
+	var after state
+	admin.mustJSON("GET", "/admin/prompts?campus=UIB", nil, http.StatusOK, &after)
+	if after.Current == nil || after.Current.Version != 1 ||
+		!strings.Contains(after.Preview, "Kamu adalah asisten kampus yang santai.") || !strings.Contains(after.Preview, "1. Jawab singkat") {
+		t.Fatalf("after saving = %+v", after)
+	}
+
+	admin.mustJSON("PUT", "/admin/prompts?campus=UIB", gin.H{}, http.StatusCreated, &saved)
+	if saved.Prompt.Version != 2 {
+		t.Fatalf("second save = %+v", saved.Prompt)
+	}
+	admin.mustJSON("POST", "/admin/prompts/versions/1/restore?campus=UIB", nil, http.StatusCreated, &saved)
+	if saved.Prompt.Version != 3 || saved.Prompt.Tone != "Kamu adalah asisten kampus yang santai." || saved.Prompt.Note != "restored version 1" {
+		t.Fatalf("restore = %+v", saved.Prompt)
+	}
+	admin.mustJSON("GET", "/admin/prompts?campus=UIB", nil, http.StatusOK, &after)
+	if len(after.History) != 3 || after.History[0].Version != 3 {
+		t.Fatalf("history = %+v", after.History)
+	}
+
+	admin.mustJSON("POST", "/admin/prompts/versions/9/restore?campus=UIB", nil, http.StatusNotFound, nil)
+	admin.mustJSON("GET", "/admin/prompts?campus=Atlantis", nil, http.StatusNotFound, nil)
+	user.mustJSON("GET", "/admin/prompts", nil, http.StatusForbidden, nil)
+}
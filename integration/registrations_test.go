@@ -0,0 +1,152 @@
+package integration
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"AkuAI/pkg/config"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestRegistrationWaitingList(t *testing.T) {
+	srv, db := newServer(t)
+	defaultCapacity := config.EventDefaultCapacity
+	config.EventDefaultCapacity = 1
+	t.Cleanup(func() { config.EventDefaultCapacity = defaultCapacity })
+
+	signIn := func(name string) *client {
+		c := &client{t: t, base: srv.URL}
+		c.mustJSON("POST", "/register", gin.H{"email": name + "@example.com", "username": name, "password": "Secret123!", "confirm_password": "Secret123!"}, http.StatusCreated, nil)
+		var login struct {
+			AccessToken string `json:"access_token"`
+		}
+		c.mustJSON("POST", "/login", gin.H{"email": name + "@example.com", "password": "Secret123!"}, http.StatusOK, &login)
+		c.token = login.AccessToken
+		return c
+	}
+	suffix := time.Now().UnixNano()
+	first := signIn(fmt.Sprintf("seat%d", suffix))
+	second := signIn(fmt.Sprintf("queue%d", suffix))
+	adminName := fmt.Sprintf("rosteradmin%d", suffix)
+	admin := signIn(adminName)
+	db.Exec("UPDATE users SET is_admin = ? WHERE username = ?", true, adminName)
+
+	const path = "/uib/events/uib_cert_oct_001/register"
+	type regResp struct {
+		Registration struct {
+			Status   string `json:"status"`
+			Position int    `json:"position"`
+		} `json:"registration"`
+		Promoted int `json:"promoted"`
+		Seats    struct {
+			Confirmed  int64 `json:"confirmed"`
+			Waitlisted int64 `json:"waitlisted"`
+		} `json:"seats"`
+	}
+	var reg regResp
+	first.mustJSON("POST", path, nil, http.StatusCreated, &reg)
+	if reg.Registration.Status != "confirmed" {
+		t.Fatalf("first registration = %+v", reg)
+	}
+	first.mustJSON("POST", path, nil, http.StatusOK, &reg)
+	second.mustJSON("POST", path, nil, http.StatusCreated, &reg)
+	if reg.Registration.Status != "waitlisted" || reg.Registration.Position != 1 || reg.Seats.Waitlisted != 1 {
+		t.Fatalf("second registration = %+v", reg)
+	}
+	second.mustJSON("POST", "/uib/events/tidak_ada/register", nil, http.StatusNotFound, nil)
+
+	var roster struct {
+		Confirmed  []struct{ Username string } `json:"confirmed"`
+		Waitlisted []struct {
+			Username string `json:"username"`
+			Position int    `json:"position"`
+		} `json:"waitlisted"`
+	}
+	first.mustJSON("GET", "/uib/events/uib_cert_oct_001/roster", nil, http.StatusForbidden, nil)
+	admin.mustJSON("GET", "/uib/events/uib_cert_oct_001/roster", nil, http.StatusOK, &roster)
+	if len(roster.Confirmed) != 1 || len(roster.Waitlisted) != 1 || roster.Waitlisted[0].Position != 1 {
+		t.Fatalf("roster = %+v", roster)
+	}
+
+	first.mustJSON("DELETE", path, nil, http.StatusOK, &reg)
+	if reg.Registration.Status != "cancelled" || reg.Promoted != 1 {
+		t.Fatalf("cancel = %+v", reg)
+	}
+	first.mustJSON("DELETE", path, nil, http.StatusNotFound, nil)
+	second.mustJSON("GET", path, nil, http.StatusOK, &reg)
+	if reg.Registration.Status != "confirmed" || reg.Registration.Position != 0 {
+		t.Fatalf("promoted registration = %+v", reg)
+	}
+
+	var mine struct {
+		Registrations []struct {
+			EventID string `json:"event_id"`
+		} `json:"registrations"`
+	}
+	first.mustJSON("GET", "/uib/registrations", nil, http.StatusOK, &mine)
+	if len(mine.Registrations) != 0 {
+		t.Fatalf("active registrations after cancel = %+v", mine)
+	}
+	first.mustJSON("GET", "/uib/registrations?all=true", nil, http.StatusOK, &mine)
+	if len(mine.Registrations) != 1 || mine.Registrations[0].EventID != "uib_cert_oct_001" {
+		t.Fatalf("all registrations = %+v", mine)
+	}
+
+	first.mustJSON("POST", path, nil, http.StatusCreated, &reg)
+	if reg.Registration.Status != "waitlisted" {
+		t.Fatalf("re-registration = %+v", reg)
+	}
+
+	var held struct {
+		Registration struct {
+			ID uint `json:"id"`
+		} `json:"registration"`
+	}
+	second.mustJSON("GET", path, nil, http.StatusOK, &held)
+	certPath := fmt.Sprintf("/uib/registrations/%d/certificate", held.Registration.ID)
+	second.mustJSON("GET", certPath, nil, http.StatusConflict, nil)
+	checkIn := fmt.Sprintf("/uib/registrations/%d/check-in", held.Registration.ID)
+	second.mustJSON("POST", checkIn, nil, http.StatusForbidden, nil)
+	admin.mustJSON("POST", checkIn, nil, http.StatusOK, nil)
+	first.mustJSON("GET", certPath, nil, http.StatusNotFound, nil)
+	var cert struct {
+		URL string `json:"url"`
+	}
+	second.mustJSON("GET", certPath, nil, http.StatusOK, &cert)
+	var holder uint
+	db.Raw("SELECT user_id FROM event_registrations WHERE id = ?", held.Registration.ID).Scan(&holder)
+	t.Cleanup(func() { os.RemoveAll(filepath.Join("storage", "certificates", strconv.Itoa(int(holder)))) })
+	u, err := url.Parse(cert.URL)
+	if err != nil {
+		t.Fatalf("certificate url %q: %v", cert.URL, err)
+	}
+	resp, err := http.Get(srv.URL + u.RequestURI())
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || !bytes.HasPrefix(body, []byte("%PDF")) {
+		t.Fatalf("certificate download = %d %.40q", resp.StatusCode, body)
+	}
+	q := u.Query()
+	q.Set("expires", strconv.FormatInt(time.Now().Add(time.Hour).Unix(), 10))
+	u.RawQuery = q.Encode()
+	resp, err = http.Get(srv.URL + u.RequestURI())
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusForbidden {
+		t.Fatalf("tampered certificate url = %d", resp.StatusCode)
+	}
+}
@@ -0,0 +1,77 @@
+package integration
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"AkuAI/models"
+	"AkuAI/pkg/retention"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestRetentionPurgeDependents(t *testing.T) {
+	srv, db := newServer(t)
+	name := fmt.Sprintf("retention%d", time.Now().UnixNano())
+	c := &client{t: t, base: srv.URL}
+	c.mustJSON("POST", "/register", gin.H{"email": name + "@example.com", "username": name, "password": "Secret123!", "confirm_password": "Secret123!"}, http.StatusCreated, nil)
+	var login struct {
+		AccessToken string `json:"access_token"`
+	}
+	c.mustJSON("POST", "/login", gin.H{"email": name + "@example.com", "password": "Secret123!"}, http.StatusOK, &login)
+	c.token = login.AccessToken
+
+	var conv struct {
+		ConversationID uint `json:"conversation_id"`
+		Messages       []struct {
+			ID uint `json:"id"`
+		} `json:"messages"`
+	}
+	c.mustJSON("POST", "/conversations", gin.H{"message": "Kapan pendaftaran wisuda dibuka?"}, http.StatusCreated, &conv)
+	if len(conv.Messages) != 2 {
+		t.Fatalf("conversation: %+v", conv)
+	}
+	var user models.User
+	if err := db.Where("username = ?", name).First(&user).Error; err != nil {
+		t.Fatal(err)
+	}
+	question, reply := conv.Messages[0].ID, conv.Messages[1].ID
+
+	link := models.ChatLink{Platform: "telegram", ExternalID: name, UserID: user.ID, ConversationID: &conv.ConversationID}
+	memory := models.UserMemory{UserID: user.ID, Kind: models.MemoryInterest, Value: name, SourceMessageID: question}
+	for _, row := range []any{
+		&models.MessageBookmark{UserID: user.ID, MessageID: reply},
+		&models.MessageReaction{UserID: user.ID, MessageID: reply, Emoji: "👍"},
+		&models.MessageCitation{MessageID: reply, Marker: "[EV-1]", Line: 1},
+		&memory,
+		&link,
+	} {
+		if err := db.Create(row).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	db.Model(&models.Conversation{}).Where("id = ?", conv.ConversationID).Update("incognito", true)
+	if n, err := retention.PurgeIncognito(db, user.ID); err != nil || n != 1 {
+		t.Fatalf("purge: n=%d err=%v", n, err)
+	}
+
+	ids := []uint{question, reply}
+	for _, model := range []any{&models.MessageBookmark{}, &models.MessageReaction{}, &models.MessageCitation{}} {
+		var left int64
+		db.Model(model).Where("message_id IN ?", ids).Count(&left)
+		if left != 0 {
+			t.Errorf("%T of purged messages kept: %d", model, left)
+		}
+	}
+	db.First(&memory, memory.ID)
+	if memory.SourceMessageID != 0 {
+		t.Errorf("memory still points at purged message %d", memory.SourceMessageID)
+	}
+	db.First(&link, link.ID)
+	if link.ConversationID != nil {
+		t.Errorf("chat link still points at purged conversation %d", *link.ConversationID)
+	}
+}
@@ -0,0 +1,91 @@
+package integration
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"AkuAI/middleware"
+	"AkuAI/models"
+	"AkuAI/pkg/apierror"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestSlotBusy(t *testing.T) {
+	srv, db := newServer(t)
+	_, queueLen, maxWait := middleware.SlotQueueConfig()
+	middleware.SetSlotQueueConfig(0, 10*time.Millisecond)
+	t.Cleanup(func() { middleware.SetSlotQueueConfig(queueLen, maxWait) })
+
+	name := fmt.Sprintf("busy%d", time.Now().UnixNano())
+	c := &client{t: t, base: srv.URL}
+	c.mustJSON("POST", "/register", gin.H{"email": name + "@example.com", "username": name, "password": "Secret123!", "confirm_password": "Secret123!"}, http.StatusCreated, nil)
+	var login struct {
+		AccessToken string `json:"access_token"`
+	}
+	c.mustJSON("POST", "/login", gin.H{"email": name + "@example.com", "password": "Secret123!"}, http.StatusOK, &login)
+	c.token = login.AccessToken
+	var user models.User
+	if err := db.Where("username = ?", name).First(&user).Error; err != nil {
+		t.Fatal(err)
+	}
+	uid := strconv.FormatUint(uint64(user.ID), 10)
+
+	// Take every slot of the user, as other requests in flight would.
+	var held []func()
+	for {
+		release, ok := middleware.AcquireUserSlotNow(uid)
+		if !ok {
+			break
+		}
+		held = append(held, release)
+	}
+	releaseAll := func() {
+		for _, release := range held {
+			release()
+		}
+		held = nil
+	}
+	t.Cleanup(releaseAll)
+
+	message := gin.H{"message": "Ada lomba apa bulan Desember?"}
+	var busy apierror.Response
+	c.mustJSON("POST", "/conversations", message, http.StatusTooManyRequests, &busy)
+	if busy.Code != apierror.CodeRateLimited || busy.Details["queue_position"] == nil {
+		t.Fatalf("429 body = %+v", busy)
+	}
+	var n int64
+	db.Model(&models.Message{}).Joins("JOIN conversations ON conversations.id = messages.conversation_id").
+		Where("conversations.user_id = ?", user.ID).Count(&n)
+	if n != 0 {
+		t.Fatalf("a 429 left %d messages behind", n)
+	}
+
+	// An async job waits in the queue without holding a worker, and runs
+	// once a slot frees up.
+	var queued struct {
+		JobID string `json:"job_id"`
+	}
+	c.mustJSON("POST", "/conversations?async=1", gin.H{"message": "Ada webinar apa bulan November?"}, http.StatusAccepted, &queued)
+	time.Sleep(300 * time.Millisecond)
+	var job struct {
+		Status string `json:"status"`
+	}
+	c.mustJSON("GET", "/jobs/"+queued.JobID, nil, http.StatusOK, &job)
+	if job.Status != "queued" {
+		t.Fatalf("job status while the user is busy = %q", job.Status)
+	}
+	releaseAll()
+	for deadline := time.Now().Add(5 * time.Second); job.Status != "done"; time.Sleep(50 * time.Millisecond) {
+		if time.Now().After(deadline) {
+			t.Fatalf("job still %q after the slots were released", job.Status)
+		}
+		c.mustJSON("GET", "/jobs/"+queued.JobID, nil, http.StatusOK, &job)
+	}
+
+	// The retry of the rejected message is not taken for a duplicate.
+	c.mustJSON("POST", "/conversations", message, http.StatusCreated, nil)
+}
@@ -0,0 +1,93 @@
+package integration
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"AkuAI/models"
+	"AkuAI/pkg/tenant"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestTenants(t *testing.T) {
+	srv, db := newServer(t)
+	reg, err := tenant.Parse([]byte(`[{"id": "acme", "name": "Acme University", "branding": {"app_name": "AcmeAI", "primary_color": "#aa0000"}}]`),
+		func(string) (string, bool) { return "", false })
+	if err != nil {
+		t.Fatal(err)
+	}
+	tenant.Use(reg)
+	t.Cleanup(func() { tenant.Use(&tenant.Registry{}) })
+
+	suffix := time.Now().UnixNano()
+	name := fmt.Sprintf("tenant%d", suffix)
+	signIn := func(tenantID string) *client {
+		c := &client{t: t, base: srv.URL, tenant: tenantID}
+		c.mustJSON("POST", "/register", gin.H{"email": name + "@example.com", "username": name, "password": "Secret123!", "confirm_password": "Secret123!"}, http.StatusCreated, nil)
+		var login struct {
+			AccessToken string `json:"access_token"`
+		}
+		c.mustJSON("POST", "/login", gin.H{"email": name + "@example.com", "password": "Secret123!"}, http.StatusOK, &login)
+		c.token = login.AccessToken
+		return c
+	}
+	// The same email and username sign up with both tenants.
+	op := signIn("")
+	acme := signIn("acme")
+
+	var brand struct {
+		ID       string          `json:"id"`
+		Branding tenant.Branding `json:"branding"`
+	}
+	acme.mustJSON("GET", "/tenant", nil, http.StatusOK, &brand)
+	if brand.ID != "acme" || brand.Branding.AppName != "AcmeAI" || brand.Branding.AssistantName != "AkuAI" {
+		t.Fatalf("acme branding = %+v", brand)
+	}
+	status, data := (&client{t: t, base: srv.URL, tenant: "nope"}).do("GET", "/tenant", nil)
+	var unknown struct {
+		Code string `json:"code"`
+	}
+	if json.Unmarshal(data, &unknown); status != http.StatusBadRequest || unknown.Code != "unknown_tenant" {
+		t.Errorf("unknown tenant = %d %s, want 400 unknown_tenant", status, data)
+	}
+
+	var conv struct {
+		ConversationID uint `json:"conversation_id"`
+	}
+	acme.mustJSON("POST", "/conversations", gin.H{"message": "Kapan pendaftaran wisuda dibuka?"}, http.StatusCreated, &conv)
+	var stored models.Conversation
+	if err := db.First(&stored, conv.ConversationID).Error; err != nil || stored.TenantID != "acme" {
+		t.Fatalf("conversation tenant = %q (%v), want acme", stored.TenantID, err)
+	}
+
+	// Tokens only work in the tenant that issued them.
+	crossed := &client{t: t, base: srv.URL, token: acme.token}
+	crossed.mustJSON("GET", "/conversations", nil, http.StatusUnauthorized, nil)
+	var list []struct {
+		ID uint `json:"id"`
+	}
+	op.mustJSON("GET", "/conversations", nil, http.StatusOK, &list)
+	for _, c := range list {
+		if c.ID == conv.ConversationID {
+			t.Fatal("operator user sees the acme conversation")
+		}
+	}
+
+	// Tenant admins keep to their tenant.
+	if err := db.Model(&models.User{}).Where("tenant_id = ? AND email = ?", "acme", name+"@example.com").Update("is_admin", true).Error; err != nil {
+		t.Fatal(err)
+	}
+	acme.mustJSON("GET", "/admin/metrics", nil, http.StatusForbidden, nil)
+	acme.mustJSON("GET", "/admin/moderation", nil, http.StatusOK, nil)
+	var stats struct {
+		Conversations int64 `json:"conversations"`
+	}
+	acme.mustJSON("GET", "/analytics/global", nil, http.StatusOK, &stats)
+	if stats.Conversations != 1 {
+		t.Errorf("acme analytics conversations = %d, want 1", stats.Conversations)
+	}
+}
@@ -0,0 +1,132 @@
+package integration
+
+import (
+	"AkuAI/middleware"
+	"AkuAI/pkg/config"
+	"bufio"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// fakeSMTP accepts mail on a local port and sends each message's data on
+// the returned channel.
+func fakeSMTP(t *testing.T) (string, int, <-chan string) {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	msgs := make(chan string, 10)
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				r := bufio.NewReader(conn)
+				reply := func(s string) { fmt.Fprint(conn, s+"\r\n") }
+				reply("220 localhost ESMTP")
+				for {
+					line, err := r.ReadString('\n')
+					if err != nil {
+						return
+					}
+					switch cmd := strings.ToUpper(strings.TrimSpace(line)); {
+					case strings.HasPrefix(cmd, "EHLO"), strings.HasPrefix(cmd, "HELO"):
+						reply("250 localhost")
+					case cmd == "DATA":
+						reply("354 go ahead")
+						var data strings.Builder
+						for {
+							l, err := r.ReadString('\n')
+							if err != nil {
+								return
+							}
+							if l == ".\r\n" {
+								break
+							}
+							data.WriteString(l)
+						}
+						msgs <- data.String()
+						reply("250 queued")
+					case cmd == "QUIT":
+						reply("221 bye")
+						return
+					default:
+						reply("250 ok")
+					}
+				}
+			}()
+		}
+	}()
+	host, port, _ := net.SplitHostPort(ln.Addr().String())
+	p, _ := strconv.Atoi(port)
+	return host, p, msgs
+}
+
+func TestEmailTranscript(t *testing.T) {
+	srv, _ := newServer(t)
+	signIn := func(name string) *client {
+		c := &client{t: t, base: srv.URL}
+		c.mustJSON("POST", "/register", gin.H{"email": name + "@example.com", "username": name, "password": "Secret123!", "confirm_password": "Secret123!"}, http.StatusCreated, nil)
+		var login struct {
+			AccessToken string `json:"access_token"`
+		}
+		c.mustJSON("POST", "/login", gin.H{"email": name + "@example.com", "password": "Secret123!"}, http.StatusOK, &login)
+		c.token = login.AccessToken
+		return c
+	}
+	suffix := time.Now().UnixNano()
+	name := fmt.Sprintf("transcript%d", suffix)
+	user := signIn(name)
+	other := signIn(fmt.Sprintf("notranscript%d", suffix))
+
+	var conv struct {
+		ConversationID uint `json:"conversation_id"`
+	}
+	user.mustJSON("POST", "/conversations", gin.H{"message": "Apa saja webinar UIB bulan November?"}, http.StatusCreated, &conv)
+	path := fmt.Sprintf("/conversations/%d/email", conv.ConversationID)
+
+	middleware.SetTranscriptEmailRateLimitConfig(time.Hour, 2)
+	t.Cleanup(func() { middleware.SetTranscriptEmailRateLimitConfig(time.Hour, 5) })
+	host, port, msgs := fakeSMTP(t)
+	smtpHost, smtpPort := config.SMTPHost, config.SMTPPort
+	t.Cleanup(func() { config.SMTPHost, config.SMTPPort = smtpHost, smtpPort })
+
+	config.SMTPHost = ""
+	user.mustJSON("POST", path, nil, http.StatusServiceUnavailable, nil)
+
+	config.SMTPHost, config.SMTPPort = host, port
+	other.mustJSON("POST", path, nil, http.StatusNotFound, nil)
+	var sent struct {
+		Email    string `json:"email"`
+		Filename string `json:"filename"`
+	}
+	user.mustJSON("POST", path, nil, http.StatusOK, &sent)
+	if sent.Email != name+"@example.com" || !strings.HasPrefix(sent.Filename, fmt.Sprintf("akuai-%d", conv.ConversationID)) {
+		t.Fatalf("sent = %+v", sent)
+	}
+	select {
+	case msg := <-msgs:
+		for _, want := range []string{"To: " + name + "@example.com", "multipart/mixed", "filename=" + sent.Filename} {
+			if !strings.Contains(msg, want) {
+				t.Fatalf("message lacks %q:\n%s", want, msg)
+			}
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("no message reached the SMTP server")
+	}
+
+	// The 503 above spent a token too.
+	user.mustJSON("POST", path, nil, http.StatusTooManyRequests, nil)
+}
@@ -0,0 +1,131 @@
+package integration
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"AkuAI/pkg/config"
+	"AkuAI/pkg/webhook"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestWebhookFlows(t *testing.T) {
+	srv, db := newServer(t)
+	config.WebhookAllowPrivate = true
+	t.Cleanup(func() { config.WebhookAllowPrivate = false })
+
+	// The receiver fails the first delivery and accepts the retry.
+	var (
+		mu       sync.Mutex
+		received []*http.Request
+		bodies   [][]byte
+	)
+	recv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		defer mu.Unlock()
+		received = append(received, r)
+		bodies = append(bodies, b)
+		if len(received) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer recv.Close()
+
+	c := &client{t: t, base: srv.URL}
+	name := fmt.Sprintf("hook%d", time.Now().UnixNano())
+	c.mustJSON("POST", "/register", gin.H{"email": name + "@example.com", "username": name, "password": "Secret123!", "confirm_password": "Secret123!"}, http.StatusCreated, nil)
+	var login struct {
+		AccessToken string `json:"access_token"`
+	}
+	c.mustJSON("POST", "/login", gin.H{"email": name + "@example.com", "password": "Secret123!"}, http.StatusOK, &login)
+	c.token = login.AccessToken
+
+	c.mustJSON("POST", "/webhooks", gin.H{"url": "ftp://example.com/x", "events": []string{"message.completed"}}, http.StatusBadRequest, nil)
+	c.mustJSON("POST", "/webhooks", gin.H{"url": recv.URL, "events": []string{"message.sent"}}, http.StatusBadRequest, nil)
+	var created struct {
+		Webhook struct {
+			ID     uint     `json:"id"`
+			Secret string   `json:"secret"`
+			Events []string `json:"events"`
+		} `json:"webhook"`
+	}
+	c.mustJSON("POST", "/webhooks", gin.H{"url": recv.URL, "events": []string{"message.completed"}}, http.StatusCreated, &created)
+	if created.Webhook.Secret == "" || len(created.Webhook.Events) != 1 {
+		t.Fatalf("created webhook = %+v", created.Webhook)
+	}
+	var listed struct {
+		Webhooks []map[string]any `json:"webhooks"`
+	}
+	c.mustJSON("GET", "/webhooks", nil, http.StatusOK, &listed)
+	if len(listed.Webhooks) != 1 || listed.Webhooks[0]["secret"] != nil {
+		t.Fatalf("webhooks = %v, want one without its secret", listed.Webhooks)
+	}
+
+	var conv conversationResp
+	c.mustJSON("POST", "/conversations", gin.H{"message": "Apa saja webinar UIB bulan November?"}, http.StatusCreated, &conv)
+
+	d := webhook.New(db, webhook.Policy{MaxAttempts: 3, Timeout: 5 * time.Second, AllowPrivate: true})
+	for i := 0; i < 2; i++ {
+		if n, err := d.DeliverDue(context.Background()); err != nil || n != 1 {
+			t.Fatalf("delivery run %d: attempted %d, %v", i+1, n, err)
+		}
+	}
+	mu.Lock()
+	if len(received) != 2 {
+		t.Fatalf("receiver got %d requests, want 2", len(received))
+	}
+	r, body := received[1], bodies[1]
+	mu.Unlock()
+	ts, _ := strconv.ParseInt(r.Header.Get("X-AkuAI-Timestamp"), 10, 64)
+	if got := r.Header.Get("X-AkuAI-Signature"); got != webhook.Sign(created.Webhook.Secret, ts, body) {
+		t.Fatalf("signature %q does not match the body", got)
+	}
+	if r.Header.Get("X-AkuAI-Event") != webhook.EventMessageCompleted {
+		t.Fatalf("X-AkuAI-Event = %q", r.Header.Get("X-AkuAI-Event"))
+	}
+	var payload struct {
+		Event string `json:"event"`
+		Data  struct {
+			ConversationID uint   `json:"conversation_id"`
+			Text           string `json:"text"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(body, &payload); err != nil || payload.Event != webhook.EventMessageCompleted ||
+		payload.Data.ConversationID != conv.ConversationID || payload.Data.Text == "" {
+		t.Fatalf("payload = %s (%v)", body, err)
+	}
+
+	var log struct {
+		Deliveries []struct {
+			Status       string `json:"status"`
+			Attempts     int    `json:"attempts"`
+			ResponseCode int    `json:"response_code"`
+		} `json:"deliveries"`
+	}
+	c.mustJSON("GET", fmt.Sprintf("/webhooks/%d/deliveries", created.Webhook.ID), nil, http.StatusOK, &log)
+	if len(log.Deliveries) != 1 || log.Deliveries[0].Status != "succeeded" || log.Deliveries[0].Attempts != 2 || log.Deliveries[0].ResponseCode != http.StatusNoContent {
+		t.Fatalf("deliveries = %+v", log.Deliveries)
+	}
+
+	// Another user can't see or delete the webhook.
+	other := &client{t: t, base: srv.URL}
+	oname := name + "x"
+	other.mustJSON("POST", "/register", gin.H{"email": oname + "@example.com", "username": oname, "password": "Secret123!", "confirm_password": "Secret123!"}, http.StatusCreated, nil)
+	other.mustJSON("POST", "/login", gin.H{"email": oname + "@example.com", "password": "Secret123!"}, http.StatusOK, &login)
+	other.token = login.AccessToken
+	other.mustJSON("GET", fmt.Sprintf("/webhooks/%d/deliveries", created.Webhook.ID), nil, http.StatusNotFound, nil)
+	other.mustJSON("DELETE", fmt.Sprintf("/webhooks/%d", created.Webhook.ID), nil, http.StatusNotFound, nil)
+	c.mustJSON("DELETE", fmt.Sprintf("/webhooks/%d", created.Webhook.ID), nil, http.StatusOK, nil)
+}
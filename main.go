@@ -1,11 +1,32 @@
 package main
 
 import (
+	"AkuAI/controllers"
 	"AkuAI/middleware"
-	"AkuAI/models"
+	"AkuAI/pkg/analytics"
+	"AkuAI/pkg/announce"
+	"AkuAI/pkg/cache"
 	"AkuAI/pkg/config"
+	"AkuAI/pkg/database"
+	"AkuAI/pkg/digest"
+	"AkuAI/pkg/fieldcrypt"
+	"AkuAI/pkg/grpcserver"
+	"AkuAI/pkg/jobs"
+	"AkuAI/pkg/knowledge"
+	"AkuAI/pkg/lockout"
+	"AkuAI/pkg/metrics"
+	"AkuAI/pkg/moderation"
+	"AkuAI/pkg/postprocess"
+	"AkuAI/pkg/prompts"
+	"AkuAI/pkg/retention"
+	"AkuAI/pkg/services"
+	"AkuAI/pkg/sse"
+	"AkuAI/pkg/tenant"
+	tokenstore "AkuAI/pkg/token"
+	"AkuAI/pkg/webhook"
+	"AkuAI/pkg/wshub"
 	"AkuAI/routes"
-	"fmt"
+	"context"
 	"log"
 	"os"
 	"strings"
@@ -13,36 +34,142 @@ import (
 
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
-	"gorm.io/driver/mysql"
 	"gorm.io/gorm"
 )
 
 func main() {
-	log.Printf("Database Config - Host:%s Port:%s User:%s DB:%s",
-		config.MySQLHost, config.MySQLPort, config.MySQLUser, config.MySQLDatabase)
+	log.Printf("Database Config - Driver:%s", database.Describe())
 
-	dsn := fmt.Sprintf("%s:%s@tcp(%s:%s)/%s?charset=utf8mb4&parseTime=True&loc=Local",
-		config.MySQLUser,
-		config.MySQLPassword,
-		config.MySQLHost,
-		config.MySQLPort,
-		config.MySQLDatabase,
-	)
-
-	db, err := gorm.Open(mysql.Open(dsn), &gorm.Config{})
+	db, err := database.Open(&gorm.Config{})
 	if err != nil {
-		log.Fatalf("failed to connect to MySQL database: %v", err)
+		log.Fatalf("failed to connect to %s database: %v", config.DBDriver, err)
+	}
+
+	log.Printf("Connected to database: %s", database.Describe())
+	if sqlDB, err := db.DB(); err == nil {
+		metrics.RegisterFunc("db_pool", func() any { return sqlDB.Stats() })
 	}
 
-	log.Printf("Connected to MySQL database: %s@%s:%s/%s",
-		config.MySQLUser, config.MySQLHost, config.MySQLPort, config.MySQLDatabase)
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		if err := runMigrate(db, os.Args[2:]); err != nil {
+			log.Fatalf("[migrate] %v", err)
+		}
+		return
+	}
+	checkMigrations(db)
+	if err := fieldcrypt.Configure(config.FieldEncryptionKeys); err != nil {
+		log.Fatalf("invalid FIELD_ENCRYPTION_KEYS: %v", err)
+	}
+	if k := fieldcrypt.Default(); k.Enabled() {
+		log.Printf("[fieldcrypt] 🔐 message texts and conversation titles are sealed with key %s", k.CurrentID())
+	}
+	if len(os.Args) > 1 && os.Args[1] == "reencrypt" {
+		if err := runReencrypt(db, os.Args[2:]); err != nil {
+			log.Fatalf("[reencrypt] %v", err)
+		}
+		return
+	}
+	if err := tenant.Configure(config.TenantsFile, config.SecretStore.Lookup); err != nil {
+		log.Fatalf("invalid TENANTS_FILE: %v", err)
+	}
+	if n := tenant.Default().Len(); n > 0 {
+		log.Printf("[tenant] 🏢 serving %d tenants besides the operator from %s", n, config.TenantsFile)
+	}
+	if _, err := tokenstore.InitKeys(db, tokenstore.JWTConfig{
+		Issuer:   config.JWTIssuer,
+		Audience: config.JWTAudience,
+		TTL:      time.Duration(config.JWTExpiryMinutes) * time.Minute,
+	}, config.JWTSecret); err != nil {
+		log.Fatalf("failed to load JWT signing keys: %v", err)
+	}
 
-	if err := db.AutoMigrate(&models.User{}, &models.Conversation{}, &models.Message{}); err != nil {
-		log.Fatalf("failed migrate: %v", err)
+	if err := analytics.Register(db); err != nil {
+		log.Fatalf("failed to register analytics callbacks: %v", err)
 	}
+	knowledge.Init(db)
+	prompts.Init(db)
 
 	middleware.SetRateLimitConfig(time.Duration(config.RateLimitWindowSeconds)*time.Second, config.RateLimitCapacity, config.UserConcurrencyLimit)
+	middleware.SetGuestRateLimitConfig(time.Duration(config.GuestRateLimitWindowSeconds)*time.Second, config.GuestRateLimitCapacity)
+	middleware.SetUploadRateLimitConfig(time.Duration(config.UploadRateLimitWindowSeconds)*time.Second, config.UploadRateLimitCount)
+	middleware.SetTranscriptEmailRateLimitConfig(time.Duration(config.TranscriptEmailWindowSeconds)*time.Second, config.TranscriptEmailLimit)
 	middleware.SetDuplicateTTL(time.Duration(config.DuplicateWindowSeconds) * time.Second)
+	cache.Default().SetLimits(config.CacheMaxEntries, config.CacheMaxBytesMB<<20)
+	metrics.RegisterFunc("cache", func() any { return cache.Default().Stats() })
+	middleware.SetIdempotencyTTL(time.Duration(config.IdempotencyTTLSeconds) * time.Second)
+	middleware.SetSlotQueueConfig(config.UserSlotQueueLength, time.Duration(config.UserSlotWaitSeconds)*time.Second)
+	jobs.Start(config.JobWorkers, config.JobQueueSize,
+		time.Duration(config.JobTimeoutSeconds)*time.Second, time.Duration(config.JobRetentionSeconds)*time.Second)
+	sse.Start(time.Duration(config.SSEReplayTTLSeconds) * time.Second)
+	hub := wshub.Start(wshub.Limits{
+		MaxPerUser:        config.WSMaxConnsPerUser,
+		MessagesPerWindow: config.WSMessagesPerWindow,
+		MessageWindow:     time.Duration(config.WSMessageWindowSeconds) * time.Second,
+		FreeReconnects:    config.WSReconnectFree,
+		ReconnectWindow:   time.Duration(config.WSReconnectWindowSeconds) * time.Second,
+		BackoffBase:       time.Duration(config.WSReconnectBackoffMs) * time.Millisecond,
+		BackoffMax:        time.Duration(config.WSReconnectBackoffMaxSeconds) * time.Second,
+	})
+	metrics.RegisterFunc("websocket", func() any { return hub.Stats() })
+	var captcha lockout.CaptchaVerifier
+	if config.CaptchaVerifyURL != "" {
+		captcha = lockout.SiteVerify{URL: config.CaptchaVerifyURL, Secret: config.CaptchaSecret}
+	}
+	lockout.Start(lockout.Policy{
+		MaxFailures:   config.LoginMaxFailures,
+		IPMaxFailures: config.LoginIPMaxFailures,
+		BaseLockout:   time.Duration(config.LoginLockoutSeconds) * time.Second,
+		MaxLockout:    time.Duration(config.LoginLockoutMaxSeconds) * time.Second,
+		CaptchaAfter:  config.LoginCaptchaAfter,
+		ResetAfter:    time.Duration(config.LoginFailureResetMinutes) * time.Minute,
+	}, captcha)
+	steps := config.PostprocessSteps
+	if steps == nil {
+		steps = postprocess.DefaultSteps
+	}
+	if p, err := postprocess.Init(steps, config.PostprocessDictionary); err != nil {
+		log.Printf("[postprocess] ⚠️ %v; using steps %s", err, strings.Join(p.Steps(), ","))
+	}
+	if _, err := services.ParseSafetySettings(config.GeminiSafetySettings); err != nil {
+		log.Printf("[config] ⚠️ GEMINI_SAFETY_SETTINGS ignored: %v", err)
+	}
+
+	retention.Init(db, retention.Policy{
+		ArchiveAfterInactive: time.Duration(config.RetentionArchiveAfterDays) * 24 * time.Hour,
+		DeleteAfter:          time.Duration(config.RetentionDeleteAfterDays) * 24 * time.Hour,
+		TrashRetention:       time.Duration(config.TrashRetentionDays) * 24 * time.Hour,
+		GuestTTL:             time.Duration(config.GuestConversationTTLHours) * time.Hour,
+		IncognitoTTL:         time.Duration(config.IncognitoTTLMinutes) * time.Minute,
+		DryRun:               config.RetentionDryRun,
+	}).Start(context.Background(), time.Duration(config.RetentionIntervalMinutes)*time.Minute)
+	announce.Start(context.Background(), db, hub, time.Duration(config.AnnouncementPollSeconds)*time.Second)
+	if config.DigestEnabled {
+		digest.Start(context.Background(), db, controllers.DigestSchedule(), controllers.SendDigest(db, time.Time{}))
+	}
+	webhook.Start(context.Background(), db, webhook.Policy{
+		MaxAttempts:  config.WebhookMaxAttempts,
+		BaseBackoff:  time.Duration(config.WebhookBackoffSeconds) * time.Second,
+		MaxBackoff:   time.Duration(config.WebhookBackoffMaxSeconds) * time.Second,
+		Timeout:      time.Duration(config.WebhookTimeoutSeconds) * time.Second,
+		PollInterval: webhook.DefaultPolicy.PollInterval,
+		AllowPrivate: config.WebhookAllowPrivate,
+	})
+
+	if config.ModerationEnabled {
+		block := map[string][]string{"custom": moderation.ParseKeywords(config.ModerationBlockKeywords)}
+		flag := map[string][]string{"custom": moderation.ParseKeywords(config.ModerationFlagKeywords)}
+		for k, v := range moderation.DefaultBlock {
+			block[k] = v
+		}
+		for k, v := range moderation.DefaultFlag {
+			flag[k] = v
+		}
+		var checker moderation.SafetyChecker
+		if config.ModerationGemini {
+			checker = services.NewGeminiService()
+		}
+		moderation.Init(moderation.New(block, flag, checker))
+	}
 
 	r := gin.Default()
 
@@ -64,12 +191,25 @@ func main() {
 	r.Use(cors.New(cors.Config{
 		AllowOrigins:     origins,
 		AllowMethods:     []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-		AllowHeaders:     []string{"Origin", "Content-Type", "Accept", "Authorization", "X-Requested-With", "X-Bypass-Duplicate", "x-bypass-duplicate"},
-		ExposeHeaders:    []string{"Content-Length"},
+		AllowHeaders:     []string{"Origin", "Content-Type", "Accept", "Authorization", "X-Requested-With", "X-Bypass-Duplicate", "x-bypass-duplicate", "Idempotency-Key", middleware.GenerationOverrideHeader, tenant.Header},
+		ExposeHeaders:    []string{"Content-Length", "Deprecation", "Sunset", "Link", "Idempotent-Replayed"},
 		AllowCredentials: true,
 		MaxAge:           12 * time.Hour,
 	}))
 
 	routes.RegisterRoutes(r, db)
+
+	if config.GRPCPort != "" {
+		health := grpcserver.NewHealth()
+		gs := grpcserver.New(health)
+		if err := controllers.RegisterGRPC(gs, health, db); err != nil {
+			log.Printf("[grpc] ⚠️ %v", err)
+		}
+		go func() {
+			if err := gs.ListenAndServe(context.Background(), ":"+config.GRPCPort); err != nil {
+				log.Fatalf("[grpc] server failed: %v", err)
+			}
+		}()
+	}
 	r.Run(":" + config.Port)
 }
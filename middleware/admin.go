@@ -0,0 +1,45 @@
+package middleware
+
+import (
+	"AkuAI/models"
+	"AkuAI/pkg/apierror"
+	"AkuAI/pkg/config"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// AdminMiddleware must run after AuthMiddleware. It lets through users flagged
+// IsAdmin or listed in ADMIN_EMAILS; the latter only in the operator tenant,
+// since anyone may sign up with a tenant under those emails.
+func AdminMiddleware(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		uid, _ := strconv.Atoi(c.GetString(ContextUserIDKey))
+
+		var user models.User
+		if err := db.First(&user, uid).Error; err != nil {
+			apierror.Respond(c, http.StatusUnauthorized, "user not found")
+			c.Abort()
+			return
+		}
+		if !user.IsAdmin && (user.TenantID != "" || !isAdminEmail(user.Email)) {
+			apierror.Respond(c, http.StatusForbidden, "admin access required")
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+func isAdminEmail(email string) bool {
+	email = strings.ToLower(strings.TrimSpace(email))
+	for _, e := range config.AdminEmails {
+		if e == email {
+			return true
+		}
+	}
+	return false
+}
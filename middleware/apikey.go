@@ -0,0 +1,102 @@
+package middleware
+
+import (
+	"AkuAI/models"
+	"AkuAI/pkg/apierror"
+	"AkuAI/pkg/apikey"
+	"AkuAI/pkg/tenant"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// ContextAPIKeyIDKey holds the ID of the API key a request was made with;
+// such requests have no ContextUserIDKey.
+const ContextAPIKeyIDKey = "current_api_key_id"
+
+var (
+	ErrAPIKeyInvalid  = errors.New("invalid API key")
+	ErrAPIKeyInactive = errors.New("API key revoked or expired")
+)
+
+// APIKeyScopeError is returned by VerifyAPIKey for a key without the scope.
+type APIKeyScopeError struct {
+	Scope string
+}
+
+func (e *APIKeyScopeError) Error() string { return "API key lacks scope " + e.Scope }
+
+// VerifyAPIKey returns the active key granted scope, and records its use.
+func VerifyAPIKey(db *gorm.DB, key, scope string) (models.APIKey, error) {
+	var k models.APIKey
+	if err := db.Where("key_hash = ?", apikey.Hash(key)).First(&k).Error; err != nil {
+		return k, ErrAPIKeyInvalid
+	}
+	now := time.Now()
+	if !k.Active(now) {
+		return k, ErrAPIKeyInactive
+	}
+	if !k.HasScope(scope) {
+		return k, &APIKeyScopeError{Scope: scope}
+	}
+	// last_used_at is only advanced once a minute to keep busy keys from
+	// writing on every request
+	if k.LastUsedAt == nil || now.Sub(*k.LastUsedAt) > time.Minute {
+		if err := db.Model(&k).UpdateColumn("last_used_at", now).Error; err != nil {
+			log.Printf("[apikey] ⚠️ failed to record use of key %d: %v", k.ID, err)
+		}
+	}
+	return k, nil
+}
+
+// apiKeyFromRequest returns the key sent as X-API-Key or
+// "Authorization: ApiKey <key>", or "".
+func apiKeyFromRequest(c *gin.Context) string {
+	if k := strings.TrimSpace(c.GetHeader("X-API-Key")); k != "" {
+		return k
+	}
+	parts := strings.Fields(c.GetHeader("Authorization"))
+	if len(parts) == 2 && strings.EqualFold(parts[0], "apikey") {
+		return parts[1]
+	}
+	return ""
+}
+
+// APIKeyAuth lets requests carrying an active API key with scope through and
+// hands every other request to AuthMiddleware, so users keep their JWT access.
+// API keys are issued by operator admins and only work for the operator
+// tenant.
+func APIKeyAuth(db *gorm.DB, scope string) gin.HandlerFunc {
+	userAuth := AuthMiddleware()
+	return func(c *gin.Context) {
+		key := apiKeyFromRequest(c)
+		if key == "" {
+			userAuth(c)
+			return
+		}
+		if !tenant.FromContext(c.Request.Context()).IsOperator() {
+			apierror.Respond(c, http.StatusUnauthorized, "API keys belong to the operator tenant")
+			c.Abort()
+			return
+		}
+		k, err := VerifyAPIKey(db, key, scope)
+		var scopeErr *APIKeyScopeError
+		if errors.As(err, &scopeErr) {
+			apierror.Respond(c, http.StatusForbidden, err.Error())
+			c.Abort()
+			return
+		}
+		if err != nil {
+			apierror.Respond(c, http.StatusUnauthorized, err.Error())
+			c.Abort()
+			return
+		}
+		c.Set(ContextAPIKeyIDKey, k.ID)
+		c.Next()
+	}
+}
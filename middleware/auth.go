@@ -1,14 +1,14 @@
 package middleware
 
 import (
-	"AkuAI/pkg/config"
+	"AkuAI/pkg/apierror"
+	"AkuAI/pkg/tenant"
 	tokenstore "AkuAI/pkg/token"
+	"errors"
 	"net/http"
-	"strconv"
 	"strings"
 
 	"github.com/gin-gonic/gin"
-	"github.com/golang-jwt/jwt/v5"
 )
 
 const (
@@ -20,53 +20,38 @@ func AuthMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		auth := c.GetHeader("Authorization")
 		if auth == "" {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"msg": "missing authorization header"})
+			apierror.Respond(c, http.StatusUnauthorized, "missing authorization header")
+			c.Abort()
 			return
 		}
 		parts := strings.Fields(auth)
 		if len(parts) != 2 || strings.ToLower(parts[0]) != "bearer" {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"msg": "invalid authorization header"})
+			apierror.Respond(c, http.StatusUnauthorized, "invalid authorization header")
+			c.Abort()
 			return
 		}
 		tokenStr := parts[1]
 
-		token, err := jwt.Parse(tokenStr, func(t *jwt.Token) (interface{}, error) {
-			if _, ok := t.Method.(*jwt.SigningMethodHMAC); !ok {
-				return nil, jwt.ErrTokenUnverifiable
-			}
-			return []byte(config.JWTSecret), nil
-		})
-		if err != nil || !token.Valid {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"msg": "invalid token"})
+		claims, err := tokenstore.Keys().Verify(tokenStr)
+		if errors.Is(err, tokenstore.ErrRevoked) {
+			apierror.Respond(c, http.StatusUnauthorized, "Token has been revoked (logout)")
+			c.Abort()
 			return
 		}
-
-		claims, ok := token.Claims.(jwt.MapClaims)
-		if !ok {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"msg": "invalid token claims"})
-			return
-		}
-
-		jtiVal, _ := claims["jti"].(string)
-		if tokenstore.IsRevoked(jtiVal) {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"msg": "Token has been revoked (logout)"})
+		if err != nil {
+			apierror.Respond(c, http.StatusUnauthorized, "invalid token")
+			c.Abort()
 			return
 		}
-
-		var userIDStr string
-		if sub, ok := claims["sub"].(string); ok {
-			userIDStr = sub
-		} else if subf, ok := claims["sub"].(float64); ok {
-			userIDStr = strconv.Itoa(int(subf))
-		}
-
-		if userIDStr == "" {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"msg": "invalid subject in token"})
+		// A token only works for the tenant that issued it.
+		if claims.TenantID != tenant.ID(c.Request.Context()) {
+			apierror.Respond(c, http.StatusUnauthorized, "token belongs to another tenant")
+			c.Abort()
 			return
 		}
 
-		c.Set(ContextUserIDKey, userIDStr)
-		c.Set(ContextJTIKey, jtiVal)
+		c.Set(ContextUserIDKey, claims.UserID)
+		c.Set(ContextJTIKey, claims.JTI)
 		c.Next()
 	}
 }
@@ -0,0 +1,33 @@
+package middleware
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Deprecated marks responses from legacy (unversioned) routes. legacyPrefix is
+// the mount point of the old route and successorPrefix the versioned one; the
+// successor path is advertised through a Link header so clients can migrate.
+func Deprecated(legacyPrefix, successorPrefix string, sunset time.Time) gin.HandlerFunc {
+	legacyPrefix = strings.TrimRight(legacyPrefix, "/")
+	successorPrefix = strings.TrimRight(successorPrefix, "/")
+	sunsetHeader := ""
+	if !sunset.IsZero() {
+		sunsetHeader = sunset.UTC().Format(http.TimeFormat)
+	}
+
+	return func(c *gin.Context) {
+		rest := strings.TrimPrefix(c.Request.URL.Path, legacyPrefix)
+		successor := successorPrefix + "/" + strings.TrimPrefix(rest, "/")
+
+		c.Header("Deprecation", "true")
+		if sunsetHeader != "" {
+			c.Header("Sunset", sunsetHeader)
+		}
+		c.Header("Link", "<"+successor+`>; rel="successor-version"`)
+		c.Next()
+	}
+}
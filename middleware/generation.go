@@ -0,0 +1,59 @@
+package middleware
+
+import (
+	"AkuAI/models"
+	"AkuAI/pkg/apierror"
+	"AkuAI/pkg/config"
+	svc "AkuAI/pkg/services"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// GenerationOverrideHeader carries a JSON object such as
+// {"temperature":0.9,"topK":32} that replaces the configured generationConfig
+// for one chat request. WebSocket clients, which cannot set headers, pass the
+// same JSON in the generation_config query parameter.
+const GenerationOverrideHeader = "X-Generation-Config"
+
+// GenerationOverride must run after AuthMiddleware. Requests without an
+// override pass through; with one, they must come from an admin unless the
+// server runs outside production (tests, the abtest runner against staging).
+func GenerationOverride(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		raw := strings.TrimSpace(c.GetHeader(GenerationOverrideHeader))
+		if raw == "" {
+			raw = strings.TrimSpace(c.Query("generation_config"))
+		}
+		if raw == "" {
+			c.Next()
+			return
+		}
+		if config.IsProduction {
+			uid, _ := strconv.Atoi(c.GetString(ContextUserIDKey))
+			var user models.User
+			if err := db.First(&user, uid).Error; err != nil || (!user.IsAdmin && !isAdminEmail(user.Email)) {
+				apierror.Respond(c, http.StatusForbidden, "generation overrides are restricted to admins")
+				c.Abort()
+				return
+			}
+		}
+		var o svc.GenerationOverride
+		if err := json.Unmarshal([]byte(raw), &o); err != nil {
+			apierror.Respond(c, http.StatusBadRequest, "invalid "+GenerationOverrideHeader)
+			c.Abort()
+			return
+		}
+		if err := o.Validate(); err != nil {
+			apierror.Respond(c, http.StatusBadRequest, err.Error())
+			c.Abort()
+			return
+		}
+		c.Request = c.Request.WithContext(svc.WithGenerationOverride(c.Request.Context(), o))
+		c.Next()
+	}
+}
@@ -0,0 +1,63 @@
+package middleware
+
+import (
+	"AkuAI/pkg/apierror"
+	"AkuAI/pkg/config"
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// GuestCookie holds the guest ID of an unauthenticated chat session.
+	GuestCookie       = "akuai_guest"
+	ContextGuestIDKey = "current_guest_id"
+)
+
+// GuestSession identifies a guest by the GuestCookie, issuing a new guest ID
+// when the cookie is missing or malformed. The cookie lives as long as guest
+// conversations are kept. Guest routes are 404 unless GUEST_CHAT_ENABLED=1.
+func GuestSession() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !config.GuestChatEnabled {
+			apierror.Respond(c, http.StatusNotFound, "guest chat is disabled")
+			c.Abort()
+			return
+		}
+		id := GuestID(c)
+		if id == "" {
+			b := make([]byte, 16)
+			_, _ = rand.Read(b)
+			id = hex.EncodeToString(b)
+		}
+		SetGuestCookie(c, id, config.GuestConversationTTLHours*3600)
+		c.Set(ContextGuestIDKey, id)
+		c.Next()
+	}
+}
+
+// GuestID returns the guest ID of the request's GuestCookie, or "".
+func GuestID(c *gin.Context) string {
+	v, err := c.Cookie(GuestCookie)
+	if err != nil || !validGuestID(v) {
+		return ""
+	}
+	return v
+}
+
+// SetGuestCookie sets the GuestCookie to id for maxAge seconds; a negative
+// maxAge clears it.
+func SetGuestCookie(c *gin.Context, id string, maxAge int) {
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie(GuestCookie, id, maxAge, "/", "", config.IsProduction, true)
+}
+
+func validGuestID(s string) bool {
+	if len(s) != 32 {
+		return false
+	}
+	_, err := hex.DecodeString(s)
+	return err == nil
+}
@@ -0,0 +1,172 @@
+package middleware
+
+import (
+	"AkuAI/pkg/apierror"
+	"AkuAI/pkg/cache"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	IdempotencyHeader       = "Idempotency-Key"
+	idempotencyReplayHeader = "Idempotent-Replayed"
+	maxIdempotencyKeyLen    = 255
+	maxIdempotencyBody      = 1 << 20
+)
+
+var (
+	idemMu  sync.Mutex
+	idemTTL = 24 * time.Hour
+)
+
+// idempotentResponse is what gets stored in the cache for one key. A pending
+// entry marks a request that is still being processed.
+type idempotentResponse struct {
+	Fingerprint string
+	Pending     bool
+	Status      int
+	ContentType string
+	Body        []byte
+}
+
+func (r idempotentResponse) CacheSize() int { return len(r.Body) + len(r.Fingerprint) }
+
+// transientStatus are client errors that say "not now" rather than "never":
+// a retry with the same key must run the request again.
+var transientStatus = map[int]bool{
+	http.StatusRequestTimeout:  true,
+	http.StatusConflict:        true,
+	http.StatusTooEarly:        true,
+	http.StatusTooManyRequests: true,
+}
+
+// finalStatus reports whether a response with status is the request's
+// outcome and may be replayed for the whole TTL.
+func finalStatus(status int) bool {
+	return status < http.StatusInternalServerError && !transientStatus[status]
+}
+
+type captureWriter struct {
+	gin.ResponseWriter
+	buf      bytes.Buffer
+	overflow bool
+}
+
+func (w *captureWriter) Write(b []byte) (int, error) {
+	w.capture(b)
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *captureWriter) WriteString(s string) (int, error) {
+	w.capture([]byte(s))
+	return w.ResponseWriter.WriteString(s)
+}
+
+func (w *captureWriter) capture(b []byte) {
+	if w.overflow {
+		return
+	}
+	if w.buf.Len()+len(b) > maxIdempotencyBody {
+		w.overflow = true
+		w.buf.Reset()
+		return
+	}
+	w.buf.Write(b)
+}
+
+func SetIdempotencyTTL(ttl time.Duration) {
+	idemMu.Lock()
+	idemTTL = ttl
+	idemMu.Unlock()
+}
+
+// Idempotency replays the original response when a client retries a request
+// with the same Idempotency-Key header. Keys are scoped per user and route;
+// reusing a key with a different payload is rejected. Requests without the
+// header pass through untouched.
+func Idempotency() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		idemKey := strings.TrimSpace(c.GetHeader(IdempotencyHeader))
+		if idemKey == "" {
+			c.Next()
+			return
+		}
+		if len(idemKey) > maxIdempotencyKeyLen {
+			apierror.Respond(c, http.StatusBadRequest, "Idempotency-Key too long")
+			c.Abort()
+			return
+		}
+
+		uid := c.GetString(ContextUserIDKey)
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			apierror.Respond(c, http.StatusBadRequest, "failed to read request body")
+			c.Abort()
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+		sum := sha256.Sum256(body)
+		fingerprint := hex.EncodeToString(sum[:])
+
+		idemMu.Lock()
+		ttl := idemTTL
+		idemMu.Unlock()
+
+		store := cache.Default()
+		key := cache.NamespacedKey("idempotency", uid, c.FullPath(), idemKey)
+		if !store.Add(key, idempotentResponse{Fingerprint: fingerprint, Pending: true}, ttl) {
+			v, _ := store.Get(key)
+			prev, _ := v.(idempotentResponse)
+			switch {
+			case prev.Fingerprint != fingerprint:
+				apierror.Respond(c, http.StatusUnprocessableEntity, "Idempotency-Key was already used with a different request")
+				c.Abort()
+			case prev.Pending:
+				apierror.Respond(c, http.StatusConflict, "request with this Idempotency-Key is still in progress")
+				c.Abort()
+			default:
+				log.Printf("[idempotency] replay uid=%s path=%s status=%d", uid, c.FullPath(), prev.Status)
+				c.Header(idempotencyReplayHeader, "true")
+				c.Data(prev.Status, prev.ContentType, prev.Body)
+				c.Abort()
+			}
+			return
+		}
+
+		// The pending entry is released unless a final response replaces it,
+		// also when a handler panics, so the key never stays stuck.
+		stored := false
+		defer func() {
+			if !stored {
+				store.Delete(key)
+			}
+		}()
+
+		cw := &captureWriter{ResponseWriter: c.Writer}
+		c.Writer = cw
+		c.Next()
+
+		// Server errors, transient client errors such as 429 and oversized
+		// responses are not stored so the client can retry.
+		status := cw.Status()
+		if !finalStatus(status) || cw.overflow {
+			return
+		}
+		stored = true
+		store.Set(key, idempotentResponse{
+			Fingerprint: fingerprint,
+			Status:      status,
+			ContentType: cw.Header().Get("Content-Type"),
+			Body:        cw.buf.Bytes(),
+		}, ttl)
+	}
+}
@@ -0,0 +1,91 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestIdempotencyReplay(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	calls := 0
+	r := gin.New()
+	r.POST("/conversations", func(c *gin.Context) {
+		c.Set(ContextUserIDKey, "42")
+	}, Idempotency(), func(c *gin.Context) {
+		calls++
+		c.JSON(http.StatusCreated, gin.H{"call": calls})
+	})
+
+	do := func(key, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/conversations", strings.NewReader(body))
+		if key != "" {
+			req.Header.Set(IdempotencyHeader, key)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	first := do("k1", `{"message":"halo"}`)
+	replay := do("k1", `{"message":"halo"}`)
+	if first.Code != http.StatusCreated || replay.Code != http.StatusCreated {
+		t.Fatalf("expected 201 for both, got %d and %d", first.Code, replay.Code)
+	}
+	if replay.Body.String() != first.Body.String() || replay.Header().Get(idempotencyReplayHeader) != "true" {
+		t.Fatalf("expected replayed body %q, got %q", first.Body.String(), replay.Body.String())
+	}
+	if calls != 1 {
+		t.Fatalf("expected handler to run once, ran %d times", calls)
+	}
+	if w := do("k1", `{"message":"lain"}`); w.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("expected 422 for key reuse with different body, got %d", w.Code)
+	}
+	do("", `{"message":"halo"}`)
+	if calls != 2 {
+		t.Fatalf("expected request without key to pass through, calls=%d", calls)
+	}
+}
+
+func TestIdempotencyRetryable(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	status := http.StatusTooManyRequests
+	calls := 0
+	r := gin.New()
+	r.Use(gin.CustomRecovery(func(c *gin.Context, _ any) { c.AbortWithStatus(http.StatusInternalServerError) }))
+	r.POST("/conversations", func(c *gin.Context) {
+		c.Set(ContextUserIDKey, "43")
+	}, Idempotency(), func(c *gin.Context) {
+		calls++
+		if status == 0 {
+			panic("boom")
+		}
+		c.JSON(status, gin.H{"call": calls})
+	})
+	do := func() int {
+		req := httptest.NewRequest(http.MethodPost, "/conversations", strings.NewReader(`{"message":"halo"}`))
+		req.Header.Set(IdempotencyHeader, "retry-key")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	for _, s := range []int{http.StatusTooManyRequests, http.StatusConflict, 0, http.StatusNotFound} {
+		status = s
+		want := s
+		if s == 0 {
+			want = http.StatusInternalServerError
+		}
+		if got := do(); got != want {
+			t.Fatalf("status %d: got %d", s, got)
+		}
+	}
+	// The 404 is final: replayed without running the handler again.
+	status = http.StatusCreated
+	if got := do(); got != http.StatusNotFound || calls != 4 {
+		t.Fatalf("after a 404: got %d, calls=%d", got, calls)
+	}
+}
@@ -0,0 +1,60 @@
+package middleware
+
+import (
+	"AkuAI/models"
+	"AkuAI/pkg/i18n"
+	svc "AkuAI/pkg/services"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// Locale must run after AuthMiddleware on protected routes. It picks the
+// locale of the response: the signed-in user's preferred language, else the
+// best match of the Accept-Language header. The user's reply preferences
+// go into the request context for the chat handlers.
+func Locale(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		uid, _ := strconv.Atoi(c.GetString(ContextUserIDKey))
+		ApplyPreferences(c, UserPreferences(db, uint(uid)))
+		c.Next()
+	}
+}
+
+// ApplyPreferences attaches p to the request context and sets the locale
+// from p.Language. Handlers that authenticate themselves, like the
+// WebSocket chat, call it directly.
+func ApplyPreferences(c *gin.Context, p models.UserPreferences) {
+	c.Request = c.Request.WithContext(svc.WithPreferences(c.Request.Context(), p))
+	SetLocale(c, p.Language)
+}
+
+// SetLocale stores profile, or the locale Accept-Language asks for when
+// profile is empty, under i18n.ContextKey and in the request context, and
+// returns it. Nothing is stored when the client states no supported
+// locale, so error messages stay as they are and server texts use
+// DEFAULT_LOCALE.
+func SetLocale(c *gin.Context, profile string) string {
+	locale := profile
+	if !i18n.Supports(locale) {
+		locale = i18n.Negotiate(c.GetHeader("Accept-Language"))
+	}
+	if locale == "" {
+		return ""
+	}
+	c.Set(i18n.ContextKey, locale)
+	c.Request = c.Request.WithContext(i18n.WithLocale(c.Request.Context(), locale))
+	c.Header("Content-Language", locale)
+	return locale
+}
+
+// UserPreferences returns the preferences of user uid, the defaults when
+// they never changed any or for anonymous requests.
+func UserPreferences(db *gorm.DB, uid uint) models.UserPreferences {
+	p := models.DefaultPreferences(uid)
+	if uid != 0 {
+		db.Where("user_id = ?", uid).Limit(1).Find(&p)
+	}
+	return p
+}
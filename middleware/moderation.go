@@ -0,0 +1,123 @@
+package middleware
+
+import (
+	"AkuAI/models"
+	"AkuAI/pkg/apierror"
+	"AkuAI/pkg/metrics"
+	"AkuAI/pkg/moderation"
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+var (
+	moderationAllowed = metrics.NewCounter("moderation_allowed_total")
+	moderationFlagged = metrics.NewCounter("moderation_flagged_total")
+	moderationBlocked = metrics.NewCounter("moderation_blocked_total")
+)
+
+// Moderation screens the "message" field of JSON chat requests. Blocked
+// messages get a 422 refusal and never reach the handler; flagged ones pass
+// through. Both are recorded as ModerationEvent rows.
+func Moderation(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if moderation.Default() == nil || c.Request.Body == nil {
+			c.Next()
+			return
+		}
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			apierror.Respond(c, http.StatusBadRequest, "failed to read request body")
+			c.Abort()
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		var payload struct {
+			Message        string `json:"message"`
+			ConversationID *uint  `json:"conversation_id"`
+			Incognito      bool   `json:"incognito"`
+		}
+		if json.Unmarshal(body, &payload) != nil || payload.Message == "" {
+			c.Next()
+			return
+		}
+		uid := c.GetString(ContextUserIDKey)
+		incognito := IncognitoChat(db, uid, payload.ConversationID, payload.Incognito)
+		v := ModerateMessage(c.Request.Context(), db, uid, c.FullPath(), payload.Message, incognito)
+		if v.Action == moderation.Block {
+			AbortModerated(c, v)
+			return
+		}
+		c.Next()
+	}
+}
+
+// IncognitoChat reports whether a message of uid goes to an incognito
+// conversation: the existing conversationID, or a new one asked for with
+// incognito.
+func IncognitoChat(db *gorm.DB, uid string, conversationID *uint, incognito bool) bool {
+	if conversationID == nil || db == nil {
+		return incognito
+	}
+	var conv models.Conversation
+	if err := db.Select("incognito").Where("id = ? AND user_id = ?", *conversationID, uid).First(&conv).Error; err != nil {
+		return false
+	}
+	return conv.Incognito
+}
+
+// ModerateMessage checks text on behalf of uid and records any flag or block.
+// It is used directly by handlers that don't receive the message in an HTTP
+// body, such as the WebSocket chat. For incognito conversations neither the
+// log nor the event keeps the message text or the matched phrase.
+func ModerateMessage(ctx context.Context, db *gorm.DB, uid, path, text string, incognito bool) moderation.Verdict {
+	v := moderation.Default().Check(ctx, text)
+	switch v.Action {
+	case moderation.Allow:
+		moderationAllowed.Inc()
+		return v
+	case moderation.Flag:
+		moderationFlagged.Inc()
+	case moderation.Block:
+		moderationBlocked.Inc()
+	}
+	matched, excerpt := v.Matched, []rune(text)
+	if incognito {
+		matched, excerpt = "", nil
+	}
+	if len(excerpt) > 200 {
+		excerpt = excerpt[:200]
+	}
+	log.Printf("[moderation] %s user=%s category=%s matched=%q source=%s path=%s incognito=%v", v.Action, uid, v.Category, matched, v.Source, path, incognito)
+
+	userID, _ := strconv.ParseUint(uid, 10, 64)
+	ev := models.ModerationEvent{UserID: uint(userID), Action: string(v.Action), Category: v.Category,
+		Matched: matched, Source: v.Source, Path: path, Excerpt: string(excerpt)}
+	if db != nil {
+		if err := db.Create(&ev).Error; err != nil {
+			log.Printf("[moderation] failed to record event: %v", err)
+		}
+	}
+	return v
+}
+
+// AbortModerated answers a blocked message with a structured refusal.
+func AbortModerated(c *gin.Context, v moderation.Verdict) {
+	refusal := moderation.Refusal(v)
+	r := apierror.New(http.StatusUnprocessableEntity, refusal)
+	r.Code = apierror.CodeMessageBlocked
+	r.Details = gin.H{"refusal": gin.H{
+		"action":   v.Action,
+		"category": v.Category,
+		"message":  refusal,
+	}}
+	c.AbortWithStatusJSON(http.StatusUnprocessableEntity, r)
+}
@@ -0,0 +1,52 @@
+package middleware
+
+import (
+	"AkuAI/pkg/moderation"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestModerationBlocksAndFlags(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	moderation.Init(moderation.New(moderation.DefaultBlock, moderation.DefaultFlag, nil))
+	defer moderation.Init(nil)
+
+	var got string
+	r := gin.New()
+	r.POST("/conversations", Moderation(nil), func(c *gin.Context) {
+		var body struct {
+			Message string `json:"message"`
+		}
+		_ = c.ShouldBindJSON(&body)
+		got = body.Message
+		c.Status(http.StatusCreated)
+	})
+
+	cases := []struct {
+		message string
+		code    int
+	}{
+		{"Apa saja webinar UIB bulan November?", http.StatusCreated},
+		{"ada jasa JOKI   skripsi murah?", http.StatusUnprocessableEntity},
+		{"bangsat, jadwal sertifikasi kapan?", http.StatusCreated},
+		{"informasi joki olahraga kampus", http.StatusCreated},
+	}
+	for _, tc := range cases {
+		got = ""
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/conversations", strings.NewReader(`{"message":"`+tc.message+`"}`)))
+		if w.Code != tc.code {
+			t.Fatalf("%q: expected %d, got %d (%s)", tc.message, tc.code, w.Code, w.Body.String())
+		}
+		if tc.code == http.StatusCreated && got != tc.message {
+			t.Fatalf("%q: handler saw %q, body was not restored", tc.message, got)
+		}
+		if tc.code == http.StatusUnprocessableEntity && (!strings.Contains(w.Body.String(), `"category":"academic_fraud"`) || !strings.Contains(w.Body.String(), `"code":"message_blocked"`)) {
+			t.Fatalf("expected structured refusal, got %s", w.Body.String())
+		}
+	}
+}
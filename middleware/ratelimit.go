@@ -1,6 +1,7 @@
 package middleware
 
 import (
+	"AkuAI/pkg/apierror"
 	"net"
 	"net/http"
 	"strconv"
@@ -31,8 +32,24 @@ var (
 	dupTTL = 45 * time.Second
 
 	cgMu     sync.Mutex
-	userSem  = map[string]chan struct{}{}
+	userSem  = map[string]*userSlots{}
 	userConc = 2
+
+	// Guests share tighter buckets keyed by IP alone, as a new guest ID is
+	// one dropped cookie away.
+	guestBuckets  = map[string]*bucket{}
+	guestWindow   = time.Minute
+	guestCapacity = 5
+
+	// Uploads are counted per user, whatever their IP.
+	uploadBuckets  = map[string]*bucket{}
+	uploadWindow   = time.Hour
+	uploadCapacity = 10
+
+	// Emailed transcripts are counted per user too.
+	emailBuckets  = map[string]*bucket{}
+	emailWindow   = time.Hour
+	emailCapacity = 5
 )
 
 func SetRateLimitConfig(win time.Duration, cap, conc int) {
@@ -46,6 +63,27 @@ func SetRateLimitConfig(win time.Duration, cap, conc int) {
 	cgMu.Unlock()
 }
 
+func SetGuestRateLimitConfig(win time.Duration, cap int) {
+	rlMu.Lock()
+	guestWindow = win
+	guestCapacity = cap
+	rlMu.Unlock()
+}
+
+func SetUploadRateLimitConfig(win time.Duration, cap int) {
+	rlMu.Lock()
+	uploadWindow = win
+	uploadCapacity = cap
+	rlMu.Unlock()
+}
+
+func SetTranscriptEmailRateLimitConfig(win time.Duration, cap int) {
+	rlMu.Lock()
+	emailWindow = win
+	emailCapacity = cap
+	rlMu.Unlock()
+}
+
 func SetDuplicateTTL(ttl time.Duration) {
 	dupMu.Lock()
 	dupTTL = ttl
@@ -69,39 +107,99 @@ func userKey(c *gin.Context) string {
 
 func RateLimit() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		key := userKey(c)
-		now := time.Now()
-
 		rlMu.Lock()
-		b := buckets[key]
-		if b == nil {
-			b = &bucket{tokens: capacity, lastRefill: now}
-			buckets[key] = b
-		}
-		elapsed := now.Sub(b.lastRefill)
-		if elapsed > 0 {
-			add := int(float64(refillPerWd) * (float64(elapsed) / float64(window)))
-			if add > 0 {
-				b.tokens += add
-				if b.tokens > capacity {
-					b.tokens = capacity
-				}
-				b.lastRefill = now
-			}
+		ok := take(buckets, userKey(c), capacity, refillPerWd, window)
+		win := window
+		rlMu.Unlock()
+		if !ok {
+			c.Header("Retry-After", strconv.Itoa(int(win.Seconds())))
+			apierror.Respond(c, http.StatusTooManyRequests, "too many requests")
+			c.Abort()
+			return
 		}
-		if b.tokens <= 0 {
-			rlMu.Unlock()
-			c.Header("Retry-After", strconv.Itoa(int(window.Seconds())))
-			c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{"msg": "too many requests"})
+		c.Next()
+	}
+}
+
+// GuestRateLimit is RateLimit for unauthenticated guest chat.
+func GuestRateLimit() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		rlMu.Lock()
+		ok := take(guestBuckets, clientIP(c), guestCapacity, guestCapacity, guestWindow)
+		win := guestWindow
+		rlMu.Unlock()
+		if !ok {
+			c.Header("Retry-After", strconv.Itoa(int(win.Seconds())))
+			apierror.Respond(c, http.StatusTooManyRequests, "too many requests")
+			c.Abort()
 			return
 		}
-		b.tokens--
+		c.Next()
+	}
+}
+
+// UploadRateLimit limits how many files a user may upload per window.
+func UploadRateLimit() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		rlMu.Lock()
+		ok := take(uploadBuckets, c.GetString(ContextUserIDKey), uploadCapacity, uploadCapacity, uploadWindow)
+		win := uploadWindow
 		rlMu.Unlock()
+		if !ok {
+			c.Header("Retry-After", strconv.Itoa(int(win.Seconds())))
+			apierror.Respond(c, http.StatusTooManyRequests, "too many uploads")
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
 
+// TranscriptEmailRateLimit limits how many transcripts a user may email per
+// window.
+func TranscriptEmailRateLimit() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		rlMu.Lock()
+		ok := take(emailBuckets, c.GetString(ContextUserIDKey), emailCapacity, emailCapacity, emailWindow)
+		win := emailWindow
+		rlMu.Unlock()
+		if !ok {
+			c.Header("Retry-After", strconv.Itoa(int(win.Seconds())))
+			apierror.Respond(c, http.StatusTooManyRequests, "too many transcript emails")
+			c.Abort()
+			return
+		}
 		c.Next()
 	}
 }
 
+// take refills the bucket of key and spends a token if one is left. rlMu
+// must be held.
+func take(m map[string]*bucket, key string, capacity, refill int, window time.Duration) bool {
+	now := time.Now()
+	b := m[key]
+	if b == nil {
+		b = &bucket{tokens: capacity, lastRefill: now}
+		m[key] = b
+	}
+	elapsed := now.Sub(b.lastRefill)
+	if elapsed > 0 {
+		add := int(float64(refill) * (float64(elapsed) / float64(window)))
+		if add > 0 {
+			b.tokens += add
+			if b.tokens > capacity {
+				b.tokens = capacity
+			}
+			b.lastRefill = now
+		}
+	}
+	if b.tokens <= 0 {
+		return false
+	}
+	b.tokens--
+	return true
+}
+
 func DuplicateGuard(uid string, text string) bool {
 	now := time.Now()
 	k := uid
@@ -118,15 +216,3 @@ func DuplicateGuard(uid string, text string) bool {
 	dupMu.Unlock()
 	return true
 }
-
-func AcquireUserSlot(uid string) (release func()) {
-	cgMu.Lock()
-	sem := userSem[uid]
-	if sem == nil {
-		sem = make(chan struct{}, userConc)
-		userSem[uid] = sem
-	}
-	cgMu.Unlock()
-	sem <- struct{}{}
-	return func() { <-sem }
-}
@@ -1,10 +1,45 @@
 package middleware
 
 import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
 	"testing"
 	"time"
+
+	"github.com/gin-gonic/gin"
 )
 
+func TestRateLimitEnvelope(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	SetGuestRateLimitConfig(time.Minute, 1)
+	defer SetGuestRateLimitConfig(time.Minute, 5)
+
+	r := gin.New()
+	r.POST("/guest/conversations", GuestRateLimit(), func(c *gin.Context) { c.Status(http.StatusCreated) })
+	send := func() *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/guest/conversations", nil)
+		req.RemoteAddr = "198.51.100.7:4000"
+		r.ServeHTTP(w, req)
+		return w
+	}
+	if w := send(); w.Code != http.StatusCreated {
+		t.Fatalf("first request: %d", w.Code)
+	}
+	w := send()
+	var body struct {
+		Code string `json:"code"`
+		Msg  string `json:"msg"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil || w.Code != http.StatusTooManyRequests || body.Code != "rate_limited" || body.Msg == "" {
+		t.Fatalf("over the limit: %d %s", w.Code, w.Body.String())
+	}
+	if w.Header().Get("Retry-After") == "" {
+		t.Error("no Retry-After on 429")
+	}
+}
+
 func TestDuplicateGuard(t *testing.T) {
 	SetDuplicateTTL(50 * time.Millisecond)
 	uid := "user-123"
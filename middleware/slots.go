@@ -0,0 +1,163 @@
+package middleware
+
+import (
+	"AkuAI/pkg/apierror"
+	"AkuAI/pkg/metrics"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+var (
+	ErrSlotQueueFull = errors.New("too many queued requests for this user")
+	ErrSlotTimeout   = errors.New("timed out waiting for a free slot")
+)
+
+// SlotBusyError is returned by TryAcquireUserSlot when the caller did not get
+// a slot. Position is the caller's place in the user's wait queue.
+type SlotBusyError struct {
+	Err      error
+	Position int
+	Waited   time.Duration
+}
+
+func (e *SlotBusyError) Error() string {
+	return fmt.Sprintf("%v (queue position %d)", e.Err, e.Position)
+}
+
+func (e *SlotBusyError) Unwrap() error { return e.Err }
+
+type userSlots struct {
+	sem     chan struct{}
+	waiting int
+}
+
+var (
+	slotQueueLen = 4
+	slotMaxWait  = 15 * time.Second
+
+	slotWaitSeconds   = metrics.NewHistogram("user_slot_wait_seconds", []float64{0.01, 0.1, 0.5, 1, 2, 5, 10, 30})
+	slotAcquired      = metrics.NewCounter("user_slot_acquired_total")
+	slotRejectedFull  = metrics.NewCounter("user_slot_rejected_queue_full_total")
+	slotRejectedTimed = metrics.NewCounter("user_slot_rejected_timeout_total")
+)
+
+// SetSlotQueueConfig bounds how many requests per user may wait for a slot and
+// for how long. Safe to call at runtime (admin API).
+func SetSlotQueueConfig(queueLen int, maxWait time.Duration) {
+	cgMu.Lock()
+	slotQueueLen = queueLen
+	slotMaxWait = maxWait
+	cgMu.Unlock()
+}
+
+func SlotQueueConfig() (conc, queueLen int, maxWait time.Duration) {
+	cgMu.Lock()
+	defer cgMu.Unlock()
+	return userConc, slotQueueLen, slotMaxWait
+}
+
+func slotsFor(uid string) *userSlots {
+	s := userSem[uid]
+	if s == nil {
+		s = &userSlots{sem: make(chan struct{}, userConc)}
+		userSem[uid] = s
+	}
+	return s
+}
+
+// AcquireUserSlotNow takes one of the user's concurrent slots if one is
+// free, without waiting. Background jobs use it and go back in the queue
+// when ok is false, so a busy user can't hold a worker; HTTP handlers
+// should use TryAcquireUserSlot.
+func AcquireUserSlotNow(uid string) (release func(), ok bool) {
+	cgMu.Lock()
+	s := slotsFor(uid)
+	cgMu.Unlock()
+	select {
+	case s.sem <- struct{}{}:
+		slotWaitSeconds.Observe(0)
+		slotAcquired.Inc()
+		return func() { <-s.sem }, true
+	default:
+		return nil, false
+	}
+}
+
+// TryAcquireUserSlot waits a bounded time for a slot. It fails fast with a
+// *SlotBusyError when the user's wait queue is full, and gives up after the
+// configured max wait or when ctx is done.
+func TryAcquireUserSlot(ctx context.Context, uid string) (release func(), err error) {
+	cgMu.Lock()
+	s := slotsFor(uid)
+	select {
+	case s.sem <- struct{}{}:
+		cgMu.Unlock()
+		slotWaitSeconds.Observe(0)
+		slotAcquired.Inc()
+		return func() { <-s.sem }, nil
+	default:
+	}
+	if s.waiting >= slotQueueLen {
+		position := s.waiting + 1
+		cgMu.Unlock()
+		slotRejectedFull.Inc()
+		log.Printf("[slots] ⛔ user=%s queue full (position=%d)", uid, position)
+		return nil, &SlotBusyError{Err: ErrSlotQueueFull, Position: position}
+	}
+	s.waiting++
+	position := s.waiting
+	maxWait := slotMaxWait
+	cgMu.Unlock()
+
+	start := time.Now()
+	timer := time.NewTimer(maxWait)
+	defer timer.Stop()
+	defer func() {
+		cgMu.Lock()
+		s.waiting--
+		cgMu.Unlock()
+	}()
+
+	select {
+	case s.sem <- struct{}{}:
+		slotWaitSeconds.Observe(time.Since(start).Seconds())
+		slotAcquired.Inc()
+		return func() { <-s.sem }, nil
+	case <-timer.C:
+	case <-ctx.Done():
+	}
+	waited := time.Since(start)
+	slotWaitSeconds.Observe(waited.Seconds())
+	slotRejectedTimed.Inc()
+	log.Printf("[slots] ⏳ user=%s gave up after %v (position=%d)", uid, waited.Round(time.Millisecond), position)
+	return nil, &SlotBusyError{Err: ErrSlotTimeout, Position: position, Waited: waited}
+}
+
+// AbortSlotBusy answers a request that could not get a slot with 429 and the
+// caller's queue position under details.
+func AbortSlotBusy(c *gin.Context, err error) {
+	position := 0
+	var busy *SlotBusyError
+	if errors.As(err, &busy) {
+		position = busy.Position
+	}
+	_, _, maxWait := SlotQueueConfig()
+	retryAfter := int(maxWait.Seconds())
+	if retryAfter < 1 {
+		retryAfter = 1
+	}
+	c.Header("Retry-After", strconv.Itoa(retryAfter))
+	apierror.RespondDetails(c, http.StatusTooManyRequests, "too many concurrent requests, please retry", gin.H{
+		"reason":         err.Error(),
+		"queue_position": position,
+		"retry_after":    retryAfter,
+	})
+	c.Abort()
+}
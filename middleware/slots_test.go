@@ -0,0 +1,43 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestTryAcquireUserSlotBounded(t *testing.T) {
+	SetRateLimitConfig(10*time.Second, 5, 1)
+	SetSlotQueueConfig(1, 50*time.Millisecond)
+	uid := "slot-user"
+
+	release, err := TryAcquireUserSlot(context.Background(), uid)
+	if err != nil {
+		t.Fatalf("expected first slot, got %v", err)
+	}
+
+	waitErr := make(chan error, 1)
+	go func() {
+		_, err := TryAcquireUserSlot(context.Background(), uid)
+		waitErr <- err
+	}()
+	time.Sleep(10 * time.Millisecond)
+
+	_, err = TryAcquireUserSlot(context.Background(), uid)
+	var busy *SlotBusyError
+	if !errors.As(err, &busy) || !errors.Is(err, ErrSlotQueueFull) || busy.Position != 2 {
+		t.Fatalf("expected queue-full error at position 2, got %v", err)
+	}
+
+	if err := <-waitErr; !errors.Is(err, ErrSlotTimeout) {
+		t.Fatalf("expected waiter to time out, got %v", err)
+	}
+
+	release()
+	release2, err := TryAcquireUserSlot(context.Background(), uid)
+	if err != nil {
+		t.Fatalf("expected slot after release, got %v", err)
+	}
+	release2()
+}
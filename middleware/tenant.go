@@ -0,0 +1,38 @@
+package middleware
+
+import (
+	"AkuAI/pkg/apierror"
+	"AkuAI/pkg/tenant"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Tenant picks the tenant of the request from its host name or the
+// X-Tenant-ID header (see tenant.Registry.Resolve) and attaches it to the
+// request context. A header naming an unknown tenant is rejected.
+func Tenant() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		t, err := tenant.Default().Resolve(c.Request.Host, c.GetHeader(tenant.Header))
+		if err != nil {
+			apierror.RespondCode(c, http.StatusBadRequest, apierror.CodeUnknownTenant, "unknown tenant")
+			c.Abort()
+			return
+		}
+		c.Request = c.Request.WithContext(tenant.WithTenant(c.Request.Context(), t))
+		c.Next()
+	}
+}
+
+// OperatorOnly must run after AdminMiddleware. It keeps the admins of
+// tenants out of settings that apply to the whole deployment.
+func OperatorOnly() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !tenant.FromContext(c.Request.Context()).IsOperator() {
+			apierror.Respond(c, http.StatusForbidden, "operator admin access required")
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
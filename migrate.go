@@ -0,0 +1,88 @@
+package main
+
+import (
+	"AkuAI/pkg/config"
+	"AkuAI/pkg/migrations"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+const migrateUsage = "usage: AkuAI migrate [up | down | status | to <id>]"
+
+// runMigrate implements the migrate subcommand.
+func runMigrate(db *gorm.DB, args []string) error {
+	cmd := "up"
+	if len(args) > 0 {
+		cmd = args[0]
+	}
+	switch cmd {
+	case "up":
+		pending, err := migrations.Pending(db)
+		if err != nil {
+			return err
+		}
+		if len(pending) == 0 {
+			fmt.Println("[migrate] nothing to apply")
+			return nil
+		}
+		if err := migrations.Up(db); err != nil {
+			return err
+		}
+		fmt.Printf("[migrate] ✅ applied %s\n", strings.Join(pending, ", "))
+	case "to":
+		if len(args) < 2 {
+			return errors.New(migrateUsage)
+		}
+		if err := migrations.To(db, args[1]); err != nil {
+			return err
+		}
+		fmt.Printf("[migrate] ✅ migrated to %s\n", args[1])
+	case "down":
+		if err := migrations.Down(db); err != nil {
+			return err
+		}
+		fmt.Println("[migrate] ✅ rolled back the last migration")
+	case "status":
+		applied, err := migrations.Applied(db)
+		if err != nil {
+			return err
+		}
+		pending, err := migrations.Pending(db)
+		if err != nil {
+			return err
+		}
+		for _, id := range applied {
+			fmt.Printf("applied  %s\n", id)
+		}
+		for _, id := range pending {
+			fmt.Printf("pending  %s\n", id)
+		}
+	default:
+		return errors.New(migrateUsage)
+	}
+	return nil
+}
+
+// checkMigrations refuses to start a production server with pending
+// migrations and applies them automatically everywhere else.
+func checkMigrations(db *gorm.DB) {
+	pending, err := migrations.Pending(db)
+	if err != nil {
+		log.Fatalf("[migrate] failed to read migration history: %v", err)
+	}
+	if len(pending) == 0 {
+		return
+	}
+	if config.IsProduction {
+		log.Fatalf("[migrate] ❌ %d pending migrations (%s); run `AkuAI migrate up` before starting the server",
+			len(pending), strings.Join(pending, ", "))
+	}
+	log.Printf("[migrate] applying %d pending migrations: %s", len(pending), strings.Join(pending, ", "))
+	if err := migrations.Up(db); err != nil {
+		log.Fatalf("[migrate] failed: %v", err)
+	}
+}
@@ -0,0 +1,28 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Announcement is an admin message shown to every user between StartsAt and
+// ExpiresAt (nil = never expires): once at the top of their next chat reply
+// and as a WebSocket broadcast when it starts (see pkg/announce).
+type Announcement struct {
+	gorm.Model
+	Title       string     `gorm:"size:200"`
+	Body        string     `gorm:"type:text;not null"`
+	StartsAt    time.Time  `gorm:"index;not null"`
+	ExpiresAt   *time.Time `gorm:"index"`
+	CreatedBy   uint       `gorm:"index"`
+	BroadcastAt *time.Time // when it was pushed to open WebSocket connections
+}
+
+// AnnouncementReceipt records that a user has been shown an announcement.
+type AnnouncementReceipt struct {
+	ID             uint `gorm:"primaryKey"`
+	AnnouncementID uint `gorm:"uniqueIndex:idx_announcement_receipt;not null"`
+	UserID         uint `gorm:"uniqueIndex:idx_announcement_receipt;not null"`
+	CreatedAt      time.Time
+}
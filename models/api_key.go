@@ -0,0 +1,48 @@
+package models
+
+import (
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// APIKey lets another campus system call the API without a user login. Only
+// the SHA-256 of the key is stored; Prefix is kept to recognise it in lists.
+type APIKey struct {
+	gorm.Model
+	Name       string `gorm:"size:100;not null"`
+	Prefix     string `gorm:"size:16;index;not null"`
+	KeyHash    string `gorm:"size:64;uniqueIndex;not null"`
+	Scopes     string `gorm:"size:255;not null"` // comma-separated, e.g. "uib:read,images:search"
+	CreatedBy  uint   `gorm:"index"`
+	ExpiresAt  *time.Time
+	RevokedAt  *time.Time
+	LastUsedAt *time.Time
+}
+
+// ScopeList returns the scopes of the key.
+func (k APIKey) ScopeList() []string {
+	var out []string
+	for _, s := range strings.Split(k.Scopes, ",") {
+		if s = strings.TrimSpace(s); s != "" {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+// HasScope reports whether the key grants scope.
+func (k APIKey) HasScope(scope string) bool {
+	for _, s := range k.ScopeList() {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// Active reports whether the key can be used at now.
+func (k APIKey) Active(now time.Time) bool {
+	return k.RevokedAt == nil && (k.ExpiresAt == nil || k.ExpiresAt.After(now))
+}
@@ -0,0 +1,19 @@
+package models
+
+import "time"
+
+// AuditLog records a sensitive operation (see pkg/audit): who did it, from
+// where, and the target's state before and after as JSON ("" when there is
+// none, e.g. no "after" for a deletion).
+type AuditLog struct {
+	ID         uint      `gorm:"primaryKey"`
+	Action     string    `gorm:"size:50;index;not null"` // e.g. auth.login, conversation.delete
+	ActorID    uint      `gorm:"index"`                  // 0 when nobody is signed in (failed login)
+	TargetType string    `gorm:"size:30;index:idx_audit_target"`
+	TargetID   string    `gorm:"size:64;index:idx_audit_target"`
+	IP         string    `gorm:"size:64"`
+	UserAgent  string    `gorm:"size:255"`
+	Before     string    `gorm:"type:text"`
+	After      string    `gorm:"type:text"`
+	CreatedAt  time.Time `gorm:"index"`
+}
@@ -0,0 +1,21 @@
+package models
+
+import "time"
+
+// MessageBookmark is a bot reply a user saved, with an optional note.
+type MessageBookmark struct {
+	ID        uint   `gorm:"primaryKey"`
+	UserID    uint   `gorm:"not null;uniqueIndex:idx_bookmark_user_message,priority:1"`
+	MessageID uint   `gorm:"not null;uniqueIndex:idx_bookmark_user_message,priority:2;index"`
+	Note      string `gorm:"size:500"`
+	CreatedAt time.Time
+}
+
+// MessageReaction is one emoji a user put on a bot reply.
+type MessageReaction struct {
+	ID        uint   `gorm:"primaryKey"`
+	UserID    uint   `gorm:"not null;uniqueIndex:idx_reaction_user_message_emoji,priority:1"`
+	MessageID uint   `gorm:"not null;uniqueIndex:idx_reaction_user_message_emoji,priority:2;index"`
+	Emoji     string `gorm:"size:32;not null;uniqueIndex:idx_reaction_user_message_emoji,priority:3"`
+	CreatedAt time.Time
+}
@@ -0,0 +1,27 @@
+package models
+
+import "time"
+
+// ChatLink ties a messaging-app chat (a Telegram chat id, a WhatsApp phone
+// number) to the user who linked it, and to the conversation its messages
+// go to.
+type ChatLink struct {
+	ID             uint   `gorm:"primaryKey"`
+	Platform       string `gorm:"size:16;not null;uniqueIndex:idx_chat_link,priority:1"`
+	ExternalID     string `gorm:"size:64;not null;uniqueIndex:idx_chat_link,priority:2"`
+	UserID         uint   `gorm:"not null;index"`
+	DisplayName    string `gorm:"size:120"`
+	ConversationID *uint
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+}
+
+// ChatLinkCode is a one-time code a signed-in user sends to the bot to link
+// a chat to their account.
+type ChatLinkCode struct {
+	ID        uint      `gorm:"primaryKey"`
+	Code      string    `gorm:"size:16;not null;uniqueIndex"`
+	UserID    uint      `gorm:"not null;index"`
+	ExpiresAt time.Time `gorm:"not null"`
+	CreatedAt time.Time
+}
@@ -1,10 +1,32 @@
 package models
 
-import "gorm.io/gorm"
+import (
+	"time"
 
+	"gorm.io/gorm"
+)
+
+// Conversation spells out gorm.Model so UpdatedAt can join the user_id index
+// that backs the conversation list.
 type Conversation struct {
-	gorm.Model
-	UserID   uint      `gorm:"not null;index"`
-	Title    string    `gorm:"size:200"`
-	Messages []Message `gorm:"constraint:OnDelete:CASCADE"`
+	ID         uint `gorm:"primarykey"`
+	CreatedAt  time.Time
+	UpdatedAt  time.Time      `gorm:"index:idx_conversations_user_updated,priority:2"`
+	DeletedAt  gorm.DeletedAt `gorm:"index"`
+	UserID     uint           `gorm:"not null;index;index:idx_conversations_user_updated,priority:1"`
+	TenantID   string         `gorm:"size:64;not null;default:'';index"`
+	Title      string         `gorm:"size:512;serializer:encrypted"` // room for the sealed form, see pkg/fieldcrypt
+	Archived   bool           `gorm:"not null;default:false;index"`
+	ArchivedAt *time.Time     `gorm:"index"`
+	PromptArm  string         `gorm:"size:20;index"` // online A/B arm (baseline | engineered), "" when not in the split
+	GuestID    *string        `gorm:"size:40;index"` // guest conversation (UserID 0) until claimed by an account
+	Pinned     bool           `gorm:"not null;default:false"`
+	FolderID   *uint          `gorm:"index"`
+	Incognito  bool           `gorm:"not null;default:false;index"` // privacy mode: purged after the session, kept out of caches, analytics and prompt logs
+	Messages   []Message      `gorm:"constraint:OnDelete:CASCADE"`
+	PinnedAt   *time.Time
+
+	// Set when the user sent FRUSTRATION_ALERT_COUNT frustrated messages in a
+	// row, cleared when an admin reviews the conversation
+	FrustrationFlaggedAt *time.Time `gorm:"index"`
 }
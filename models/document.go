@@ -0,0 +1,23 @@
+package models
+
+import "gorm.io/gorm"
+
+// Document is an uploaded FAQ or guide the assistant can cite.
+type Document struct {
+	gorm.Model
+	Title       string          `gorm:"size:200;not null"`
+	Filename    string          `gorm:"size:255;not null"`
+	ContentType string          `gorm:"size:50"`
+	Size        int64           `gorm:"not null"`
+	UploadedBy  uint            `gorm:"index"`
+	Chunks      []DocumentChunk `gorm:"constraint:OnDelete:CASCADE"`
+}
+
+// DocumentChunk is one indexed passage of a Document.
+type DocumentChunk struct {
+	ID         uint   `gorm:"primaryKey"`
+	DocumentID uint   `gorm:"index;not null"`
+	Seq        int    `gorm:"not null"`
+	Heading    string `gorm:"size:255"`
+	Text       string `gorm:"type:text;not null"`
+}
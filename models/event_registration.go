@@ -0,0 +1,37 @@
+package models
+
+import "time"
+
+// Registration statuses.
+const (
+	RegistrationConfirmed  = "confirmed"
+	RegistrationWaitlisted = "waitlisted"
+	RegistrationCancelled  = "cancelled"
+)
+
+// EventRegistration is a user's registration for a dataset event. Events
+// live in the campus JSON files, so they are referred to by campus
+// (institution) and event ID. Cancelling and registering again reuses the
+// row; QueuedAt orders the waiting list. CertificatePath is the stored
+// certificate of attendance, generated on first download after check-in.
+type EventRegistration struct {
+	ID              uint      `gorm:"primaryKey"`
+	UserID          uint      `gorm:"not null;uniqueIndex:idx_registration_event_user,priority:3;index"`
+	TenantID        string    `gorm:"size:64;not null;default:'';index"`
+	Campus          string    `gorm:"size:191;not null;uniqueIndex:idx_registration_event_user,priority:1"`
+	EventID         string    `gorm:"size:64;not null;uniqueIndex:idx_registration_event_user,priority:2"`
+	Status          string    `gorm:"size:16;not null;index"`
+	QueuedAt        time.Time `gorm:"not null"`
+	ConfirmedAt     *time.Time
+	CancelledAt     *time.Time
+	CheckedInAt     *time.Time
+	CertificatePath string `gorm:"size:255"`
+	CreatedAt       time.Time
+	UpdatedAt       time.Time
+}
+
+// Active reports whether the registration holds a seat or a waiting-list
+// place.
+func (r EventRegistration) Active() bool {
+	return r.Status == RegistrationConfirmed || r.Status == RegistrationWaitlisted
+}
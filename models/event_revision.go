@@ -0,0 +1,19 @@
+package models
+
+import "time"
+
+// EventRevision records a change to a dataset event: who made it, through
+// what (Source, e.g. "import"), and the changed fields as a JSON list of
+// {"field", "before", "after"}. A created event lists every field it was
+// created with.
+type EventRevision struct {
+	ID        uint      `gorm:"primaryKey"`
+	TenantID  string    `gorm:"size:64;not null;default:'';index"`
+	Campus    string    `gorm:"size:191;not null;index:idx_event_revision_event,priority:1"`
+	EventID   string    `gorm:"size:64;not null;index:idx_event_revision_event,priority:2"`
+	ActorID   uint      `gorm:"index"`
+	Source    string    `gorm:"size:32;not null"`
+	Action    string    `gorm:"size:16;not null"` // create, update
+	Changes   string    `gorm:"type:text"`
+	CreatedAt time.Time `gorm:"index"`
+}
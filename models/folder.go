@@ -0,0 +1,13 @@
+package models
+
+import "time"
+
+// Folder is a user-defined group of conversations. Deleting a folder leaves
+// its conversations unfiled.
+type Folder struct {
+	ID        uint   `gorm:"primaryKey"`
+	UserID    uint   `gorm:"not null;uniqueIndex:idx_folder_user_name,priority:1"`
+	Name      string `gorm:"size:100;not null;uniqueIndex:idx_folder_user_name,priority:2"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
@@ -3,13 +3,44 @@ package models
 import (
 	"time"
 
+	_ "AkuAI/pkg/fieldcrypt" // the "encrypted" serializer, on when FIELD_ENCRYPTION_KEYS is set
 	"gorm.io/gorm"
 )
 
+// Bot message statuses. Replies that were cut short keep the partial text and
+// can be finished with POST /conversations/:id/continue.
+const (
+	MessageCompleted = "completed"
+	MessageStopped   = "stopped" // the user stopped the stream or the connection dropped
+	MessageError     = "error"   // generation failed or timed out part-way
+)
+
 type Message struct {
 	gorm.Model
-	ConversationID uint      `gorm:"index;not null"`
-	Sender         string    `gorm:"size:20;not null"` // "user" or "bot"
-	Text           string    `gorm:"type:text;not null"`
-	Timestamp      time.Time `gorm:"autoCreateTime"`
+	ConversationID uint              `gorm:"index;index:idx_messages_conversation_timestamp,priority:1;not null"`
+	Sender         string            `gorm:"size:20;not null"` // "user" or "bot"
+	Text           string            `gorm:"type:text;not null;serializer:encrypted"`
+	Timestamp      time.Time         `gorm:"autoCreateTime;index:idx_messages_conversation_timestamp,priority:2"`
+	Topic          string            `gorm:"size:20;index"` // user messages, set by pkg/analytics
+	Label          string            `gorm:"size:20;index"` // user messages: events | academics | admissions | facilities | other
+	Sentiment      string            `gorm:"size:12;index"` // user messages: positive | neutral | frustrated
+	LatencyMs      int64             // bot messages: time since the user message they answer
+	Confidence     *float64          // nil for user messages
+	LowConfidence  bool              `gorm:"not null;default:false"`
+	PromptMode     string            `gorm:"size:20;index"`      // bot messages: baseline | engineered prompt that produced it
+	Feedback       int8              `gorm:"not null;default:0"` // bot messages: 1 thumbs up, -1 thumbs down
+	Status         string            `gorm:"size:16;not null;default:completed"`
+	Citations      []MessageCitation `gorm:"constraint:OnDelete:CASCADE"`
+	// Generation metadata (bot messages) for offline evaluation of live replies
+	ModelName             string `gorm:"column:model;size:64"` // Gemini model, "local" for the fallback responder
+	PromptTemplateID      string `gorm:"size:64;index"`
+	PromptTemplateVersion string `gorm:"size:20"`
+	ContextHash           string `gorm:"size:64"` // sha256 of the event context, as in abtest prompt logs
+	DatasetHash           string `gorm:"size:64"` // sha256 of the event dataset file the context came from
+	FinishReason          string `gorm:"size:32"`
+	Cached                bool   `gorm:"not null;default:false"`
+	Parts                 int    `gorm:"not null;default:1"` // model calls stitched together; over 1 when continued past MAX_TOKENS
+	PromptTokens          int    `gorm:"not null;default:0"` // Gemini usageMetadata, summed over the calls of the reply
+	OutputTokens          int    `gorm:"not null;default:0"`
+	Route                 string `gorm:"size:16"` // GEMINI_ROUTING route: simple, standard or complex; "" when off
 }
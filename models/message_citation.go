@@ -0,0 +1,13 @@
+package models
+
+// MessageCitation links a bot message to a UIB event cited with an [EV-xxx]
+// marker or to a document chunk cited with a [DOC-x-y] marker.
+type MessageCitation struct {
+	ID         uint   `gorm:"primaryKey"`
+	MessageID  uint   `gorm:"index;not null"`
+	EventID    string `gorm:"size:64"`
+	DocumentID uint   `gorm:"index"`
+	Marker     string `gorm:"size:64;not null"`
+	Title      string `gorm:"size:255"`
+	Line       int    `gorm:"not null"`
+}
@@ -0,0 +1,14 @@
+package models
+
+import "gorm.io/gorm"
+
+// AutoMigrate creates or updates the tables of every model. It initialises
+// the schema of databases without migration history (see pkg/migrations);
+// later changes belong in a versioned migration.
+func AutoMigrate(db *gorm.DB) error {
+	if db.Dialector.Name() == "mysql" {
+		// utf8mb4 keeps emoji in messages; SQLite and Postgres are UTF-8 already
+		db = db.Set("gorm:table_options", "ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci")
+	}
+	return db.AutoMigrate(&User{}, &Conversation{}, &Message{}, &MessageCitation{}, &RetentionEvent{}, &ModerationEvent{}, &Document{}, &DocumentChunk{}, &UserMemory{}, &Announcement{}, &AnnouncementReceipt{}, &APIKey{}, &AuditLog{}, &SigningKey{}, &Webhook{}, &WebhookDelivery{}, &ChatLink{}, &ChatLinkCode{}, &Folder{}, &MessageBookmark{}, &MessageReaction{}, &EventRegistration{}, &EventRevision{}, &UserPreferences{}, &PromptVersion{})
+}
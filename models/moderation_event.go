@@ -0,0 +1,16 @@
+package models
+
+import "time"
+
+// ModerationEvent records a chat message that moderation flagged or blocked.
+type ModerationEvent struct {
+	ID        uint      `gorm:"primaryKey"`
+	UserID    uint      `gorm:"index;not null"`
+	Action    string    `gorm:"size:10;index;not null"` // flag | block
+	Category  string    `gorm:"size:40"`
+	Matched   string    `gorm:"size:100"`
+	Source    string    `gorm:"size:20"` // keywords | gemini
+	Path      string    `gorm:"size:100"`
+	Excerpt   string    `gorm:"type:text;serializer:encrypted"` // sealed like the message, see pkg/fieldcrypt
+	CreatedAt time.Time `gorm:"index"`
+}
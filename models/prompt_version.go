@@ -0,0 +1,20 @@
+package models
+
+import "time"
+
+// PromptVersion is one saved version of the system-instruction blocks of
+// event answers for a tenant's campus (Campus "" = all its campuses): the
+// assistant's tone and the rules, a JSON list of strings. Empty blocks fall
+// back to the built-in text. The highest Version of each scope is in
+// effect; older ones are kept for history and restores (see pkg/prompts).
+type PromptVersion struct {
+	ID        uint   `gorm:"primaryKey"`
+	TenantID  string `gorm:"size:64;not null;default:'';uniqueIndex:idx_prompt_version_scope,priority:1"`
+	Campus    string `gorm:"size:191;not null;default:'';uniqueIndex:idx_prompt_version_scope,priority:2"`
+	Version   int    `gorm:"not null;uniqueIndex:idx_prompt_version_scope,priority:3"`
+	Tone      string `gorm:"type:text"`
+	Rules     string `gorm:"type:text"`
+	Note      string `gorm:"size:200"`
+	AuthorID  uint   `gorm:"index"`
+	CreatedAt time.Time
+}
@@ -0,0 +1,15 @@
+package models
+
+import "time"
+
+// RetentionEvent records one action taken by the retention engine.
+type RetentionEvent struct {
+	ID             uint      `gorm:"primaryKey"`
+	RunID          string    `gorm:"size:36;index;not null"`
+	Action         string    `gorm:"size:20;not null"` // archive | purge | purge_trash
+	ConversationID uint      `gorm:"index;not null"`
+	UserID         uint      `gorm:"index;not null"`
+	Reason         string    `gorm:"size:200"`
+	DryRun         bool      `gorm:"not null;default:false"`
+	CreatedAt      time.Time `gorm:"index"`
+}
@@ -0,0 +1,14 @@
+package models
+
+import "time"
+
+// SigningKey is a JWT signing key created by rotation (see pkg/token). The
+// newest key that is not retired signs new tokens; retired keys still verify
+// the tokens they signed until those expire. The key configured with
+// JWT_SECRET_KEY has the id "env" and stores only its retirement.
+type SigningKey struct {
+	ID        string `gorm:"primaryKey;size:32"`
+	Secret    string `gorm:"size:128"` // base64; empty for "env"
+	CreatedAt time.Time
+	RetiredAt *time.Time `gorm:"index"`
+}
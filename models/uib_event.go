@@ -1,27 +1,76 @@
 package models
 
 import (
+	"slices"
+	"strconv"
+	"strings"
 	"time"
 )
 
-// UIBEvent represents a UIB event (certification or webinar)
+// Event types of UIBEvent.Type.
+const (
+	EventTypeCertification = "certification"
+	EventTypeWebinar       = "webinar"
+	EventTypeSeminar       = "seminar"
+	EventTypeWorkshop      = "workshop"
+	EventTypeBootcamp      = "bootcamp"
+	EventTypeCompetition   = "competition"    // lomba
+	EventTypePublicLecture = "public_lecture" // kuliah umum
+)
+
+// EventTypes lists every event type.
+var EventTypes = []string{
+	EventTypeCertification, EventTypeWebinar, EventTypeSeminar, EventTypeWorkshop,
+	EventTypeBootcamp, EventTypeCompetition, EventTypePublicLecture,
+}
+
+// eventTypeAliases are the Indonesian names an event type is asked for by.
+var eventTypeAliases = map[string]string{
+	"sertifikasi": EventTypeCertification,
+	"lomba":       EventTypeCompetition,
+	"kompetisi":   EventTypeCompetition,
+	"kuliah_umum": EventTypePublicLecture,
+	"kuliah-umum": EventTypePublicLecture,
+	"kuliah umum": EventTypePublicLecture,
+}
+
+// NormalizeEventType returns the event type s names, either an EventTypes
+// value or an alias such as "sertifikasi" or "lomba", case-insensitively.
+func NormalizeEventType(s string) (string, bool) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	if slices.Contains(EventTypes, s) {
+		return s, true
+	}
+	t, ok := eventTypeAliases[s]
+	return t, ok
+}
+
+// UIBEvent represents a UIB event
 type UIBEvent struct {
-	ID               string `json:"id"`
-	Type             string `json:"type"` // "certification" or "webinar"
-	Title            string `json:"title"`
-	Date             string `json:"date"`
-	Time             string `json:"time,omitempty"`
-	Location         string `json:"location,omitempty"`
-	Platform         string `json:"platform,omitempty"`
-	Institution      string `json:"institution"`
-	Department       string `json:"department"`
-	Description      string `json:"description"`
-	Speaker          string `json:"speaker,omitempty"`
-	Requirements     string `json:"requirements,omitempty"`
-	RegistrationFee  string `json:"registration_fee,omitempty"`
-	Contact          string `json:"contact,omitempty"`
-	RegistrationLink string `json:"registration_link,omitempty"`
-	Mark             string `json:"mark"` // "UIB_OFFICIAL"
+	ID    string `json:"id"`
+	Type  string `json:"type"` // one of EventTypes
+	Title string `json:"title"`
+	Date  string `json:"date"`
+	Time  string `json:"time,omitempty"`
+	// StartsAt and EndsAt are parsed from Date and Time when the data is
+	// loaded. EndsAt is nil when Time names no end; an event without a Time
+	// starts at midnight and is AllDay.
+	StartsAt         *time.Time `json:"starts_at,omitempty"`
+	EndsAt           *time.Time `json:"ends_at,omitempty"`
+	AllDay           bool       `json:"all_day,omitempty"`
+	Location         string     `json:"location,omitempty"`
+	Platform         string     `json:"platform,omitempty"`
+	Institution      string     `json:"institution"`
+	Department       string     `json:"department"`
+	Faculty          string     `json:"faculty,omitempty"` // faculty of a study program; a faculty's own events may leave it empty
+	Description      string     `json:"description"`
+	Speaker          string     `json:"speaker,omitempty"`
+	Requirements     string     `json:"requirements,omitempty"`
+	RegistrationFee  string     `json:"registration_fee,omitempty"`
+	Fee              *Fee       `json:"fee,omitempty"` // parsed from RegistrationFee when the data is loaded; nil when it doesn't parse
+	Contact          string     `json:"contact,omitempty"`
+	RegistrationLink string     `json:"registration_link,omitempty"`
+	Mark             string     `json:"mark"` // "UIB_OFFICIAL"
 
 	// Additional fields for certifications
 	Certificate       string `json:"certificate,omitempty"`
@@ -38,6 +87,10 @@ type UIBEvent struct {
 	CertificateAttendance bool   `json:"certificate_attendance,omitempty"`
 	NetworkingSession     bool   `json:"networking_session,omitempty"`
 	RegistrationDeadline  string `json:"registration_deadline,omitempty"`
+
+	// Capacity is the number of confirmed registrations; 0 leaves it to
+	// EVENT_DEFAULT_CAPACITY
+	Capacity int `json:"capacity,omitempty"`
 }
 
 // UIBEventsData represents the complete UIB events data structure
@@ -46,35 +99,124 @@ type UIBEventsData struct {
 		October2025  []UIBEvent `json:"october_2025"`
 		November2025 []UIBEvent `json:"november_2025"`
 		December2025 []UIBEvent `json:"december_2025"`
+		// Other holds events outside the monthly lists, such as imported
+		// events of later months
+		Other []UIBEvent `json:"other_events,omitempty"`
 	} `json:"uib_events"`
 	Metadata struct {
+		Version        string `json:"version,omitempty"`
 		LastUpdated    string `json:"last_updated"`
 		TotalEvents    int    `json:"total_events"`
 		Institution    string `json:"institution"`
 		ContactGeneral string `json:"contact_general"`
 		Website        string `json:"website"`
 		Note           string `json:"note"`
+		// Aliases are extra names the campus is asked about by ("ITB", "Ganesha")
+		Aliases []string `json:"aliases,omitempty"`
 	} `json:"metadata"`
 }
 
+// Lists returns every event list of d, monthly lists first.
+func (d *UIBEventsData) Lists() []*[]UIBEvent {
+	e := &d.UIBEvents
+	return []*[]UIBEvent{&e.October2025, &e.November2025, &e.December2025, &e.Other}
+}
+
+// ListFor returns the list an event on date ("2025-11-12") belongs in.
+func (d *UIBEventsData) ListFor(date string) *[]UIBEvent {
+	switch {
+	case strings.HasPrefix(date, "2025-10-"):
+		return &d.UIBEvents.October2025
+	case strings.HasPrefix(date, "2025-11-"):
+		return &d.UIBEvents.November2025
+	case strings.HasPrefix(date, "2025-12-"):
+		return &d.UIBEvents.December2025
+	}
+	return &d.UIBEvents.Other
+}
+
 // EventSearchCriteria for filtering events
 type EventSearchCriteria struct {
-	EventType  string // "certification", "webinar", or "" for all
+	EventType  string // one of EventTypes, or "" for all
 	Month      string // "october", "november", "december", or "" for all
 	DateFrom   time.Time
 	DateTo     time.Time
 	Department string
-	FreeOnly   bool // true to filter only free events
+	Faculty    string
+	FreeOnly   bool  // true to filter only free events
+	MinFee     int64 // rupiah; events whose fee range reaches it, 0 for no bound
+	MaxFee     int64 // rupiah; events whose fee range starts at or below it, 0 for no bound
+}
+
+// Fee is a registration fee in rupiah. Max equals Min for a single price;
+// Min is 0 when the event is free for some participants.
+type Fee struct {
+	Min  int64 `json:"min"`
+	Max  int64 `json:"max"`
+	Free bool  `json:"free"` // Max is 0
+}
+
+// String renders the fee the same way everywhere: "Gratis", "Rp 500.000"
+// or "Rp 100.000 - Rp 250.000" ("Gratis - Rp 50.000" when free for some).
+func (f Fee) String() string {
+	if f.Free {
+		return "Gratis"
+	}
+	if f.Min == f.Max {
+		return rupiah(f.Min)
+	}
+	low := rupiah(f.Min)
+	if f.Min == 0 {
+		low = "Gratis"
+	}
+	return low + " - " + rupiah(f.Max)
+}
+
+// rupiah formats n as "Rp 1.200.000".
+func rupiah(n int64) string {
+	s := strconv.FormatInt(n, 10)
+	for i := len(s) - 3; i > 0; i -= 3 {
+		s = s[:i] + "." + s[i:]
+	}
+	return "Rp " + s
+}
+
+// FeeText is the event's fee as Fee.String renders it, or the raw
+// RegistrationFee when it didn't parse.
+func (e UIBEvent) FeeText() string {
+	if e.Fee != nil {
+		return e.Fee.String()
+	}
+	return e.RegistrationFee
 }
 
 // EventSummary for quick display
 type EventSummary struct {
-	ID         string `json:"id"`
-	Type       string `json:"type"`
-	Title      string `json:"title"`
-	Date       string `json:"date"`
-	Time       string `json:"time"`
+	ID         string     `json:"id"`
+	Type       string     `json:"type"`
+	Title      string     `json:"title"`
+	Date       string     `json:"date"`
+	Time       string     `json:"time"`
+	StartsAt   *time.Time `json:"starts_at,omitempty"`
+	EndsAt     *time.Time `json:"ends_at,omitempty"`
+	Department string     `json:"department"`
+	Faculty    string     `json:"faculty,omitempty"`
+	IsFree     bool       `json:"is_free"`
+	Fee        *Fee       `json:"fee,omitempty"`
+	Mark       string     `json:"mark"`
+}
+
+// DepartmentCount is a department with the number of its events.
+type DepartmentCount struct {
 	Department string `json:"department"`
-	IsFree     bool   `json:"is_free"`
-	Mark       string `json:"mark"`
+	Faculty    string `json:"faculty,omitempty"`
+	Events     int    `json:"events"`
+}
+
+// FacultyCount is a faculty with the number of events of it and its
+// departments.
+type FacultyCount struct {
+	Faculty     string   `json:"faculty"`
+	Events      int      `json:"events"`
+	Departments []string `json:"departments"`
 }
@@ -1,16 +1,25 @@
 package models
 
 import (
+	"time"
+
 	"golang.org/x/crypto/bcrypt"
 	"gorm.io/gorm"
 )
 
 type User struct {
 	gorm.Model
-	Email           string `gorm:"uniqueIndex;size:120;not null"`
-	Username        string `gorm:"uniqueIndex;size:80;not null"`
-	PasswordHash    string `gorm:"size:255;not null"`
-	ProfileImageURL string `gorm:"size:500"`
+	TenantID           string `gorm:"size:64;not null;default:'';uniqueIndex:idx_users_tenant_email,priority:1;uniqueIndex:idx_users_tenant_username,priority:1"` // "" = the operator tenant
+	Email              string `gorm:"uniqueIndex:idx_users_tenant_email,priority:2;size:120;not null"`
+	Username           string `gorm:"uniqueIndex:idx_users_tenant_username,priority:2;size:80;not null"`
+	PasswordHash       string `gorm:"size:255;not null"`
+	ProfileImageURL    string `gorm:"size:500"`
+	ProfileImagePublic bool   `gorm:"not null;default:false"` // profile image served without a signed URL
+	IsAdmin            bool   `gorm:"not null;default:false"`
+	MemoryEnabled      bool   `gorm:"not null;default:false"` // consent to remember facts from chats (UserMemory)
+	DigestEnabled      bool   `gorm:"not null;default:false"` // opted in to the weekly event digest
+	DigestEmail        bool   `gorm:"not null;default:false"` // also email the digest
+	DigestSentAt       *time.Time
 }
 
 func (u *User) SetPassword(password string) error {
@@ -0,0 +1,21 @@
+package models
+
+import "gorm.io/gorm"
+
+// Kinds of facts kept in UserMemory.
+const (
+	MemoryProgram  = "program"  // program of study, one per user
+	MemoryInterest = "interest" // topics such as "data science", several per user
+	MemoryCampus   = "campus"   // preferred campus, one per user
+)
+
+// UserMemory is a stable fact about a user picked up from their chat
+// messages. Facts are only collected while User.MemoryEnabled is set and are
+// deleted when the user turns it off.
+type UserMemory struct {
+	gorm.Model
+	UserID          uint   `gorm:"uniqueIndex:idx_user_memory_fact,priority:1;not null"`
+	Kind            string `gorm:"size:20;uniqueIndex:idx_user_memory_fact,priority:2;not null"`
+	Value           string `gorm:"size:120;uniqueIndex:idx_user_memory_fact,priority:3;not null"`
+	SourceMessageID uint   // the user message the fact was taken from
+}
@@ -0,0 +1,50 @@
+package models
+
+import (
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// How long replies should be, per UserPreferences.Verbosity.
+const (
+	VerbosityShort    = "short"
+	VerbosityNormal   = "normal"
+	VerbosityDetailed = "detailed"
+)
+
+// UserPreferences shape the replies a user gets and the language of the
+// server's own texts. Users without a row have DefaultPreferences.
+type UserPreferences struct {
+	gorm.Model
+	UserID    uint   `gorm:"uniqueIndex;not null"`
+	Language  string `gorm:"size:8"` // id | en; "" follows Accept-Language, and replies the question
+	Verbosity string `gorm:"size:16;not null"`
+	Markdown  bool   `gorm:"not null"`
+	Emoji     bool   `gorm:"not null"`
+}
+
+// DefaultPreferences are the preferences of userID before they change any.
+func DefaultPreferences(userID uint) UserPreferences {
+	return UserPreferences{UserID: userID, Verbosity: VerbosityNormal, Markdown: true, Emoji: true}
+}
+
+// ParseVerbosity returns the verbosity v names, accepting the Indonesian
+// "ringkas" and "lengkap" for short and detailed, and "" for none; ok is
+// false for anything else.
+func ParseVerbosity(v string) (verbosity string, ok bool) {
+	switch v = strings.ToLower(strings.TrimSpace(v)); v {
+	case "", VerbosityShort, VerbosityNormal, VerbosityDetailed:
+		return v, true
+	case "ringkas":
+		return VerbosityShort, true
+	case "lengkap":
+		return VerbosityDetailed, true
+	}
+	return "", false
+}
+
+// IsDefault reports whether p changes nothing about replies.
+func (p UserPreferences) IsDefault() bool {
+	return p.Language == "" && p.Verbosity == VerbosityNormal && p.Markdown && p.Emoji
+}
@@ -0,0 +1,64 @@
+package models
+
+import (
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Webhook is a callback URL that receives events signed with Secret. A user's
+// webhook gets the events about that user; one created by an admin (UserID
+// nil) gets every event it subscribes to.
+type Webhook struct {
+	gorm.Model
+	UserID *uint  `gorm:"index"`
+	URL    string `gorm:"size:500;not null"`
+	Secret string `gorm:"size:100;not null"`
+	Events string `gorm:"size:255;not null"` // comma-separated, e.g. "message.completed,event.created"
+	Active bool   `gorm:"not null;default:true"`
+}
+
+// EventList returns the events the webhook subscribes to.
+func (w Webhook) EventList() []string {
+	var out []string
+	for _, e := range strings.Split(w.Events, ",") {
+		if e = strings.TrimSpace(e); e != "" {
+			out = append(out, e)
+		}
+	}
+	return out
+}
+
+// Subscribes reports whether the webhook receives event.
+func (w Webhook) Subscribes(event string) bool {
+	for _, e := range w.EventList() {
+		if e == event || e == "*" {
+			return true
+		}
+	}
+	return false
+}
+
+// Webhook delivery statuses.
+const (
+	DeliveryPending   = "pending"
+	DeliverySucceeded = "succeeded"
+	DeliveryFailed    = "failed" // gave up after the last attempt
+)
+
+// WebhookDelivery is one event sent to one webhook, with the outcome of its
+// latest attempt. Pending deliveries are retried at NextAttemptAt.
+type WebhookDelivery struct {
+	ID            uint   `gorm:"primaryKey"`
+	WebhookID     uint   `gorm:"index;not null"`
+	Event         string `gorm:"size:64;not null"`
+	Payload       string `gorm:"type:text;not null;serializer:encrypted"` // may quote a reply, see pkg/fieldcrypt
+	Status        string `gorm:"size:16;index:idx_delivery_due,priority:1;not null"`
+	Attempts      int    `gorm:"not null;default:0"`
+	ResponseCode  int
+	Error         string    `gorm:"size:500"`
+	NextAttemptAt time.Time `gorm:"index:idx_delivery_due,priority:2"`
+	DeliveredAt   *time.Time
+	CreatedAt     time.Time
+}
@@ -0,0 +1,76 @@
+// Package abio reads and writes the files exchanged by the A/B evaluation
+// commands under cmd/abtest/results.
+package abio
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// ResultsDir is where abtest writes its runs and the other commands their
+// reports, relative to the module root.
+const ResultsDir = "cmd/abtest/results"
+
+// LatestResultsJSON returns the most recently modified abtest-*.json in dir.
+func LatestResultsJSON(dir string) (string, error) {
+	dir = filepath.Clean(dir)
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return "", err
+	}
+	var latest string
+	var latestT time.Time
+	for _, e := range entries {
+		if e.IsDir() || !strings.HasPrefix(e.Name(), "abtest-") || !strings.HasSuffix(strings.ToLower(e.Name()), ".json") {
+			continue
+		}
+		if info, err := e.Info(); err == nil && (latest == "" || info.ModTime().After(latestT)) {
+			latest, latestT = filepath.Join(dir, e.Name()), info.ModTime()
+		}
+	}
+	if latest == "" {
+		return "", fmt.Errorf("no results json found in %s", dir)
+	}
+	return latest, nil
+}
+
+// ReadJSON decodes the JSON file at path into v.
+func ReadJSON(path string, v any) error {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(b, v)
+}
+
+// WriteJSON writes v as indented JSON.
+func WriteJSON(path string, v any) error {
+	b, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, b, 0o644)
+}
+
+// WriteCSV writes header followed by rows.
+func WriteCSV(path string, header []string, rows [][]string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	w := csv.NewWriter(f)
+	if err := w.Write(header); err != nil {
+		_ = f.Close()
+		return err
+	}
+	if err := w.WriteAll(rows); err != nil {
+		_ = f.Close()
+		return err
+	}
+	return f.Close()
+}
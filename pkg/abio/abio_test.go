@@ -0,0 +1,54 @@
+package abio
+
+import (
+	"encoding/csv"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestLatestResultsJSON(t *testing.T) {
+	dir := t.TempDir()
+	if _, err := LatestResultsJSON(dir); err == nil {
+		t.Fatal("expected error for empty dir")
+	}
+	now := time.Now()
+	for i, name := range []string{"abtest-old.json", "abtest-new.json", "score-newest.json"} {
+		p := filepath.Join(dir, name)
+		if err := os.WriteFile(p, []byte("{}"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		ts := now.Add(time.Duration(i) * time.Minute)
+		_ = os.Chtimes(p, ts, ts)
+	}
+	got, err := LatestResultsJSON(dir)
+	if err != nil || filepath.Base(got) != "abtest-new.json" {
+		t.Fatalf("got %q, %v; want abtest-new.json", got, err)
+	}
+}
+
+func TestJSONRoundTrip(t *testing.T) {
+	p := filepath.Join(t.TempDir(), "x.json")
+	in := map[string]float64{"p": 0.25}
+	if err := WriteJSON(p, in); err != nil {
+		t.Fatal(err)
+	}
+	var out map[string]float64
+	if err := ReadJSON(p, &out); err != nil || out["p"] != 0.25 {
+		t.Fatalf("got %v, %v", out, err)
+	}
+}
+
+func TestWriteCSV(t *testing.T) {
+	p := filepath.Join(t.TempDir(), "x.csv")
+	if err := WriteCSV(p, []string{"query", "mode"}, [][]string{{"a, b", "baseline"}}); err != nil {
+		t.Fatal(err)
+	}
+	f, _ := os.Open(p)
+	defer f.Close()
+	recs, err := csv.NewReader(f).ReadAll()
+	if err != nil || len(recs) != 2 || recs[1][0] != "a, b" {
+		t.Fatalf("got %v, %v", recs, err)
+	}
+}
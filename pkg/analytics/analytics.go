@@ -0,0 +1,341 @@
+package analytics
+
+import (
+	"AkuAI/models"
+	svc "AkuAI/pkg/services"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Register installs create callbacks that tag messages as they are
+// written: user messages get a Topic and a Sentiment, bot messages the
+// latency since the user message they answer. A run of frustrated messages
+// flags the conversation for review. Every write path is covered without
+// the handlers having to know about analytics.
+func Register(db *gorm.DB) error {
+	if err := db.Callback().Create().Before("gorm:create").Register("analytics:tag_message", tagMessage); err != nil {
+		return err
+	}
+	return db.Callback().Create().After("gorm:create").Register("analytics:flag_frustration", flagFrustration)
+}
+
+func tagMessage(tx *gorm.DB) {
+	msg, ok := tx.Statement.Dest.(*models.Message)
+	if !ok || tx.Error != nil {
+		return
+	}
+	if msg.Timestamp.IsZero() {
+		msg.Timestamp = time.Now()
+	}
+	switch msg.Sender {
+	case "user":
+		if msg.Topic == "" {
+			msg.Topic = svc.EventTopic(msg.Text)
+		}
+		if msg.Label == "" {
+			label, _ := svc.ClassifyQueryRules(msg.Text)
+			msg.Label = string(label)
+		}
+		if msg.Sentiment == "" {
+			msg.Sentiment = string(svc.ClassifySentiment(msg.Text))
+		}
+	case "bot":
+		if msg.LatencyMs != 0 {
+			return
+		}
+		var last models.Message
+		err := tx.Session(&gorm.Session{NewDB: true}).
+			Where("conversation_id = ? AND sender = ?", msg.ConversationID, "user").
+			Order("id DESC").Limit(1).Find(&last).Error
+		if err == nil && last.ID != 0 && msg.Timestamp.After(last.Timestamp) {
+			msg.LatencyMs = msg.Timestamp.Sub(last.Timestamp).Milliseconds()
+		}
+	}
+}
+
+type DayCount struct {
+	Date string `json:"date"`
+	User int64  `json:"user"`
+	Bot  int64  `json:"bot"`
+}
+
+type TopicCount struct {
+	Topic string `json:"topic"`
+	Count int64  `json:"count"`
+}
+
+type EmojiCount struct {
+	Emoji string `json:"emoji"`
+	Count int64  `json:"count"`
+}
+
+// AnswerStat is a bot reply users bookmarked.
+type AnswerStat struct {
+	MessageID      uint   `json:"message_id"`
+	ConversationID uint   `json:"conversation_id"`
+	Excerpt        string `json:"excerpt"`
+	Bookmarks      int64  `json:"bookmarks"`
+	Reactions      int64  `json:"reactions"`
+}
+
+// ModelUsage is the Gemini tokens spent by one model's replies. Replies
+// without usage metadata (cached, local or mock) count as zero.
+type ModelUsage struct {
+	Model        string  `json:"model"`
+	Replies      int64   `json:"replies"`
+	PromptTokens int64   `json:"prompt_tokens"`
+	OutputTokens int64   `json:"output_tokens"`
+	CostUSD      float64 `json:"cost_usd"` // at the GEMINI_PRICES of the model, 0 when unknown
+}
+
+// RouteUsage is the tokens and cost of the replies GEMINI_ROUTING sent down
+// one route.
+type RouteUsage struct {
+	Route        string  `json:"route"`
+	Replies      int64   `json:"replies"`
+	PromptTokens int64   `json:"prompt_tokens"`
+	OutputTokens int64   `json:"output_tokens"`
+	CostUSD      float64 `json:"cost_usd"`
+}
+
+// topAnswers is how many of the most bookmarked replies a report lists.
+const topAnswers = 10
+
+// Report aggregates messages written since Since. Bookmarks and reactions
+// count those made since Since.
+type Report struct {
+	Since                time.Time    `json:"since"`
+	Conversations        int64        `json:"conversations"`
+	Users                int64        `json:"users,omitempty"`
+	MessagesPerDay       []DayCount   `json:"messages_per_day"`
+	AvgResponseLatencyMs float64      `json:"avg_response_latency_ms"`
+	Topics               []TopicCount `json:"topics"`
+	Labels               []TopicCount `json:"labels"`
+	Sentiments           []TopicCount `json:"sentiments"`
+	Bookmarks            int64        `json:"bookmarks"`
+	Reactions            []EmojiCount `json:"reactions"`
+	TopAnswers           []AnswerStat `json:"top_answers"`
+	PromptTokens         int64        `json:"prompt_tokens"`
+	OutputTokens         int64        `json:"output_tokens"`
+	Usage                []ModelUsage `json:"usage"` // by model, most output tokens first
+	CostUSD              float64      `json:"cost_usd"`
+	Routes               []RouteUsage `json:"routes"` // by route, routed replies only
+}
+
+// Scope narrows a report. Zero values mean "all".
+type Scope struct {
+	UserID         uint
+	ConversationID uint
+	Tenant         *string // tenant ID, "" for the operator's
+	Since          time.Time
+}
+
+// Build computes the report for scope. Messages in trashed conversations
+// are excluded.
+func Build(db *gorm.DB, scope Scope) (Report, error) {
+	rep := Report{Since: scope.Since, MessagesPerDay: []DayCount{}, Topics: []TopicCount{}, Labels: []TopicCount{},
+		Sentiments: []TopicCount{}, Reactions: []EmojiCount{}, TopAnswers: []AnswerStat{}, Usage: []ModelUsage{}, Routes: []RouteUsage{}}
+	base := func() *gorm.DB {
+		q := db.Model(&models.Message{}).
+			Joins("JOIN conversations ON conversations.id = messages.conversation_id AND conversations.deleted_at IS NULL AND conversations.incognito = ?", false).
+			Where("messages.timestamp >= ?", scope.Since)
+		if scope.UserID != 0 {
+			q = q.Where("conversations.user_id = ?", scope.UserID)
+		}
+		if scope.ConversationID != 0 {
+			q = q.Where("messages.conversation_id = ?", scope.ConversationID)
+		}
+		if scope.Tenant != nil {
+			q = q.Where("conversations.tenant_id = ?", *scope.Tenant)
+		}
+		return q
+	}
+
+	var days []struct {
+		Day    string
+		Sender string
+		N      int64
+	}
+	if err := base().Select("DATE(messages.timestamp) AS day, messages.sender AS sender, COUNT(*) AS n").
+		Group("day, sender").Order("day").Scan(&days).Error; err != nil {
+		return rep, err
+	}
+	for _, d := range days {
+		date := d.Day
+		if len(date) > 10 {
+			date = date[:10]
+		}
+		if n := len(rep.MessagesPerDay); n == 0 || rep.MessagesPerDay[n-1].Date != date {
+			rep.MessagesPerDay = append(rep.MessagesPerDay, DayCount{Date: date})
+		}
+		dc := &rep.MessagesPerDay[len(rep.MessagesPerDay)-1]
+		if d.Sender == "bot" {
+			dc.Bot += d.N
+		} else {
+			dc.User += d.N
+		}
+	}
+
+	var avg struct{ Avg *float64 }
+	if err := base().Select("AVG(messages.latency_ms) AS avg").
+		Where("messages.sender = ? AND messages.latency_ms > 0", "bot").Scan(&avg).Error; err != nil {
+		return rep, err
+	}
+	if avg.Avg != nil {
+		rep.AvgResponseLatencyMs = *avg.Avg
+	}
+
+	if err := base().Select("messages.topic AS topic, COUNT(*) AS count").
+		Where("messages.sender = ? AND messages.topic <> ''", "user").
+		Group("messages.topic").Order("count DESC").Scan(&rep.Topics).Error; err != nil {
+		return rep, err
+	}
+	if err := base().Select("messages.label AS topic, COUNT(*) AS count").
+		Where("messages.sender = ? AND messages.label <> ''", "user").
+		Group("messages.label").Order("count DESC").Scan(&rep.Labels).Error; err != nil {
+		return rep, err
+	}
+	if err := base().Select("messages.sentiment AS topic, COUNT(*) AS count").
+		Where("messages.sender = ? AND messages.sentiment <> ''", "user").
+		Group("messages.sentiment").Order("count DESC").Scan(&rep.Sentiments).Error; err != nil {
+		return rep, err
+	}
+
+	if err := base().Select("messages.model AS model, COUNT(*) AS replies, "+
+		"SUM(messages.prompt_tokens) AS prompt_tokens, SUM(messages.output_tokens) AS output_tokens").
+		Where("messages.sender = ? AND messages.model <> ''", "bot").
+		Group("messages.model").Order("output_tokens DESC").Scan(&rep.Usage).Error; err != nil {
+		return rep, err
+	}
+	for i, u := range rep.Usage {
+		rep.Usage[i].CostUSD = cost(u.Model, u.PromptTokens, u.OutputTokens)
+		rep.PromptTokens += u.PromptTokens
+		rep.OutputTokens += u.OutputTokens
+		rep.CostUSD += rep.Usage[i].CostUSD
+	}
+	if err := routes(base(), &rep); err != nil {
+		return rep, err
+	}
+
+	if err := base().Distinct("messages.conversation_id").Count(&rep.Conversations).Error; err != nil {
+		return rep, err
+	}
+	if scope.UserID == 0 {
+		if err := base().Distinct("conversations.user_id").Count(&rep.Users).Error; err != nil {
+			return rep, err
+		}
+	}
+	return rep, marks(db, scope, &rep)
+}
+
+func cost(model string, prompt, output int64) float64 {
+	return svc.TokenCost(model, svc.Usage{PromptTokens: int(prompt), OutputTokens: int(output)})
+}
+
+// routes fills rep.Routes. Models are priced separately, since a route's
+// replies may come from a fallback model.
+func routes(q *gorm.DB, rep *Report) error {
+	var rows []struct {
+		Route        string
+		Model        string
+		Replies      int64
+		PromptTokens int64
+		OutputTokens int64
+	}
+	if err := q.Select("messages.route AS route, messages.model AS model, COUNT(*) AS replies, "+
+		"SUM(messages.prompt_tokens) AS prompt_tokens, SUM(messages.output_tokens) AS output_tokens").
+		Where("messages.sender = ? AND messages.route <> ''", "bot").
+		Group("messages.route, messages.model").Order("route").Scan(&rows).Error; err != nil {
+		return err
+	}
+	for _, r := range rows {
+		if n := len(rep.Routes); n == 0 || rep.Routes[n-1].Route != r.Route {
+			rep.Routes = append(rep.Routes, RouteUsage{Route: r.Route})
+		}
+		ru := &rep.Routes[len(rep.Routes)-1]
+		ru.Replies += r.Replies
+		ru.PromptTokens += r.PromptTokens
+		ru.OutputTokens += r.OutputTokens
+		ru.CostUSD += cost(r.Model, r.PromptTokens, r.OutputTokens)
+	}
+	return nil
+}
+
+// marks fills the bookmark and reaction counts of rep.
+func marks(db *gorm.DB, scope Scope, rep *Report) error {
+	on := func(model any, table string) *gorm.DB {
+		q := db.Model(model).
+			Joins("JOIN messages ON messages.id = "+table+".message_id AND messages.deleted_at IS NULL").
+			Joins("JOIN conversations ON conversations.id = messages.conversation_id AND conversations.deleted_at IS NULL AND conversations.incognito = ?", false).
+			Where(table+".created_at >= ?", scope.Since)
+		if scope.UserID != 0 {
+			q = q.Where("conversations.user_id = ?", scope.UserID)
+		}
+		if scope.ConversationID != 0 {
+			q = q.Where("messages.conversation_id = ?", scope.ConversationID)
+		}
+		if scope.Tenant != nil {
+			q = q.Where("conversations.tenant_id = ?", *scope.Tenant)
+		}
+		return q
+	}
+	if err := on(&models.MessageBookmark{}, "message_bookmarks").Count(&rep.Bookmarks).Error; err != nil {
+		return err
+	}
+	if err := on(&models.MessageReaction{}, "message_reactions").
+		Select("message_reactions.emoji AS emoji, COUNT(*) AS count").
+		Group("message_reactions.emoji").Order("count DESC").Scan(&rep.Reactions).Error; err != nil {
+		return err
+	}
+
+	if err := on(&models.MessageBookmark{}, "message_bookmarks").
+		Select("messages.id AS message_id, messages.conversation_id AS conversation_id, COUNT(*) AS bookmarks").
+		Group("messages.id, messages.conversation_id").Order("bookmarks DESC, message_id DESC").
+		Limit(topAnswers).Scan(&rep.TopAnswers).Error; err != nil {
+		return err
+	}
+	if len(rep.TopAnswers) == 0 {
+		return nil
+	}
+	ids := make([]uint, len(rep.TopAnswers))
+	for i, a := range rep.TopAnswers {
+		ids[i] = a.MessageID
+	}
+	var msgs []models.Message
+	if err := db.Select("id", "text").Where("id IN ?", ids).Find(&msgs).Error; err != nil {
+		return err
+	}
+	var reactions []struct {
+		MessageID uint
+		N         int64
+	}
+	if err := on(&models.MessageReaction{}, "message_reactions").Where("message_reactions.message_id IN ?", ids).
+		Select("message_reactions.message_id AS message_id, COUNT(*) AS n").
+		Group("message_reactions.message_id").Scan(&reactions).Error; err != nil {
+		return err
+	}
+	text := make(map[uint]string, len(msgs))
+	for _, m := range msgs {
+		text[m.ID] = m.Text
+	}
+	counts := make(map[uint]int64, len(reactions))
+	for _, r := range reactions {
+		counts[r.MessageID] = r.N
+	}
+	for i := range rep.TopAnswers {
+		a := &rep.TopAnswers[i]
+		a.Excerpt = excerpt(text[a.MessageID], 200)
+		a.Reactions = counts[a.MessageID]
+	}
+	return nil
+}
+
+func excerpt(s string, n int) string {
+	r := []rune(strings.TrimSpace(s))
+	if len(r) <= n {
+		return string(r)
+	}
+	return string(r[:n]) + "…"
+}
@@ -0,0 +1,96 @@
+package analytics
+
+import (
+	"AkuAI/models"
+	"AkuAI/pkg/config"
+	svc "AkuAI/pkg/services"
+	"log"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// flagFrustration flags the conversation of a frustrated user message when
+// it ends a run of config.FrustrationAlertCount of them. Incognito
+// conversations and ones already awaiting review are left alone.
+func flagFrustration(tx *gorm.DB) {
+	msg, ok := tx.Statement.Dest.(*models.Message)
+	n := config.FrustrationAlertCount
+	if !ok || tx.Error != nil || n == 0 || msg.Sender != "user" || msg.Sentiment != string(svc.SentimentFrustrated) {
+		return
+	}
+	db := tx.Session(&gorm.Session{NewDB: true})
+	var recent []string
+	if err := db.Model(&models.Message{}).Where("conversation_id = ? AND sender = ?", msg.ConversationID, "user").
+		Order("id DESC").Limit(n).Pluck("sentiment", &recent).Error; err != nil || len(recent) < n {
+		return
+	}
+	for _, s := range recent {
+		if s != string(svc.SentimentFrustrated) {
+			return
+		}
+	}
+	// UpdateColumn keeps updated_at, which orders the user's conversation list
+	res := db.Model(&models.Conversation{}).Where("id = ? AND frustration_flagged_at IS NULL AND incognito = ?", msg.ConversationID, false).
+		UpdateColumn("frustration_flagged_at", time.Now())
+	if res.Error != nil {
+		log.Printf("[analytics] ⚠️ failed to flag conversation %d: %v", msg.ConversationID, res.Error)
+	} else if res.RowsAffected > 0 {
+		log.Printf("[analytics] 😤 conversation %d flagged for review after %d frustrated messages", msg.ConversationID, n)
+	}
+}
+
+// recentMessages is how many of a flagged conversation's last user messages
+// are shown for review.
+const recentMessages = 5
+
+// FlaggedConversation is a conversation awaiting review for frustration.
+type FlaggedConversation struct {
+	ConversationID uint             `json:"conversation_id"`
+	UserID         uint             `json:"user_id"`
+	TenantID       string           `json:"tenant_id"`
+	Title          string           `json:"title"`
+	FlaggedAt      time.Time        `json:"flagged_at"`
+	Frustrated     int64            `json:"frustrated"` // frustrated user messages in the whole conversation
+	Recent         []FlaggedMessage `json:"recent"`     // the last user messages, newest first
+}
+
+// FlaggedMessage is one user message of a FlaggedConversation.
+type FlaggedMessage struct {
+	MessageID uint      `json:"message_id"`
+	Excerpt   string    `json:"excerpt"`
+	Sentiment string    `json:"sentiment"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// Flagged returns the conversations awaiting review, most recently flagged
+// first; with tenant set, only that tenant's.
+func Flagged(db *gorm.DB, tenant *string, limit int) ([]FlaggedConversation, error) {
+	q := db.Where("frustration_flagged_at IS NOT NULL").Order("frustration_flagged_at DESC").Limit(limit)
+	if tenant != nil {
+		q = q.Where("tenant_id = ?", *tenant)
+	}
+	var convs []models.Conversation
+	if err := q.Find(&convs).Error; err != nil {
+		return nil, err
+	}
+	out := make([]FlaggedConversation, 0, len(convs))
+	for _, c := range convs {
+		f := FlaggedConversation{ConversationID: c.ID, UserID: c.UserID, TenantID: c.TenantID, Title: c.Title,
+			FlaggedAt: *c.FrustrationFlaggedAt, Recent: []FlaggedMessage{}}
+		if err := db.Model(&models.Message{}).Where("conversation_id = ? AND sender = ? AND sentiment = ?", c.ID, "user", svc.SentimentFrustrated).
+			Count(&f.Frustrated).Error; err != nil {
+			return nil, err
+		}
+		var msgs []models.Message
+		if err := db.Select("id", "text", "sentiment", "timestamp").Where("conversation_id = ? AND sender = ?", c.ID, "user").
+			Order("id DESC").Limit(recentMessages).Find(&msgs).Error; err != nil {
+			return nil, err
+		}
+		for _, m := range msgs {
+			f.Recent = append(f.Recent, FlaggedMessage{MessageID: m.ID, Excerpt: excerpt(m.Text, 200), Sentiment: m.Sentiment, Timestamp: m.Timestamp})
+		}
+		out = append(out, f)
+	}
+	return out, nil
+}
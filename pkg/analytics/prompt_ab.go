@@ -0,0 +1,88 @@
+package analytics
+
+import (
+	"AkuAI/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ArmStats summarises the bot replies of one prompt arm in the online A/B
+// test and the sentiment of the user messages in its conversations. Only conversations assigned by the traffic split are counted, so
+// requests that picked a mode explicitly don't skew the comparison.
+type ArmStats struct {
+	Arm            string   `json:"arm"`
+	Conversations  int64    `json:"conversations"`
+	Replies        int64    `json:"replies"`
+	AvgLatencyMs   float64  `json:"avg_latency_ms"`
+	AvgConfidence  *float64 `json:"avg_confidence"`
+	ThumbsUp       int64    `json:"thumbs_up"`
+	ThumbsDown     int64    `json:"thumbs_down"`
+	FeedbackRate   float64  `json:"feedback_rate"` // share of replies that received a rating
+	UserMessages   int64    `json:"user_messages"`
+	Frustrated     int64    `json:"frustrated"`
+	FrustratedRate float64  `json:"frustrated_rate"` // share of user messages rated frustrated
+}
+
+// PromptArms segments replies written since since by prompt arm, along
+// with the sentiment of the user messages of the arm's conversations.
+func PromptArms(db *gorm.DB, since time.Time) ([]ArmStats, error) {
+	var rows []struct {
+		Arm           string
+		Conversations int64
+		Replies       int64
+		AvgLatency    *float64
+		AvgConfidence *float64
+		Up            int64
+		Down          int64
+	}
+	err := db.Model(&models.Message{}).
+		Joins("JOIN conversations ON conversations.id = messages.conversation_id AND conversations.deleted_at IS NULL AND conversations.incognito = ?", false).
+		Where("messages.sender = ? AND messages.timestamp >= ? AND conversations.prompt_arm <> '' AND messages.prompt_mode = conversations.prompt_arm", "bot", since).
+		Select(`messages.prompt_mode AS arm,
+			COUNT(DISTINCT messages.conversation_id) AS conversations,
+			COUNT(*) AS replies,
+			AVG(NULLIF(messages.latency_ms, 0)) AS avg_latency,
+			AVG(messages.confidence) AS avg_confidence,
+			SUM(CASE WHEN messages.feedback > 0 THEN 1 ELSE 0 END) AS up,
+			SUM(CASE WHEN messages.feedback < 0 THEN 1 ELSE 0 END) AS down`).
+		Group("messages.prompt_mode").Order("arm").Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	var tone []struct {
+		Arm        string
+		Messages   int64
+		Frustrated int64
+	}
+	err = db.Model(&models.Message{}).
+		Joins("JOIN conversations ON conversations.id = messages.conversation_id AND conversations.deleted_at IS NULL AND conversations.incognito = ?", false).
+		Where("messages.sender = ? AND messages.timestamp >= ? AND conversations.prompt_arm <> ''", "user", since).
+		Select(`conversations.prompt_arm AS arm,
+			COUNT(*) AS messages,
+			SUM(CASE WHEN messages.sentiment = 'frustrated' THEN 1 ELSE 0 END) AS frustrated`).
+		Group("conversations.prompt_arm").Scan(&tone).Error
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]ArmStats, 0, len(rows))
+	for _, r := range rows {
+		s := ArmStats{Arm: r.Arm, Conversations: r.Conversations, Replies: r.Replies, AvgConfidence: r.AvgConfidence, ThumbsUp: r.Up, ThumbsDown: r.Down}
+		if r.AvgLatency != nil {
+			s.AvgLatencyMs = *r.AvgLatency
+		}
+		if r.Replies > 0 {
+			s.FeedbackRate = float64(r.Up+r.Down) / float64(r.Replies)
+		}
+		for _, t := range tone {
+			if t.Arm == r.Arm && t.Messages > 0 {
+				s.UserMessages, s.Frustrated = t.Messages, t.Frustrated
+				s.FrustratedRate = float64(t.Frustrated) / float64(t.Messages)
+			}
+		}
+		out = append(out, s)
+	}
+	return out, nil
+}
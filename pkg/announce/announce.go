@@ -0,0 +1,110 @@
+// Package announce delivers admin announcements (models.Announcement). Each
+// user sees a live announcement once: at the top of their next chat reply, or
+// earlier when it is broadcast to their open WebSocket connection.
+package announce
+
+import (
+	"AkuAI/models"
+	"AkuAI/pkg/wshub"
+	"context"
+	"log"
+	"strconv"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Payload is the JSON form of an announcement shown to users.
+func Payload(a models.Announcement) map[string]any {
+	return map[string]any{"id": a.ID, "title": a.Title, "body": a.Body, "starts_at": a.StartsAt, "expires_at": a.ExpiresAt}
+}
+
+// Message is what a WebSocket listener receives for an announcement.
+func Message(a models.Announcement) map[string]any {
+	return map[string]any{"type": "announcement", "announcement": Payload(a)}
+}
+
+// live selects the announcements shown at now.
+func live(db *gorm.DB, now time.Time) *gorm.DB {
+	return db.Where("starts_at <= ? AND (expires_at IS NULL OR expires_at > ?)", now, now)
+}
+
+// Pending returns the live announcements userID has not seen yet, oldest
+// first, and marks them seen.
+func Pending(db *gorm.DB, userID uint, now time.Time) ([]models.Announcement, error) {
+	var out []models.Announcement
+	seen := db.Model(&models.AnnouncementReceipt{}).Select("announcement_id").Where("user_id = ?", userID)
+	if err := live(db, now).Where("id NOT IN (?)", seen).Order("starts_at, id").Find(&out).Error; err != nil {
+		return nil, err
+	}
+	for _, a := range out {
+		if _, err := MarkSeen(db, a.ID, userID); err != nil {
+			return nil, err
+		}
+	}
+	return out, nil
+}
+
+// MarkSeen records that userID has been shown announcement id and reports
+// whether they had not seen it before.
+func MarkSeen(db *gorm.DB, id, userID uint) (bool, error) {
+	res := db.Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&models.AnnouncementReceipt{AnnouncementID: id, UserID: userID})
+	return res.RowsAffected == 1, res.Error
+}
+
+// BroadcastDue sends the live announcements that have not been broadcast yet
+// to the users listening on the hub who have not seen them, and stamps their
+// BroadcastAt. Users whose connections are full get them with their next
+// chat reply instead.
+func BroadcastDue(db *gorm.DB, hub *wshub.Hub, now time.Time) (int, error) {
+	var due []models.Announcement
+	if err := live(db, now).Where("broadcast_at IS NULL").Order("starts_at, id").Find(&due).Error; err != nil {
+		return 0, err
+	}
+	for _, a := range due {
+		if err := db.Model(&a).Update("broadcast_at", now).Error; err != nil {
+			return 0, err
+		}
+		users := 0
+		for _, u := range hub.Listeners() {
+			uid, err := strconv.ParseUint(u, 10, 64)
+			if err != nil {
+				continue
+			}
+			var seen int64
+			if err := db.Model(&models.AnnouncementReceipt{}).Where("announcement_id = ? AND user_id = ?", a.ID, uid).Count(&seen).Error; err != nil || seen > 0 {
+				continue
+			}
+			if hub.SendUser(u, Message(a)) > 0 {
+				if _, err := MarkSeen(db, a.ID, uint(uid)); err != nil {
+					return 0, err
+				}
+				users++
+			}
+		}
+		log.Printf("[announce] 📣 broadcast announcement %d to %d users", a.ID, users)
+	}
+	return len(due), nil
+}
+
+// Start runs BroadcastDue every interval until ctx is done, so scheduled
+// announcements go out when they start.
+func Start(ctx context.Context, db *gorm.DB, hub *wshub.Hub, interval time.Duration) {
+	go func() {
+		t := time.NewTicker(interval)
+		defer t.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case now := <-t.C:
+				if _, err := BroadcastDue(db, hub, now); err != nil {
+					log.Printf("[announce] ❌ broadcast failed: %v", err)
+				}
+			}
+		}
+	}()
+	log.Printf("[announce] scheduler started interval=%v", interval)
+}
@@ -0,0 +1,222 @@
+package apidocs
+
+import (
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Param describes a path, query, or header parameter of an operation.
+type Param struct {
+	Name        string
+	In          string // path | query | header
+	Description string
+	Required    bool
+	Type        string // string | integer | boolean (default string)
+}
+
+// Operation documents a single HTTP endpoint. Body holds an example request
+// payload; its value types are used to derive a loose JSON schema.
+type Operation struct {
+	Method      string
+	Path        string // gin-style path, e.g. /conversations/:conversation_id
+	Tag         string
+	Summary     string
+	Description string
+	Secured     bool
+	APIKeyScope string // also callable with an API key granted this scope
+	Params      []Param
+	Body        map[string]any
+	Responses   map[int]string
+}
+
+var (
+	mu         sync.RWMutex
+	operations []Operation
+)
+
+// Register adds operations to the published spec.
+func Register(ops ...Operation) {
+	mu.Lock()
+	operations = append(operations, ops...)
+	mu.Unlock()
+}
+
+// Operations returns a copy of the registered operations sorted by path and method.
+func Operations() []Operation {
+	mu.RLock()
+	out := append([]Operation(nil), operations...)
+	mu.RUnlock()
+	sort.SliceStable(out, func(i, j int) bool {
+		if out[i].Path == out[j].Path {
+			return out[i].Method < out[j].Method
+		}
+		return out[i].Path < out[j].Path
+	})
+	return out
+}
+
+var ginParam = regexp.MustCompile(`[:*]([A-Za-z0-9_]+)`)
+
+// openAPIPath converts gin path params (:id, *file) into OpenAPI templates ({id}).
+func openAPIPath(p string) string {
+	return ginParam.ReplaceAllString(p, "{$1}")
+}
+
+// Spec builds an OpenAPI 3.0 document from the registered operations.
+func Spec(title, version string) map[string]any {
+	paths := map[string]any{}
+	tagSet := map[string]bool{}
+
+	for _, op := range Operations() {
+		path := openAPIPath(op.Path)
+		item, _ := paths[path].(map[string]any)
+		if item == nil {
+			item = map[string]any{}
+			paths[path] = item
+		}
+
+		params := make([]any, 0, len(op.Params))
+		declared := map[string]bool{}
+		for _, p := range op.Params {
+			declared[p.Name] = true
+			params = append(params, paramSpec(p))
+		}
+		// Make sure every templated path param is declared even if the
+		// operation did not list it explicitly.
+		for _, m := range ginParam.FindAllStringSubmatch(op.Path, -1) {
+			if !declared[m[1]] {
+				params = append(params, paramSpec(Param{Name: m[1], In: "path", Required: true}))
+			}
+		}
+
+		responses := map[string]any{}
+		for code, desc := range op.Responses {
+			responses[strconv.Itoa(code)] = map[string]any{"description": desc}
+		}
+		if len(responses) == 0 {
+			responses["200"] = map[string]any{"description": http.StatusText(http.StatusOK)}
+		}
+
+		spec := map[string]any{
+			"summary":   op.Summary,
+			"responses": responses,
+		}
+		if op.Description != "" {
+			spec["description"] = op.Description
+		}
+		if op.Tag != "" {
+			spec["tags"] = []string{op.Tag}
+			tagSet[op.Tag] = true
+		}
+		if len(params) > 0 {
+			spec["parameters"] = params
+		}
+		if op.Secured {
+			spec["security"] = []any{map[string]any{"bearerAuth": []string{}}}
+		}
+		if op.APIKeyScope != "" {
+			spec["security"] = []any{map[string]any{"bearerAuth": []string{}}, map[string]any{"apiKeyAuth": []string{}}}
+			spec["x-api-key-scope"] = op.APIKeyScope
+		}
+		if op.Body != nil {
+			spec["requestBody"] = map[string]any{
+				"required": true,
+				"content": map[string]any{
+					"application/json": map[string]any{
+						"schema":  schemaFor(op.Body),
+						"example": op.Body,
+					},
+				},
+			}
+		}
+		item[strings.ToLower(op.Method)] = spec
+	}
+
+	tags := make([]string, 0, len(tagSet))
+	for t := range tagSet {
+		tags = append(tags, t)
+	}
+	sort.Strings(tags)
+	tagList := make([]any, 0, len(tags))
+	for _, t := range tags {
+		tagList = append(tagList, map[string]any{"name": t})
+	}
+
+	return map[string]any{
+		"openapi": "3.0.3",
+		"info": map[string]any{
+			"title":   title,
+			"version": version,
+		},
+		"tags":  tagList,
+		"paths": paths,
+		"components": map[string]any{
+			"securitySchemes": map[string]any{
+				"bearerAuth": map[string]any{
+					"type":         "http",
+					"scheme":       "bearer",
+					"bearerFormat": "JWT",
+				},
+				"apiKeyAuth": map[string]any{
+					"type":        "apiKey",
+					"in":          "header",
+					"name":        "X-API-Key",
+					"description": "Service API key issued via /admin/api-keys; each operation names the scope it needs in x-api-key-scope",
+				},
+			},
+		},
+	}
+}
+
+func paramSpec(p Param) map[string]any {
+	typ := p.Type
+	if typ == "" {
+		typ = "string"
+	}
+	in := p.In
+	if in == "" {
+		in = "query"
+	}
+	out := map[string]any{
+		"name":     p.Name,
+		"in":       in,
+		"required": p.Required || in == "path",
+		"schema":   map[string]any{"type": typ},
+	}
+	if p.Description != "" {
+		out["description"] = p.Description
+	}
+	return out
+}
+
+// schemaFor derives a minimal JSON schema from an example value.
+func schemaFor(v any) map[string]any {
+	switch t := v.(type) {
+	case map[string]any:
+		props := map[string]any{}
+		for k, val := range t {
+			props[k] = schemaFor(val)
+		}
+		return map[string]any{"type": "object", "properties": props}
+	case []any:
+		items := map[string]any{}
+		if len(t) > 0 {
+			items = schemaFor(t[0])
+		}
+		return map[string]any{"type": "array", "items": items}
+	case []string:
+		return map[string]any{"type": "array", "items": map[string]any{"type": "string"}}
+	case bool:
+		return map[string]any{"type": "boolean"}
+	case int, int64, uint:
+		return map[string]any{"type": "integer"}
+	case float64:
+		return map[string]any{"type": "number"}
+	default:
+		return map[string]any{"type": "string"}
+	}
+}
@@ -0,0 +1,389 @@
+package apidocs
+
+import "net/http"
+
+// v1 mirrors routes.APIV1Prefix. Legacy unversioned aliases are not listed.
+const v1 = "/api/v1"
+
+// Endpoint catalog. Keep this in sync with routes/* when adding handlers.
+func init() {
+	Register(
+		// Auth
+		Operation{Method: http.MethodPost, Path: v1 + "/register", Tag: "auth", Summary: "Register a new user",
+			Body:      map[string]any{"email": "mahasiswa@uib.ac.id", "username": "mahasiswa", "password": "rahasia123", "confirm_password": "rahasia123"},
+			Responses: map[int]string{201: "User created", 400: "Validation error", 409: "Email or username already exists"}},
+		Operation{Method: http.MethodPost, Path: v1 + "/login", Tag: "auth", Summary: "Log in and obtain a JWT access token",
+			Description: "After LOGIN_CAPTCHA_AFTER failures (when CAPTCHA_VERIFY_URL is set) the next attempts need captcha_token; too many failures lock the account or IP out for a doubling time.",
+			Body:        map[string]any{"email": "mahasiswa@uib.ac.id", "password": "rahasia123", "captcha_token": "<token, when required>"},
+			Responses:   map[int]string{200: "access_token, username and expires_at", 401: "Invalid credentials (details.captcha_required) or missing CAPTCHA", 429: "Account or IP locked out; see Retry-After"}},
+		Operation{Method: http.MethodPost, Path: v1 + "/logout", Tag: "auth", Summary: "Revoke the current token", Secured: true},
+		Operation{Method: http.MethodPost, Path: v1 + "/auth/claim", Tag: "auth", Summary: "Move the guest cookie's conversations into your account", Secured: true,
+			Description: "Call after registering or logging in from a browser that chatted as a guest; the akuai_guest cookie is cleared.",
+			Responses:   map[int]string{200: "claimed and conversation_ids", 400: "No guest cookie"}},
+
+		// Tenant
+		Operation{Method: http.MethodGet, Path: v1 + "/tenant", Tag: "tenant", Summary: "Name and branding of the request's tenant",
+			Description: "The tenant is picked by host name or X-Tenant-ID (TENANTS_FILE); without one this is the operator tenant with id \"\". Every endpoint accepts X-Tenant-ID, and tokens only work in the tenant that issued them.",
+			Params:      []Param{{Name: "X-Tenant-ID", In: "header", Description: "Tenant on a shared host name"}},
+			Responses:   map[int]string{200: "id, name and branding (app_name, assistant_name, logo_url, primary_color)", 400: "Unknown tenant"}},
+
+		// Profile
+		Operation{Method: http.MethodGet, Path: v1 + "/profile", Tag: "profile", Summary: "Get the current user's profile", Secured: true},
+		Operation{Method: http.MethodPut, Path: v1 + "/profile", Tag: "profile", Summary: "Update email, username, or password", Secured: true,
+			Body:      map[string]any{"email": "baru@uib.ac.id", "username": "baru", "password": "rahasia456"},
+			Responses: map[int]string{200: "Profile updated", 409: "Email or username already exists"}},
+		Operation{Method: http.MethodPost, Path: v1 + "/profile/image/token", Tag: "profile", Summary: "Issue a short-lived upload token", Secured: true,
+			Body: map[string]any{"file_extension": ".png"}},
+		Operation{Method: http.MethodPost, Path: v1 + "/profile/image/upload", Tag: "profile", Summary: "Upload a profile image (multipart: image, upload_token, visibility=public|private)", Secured: true,
+			Description: "The content must be a JPG, PNG, GIF or WEBP image matching the extension (415). Limited by UPLOAD_MAX_MB and UPLOAD_QUOTA_MB (413) and UPLOAD_RATE_LIMIT_COUNT per window (429); with CLAMAV_ADDRESS infected files get 422.",
+			Params:      []Param{{Name: "X-Upload-Token", In: "header", Description: "Alternative to the upload_token form field"}}},
+		Operation{Method: http.MethodGet, Path: v1 + "/profile/image", Tag: "profile", Summary: "Get the profile image URL", Secured: true,
+			Description: "Private images get a signed URL that expires at expires_at (IMAGE_URL_TTL_MINUTES); public ones a plain URL."},
+		Operation{Method: http.MethodPut, Path: v1 + "/profile/image/visibility", Tag: "profile", Summary: "Make the profile image public or private", Secured: true,
+			Body: map[string]any{"public": true}},
+		Operation{Method: http.MethodDelete, Path: v1 + "/profile/image", Tag: "profile", Summary: "Delete the profile image", Secured: true},
+		Operation{Method: http.MethodGet, Path: v1 + "/profile/preferences", Tag: "profile", Summary: "Get the reply preferences: language, verbosity, markdown, emoji", Secured: true},
+		Operation{Method: http.MethodPut, Path: v1 + "/profile/preferences", Tag: "profile", Summary: "Change the reply preferences; only the fields sent change", Secured: true,
+			Description: "language (id, en, or \"\" to follow the question and Accept-Language) also sets the language of error messages and server-written texts.",
+			Body:        map[string]any{"language": "en", "verbosity": "short", "markdown": false, "emoji": false},
+			Responses:   map[int]string{200: "Preferences updated", 400: "Unsupported language or verbosity"}},
+		Operation{Method: http.MethodGet, Path: v1 + "/profile/memory", Tag: "profile", Summary: "List the facts remembered from your chats and whether memory is on", Secured: true},
+		Operation{Method: http.MethodPut, Path: v1 + "/profile/memory", Tag: "profile", Summary: "Turn chat memory on or off; off deletes every remembered fact", Secured: true,
+			Body: map[string]any{"enabled": true}},
+		Operation{Method: http.MethodDelete, Path: v1 + "/profile/memory", Tag: "profile", Summary: "Forget every remembered fact", Secured: true},
+		Operation{Method: http.MethodDelete, Path: v1 + "/profile/memory/:id", Tag: "profile", Summary: "Forget one remembered fact", Secured: true,
+			Responses: map[int]string{200: "Fact deleted", 404: "Fact not found"}},
+		Operation{Method: http.MethodGet, Path: v1 + "/profile/digest", Tag: "profile", Summary: "Weekly event digest settings and the next send time", Secured: true},
+		Operation{Method: http.MethodPut, Path: v1 + "/profile/digest", Tag: "profile", Summary: "Opt in to or out of the weekly event digest, and its email copy", Secured: true,
+			Body: map[string]any{"enabled": true, "email": false}},
+		Operation{Method: http.MethodGet, Path: v1 + "/profile/digest/preview", Tag: "profile", Summary: "Compose your weekly digest without posting it", Secured: true,
+			Params: []Param{{Name: "from", In: "query", Description: "First day of the week, YYYY-MM-DD (default today)"}}},
+
+		// Conversations
+		Operation{Method: http.MethodPost, Path: v1 + "/conversations", Tag: "chat", Summary: "Send a message and receive the full bot reply", Secured: true,
+			Params: []Param{
+				{Name: "X-Prompt-Mode", In: "header", Description: "baseline | engineered"},
+				{Name: "X-Bypass-Duplicate", In: "header", Description: "Set to 1 to skip the duplicate-message guard"},
+				{Name: "Idempotency-Key", In: "header", Description: "Retries with the same key replay the original response"},
+				{Name: "X-Generation-Config", In: "header", Description: "Admin-only in production: JSON generationConfig override, e.g. {\"temperature\":0.9}; bypasses the reply caches"},
+				{Name: "async", In: "query", Description: "Set to 1 to queue the generation and return a job ID (202)"},
+			},
+			Body:      map[string]any{"message": "Apa saja webinar UIB bulan November?", "conversation_id": 1, "request_images": false, "mode": "engineered", "verbosity": "ringkas", "incognito": false},
+			Responses: map[int]string{201: "Conversation with messages", 202: "Job queued (async=1)", 503: "Job queue full", 409: "Duplicate message or request still in progress", 422: "Idempotency-Key reused with a different body, or message refused by moderation", 429: "Too many requests"}},
+		Operation{Method: http.MethodPost, Path: v1 + "/conversations/stream", Tag: "chat", Summary: "Send a message and stream the reply as Server-Sent Events", Secured: true,
+			Description: "Emits user_saved, delta, confidence, citations, images_*, image_results and done events. Every event carries an id (<stream_id>:<seq>) and JSON data; delta data is a JSON string. Ping comments are sent every SSE_HEARTBEAT_SECONDS. Image search runs when request_images is set or the message asks for pictures (\"tampilkan gambar kampus\").",
+			Params: []Param{
+				{Name: "Idempotency-Key", In: "header", Description: "Retries with the same key replay the original event stream"},
+				{Name: "X-Generation-Config", In: "header", Description: "Same as for POST /conversations"},
+			},
+			Body: map[string]any{"message": "Sertifikasi apa yang ada di Desember?", "conversation_id": 1, "request_images": true, "mode": "engineered", "incognito": false}},
+		Operation{Method: http.MethodGet, Path: v1 + "/conversations/stream/resume", Tag: "chat", Summary: "Resume an interrupted reply stream", Secured: true,
+			Description: "Replays the events after Last-Event-ID and follows the stream until done. Streams stay resumable for SSE_REPLAY_TTL_SECONDS after they finish; 410 once expired.",
+			Params: []Param{
+				{Name: "Last-Event-ID", In: "header", Description: "ID of the last event received, <stream_id>:<seq>"},
+				{Name: "last_event_id", In: "query", Description: "Same as the header, for clients that cannot set it"},
+			}},
+		Operation{Method: http.MethodPost, Path: v1 + "/conversations/compare", Tag: "chat", Summary: "Run baseline and engineered prompts side by side", Secured: true,
+			Description: "Both prompts run concurrently. Each arm reports response, duration_ms, template_id and the event_ids it mentions; diff compares those IDs with each other and with the retrieved relevant events.",
+			Body:        map[string]any{"message": "webinar uib nov 2025 apa aja?", "timeout_sec": 60}},
+		Operation{Method: http.MethodGet, Path: v1 + "/conversations", Tag: "chat", Summary: "List conversations", Secured: true,
+			Params: []Param{
+				{Name: "q", In: "query", Description: "Filter by title or message text"},
+				{Name: "archived", In: "query", Description: "1 = only archived, all = archived and active (default: active only)"},
+				{Name: "folder_id", In: "query", Description: "Only conversations in this folder; none = unfiled ones"},
+			},
+			Description: "Pinned conversations come first, then the rest by last activity."},
+		Operation{Method: http.MethodGet, Path: v1 + "/conversations/:conversation_id", Tag: "chat", Summary: "Get a conversation with its messages", Secured: true,
+			Description: "Includes messages_count. With limit (and before) only the newest page of messages is returned, as in /messages.",
+			Params: []Param{
+				{Name: "limit", In: "query", Description: "Return only the newest N messages (max 200)"},
+				{Name: "before", In: "query", Description: "Message ID cursor: only messages older than it"},
+			}},
+		Operation{Method: http.MethodGet, Path: v1 + "/conversations/:conversation_id/messages", Tag: "chat", Summary: "Page through a conversation's messages", Secured: true,
+			Description: "Pages run newest to oldest, messages within a page oldest first. Pass next_before as before to load the previous page until has_more is false.",
+			Params: []Param{
+				{Name: "limit", In: "query", Description: "Page size (default 50, max 200)"},
+				{Name: "before", In: "query", Description: "Message ID cursor: only messages older than it"},
+			},
+			Responses: map[int]string{200: "messages, has_more, next_before", 400: "Invalid limit or before", 404: "Conversation not found"}},
+		Operation{Method: http.MethodPost, Path: v1 + "/conversations/:conversation_id/email", Tag: "chat", Summary: "Email the conversation's transcript to the user", Secured: true,
+			Description: "Mails the transcript as a Markdown attachment to the account email. Limited to TRANSCRIPT_EMAIL_LIMIT per TRANSCRIPT_EMAIL_WINDOW_SECONDS per user.",
+			Responses:   map[int]string{200: "email, filename", 404: "Conversation not found", 429: "Too many transcript emails", 502: "The SMTP relay refused the message", 503: "Email is not configured"}},
+		Operation{Method: http.MethodPost, Path: v1 + "/conversations/:conversation_id/continue", Tag: "chat", Summary: "Finish a reply that was stopped or failed part-way", Secured: true,
+			Description: "Continues the conversation's last bot message when its status is stopped or error, from the partial text, and updates it in place with status completed.",
+			Responses:   map[int]string{200: "conversation_id, message", 404: "Conversation or reply not found", 409: "The last reply is already complete", 502: "The model could not continue the reply"}},
+		Operation{Method: http.MethodPost, Path: v1 + "/conversations/:conversation_id/summarize", Tag: "chat", Summary: "Condense the last reply (ringkas)", Secured: true,
+			Description: "Asks the model to summarize the conversation's last completed bot message at verbosity short and adds the request and the summary to the conversation.",
+			Responses:   map[int]string{201: "conversation_id, summarized_message_id, message", 404: "Conversation or reply not found", 502: "The model could not summarize the reply"}},
+		Operation{Method: http.MethodDelete, Path: v1 + "/conversations/:conversation_id", Tag: "chat", Summary: "Move a conversation to the trash", Secured: true},
+		Operation{Method: http.MethodDelete, Path: v1 + "/conversations", Tag: "chat", Summary: "Move all conversations to the trash", Secured: true},
+		Operation{Method: http.MethodGet, Path: v1 + "/conversations/trash", Tag: "chat", Summary: "List deleted conversations that can still be restored", Secured: true},
+		Operation{Method: http.MethodPost, Path: v1 + "/conversations/:conversation_id/restore", Tag: "chat", Summary: "Restore a conversation from the trash", Secured: true,
+			Responses: map[int]string{200: "Restored", 404: "Not in trash"}},
+		Operation{Method: http.MethodPost, Path: v1 + "/conversations/:conversation_id/archive", Tag: "chat", Summary: "Archive a conversation", Secured: true},
+		Operation{Method: http.MethodPost, Path: v1 + "/conversations/:conversation_id/unarchive", Tag: "chat", Summary: "Restore an archived conversation", Secured: true},
+		Operation{Method: http.MethodPut, Path: v1 + "/conversations/:conversation_id/messages/:message_id/bookmark", Tag: "chat", Summary: "Bookmark a bot reply, with an optional note", Secured: true,
+			Body:      map[string]any{"note": "jadwal webinar"},
+			Responses: map[int]string{200: "Bookmarked (again: note updated)", 404: "Bot message not found"}},
+		Operation{Method: http.MethodDelete, Path: v1 + "/conversations/:conversation_id/messages/:message_id/bookmark", Tag: "chat", Summary: "Remove a bookmark", Secured: true},
+		Operation{Method: http.MethodPost, Path: v1 + "/conversations/:conversation_id/messages/:message_id/reactions", Tag: "chat", Summary: "React to a bot reply with an emoji", Secured: true,
+			Body:      map[string]any{"emoji": "👍"},
+			Responses: map[int]string{200: "Your reactions on the message", 400: "Not a single emoji", 404: "Bot message not found"}},
+		Operation{Method: http.MethodDelete, Path: v1 + "/conversations/:conversation_id/messages/:message_id/reactions/:emoji", Tag: "chat", Summary: "Remove a reaction (URL-encoded emoji)", Secured: true},
+		Operation{Method: http.MethodGet, Path: v1 + "/bookmarks", Tag: "chat", Summary: "Your bookmarked replies across conversations, newest first", Secured: true,
+			Params: []Param{
+				{Name: "q", In: "query", Description: "Search the reply, the note and the conversation title"},
+				{Name: "limit", In: "query", Type: "integer"},
+				{Name: "before", In: "query", Type: "integer", Description: "next_before of the previous page"},
+			}},
+		Operation{Method: http.MethodPost, Path: v1 + "/conversations/:conversation_id/pin", Tag: "chat", Summary: "Pin a conversation to the top of the list", Secured: true},
+		Operation{Method: http.MethodPost, Path: v1 + "/conversations/:conversation_id/unpin", Tag: "chat", Summary: "Unpin a conversation", Secured: true},
+		Operation{Method: http.MethodPut, Path: v1 + "/conversations/:conversation_id/folder", Tag: "chat", Summary: "Move a conversation into a folder, or out with null", Secured: true,
+			Body:      map[string]any{"folder_id": 3},
+			Responses: map[int]string{200: "Conversation moved", 404: "Conversation or folder not found"}},
+		Operation{Method: http.MethodGet, Path: v1 + "/folders", Tag: "chat", Summary: "List your folders with conversations_count", Secured: true},
+		Operation{Method: http.MethodPost, Path: v1 + "/folders", Tag: "chat", Summary: "Create a folder", Secured: true,
+			Body:      map[string]any{"name": "Webinar"},
+			Responses: map[int]string{201: "Folder created", 409: "Folder name already used"}},
+		Operation{Method: http.MethodPut, Path: v1 + "/folders/:id", Tag: "chat", Summary: "Rename a folder", Secured: true,
+			Body:      map[string]any{"name": "Sertifikasi"},
+			Responses: map[int]string{200: "Folder renamed", 404: "Folder not found", 409: "Folder name already used"}},
+		Operation{Method: http.MethodDelete, Path: v1 + "/folders/:id", Tag: "chat", Summary: "Delete a folder; its conversations become unfiled", Secured: true},
+		Operation{Method: http.MethodPut, Path: v1 + "/conversations/:conversation_id/messages/:message_id/feedback", Tag: "chat", Summary: "Rate a bot reply (1 = thumbs up, -1 = thumbs down, 0 = clear)", Secured: true,
+			Body:      map[string]any{"rating": 1},
+			Responses: map[int]string{200: "Rating saved", 400: "Invalid rating", 404: "Message not found"}},
+
+		// Guest chat
+		Operation{Method: http.MethodPost, Path: v1 + "/guest/conversations", Tag: "guest", Summary: "Chat without an account (GUEST_CHAT_ENABLED=1)",
+			Description: "The guest is identified by the akuai_guest cookie, issued on first use. Guest conversations are purged after GUEST_CONVERSATION_TTL_HOURS unless claimed.",
+			Body:        map[string]any{"message": "Apa saja webinar UIB bulan November?", "conversation_id": 1},
+			Responses:   map[int]string{201: "Conversation with messages and questions_left", 403: "guest_limit_reached: sign up to keep chatting", 404: "Guest chat disabled or conversation not found", 422: "Message refused by moderation", 429: "Too many requests"}},
+		Operation{Method: http.MethodGet, Path: v1 + "/guest/conversations", Tag: "guest", Summary: "List the guest's conversations"},
+		Operation{Method: http.MethodGet, Path: v1 + "/guest/conversations/:conversation_id", Tag: "guest", Summary: "Get a guest conversation with its messages"},
+
+		// WebSocket
+		Operation{Method: http.MethodGet, Path: v1 + "/ws/chat", Tag: "chat", Summary: "WebSocket chat (send {type:start} then {type:stop} to abort)",
+			Params: []Param{
+				{Name: "token", In: "query", Required: true, Description: "JWT access token"},
+				{Name: "generation_config", In: "query", Description: "Same as the X-Generation-Config header"},
+			}},
+		Operation{Method: http.MethodGet, Path: v1 + "/ws/jobs", Tag: "jobs", Summary: "WebSocket feed of job_done events for async jobs",
+			Params: []Param{{Name: "token", In: "query", Required: true, Description: "JWT access token"}}},
+
+		// Async jobs
+		Operation{Method: http.MethodGet, Path: v1 + "/jobs/:id", Tag: "jobs", Summary: "Poll an async job (queued, running, done, failed)", Secured: true,
+			Responses: map[int]string{200: "Job with result once done", 404: "Job not found"}},
+
+		// UIB events
+		Operation{Method: http.MethodGet, Path: v1 + "/uib/health", Tag: "uib", APIKeyScope: "uib:read", Summary: "UIB event service health", Secured: true},
+		Operation{Method: http.MethodGet, Path: v1 + "/uib/campuses", Tag: "uib", APIKeyScope: "uib:read", Summary: "List campuses with loaded event data", Secured: true,
+			Description: "Every /uib endpoint accepts ?campus=<name or alias> to read another campus's dataset (default: UIB)."},
+		Operation{Method: http.MethodGet, Path: v1 + "/uib/departments", Tag: "uib", APIKeyScope: "uib:read", Summary: "Departments and faculties with event counts", Secured: true},
+		Operation{Method: http.MethodGet, Path: v1 + "/uib/events", Tag: "uib", APIKeyScope: "uib:read", Summary: "List all UIB events", Secured: true},
+		Operation{Method: http.MethodGet, Path: v1 + "/uib/events/month/:month", Tag: "uib", APIKeyScope: "uib:read", Summary: "List events for a month (october, november, december)", Secured: true},
+		Operation{Method: http.MethodGet, Path: v1 + "/uib/events/type/:type", Tag: "uib", APIKeyScope: "uib:read", Summary: "List events by type (certification, webinar, seminar, workshop, bootcamp, competition, public_lecture)", Secured: true},
+		Operation{Method: http.MethodGet, Path: v1 + "/uib/events/upcoming", Tag: "uib", APIKeyScope: "uib:read", Summary: "List upcoming events", Secured: true},
+		Operation{Method: http.MethodGet, Path: v1 + "/uib/events/recommended", Tag: "uib", Summary: "Upcoming events ranked for the current user, with reasons", Secured: true,
+			Params: []Param{
+				{Name: "limit", In: "query", Type: "integer"},
+				{Name: "from", In: "query"},
+				{Name: "campus", In: "query"},
+			}},
+		Operation{Method: http.MethodGet, Path: v1 + "/uib/events/summaries", Tag: "uib", APIKeyScope: "uib:read", Summary: "Compact event summaries", Secured: true,
+			Params: []Param{
+				{Name: "department", In: "query"},
+				{Name: "faculty", In: "query"},
+				{Name: "group_by", In: "query", Description: "department or faculty"},
+			}},
+		Operation{Method: http.MethodGet, Path: v1 + "/uib/events/search", Tag: "uib", APIKeyScope: "uib:read", Summary: "Search events by criteria", Secured: true,
+			Params: []Param{
+				{Name: "type", In: "query"},
+				{Name: "month", In: "query"},
+				{Name: "department", In: "query"},
+				{Name: "faculty", In: "query"},
+				{Name: "free", In: "query", Type: "boolean"},
+				{Name: "min_fee", In: "query", Type: "integer", Description: "rupiah"},
+				{Name: "max_fee", In: "query", Type: "integer", Description: "rupiah"},
+				{Name: "group_by", In: "query", Description: "department or faculty"},
+			}},
+		Operation{Method: http.MethodGet, Path: v1 + "/uib/events/conflicts", Tag: "uib", APIKeyScope: "uib:read", Summary: "Pairs of events whose times overlap", Secured: true,
+			Params: []Param{
+				{Name: "ids", In: "query", Description: "comma-separated event IDs; the upcoming events when empty"},
+			}},
+		Operation{Method: http.MethodPost, Path: v1 + "/uib/events/import", Tag: "uib", Summary: "Admin: upsert events from a CSV or XLSX file", Secured: true,
+			Description: "Multipart form: file (.csv or .xlsx), mapping (JSON object from column header to event field), dry_run=true to only validate. Any invalid row rejects the file with 422 and the row errors.",
+			Params: []Param{
+				{Name: "campus", In: "query", Description: "dataset to import into; UIB by default"},
+			}},
+		Operation{Method: http.MethodGet, Path: v1 + "/uib/events/:id", Tag: "uib", APIKeyScope: "uib:read", Summary: "Get an event by ID", Secured: true},
+		Operation{Method: http.MethodPost, Path: v1 + "/uib/events/:id/register", Tag: "uib", Summary: "Register for an event, or join its waiting list when it is full", Secured: true},
+		Operation{Method: http.MethodGet, Path: v1 + "/uib/events/:id/register", Tag: "uib", Summary: "Your registration for an event and its seats", Secured: true},
+		Operation{Method: http.MethodDelete, Path: v1 + "/uib/events/:id/register", Tag: "uib", Summary: "Cancel your registration; the first on the waiting list takes the seat", Secured: true},
+		Operation{Method: http.MethodGet, Path: v1 + "/uib/events/:id/history", Tag: "uib", Summary: "Admin: revisions of an event with field-level diffs", Secured: true,
+			Params: []Param{
+				{Name: "limit", In: "query", Type: "integer", Description: "default 50, at most 200"},
+				{Name: "before", In: "query", Type: "integer", Description: "revision ID to page back from"},
+			}},
+		Operation{Method: http.MethodGet, Path: v1 + "/uib/events/:id/roster", Tag: "uib", Summary: "Admin: confirmed participants and waiting list of an event", Secured: true},
+		Operation{Method: http.MethodGet, Path: v1 + "/uib/registrations", Tag: "uib", Summary: "Your event registrations", Secured: true,
+			Params: []Param{
+				{Name: "all", In: "query", Type: "boolean", Description: "include cancelled registrations"},
+			}},
+		Operation{Method: http.MethodPost, Path: v1 + "/uib/registrations/:id/check-in", Tag: "uib", Summary: "Admin: mark a confirmed registration as attended", Secured: true},
+		Operation{Method: http.MethodGet, Path: v1 + "/uib/registrations/:id/certificate", Tag: "uib", Summary: "Signed, expiring download URL of your certificate of attendance", Secured: true,
+			Description: "Available after check-in. The PDF is generated on the first request; the URL expires after CERTIFICATE_URL_TTL_MINUTES."},
+		Operation{Method: http.MethodPost, Path: v1 + "/uib/query", Tag: "uib", APIKeyScope: "uib:read", Summary: "Find events relevant to a natural-language query", Secured: true,
+			Body: map[string]any{"query": "acara bulan 11"}},
+		Operation{Method: http.MethodPost, Path: v1 + "/uib/context", Tag: "uib", APIKeyScope: "uib:read", Summary: "Build the formatted Gemini context for a query", Secured: true,
+			Body: map[string]any{"query": "webinar november"}},
+
+		// Images
+		Operation{Method: http.MethodGet, Path: v1 + "/images/health", Tag: "images", APIKeyScope: "images:search", Summary: "Image search service status and today's Google API quota", Secured: true},
+		Operation{Method: http.MethodPost, Path: v1 + "/images/search", Tag: "images", APIKeyScope: "images:search", Summary: "Search images for a query", Secured: true,
+			Description: "Results are cached per normalized query (IMAGE_CACHE_TTL_SECONDS). Once GOOGLE_API_DAILY_QUOTA is used up, mock catalog images are returned.",
+			Body:        map[string]any{"query": "kampus UIB", "max_results": 4}},
+		Operation{Method: http.MethodGet, Path: v1 + "/images/chat", Tag: "images", APIKeyScope: "images:search", Summary: "Search images using a chat message", Secured: true,
+			Description: "The search term is extracted from q: a mentioned UIB event title, else the university name plus remaining keywords.",
+			Params: []Param{
+				{Name: "q", In: "query", Required: true},
+				{Name: "max", In: "query", Type: "integer"},
+			}},
+
+		// Analytics
+		Operation{Method: http.MethodGet, Path: v1 + "/analytics/me", Tag: "analytics", Summary: "Messages per day, response latency and topics for the current user", Secured: true,
+			Params: []Param{
+				{Name: "days", In: "query", Type: "integer", Description: "Window in days (default 30, max 365)"},
+				{Name: "conversation_id", In: "query", Type: "integer", Description: "Limit to one conversation"},
+			}},
+		Operation{Method: http.MethodGet, Path: v1 + "/analytics/global", Tag: "analytics", Summary: "Activity and topic distribution across all users (admin)", Secured: true,
+			Description: "Includes bookmark and reaction counts and top_answers, the most bookmarked replies.",
+			Params:      []Param{{Name: "days", In: "query", Type: "integer", Description: "Window in days (default 30, max 365)"}}},
+		Operation{Method: http.MethodGet, Path: v1 + "/analytics/prompt-ab", Tag: "analytics", Summary: "Online prompt A/B results per arm (admin)", Secured: true,
+			Description: "Replies, latency, confidence, thumbs up/down and the share of frustrated user messages for conversations assigned by PROMPT_AB_BASELINE_PERCENT.",
+			Params:      []Param{{Name: "days", In: "query", Type: "integer", Description: "Window in days (default 30, max 365)"}}},
+
+		// Webhooks
+		Operation{Method: http.MethodGet, Path: v1 + "/webhooks", Tag: "webhooks", Summary: "List your webhooks and the events they can subscribe to", Secured: true},
+		Operation{Method: http.MethodPost, Path: v1 + "/webhooks", Tag: "webhooks", Summary: "Register a webhook; the signing secret is only shown in this response", Secured: true,
+			Description: "Deliveries are POSTed with X-AkuAI-Event, X-AkuAI-Delivery, X-AkuAI-Timestamp and X-AkuAI-Signature (sha256=HMAC-SHA256 of \"<timestamp>.<body>\") and retried with backoff until a 2xx answer.",
+			Body:        map[string]any{"url": "https://example.com/hooks/akuai", "events": []any{"message.completed"}},
+			Responses:   map[int]string{201: "Webhook with secret", 400: "Invalid URL or unknown event"}},
+		Operation{Method: http.MethodDelete, Path: v1 + "/webhooks/:id", Tag: "webhooks", Summary: "Delete one of your webhooks", Secured: true},
+		Operation{Method: http.MethodGet, Path: v1 + "/webhooks/:id/deliveries", Tag: "webhooks", Summary: "Delivery log of one of your webhooks, newest first", Secured: true,
+			Params: []Param{
+				{Name: "status", In: "query", Description: "pending | succeeded | failed"},
+				{Name: "event", In: "query"},
+				{Name: "limit", In: "query", Description: "Page size, 1-200 (default 50)"},
+				{Name: "before", In: "query", Description: "next_before of the previous page"},
+			}},
+
+		// Messaging gateway
+		Operation{Method: http.MethodPost, Path: v1 + "/messaging/link-code", Tag: "messaging", Summary: "Issue a one-time code that links a Telegram or WhatsApp chat to your account", Secured: true,
+			Description: "Send \"/link <code>\" to the bot within 10 minutes. The response also says which platforms are configured."},
+		Operation{Method: http.MethodGet, Path: v1 + "/messaging/links", Tag: "messaging", Summary: "Chats linked to your account", Secured: true},
+		Operation{Method: http.MethodDelete, Path: v1 + "/messaging/links/:id", Tag: "messaging", Summary: "Unlink a chat", Secured: true},
+		Operation{Method: http.MethodPost, Path: v1 + "/messaging/telegram/webhook", Tag: "messaging", Summary: "Telegram Bot API webhook (called by Telegram)",
+			Params:    []Param{{Name: "X-Telegram-Bot-Api-Secret-Token", In: "header", Description: "TELEGRAM_WEBHOOK_SECRET"}},
+			Responses: map[int]string{200: "Update accepted; the reply is sent in the background", 401: "Wrong secret token", 404: "Telegram not configured"}},
+		Operation{Method: http.MethodGet, Path: v1 + "/messaging/whatsapp/webhook", Tag: "messaging", Summary: "WhatsApp Cloud API subscription check (called by Meta)",
+			Params: []Param{{Name: "hub.mode", In: "query"}, {Name: "hub.verify_token", In: "query"}, {Name: "hub.challenge", In: "query"}}},
+		Operation{Method: http.MethodPost, Path: v1 + "/messaging/whatsapp/webhook", Tag: "messaging", Summary: "WhatsApp Cloud API message webhook (called by Meta)",
+			Params:    []Param{{Name: "X-Hub-Signature-256", In: "header", Description: "HMAC of the body with WHATSAPP_APP_SECRET; updates are refused while it is unset"}},
+			Responses: map[int]string{200: "Update accepted", 401: "Invalid signature", 404: "WhatsApp not configured"}},
+		Operation{Method: http.MethodPost, Path: v1 + "/messaging/slack/command", Tag: "messaging", Summary: "Slack /uib slash command (called by Slack, form-encoded)",
+			Description: "Listings are answered at once; questions are acknowledged and answered through response_url.",
+			Params:      []Param{{Name: "X-Slack-Signature", In: "header"}, {Name: "X-Slack-Request-Timestamp", In: "header"}},
+			Responses:   map[int]string{200: "Slack message", 401: "Invalid or stale signature", 404: "Slack not configured"}},
+		Operation{Method: http.MethodPost, Path: v1 + "/messaging/discord/interactions", Tag: "messaging", Summary: "Discord interactions endpoint for the /uib command (called by Discord)",
+			Params:    []Param{{Name: "X-Signature-Ed25519", In: "header"}, {Name: "X-Signature-Timestamp", In: "header"}},
+			Responses: map[int]string{200: "Interaction response (PONG, message or deferred)", 401: "Invalid signature", 404: "Discord not configured"}},
+
+		// Static
+		// Admin (IsAdmin users or ADMIN_EMAILS). Except for moderation,
+		// frustration and prompts, only the operator tenant's admins
+		Operation{Method: http.MethodGet, Path: v1 + "/admin/metrics", Tag: "admin", Summary: "Snapshot of runtime metrics", Secured: true},
+		Operation{Method: http.MethodGet, Path: v1 + "/admin/slots", Tag: "admin", Summary: "Per-user concurrency and wait-queue limits", Secured: true},
+		Operation{Method: http.MethodPut, Path: v1 + "/admin/slots", Tag: "admin", Summary: "Tune the per-user wait queue", Secured: true,
+			Body: map[string]any{"max_queue": 4, "max_wait_seconds": 15}},
+		Operation{Method: http.MethodGet, Path: v1 + "/admin/retention", Tag: "admin", Summary: "Retention policy, last run and recent audit events", Secured: true},
+		Operation{Method: http.MethodPost, Path: v1 + "/admin/retention/run", Tag: "admin", Summary: "Run the retention policy now", Secured: true,
+			Params: []Param{{Name: "dry_run", In: "query", Description: "Set to 1 to only record what would change"}}},
+		Operation{Method: http.MethodPost, Path: v1 + "/admin/digest/run", Tag: "admin", Summary: "Send the weekly digest to every opted-in user now", Secured: true,
+			Params: []Param{{Name: "from", In: "query", Description: "First day of the week, YYYY-MM-DD (default today)"}}},
+		Operation{Method: http.MethodGet, Path: v1 + "/admin/moderation", Tag: "admin", Summary: "Recent flagged and blocked chat messages", Secured: true,
+			Params: []Param{{Name: "action", In: "query", Description: "flag | block"}}},
+		Operation{Method: http.MethodGet, Path: v1 + "/admin/frustration", Tag: "admin", Summary: "Conversations flagged after repeated frustrated messages", Secured: true,
+			Description: "A conversation is flagged when FRUSTRATION_ALERT_COUNT user messages in a row are rated frustrated. Each comes with its last user messages and their sentiment."},
+		Operation{Method: http.MethodPost, Path: v1 + "/admin/frustration/:conversation_id/review", Tag: "admin", Summary: "Mark a flagged conversation as reviewed", Secured: true,
+			Description: "Clears the flag; the conversation is flagged again on its next run of frustrated messages.",
+			Responses:   map[int]string{200: "Reviewed", 404: "No flagged conversation with that ID"}},
+		Operation{Method: http.MethodGet, Path: v1 + "/admin/prompts", Tag: "admin", Summary: "Prompt blocks of event answers: version in effect, defaults, history", Secured: true,
+			Description: "With ?campus= also a preview of the whole system instruction.",
+			Params:      []Param{{Name: "campus", In: "query", Description: "Campus name or alias (default: every campus of the tenant)"}},
+			Responses:   map[int]string{200: "campus, current, defaults, history and preview", 404: "Unknown campus"}},
+		Operation{Method: http.MethodPut, Path: v1 + "/admin/prompts", Tag: "admin", Summary: "Save a new version of the tone and rules", Secured: true,
+			Description: "Takes effect on the next question and drops the campus's cached event answers. An empty tone or rules keeps the built-in block.",
+			Params:      []Param{{Name: "campus", In: "query", Description: "Campus name or alias (default: every campus of the tenant)"}},
+			Body:        map[string]any{"tone": "Kamu adalah asisten ramah untuk UIB.", "rules": []string{"Jawab singkat", "Sebutkan penanda sumber"}, "note": "lebih ringkas"},
+			Responses:   map[int]string{201: "The saved version", 400: "Validation error", 404: "Unknown campus"}},
+		Operation{Method: http.MethodPost, Path: v1 + "/admin/prompts/versions/:version/restore", Tag: "admin", Summary: "Save an older version again as the one in effect", Secured: true,
+			Params:    []Param{{Name: "campus", In: "query", Description: "Campus name or alias (default: every campus of the tenant)"}},
+			Responses: map[int]string{201: "The new version", 404: "Unknown campus or version"}},
+		Operation{Method: http.MethodGet, Path: v1 + "/admin/documents", Tag: "admin", Summary: "List knowledge-base documents", Secured: true},
+		Operation{Method: http.MethodPost, Path: v1 + "/admin/documents", Tag: "admin", Summary: "Upload an FAQ document (multipart: file, title)", Secured: true,
+			Description: "Accepts .md, .markdown, .txt and text-based .pdf up to DOCUMENT_MAX_UPLOAD_MB. The text is chunked and relevant chunks are added to the Gemini context with [DOC-<id>-<seq>] citation markers.",
+			Responses:   map[int]string{201: "Document indexed", 400: "Missing file or unsupported type", 413: "File too large", 422: "No extractable text"}},
+		Operation{Method: http.MethodDelete, Path: v1 + "/admin/documents/:id", Tag: "admin", Summary: "Delete a document and its chunks", Secured: true},
+		Operation{Method: http.MethodGet, Path: v1 + "/admin/announcements", Tag: "admin", Summary: "List announcements with status and seen counts", Secured: true},
+		Operation{Method: http.MethodPost, Path: v1 + "/admin/announcements", Tag: "admin", Summary: "Publish or schedule an announcement", Secured: true,
+			Body: map[string]any{"title": "Libur", "body": "Kampus tutup 25 Desember.", "starts_at": "2026-12-20T08:00:00+07:00", "expires_at": "2026-12-26T00:00:00+07:00"}},
+		Operation{Method: http.MethodDelete, Path: v1 + "/admin/announcements/:id", Tag: "admin", Summary: "Withdraw an announcement", Secured: true},
+		Operation{Method: http.MethodGet, Path: v1 + "/admin/api-keys", Tag: "admin", Summary: "List API keys (without secrets) and the known scopes", Secured: true},
+		Operation{Method: http.MethodPost, Path: v1 + "/admin/api-keys", Tag: "admin", Summary: "Issue an API key; the key is only shown in this response", Secured: true,
+			Body: map[string]any{"name": "portal-akademik", "scopes": []any{"uib:read"}, "expires_at": "2027-06-30T00:00:00+07:00"}},
+		Operation{Method: http.MethodDelete, Path: v1 + "/admin/api-keys/:id", Tag: "admin", Summary: "Revoke an API key", Secured: true},
+		Operation{Method: http.MethodGet, Path: v1 + "/admin/lockouts", Tag: "admin", Summary: "Accounts and IPs with failed logins, locked ones first", Secured: true},
+		Operation{Method: http.MethodPost, Path: v1 + "/admin/lockouts/reset", Tag: "admin", Summary: "Unlock an account and/or IP", Secured: true,
+			Body: map[string]any{"account": "mahasiswa@uib.ac.id", "ip": "203.0.113.7"}},
+		Operation{Method: http.MethodGet, Path: v1 + "/admin/webhooks", Tag: "admin", Summary: "Every webhook, users' and admin ones, without secrets", Secured: true},
+		Operation{Method: http.MethodPost, Path: v1 + "/admin/webhooks", Tag: "admin", Summary: "Register an admin webhook that receives every subscribed event", Secured: true,
+			Body: map[string]any{"url": "https://ops.uib.ac.id/hooks/akuai", "events": []any{"*"}}},
+		Operation{Method: http.MethodDelete, Path: v1 + "/admin/webhooks/:id", Tag: "admin", Summary: "Delete any webhook", Secured: true},
+		Operation{Method: http.MethodGet, Path: v1 + "/admin/webhooks/deliveries", Tag: "admin", Summary: "Webhook delivery log, newest first", Secured: true,
+			Params: []Param{
+				{Name: "webhook_id", In: "query"},
+				{Name: "status", In: "query", Description: "pending | succeeded | failed"},
+				{Name: "event", In: "query"},
+				{Name: "limit", In: "query", Description: "Page size, 1-200 (default 50)"},
+				{Name: "before", In: "query", Description: "next_before of the previous page"},
+			}},
+		Operation{Method: http.MethodPost, Path: v1 + "/admin/webhooks/deliveries/:id/redeliver", Tag: "admin", Summary: "Queue a delivery to be sent again", Secured: true,
+			Responses: map[int]string{202: "New pending delivery", 404: "Delivery not found"}},
+		Operation{Method: http.MethodGet, Path: v1 + "/admin/jwt/keys", Tag: "admin", Summary: "JWT signing keys (kid, status, verify_until), without secrets", Secured: true},
+		Operation{Method: http.MethodPost, Path: v1 + "/admin/jwt/rotate", Tag: "admin", Summary: "Sign new tokens with a fresh key; the previous key verifies until its tokens expire", Secured: true},
+		Operation{Method: http.MethodGet, Path: v1 + "/admin/audit", Tag: "admin", Summary: "Audit log of sensitive operations, newest first", Secured: true,
+			Params: []Param{
+				{Name: "action", In: "query", Description: `Exact action, or a prefix ending in "." (e.g. admin.)`},
+				{Name: "actor_id", In: "query", Description: "User who performed the operation"},
+				{Name: "target_type", In: "query", Description: "user | conversation | document | announcement | api_key | settings | retention_run"},
+				{Name: "target_id", In: "query", Description: "Id of the target"},
+				{Name: "from", In: "query", Description: "RFC 3339 or YYYY-MM-DD, inclusive"},
+				{Name: "to", In: "query", Description: "RFC 3339 or YYYY-MM-DD, exclusive"},
+				{Name: "limit", In: "query", Description: "Page size, 1-500 (default 100)"},
+				{Name: "before", In: "query", Description: "next_before of the previous page"},
+			}},
+
+		Operation{Method: http.MethodGet, Path: "/uploads/profiles/*path", Tag: "static", Summary: "Serve a profile image",
+			Description: "Needs the expires and signature of a URL from GET /profile/image unless the image is public.",
+			Params: []Param{
+				{Name: "expires", In: "query", Description: "Unix time the URL expires"},
+				{Name: "signature", In: "query", Description: "HMAC of the path and expiry"},
+			}},
+		Operation{Method: http.MethodGet, Path: "/files/certificates/*path", Tag: "static", Summary: "Download a certificate of attendance",
+			Description: "Only through the signed, expiring URL from GET /uib/registrations/:id/certificate.",
+			Params: []Param{
+				{Name: "expires", In: "query", Description: "Unix time the URL expires"},
+				{Name: "signature", In: "query", Description: "HMAC of the path and expiry"},
+			}},
+	)
+}
@@ -0,0 +1,118 @@
+// Package apierror is the error envelope the JSON handlers answer with, and
+// the request binding that fills it with per-field validation errors.
+package apierror
+
+import (
+	"AkuAI/pkg/i18n"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// FieldError is one failed rule on one request field.
+type FieldError struct {
+	Field   string `json:"field"`   // JSON name, e.g. "confirm_password"
+	Rule    string `json:"rule"`    // binding tag that failed, e.g. "required"
+	Message string `json:"message"` // readable reason
+}
+
+// Response is the body of every error reply.
+type Response struct {
+	Success bool           `json:"success"`
+	Code    string         `json:"code"`
+	Message string         `json:"message"`
+	Fields  []FieldError   `json:"fields,omitempty"`
+	Details map[string]any `json:"details,omitempty"`
+	// Msg repeats Message for clients written against the old {"msg": ...}
+	// errors.
+	Msg string `json:"msg"`
+}
+
+// Codes by HTTP status; CodeValidation replaces invalid_request when the body
+// parsed but broke a field rule.
+const (
+	CodeInvalidRequest = "invalid_request"
+	CodeValidation     = "validation_failed"
+	CodeUnauthorized   = "unauthorized"
+	CodeForbidden      = "forbidden"
+	CodeNotFound       = "not_found"
+	CodeConflict       = "conflict"
+	CodeGone           = "gone"
+	CodeTooLarge       = "payload_too_large"
+	CodeRateLimited    = "rate_limited"
+	CodeInternal       = "internal_error"
+	CodeUnavailable    = "unavailable"
+	CodeTimeout        = "timeout"
+)
+
+// Codes that say more than the status.
+const (
+	CodeAccountLocked   = "account_locked"
+	CodeCaptchaRequired = "captcha_required"
+	CodeGuestLimit      = "guest_limit_reached"
+	CodeUnknownTenant   = "unknown_tenant"
+	CodeMessageBlocked  = "message_blocked"
+)
+
+// CodeFor returns the code of an HTTP error status.
+func CodeFor(status int) string {
+	switch status {
+	case http.StatusBadRequest:
+		return CodeInvalidRequest
+	case http.StatusUnauthorized:
+		return CodeUnauthorized
+	case http.StatusForbidden:
+		return CodeForbidden
+	case http.StatusNotFound:
+		return CodeNotFound
+	case http.StatusConflict:
+		return CodeConflict
+	case http.StatusGone:
+		return CodeGone
+	case http.StatusRequestEntityTooLarge:
+		return CodeTooLarge
+	case http.StatusUnprocessableEntity:
+		return CodeValidation
+	case http.StatusTooManyRequests:
+		return CodeRateLimited
+	case http.StatusServiceUnavailable:
+		return CodeUnavailable
+	case http.StatusGatewayTimeout:
+		return CodeTimeout
+	}
+	if status >= 500 {
+		return CodeInternal
+	}
+	return CodeInvalidRequest
+}
+
+// New builds the envelope for status.
+func New(status int, message string) Response {
+	return Response{Code: CodeFor(status), Message: message, Msg: message}
+}
+
+// Respond writes the envelope for status, with message in the locale of
+// the request.
+func Respond(c *gin.Context, status int, message string) {
+	c.JSON(status, New(status, localize(c, message)))
+}
+
+// localize translates message to the locale the client asked for, if any.
+func localize(c *gin.Context, message string) string {
+	return i18n.Error(c.GetString(i18n.ContextKey), message)
+}
+
+// RespondCode writes the envelope with a code other than the status's.
+func RespondCode(c *gin.Context, status int, code, message string) {
+	r := New(status, localize(c, message))
+	r.Code = code
+	c.JSON(status, r)
+}
+
+// RespondDetails writes the envelope with extra data the client can act on,
+// such as the campuses to pick from.
+func RespondDetails(c *gin.Context, status int, message string, details map[string]any) {
+	r := New(status, localize(c, message))
+	r.Details = details
+	c.JSON(status, r)
+}
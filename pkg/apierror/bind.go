@@ -0,0 +1,82 @@
+package apierror
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+)
+
+// BindJSON decodes the JSON body into obj and checks its binding tags. On
+// failure it writes a 400 envelope, listing the failed fields, and returns
+// false.
+func BindJSON(c *gin.Context, obj any) bool {
+	err := c.ShouldBindJSON(obj)
+	if err == nil {
+		return true
+	}
+	var verrs validator.ValidationErrors
+	if !errors.As(err, &verrs) {
+		Respond(c, http.StatusBadRequest, "request body must be valid JSON")
+		return false
+	}
+	r := New(http.StatusBadRequest, "")
+	r.Code = CodeValidation
+	for _, fe := range verrs {
+		r.Fields = append(r.Fields, FieldError{Field: fe.Field(), Rule: fe.Tag(), Message: fieldMessage(fe)})
+	}
+	r.Message = r.Fields[0].Message
+	r.Msg = r.Message
+	c.JSON(http.StatusBadRequest, r)
+	return false
+}
+
+func fieldMessage(fe validator.FieldError) string {
+	f := fe.Field()
+	switch fe.Tag() {
+	case "required", "notblank":
+		return f + " is required"
+	case "email":
+		return f + " must be a valid email address"
+	case "min":
+		if fe.Kind() == reflect.String {
+			return fmt.Sprintf("%s must be at least %s characters", f, fe.Param())
+		}
+		return fmt.Sprintf("%s must be at least %s", f, fe.Param())
+	case "max":
+		if fe.Kind() == reflect.String {
+			return fmt.Sprintf("%s must be at most %s characters", f, fe.Param())
+		}
+		return fmt.Sprintf("%s must be at most %s", f, fe.Param())
+	case "oneof":
+		return fmt.Sprintf("%s must be one of: %s", f, strings.ReplaceAll(fe.Param(), " ", ", "))
+	case "eqfield":
+		// Param is the Go name of the other field; the ones compared are
+		// single words, so lowercasing gives their JSON name.
+		return fmt.Sprintf("%s must match %s", f, strings.ToLower(fe.Param()))
+	case "password":
+		return f + " must contain at least one letter and one number"
+	}
+	return f + " is invalid"
+}
+
+func init() {
+	v, ok := binding.Validator.Engine().(*validator.Validate)
+	if !ok {
+		return
+	}
+	// Report fields by their JSON names.
+	v.RegisterTagNameFunc(func(f reflect.StructField) string {
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if name == "-" || name == "" {
+			return f.Name
+		}
+		return name
+	})
+	registerValidators(v)
+}
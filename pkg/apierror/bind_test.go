@@ -0,0 +1,75 @@
+package apierror
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+type registerBody struct {
+	Email           string `json:"email" binding:"required,email"`
+	Username        string `json:"username" binding:"notblank,max=5"`
+	Password        string `json:"password" binding:"required,password"`
+	ConfirmPassword string `json:"confirm_password" binding:"required,eqfield=Password"`
+}
+
+func bind(t *testing.T, body string) (bool, int, Response) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+	c.Request.Header.Set("Content-Type", "application/json")
+	var b registerBody
+	ok := BindJSON(c, &b)
+	var r Response
+	if !ok {
+		if err := json.Unmarshal(w.Body.Bytes(), &r); err != nil {
+			t.Fatalf("bad envelope %q: %v", w.Body.String(), err)
+		}
+	}
+	return ok, w.Code, r
+}
+
+func TestBindJSON(t *testing.T) {
+	if ok, _, r := bind(t, `{"email":"a@b.co","username":"ani","password":"abc123","confirm_password":"abc123"}`); !ok {
+		t.Fatalf("valid body rejected: %+v", r)
+	}
+
+	ok, code, r := bind(t, `{"email":"nope","username":"  ","password":"abcdef","confirm_password":"abc"}`)
+	if ok || code != http.StatusBadRequest || r.Code != CodeValidation || r.Success {
+		t.Fatalf("got ok=%v code=%d %+v", ok, code, r)
+	}
+	want := map[string]string{"email": "email", "username": "notblank", "password": "password", "confirm_password": "eqfield"}
+	if len(r.Fields) != len(want) {
+		t.Fatalf("fields %+v, want %v", r.Fields, want)
+	}
+	for _, f := range r.Fields {
+		if want[f.Field] != f.Rule {
+			t.Errorf("field %s failed %s, want %s", f.Field, f.Rule, want[f.Field])
+		}
+	}
+	if r.Message != "email must be a valid email address" || r.Msg != r.Message {
+		t.Errorf("message %q msg %q", r.Message, r.Msg)
+	}
+	if got := r.Fields[3].Message; got != "confirm_password must match password" {
+		t.Errorf("eqfield message %q", got)
+	}
+
+	ok, code, r = bind(t, `{"email":`)
+	if ok || code != http.StatusBadRequest || r.Code != CodeInvalidRequest || len(r.Fields) != 0 {
+		t.Errorf("malformed JSON gave ok=%v code=%d %+v", ok, code, r)
+	}
+}
+
+func TestCodeFor(t *testing.T) {
+	for status, want := range map[int]string{400: CodeInvalidRequest, 401: CodeUnauthorized, 404: CodeNotFound, 409: CodeConflict, 429: CodeRateLimited, 500: CodeInternal, 502: CodeInternal, 503: CodeUnavailable} {
+		if got := CodeFor(status); got != want {
+			t.Errorf("CodeFor(%d) = %s, want %s", status, got, want)
+		}
+	}
+}
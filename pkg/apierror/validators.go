@@ -0,0 +1,26 @@
+package apierror
+
+import (
+	utils "AkuAI/pkg/utills"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// PasswordOK is the password policy: at least one letter and one number.
+func PasswordOK(s string) bool {
+	return utils.HasLetter(s) && utils.HasNumber(s)
+}
+
+// registerValidators adds the custom binding tags:
+//
+//	password  PasswordOK
+//	notblank  not empty after trimming spaces
+func registerValidators(v *validator.Validate) {
+	v.RegisterValidation("password", func(fl validator.FieldLevel) bool {
+		return PasswordOK(fl.Field().String())
+	})
+	v.RegisterValidation("notblank", func(fl validator.FieldLevel) bool {
+		return strings.TrimSpace(fl.Field().String()) != ""
+	})
+}
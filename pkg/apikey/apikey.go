@@ -0,0 +1,71 @@
+// Package apikey issues and checks the API keys other campus systems use
+// instead of a user JWT (models.APIKey).
+package apikey
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// keyPrefix starts every key, so leaked keys are easy to grep for.
+const keyPrefix = "akuai_"
+
+// Scopes an API key can be granted and what they allow.
+const (
+	ScopeUIBRead      = "uib:read"      // /uib/* event data
+	ScopeImagesSearch = "images:search" // /images/*
+)
+
+var known = map[string]bool{ScopeUIBRead: true, ScopeImagesSearch: true}
+
+// KnownScopes lists the scopes keys can be granted, sorted.
+func KnownScopes() []string {
+	out := make([]string, 0, len(known))
+	for s := range known {
+		out = append(out, s)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// NormalizeScopes validates scopes and returns them sorted, without
+// duplicates, joined for storage.
+func NormalizeScopes(scopes []string) (string, error) {
+	seen := map[string]bool{}
+	var out []string
+	for _, s := range scopes {
+		s = strings.ToLower(strings.TrimSpace(s))
+		if !known[s] {
+			return "", fmt.Errorf("unknown scope %q (known: %s)", s, strings.Join(KnownScopes(), ", "))
+		}
+		if !seen[s] {
+			seen[s] = true
+			out = append(out, s)
+		}
+	}
+	if len(out) == 0 {
+		return "", fmt.Errorf("at least one scope is required (known: %s)", strings.Join(KnownScopes(), ", "))
+	}
+	sort.Strings(out)
+	return strings.Join(out, ","), nil
+}
+
+// Generate returns a new key, its display prefix and the hash to store.
+func Generate() (key, prefix, hash string, err error) {
+	b := make([]byte, 24)
+	if _, err := rand.Read(b); err != nil {
+		return "", "", "", err
+	}
+	key = keyPrefix + hex.EncodeToString(b)
+	return key, key[:len(keyPrefix)+6], Hash(key), nil
+}
+
+// Hash is the stored form of a key.
+func Hash(key string) string {
+	h := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(h[:])
+}
@@ -0,0 +1,36 @@
+package apikey
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestNormalizeScopes(t *testing.T) {
+	got, err := NormalizeScopes([]string{" images:search", "UIB:READ", "uib:read"})
+	if err != nil || got != "images:search,uib:read" {
+		t.Fatalf("got %q, %v", got, err)
+	}
+	if _, err := NormalizeScopes([]string{"uib:write"}); err == nil {
+		t.Error("unknown scope accepted")
+	}
+	if _, err := NormalizeScopes(nil); err == nil {
+		t.Error("empty scopes accepted")
+	}
+}
+
+func TestGenerate(t *testing.T) {
+	key, prefix, hash, err := Generate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(key, keyPrefix) || !strings.HasPrefix(key, prefix) || len(prefix) >= len(key) {
+		t.Errorf("key %q prefix %q", key, prefix)
+	}
+	if hash != Hash(key) || strings.Contains(hash, key) {
+		t.Error("hash does not match the key")
+	}
+	other, _, _, _ := Generate()
+	if other == key {
+		t.Error("two keys are equal")
+	}
+}
@@ -0,0 +1,151 @@
+// Package audit records sensitive operations (models.AuditLog) and queries
+// them for the admin audit endpoint. Recording never fails the request it
+// describes; errors are only logged.
+package audit
+
+import (
+	"AkuAI/models"
+	"encoding/json"
+	"log"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Audited actions.
+const (
+	ActionLogin        = "auth.login"
+	ActionLoginFailed  = "auth.login_failed"
+	ActionLogout       = "auth.logout"
+	ActionLoginLockout = "auth.lockout"
+	ActionGuestClaim   = "auth.guest_claim"
+
+	ActionProfileUpdate      = "profile.update"
+	ActionProfileImageUpload = "profile.image_upload"
+	ActionProfileImageDelete = "profile.image_delete"
+	ActionPreferencesUpdate  = "profile.preferences_update"
+	ActionChatLink           = "profile.chat_link"
+	ActionChatUnlink         = "profile.chat_unlink"
+
+	ActionConversationDelete    = "conversation.delete"
+	ActionConversationDeleteAll = "conversation.delete_all"
+	ActionConversationRestore   = "conversation.restore"
+	ActionConversationEmail     = "conversation.email"
+
+	ActionWebhookCreate = "webhook.create"
+	ActionWebhookDelete = "webhook.delete"
+
+	ActionRegistrationCreate  = "registration.create"
+	ActionRegistrationCancel  = "registration.cancel"
+	ActionRegistrationCheckIn = "registration.check_in"
+
+	ActionSlotsUpdate        = "admin.slots_update"
+	ActionRetentionRun       = "admin.retention_run"
+	ActionDocumentUpload     = "admin.document_upload"
+	ActionEventImport        = "admin.event_import"
+	ActionDocumentDelete     = "admin.document_delete"
+	ActionAnnouncementCreate = "admin.announcement_create"
+	ActionAnnouncementDelete = "admin.announcement_delete"
+	ActionAPIKeyCreate       = "admin.api_key_create"
+	ActionAPIKeyRevoke       = "admin.api_key_revoke"
+	ActionLockoutReset       = "admin.lockout_reset"
+	ActionJWTKeyRotate       = "admin.jwt_key_rotate"
+	ActionWebhookRedeliver   = "admin.webhook_redeliver"
+	ActionDigestRun          = "admin.digest_run"
+	ActionPromptSave         = "admin.prompt_save"
+	ActionFrustrationReview  = "admin.frustration_review"
+)
+
+// Entry is one operation to record. Before and After are marshalled to JSON;
+// leave them nil when there is no such state.
+type Entry struct {
+	Action     string
+	ActorID    uint
+	TargetType string
+	TargetID   string
+	IP         string
+	UserAgent  string
+	Before     any
+	After      any
+}
+
+// Record stores e.
+func Record(db *gorm.DB, e Entry) {
+	row := models.AuditLog{
+		Action:     e.Action,
+		ActorID:    e.ActorID,
+		TargetType: e.TargetType,
+		TargetID:   e.TargetID,
+		IP:         e.IP,
+		UserAgent:  truncate(e.UserAgent, 255),
+		Before:     snapshot(e.Before),
+		After:      snapshot(e.After),
+	}
+	if err := db.Create(&row).Error; err != nil {
+		log.Printf("[audit] ⚠️ failed to record %s by user %d: %v", e.Action, e.ActorID, err)
+	}
+}
+
+func snapshot(v any) string {
+	if v == nil {
+		return ""
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		log.Printf("[audit] ⚠️ snapshot not serialisable: %v", err)
+		return ""
+	}
+	return string(b)
+}
+
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return s[:n]
+}
+
+// Filter narrows Query. Zero fields match everything.
+type Filter struct {
+	Action     string // exact, or a prefix ending in "." such as "admin."
+	ActorID    uint
+	TargetType string
+	TargetID   string
+	From, To   time.Time // CreatedAt in [From, To)
+	BeforeID   uint      // page cursor: only entries older than this id
+	Limit      int       // 0 = no limit
+}
+
+// Query returns the entries matching f, newest first.
+func Query(db *gorm.DB, f Filter) ([]models.AuditLog, error) {
+	q := db.Model(&models.AuditLog{}).Order("id DESC")
+	if f.Limit > 0 {
+		q = q.Limit(f.Limit)
+	}
+	if strings.HasSuffix(f.Action, ".") {
+		q = q.Where("action LIKE ?", f.Action+"%")
+	} else if f.Action != "" {
+		q = q.Where("action = ?", f.Action)
+	}
+	if f.ActorID != 0 {
+		q = q.Where("actor_id = ?", f.ActorID)
+	}
+	if f.TargetType != "" {
+		q = q.Where("target_type = ?", f.TargetType)
+	}
+	if f.TargetID != "" {
+		q = q.Where("target_id = ?", f.TargetID)
+	}
+	if !f.From.IsZero() {
+		q = q.Where("created_at >= ?", f.From)
+	}
+	if !f.To.IsZero() {
+		q = q.Where("created_at < ?", f.To)
+	}
+	if f.BeforeID != 0 {
+		q = q.Where("id < ?", f.BeforeID)
+	}
+	var out []models.AuditLog
+	return out, q.Find(&out).Error
+}
@@ -0,0 +1,467 @@
+// Package backup writes what it takes to bring AkuAI back on a new server -
+// accounts, conversations and messages, event datasets and registrations,
+// and a manifest of the uploaded files - into one versioned archive, and
+// restores such an archive into a fresh database.
+//
+// The archive is a gzipped tar: manifest.json first, then one JSON Lines
+// file per table, the event dataset files under events/, and uploads.jsonl
+// listing every file under the uploads directory with its size and sha256.
+// Uploads themselves are not copied; Restore reports the listed files that
+// are missing or changed on the new server.
+package backup
+
+import (
+	"AkuAI/models"
+	"AkuAI/pkg/migrations"
+	"archive/tar"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Format and Version identify the archive layout. Restore refuses archives
+// of a later Version.
+const (
+	Format  = "akuai-backup"
+	Version = 1
+)
+
+// ErrNotEmpty is returned by Restore when the database already has users or
+// conversations.
+var ErrNotEmpty = errors.New("the database is not empty; restore into a fresh database")
+
+// Manifest describes an archive.
+type Manifest struct {
+	Format         string         `json:"format"`
+	Version        int            `json:"version"`
+	CreatedAt      time.Time      `json:"created_at"`
+	Schema         string         `json:"schema"`          // last migration applied to the source database
+	PasswordHashes bool           `json:"password_hashes"` // false: users were dumped without them
+	Counts         map[string]int `json:"counts"`          // rows per table, files per events/ and uploads
+}
+
+// Paths are where the files of a server live: the default event dataset
+// (data/uib_events.json), the directory of the other campus datasets
+// (CAMPUS_DATA_DIR) and the uploads directory.
+type Paths struct {
+	DefaultDataset string
+	CampusDataDir  string
+	UploadsDir     string
+}
+
+// Options says what Create puts in the archive.
+type Options struct {
+	Paths
+	// PasswordHashes keeps the users' password hashes. Without them restored
+	// accounts can't sign in until a password is set again.
+	PasswordHashes bool
+}
+
+// Upload is one line of uploads.jsonl.
+type Upload struct {
+	Path    string    `json:"path"` // slash-separated, relative to the uploads directory
+	Size    int64     `json:"size"`
+	SHA256  string    `json:"sha256"`
+	ModTime time.Time `json:"mod_time"`
+}
+
+// Result is what Restore did.
+type Result struct {
+	Manifest Manifest       `json:"manifest"`
+	Restored map[string]int `json:"restored"` // rows per table, and event files written
+	// Uploads listed in the archive that are missing from the uploads
+	// directory or differ from it
+	MissingUploads []string `json:"missing_uploads"`
+}
+
+// batchSize is how many rows are read or inserted at a time.
+const batchSize = 500
+
+// table dumps and loads one table as JSON Lines of its model.
+type table struct {
+	name string
+	dump func(db *gorm.DB, w io.Writer, opt Options) (int, error)
+	load func(tx *gorm.DB, r io.Reader) (int, error)
+}
+
+// tables are restored in this order, parents first.
+var tables = []table{
+	{"users", dumpRows(func(u *models.User, opt Options) {
+		if !opt.PasswordHashes {
+			u.PasswordHash = ""
+		}
+	}), loadRows[models.User]},
+	{"user_preferences", dumpRows[models.UserPreferences](nil), loadRows[models.UserPreferences]},
+	{"folders", dumpRows[models.Folder](nil), loadRows[models.Folder]},
+	{"conversations", dumpRows[models.Conversation](nil), loadRows[models.Conversation]},
+	{"messages", dumpRows[models.Message](nil), loadRows[models.Message]},
+	{"message_citations", dumpRows[models.MessageCitation](nil), loadRows[models.MessageCitation]},
+	{"message_bookmarks", dumpRows[models.MessageBookmark](nil), loadRows[models.MessageBookmark]},
+	{"message_reactions", dumpRows[models.MessageReaction](nil), loadRows[models.MessageReaction]},
+	{"user_memories", dumpRows[models.UserMemory](nil), loadRows[models.UserMemory]},
+	{"event_registrations", dumpRows[models.EventRegistration](nil), loadRows[models.EventRegistration]},
+	{"event_revisions", dumpRows[models.EventRevision](nil), loadRows[models.EventRevision]},
+	{"prompt_versions", dumpRows[models.PromptVersion](nil), loadRows[models.PromptVersion]},
+}
+
+// dumpRows writes every row of T, soft-deleted ones (the trash) included,
+// after scrub.
+func dumpRows[T any](scrub func(*T, Options)) func(*gorm.DB, io.Writer, Options) (int, error) {
+	return func(db *gorm.DB, w io.Writer, opt Options) (int, error) {
+		enc := json.NewEncoder(w)
+		n := 0
+		var rows []T
+		err := db.Unscoped().FindInBatches(&rows, batchSize, func(*gorm.DB, int) error {
+			for i := range rows {
+				if scrub != nil {
+					scrub(&rows[i], opt)
+				}
+				if err := enc.Encode(&rows[i]); err != nil {
+					return err
+				}
+				n++
+			}
+			return nil
+		}).Error
+		return n, err
+	}
+}
+
+// loadRows inserts the rows of r, keeping their IDs.
+func loadRows[T any](tx *gorm.DB, r io.Reader) (int, error) {
+	dec := json.NewDecoder(r)
+	n := 0
+	batch := make([]T, 0, batchSize)
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		if err := tx.Omit(clause.Associations).Create(&batch).Error; err != nil {
+			return err
+		}
+		n += len(batch)
+		batch = batch[:0]
+		return nil
+	}
+	for {
+		var row T
+		if err := dec.Decode(&row); err == io.EOF {
+			break
+		} else if err != nil {
+			return n, err
+		}
+		batch = append(batch, row)
+		if len(batch) == batchSize {
+			if err := flush(); err != nil {
+				return n, err
+			}
+		}
+	}
+	return n, flush()
+}
+
+// schemaVersion is the last migration applied to db.
+func schemaVersion(db *gorm.DB) (string, error) {
+	applied, err := migrations.Applied(db)
+	if err != nil {
+		return "", err
+	}
+	last := ""
+	for _, id := range applied {
+		if id != migrations.SchemaInitID && id > last {
+			last = id
+		}
+	}
+	return last, nil
+}
+
+// Create writes an archive of db to w.
+func Create(db *gorm.DB, w io.Writer, opt Options) (Manifest, error) {
+	m := Manifest{Format: Format, Version: Version, CreatedAt: time.Now().UTC(), PasswordHashes: opt.PasswordHashes, Counts: map[string]int{}}
+	schema, err := schemaVersion(db)
+	if err != nil {
+		return m, err
+	}
+	m.Schema = schema
+
+	// The tables are dumped to temporary files first: tar needs every
+	// size up front, and the manifest with the counts goes first.
+	tmp, err := os.MkdirTemp("", "akuai-backup-")
+	if err != nil {
+		return m, err
+	}
+	defer os.RemoveAll(tmp)
+	type entry struct{ name, file string }
+	var entries []entry
+	for _, t := range tables {
+		file := filepath.Join(tmp, t.name+".jsonl")
+		n, err := dumpTo(file, func(w io.Writer) (int, error) { return t.dump(db, w, opt) })
+		if err != nil {
+			return m, fmt.Errorf("%s: %w", t.name, err)
+		}
+		m.Counts[t.name] = n
+		entries = append(entries, entry{t.name + ".jsonl", file})
+	}
+	if opt.DefaultDataset != "" {
+		entries = append(entries, entry{"events/default.json", opt.DefaultDataset})
+		m.Counts["events"]++
+	}
+	campuses, _ := filepath.Glob(filepath.Join(opt.CampusDataDir, "*.json"))
+	for _, f := range campuses {
+		entries = append(entries, entry{"events/campuses/" + filepath.Base(f), f})
+		m.Counts["events"]++
+	}
+	file := filepath.Join(tmp, "uploads.jsonl")
+	n, err := dumpTo(file, func(w io.Writer) (int, error) { return listUploads(opt.UploadsDir, w) })
+	if err != nil {
+		return m, fmt.Errorf("uploads: %w", err)
+	}
+	m.Counts["uploads"] = n
+	entries = append(entries, entry{"uploads.jsonl", file})
+
+	gz := gzip.NewWriter(w)
+	tw := tar.NewWriter(gz)
+	manifest, _ := json.MarshalIndent(m, "", "  ")
+	if err := tw.WriteHeader(&tar.Header{Name: "manifest.json", Mode: 0o600, Size: int64(len(manifest)), ModTime: m.CreatedAt}); err != nil {
+		return m, err
+	}
+	if _, err := tw.Write(manifest); err != nil {
+		return m, err
+	}
+	for _, e := range entries {
+		if err := addFile(tw, e.name, e.file); err != nil {
+			return m, err
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return m, err
+	}
+	return m, gz.Close()
+}
+
+// dumpTo runs dump into a new file.
+func dumpTo(file string, dump func(io.Writer) (int, error)) (int, error) {
+	f, err := os.Create(file)
+	if err != nil {
+		return 0, err
+	}
+	n, err := dump(f)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	return n, err
+}
+
+func addFile(tw *tar.Writer, name, file string) error {
+	f, err := os.Open(file)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	fi, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	if err := tw.WriteHeader(&tar.Header{Name: name, Mode: 0o600, Size: fi.Size(), ModTime: fi.ModTime()}); err != nil {
+		return err
+	}
+	_, err = io.Copy(tw, f)
+	return err
+}
+
+// listUploads writes an Upload line for every file under dir; a missing
+// dir has none.
+func listUploads(dir string, w io.Writer) (int, error) {
+	if dir == "" {
+		return 0, nil
+	}
+	enc := json.NewEncoder(w)
+	n := 0
+	err := filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if errors.Is(err, fs.ErrNotExist) && p == dir {
+			return filepath.SkipDir
+		}
+		if err != nil || d.IsDir() {
+			return err
+		}
+		u, err := describeUpload(dir, p)
+		if err != nil {
+			return err
+		}
+		n++
+		return enc.Encode(u)
+	})
+	return n, err
+}
+
+func describeUpload(dir, p string) (Upload, error) {
+	rel, err := filepath.Rel(dir, p)
+	if err != nil {
+		return Upload{}, err
+	}
+	f, err := os.Open(p)
+	if err != nil {
+		return Upload{}, err
+	}
+	defer f.Close()
+	fi, err := f.Stat()
+	if err != nil {
+		return Upload{}, err
+	}
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return Upload{}, err
+	}
+	return Upload{Path: filepath.ToSlash(rel), Size: fi.Size(), SHA256: hex.EncodeToString(h.Sum(nil)), ModTime: fi.ModTime().UTC()}, nil
+}
+
+// Restore migrates db to the current schema and loads the archive from r
+// into it, all tables in one transaction. The event datasets are written to
+// paths, replacing the files there, and the uploads listed in the archive
+// are checked against paths.UploadsDir. db must hold no users or
+// conversations yet.
+func Restore(db *gorm.DB, r io.Reader, paths Paths) (Result, error) {
+	res := Result{Restored: map[string]int{}}
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return res, fmt.Errorf("not a backup archive: %w", err)
+	}
+	tr := tar.NewReader(gz)
+	hdr, err := tr.Next()
+	if err != nil || hdr.Name != "manifest.json" {
+		return res, errors.New("not a backup archive: manifest.json missing")
+	}
+	if err := json.NewDecoder(tr).Decode(&res.Manifest); err != nil || res.Manifest.Format != Format {
+		return res, errors.New("not a backup archive: unreadable manifest.json")
+	}
+	m := res.Manifest
+	if m.Version > Version {
+		return res, fmt.Errorf("archive version %d is newer than this build supports (%d)", m.Version, Version)
+	}
+	known := migrations.List()
+	if len(known) > 0 && m.Schema > known[len(known)-1].ID {
+		return res, fmt.Errorf("archive schema %s is newer than this build (%s); restore with the release that made it", m.Schema, known[len(known)-1].ID)
+	}
+
+	if err := migrations.Up(db); err != nil {
+		return res, fmt.Errorf("migrate: %w", err)
+	}
+	var users, convs int64
+	if err := db.Unscoped().Model(&models.User{}).Count(&users).Error; err != nil {
+		return res, err
+	}
+	if err := db.Unscoped().Model(&models.Conversation{}).Count(&convs).Error; err != nil {
+		return res, err
+	}
+	if users+convs > 0 {
+		return res, ErrNotEmpty
+	}
+
+	err = db.Transaction(func(tx *gorm.DB) error {
+		for {
+			hdr, err := tr.Next()
+			if err == io.EOF {
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+			name := path.Clean(hdr.Name)
+			switch {
+			case name == "uploads.jsonl":
+				missing, err := checkUploads(paths.UploadsDir, tr)
+				if err != nil {
+					return fmt.Errorf("uploads: %w", err)
+				}
+				res.MissingUploads = missing
+			case name == "events/default.json" || path.Dir(name) == "events/campuses":
+				dst := paths.DefaultDataset
+				if name != "events/default.json" {
+					dst = filepath.Join(paths.CampusDataDir, path.Base(name))
+				}
+				if err := restoreFile(dst, tr); err != nil {
+					return fmt.Errorf("%s: %w", name, err)
+				}
+				res.Restored["events"]++
+			case strings.HasSuffix(name, ".jsonl"):
+				i := slices.IndexFunc(tables, func(t table) bool { return t.name+".jsonl" == name })
+				if i < 0 {
+					continue // a table of a later layout this build doesn't know
+				}
+				n, err := tables[i].load(tx, tr)
+				if err != nil {
+					return fmt.Errorf("%s: %w", tables[i].name, err)
+				}
+				res.Restored[tables[i].name] = n
+			}
+		}
+	})
+	if err != nil {
+		return res, err
+	}
+	return res, resetSequences(db)
+}
+
+// restoreFile writes the contents of r to p.
+func restoreFile(p string, r io.Reader) error {
+	if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+		return err
+	}
+	f, err := os.Create(p)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(f, r); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// checkUploads returns the listed uploads missing from dir or differing
+// from the archived size and checksum.
+func checkUploads(dir string, r io.Reader) ([]string, error) {
+	missing := []string{}
+	dec := json.NewDecoder(r)
+	for {
+		var u Upload
+		if err := dec.Decode(&u); err == io.EOF {
+			return missing, nil
+		} else if err != nil {
+			return missing, err
+		}
+		got, err := describeUpload(dir, filepath.Join(dir, filepath.FromSlash(u.Path)))
+		if err != nil || got.Size != u.Size || got.SHA256 != u.SHA256 {
+			missing = append(missing, u.Path)
+		}
+	}
+}
+
+// resetSequences moves the Postgres ID sequences past the restored IDs;
+// MySQL and SQLite follow inserted IDs by themselves.
+func resetSequences(db *gorm.DB) error {
+	if db.Dialector.Name() != "postgres" {
+		return nil
+	}
+	for _, t := range tables {
+		sql := fmt.Sprintf("SELECT setval(pg_get_serial_sequence('%[1]s', 'id'), COALESCE(MAX(id), 0) + 1, false) FROM %[1]s", t.name)
+		if err := db.Exec(sql).Error; err != nil {
+			return fmt.Errorf("%s: reset id sequence: %w", t.name, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,128 @@
+package backup
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"AkuAI/models"
+	"AkuAI/pkg/database"
+	"AkuAI/pkg/migrations"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func openDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := database.OpenWith("sqlite", "file:"+filepath.Join(t.TempDir(), "akuai.db"), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+func writeFile(t *testing.T, p, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(p, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCreateRestore(t *testing.T) {
+	src := openDB(t)
+	if err := migrations.Up(src); err != nil {
+		t.Fatal(err)
+	}
+	user := models.User{Email: "a@example.com", Username: "a"}
+	user.SetPassword("Secret123!")
+	src.Create(&user)
+	folder := models.Folder{UserID: user.ID, Name: "Kuliah"}
+	src.Create(&folder)
+	conv := models.Conversation{UserID: user.ID, Title: "Webinar November", FolderID: &folder.ID}
+	src.Create(&conv)
+	question := models.Message{ConversationID: conv.ID, Sender: "user", Text: "Apa saja webinar UIB bulan November?"}
+	src.Create(&question)
+	reply := models.Message{ConversationID: conv.ID, Sender: "bot", Text: "Ada 3 webinar.", Status: models.MessageStopped}
+	src.Create(&reply)
+	src.Create(&models.MessageBookmark{UserID: user.ID, MessageID: reply.ID, Note: "penting"})
+	src.Create(&models.MessageReaction{UserID: user.ID, MessageID: reply.ID, Emoji: "👍"})
+	src.Create(&models.UserMemory{UserID: user.ID, Kind: models.MemoryInterest, Value: "webinar", SourceMessageID: question.ID})
+	trashed := models.Conversation{UserID: user.ID, Title: "Dihapus"}
+	src.Create(&trashed)
+	src.Delete(&trashed)
+	src.Create(&models.EventRegistration{UserID: user.ID, Campus: "UIB", EventID: "EV-1", Status: models.RegistrationConfirmed, QueuedAt: time.Now()})
+
+	dir := t.TempDir()
+	from := Paths{DefaultDataset: filepath.Join(dir, "old", "uib_events.json"), CampusDataDir: filepath.Join(dir, "old", "campuses"), UploadsDir: filepath.Join(dir, "old", "uploads")}
+	writeFile(t, from.DefaultDataset, `{"events":[]}`)
+	writeFile(t, filepath.Join(from.CampusDataDir, "polibatam.json"), `{"institution":"Polibatam"}`)
+	writeFile(t, filepath.Join(from.UploadsDir, "profiles", "1.png"), "png")
+	writeFile(t, filepath.Join(from.UploadsDir, "profiles", "2.png"), "png")
+
+	var archive bytes.Buffer
+	m, err := Create(src, &archive, Options{Paths: from})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.PasswordHashes || m.Counts["conversations"] != 2 || m.Counts["messages"] != 2 || m.Counts["events"] != 2 || m.Counts["uploads"] != 2 {
+		t.Fatalf("manifest = %+v", m)
+	}
+
+	to := Paths{DefaultDataset: filepath.Join(dir, "new", "uib_events.json"), CampusDataDir: filepath.Join(dir, "new", "campuses"), UploadsDir: filepath.Join(dir, "new", "uploads")}
+	writeFile(t, filepath.Join(to.UploadsDir, "profiles", "1.png"), "png")
+	dst := openDB(t)
+	res, err := Restore(dst, bytes.NewReader(archive.Bytes()), to)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Restored["users"] != 1 || res.Restored["messages"] != 2 || res.Restored["events"] != 2 || res.Restored["folders"] != 1 ||
+		res.Restored["message_bookmarks"] != 1 || res.Restored["message_reactions"] != 1 || res.Restored["user_memories"] != 1 {
+		t.Errorf("restored = %v", res.Restored)
+	}
+	if len(res.MissingUploads) != 1 || res.MissingUploads[0] != "profiles/2.png" {
+		t.Errorf("missing uploads = %v", res.MissingUploads)
+	}
+	if b, err := os.ReadFile(filepath.Join(to.CampusDataDir, "polibatam.json")); err != nil || string(b) != `{"institution":"Polibatam"}` {
+		t.Errorf("campus dataset = %q, %v", b, err)
+	}
+
+	var got models.Conversation
+	if err := dst.Preload("Messages").First(&got, conv.ID).Error; err != nil {
+		t.Fatal(err)
+	}
+	if got.Title != conv.Title || len(got.Messages) != 2 || got.Messages[1].Status != models.MessageStopped {
+		t.Errorf("conversation = %+v", got)
+	}
+	var restoredFolder models.Folder
+	if got.FolderID == nil || dst.First(&restoredFolder, *got.FolderID).Error != nil || restoredFolder.Name != folder.Name {
+		t.Errorf("conversation folder %v not restored", got.FolderID)
+	}
+	var restoredUser models.User
+	dst.First(&restoredUser, user.ID)
+	if restoredUser.Email != user.Email || restoredUser.PasswordHash != "" {
+		t.Errorf("user = %+v", restoredUser)
+	}
+	var n int64
+	dst.Unscoped().Model(&models.Conversation{}).Where("id = ? AND deleted_at IS NOT NULL", trashed.ID).Count(&n)
+	if n != 1 {
+		t.Error("trashed conversation not restored to the trash")
+	}
+	next := models.Conversation{UserID: user.ID}
+	if err := dst.Create(&next).Error; err != nil || next.ID <= trashed.ID {
+		t.Errorf("new conversation after restore: id %d, %v", next.ID, err)
+	}
+
+	if _, err := Restore(dst, bytes.NewReader(archive.Bytes()), to); !errors.Is(err, ErrNotEmpty) {
+		t.Errorf("restore into a used database: %v", err)
+	}
+	if _, err := Restore(openDB(t), bytes.NewReader([]byte("not an archive")), to); err == nil {
+		t.Error("restored garbage")
+	}
+}
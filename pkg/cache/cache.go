@@ -1,9 +1,12 @@
 package cache
 
 import (
+	"AkuAI/pkg/i18n"
+	"container/list"
 	"encoding/hex"
 	"hash/fnv"
 	"log"
+	"strings"
 	"sync"
 	"time"
 )
@@ -25,12 +28,40 @@ type CachedResponse struct {
 
 type Item struct {
 	V   any
-	Exp int64
+	Exp int64 // unix nanoseconds, 0 = no expiry
 }
 
+type entry struct {
+	key  string
+	item Item
+	size int
+	tags []string
+}
+
+// Stats is a point-in-time view of cache activity since start.
+type Stats struct {
+	Hits        uint64 `json:"hits"`
+	Misses      uint64 `json:"misses"`
+	Evictions   uint64 `json:"evictions"`
+	Expirations uint64 `json:"expirations"`
+	Entries     int    `json:"entries"`
+	Bytes       int    `json:"bytes"`
+	MaxEntries  int    `json:"max_entries"`
+	MaxBytes    int    `json:"max_bytes"`
+}
+
+// Cache is an in-memory TTL cache with LRU eviction. A zero maxEntries or
+// maxBytes disables that bound.
 type Cache struct {
-	mu    sync.RWMutex
-	items map[string]Item
+	mu         sync.Mutex
+	items      map[string]*list.Element
+	lru        *list.List // front = most recently used
+	tags       map[string]map[*list.Element]struct{}
+	bytes      int
+	maxEntries int
+	maxBytes   int
+
+	hits, misses, evictions, expirations uint64
 }
 
 var (
@@ -38,32 +69,58 @@ var (
 	once         sync.Once
 )
 
+func New(maxEntries, maxBytes int) *Cache {
+	return &Cache{
+		items:      make(map[string]*list.Element),
+		lru:        list.New(),
+		tags:       make(map[string]map[*list.Element]struct{}),
+		maxEntries: maxEntries,
+		maxBytes:   maxBytes,
+	}
+}
+
 func Default() *Cache {
 	once.Do(func() {
-		defaultCache = &Cache{items: make(map[string]Item)}
+		defaultCache = New(10000, 64<<20)
 		go defaultCache.janitor(60 * time.Second)
 	})
 	return defaultCache
 }
 
+// SetLimits changes the bounds and evicts immediately if they are exceeded.
+func (c *Cache) SetLimits(maxEntries, maxBytes int) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	c.maxEntries = maxEntries
+	c.maxBytes = maxBytes
+	c.evictLocked()
+	c.mu.Unlock()
+}
+
 func (c *Cache) Get(key string) (any, bool) {
 	if c == nil {
 		return nil, false
 	}
-	now := time.Now().Unix()
-	c.mu.RLock()
-	it, ok := c.items[key]
-	c.mu.RUnlock()
+	now := time.Now().UnixNano()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	el, ok := c.items[MigrateKey(key)]
 	if !ok {
+		c.misses++
 		return nil, false
 	}
-	if it.Exp != 0 && it.Exp < now {
-		c.mu.Lock()
-		delete(c.items, key)
-		c.mu.Unlock()
+	e := el.Value.(*entry)
+	if e.item.Exp != 0 && e.item.Exp < now {
+		c.removeLocked(el)
+		c.expirations++
+		c.misses++
 		return nil, false
 	}
-	return it.V, true
+	c.lru.MoveToFront(el)
+	c.hits++
+	return e.item.V, true
 }
 
 func (c *Cache) Set(key string, v any, ttl time.Duration) {
@@ -72,56 +129,229 @@ func (c *Cache) Set(key string, v any, ttl time.Duration) {
 	}
 	var exp int64
 	if ttl > 0 {
-		exp = time.Now().Add(ttl).Unix()
+		exp = time.Now().Add(ttl).UnixNano()
+	}
+	c.mu.Lock()
+	c.setLocked(key, Item{V: v, Exp: exp}, nil)
+	c.mu.Unlock()
+}
+
+// SetTagged stores v like Set and files it under tags, so InvalidateTags
+// can drop it before it expires.
+func (c *Cache) SetTagged(key string, v any, ttl time.Duration, tags ...string) {
+	if c == nil {
+		return
+	}
+	var exp int64
+	if ttl > 0 {
+		exp = time.Now().Add(ttl).UnixNano()
 	}
 	c.mu.Lock()
-	c.items[key] = Item{V: v, Exp: exp}
+	c.setLocked(key, Item{V: v, Exp: exp}, tags)
 	c.mu.Unlock()
 }
 
+// InvalidateTags drops every entry filed under any of tags and returns how
+// many it dropped.
+func (c *Cache) InvalidateTags(tags ...string) int {
+	if c == nil {
+		return 0
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	n := 0
+	for _, tag := range tags {
+		for el := range c.tags[tag] {
+			c.removeLocked(el)
+			n++
+		}
+	}
+	return n
+}
+
+// Add stores v only when key is absent or expired and reports whether it did.
+func (c *Cache) Add(key string, v any, ttl time.Duration) bool {
+	if c == nil {
+		return false
+	}
+	now := time.Now()
+	var exp int64
+	if ttl > 0 {
+		exp = now.Add(ttl).UnixNano()
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if el, ok := c.items[MigrateKey(key)]; ok {
+		if it := el.Value.(*entry).item; it.Exp == 0 || it.Exp >= now.UnixNano() {
+			return false
+		}
+	}
+	c.setLocked(key, Item{V: v, Exp: exp}, nil)
+	return true
+}
+
 func (c *Cache) Delete(key string) {
 	if c == nil {
 		return
 	}
 	c.mu.Lock()
-	delete(c.items, key)
+	if el, ok := c.items[MigrateKey(key)]; ok {
+		c.removeLocked(el)
+	}
 	c.mu.Unlock()
 }
 
+func (c *Cache) Stats() Stats {
+	if c == nil {
+		return Stats{}
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return Stats{
+		Hits:        c.hits,
+		Misses:      c.misses,
+		Evictions:   c.evictions,
+		Expirations: c.expirations,
+		Entries:     c.lru.Len(),
+		Bytes:       c.bytes,
+		MaxEntries:  c.maxEntries,
+		MaxBytes:    c.maxBytes,
+	}
+}
+
+func (c *Cache) setLocked(key string, it Item, tags []string) {
+	key = MigrateKey(key)
+	size := sizeOf(key, it.V)
+	el, ok := c.items[key]
+	if ok {
+		e := el.Value.(*entry)
+		c.untagLocked(el)
+		c.bytes += size - e.size
+		e.item, e.size, e.tags = it, size, tags
+		c.lru.MoveToFront(el)
+	} else {
+		el = c.lru.PushFront(&entry{key: key, item: it, size: size, tags: tags})
+		c.items[key] = el
+		c.bytes += size
+	}
+	for _, tag := range tags {
+		if c.tags[tag] == nil {
+			c.tags[tag] = make(map[*list.Element]struct{})
+		}
+		c.tags[tag][el] = struct{}{}
+	}
+	c.evictLocked()
+}
+
+func (c *Cache) untagLocked(el *list.Element) {
+	for _, tag := range el.Value.(*entry).tags {
+		delete(c.tags[tag], el)
+		if len(c.tags[tag]) == 0 {
+			delete(c.tags, tag)
+		}
+	}
+}
+
+func (c *Cache) evictLocked() {
+	for c.lru.Len() > 0 &&
+		((c.maxEntries > 0 && c.lru.Len() > c.maxEntries) || (c.maxBytes > 0 && c.bytes > c.maxBytes)) {
+		c.removeLocked(c.lru.Back())
+		c.evictions++
+	}
+}
+
+func (c *Cache) removeLocked(el *list.Element) {
+	c.untagLocked(el)
+	e := c.lru.Remove(el).(*entry)
+	delete(c.items, e.key)
+	c.bytes -= e.size
+}
+
+// sizeOf estimates the memory held by an entry. Only the variable-length
+// payloads that dominate real usage are measured exactly.
+func sizeOf(key string, v any) int {
+	const overhead = 64
+	n := overhead + len(key)
+	switch x := v.(type) {
+	case string:
+		n += len(x)
+	case []byte:
+		n += len(x)
+	case CachedResponse:
+		n += len(x.Text)
+	case interface{ CacheSize() int }:
+		n += x.CacheSize()
+	}
+	return n
+}
+
 func (c *Cache) janitor(interval time.Duration) {
 	t := time.NewTicker(interval)
 	defer t.Stop()
 	for range t.C {
-		now := time.Now().Unix()
+		now := time.Now().UnixNano()
 		c.mu.Lock()
-		for k, it := range c.items {
-			if it.Exp != 0 && it.Exp < now {
-				delete(c.items, k)
+		for el := c.lru.Back(); el != nil; {
+			prev := el.Prev()
+			if e := el.Value.(*entry); e.item.Exp != 0 && e.item.Exp < now {
+				c.removeLocked(el)
+				c.expirations++
 			}
+			el = prev
 		}
 		c.mu.Unlock()
 	}
 }
 
+// KeyFromStrings hashes parts into a printable key of 16 hex digits. Each
+// part is framed, so ("ab", "c") and ("a", "bc") give different keys.
 func KeyFromStrings(parts ...string) string {
 	h := fnv.New64a()
 	for _, p := range parts {
 		_, _ = h.Write([]byte{0})
 		_, _ = h.Write([]byte(p))
 	}
-	return string(h.Sum(nil))
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// NamespacedKey is KeyFromStrings prefixed with "namespace:", so keys show
+// what they belong to in logs and external stores. An empty namespace adds
+// no prefix.
+func NamespacedKey(namespace string, parts ...string) string {
+	if namespace == "" {
+		return KeyFromStrings(parts...)
+	}
+	return namespace + ":" + KeyFromStrings(parts...)
+}
+
+// MigrateKey returns the key KeyFromStrings now gives for a key it made when
+// it returned the raw 8 hash bytes; other keys are returned as they are.
+// The cache applies it to every key it is given, so callers holding old keys
+// keep finding their entries.
+func MigrateKey(key string) string {
+	if len(key) != 8 {
+		return key
+	}
+	for i := 0; i < len(key); i++ {
+		if key[i] < 0x20 || key[i] > 0x7e {
+			return hex.EncodeToString([]byte(key))
+		}
+	}
+	return key
 }
 
-func (c *Cache) SetChatResponse(key string, text string, status ResponseStatus, ttl time.Duration) {
-	if status == StatusCompleted && text != "" && text != "Maaf, belum ada jawaban." {
+// SetChatResponse caches a completed reply under key; tags are as for
+// SetTagged.
+func (c *Cache) SetChatResponse(key string, text string, status ResponseStatus, ttl time.Duration, tags ...string) {
+	if status == StatusCompleted && text != "" && !i18n.Is(i18n.NoAnswer, text) {
 		response := CachedResponse{
 			Text:     text,
 			Status:   status,
 			CachedAt: time.Now(),
 		}
-		c.Set(key, response, ttl)
-		log.Printf("[cache] Cache SAVED: key=%s, status=%s, text_length=%d, ttl=%v",
-			shortenKey(key), status, len(text), ttl)
+		c.SetTagged(key, response, ttl, tags...)
+		log.Printf("[cache] Cache SAVED: key=%s, status=%s, text_length=%d, ttl=%v, tags=%d",
+			shortenKey(key), status, len(text), ttl, len(tags))
 	} else {
 		log.Printf("[cache] Cache SKIPPED: key=%s, status=%s, text_length=%d (not caching incomplete/error responses)",
 			shortenKey(key), status, len(text))
@@ -141,7 +371,7 @@ func (c *Cache) GetChatResponseWithInfo(key string) (string, bool, *CachedRespon
 
 	switch resp := v.(type) {
 	case string:
-		if resp != "" && resp != "Maaf, belum ada jawaban." {
+		if resp != "" && !i18n.Is(i18n.NoAnswer, resp) {
 			log.Printf("[cache] Cache HIT (legacy format): key=%s, text_length=%d", shortenKey(key), len(resp))
 			return resp, true, &CachedResponse{
 				Text:     resp,
@@ -151,7 +381,7 @@ func (c *Cache) GetChatResponseWithInfo(key string) (string, bool, *CachedRespon
 		}
 		return "", false, nil
 	case CachedResponse:
-		if resp.Status == StatusCompleted && resp.Text != "" && resp.Text != "Maaf, belum ada jawaban." {
+		if resp.Status == StatusCompleted && resp.Text != "" && !i18n.Is(i18n.NoAnswer, resp.Text) {
 			log.Printf("[cache] Cache HIT: key=%s, status=%s, text_length=%d, cached_at=%s",
 				shortenKey(key), resp.Status, len(resp.Text), resp.CachedAt.Format("15:04:05"))
 			return resp.Text, true, &resp
@@ -162,12 +392,14 @@ func (c *Cache) GetChatResponseWithInfo(key string) (string, bool, *CachedRespon
 	}
 }
 
+// shortenKey keeps the namespace of key and the start of its hash.
 func shortenKey(key string) string {
-	hexKey := hex.EncodeToString([]byte(key))
-	if len(hexKey) <= 16 {
-		return hexKey
+	key = MigrateKey(key)
+	i := strings.LastIndexByte(key, ':') + 1
+	if len(key)-i <= 8 {
+		return key
 	}
-	return hexKey[:8] + "..." + hexKey[len(hexKey)-8:]
+	return key[:i+8] + "..."
 }
 
 func (c *Cache) InvalidateChatResponse(key string) {
@@ -6,7 +6,7 @@ import (
 )
 
 func TestChatResponseCaching(t *testing.T) {
-	c := &Cache{items: make(map[string]Item)}
+	c := New(0, 0)
 	key := "test-key"
 
 	t.Run("Cache completed response", func(t *testing.T) {
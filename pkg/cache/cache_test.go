@@ -1,6 +1,9 @@
 package cache
 
 import (
+	"hash/fnv"
+	"strconv"
+	"strings"
 	"testing"
 	"time"
 )
@@ -48,3 +51,134 @@ func TestKeyFromStringsStability(t *testing.T) {
 		t.Fatalf("expected different inputs to yield different key")
 	}
 }
+
+func TestKeyFromStringsPrintable(t *testing.T) {
+	for _, parts := range [][]string{{}, {""}, {"a"}, {"chat", "1", "webinar november"}, {"\x00\xff", "ü"}} {
+		k := KeyFromStrings(parts...)
+		if len(k) != 16 || strings.Trim(k, "0123456789abcdef") != "" {
+			t.Fatalf("key of %q = %q, want 16 hex digits", parts, k)
+		}
+	}
+	if k := NamespacedKey("images-v1", "kampus"); k != "images-v1:"+KeyFromStrings("kampus") {
+		t.Fatalf("namespaced key = %q", k)
+	}
+	if NamespacedKey("", "kampus") != KeyFromStrings("kampus") {
+		t.Fatal("expected an empty namespace to add no prefix")
+	}
+	if NamespacedKey("a", "b") == NamespacedKey("b", "a") {
+		t.Fatal("expected namespace and parts to give different keys")
+	}
+}
+
+func TestKeyFromStringsCollisions(t *testing.T) {
+	framed := [][2][]string{
+		{{"ab", "c"}, {"a", "bc"}},
+		{{"a", ""}, {"a"}},
+		{{"", "a"}, {"a"}},
+	}
+	for _, pair := range framed {
+		if KeyFromStrings(pair[0]...) == KeyFromStrings(pair[1]...) {
+			t.Errorf("keys of %q and %q collide", pair[0], pair[1])
+		}
+	}
+
+	seen := make(map[string][]string, 100000)
+	for i := 0; i < 100000; i++ {
+		parts := []string{"chat-final", strconv.Itoa(i % 997), "pertanyaan " + strconv.Itoa(i)}
+		k := KeyFromStrings(parts...)
+		if prev, ok := seen[k]; ok {
+			t.Fatalf("keys of %q and %q collide", prev, parts)
+		}
+		seen[k] = parts
+	}
+}
+
+func TestMigrateKey(t *testing.T) {
+	parts := []string{"chat-final", "42", "webinar november"}
+	h := fnv.New64a()
+	for _, p := range parts {
+		h.Write([]byte{0})
+		h.Write([]byte(p))
+	}
+	legacy := string(h.Sum(nil))
+	if MigrateKey(legacy) != KeyFromStrings(parts...) {
+		t.Fatalf("legacy key migrates to %q, want %q", MigrateKey(legacy), KeyFromStrings(parts...))
+	}
+	for _, k := range []string{"a", "abcdefgh", KeyFromStrings("x"), "images-v1:" + KeyFromStrings("x")} {
+		if MigrateKey(k) != k {
+			t.Errorf("printable key %q changed to %q", k, MigrateKey(k))
+		}
+	}
+
+	c := New(0, 0)
+	c.Set(legacy, "old", time.Minute)
+	if v, ok := c.Get(KeyFromStrings(parts...)); !ok || v != "old" {
+		t.Fatalf("entry stored under the legacy key: %v ok=%v", v, ok)
+	}
+	c.Delete(legacy)
+	if _, ok := c.Get(KeyFromStrings(parts...)); ok {
+		t.Fatal("expected delete by the legacy key to drop the entry")
+	}
+}
+
+func TestLRUEvictionAndStats(t *testing.T) {
+	c := New(2, 0)
+	c.Set("a", "1", time.Minute)
+	c.Set("b", "2", time.Minute)
+	if _, ok := c.Get("a"); !ok {
+		t.Fatalf("expected a present")
+	}
+	c.Set("c", "3", time.Minute)
+
+	if _, ok := c.Get("b"); ok {
+		t.Fatalf("expected least recently used key b to be evicted")
+	}
+	if _, ok := c.Get("a"); !ok {
+		t.Fatalf("expected recently used key a to survive")
+	}
+
+	st := c.Stats()
+	if st.Entries != 2 || st.Evictions != 1 || st.Hits != 2 || st.Misses != 1 {
+		t.Fatalf("unexpected stats: %+v", st)
+	}
+}
+
+func TestMaxBytesEviction(t *testing.T) {
+	c := New(0, 300)
+	big := string(make([]byte, 100))
+	for _, k := range []string{"k1", "k2", "k3"} {
+		c.Set(k, big, time.Minute)
+	}
+	st := c.Stats()
+	if st.Bytes > 300 || st.Evictions == 0 {
+		t.Fatalf("expected byte bound to evict, got %+v", st)
+	}
+	if _, ok := c.Get("k3"); !ok {
+		t.Fatalf("expected newest key to be kept")
+	}
+}
+
+func TestInvalidateTags(t *testing.T) {
+	c := New(0, 0)
+	c.SetTagged("nov", "webinar november", time.Minute, "month:2025-11", "event:web_nov_001")
+	c.SetTagged("dec", "seminar desember", time.Minute, "month:2025-12")
+	c.Set("plain", "untagged", time.Minute)
+	c.SetTagged("retagged", "x", time.Minute, "month:2025-11")
+	c.SetTagged("retagged", "y", time.Minute, "month:2025-10")
+
+	if n := c.InvalidateTags("event:web_nov_001", "month:2025-11"); n != 1 {
+		t.Fatalf("expected 1 entry dropped, got %d", n)
+	}
+	if _, ok := c.Get("nov"); ok {
+		t.Fatalf("expected tagged entry to be dropped")
+	}
+	for _, k := range []string{"dec", "plain", "retagged"} {
+		if _, ok := c.Get(k); !ok {
+			t.Fatalf("expected %s to survive", k)
+		}
+	}
+	c.Delete("dec")
+	if n := c.InvalidateTags("month:2025-12"); n != 0 {
+		t.Fatalf("expected deleted entry to be untagged, dropped %d", n)
+	}
+}
@@ -0,0 +1,235 @@
+package cache
+
+import (
+	"context"
+	"hash/fnv"
+	"log"
+	"math"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+)
+
+// Embedder turns text into a vector. Vectors from one embedder must share a
+// dimension; they do not need to be normalised.
+type Embedder interface {
+	Embed(ctx context.Context, text string) ([]float32, error)
+}
+
+// LocalEmbedder is a dependency-free embedder based on hashed word and
+// character-trigram features. It is good enough to match rephrasings such as
+// "webinar bulan November?" and "ada webinar di bulan november", but scores
+// questions that differ only in a month just as close; callers key scopes
+// with what must match exactly.
+type LocalEmbedder struct {
+	Dim int
+}
+
+// semanticStopwords are filler words that do not change what is being asked.
+var semanticStopwords = map[string]bool{
+	"di": true, "ke": true, "dari": true, "yang": true, "apa": true, "aja": true, "saja": true,
+	"ada": true, "dong": true, "sih": true, "ya": true, "kah": true, "nya": true,
+	"tolong": true, "mau": true, "ingin": true, "tanya": true, "apakah": true, "untuk": true,
+	"the": true, "a": true, "an": true, "in": true, "on": true, "of": true, "what": true, "are": true,
+	"is": true, "please": true,
+}
+
+func semanticTokens(text string) []string {
+	fields := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	tokens := fields[:0]
+	for _, f := range fields {
+		if !semanticStopwords[f] {
+			tokens = append(tokens, f)
+		}
+	}
+	return tokens
+}
+
+func (e LocalEmbedder) Embed(_ context.Context, text string) ([]float32, error) {
+	dim := e.Dim
+	if dim <= 0 {
+		dim = 512
+	}
+	vec := make([]float32, dim)
+	add := func(feature string, weight float32) {
+		h := fnv.New32a()
+		_, _ = h.Write([]byte(feature))
+		vec[h.Sum32()%uint32(dim)] += weight
+	}
+	for _, tok := range semanticTokens(text) {
+		add("w:"+tok, 1)
+		runes := []rune("^" + tok + "$")
+		for i := 0; i+3 <= len(runes); i++ {
+			add("t:"+string(runes[i:i+3]), 0.3)
+		}
+	}
+	return vec, nil
+}
+
+// CosineSimilarity returns the cosine of the angle between a and b, or 0 when
+// either is empty or their dimensions differ.
+func CosineSimilarity(a, b []float32) float64 {
+	if len(a) == 0 || len(a) != len(b) {
+		return 0
+	}
+	var dot, na, nb float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+		na += float64(a[i]) * float64(a[i])
+		nb += float64(b[i]) * float64(b[i])
+	}
+	if na == 0 || nb == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(na) * math.Sqrt(nb))
+}
+
+type semanticEntry struct {
+	scope    string
+	question string
+	vec      []float32
+	response string
+	exp      time.Time
+	tags     []string
+}
+
+// SemanticCache serves responses for questions that are close, but not
+// identical, to ones answered before. Entries are partitioned by scope (for
+// example one scope per user and prompt mode) and matched by cosine similarity.
+type SemanticCache struct {
+	mu         sync.RWMutex
+	entries    []semanticEntry
+	embedder   Embedder
+	threshold  float64
+	maxEntries int
+}
+
+func NewSemanticCache(embedder Embedder, threshold float64, maxEntries int) *SemanticCache {
+	if embedder == nil {
+		embedder = LocalEmbedder{}
+	}
+	if maxEntries <= 0 {
+		maxEntries = 1000
+	}
+	return &SemanticCache{embedder: embedder, threshold: threshold, maxEntries: maxEntries}
+}
+
+// Lookup returns the cached response of the most similar question in scope
+// when its similarity reaches the threshold.
+func (s *SemanticCache) Lookup(ctx context.Context, scope, question string) (string, float64, bool) {
+	if s == nil {
+		return "", 0, false
+	}
+	vec, err := s.embedder.Embed(ctx, question)
+	if err != nil {
+		log.Printf("[cache] semantic embed failed: %v", err)
+		return "", 0, false
+	}
+
+	now := time.Now()
+	best, bestScore := -1, 0.0
+	s.mu.RLock()
+	for i, e := range s.entries {
+		if e.scope != scope || now.After(e.exp) {
+			continue
+		}
+		if score := CosineSimilarity(vec, e.vec); score > bestScore {
+			best, bestScore = i, score
+		}
+	}
+	var hit semanticEntry
+	if best >= 0 {
+		hit = s.entries[best]
+	}
+	s.mu.RUnlock()
+
+	if best < 0 || bestScore < s.threshold {
+		return "", bestScore, false
+	}
+	log.Printf("[cache] Semantic HIT: scope=%s score=%.3f question=%.50q matched=%.50q", scope, bestScore, question, hit.question)
+	return hit.response, bestScore, true
+}
+
+// Store records response for question in scope, evicting expired entries
+// first and then the oldest ones once the cache is full. tags are as for
+// Cache.SetTagged.
+func (s *SemanticCache) Store(ctx context.Context, scope, question, response string, ttl time.Duration, tags ...string) {
+	if s == nil || strings.TrimSpace(response) == "" {
+		return
+	}
+	vec, err := s.embedder.Embed(ctx, question)
+	if err != nil {
+		log.Printf("[cache] semantic embed failed: %v", err)
+		return
+	}
+
+	now := time.Now()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	live := s.entries[:0]
+	for _, e := range s.entries {
+		if now.Before(e.exp) {
+			live = append(live, e)
+		}
+	}
+	s.entries = live
+	if over := len(s.entries) - s.maxEntries + 1; over > 0 {
+		s.entries = append(s.entries[:0], s.entries[over:]...)
+	}
+	s.entries = append(s.entries, semanticEntry{
+		scope:    scope,
+		question: question,
+		vec:      vec,
+		response: response,
+		exp:      now.Add(ttl),
+		tags:     tags,
+	})
+}
+
+// InvalidateScope drops every entry in scope.
+func (s *SemanticCache) InvalidateScope(scope string) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	kept := s.entries[:0]
+	for _, e := range s.entries {
+		if e.scope != scope {
+			kept = append(kept, e)
+		}
+	}
+	s.entries = kept
+	s.mu.Unlock()
+}
+
+// InvalidateTags drops every entry stored with any of tags and returns how
+// many it dropped.
+func (s *SemanticCache) InvalidateTags(tags ...string) int {
+	if s == nil {
+		return 0
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	kept := s.entries[:0]
+	for _, e := range s.entries {
+		if !slices.ContainsFunc(e.tags, func(t string) bool { return slices.Contains(tags, t) }) {
+			kept = append(kept, e)
+		}
+	}
+	n := len(s.entries) - len(kept)
+	s.entries = kept
+	return n
+}
+
+func (s *SemanticCache) Len() int {
+	if s == nil {
+		return 0
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return len(s.entries)
+}
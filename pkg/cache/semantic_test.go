@@ -0,0 +1,53 @@
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestSemanticCacheLookup(t *testing.T) {
+	ctx := context.Background()
+	sc := NewSemanticCache(LocalEmbedder{}, 0.85, 10)
+	sc.Store(ctx, "user:1:engineered", "webinar bulan November?", "Ada 3 webinar di November.", time.Minute)
+
+	t.Run("Near-duplicate question hits", func(t *testing.T) {
+		text, score, ok := sc.Lookup(ctx, "user:1:engineered", "ada webinar di bulan november?")
+		if !ok || text != "Ada 3 webinar di November." {
+			t.Fatalf("Expected semantic hit, got ok=%v score=%.3f text=%q", ok, score, text)
+		}
+	})
+
+	t.Run("Different question misses", func(t *testing.T) {
+		if _, score, ok := sc.Lookup(ctx, "user:1:engineered", "sertifikasi desember"); ok {
+			t.Fatalf("Expected miss for unrelated question, score=%.3f", score)
+		}
+	})
+
+	t.Run("Scopes are isolated", func(t *testing.T) {
+		if _, _, ok := sc.Lookup(ctx, "user:2:engineered", "ada webinar di bulan november?"); ok {
+			t.Fatal("Expected miss for another user's scope")
+		}
+	})
+
+	t.Run("Expired entries are ignored", func(t *testing.T) {
+		sc.Store(ctx, "user:3:engineered", "jadwal workshop", "Workshop hari Senin.", time.Nanosecond)
+		time.Sleep(time.Millisecond)
+		if _, _, ok := sc.Lookup(ctx, "user:3:engineered", "jadwal workshop"); ok {
+			t.Fatal("Expected expired entry to be ignored")
+		}
+	})
+
+	t.Run("Tagged entries are invalidated", func(t *testing.T) {
+		sc.Store(ctx, "user:4:engineered", "lomba desember", "Ada 1 lomba.", time.Minute, "month:2025-12")
+		if n := sc.InvalidateTags("month:2025-12"); n != 1 {
+			t.Fatalf("Expected 1 entry dropped, got %d", n)
+		}
+		if _, _, ok := sc.Lookup(ctx, "user:4:engineered", "lomba desember"); ok {
+			t.Fatal("Expected invalidated entry to miss")
+		}
+		if _, _, ok := sc.Lookup(ctx, "user:1:engineered", "ada webinar di bulan november?"); !ok {
+			t.Fatal("Expected untagged entry to survive")
+		}
+	})
+}
@@ -0,0 +1,261 @@
+// Package certificate renders certificates of attendance as single-page PDF
+// documents. It writes the PDF itself with the standard Helvetica fonts, so
+// it needs no fonts or PDF library.
+package certificate
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"image"
+	"image/color"
+	_ "image/jpeg" // signature images may be JPEG
+	_ "image/png"
+	"os"
+	"strings"
+)
+
+// Certificate is what a certificate of attendance states.
+type Certificate struct {
+	Number      string // printed at the bottom, e.g. "UIB-EVT-2025-000042"
+	Name        string
+	Event       string
+	Date        string // already formatted, e.g. "12 November 2025"
+	Organizer   string
+	Signer      string
+	SignerTitle string
+	Signature   image.Image // nil prints the signature line only
+}
+
+// LoadSignature reads a PNG or JPEG signature image.
+func LoadSignature(path string) (image.Image, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	img, _, err := image.Decode(f)
+	return img, err
+}
+
+// A4 landscape, in points.
+const (
+	pageW = 842.0
+	pageH = 595.0
+)
+
+// helvetica holds the Helvetica widths of characters 32-126, in thousandths
+// of the font size.
+var helvetica = [95]int{
+	278, 278, 355, 556, 556, 889, 667, 191, 333, 333, 389, 584, 278, 333, 278, 278,
+	556, 556, 556, 556, 556, 556, 556, 556, 556, 556,
+	278, 278, 584, 584, 584, 556, 1015,
+	667, 667, 722, 722, 667, 611, 778, 722, 278, 500, 667, 556, 833,
+	722, 778, 667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611,
+	278, 278, 278, 469, 556, 333,
+	556, 556, 500, 556, 556, 278, 556, 556, 222, 222, 500, 222, 833,
+	556, 556, 556, 556, 333, 500, 278, 556, 500, 722, 500, 500, 500,
+	334, 260, 334, 584,
+}
+
+// textWidth is the width of s at size; bold text is approximated as 7%
+// wider.
+func textWidth(s string, size float64, bold bool) float64 {
+	w := 0
+	for _, b := range []byte(winAnsi(s)) {
+		if b >= 32 && b <= 126 {
+			w += helvetica[b-32]
+		} else {
+			w += 556
+		}
+	}
+	width := float64(w) * size / 1000
+	if bold {
+		width *= 1.07
+	}
+	return width
+}
+
+// winAnsi encodes s for the WinAnsiEncoding fonts: Latin-1 letters are kept,
+// anything else becomes "?".
+func winAnsi(s string) string {
+	out := make([]byte, 0, len(s))
+	for _, r := range s {
+		switch {
+		case r >= 32 && r <= 126, r >= 160 && r <= 255:
+			out = append(out, byte(r))
+		case r == '\t' || r == '\n':
+			out = append(out, ' ')
+		default:
+			out = append(out, '?')
+		}
+	}
+	return string(out)
+}
+
+func pdfString(s string) string {
+	return "(" + strings.NewReplacer(`\`, `\\`, "(", `\(`, ")", `\)`).Replace(winAnsi(s)) + ")"
+}
+
+// wrap splits s into lines no wider than width.
+func wrap(s string, size, width float64, bold bool) []string {
+	var lines []string
+	line := ""
+	for _, w := range strings.Fields(s) {
+		if line != "" && textWidth(line+" "+w, size, bold) > width {
+			lines = append(lines, line)
+			line = w
+			continue
+		}
+		if line != "" {
+			line += " "
+		}
+		line += w
+	}
+	if line != "" {
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+type page struct{ bytes.Buffer }
+
+// text writes s centered on the page with its baseline at y.
+func (p *page) text(s string, y, size float64, bold bool) {
+	p.textAt(s, (pageW-textWidth(s, size, bold))/2, y, size, bold)
+}
+
+func (p *page) textAt(s string, x, y, size float64, bold bool) {
+	font := "F1"
+	if bold {
+		font = "F2"
+	}
+	fmt.Fprintf(p, "BT /%s %.1f Tf %.2f %.2f Td %s Tj ET\n", font, size, x, y, pdfString(s))
+}
+
+// Render returns c as a PDF document.
+func Render(c Certificate) ([]byte, error) {
+	var p page
+	p.WriteString("q 0.13 0.31 0.58 RG 3 w 28 28 786 539 re S 1 w 38 38 766 519 re S Q\n")
+
+	p.WriteString("0.13 0.31 0.58 rg\n")
+	p.text("SERTIFIKAT", 475, 40, true)
+	p.WriteString("0.35 0.35 0.35 rg\n")
+	p.text("Certificate of Attendance", 450, 14, false)
+	p.WriteString("0 0 0 rg\n")
+	p.text("Diberikan kepada", 405, 14, false)
+
+	nameSize := 30.0
+	for nameSize > 16 && textWidth(c.Name, nameSize, true) > 700 {
+		nameSize -= 2
+	}
+	p.text(c.Name, 365, nameSize, true)
+	fmt.Fprintf(&p, "q 0.6 0.6 0.6 RG 0.75 w %.2f 355 m %.2f 355 l S Q\n", pageW/2-220, pageW/2+220)
+
+	p.text("atas kehadirannya sebagai peserta", 325, 14, false)
+	y := 297.0
+	lines := wrap(c.Event, 18, 700, true)
+	if len(lines) > 3 {
+		lines = lines[:3]
+		lines[2] += " ..."
+	}
+	for _, line := range lines {
+		p.text(line, y, 18, true)
+		y -= 22
+	}
+	when := c.Date
+	if c.Organizer != "" {
+		when = strings.TrimPrefix(when+" - "+c.Organizer, " - ")
+	}
+	p.text(when, y-4, 12, false)
+
+	var img []byte
+	var imgW, imgH int
+	if c.Signature != nil {
+		var err error
+		if img, imgW, imgH, err = rgbImage(c.Signature); err != nil {
+			return nil, err
+		}
+		h := 55.0
+		w := h * float64(imgW) / float64(imgH)
+		if w > 160 {
+			w, h = 160, 160*float64(imgH)/float64(imgW)
+		}
+		fmt.Fprintf(&p, "q %.2f 0 0 %.2f %.2f 122 cm /Im1 Do Q\n", w, h, pageW/2-w/2)
+	}
+	fmt.Fprintf(&p, "q 0 0 0 RG 0.75 w %.2f 118 m %.2f 118 l S Q\n", pageW/2-100, pageW/2+100)
+	p.text(c.Signer, 102, 12, true)
+	p.text(c.SignerTitle, 88, 10, false)
+
+	p.WriteString("0.45 0.45 0.45 rg\n")
+	if c.Number != "" {
+		p.textAt("No. "+c.Number, 50, 48, 9, false)
+	}
+	return document(p.Bytes(), img, imgW, imgH), nil
+}
+
+// rgbImage returns the zlib-compressed RGB samples of img, with
+// transparency laid over white.
+func rgbImage(img image.Image) ([]byte, int, int, error) {
+	b := img.Bounds()
+	raw := make([]byte, 0, b.Dx()*b.Dy()*3)
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			c := color.NRGBAModel.Convert(img.At(x, y)).(color.NRGBA)
+			a := uint32(c.A)
+			over := func(v uint8) byte { return byte((uint32(v)*a + 255*(255-a)) / 255) }
+			raw = append(raw, over(c.R), over(c.G), over(c.B))
+		}
+	}
+	var z bytes.Buffer
+	zw := zlib.NewWriter(&z)
+	if _, err := zw.Write(raw); err != nil {
+		return nil, 0, 0, err
+	}
+	if err := zw.Close(); err != nil {
+		return nil, 0, 0, err
+	}
+	return z.Bytes(), b.Dx(), b.Dy(), nil
+}
+
+// document assembles the PDF file around the page content and the optional
+// signature image.
+func document(content, img []byte, imgW, imgH int) []byte {
+	var buf bytes.Buffer
+	var offsets []int
+	obj := func(body string, stream []byte) {
+		offsets = append(offsets, buf.Len())
+		fmt.Fprintf(&buf, "%d 0 obj\n%s", len(offsets), body)
+		if stream != nil {
+			buf.WriteString("\nstream\n")
+			buf.Write(stream)
+			buf.WriteString("\nendstream")
+		}
+		buf.WriteString("\nendobj\n")
+	}
+
+	buf.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+	resources := "/Font << /F1 5 0 R /F2 6 0 R >>"
+	if img != nil {
+		resources += " /XObject << /Im1 7 0 R >>"
+	}
+	obj("<< /Type /Catalog /Pages 2 0 R >>", nil)
+	obj("<< /Type /Pages /Kids [3 0 R] /Count 1 >>", nil)
+	obj(fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %.0f %.0f] /Resources << %s >> /Contents 4 0 R >>",
+		pageW, pageH, resources), nil)
+	obj(fmt.Sprintf("<< /Length %d >>", len(content)), content)
+	obj("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>", nil)
+	obj("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica-Bold /Encoding /WinAnsiEncoding >>", nil)
+	if img != nil {
+		obj(fmt.Sprintf("<< /Type /XObject /Subtype /Image /Width %d /Height %d /ColorSpace /DeviceRGB /BitsPerComponent 8 /Filter /FlateDecode /Length %d >>",
+			imgW, imgH, len(img)), img)
+	}
+
+	xref := buf.Len()
+	fmt.Fprintf(&buf, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+	for _, off := range offsets {
+		fmt.Fprintf(&buf, "%010d 00000 n \n", off)
+	}
+	fmt.Fprintf(&buf, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets)+1, xref)
+	return buf.Bytes()
+}
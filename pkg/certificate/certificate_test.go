@@ -0,0 +1,62 @@
+package certificate
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"regexp"
+	"strconv"
+	"testing"
+)
+
+func TestRender(t *testing.T) {
+	sig := image.NewNRGBA(image.Rect(0, 0, 40, 20))
+	sig.Set(5, 5, color.NRGBA{A: 255})
+	pdf, err := Render(Certificate{
+		Number:    "UIB_CERT_OCT_001-000042",
+		Name:      "Siti (Nurhaliza) Pérez",
+		Event:     "Sertifikasi Akuntansi Dasar",
+		Date:      "5 Oktober 2025",
+		Organizer: "Universitas Internasional Batam",
+		Signer:    "Dr. Budi",
+		Signature: sig,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.HasPrefix(pdf, []byte("%PDF-1.4")) || !bytes.HasSuffix(pdf, []byte("%%EOF\n")) {
+		t.Fatalf("not a PDF: %q", pdf[:20])
+	}
+	for _, want := range []string{`(Siti \(Nurhaliza\) P` + "\xe9" + `rez) Tj`, "(Sertifikasi Akuntansi Dasar) Tj",
+		"/Subtype /Image /Width 40 /Height 20", "/Im1 Do"} {
+		if !bytes.Contains(pdf, []byte(want)) {
+			t.Errorf("PDF lacks %q", want)
+		}
+	}
+
+	// Every xref entry points at its object.
+	m := regexp.MustCompile(`startxref\n(\d+)`).FindSubmatch(pdf)
+	xref, _ := strconv.Atoi(string(m[1]))
+	entries := regexp.MustCompile(`(\d{10}) 00000 n`).FindAllSubmatch(pdf[xref:], -1)
+	if len(entries) != 7 {
+		t.Fatalf("xref has %d objects", len(entries))
+	}
+	for i, e := range entries {
+		off, _ := strconv.Atoi(string(e[1]))
+		if !bytes.HasPrefix(pdf[off:], []byte(strconv.Itoa(i+1)+" 0 obj")) {
+			t.Errorf("xref entry %d points at %q", i+1, pdf[off:off+10])
+		}
+	}
+}
+
+func TestWrap(t *testing.T) {
+	lines := wrap("Workshop Pengembangan Aplikasi Mobile dengan Flutter untuk Mahasiswa Tingkat Akhir", 18, 300, true)
+	if len(lines) < 2 {
+		t.Fatalf("wrap = %q", lines)
+	}
+	for _, l := range lines {
+		if textWidth(l, 18, true) > 300 {
+			t.Errorf("line %q is too wide", l)
+		}
+	}
+}
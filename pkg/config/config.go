@@ -6,6 +6,7 @@ import (
 	"slices"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/joho/godotenv"
 )
@@ -22,20 +23,290 @@ var (
 	IsGeminiEnabled    bool
 	IsGoogleAPIEnabled bool
 
+	// Gemini generationConfig defaults and optional safetySettings
+	// ("CATEGORY=THRESHOLD,...", "*" for every category)
+	GeminiTemperature     float64
+	GeminiTopK            int
+	GeminiTopP            float64
+	GeminiMaxOutputTokens int
+	GeminiSafetySettings  string
+	// maxOutputTokens of replies asked to be short or detailed
+	GeminiShortMaxOutputTokens    int
+	GeminiDetailedMaxOutputTokens int
+	// Times a reply cut off at maxOutputTokens is continued
+	GeminiMaxContinuations int
+	// Model calls a request may add to its first for retries and fallback
+	// models, and the time one needs before the request's deadline
+	GeminiRetryBudget       int
+	GeminiMinAttemptSeconds int
+	// Route greetings and short questions to a flash model and long or
+	// comparative UIB questions to a pro model, by character thresholds
+	GeminiRouting           bool
+	GeminiFlashModel        string
+	GeminiProModel          string
+	GeminiRouteSimpleChars  int
+	GeminiRouteComplexChars int
+	// USD per million prompt/output tokens by model ("model=in/out,..."),
+	// on top of the built-in prices
+	GeminiPrices string
+
 	JWTSecret string
 	Port      string
 
+	// Port of the gRPC server (cleartext HTTP/2), empty = gRPC off
+	GRPCPort string
+
+	// Claims of issued JWTs; tokens with another iss or aud are rejected
+	JWTIssuer        string
+	JWTAudience      string
+	JWTExpiryMinutes int
+
 	MySQLHost     string
 	MySQLPort     string
 	MySQLUser     string
 	MySQLPassword string
 	MySQLDatabase string
 
+	DBDriver   string // mysql | sqlite | postgres
+	DBDSN      string // overrides the DSN built from the MYSQL_* vars; required for postgres
+	SQLitePath string
+
+	// Connection pool (ignored for sqlite, which uses one connection) and slow query log, 0 = off
+	DBMaxOpenConns           int
+	DBMaxIdleConns           int
+	DBConnMaxLifetimeMinutes int
+	DBSlowQueryMs            int
+
 	RateLimitWindowSeconds int
 	RateLimitCapacity      int
 	UserConcurrencyLimit   int
 	DuplicateWindowSeconds int
 	ChatCacheTTLSeconds    int
+	CacheMaxEntries        int
+	CacheMaxBytesMB        int
+	IdempotencyTTLSeconds  int
+
+	// Chat reply cache keys: "user" keys every reply by user, "topic" shares
+	// first-turn, non-personal replies on ChatCacheGlobalTopics across users
+	ChatCacheKeyPolicy    string
+	ChatCacheGlobalTopics []string
+
+	// Locale of server-written texts for clients that state none (id, en)
+	DefaultLocale string
+
+	// Outgoing email over SMTP; off while SMTPHost is empty
+	SMTPHost     string
+	SMTPPort     int
+	SMTPUsername string
+	SMTPPassword string
+	SMTPFrom     string
+
+	// Weekly event digest for users who opt in: sent on DigestWeekday
+	// (0 = Sunday) at DigestHour, local time, covering the next DigestDays
+	DigestEnabled bool
+	DigestWeekday int
+	DigestHour    int
+	DigestDays    int
+
+	// How many conversation transcripts a user may email per window
+	TranscriptEmailLimit         int
+	TranscriptEmailWindowSeconds int
+
+	// Guest chat without an account (opt-in): its own per-IP rate limit, a cap
+	// on user messages per conversation, and conversations purged after the TTL
+	GuestChatEnabled            bool
+	GuestRateLimitWindowSeconds int
+	GuestRateLimitCapacity      int
+	GuestMaxMessages            int
+	GuestConversationTTLHours   int
+	// Incognito conversations are purged this long after their last message,
+	// or when their owner logs out
+	IncognitoTTLMinutes int
+
+	// Semantic cache: near-duplicate questions reuse an earlier answer
+	SemanticCacheEnabled    bool
+	SemanticCacheEmbedder   string // local | gemini
+	SemanticCacheThreshold  float64
+	SemanticCacheMaxEntries int
+	SemanticCacheGlobalUIB  bool
+	GeminiEmbeddingModel    string
+
+	JobWorkers          int
+	JobQueueSize        int
+	JobTimeoutSeconds   int
+	JobRetentionSeconds int
+
+	RetentionArchiveAfterDays int
+	RetentionDeleteAfterDays  int
+	RetentionIntervalMinutes  int
+	TrashRetentionDays        int
+	RetentionDryRun           bool
+
+	UserSlotQueueLength int
+	UserSlotWaitSeconds int
+
+	// SSE streams: ping comment interval, client reconnect delay and how long
+	// finished streams stay replayable via Last-Event-ID
+	SSEHeartbeatSeconds int
+	SSERetryMs          int
+	SSEReplayTTLSeconds int
+
+	// WebSocket limits per user, 0 disables each: open connections, messages
+	// per window, and handshakes allowed in a row before reconnects are backed
+	// off exponentially (reset after a quiet window)
+	WSMaxConnsPerUser            int
+	WSMessagesPerWindow          int
+	WSMessageWindowSeconds       int
+	WSReconnectFree              int
+	WSReconnectWindowSeconds     int
+	WSReconnectBackoffMs         int
+	WSReconnectBackoffMaxSeconds int
+
+	// AdminEmails are granted admin access in addition to users flagged IsAdmin
+	AdminEmails []string
+
+	// Login brute-force protection, 0 disables each: failures per account and
+	// per IP before a lockout that starts at LoginLockoutSeconds and doubles up
+	// to the max, failures before a CAPTCHA is required (needs
+	// CaptchaVerifyURL), and how long a quiet account or IP keeps its count
+	LoginMaxFailures         int
+	LoginIPMaxFailures       int
+	LoginLockoutSeconds      int
+	LoginLockoutMaxSeconds   int
+	LoginCaptchaAfter        int
+	LoginFailureResetMinutes int
+	CaptchaVerifyURL         string // reCAPTCHA/hCaptcha/Turnstile siteverify endpoint
+	CaptchaSecret            string
+
+	// Webhooks: attempts per delivery, the backoff after the first failure
+	// (doubling up to the max), the per-attempt timeout, and whether callbacks
+	// may point at private or loopback addresses
+	WebhookMaxAttempts       int
+	WebhookBackoffSeconds    int
+	WebhookBackoffMaxSeconds int
+	WebhookTimeoutSeconds    int
+	WebhookAllowPrivate      bool
+
+	// Messaging gateway: the Telegram bot is on when TelegramBotToken is set,
+	// WhatsApp (Cloud API) when WhatsAppToken and WhatsAppPhoneNumberID are.
+	// The API bases are only overridden in tests.
+	TelegramBotToken      string
+	TelegramWebhookSecret string // secret_token given to setWebhook
+	TelegramAPIBase       string
+	WhatsAppToken         string
+	WhatsAppPhoneNumberID string
+	WhatsAppVerifyToken   string // answers the webhook subscription check
+	WhatsAppAppSecret     string // verifies X-Hub-Signature-256
+	WhatsAppAPIBase       string
+
+	// Staff channel bots answering /uib: Slack is on when SlackSigningSecret is
+	// set, Discord when DiscordPublicKey is. Empty channel lists allow every
+	// channel the app is installed in.
+	SlackSigningSecret     string
+	SlackAllowedChannels   []string
+	DiscordApplicationID   string
+	DiscordPublicKey       string // hex, from the Developer Portal
+	DiscordAllowedChannels []string
+	DiscordAPIBase         string
+
+	// Image intent: detect "tampilkan gambar ..." and search images without request_images
+	ImageIntentEnabled bool
+	ImageIntentGemini  bool
+
+	ImageCacheTTLSeconds int
+	GoogleAPIDailyQuota  int // Custom Search calls per day, 0 = unlimited
+
+	ImageValidateWorkers         int
+	ImageValidateDeadlineSeconds int
+
+	// Replies scoring below this get the "saya tidak yakin" disclaimer
+	ConfidenceLowThreshold float64
+
+	// Moderation of incoming chat messages
+	ModerationEnabled       bool
+	ModerationGemini        bool
+	ModerationBlockKeywords string // extra comma-separated phrases to block
+	ModerationFlagKeywords  string // extra comma-separated phrases to flag
+
+	// Ask Gemini to label queries the keyword rules can't decide
+	TopicClassifierGemini bool
+
+	// Uploaded FAQ/guide documents retrieved into the Gemini context
+	KnowledgeTopK       int
+	KnowledgeMinScore   float64
+	KnowledgeChunkChars int
+	DocumentMaxUploadMB int
+
+	// Extra campus event datasets (*.json, same schema as data/uib_events.json)
+	CampusDataDir string
+
+	// JSON list of the tenants served by this deployment, each with its host
+	// names, branding, event datasets and Gemini key; empty = single tenant.
+	// See pkg/tenant.
+	TenantsFile string
+
+	// Size limits of the event context injected into prompts, 0 = unlimited
+	EventContextMaxTokens int
+	EventContextDescChars int
+
+	// Time zone of event dates and times that don't name one (WIB)
+	EventTimezone string
+
+	// Seats of an event without its own capacity, 0 = unlimited
+	EventDefaultCapacity int
+
+	// Certificates of attendance: the PNG or JPEG signature image and the
+	// signer printed on them (the event's department when unset), and how
+	// long their signed download URLs stay valid
+	CertificateSignatureImage string
+	CertificateSigner         string
+	CertificateSignerTitle    string
+	CertificateURLTTLMinutes  int
+
+	// Key of signed storage download URLs; JWT_SECRET_KEY when unset
+	StorageSigningKey string
+	// How long signed URLs of private profile images stay valid
+	ImageURLTTLMinutes int
+
+	// Keys sealing message texts and conversation titles at rest,
+	// "id:base64key,..." with the current key first; empty = stored in plain
+	// text. See pkg/fieldcrypt.
+	FieldEncryptionKeys string
+
+	// Profile image uploads: the size of one file, the total a user may
+	// store, how many uploads a user may make per window, and the clamd
+	// (host:port or socket path) that scans them; no scan when unset
+	UploadMaxMB                  int
+	UploadQuotaMB                int
+	UploadRateLimitCount         int
+	UploadRateLimitWindowSeconds int
+	ClamAVAddress                string
+
+	// How often scheduled announcements are checked for broadcast
+	AnnouncementPollSeconds int
+
+	// Share of conversations (0-100) put in the baseline arm of the online prompt A/B test, 0 = off
+	PromptABBaselinePercent int
+
+	// Frustrated user messages in a row that flag a conversation for admin review, 0 = off
+	FrustrationAlertCount int
+
+	// Reply post-processing: comma-separated steps (default unicode,urls,numbers,words,whitespace,markdown)
+	// and an optional file of extra dictionary words and domains
+	PostprocessSteps      []string
+	PostprocessDictionary string
+
+	// Directory of mock LLM fixture files (*.yaml); when set, Gemini calls are answered from it
+	MockLLMFixtures string
+
+	APIDocsEnabled bool
+
+	// Built frontend served for unmatched paths (FRONTEND_ENABLED=1), from FRONTEND_DIR or the embedded bundle
+	FrontendEnabled bool
+	FrontendDir     string
+
+	LegacyRoutesEnabled bool
+	LegacyRoutesSunset  time.Time
 )
 
 func loadAppEnv() {
@@ -53,19 +324,40 @@ func loadAppEnv() {
 func init() {
 	loadAppEnv()
 
-	GeminiAPIKey = os.Getenv("GEMINI_API_KEY")
+	store, err := loadSecrets()
+	if err != nil {
+		log.Fatalf("[config] failed to load secrets: %v", err)
+	}
+	SecretStore = store
+
+	GeminiAPIKey = secret("GEMINI_API_KEY")
 	GeminiModel = os.Getenv("GEMINI_MODEL")
 	GoogleAPI_CX = os.Getenv("GOOGLE_API_CX")
-	GoogleAPIKey = os.Getenv("GOOGLE_API_KEY")
+	GoogleAPIKey = secret("GOOGLE_API_KEY")
 
 	AppEnv = os.Getenv("APP_ENV")
 	PromptMode = strings.ToLower(strings.TrimSpace(os.Getenv("PROMPT_MODE")))
+	PromptABBaselinePercent = min(max(atoiOr(os.Getenv("PROMPT_AB_BASELINE_PERCENT"), 0), 0), 100)
+	FrustrationAlertCount = max(atoiOr(os.Getenv("FRUSTRATION_ALERT_COUNT"), 3), 0)
 
 	MySQLHost = os.Getenv("MYSQL_HOST")
 	MySQLPort = os.Getenv("MYSQL_PORT")
 	MySQLUser = os.Getenv("MYSQL_USER")
-	MySQLPassword = os.Getenv("MYSQL_PASSWORD")
+	MySQLPassword = secret("MYSQL_PASSWORD")
 	MySQLDatabase = os.Getenv("MYSQL_DATABASE")
+	DBDriver = strings.ToLower(strings.TrimSpace(os.Getenv("DB_DRIVER")))
+	if DBDriver == "" {
+		DBDriver = "mysql"
+	}
+	DBDSN = secret("DB_DSN")
+	SQLitePath = os.Getenv("SQLITE_PATH")
+	if SQLitePath == "" {
+		SQLitePath = "akuai.db"
+	}
+	DBMaxOpenConns = atoiOr(os.Getenv("DB_MAX_OPEN_CONNS"), 25)
+	DBMaxIdleConns = atoiOr(os.Getenv("DB_MAX_IDLE_CONNS"), 10)
+	DBConnMaxLifetimeMinutes = atoiOr(os.Getenv("DB_CONN_MAX_LIFETIME_MINUTES"), 30)
+	DBSlowQueryMs = atoiOr(os.Getenv("DB_SLOW_QUERY_MS"), 200)
 
 	if !slices.Contains([]string{"staging", "production"}, AppEnv) {
 		log.Fatal("environment variable APP_ENV must be 'staging' or 'production'")
@@ -89,29 +381,277 @@ func init() {
 	if GeminiModel == "" {
 		GeminiModel = "gemini-2.0-flash"
 	}
+	GeminiTemperature = floatOr(os.Getenv("GEMINI_TEMPERATURE"), 0.4)
+	GeminiTopK = atoiOr(os.Getenv("GEMINI_TOP_K"), 40)
+	GeminiTopP = floatOr(os.Getenv("GEMINI_TOP_P"), 0.9)
+	GeminiMaxOutputTokens = atoiOr(os.Getenv("GEMINI_MAX_OUTPUT_TOKENS"), 2048)
+	GeminiSafetySettings = strings.TrimSpace(os.Getenv("GEMINI_SAFETY_SETTINGS"))
+	GeminiShortMaxOutputTokens = atoiOr(os.Getenv("GEMINI_SHORT_MAX_OUTPUT_TOKENS"), 512)
+	GeminiDetailedMaxOutputTokens = atoiOr(os.Getenv("GEMINI_DETAILED_MAX_OUTPUT_TOKENS"), 4096)
+	GeminiMaxContinuations = atoiOr(os.Getenv("GEMINI_MAX_CONTINUATIONS"), 2)
+	GeminiRetryBudget = atoiOr(os.Getenv("GEMINI_RETRY_BUDGET"), 2)
+	GeminiMinAttemptSeconds = atoiOr(os.Getenv("GEMINI_MIN_ATTEMPT_SECONDS"), 10)
+	GeminiRouting = os.Getenv("GEMINI_ROUTING") == "1"
+	GeminiFlashModel = strings.TrimSpace(os.Getenv("GEMINI_FLASH_MODEL"))
+	if GeminiFlashModel == "" {
+		GeminiFlashModel = "gemini-2.0-flash"
+	}
+	GeminiProModel = strings.TrimSpace(os.Getenv("GEMINI_PRO_MODEL"))
+	if GeminiProModel == "" {
+		GeminiProModel = "gemini-2.5-pro"
+	}
+	GeminiRouteSimpleChars = atoiOr(os.Getenv("GEMINI_ROUTE_SIMPLE_CHARS"), 40)
+	GeminiRouteComplexChars = atoiOr(os.Getenv("GEMINI_ROUTE_COMPLEX_CHARS"), 160)
+	GeminiPrices = strings.TrimSpace(os.Getenv("GEMINI_PRICES"))
 
-	JWTSecret = os.Getenv("JWT_SECRET_KEY")
+	JWTSecret = secret("JWT_SECRET_KEY")
+	JWTIssuer = os.Getenv("JWT_ISSUER")
+	if JWTIssuer == "" {
+		JWTIssuer = "akuai"
+	}
+	JWTAudience = os.Getenv("JWT_AUDIENCE")
+	if JWTAudience == "" {
+		JWTAudience = "akuai-api"
+	}
+	JWTExpiryMinutes = atoiOr(os.Getenv("JWT_EXPIRY_MINUTES"), 1440)
 	Port = os.Getenv("PORT")
 	if Port == "" {
 		Port = "5000"
 	}
+	GRPCPort = os.Getenv("GRPC_PORT")
 
 	RateLimitWindowSeconds = atoiOr(os.Getenv("RATE_LIMIT_WINDOW_SECONDS"), 10)
 	RateLimitCapacity = atoiOr(os.Getenv("RATE_LIMIT_CAPACITY"), 5)
 	UserConcurrencyLimit = atoiOr(os.Getenv("USER_CONCURRENCY_LIMIT"), 2)
 	DuplicateWindowSeconds = atoiOr(os.Getenv("DUPLICATE_WINDOW_SECONDS"), 45)
 	ChatCacheTTLSeconds = atoiOr(os.Getenv("CHAT_CACHE_TTL_SECONDS"), 600)
+	CacheMaxEntries = atoiOr(os.Getenv("CACHE_MAX_ENTRIES"), 10000)
+	CacheMaxBytesMB = atoiOr(os.Getenv("CACHE_MAX_BYTES_MB"), 64)
+	ChatCacheKeyPolicy = strings.ToLower(strings.TrimSpace(os.Getenv("CHAT_CACHE_KEY_POLICY")))
+	if ChatCacheKeyPolicy != "user" {
+		ChatCacheKeyPolicy = "topic"
+	}
+	ChatCacheGlobalTopics = splitList(os.Getenv("CHAT_CACHE_GLOBAL_TOPICS"))
+	if len(ChatCacheGlobalTopics) == 0 {
+		ChatCacheGlobalTopics = []string{"events", "admissions", "academics", "facilities"}
+	}
+	IdempotencyTTLSeconds = atoiOr(os.Getenv("IDEMPOTENCY_TTL_SECONDS"), 86400)
+
+	DefaultLocale = strings.ToLower(strings.TrimSpace(os.Getenv("DEFAULT_LOCALE")))
+	if DefaultLocale == "" {
+		DefaultLocale = "id"
+	}
+	SMTPHost = os.Getenv("SMTP_HOST")
+	SMTPPort = atoiOr(os.Getenv("SMTP_PORT"), 587)
+	SMTPUsername = os.Getenv("SMTP_USERNAME")
+	SMTPPassword = secret("SMTP_PASSWORD")
+	SMTPFrom = os.Getenv("SMTP_FROM")
+	if SMTPFrom == "" {
+		SMTPFrom = "AkuAI <noreply@akuai.local>"
+	}
+
+	DigestEnabled = os.Getenv("DIGEST_ENABLED") != "0"
+	DigestWeekday = atoiOr(os.Getenv("DIGEST_WEEKDAY"), int(time.Monday))
+	DigestHour = atoiOr(os.Getenv("DIGEST_HOUR"), 7)
+	DigestDays = atoiOr(os.Getenv("DIGEST_DAYS"), 7)
+	TranscriptEmailLimit = atoiOr(os.Getenv("TRANSCRIPT_EMAIL_LIMIT"), 5)
+	TranscriptEmailWindowSeconds = atoiOr(os.Getenv("TRANSCRIPT_EMAIL_WINDOW_SECONDS"), 3600)
+
+	GuestChatEnabled = os.Getenv("GUEST_CHAT_ENABLED") == "1"
+	GuestRateLimitWindowSeconds = atoiOr(os.Getenv("GUEST_RATE_LIMIT_WINDOW_SECONDS"), 60)
+	GuestRateLimitCapacity = atoiOr(os.Getenv("GUEST_RATE_LIMIT_CAPACITY"), 5)
+	GuestMaxMessages = atoiOr(os.Getenv("GUEST_MAX_MESSAGES"), 10)
+	GuestConversationTTLHours = atoiOr(os.Getenv("GUEST_CONVERSATION_TTL_HOURS"), 24)
+	IncognitoTTLMinutes = atoiOr(os.Getenv("INCOGNITO_TTL_MINUTES"), 60)
+
+	SemanticCacheEnabled = os.Getenv("SEMANTIC_CACHE_ENABLED") == "1"
+	SemanticCacheEmbedder = strings.ToLower(strings.TrimSpace(os.Getenv("SEMANTIC_CACHE_EMBEDDER")))
+	if SemanticCacheEmbedder == "" {
+		SemanticCacheEmbedder = "local"
+	}
+	SemanticCacheThreshold = floatOr(os.Getenv("SEMANTIC_CACHE_THRESHOLD"), 0.85)
+	SemanticCacheMaxEntries = atoiOr(os.Getenv("SEMANTIC_CACHE_MAX_ENTRIES"), 2000)
+	SemanticCacheGlobalUIB = os.Getenv("SEMANTIC_CACHE_GLOBAL_UIB") != "0"
+	GeminiEmbeddingModel = os.Getenv("GEMINI_EMBEDDING_MODEL")
+	if GeminiEmbeddingModel == "" {
+		GeminiEmbeddingModel = "text-embedding-004"
+	}
+
+	JobWorkers = atoiOr(os.Getenv("JOB_WORKERS"), 4)
+	JobQueueSize = atoiOr(os.Getenv("JOB_QUEUE_SIZE"), 100)
+	JobTimeoutSeconds = atoiOr(os.Getenv("JOB_TIMEOUT_SECONDS"), 90)
+	JobRetentionSeconds = atoiOr(os.Getenv("JOB_RETENTION_SECONDS"), 3600)
+
+	// Retention: 0 disables a rule; the scheduler only runs when a rule is set
+	RetentionArchiveAfterDays = atoiOr(os.Getenv("RETENTION_ARCHIVE_AFTER_DAYS"), 0)
+	RetentionDeleteAfterDays = atoiOr(os.Getenv("RETENTION_DELETE_AFTER_DAYS"), 0)
+	RetentionIntervalMinutes = atoiOr(os.Getenv("RETENTION_INTERVAL_MINUTES"), 60)
+	RetentionDryRun = os.Getenv("RETENTION_DRY_RUN") == "1"
+	TrashRetentionDays = atoiOr(os.Getenv("TRASH_RETENTION_DAYS"), 30)
+
+	UserSlotQueueLength = atoiOr(os.Getenv("USER_SLOT_QUEUE_LENGTH"), 4)
+	UserSlotWaitSeconds = atoiOr(os.Getenv("USER_SLOT_WAIT_SECONDS"), 15)
+	SSEHeartbeatSeconds = atoiOr(os.Getenv("SSE_HEARTBEAT_SECONDS"), 15)
+	SSERetryMs = atoiOr(os.Getenv("SSE_RETRY_MS"), 3000)
+	SSEReplayTTLSeconds = atoiOr(os.Getenv("SSE_REPLAY_TTL_SECONDS"), 300)
+	WSMaxConnsPerUser = atoiOr(os.Getenv("WS_MAX_CONNS_PER_USER"), 5)
+	WSMessagesPerWindow = atoiOr(os.Getenv("WS_MESSAGES_PER_WINDOW"), 20)
+	WSMessageWindowSeconds = atoiOr(os.Getenv("WS_MESSAGE_WINDOW_SECONDS"), 10)
+	WSReconnectFree = atoiOr(os.Getenv("WS_RECONNECT_FREE"), 5)
+	WSReconnectWindowSeconds = atoiOr(os.Getenv("WS_RECONNECT_WINDOW_SECONDS"), 30)
+	WSReconnectBackoffMs = atoiOr(os.Getenv("WS_RECONNECT_BACKOFF_MS"), 1000)
+	WSReconnectBackoffMaxSeconds = atoiOr(os.Getenv("WS_RECONNECT_BACKOFF_MAX_SECONDS"), 60)
+
+	ImageIntentEnabled = os.Getenv("IMAGE_INTENT_ENABLED") != "0"
+	ImageIntentGemini = os.Getenv("IMAGE_INTENT_GEMINI") != "0"
+	ImageCacheTTLSeconds = atoiOr(os.Getenv("IMAGE_CACHE_TTL_SECONDS"), 21600)
+	GoogleAPIDailyQuota = atoiOr(os.Getenv("GOOGLE_API_DAILY_QUOTA"), 100)
+	ImageValidateWorkers = atoiOr(os.Getenv("IMAGE_VALIDATE_WORKERS"), 6)
+	ImageValidateDeadlineSeconds = atoiOr(os.Getenv("IMAGE_VALIDATE_DEADLINE_SECONDS"), 10)
+	ConfidenceLowThreshold = floatOr(os.Getenv("CONFIDENCE_LOW_THRESHOLD"), 0.5)
+	ModerationEnabled = os.Getenv("MODERATION_ENABLED") != "0"
+	ModerationGemini = os.Getenv("MODERATION_GEMINI") == "1"
+	ModerationBlockKeywords = os.Getenv("MODERATION_BLOCK_KEYWORDS")
+	ModerationFlagKeywords = os.Getenv("MODERATION_FLAG_KEYWORDS")
+	TopicClassifierGemini = os.Getenv("TOPIC_CLASSIFIER_GEMINI") == "1"
+	KnowledgeTopK = atoiOr(os.Getenv("KNOWLEDGE_TOP_K"), 3)
+	KnowledgeMinScore = floatOr(os.Getenv("KNOWLEDGE_MIN_SCORE"), 1.0)
+	KnowledgeChunkChars = atoiOr(os.Getenv("KNOWLEDGE_CHUNK_CHARS"), 800)
+	DocumentMaxUploadMB = atoiOr(os.Getenv("DOCUMENT_MAX_UPLOAD_MB"), 5)
+	CampusDataDir = os.Getenv("CAMPUS_DATA_DIR")
+	if CampusDataDir == "" {
+		CampusDataDir = "data/campuses"
+	}
+	TenantsFile = os.Getenv("TENANTS_FILE")
+	EventContextMaxTokens = atoiOr(os.Getenv("EVENT_CONTEXT_MAX_TOKENS"), 2500)
+	EventContextDescChars = atoiOr(os.Getenv("EVENT_CONTEXT_DESC_CHARS"), 300)
+	EventTimezone = os.Getenv("EVENT_TIMEZONE")
+	if EventTimezone == "" {
+		EventTimezone = "Asia/Jakarta"
+	}
+	EventDefaultCapacity = atoiOr(os.Getenv("EVENT_DEFAULT_CAPACITY"), 0)
+	CertificateSignatureImage = os.Getenv("CERTIFICATE_SIGNATURE_IMAGE")
+	CertificateSigner = os.Getenv("CERTIFICATE_SIGNER")
+	CertificateSignerTitle = os.Getenv("CERTIFICATE_SIGNER_TITLE")
+	CertificateURLTTLMinutes = atoiOr(os.Getenv("CERTIFICATE_URL_TTL_MINUTES"), 15)
+	StorageSigningKey = secret("STORAGE_SIGNING_KEY")
+	if StorageSigningKey == "" {
+		StorageSigningKey = JWTSecret
+	}
+	ImageURLTTLMinutes = atoiOr(os.Getenv("IMAGE_URL_TTL_MINUTES"), 60)
+	FieldEncryptionKeys = secret("FIELD_ENCRYPTION_KEYS")
+	UploadMaxMB = atoiOr(os.Getenv("UPLOAD_MAX_MB"), 5)
+	UploadQuotaMB = atoiOr(os.Getenv("UPLOAD_QUOTA_MB"), 20)
+	UploadRateLimitCount = atoiOr(os.Getenv("UPLOAD_RATE_LIMIT_COUNT"), 10)
+	UploadRateLimitWindowSeconds = atoiOr(os.Getenv("UPLOAD_RATE_LIMIT_WINDOW_SECONDS"), 3600)
+	ClamAVAddress = strings.TrimSpace(os.Getenv("CLAMAV_ADDRESS"))
+	AnnouncementPollSeconds = atoiOr(os.Getenv("ANNOUNCEMENT_POLL_SECONDS"), 30)
+	MockLLMFixtures = os.Getenv("MOCK_LLM_FIXTURES")
+	if s := strings.TrimSpace(os.Getenv("POSTPROCESS_STEPS")); s != "" {
+		PostprocessSteps = strings.Split(s, ",")
+	}
+	PostprocessDictionary = os.Getenv("POSTPROCESS_DICTIONARY")
+
+	LoginMaxFailures = atoiOr(os.Getenv("LOGIN_MAX_FAILURES"), 5)
+	LoginIPMaxFailures = atoiOr(os.Getenv("LOGIN_IP_MAX_FAILURES"), 20)
+	LoginLockoutSeconds = atoiOr(os.Getenv("LOGIN_LOCKOUT_SECONDS"), 60)
+	LoginLockoutMaxSeconds = atoiOr(os.Getenv("LOGIN_LOCKOUT_MAX_SECONDS"), 3600)
+	LoginCaptchaAfter = atoiOr(os.Getenv("LOGIN_CAPTCHA_AFTER"), 3)
+	LoginFailureResetMinutes = atoiOr(os.Getenv("LOGIN_FAILURE_RESET_MINUTES"), 1440)
+	CaptchaVerifyURL = os.Getenv("CAPTCHA_VERIFY_URL")
+	CaptchaSecret = secret("CAPTCHA_SECRET")
+
+	WebhookMaxAttempts = atoiOr(os.Getenv("WEBHOOK_MAX_ATTEMPTS"), 6)
+	WebhookBackoffSeconds = atoiOr(os.Getenv("WEBHOOK_BACKOFF_SECONDS"), 30)
+	WebhookBackoffMaxSeconds = atoiOr(os.Getenv("WEBHOOK_BACKOFF_MAX_SECONDS"), 3600)
+	WebhookTimeoutSeconds = atoiOr(os.Getenv("WEBHOOK_TIMEOUT_SECONDS"), 10)
+	WebhookAllowPrivate = os.Getenv("WEBHOOK_ALLOW_PRIVATE") == "1"
+
+	TelegramBotToken = secret("TELEGRAM_BOT_TOKEN")
+	TelegramWebhookSecret = secret("TELEGRAM_WEBHOOK_SECRET")
+	TelegramAPIBase = os.Getenv("TELEGRAM_API_BASE")
+	WhatsAppToken = secret("WHATSAPP_TOKEN")
+	WhatsAppPhoneNumberID = os.Getenv("WHATSAPP_PHONE_NUMBER_ID")
+	WhatsAppVerifyToken = secret("WHATSAPP_VERIFY_TOKEN")
+	WhatsAppAppSecret = secret("WHATSAPP_APP_SECRET")
+	WhatsAppAPIBase = os.Getenv("WHATSAPP_API_BASE")
+
+	SlackSigningSecret = secret("SLACK_SIGNING_SECRET")
+	SlackAllowedChannels = splitList(os.Getenv("SLACK_ALLOWED_CHANNELS"))
+	DiscordApplicationID = os.Getenv("DISCORD_APPLICATION_ID")
+	DiscordPublicKey = os.Getenv("DISCORD_PUBLIC_KEY")
+	DiscordAllowedChannels = splitList(os.Getenv("DISCORD_ALLOWED_CHANNELS"))
+	DiscordAPIBase = os.Getenv("DISCORD_API_BASE")
+
+	for _, e := range strings.Split(os.Getenv("ADMIN_EMAILS"), ",") {
+		if e = strings.ToLower(strings.TrimSpace(e)); e != "" {
+			AdminEmails = append(AdminEmails, e)
+		}
+	}
+
+	// API docs are served unless explicitly disabled (API_DOCS_ENABLED=0)
+	APIDocsEnabled = os.Getenv("API_DOCS_ENABLED") != "0"
+
+	FrontendEnabled = os.Getenv("FRONTEND_ENABLED") == "1"
+	FrontendDir = os.Getenv("FRONTEND_DIR")
+
+	// Unversioned routes stay mounted as deprecated aliases of /api/v1 until the sunset date
+	LegacyRoutesEnabled = os.Getenv("LEGACY_ROUTES_ENABLED") != "0"
+	LegacyRoutesSunset = dateOr(os.Getenv("LEGACY_ROUTES_SUNSET"), time.Date(2026, time.December, 31, 0, 0, 0, 0, time.UTC))
 
 	if IsProduction && JWTSecret == "" {
 		log.Fatal("JWT_SECRET_KEY must be set in production")
 	}
 
-	log.Printf("[config] AppEnv=%s IsStaging=%v IsProduction=%v", AppEnv, IsStaging, IsProduction)
+	log.Printf("[config] AppEnv=%s IsStaging=%v IsProduction=%v Secrets=%s", AppEnv, IsStaging, IsProduction, SecretStore.Name())
 	log.Printf("[config] IsGeminiEnabled=%v GeminiAPIKeyPresent=%v", IsGeminiEnabled, GeminiAPIKey != "")
 	log.Printf("[config] GeminiModel=%s", GeminiModel)
+	log.Printf("[config] Gemini temperature=%.2f topK=%d topP=%.2f maxOutputTokens=%d safety=%q",
+		GeminiTemperature, GeminiTopK, GeminiTopP, GeminiMaxOutputTokens, GeminiSafetySettings)
 	log.Printf("[config] PromptMode=%s", PromptMode)
-	log.Printf("[config] RateLimit window=%ds capacity=%d userConc=%d dupWindow=%ds cacheTTL=%ds",
-		RateLimitWindowSeconds, RateLimitCapacity, UserConcurrencyLimit, DuplicateWindowSeconds, ChatCacheTTLSeconds)
+	log.Printf("[config] Retention archiveAfter=%dd deleteAfter=%dd trash=%dd interval=%dm dryRun=%v",
+		RetentionArchiveAfterDays, RetentionDeleteAfterDays, TrashRetentionDays, RetentionIntervalMinutes, RetentionDryRun)
+	log.Printf("[config] Cache maxEntries=%d maxBytes=%dMB keyPolicy=%s globalTopics=%v", CacheMaxEntries, CacheMaxBytesMB,
+		ChatCacheKeyPolicy, ChatCacheGlobalTopics)
+	log.Printf("[config] DB driver=%s maxOpen=%d maxIdle=%d connMaxLifetime=%dm slowQuery=%dms",
+		DBDriver, DBMaxOpenConns, DBMaxIdleConns, DBConnMaxLifetimeMinutes, DBSlowQueryMs)
+	log.Printf("[config] SemanticCache enabled=%v embedder=%s threshold=%.2f globalUIB=%v",
+		SemanticCacheEnabled, SemanticCacheEmbedder, SemanticCacheThreshold, SemanticCacheGlobalUIB)
+	log.Printf("[config] LegacyRoutes enabled=%v sunset=%s", LegacyRoutesEnabled, LegacyRoutesSunset.Format("2006-01-02"))
+	log.Printf("[config] RateLimit window=%ds capacity=%d userConc=%d dupWindow=%ds cacheTTL=%ds idempotencyTTL=%ds",
+		RateLimitWindowSeconds, RateLimitCapacity, UserConcurrencyLimit, DuplicateWindowSeconds, ChatCacheTTLSeconds, IdempotencyTTLSeconds)
+}
+
+func dateOr(s string, def time.Time) time.Time {
+	if s == "" {
+		return def
+	}
+	if t, err := time.Parse("2006-01-02", strings.TrimSpace(s)); err == nil {
+		return t
+	}
+	log.Printf("[config] WARN: invalid date %q, using %s", s, def.Format("2006-01-02"))
+	return def
+}
+
+func floatOr(s string, def float64) float64 {
+	if s == "" {
+		return def
+	}
+	if f, err := strconv.ParseFloat(strings.TrimSpace(s), 64); err == nil {
+		return f
+	}
+	return def
+}
+
+// splitList splits a comma-separated value, dropping empty items.
+func splitList(s string) []string {
+	var out []string
+	for _, v := range strings.Split(s, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			out = append(out, v)
+		}
+	}
+	return out
 }
 
 func atoiOr(s string, def int) int {
@@ -0,0 +1,302 @@
+package config
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Secrets resolves secrets by their environment variable name, e.g.
+// JWT_SECRET_KEY. SECRETS_PROVIDER picks the providers (see loadSecrets).
+type Secrets interface {
+	Name() string
+	Lookup(key string) (string, bool)
+}
+
+// SecretStore is consulted for GEMINI_API_KEY, GOOGLE_API_KEY,
+// JWT_SECRET_KEY, MYSQL_PASSWORD, DB_DSN, CAPTCHA_SECRET,
+// FIELD_ENCRYPTION_KEYS and the Gemini keys of tenants (TENANTS_FILE).
+var SecretStore Secrets = EnvSecrets{}
+
+// secret returns key from SecretStore, "" when no provider has it.
+func secret(key string) string {
+	v, _ := SecretStore.Lookup(key)
+	return v
+}
+
+// EnvSecrets reads the environment. KEY_FILE, when set, names a file holding
+// the value instead, so a secret need not be in the environment itself.
+type EnvSecrets struct{}
+
+func (EnvSecrets) Name() string { return "env" }
+
+func (EnvSecrets) Lookup(key string) (string, bool) {
+	if v, ok := os.LookupEnv(key); ok {
+		return v, true
+	}
+	if f := os.Getenv(key + "_FILE"); f != "" {
+		b, err := os.ReadFile(f)
+		if err != nil {
+			return "", false
+		}
+		return strings.TrimSpace(string(b)), true
+	}
+	return "", false
+}
+
+// FileSecrets reads one file per secret from Dir, as Docker and Kubernetes
+// mount them: jwt_secret_key, or JWT_SECRET_KEY.
+type FileSecrets struct {
+	Dir string
+}
+
+func (f FileSecrets) Name() string { return "file" }
+
+func (f FileSecrets) Lookup(key string) (string, bool) {
+	for _, name := range []string{strings.ToLower(key), key} {
+		if b, err := os.ReadFile(filepath.Join(f.Dir, name)); err == nil {
+			return strings.TrimSpace(string(b)), true
+		}
+	}
+	return "", false
+}
+
+// mapSecrets holds the secrets a remote provider returned at startup.
+type mapSecrets struct {
+	name   string
+	values map[string]string
+}
+
+func (m mapSecrets) Name() string { return m.name }
+
+func (m mapSecrets) Lookup(key string) (string, bool) {
+	v, ok := m.values[key]
+	return v, ok
+}
+
+// ChainSecrets asks each provider in turn.
+type ChainSecrets []Secrets
+
+func (c ChainSecrets) Name() string {
+	names := make([]string, len(c))
+	for i, s := range c {
+		names[i] = s.Name()
+	}
+	return strings.Join(names, ",")
+}
+
+func (c ChainSecrets) Lookup(key string) (string, bool) {
+	for _, s := range c {
+		if v, ok := s.Lookup(key); ok {
+			return v, true
+		}
+	}
+	return "", false
+}
+
+var secretsClient = &http.Client{Timeout: 10 * time.Second}
+
+// NewVaultSecrets reads every key of the HashiCorp Vault secret at secretPath
+// (KV v2 "secret/data/akuai" or KV v1 "secret/akuai").
+func NewVaultSecrets(addr, token, secretPath string) (Secrets, error) {
+	req, err := http.NewRequest(http.MethodGet, strings.TrimRight(addr, "/")+"/v1/"+strings.TrimLeft(secretPath, "/"), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("X-Vault-Token", token)
+	resp, err := secretsClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("vault: %s returned %s", secretPath, resp.Status)
+	}
+	var out struct {
+		Data map[string]any `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return nil, fmt.Errorf("vault: %w", err)
+	}
+	data := out.Data
+	if inner, ok := data["data"].(map[string]any); ok {
+		data = inner // KV v2 nests the values
+	}
+	values := make(map[string]string, len(data))
+	for k, v := range data {
+		if s, ok := v.(string); ok {
+			values[k] = s
+		}
+	}
+	return mapSecrets{name: "vault", values: values}, nil
+}
+
+// AWSCredentials sign SSM requests.
+type AWSCredentials struct {
+	AccessKeyID     string
+	SecretAccessKey string
+	SessionToken    string
+}
+
+// NewSSMSecrets reads the AWS SSM Parameter Store parameters under
+// paramPath (e.g. /akuai/prod/), decrypting SecureStrings; each is keyed by
+// the last segment of its name. endpoint overrides
+// https://ssm.<region>.amazonaws.com.
+func NewSSMSecrets(region, paramPath string, creds AWSCredentials, endpoint string) (Secrets, error) {
+	if endpoint == "" {
+		endpoint = "https://ssm." + region + ".amazonaws.com"
+	}
+	values := map[string]string{}
+	next := ""
+	for {
+		in := map[string]any{"Path": paramPath, "WithDecryption": true, "Recursive": false}
+		if next != "" {
+			in["NextToken"] = next
+		}
+		body, _ := json.Marshal(in)
+		req, err := http.NewRequest(http.MethodPost, endpoint+"/", bytes.NewReader(body))
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Content-Type", "application/x-amz-json-1.1")
+		req.Header.Set("X-Amz-Target", "AmazonSSM.GetParametersByPath")
+		signAWSv4(req, body, "ssm", region, creds, time.Now())
+		resp, err := secretsClient.Do(req)
+		if err != nil {
+			return nil, err
+		}
+		raw, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		if resp.StatusCode != http.StatusOK {
+			return nil, fmt.Errorf("ssm: %s: %s", resp.Status, strings.TrimSpace(string(raw)))
+		}
+		var out struct {
+			Parameters []struct {
+				Name  string
+				Value string
+			}
+			NextToken string
+		}
+		if err := json.Unmarshal(raw, &out); err != nil {
+			return nil, fmt.Errorf("ssm: %w", err)
+		}
+		for _, p := range out.Parameters {
+			values[path.Base(p.Name)] = p.Value
+		}
+		if out.NextToken == "" {
+			break
+		}
+		next = out.NextToken
+	}
+	return mapSecrets{name: "ssm", values: values}, nil
+}
+
+// signAWSv4 adds an AWS Signature Version 4 Authorization header covering
+// the host and every header already set on req.
+func signAWSv4(req *http.Request, body []byte, service, region string, creds AWSCredentials, now time.Time) {
+	now = now.UTC()
+	amzDate := now.Format("20060102T150405Z")
+	day := now.Format("20060102")
+	req.Header.Set("X-Amz-Date", amzDate)
+	if creds.SessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", creds.SessionToken)
+	}
+
+	headers := map[string]string{"host": req.URL.Host}
+	for k, v := range req.Header {
+		headers[strings.ToLower(k)] = strings.TrimSpace(strings.Join(v, ","))
+	}
+	names := make([]string, 0, len(headers))
+	for k := range headers {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	var canonHeaders strings.Builder
+	for _, k := range names {
+		canonHeaders.WriteString(k + ":" + headers[k] + "\n")
+	}
+	signed := strings.Join(names, ";")
+	uri := req.URL.EscapedPath()
+	if uri == "" {
+		uri = "/"
+	}
+	payload := sha256.Sum256(body)
+	canonical := strings.Join([]string{req.Method, uri, req.URL.RawQuery, canonHeaders.String(), signed, hex.EncodeToString(payload[:])}, "\n")
+	canonHash := sha256.Sum256([]byte(canonical))
+	scope := day + "/" + region + "/" + service + "/aws4_request"
+	toSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(canonHash[:])
+
+	mac := func(key []byte, data string) []byte {
+		h := hmac.New(sha256.New, key)
+		h.Write([]byte(data))
+		return h.Sum(nil)
+	}
+	k := mac([]byte("AWS4"+creds.SecretAccessKey), day)
+	k = mac(k, region)
+	k = mac(k, service)
+	k = mac(k, "aws4_request")
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		creds.AccessKeyID, scope, signed, hex.EncodeToString(mac(k, toSign))))
+}
+
+// loadSecrets builds SecretStore from SECRETS_PROVIDER, a comma-separated
+// list of env, file, vault and ssm asked in that order; env is always asked
+// last. The providers are configured from the environment:
+//
+//	file   SECRETS_DIR (default /run/secrets)
+//	vault  VAULT_ADDR, VAULT_TOKEN (or VAULT_TOKEN_FILE), VAULT_SECRET_PATH
+//	ssm    AWS_REGION, SSM_PARAMETER_PATH, AWS_ACCESS_KEY_ID,
+//	       AWS_SECRET_ACCESS_KEY, AWS_SESSION_TOKEN, SSM_ENDPOINT
+func loadSecrets() (Secrets, error) {
+	var chain ChainSecrets
+	env := EnvSecrets{}
+	for _, name := range strings.Split(os.Getenv("SECRETS_PROVIDER"), ",") {
+		switch name = strings.ToLower(strings.TrimSpace(name)); name {
+		case "", "env":
+		case "file":
+			dir := os.Getenv("SECRETS_DIR")
+			if dir == "" {
+				dir = "/run/secrets"
+			}
+			chain = append(chain, FileSecrets{Dir: dir})
+		case "vault":
+			token, _ := env.Lookup("VAULT_TOKEN")
+			s, err := NewVaultSecrets(os.Getenv("VAULT_ADDR"), token, os.Getenv("VAULT_SECRET_PATH"))
+			if err != nil {
+				return nil, err
+			}
+			chain = append(chain, s)
+		case "ssm":
+			secretKey, _ := env.Lookup("AWS_SECRET_ACCESS_KEY")
+			s, err := NewSSMSecrets(os.Getenv("AWS_REGION"), os.Getenv("SSM_PARAMETER_PATH"), AWSCredentials{
+				AccessKeyID:     os.Getenv("AWS_ACCESS_KEY_ID"),
+				SecretAccessKey: secretKey,
+				SessionToken:    os.Getenv("AWS_SESSION_TOKEN"),
+			}, os.Getenv("SSM_ENDPOINT"))
+			if err != nil {
+				return nil, err
+			}
+			chain = append(chain, s)
+		default:
+			return nil, fmt.Errorf("unknown secrets provider %q (want env, file, vault or ssm)", name)
+		}
+	}
+	if len(chain) == 0 {
+		return env, nil
+	}
+	return append(chain, env), nil
+}
@@ -0,0 +1,109 @@
+package config
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestFileAndEnvSecrets(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "jwt_secret_key"), []byte("from-docker\n"), 0o600)
+	os.WriteFile(filepath.Join(dir, "gemini"), []byte("from-env-file\n"), 0o600)
+	t.Setenv("GEMINI_API_KEY_FILE", filepath.Join(dir, "gemini"))
+	t.Setenv("JWT_SECRET_KEY", "from-env")
+
+	s := ChainSecrets{FileSecrets{Dir: dir}, EnvSecrets{}}
+	if v, _ := s.Lookup("JWT_SECRET_KEY"); v != "from-docker" {
+		t.Errorf("JWT_SECRET_KEY = %q, want the secrets file to win", v)
+	}
+	if v, _ := s.Lookup("GEMINI_API_KEY"); v != "from-env-file" {
+		t.Errorf("GEMINI_API_KEY = %q, want it read from GEMINI_API_KEY_FILE", v)
+	}
+	if _, ok := s.Lookup("MYSQL_PASSWORD"); ok {
+		t.Error("MYSQL_PASSWORD found, want missing")
+	}
+	if s.Name() != "file,env" {
+		t.Errorf("Name = %q", s.Name())
+	}
+}
+
+func TestVaultSecrets(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Vault-Token") != "root" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		if r.URL.Path != "/v1/secret/data/akuai" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write([]byte(`{"data":{"data":{"JWT_SECRET_KEY":"vaulted","MYSQL_PASSWORD":"pw"},"metadata":{"version":3}}}`))
+	}))
+	defer srv.Close()
+
+	s, err := NewVaultSecrets(srv.URL, "root", "secret/data/akuai")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v, _ := s.Lookup("JWT_SECRET_KEY"); v != "vaulted" {
+		t.Errorf("JWT_SECRET_KEY = %q", v)
+	}
+	if _, err := NewVaultSecrets(srv.URL, "wrong", "secret/data/akuai"); err == nil {
+		t.Error("bad token: want error")
+	}
+}
+
+func TestSSMSecrets(t *testing.T) {
+	calls := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		auth := r.Header.Get("Authorization")
+		if !strings.HasPrefix(auth, "AWS4-HMAC-SHA256 Credential=AKID/") || !strings.Contains(auth, "/ap-southeast-3/ssm/aws4_request") {
+			t.Errorf("Authorization = %q", auth)
+		}
+		if r.Header.Get("X-Amz-Target") != "AmazonSSM.GetParametersByPath" {
+			t.Errorf("X-Amz-Target = %q", r.Header.Get("X-Amz-Target"))
+		}
+		var in struct {
+			Path           string
+			WithDecryption bool
+			NextToken      string
+		}
+		json.NewDecoder(r.Body).Decode(&in)
+		if in.Path != "/akuai/prod/" || !in.WithDecryption {
+			t.Errorf("request = %+v", in)
+		}
+		if in.NextToken == "" {
+			w.Write([]byte(`{"Parameters":[{"Name":"/akuai/prod/GEMINI_API_KEY","Value":"g"}],"NextToken":"p2"}`))
+			return
+		}
+		w.Write([]byte(`{"Parameters":[{"Name":"/akuai/prod/DB_DSN","Value":"dsn"}]}`))
+	}))
+	defer srv.Close()
+
+	s, err := NewSSMSecrets("ap-southeast-3", "/akuai/prod/", AWSCredentials{AccessKeyID: "AKID", SecretAccessKey: "secret"}, srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if calls != 2 {
+		t.Errorf("calls = %d, want 2 pages", calls)
+	}
+	if v, _ := s.Lookup("GEMINI_API_KEY"); v != "g" {
+		t.Errorf("GEMINI_API_KEY = %q", v)
+	}
+	if v, _ := s.Lookup("DB_DSN"); v != "dsn" {
+		t.Errorf("DB_DSN = %q", v)
+	}
+}
+
+func TestLoadSecretsUnknownProvider(t *testing.T) {
+	t.Setenv("SECRETS_PROVIDER", "file,keychain")
+	if _, err := loadSecrets(); err == nil {
+		t.Error("want error for unknown provider")
+	}
+}
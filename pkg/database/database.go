@@ -0,0 +1,121 @@
+// Package database opens the GORM connection for the configured driver
+// (DB_DRIVER): mysql for production, sqlite for local development and CI,
+// postgres for hosted setups.
+package database
+
+import (
+	"AkuAI/pkg/config"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/driver/mysql"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+// Drivers lists the supported DB_DRIVER values.
+var Drivers = []string{"mysql", "sqlite", "postgres"}
+
+// DSN returns DB_DSN when set, otherwise the default DSN for driver.
+func DSN(driver string) (string, error) {
+	if config.DBDSN != "" {
+		return config.DBDSN, nil
+	}
+	switch driver {
+	case "mysql":
+		return fmt.Sprintf("%s:%s@tcp(%s:%s)/%s?charset=utf8mb4&parseTime=True&loc=Local",
+			config.MySQLUser, config.MySQLPassword, config.MySQLHost, config.MySQLPort, config.MySQLDatabase), nil
+	case "sqlite":
+		return config.SQLitePath, nil
+	case "postgres":
+		return "", fmt.Errorf("DB_DSN is required for DB_DRIVER=postgres")
+	}
+	return "", fmt.Errorf("unsupported DB_DRIVER %q (want one of %s)", driver, strings.Join(Drivers, ", "))
+}
+
+// Dialector returns the GORM dialector for driver and dsn.
+func Dialector(driver, dsn string) (gorm.Dialector, error) {
+	switch driver {
+	case "mysql":
+		return mysql.Open(dsn), nil
+	case "sqlite":
+		return sqlite.Open(sqliteDSN(dsn)), nil
+	case "postgres":
+		return postgres.Open(dsn), nil
+	}
+	return nil, fmt.Errorf("unsupported DB_DRIVER %q (want one of %s)", driver, strings.Join(Drivers, ", "))
+}
+
+// sqliteDSN turns on foreign keys (needed for OnDelete:CASCADE) and a busy
+// timeout unless the DSN already sets pragmas.
+func sqliteDSN(dsn string) string {
+	if strings.Contains(dsn, "_pragma=") {
+		return dsn
+	}
+	sep := "?"
+	if strings.Contains(dsn, "?") {
+		sep = "&"
+	}
+	return dsn + sep + "_pragma=foreign_keys(1)&_pragma=busy_timeout(5000)"
+}
+
+// Open connects with the configured driver.
+func Open(cfg *gorm.Config) (*gorm.DB, error) {
+	dsn, err := DSN(config.DBDriver)
+	if err != nil {
+		return nil, err
+	}
+	return OpenWith(config.DBDriver, dsn, cfg)
+}
+
+// OpenWith connects to dsn with driver.
+func OpenWith(driver, dsn string, cfg *gorm.Config) (*gorm.DB, error) {
+	dialector, err := Dialector(driver, dsn)
+	if err != nil {
+		return nil, err
+	}
+	if cfg == nil {
+		cfg = &gorm.Config{}
+	}
+	if cfg.Logger == nil {
+		cfg.Logger = newSlowQueryLogger(time.Duration(config.DBSlowQueryMs) * time.Millisecond)
+	}
+	db, err := gorm.Open(dialector, cfg)
+	if err != nil {
+		return nil, err
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		return nil, err
+	}
+	if driver == "sqlite" {
+		// SQLite allows one writer; a single connection avoids "database is
+		// locked" errors and keeps :memory: databases shared.
+		sqlDB.SetMaxOpenConns(1)
+		return db, nil
+	}
+	sqlDB.SetMaxOpenConns(config.DBMaxOpenConns)
+	sqlDB.SetMaxIdleConns(config.DBMaxIdleConns)
+	sqlDB.SetConnMaxLifetime(time.Duration(config.DBConnMaxLifetimeMinutes) * time.Minute)
+	return db, nil
+}
+
+// Describe returns a loggable description of the configured database
+// without credentials.
+func Describe() string {
+	switch config.DBDriver {
+	case "mysql":
+		if config.DBDSN != "" {
+			return "mysql (DB_DSN)"
+		}
+		return fmt.Sprintf("mysql %s@%s:%s/%s", config.MySQLUser, config.MySQLHost, config.MySQLPort, config.MySQLDatabase)
+	case "sqlite":
+		if config.DBDSN != "" {
+			return "sqlite " + config.DBDSN
+		}
+		return "sqlite " + config.SQLitePath
+	}
+	return config.DBDriver + " (DB_DSN)"
+}
@@ -0,0 +1,44 @@
+package database
+
+import (
+	"AkuAI/pkg/metrics"
+	"context"
+	"errors"
+	"log"
+	"os"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+var slowQueries = metrics.NewCounter("db_slow_queries_total")
+
+// slowQueryLogger logs errors and queries slower than threshold (0 disables
+// the slow log) and counts the slow ones in db_slow_queries_total.
+type slowQueryLogger struct {
+	logger.Interface
+	threshold time.Duration
+}
+
+func newSlowQueryLogger(threshold time.Duration) logger.Interface {
+	return &slowQueryLogger{
+		Interface: logger.New(log.New(os.Stdout, "\r\n", log.LstdFlags), logger.Config{
+			SlowThreshold:             threshold,
+			LogLevel:                  logger.Warn,
+			IgnoreRecordNotFoundError: true,
+		}),
+		threshold: threshold,
+	}
+}
+
+func (l *slowQueryLogger) LogMode(level logger.LogLevel) logger.Interface {
+	return &slowQueryLogger{Interface: l.Interface.LogMode(level), threshold: l.threshold}
+}
+
+func (l *slowQueryLogger) Trace(ctx context.Context, begin time.Time, fc func() (string, int64), err error) {
+	if l.threshold > 0 && time.Since(begin) > l.threshold && (err == nil || errors.Is(err, gorm.ErrRecordNotFound)) {
+		slowQueries.Inc()
+	}
+	l.Interface.Trace(ctx, begin, fc, err)
+}
@@ -0,0 +1,102 @@
+// Package digest schedules the weekly event digest: once a week, every user
+// who opted in is sent a summary of upcoming events. What a digest says and
+// how it is delivered is up to the Send func of the caller.
+package digest
+
+import (
+	"AkuAI/models"
+	"context"
+	"log"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// DefaultInterval is how often Start looks for due digests.
+const DefaultInterval = 10 * time.Minute
+
+// batchSize caps the users handled per run; the rest follow on the next tick.
+const batchSize = 200
+
+// Schedule is when digests go out.
+type Schedule struct {
+	Weekday time.Weekday
+	Hour    int
+	// Interval is how often due digests are looked for.
+	Interval time.Duration
+}
+
+// Slot returns the latest send time at or before now.
+func (s Schedule) Slot(now time.Time) time.Time {
+	back := (int(now.Weekday()) - int(s.Weekday) + 7) % 7
+	slot := time.Date(now.Year(), now.Month(), now.Day()-back, s.Hour, 0, 0, 0, now.Location())
+	if slot.After(now) {
+		slot = slot.AddDate(0, 0, -7)
+	}
+	return slot
+}
+
+// Next returns the first send time after now.
+func (s Schedule) Next(now time.Time) time.Time {
+	return s.Slot(now).AddDate(0, 0, 7)
+}
+
+// Send delivers the digest of user. sent is false when there was nothing to
+// tell; an error leaves the user due, to be retried on the next tick.
+type Send func(ctx context.Context, user models.User) (sent bool, err error)
+
+type Result struct {
+	Sent    int `json:"sent"`
+	Skipped int `json:"skipped"`
+	Failed  int `json:"failed"`
+}
+
+// RunDue sends the digest of every opted-in user who has not had one since
+// slot.
+func RunDue(ctx context.Context, db *gorm.DB, slot time.Time, send Send) (Result, error) {
+	var res Result
+	var users []models.User
+	if err := db.WithContext(ctx).Where("digest_enabled = ? AND (digest_sent_at IS NULL OR digest_sent_at < ?)", true, slot).
+		Order("id").Limit(batchSize).Find(&users).Error; err != nil {
+		return res, err
+	}
+	for _, u := range users {
+		sent, err := send(ctx, u)
+		if err != nil {
+			log.Printf("[digest] ❌ user %d: %v", u.ID, err)
+			res.Failed++
+			continue
+		}
+		if err := db.Model(&u).Update("digest_sent_at", time.Now()).Error; err != nil {
+			return res, err
+		}
+		if sent {
+			res.Sent++
+		} else {
+			res.Skipped++
+		}
+	}
+	if len(users) > 0 {
+		log.Printf("[digest] slot=%s sent=%d skipped=%d failed=%d", slot.Format(time.RFC3339), res.Sent, res.Skipped, res.Failed)
+	}
+	return res, nil
+}
+
+// Start runs RunDue every s.Interval until ctx is done.
+func Start(ctx context.Context, db *gorm.DB, s Schedule, send Send) {
+	go func() {
+		t := time.NewTicker(s.Interval)
+		defer t.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case now := <-t.C:
+				if _, err := RunDue(ctx, db, s.Slot(now), send); err != nil {
+					log.Printf("[digest] ❌ run failed: %v", err)
+				}
+			}
+		}
+	}()
+	log.Printf("[digest] scheduler started weekday=%s hour=%d interval=%v", s.Weekday, s.Hour, s.Interval)
+}
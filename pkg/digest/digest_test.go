@@ -0,0 +1,31 @@
+package digest
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSlot(t *testing.T) {
+	s := Schedule{Weekday: time.Monday, Hour: 7}
+	at := func(v string) time.Time {
+		d, err := time.ParseInLocation("2006-01-02 15:04", v, time.UTC)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return d
+	}
+	for _, tc := range []struct{ now, slot string }{
+		{"2026-10-12 07:00", "2026-10-12 07:00"}, // Monday, on the hour
+		{"2026-10-12 06:59", "2026-10-05 07:00"}, // Monday, before the hour
+		{"2026-10-15 12:00", "2026-10-12 07:00"}, // Thursday
+		{"2026-10-18 23:00", "2026-10-12 07:00"}, // Sunday
+		{"2026-11-02 08:00", "2026-11-02 07:00"}, // across a month
+	} {
+		if got := s.Slot(at(tc.now)); !got.Equal(at(tc.slot)) {
+			t.Errorf("Slot(%s) = %s, want %s", tc.now, got.Format("2006-01-02 15:04"), tc.slot)
+		}
+	}
+	if got := s.Next(at("2026-10-15 12:00")); !got.Equal(at("2026-10-19 07:00")) {
+		t.Errorf("Next = %s", got)
+	}
+}
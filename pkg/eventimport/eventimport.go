@@ -0,0 +1,276 @@
+// Package eventimport reads event tables exported from spreadsheets (CSV or
+// XLSX) and maps their columns to event fields, so staff can add and update
+// events without editing the dataset JSON. Validation against the dataset
+// happens in services.UIBEventService.ImportEvents.
+package eventimport
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/csv"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrUnsupportedType is returned by Read for files that are neither CSV nor
+// XLSX.
+var ErrUnsupportedType = errors.New("unsupported file type (use .csv or .xlsx)")
+
+// Fields lists the event fields a column can be mapped to.
+var Fields = []string{
+	"id", "type", "title", "date", "time", "location", "platform", "department", "faculty", "description",
+	"speaker", "requirements", "registration_fee", "contact", "registration_link", "registration_deadline",
+	"certificate", "capacity", "mark",
+}
+
+// headerAliases are the Indonesian headers a column is recognised by without
+// a mapping.
+var headerAliases = map[string]string{
+	"judul": "title", "nama_acara": "title", "jenis": "type", "tipe": "type", "tanggal": "date", "waktu": "time",
+	"jam": "time", "lokasi": "location", "tempat": "location", "penyelenggara": "department", "jurusan": "department",
+	"prodi": "department", "program_studi": "department", "fakultas": "faculty", "deskripsi": "description",
+	"pembicara": "speaker", "narasumber": "speaker", "persyaratan": "requirements", "syarat": "requirements",
+	"biaya": "registration_fee", "biaya_pendaftaran": "registration_fee", "kontak": "contact",
+	"link_pendaftaran": "registration_link", "batas_pendaftaran": "registration_deadline", "sertifikat": "certificate",
+	"kuota": "capacity", "kapasitas": "capacity",
+}
+
+// Row is a data row: its line in the file (the header is line 1) and its
+// non-empty values by event field.
+type Row struct {
+	Line   int
+	Fields map[string]string
+}
+
+// Read returns the cells of a CSV or XLSX file by its extension; of an XLSX
+// workbook only the first sheet is read.
+func Read(filename string, data []byte) ([][]string, error) {
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".csv":
+		return ReadCSV(bytes.NewReader(data))
+	case ".xlsx":
+		return ReadXLSX(data)
+	}
+	return nil, ErrUnsupportedType
+}
+
+// ReadCSV reads a comma- or semicolon-separated table, as spreadsheets with
+// an Indonesian locale export it.
+func ReadCSV(r io.Reader) ([][]string, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))
+	cr := csv.NewReader(bytes.NewReader(data))
+	first, _, _ := bytes.Cut(data, []byte("\n"))
+	if bytes.Count(first, []byte(";")) > bytes.Count(first, []byte(",")) {
+		cr.Comma = ';'
+	}
+	cr.FieldsPerRecord = -1
+	return cr.ReadAll()
+}
+
+// Map maps the header row of table to event fields and returns the data
+// rows. mapping takes a header (case-insensitive) to a field; headers it
+// leaves out are matched to Fields and headerAliases by name, and columns
+// that match nothing are returned in ignored. The id column is required.
+func Map(table [][]string, mapping map[string]string) (rows []Row, ignored []string, err error) {
+	if len(table) == 0 {
+		return nil, nil, errors.New("the file is empty")
+	}
+	byHeader := make(map[string]string, len(mapping))
+	for h, f := range mapping {
+		if !slices.Contains(Fields, f) {
+			return nil, nil, fmt.Errorf("mapping of %q: unknown field %q", h, f)
+		}
+		byHeader[headerKey(h)] = f
+	}
+	columns := make([]string, len(table[0]))
+	seen := map[string]string{}
+	for i, h := range table[0] {
+		key := headerKey(h)
+		f, ok := byHeader[key]
+		if !ok {
+			if slices.Contains(Fields, key) {
+				f = key
+			} else {
+				f = headerAliases[key]
+			}
+		}
+		if f == "" {
+			if strings.TrimSpace(h) != "" {
+				ignored = append(ignored, strings.TrimSpace(h))
+			}
+			continue
+		}
+		if prev, dup := seen[f]; dup {
+			return nil, nil, fmt.Errorf("columns %q and %q both map to %s", prev, h, f)
+		}
+		seen[f] = h
+		columns[i] = f
+	}
+	if _, ok := seen["id"]; !ok {
+		return nil, nil, errors.New("no id column; events are matched by id")
+	}
+
+	for n, record := range table[1:] {
+		row := Row{Line: n + 2, Fields: map[string]string{}}
+		for i, v := range record {
+			if i >= len(columns) || columns[i] == "" {
+				continue
+			}
+			if v = strings.TrimSpace(v); v != "" {
+				row.Fields[columns[i]] = v
+			}
+		}
+		if len(row.Fields) == 0 {
+			continue // blank line
+		}
+		if d, ok := row.Fields["date"]; ok {
+			row.Fields["date"] = normalizeDate(d)
+		}
+		rows = append(rows, row)
+	}
+	return rows, ignored, nil
+}
+
+// headerKey normalizes "Link Pendaftaran" to "link_pendaftaran".
+func headerKey(h string) string {
+	return strings.Join(strings.Fields(strings.ToLower(strings.NewReplacer("-", " ", "_", " ").Replace(h))), "_")
+}
+
+// normalizeDate turns spreadsheet dates into YYYY-MM-DD: day-first dates
+// ("12/11/2025", "12-11-2025") and XLSX date serials ("45973"). Anything
+// else is returned unchanged for validation to report.
+func normalizeDate(s string) string {
+	for _, layout := range []string{"2006-01-02", "2/1/2006", "2-1-2006", "2.1.2006"} {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t.Format("2006-01-02")
+		}
+	}
+	if serial, err := strconv.ParseFloat(s, 64); err == nil && serial > 1 && serial < 100000 {
+		return time.Date(1899, 12, 30, 0, 0, 0, 0, time.UTC).AddDate(0, 0, int(serial)).Format("2006-01-02")
+	}
+	return s
+}
+
+// ReadXLSX reads the cells of the first sheet of an XLSX workbook.
+func ReadXLSX(data []byte) ([][]string, error) {
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, fmt.Errorf("not an xlsx workbook: %w", err)
+	}
+	files := make(map[string]*zip.File, len(zr.File))
+	for _, f := range zr.File {
+		files[f.Name] = f
+	}
+
+	var shared []string
+	if f := files["xl/sharedStrings.xml"]; f != nil {
+		var sst struct {
+			Items []xlsxText `xml:"si"`
+		}
+		if err := decodeXML(f, &sst); err != nil {
+			return nil, fmt.Errorf("xlsx shared strings: %w", err)
+		}
+		for _, si := range sst.Items {
+			shared = append(shared, si.String())
+		}
+	}
+
+	sheet := files["xl/worksheets/sheet1.xml"]
+	if sheet == nil {
+		return nil, errors.New("xlsx workbook has no first sheet")
+	}
+	var ws struct {
+		Rows []struct {
+			Cells []struct {
+				Ref    string   `xml:"r,attr"`
+				Type   string   `xml:"t,attr"`
+				Value  string   `xml:"v"`
+				Inline xlsxText `xml:"is"`
+			} `xml:"c"`
+		} `xml:"sheetData>row"`
+	}
+	if err := decodeXML(sheet, &ws); err != nil {
+		return nil, fmt.Errorf("xlsx sheet: %w", err)
+	}
+	table := make([][]string, 0, len(ws.Rows))
+	for _, r := range ws.Rows {
+		var record []string
+		for i, c := range r.Cells {
+			col := columnIndex(c.Ref)
+			if col < 0 {
+				col = i
+			}
+			for len(record) <= col {
+				record = append(record, "")
+			}
+			switch c.Type {
+			case "s":
+				n, err := strconv.Atoi(c.Value)
+				if err != nil || n < 0 || n >= len(shared) {
+					return nil, fmt.Errorf("xlsx cell %s: bad shared string %q", c.Ref, c.Value)
+				}
+				record[col] = shared[n]
+			case "inlineStr":
+				record[col] = c.Inline.String()
+			default:
+				record[col] = c.Value
+			}
+		}
+		table = append(table, record)
+	}
+	return table, nil
+}
+
+// xlsxText is rich text: a plain <t> or formatted <r><t> runs.
+type xlsxText struct {
+	T    string `xml:"t"`
+	Runs []struct {
+		T string `xml:"t"`
+	} `xml:"r"`
+}
+
+func (x xlsxText) String() string {
+	s := x.T
+	for _, r := range x.Runs {
+		s += r.T
+	}
+	return s
+}
+
+func decodeXML(f *zip.File, v any) error {
+	rc, err := f.Open()
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+	return xml.NewDecoder(io.LimitReader(rc, 64<<20)).Decode(v)
+}
+
+// columnIndex returns the 0-based column of a cell reference ("C7" is 2),
+// or -1 when ref has none.
+func columnIndex(ref string) int {
+	col := 0
+	n := 0
+	for _, r := range ref {
+		if r < 'A' || r > 'Z' {
+			break
+		}
+		col = col*26 + int(r-'A'+1)
+		n++
+	}
+	if n == 0 {
+		return -1
+	}
+	return col - 1
+}
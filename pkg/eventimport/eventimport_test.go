@@ -0,0 +1,80 @@
+package eventimport
+
+import (
+	"archive/zip"
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestMapCSV(t *testing.T) {
+	csv := "\xef\xbb\xbfID;Judul;Jenis;Tanggal;Kuota;Catatan\n" +
+		"ws_001;Workshop Flutter;workshop;12/11/2025;40;internal\n" +
+		";;;;;\n" +
+		"ws_002;\"Lomba; Desain\";lomba;2025-12-01;;\n"
+	table, err := Read("acara.csv", []byte(csv))
+	if err != nil {
+		t.Fatal(err)
+	}
+	rows, ignored, err := Map(table, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 2 || len(ignored) != 1 || ignored[0] != "Catatan" {
+		t.Fatalf("rows = %+v, ignored = %v", rows, ignored)
+	}
+	if f := rows[0].Fields; f["id"] != "ws_001" || f["date"] != "2025-11-12" || f["capacity"] != "40" || f["type"] != "workshop" {
+		t.Errorf("row 2 = %v", f)
+	}
+	if r := rows[1]; r.Line != 4 || r.Fields["title"] != "Lomba; Desain" {
+		t.Errorf("row 4 = %+v", r)
+	}
+	if _, has := rows[1].Fields["capacity"]; has {
+		t.Error("empty cell mapped")
+	}
+
+	if _, _, err := Map(table, map[string]string{"Catatan": "notes"}); err == nil {
+		t.Error("mapping to an unknown field accepted")
+	}
+	rows, _, err = Map(table, map[string]string{"catatan": "description"})
+	if err != nil || rows[0].Fields["description"] != "internal" {
+		t.Errorf("mapped rows = %+v, %v", rows, err)
+	}
+	if _, _, err := Map([][]string{{"Judul"}}, nil); err == nil {
+		t.Error("table without an id column accepted")
+	}
+	if _, err := Read("acara.json", nil); err != ErrUnsupportedType {
+		t.Errorf("Read json = %v", err)
+	}
+}
+
+func TestReadXLSX(t *testing.T) {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	add := func(name, body string) {
+		w, _ := zw.Create(name)
+		w.Write([]byte(body))
+	}
+	add("xl/sharedStrings.xml", `<sst><si><t>id</t></si><si><t>title</t></si><si><t>date</t></si>`+
+		`<si><r><t>Seminar </t></r><r><t>AI</t></r></si></sst>`)
+	add("xl/worksheets/sheet1.xml", `<worksheet><sheetData>`+
+		`<row r="1"><c r="A1" t="s"><v>0</v></c><c r="B1" t="s"><v>1</v></c><c r="D1" t="s"><v>2</v></c></row>`+
+		`<row r="2"><c r="A2" t="inlineStr"><is><t>sem_001</t></is></c><c r="B2" t="s"><v>3</v></c><c r="D2"><v>45973</v></c></row>`+
+		`</sheetData></worksheet>`)
+	zw.Close()
+
+	table, err := Read("Acara.XLSX", buf.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(table) != 2 || strings.Join(table[1], "|") != "sem_001|Seminar AI||45973" {
+		t.Fatalf("table = %q", table)
+	}
+	rows, _, err := Map(table, nil)
+	if err != nil || len(rows) != 1 || rows[0].Fields["date"] != "2025-11-12" {
+		t.Fatalf("rows = %+v, %v", rows, err)
+	}
+	if _, err := ReadXLSX([]byte("not a zip")); err == nil {
+		t.Error("ReadXLSX accepted garbage")
+	}
+}
@@ -0,0 +1,57 @@
+// Package export renders conversations as transcripts people can keep
+// outside the app, e.g. to share with an academic advisor.
+package export
+
+import (
+	"AkuAI/models"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// senders names the sides of a conversation in transcripts.
+var senders = map[string]string{"user": "Anda", "bot": "AkuAI"}
+
+// Transcript renders conv and its Messages, oldest first, as Markdown.
+// Times are shown in loc. Messages without text, such as failed replies,
+// are left out.
+func Transcript(conv models.Conversation, loc *time.Location, now time.Time) []byte {
+	var b strings.Builder
+	title := conv.Title
+	if title == "" {
+		title = "Percakapan"
+	}
+	fmt.Fprintf(&b, "# %s\n\n", title)
+	fmt.Fprintf(&b, "_Diekspor dari AkuAI pada %s_\n", now.In(loc).Format("02/01/2006 15:04 MST"))
+	for _, m := range conv.Messages {
+		text := strings.TrimSpace(m.Text)
+		if text == "" {
+			continue
+		}
+		who := senders[m.Sender]
+		if who == "" {
+			who = m.Sender
+		}
+		fmt.Fprintf(&b, "\n---\n\n**%s** · %s\n\n%s\n", who, m.Timestamp.In(loc).Format("02/01/2006 15:04"), text)
+		if m.Status == models.MessageStopped || m.Status == models.MessageError {
+			b.WriteString("\n_(jawaban tidak selesai)_\n")
+		}
+	}
+	return []byte(b.String())
+}
+
+var unsafeName = regexp.MustCompile(`[^a-z0-9]+`)
+
+// Filename is the name of conv's transcript file, e.g.
+// "akuai-1-jadwal-webinar-ai.md".
+func Filename(conv models.Conversation) string {
+	slug := strings.Trim(unsafeName.ReplaceAllString(strings.ToLower(conv.Title), "-"), "-")
+	if len(slug) > 40 {
+		slug = strings.TrimRight(slug[:40], "-")
+	}
+	if slug == "" {
+		return fmt.Sprintf("akuai-%d.md", conv.ID)
+	}
+	return fmt.Sprintf("akuai-%d-%s.md", conv.ID, slug)
+}
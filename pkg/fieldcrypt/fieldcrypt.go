@@ -0,0 +1,210 @@
+// Package fieldcrypt encrypts chat content at rest. Columns tagged
+// gorm:"serializer:encrypted" are sealed with AES-GCM under the current key
+// on write and opened with whichever key sealed them on read, so a database
+// backup alone does not reveal what users wrote. Values stored before
+// encryption was turned on are read as they are.
+package fieldcrypt
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+
+	"gorm.io/gorm/schema"
+)
+
+// prefix marks a sealed value: enc:v1:<key id>:<base64 of nonce+ciphertext>.
+const prefix = "enc:v1:"
+
+// Keyring holds the keys values are sealed with. The zero Keyring stores
+// values in plain text.
+type Keyring struct {
+	current string
+	keys    map[string]cipher.AEAD
+}
+
+// Parse reads a key list "id:base64key,id:base64key,...". The first key
+// seals new values; the others only open values sealed before a rotation.
+// Keys are 16, 24 or 32 bytes (AES-128, -192 or -256). "" is no encryption.
+func Parse(spec string) (*Keyring, error) {
+	k := &Keyring{keys: map[string]cipher.AEAD{}}
+	for _, part := range strings.Split(spec, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		id, enc, ok := strings.Cut(part, ":")
+		if !ok || id == "" || strings.Contains(id, ":") {
+			return nil, fmt.Errorf("key %q is not id:base64key", part)
+		}
+		if _, dup := k.keys[id]; dup {
+			return nil, fmt.Errorf("key id %q given twice", id)
+		}
+		raw, err := base64.StdEncoding.DecodeString(enc)
+		if err != nil {
+			return nil, fmt.Errorf("key %s: %w", id, err)
+		}
+		block, err := aes.NewCipher(raw)
+		if err != nil {
+			return nil, fmt.Errorf("key %s: %w", id, err)
+		}
+		aead, err := cipher.NewGCM(block)
+		if err != nil {
+			return nil, fmt.Errorf("key %s: %w", id, err)
+		}
+		k.keys[id] = aead
+		if k.current == "" {
+			k.current = id
+		}
+	}
+	return k, nil
+}
+
+// Enabled reports whether new values are sealed.
+func (k *Keyring) Enabled() bool {
+	return k != nil && k.current != ""
+}
+
+// CurrentID is the id of the key new values are sealed with, "" when off.
+func (k *Keyring) CurrentID() string {
+	if k == nil {
+		return ""
+	}
+	return k.current
+}
+
+// Encrypt seals plain under the current key. It is returned unchanged when
+// encryption is off or plain is empty.
+func (k *Keyring) Encrypt(plain string) (string, error) {
+	if !k.Enabled() || plain == "" {
+		return plain, nil
+	}
+	aead := k.keys[k.current]
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	sealed := aead.Seal(nonce, nonce, []byte(plain), nil)
+	return prefix + k.current + ":" + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// Decrypt opens a value written by Encrypt; values without the prefix are
+// plain text and returned as they are.
+func (k *Keyring) Decrypt(stored string) (string, error) {
+	id, ok := keyID(stored)
+	if !ok {
+		return stored, nil
+	}
+	var aead cipher.AEAD
+	if k != nil {
+		aead = k.keys[id]
+	}
+	if aead == nil {
+		return "", fmt.Errorf("fieldcrypt: value sealed with unknown key %q", id)
+	}
+	sealed, err := base64.StdEncoding.DecodeString(stored[len(prefix)+len(id)+1:])
+	if err != nil || len(sealed) < aead.NonceSize() {
+		return "", errors.New("fieldcrypt: malformed sealed value")
+	}
+	plain, err := aead.Open(nil, sealed[:aead.NonceSize()], sealed[aead.NonceSize():], nil)
+	if err != nil {
+		return "", fmt.Errorf("fieldcrypt: open value sealed with key %q: %w", id, err)
+	}
+	return string(plain), nil
+}
+
+// Stale reports whether stored is not sealed with the current key and would
+// change when re-encrypted: plain text while encryption is on, or a value
+// sealed with an older key.
+func (k *Keyring) Stale(stored string) bool {
+	if stored == "" {
+		return false
+	}
+	id, sealed := keyID(stored)
+	if !sealed {
+		return k.Enabled()
+	}
+	return id != k.CurrentID()
+}
+
+// keyID returns the key id of a sealed value.
+func keyID(stored string) (string, bool) {
+	rest, ok := strings.CutPrefix(stored, prefix)
+	if !ok {
+		return "", false
+	}
+	id, _, ok := strings.Cut(rest, ":")
+	return id, ok
+}
+
+var (
+	mu      sync.RWMutex
+	keyring = &Keyring{}
+)
+
+// Configure makes spec (see Parse) the keys of the encrypted serializer.
+func Configure(spec string) error {
+	k, err := Parse(spec)
+	if err != nil {
+		return err
+	}
+	Use(k)
+	return nil
+}
+
+// Use makes k the keyring of the encrypted serializer.
+func Use(k *Keyring) {
+	mu.Lock()
+	keyring = k
+	mu.Unlock()
+}
+
+// Default returns the keyring of the encrypted serializer.
+func Default() *Keyring {
+	mu.RLock()
+	defer mu.RUnlock()
+	return keyring
+}
+
+// Serializer is the GORM serializer "encrypted" for string fields.
+type Serializer struct{}
+
+func init() {
+	schema.RegisterSerializer("encrypted", Serializer{})
+}
+
+// Scan opens the column value into the field.
+func (Serializer) Scan(ctx context.Context, field *schema.Field, dst reflect.Value, dbValue any) error {
+	var stored string
+	switch v := dbValue.(type) {
+	case nil:
+	case string:
+		stored = v
+	case []byte:
+		stored = string(v)
+	default:
+		return fmt.Errorf("fieldcrypt: unsupported column value %T", dbValue)
+	}
+	plain, err := Default().Decrypt(stored)
+	if err != nil {
+		return err
+	}
+	field.ReflectValueOf(ctx, dst).SetString(plain)
+	return nil
+}
+
+// Value seals the field value for the column.
+func (Serializer) Value(ctx context.Context, field *schema.Field, dst reflect.Value, fieldValue any) (any, error) {
+	plain, ok := fieldValue.(string)
+	if !ok {
+		return nil, fmt.Errorf("fieldcrypt: %s is %T, not a string", field.Name, fieldValue)
+	}
+	return Default().Encrypt(plain)
+}
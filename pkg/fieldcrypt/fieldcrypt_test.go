@@ -0,0 +1,62 @@
+package fieldcrypt
+
+import (
+	"encoding/base64"
+	"strings"
+	"testing"
+)
+
+func key(id string, n int) string {
+	return id + ":" + base64.StdEncoding.EncodeToString([]byte(strings.Repeat(id[:1], n)))
+}
+
+func TestParse(t *testing.T) {
+	for _, spec := range []string{"nokey", "a:%%%", key("a", 10), key("a", 32) + "," + key("a", 16)} {
+		if _, err := Parse(spec); err == nil {
+			t.Errorf("Parse(%q) accepted", spec)
+		}
+	}
+	k, err := Parse(" " + key("b", 32) + ", " + key("a", 16) + " ")
+	if err != nil || k.CurrentID() != "b" {
+		t.Fatalf("Parse = %v, %v", k, err)
+	}
+	if off, _ := Parse(""); off.Enabled() {
+		t.Error("empty spec enables encryption")
+	}
+}
+
+func TestEncryptDecrypt(t *testing.T) {
+	old, _ := Parse(key("a", 32))
+	k, _ := Parse(key("b", 32) + "," + key("a", 32))
+	sealed, err := old.Encrypt("Lomba saya bulan Desember?")
+	if err != nil || !strings.HasPrefix(sealed, "enc:v1:a:") || strings.Contains(sealed, "Desember") {
+		t.Fatalf("Encrypt = %q, %v", sealed, err)
+	}
+	if again, _ := old.Encrypt("Lomba saya bulan Desember?"); again == sealed {
+		t.Error("equal plain texts sealed alike")
+	}
+	if plain, err := k.Decrypt(sealed); err != nil || plain != "Lomba saya bulan Desember?" {
+		t.Errorf("Decrypt with the rotated keyring = %q, %v", plain, err)
+	}
+	if !k.Stale(sealed) || old.Stale(sealed) {
+		t.Error("Stale does not follow the current key")
+	}
+	if plain, err := k.Decrypt("plain text"); err != nil || plain != "plain text" || !k.Stale("plain text") {
+		t.Errorf("plain value: %q, %v", plain, err)
+	}
+	if s, _ := k.Encrypt(""); s != "" || k.Stale("") {
+		t.Error("empty value sealed")
+	}
+
+	off := &Keyring{}
+	if s, _ := off.Encrypt("x"); s != "x" || off.Stale("x") {
+		t.Error("keyring without keys sealed a value")
+	}
+	if _, err := off.Decrypt(sealed); err == nil {
+		t.Error("opened a value without its key")
+	}
+	tampered := sealed[:len(sealed)-4] + "AAA="
+	if _, err := old.Decrypt(tampered); err == nil {
+		t.Error("opened a tampered value")
+	}
+}
@@ -0,0 +1,61 @@
+package fieldcrypt
+
+import (
+	"fmt"
+
+	"gorm.io/gorm"
+)
+
+// Column names a table column holding encrypted values, e.g. messages.text.
+type Column struct {
+	Table string
+	Name  string
+}
+
+func (c Column) String() string { return c.Table + "." + c.Name }
+
+// rotateBatch is how many rows Reencrypt reads at a time.
+const rotateBatch = 500
+
+// Reencrypt seals every value of col that is not sealed with the current key
+// of k — plain text, or sealed with a key rotated out — and returns how many
+// rows it rewrote. Soft-deleted rows are included. With dryRun it only
+// counts them.
+func Reencrypt(db *gorm.DB, k *Keyring, col Column, dryRun bool) (int, error) {
+	n := 0
+	var last uint
+	for {
+		var rows []struct {
+			ID    uint
+			Value string
+		}
+		if err := db.Table(col.Table).Select("id, "+col.Name+" AS value").
+			Where("id > ?", last).Order("id").Limit(rotateBatch).Scan(&rows).Error; err != nil {
+			return n, err
+		}
+		if len(rows) == 0 {
+			return n, nil
+		}
+		for _, r := range rows {
+			last = r.ID
+			if !k.Stale(r.Value) {
+				continue
+			}
+			plain, err := k.Decrypt(r.Value)
+			if err != nil {
+				return n, fmt.Errorf("%s of row %d: %w", col, r.ID, err)
+			}
+			sealed, err := k.Encrypt(plain)
+			if err != nil {
+				return n, err
+			}
+			if !dryRun {
+				// the value stays the same for the application, so UpdatedAt does too
+				if err := db.Table(col.Table).Where("id = ?", r.ID).UpdateColumn(col.Name, sealed).Error; err != nil {
+					return n, fmt.Errorf("%s of row %d: %w", col, r.ID, err)
+				}
+			}
+			n++
+		}
+	}
+}
@@ -0,0 +1,193 @@
+package grpcserver
+
+import (
+	akuaiproto "AkuAI/proto"
+	"strings"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+// Files holds the descriptors of every service the server knows:
+// proto/akuai/v1/akuai.proto (parsed from the .proto itself), grpc.health.v1
+// and grpc.reflection v1 and v1alpha (fixed upstream definitions, written out
+// below).
+var Files = buildFiles()
+
+type (
+	fieldType = descriptorpb.FieldDescriptorProto_Type
+	methodDef struct {
+		name, in, out        string
+		clientStream, stream bool
+	}
+)
+
+const (
+	tString  = descriptorpb.FieldDescriptorProto_TYPE_STRING
+	tInt32   = descriptorpb.FieldDescriptorProto_TYPE_INT32
+	tBytes   = descriptorpb.FieldDescriptorProto_TYPE_BYTES
+	tMessage = descriptorpb.FieldDescriptorProto_TYPE_MESSAGE
+	tEnum    = descriptorpb.FieldDescriptorProto_TYPE_ENUM
+)
+
+// field declares a singular field; typeName is the full name of a message or
+// enum type, e.g. ".akuai.v1.Event".
+func field(name string, number int32, t fieldType, typeName ...string) *descriptorpb.FieldDescriptorProto {
+	f := &descriptorpb.FieldDescriptorProto{
+		Name:     proto.String(name),
+		JsonName: proto.String(jsonName(name)),
+		Number:   proto.Int32(number),
+		Label:    descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum(),
+		Type:     t.Enum(),
+	}
+	if len(typeName) > 0 {
+		f.TypeName = proto.String(typeName[0])
+	}
+	return f
+}
+
+func repeated(f *descriptorpb.FieldDescriptorProto) *descriptorpb.FieldDescriptorProto {
+	f.Label = descriptorpb.FieldDescriptorProto_LABEL_REPEATED.Enum()
+	return f
+}
+
+// oneof puts fields into the oneof at index in their message.
+func oneof(index int32, fields ...*descriptorpb.FieldDescriptorProto) []*descriptorpb.FieldDescriptorProto {
+	for _, f := range fields {
+		f.OneofIndex = proto.Int32(index)
+	}
+	return fields
+}
+
+func message(name string, fields ...*descriptorpb.FieldDescriptorProto) *descriptorpb.DescriptorProto {
+	return &descriptorpb.DescriptorProto{Name: proto.String(name), Field: fields}
+}
+
+func service(pkg, name string, methods ...methodDef) *descriptorpb.ServiceDescriptorProto {
+	s := &descriptorpb.ServiceDescriptorProto{Name: proto.String(name)}
+	for _, m := range methods {
+		md := &descriptorpb.MethodDescriptorProto{
+			Name:       proto.String(m.name),
+			InputType:  proto.String("." + pkg + "." + m.in),
+			OutputType: proto.String("." + pkg + "." + m.out),
+		}
+		if m.clientStream {
+			md.ClientStreaming = proto.Bool(true)
+		}
+		if m.stream {
+			md.ServerStreaming = proto.Bool(true)
+		}
+		s.Method = append(s.Method, md)
+	}
+	return s
+}
+
+func file(name, pkg string, messages []*descriptorpb.DescriptorProto, services ...*descriptorpb.ServiceDescriptorProto) *descriptorpb.FileDescriptorProto {
+	return &descriptorpb.FileDescriptorProto{
+		Name:        proto.String(name),
+		Package:     proto.String(pkg),
+		Syntax:      proto.String("proto3"),
+		MessageType: messages,
+		Service:     services,
+	}
+}
+
+func jsonName(s string) string {
+	parts := strings.Split(s, "_")
+	for i := 1; i < len(parts); i++ {
+		if parts[i] != "" {
+			parts[i] = strings.ToUpper(parts[i][:1]) + parts[i][1:]
+		}
+	}
+	return strings.Join(parts, "")
+}
+
+// akuaiFile is proto/akuai/v1/akuai.proto, parsed from the embedded copy so
+// the .proto stays the only definition of the API.
+func akuaiFile() *descriptorpb.FileDescriptorProto {
+	fd, err := parseProtoFile(akuaiproto.Files, "akuai/v1/akuai.proto")
+	if err != nil {
+		panic("grpcserver: " + err.Error())
+	}
+	return fd
+}
+
+// healthFile is grpc/health/v1/health.proto.
+func healthFile() *descriptorpb.FileDescriptorProto {
+	const pkg = "grpc.health.v1"
+	resp := message("HealthCheckResponse", field("status", 1, tEnum, ".grpc.health.v1.HealthCheckResponse.ServingStatus"))
+	status := &descriptorpb.EnumDescriptorProto{Name: proto.String("ServingStatus")}
+	for i, name := range []string{"UNKNOWN", "SERVING", "NOT_SERVING", "SERVICE_UNKNOWN"} {
+		status.Value = append(status.Value, &descriptorpb.EnumValueDescriptorProto{Name: proto.String(name), Number: proto.Int32(int32(i))})
+	}
+	resp.EnumType = []*descriptorpb.EnumDescriptorProto{status}
+	return file("grpc/health/v1/health.proto", pkg, []*descriptorpb.DescriptorProto{
+		message("HealthCheckRequest", field("service", 1, tString)),
+		resp,
+	},
+		service(pkg, "Health",
+			methodDef{name: "Check", in: "HealthCheckRequest", out: "HealthCheckResponse"},
+			methodDef{name: "Watch", in: "HealthCheckRequest", out: "HealthCheckResponse", stream: true}),
+	)
+}
+
+// reflectionFile is grpc/reflection/<version>/reflection.proto.
+func reflectionFile(version string) *descriptorpb.FileDescriptorProto {
+	pkg := "grpc.reflection." + version
+	t := func(name string) string { return "." + pkg + "." + name }
+
+	req := message("ServerReflectionRequest", field("host", 1, tString))
+	req.Field = append(req.Field, oneof(0,
+		field("file_by_filename", 3, tString),
+		field("file_containing_symbol", 4, tString),
+		field("file_containing_extension", 5, tMessage, t("ExtensionRequest")),
+		field("all_extension_numbers_of_type", 6, tString),
+		field("list_services", 7, tString))...)
+	req.OneofDecl = []*descriptorpb.OneofDescriptorProto{{Name: proto.String("message_request")}}
+
+	resp := message("ServerReflectionResponse",
+		field("valid_host", 1, tString),
+		field("original_request", 2, tMessage, t("ServerReflectionRequest")))
+	resp.Field = append(resp.Field, oneof(0,
+		field("file_descriptor_response", 4, tMessage, t("FileDescriptorResponse")),
+		field("all_extension_numbers_response", 5, tMessage, t("ExtensionNumberResponse")),
+		field("list_services_response", 6, tMessage, t("ListServiceResponse")),
+		field("error_response", 7, tMessage, t("ErrorResponse")))...)
+	resp.OneofDecl = []*descriptorpb.OneofDescriptorProto{{Name: proto.String("message_response")}}
+
+	return file("grpc/reflection/"+version+"/reflection.proto", pkg, []*descriptorpb.DescriptorProto{
+		req,
+		message("ExtensionRequest",
+			field("containing_type", 1, tString),
+			field("extension_number", 2, tInt32)),
+		resp,
+		message("FileDescriptorResponse", repeated(field("file_descriptor_proto", 1, tBytes))),
+		message("ExtensionNumberResponse",
+			field("base_type_name", 1, tString),
+			repeated(field("extension_number", 2, tInt32))),
+		message("ListServiceResponse", repeated(field("service", 1, tMessage, t("ServiceResponse")))),
+		message("ServiceResponse", field("name", 1, tString)),
+		message("ErrorResponse",
+			field("error_code", 1, tInt32),
+			field("error_message", 2, tString)),
+	},
+		service(pkg, "ServerReflection",
+			methodDef{name: "ServerReflectionInfo", in: "ServerReflectionRequest", out: "ServerReflectionResponse", clientStream: true, stream: true}),
+	)
+}
+
+func buildFiles() *protoregistry.Files {
+	files := new(protoregistry.Files)
+	for _, fdp := range []*descriptorpb.FileDescriptorProto{akuaiFile(), healthFile(), reflectionFile("v1"), reflectionFile("v1alpha")} {
+		fd, err := protodesc.NewFile(fdp, files)
+		if err != nil {
+			panic("grpcserver: invalid descriptor " + fdp.GetName() + ": " + err.Error())
+		}
+		if err := files.RegisterFile(fd); err != nil {
+			panic(err)
+		}
+	}
+	return files
+}
@@ -0,0 +1,99 @@
+package grpcserver
+
+import (
+	"sync"
+)
+
+// Serving statuses of grpc.health.v1.HealthCheckResponse.
+const (
+	StatusServing    = "SERVING"
+	StatusNotServing = "NOT_SERVING"
+)
+
+// Health is the grpc.health.v1 service. The empty service name stands for
+// the whole server.
+type Health struct {
+	mu       sync.Mutex
+	statuses map[string]string
+	watchers map[chan struct{}]struct{}
+}
+
+// NewHealth returns a health service reporting the server as serving.
+func NewHealth() *Health {
+	return &Health{statuses: map[string]string{"": StatusServing}, watchers: map[chan struct{}]struct{}{}}
+}
+
+// Set records the status of service and notifies watchers.
+func (h *Health) Set(service, status string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.statuses[service] = status
+	for ch := range h.watchers {
+		select {
+		case ch <- struct{}{}:
+		default:
+		}
+	}
+}
+
+func (h *Health) status(service string) (string, bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	s, ok := h.statuses[service]
+	return s, ok
+}
+
+func (h *Health) check(s *Stream) error {
+	req, err := s.RecvOne()
+	if err != nil {
+		return err
+	}
+	name := getString(req, "service")
+	status, ok := h.status(name)
+	if !ok {
+		return Errorf(NotFound, "unknown service %q", name)
+	}
+	resp := s.NewResponse()
+	setEnum(resp, "status", status)
+	return s.Send(resp)
+}
+
+// watch sends the status of the service at once and again on every change,
+// SERVICE_UNKNOWN while it is not registered.
+func (h *Health) watch(s *Stream) error {
+	req, err := s.RecvOne()
+	if err != nil {
+		return err
+	}
+	name := getString(req, "service")
+	changed := make(chan struct{}, 1)
+	h.mu.Lock()
+	h.watchers[changed] = struct{}{}
+	h.mu.Unlock()
+	defer func() {
+		h.mu.Lock()
+		delete(h.watchers, changed)
+		h.mu.Unlock()
+	}()
+
+	last := ""
+	for {
+		status, ok := h.status(name)
+		if !ok {
+			status = "SERVICE_UNKNOWN"
+		}
+		if status != last {
+			resp := s.NewResponse()
+			setEnum(resp, "status", status)
+			if err := s.Send(resp); err != nil {
+				return err
+			}
+			last = status
+		}
+		select {
+		case <-s.Context().Done():
+			return s.Context().Err()
+		case <-changed:
+		}
+	}
+}
@@ -0,0 +1,78 @@
+package grpcserver
+
+import (
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+// Accessors of dynamic message fields by proto name. They panic on a name
+// the descriptor does not have, as generated code would fail to compile.
+
+func fieldOf(m protoreflect.Message, name string) protoreflect.FieldDescriptor {
+	fd := m.Descriptor().Fields().ByName(protoreflect.Name(name))
+	if fd == nil {
+		panic("grpcserver: " + string(m.Descriptor().FullName()) + " has no field " + name)
+	}
+	return fd
+}
+
+func getString(m *dynamicpb.Message, name string) string {
+	return m.Get(fieldOf(m, name)).String()
+}
+
+// GetString returns a string field.
+func GetString(m *dynamicpb.Message, name string) string { return getString(m, name) }
+
+// GetUint returns an unsigned integer field.
+func GetUint(m *dynamicpb.Message, name string) uint64 { return m.Get(fieldOf(m, name)).Uint() }
+
+// GetBool returns a bool field.
+func GetBool(m *dynamicpb.Message, name string) bool { return m.Get(fieldOf(m, name)).Bool() }
+
+// Set sets a scalar field to v (string, bool, uint64 or float64); zero values
+// are left unset as proto3 does.
+func Set(m *dynamicpb.Message, name string, v any) {
+	fd := fieldOf(m, name)
+	var pv protoreflect.Value
+	switch v := v.(type) {
+	case string:
+		if v == "" {
+			return
+		}
+		pv = protoreflect.ValueOfString(v)
+	case bool:
+		if !v {
+			return
+		}
+		pv = protoreflect.ValueOfBool(v)
+	case uint64:
+		if v == 0 {
+			return
+		}
+		pv = protoreflect.ValueOfUint64(v)
+	case float64:
+		if v == 0 {
+			return
+		}
+		pv = protoreflect.ValueOfFloat64(v)
+	default:
+		panic("grpcserver: unsupported value for field " + name)
+	}
+	m.Set(fd, pv)
+}
+
+// Append adds a message to a repeated message field and returns it to fill.
+func Append(m *dynamicpb.Message, name string) *dynamicpb.Message {
+	fd := fieldOf(m, name)
+	list := m.Mutable(fd).List()
+	el := list.NewElement()
+	list.Append(el)
+	return el.Message().Interface().(*dynamicpb.Message)
+}
+
+func setEnum(m *dynamicpb.Message, name, value string) {
+	fd := fieldOf(m, name)
+	if ev := fd.Enum().Values().ByName(protoreflect.Name(value)); ev != nil {
+		m.Set(fd, protoreflect.ValueOfEnum(ev.Number()))
+	}
+}
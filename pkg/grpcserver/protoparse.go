@@ -0,0 +1,392 @@
+package grpcserver
+
+import (
+	"fmt"
+	"io/fs"
+	"strings"
+	"unicode"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+// The parser below reads the proto3 subset the API definitions use: syntax,
+// package, option go_package, messages (nested messages and enums, oneofs,
+// repeated fields), enums and services with unary and streaming rpcs.
+// Anything else (imports, maps, field options, optional, reserved) is an
+// error rather than being skipped, so a .proto change the server can't
+// describe fails at startup and in the tests instead of drifting silently.
+
+var scalarTypes = map[string]fieldType{
+	"double":   descriptorpb.FieldDescriptorProto_TYPE_DOUBLE,
+	"float":    descriptorpb.FieldDescriptorProto_TYPE_FLOAT,
+	"int32":    descriptorpb.FieldDescriptorProto_TYPE_INT32,
+	"int64":    descriptorpb.FieldDescriptorProto_TYPE_INT64,
+	"uint32":   descriptorpb.FieldDescriptorProto_TYPE_UINT32,
+	"uint64":   descriptorpb.FieldDescriptorProto_TYPE_UINT64,
+	"sint32":   descriptorpb.FieldDescriptorProto_TYPE_SINT32,
+	"sint64":   descriptorpb.FieldDescriptorProto_TYPE_SINT64,
+	"fixed32":  descriptorpb.FieldDescriptorProto_TYPE_FIXED32,
+	"fixed64":  descriptorpb.FieldDescriptorProto_TYPE_FIXED64,
+	"sfixed32": descriptorpb.FieldDescriptorProto_TYPE_SFIXED32,
+	"sfixed64": descriptorpb.FieldDescriptorProto_TYPE_SFIXED64,
+	"bool":     descriptorpb.FieldDescriptorProto_TYPE_BOOL,
+	"string":   descriptorpb.FieldDescriptorProto_TYPE_STRING,
+	"bytes":    descriptorpb.FieldDescriptorProto_TYPE_BYTES,
+}
+
+type token struct {
+	text string
+	line int
+}
+
+type protoParser struct {
+	name   string
+	toks   []token
+	pos    int
+	pkg    string
+	types  map[string]fieldType // full name without the leading dot: message or enum
+	fields []pendingField
+}
+
+// pendingField is a field whose type names a message or enum, resolved once
+// every type of the file is known.
+type pendingField struct {
+	f     *descriptorpb.FieldDescriptorProto
+	scope string
+	line  int
+}
+
+// parseProtoFile reads path from fsys and returns its descriptor, named path.
+func parseProtoFile(fsys fs.FS, path string) (*descriptorpb.FileDescriptorProto, error) {
+	src, err := fs.ReadFile(fsys, path)
+	if err != nil {
+		return nil, err
+	}
+	return parseProto(path, string(src))
+}
+
+func parseProto(name, src string) (fd *descriptorpb.FileDescriptorProto, err error) {
+	p := &protoParser{name: name, types: map[string]fieldType{}}
+	if p.toks, err = tokenize(src); err != nil {
+		return nil, fmt.Errorf("%s:%w", name, err)
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			perr, ok := r.(parseError)
+			if !ok {
+				panic(r)
+			}
+			fd, err = nil, perr
+		}
+	}()
+	fd = &descriptorpb.FileDescriptorProto{Name: proto.String(name)}
+	for !p.done() {
+		switch t := p.next(); t.text {
+		case "syntax":
+			p.expect("=")
+			if s := p.str(); s != "proto3" {
+				p.fail(t, "syntax %q is not supported", s)
+			}
+			p.expect(";")
+			fd.Syntax = proto.String("proto3")
+		case "package":
+			p.pkg = p.ident()
+			p.expect(";")
+			fd.Package = proto.String(p.pkg)
+		case "option":
+			if opt := p.ident(); opt != "go_package" {
+				p.fail(t, "option %s is not supported", opt)
+			}
+			p.expect("=")
+			fd.Options = &descriptorpb.FileOptions{GoPackage: proto.String(p.str())}
+			p.expect(";")
+		case "message":
+			fd.MessageType = append(fd.MessageType, p.message(p.pkg))
+		case "enum":
+			fd.EnumType = append(fd.EnumType, p.enum(p.pkg))
+		case "service":
+			fd.Service = append(fd.Service, p.service())
+		default:
+			p.fail(t, "unexpected %q", t.text)
+		}
+	}
+	if fd.Syntax == nil {
+		return nil, fmt.Errorf("%s: missing syntax = \"proto3\"", name)
+	}
+	for _, pf := range p.fields {
+		full, t, ok := p.resolve(pf.f.GetTypeName(), pf.scope)
+		if !ok {
+			return nil, fmt.Errorf("%s:%d: unknown type %s", name, pf.line, pf.f.GetTypeName())
+		}
+		pf.f.TypeName = proto.String(full)
+		pf.f.Type = t.Enum()
+	}
+	for _, s := range fd.Service {
+		for _, m := range s.Method {
+			for _, typ := range []*string{m.InputType, m.OutputType} {
+				full, t, ok := p.resolve(*typ, p.pkg)
+				if !ok || t != tMessage {
+					return nil, fmt.Errorf("%s: %s.%s: %s is not a message", name, s.GetName(), m.GetName(), *typ)
+				}
+				*typ = full
+			}
+		}
+	}
+	return fd, nil
+}
+
+// message parses `message Name { ... }` declared in scope.
+func (p *protoParser) message(scope string) *descriptorpb.DescriptorProto {
+	name := p.ident()
+	full := join(scope, name)
+	p.types[full] = tMessage
+	m := &descriptorpb.DescriptorProto{Name: proto.String(name)}
+	p.expect("{")
+	for !p.accept("}") {
+		switch t := p.peek(); t.text {
+		case "message":
+			p.next()
+			m.NestedType = append(m.NestedType, p.message(full))
+		case "enum":
+			p.next()
+			m.EnumType = append(m.EnumType, p.enum(full))
+		case "oneof":
+			p.next()
+			index := int32(len(m.OneofDecl))
+			m.OneofDecl = append(m.OneofDecl, &descriptorpb.OneofDescriptorProto{Name: proto.String(p.ident())})
+			p.expect("{")
+			for !p.accept("}") {
+				f := p.field(full)
+				if f.GetLabel() == descriptorpb.FieldDescriptorProto_LABEL_REPEATED {
+					p.fail(t, "repeated field %s in oneof", f.GetName())
+				}
+				f.OneofIndex = proto.Int32(index)
+				m.Field = append(m.Field, f)
+			}
+		default:
+			m.Field = append(m.Field, p.field(full))
+		}
+	}
+	return m
+}
+
+// field parses `[repeated] type name = number;` in message scope.
+func (p *protoParser) field(scope string) *descriptorpb.FieldDescriptorProto {
+	t := p.peek()
+	repeat := p.accept("repeated")
+	switch t.text {
+	case "optional", "required", "map", "reserved", "extensions", "option", "extend":
+		p.fail(t, "%s is not supported", t.text)
+	}
+	typ := p.ident()
+	name := p.ident()
+	p.expect("=")
+	f := field(name, p.number(), 0)
+	if p.peek().text == "[" {
+		p.fail(p.peek(), "field options are not supported")
+	}
+	p.expect(";")
+	if repeat {
+		repeated(f)
+	}
+	if st, ok := scalarTypes[typ]; ok {
+		f.Type = st.Enum()
+	} else {
+		f.TypeName = proto.String(typ)
+		p.fields = append(p.fields, pendingField{f: f, scope: scope, line: t.line})
+	}
+	return f
+}
+
+// enum parses `enum Name { VALUE = 0; ... }` declared in scope.
+func (p *protoParser) enum(scope string) *descriptorpb.EnumDescriptorProto {
+	name := p.ident()
+	p.types[join(scope, name)] = tEnum
+	e := &descriptorpb.EnumDescriptorProto{Name: proto.String(name)}
+	p.expect("{")
+	for !p.accept("}") {
+		v := p.ident()
+		p.expect("=")
+		e.Value = append(e.Value, &descriptorpb.EnumValueDescriptorProto{Name: proto.String(v), Number: proto.Int32(p.number())})
+		p.expect(";")
+	}
+	return e
+}
+
+// service parses `service Name { rpc M([stream] In) returns ([stream] Out); }`.
+// Input and output types are resolved by parseProto.
+func (p *protoParser) service() *descriptorpb.ServiceDescriptorProto {
+	s := &descriptorpb.ServiceDescriptorProto{Name: proto.String(p.ident())}
+	p.expect("{")
+	for !p.accept("}") {
+		p.expect("rpc")
+		m := &descriptorpb.MethodDescriptorProto{Name: proto.String(p.ident())}
+		p.expect("(")
+		if p.accept("stream") {
+			m.ClientStreaming = proto.Bool(true)
+		}
+		m.InputType = proto.String(p.ident())
+		p.expect(")")
+		p.expect("returns")
+		p.expect("(")
+		if p.accept("stream") {
+			m.ServerStreaming = proto.Bool(true)
+		}
+		m.OutputType = proto.String(p.ident())
+		p.expect(")")
+		if p.accept("{") {
+			p.expect("}")
+		} else {
+			p.expect(";")
+		}
+		s.Method = append(s.Method, m)
+	}
+	return s
+}
+
+// resolve finds a type name the way protoc does: a leading dot is fully
+// qualified, otherwise scope and then each enclosing scope is tried.
+func (p *protoParser) resolve(name, scope string) (string, fieldType, bool) {
+	if strings.HasPrefix(name, ".") {
+		t, ok := p.types[name[1:]]
+		return name, t, ok
+	}
+	for {
+		if t, ok := p.types[join(scope, name)]; ok {
+			return "." + join(scope, name), t, true
+		}
+		if scope == "" {
+			return "", 0, false
+		}
+		if i := strings.LastIndexByte(scope, '.'); i >= 0 {
+			scope = scope[:i]
+		} else {
+			scope = ""
+		}
+	}
+}
+
+func join(scope, name string) string {
+	if scope == "" {
+		return name
+	}
+	return scope + "." + name
+}
+
+type parseError struct{ msg string }
+
+func (e parseError) Error() string { return e.msg }
+
+func (p *protoParser) fail(t token, format string, args ...any) {
+	panic(parseError{fmt.Sprintf("%s:%d: %s", p.name, t.line, fmt.Sprintf(format, args...))})
+}
+
+func (p *protoParser) done() bool { return p.pos >= len(p.toks) }
+
+func (p *protoParser) peek() token {
+	if p.done() {
+		last := 0
+		if len(p.toks) > 0 {
+			last = p.toks[len(p.toks)-1].line
+		}
+		return token{line: last}
+	}
+	return p.toks[p.pos]
+}
+
+func (p *protoParser) next() token {
+	t := p.peek()
+	if p.done() {
+		p.fail(t, "unexpected end of file")
+	}
+	p.pos++
+	return t
+}
+
+func (p *protoParser) accept(text string) bool {
+	if !p.done() && p.toks[p.pos].text == text {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *protoParser) expect(text string) {
+	if t := p.next(); t.text != text {
+		p.fail(t, "expected %q, got %q", text, t.text)
+	}
+}
+
+func (p *protoParser) ident() string {
+	t := p.next()
+	for _, r := range t.text {
+		if r != '_' && r != '.' && !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			p.fail(t, "expected a name, got %q", t.text)
+		}
+	}
+	return t.text
+}
+
+func (p *protoParser) str() string {
+	t := p.next()
+	if len(t.text) < 2 || t.text[0] != '"' {
+		p.fail(t, "expected a string, got %q", t.text)
+	}
+	return t.text[1 : len(t.text)-1]
+}
+
+func (p *protoParser) number() int32 {
+	t := p.next()
+	var n int32
+	if _, err := fmt.Sscanf(t.text, "%d", &n); err != nil || fmt.Sprint(n) != t.text {
+		p.fail(t, "expected a number, got %q", t.text)
+	}
+	return n
+}
+
+// tokenize splits src into names, numbers, double-quoted strings and single
+// punctuation characters, dropping whitespace and comments.
+func tokenize(src string) ([]token, error) {
+	var toks []token
+	line := 1
+	for i := 0; i < len(src); {
+		c := src[i]
+		switch {
+		case c == '\n':
+			line++
+			i++
+		case c == ' ' || c == '\t' || c == '\r':
+			i++
+		case strings.HasPrefix(src[i:], "//"):
+			for i < len(src) && src[i] != '\n' {
+				i++
+			}
+		case strings.HasPrefix(src[i:], "/*"):
+			end := strings.Index(src[i+2:], "*/")
+			if end < 0 {
+				return nil, fmt.Errorf("%d: unterminated comment", line)
+			}
+			line += strings.Count(src[i:i+2+end], "\n")
+			i += end + 4
+		case c == '"':
+			end := strings.IndexAny(src[i+1:], "\"\n")
+			if end < 0 || src[i+1+end] != '"' {
+				return nil, fmt.Errorf("%d: unterminated string", line)
+			}
+			toks = append(toks, token{src[i : i+end+2], line})
+			i += end + 2
+		case c == '_' || c == '.' || c == '-' || unicode.IsLetter(rune(c)) || unicode.IsDigit(rune(c)):
+			j := i + 1
+			for j < len(src) && (src[j] == '_' || src[j] == '.' || unicode.IsLetter(rune(src[j])) || unicode.IsDigit(rune(src[j]))) {
+				j++
+			}
+			toks = append(toks, token{src[i:j], line})
+			i = j
+		default:
+			toks = append(toks, token{string(c), line})
+			i++
+		}
+	}
+	return toks, nil
+}
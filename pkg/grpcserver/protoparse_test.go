@@ -0,0 +1,166 @@
+package grpcserver
+
+import (
+	akuaiproto "AkuAI/proto"
+	"io/fs"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+func TestParseProto(t *testing.T) {
+	fd, err := parseProto("t.proto", `
+syntax = "proto3"; /* block
+comment */
+package t.v1;
+option go_package = "example/tpb";
+
+service S {
+  rpc Get(Req) returns (Outer.Inner);
+  rpc Chat(stream Req) returns (stream .t.v1.Req) {}
+}
+
+// Req is a request.
+message Req {
+  string id = 1; // trailing
+  repeated Outer.Kind kinds = 2;
+  oneof pick {
+    bytes raw = 3;
+    Outer.Inner inner = 4;
+  }
+}
+
+message Outer {
+  enum Kind {
+    KIND_UNSPECIFIED = 0;
+    KIND_A = 1;
+  }
+  message Inner { Kind kind = 1; }
+  Inner inner = 1;
+}
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	kind := &descriptorpb.EnumDescriptorProto{Name: proto.String("Kind"), Value: []*descriptorpb.EnumValueDescriptorProto{
+		{Name: proto.String("KIND_UNSPECIFIED"), Number: proto.Int32(0)},
+		{Name: proto.String("KIND_A"), Number: proto.Int32(1)},
+	}}
+	req := message("Req",
+		field("id", 1, tString),
+		repeated(field("kinds", 2, tEnum, ".t.v1.Outer.Kind")))
+	req.Field = append(req.Field, oneof(0,
+		field("raw", 3, tBytes),
+		field("inner", 4, tMessage, ".t.v1.Outer.Inner"))...)
+	req.OneofDecl = []*descriptorpb.OneofDescriptorProto{{Name: proto.String("pick")}}
+	outer := message("Outer", field("inner", 1, tMessage, ".t.v1.Outer.Inner"))
+	outer.NestedType = []*descriptorpb.DescriptorProto{message("Inner", field("kind", 1, tEnum, ".t.v1.Outer.Kind"))}
+	outer.EnumType = []*descriptorpb.EnumDescriptorProto{kind}
+	want := file("t.proto", "t.v1", []*descriptorpb.DescriptorProto{req, outer},
+		service("t.v1", "S",
+			methodDef{name: "Get", in: "Req", out: "Outer.Inner"},
+			methodDef{name: "Chat", in: "Req", out: "Req", clientStream: true, stream: true}))
+	want.Options = &descriptorpb.FileOptions{GoPackage: proto.String("example/tpb")}
+	if !proto.Equal(fd, want) {
+		t.Errorf("parsed\n%v\nwant\n%v", fd, want)
+	}
+}
+
+func TestParseProtoRejectsUnsupported(t *testing.T) {
+	for src, want := range map[string]string{
+		`package t;`:         `missing syntax`,
+		`syntax = "proto2";`: `t.proto:1: syntax "proto2" is not supported`,
+		"syntax = \"proto3\";\nimport \"x.proto\";":                                 `t.proto:2: unexpected "import"`,
+		"syntax = \"proto3\";\noption java_package = \"x\";":                        `t.proto:2: option java_package is not supported`,
+		"syntax = \"proto3\";\nmessage M {\n  map<string, string> m = 1;\n}":        `t.proto:3: map is not supported`,
+		"syntax = \"proto3\";\nmessage M {\n  optional string s = 1;\n}":            `t.proto:3: optional is not supported`,
+		"syntax = \"proto3\";\nmessage M {\n  reserved 2;\n}":                       `t.proto:3: reserved is not supported`,
+		"syntax = \"proto3\";\nmessage M {\n  string s = 1 [deprecated = true];\n}": `t.proto:3: field options are not supported`,
+		"syntax = \"proto3\";\nmessage M {\n  Missing m = 1;\n}":                    `t.proto:3: unknown type Missing`,
+		"syntax = \"proto3\";\nservice S {\n  rpc A(M) returns (M);\n}":             `S.A: M is not a message`,
+		"syntax = \"proto3\";\nmessage M {\n  string s = 1;\n":                      `unexpected end of file`,
+		"syntax = \"proto3\";\n/* open":                                             `t.proto:2: unterminated comment`,
+	} {
+		if _, err := parseProto("t.proto", src); err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("%q: err %v, want %q", src, err, want)
+		}
+	}
+}
+
+// TestAkuAIDescriptorMatchesProto cross-checks the served akuai.v1
+// descriptor against a plain scan of the .proto: every message, field and rpc
+// declared in the file is served with the same number, label and streaming.
+func TestAkuAIDescriptorMatchesProto(t *testing.T) {
+	src, err := fs.ReadFile(akuaiproto.Files, "akuai/v1/akuai.proto")
+	if err != nil {
+		t.Fatal(err)
+	}
+	served, err := Files.FindFileByPath("akuai/v1/akuai.proto")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var (
+		messageRe = regexp.MustCompile(`^message (\w+) \{$`)
+		fieldRe   = regexp.MustCompile(`^(repeated )?(\w+) (\w+) = (\d+);`)
+		rpcRe     = regexp.MustCompile(`^rpc (\w+)\((stream )?(\w+)\) returns \((stream )?(\w+)\);`)
+		serviceRe = regexp.MustCompile(`^service (\w+) \{$`)
+	)
+	var msg protoreflect.MessageDescriptor
+	var svc protoreflect.ServiceDescriptor
+	messages, fields, rpcs := 0, 0, 0
+	for i, line := range strings.Split(strings.ReplaceAll(string(src), "\r", ""), "\n") {
+		line = strings.TrimSpace(line)
+		at := func(format string, args ...any) {
+			t.Errorf("akuai.proto:%d: "+format, append([]any{i + 1}, args...)...)
+		}
+		if m := messageRe.FindStringSubmatch(line); m != nil {
+			messages++
+			if msg = served.Messages().ByName(protoreflect.Name(m[1])); msg == nil {
+				at("message %s is not served", m[1])
+			}
+		} else if m := serviceRe.FindStringSubmatch(line); m != nil {
+			if svc = served.Services().ByName(protoreflect.Name(m[1])); svc == nil {
+				at("service %s is not served", m[1])
+			}
+		} else if m := fieldRe.FindStringSubmatch(line); m != nil && msg != nil {
+			fields++
+			f := msg.Fields().ByName(protoreflect.Name(m[3]))
+			switch {
+			case f == nil:
+				at("field %s.%s is not served", msg.Name(), m[3])
+			case m[4] != strconv.Itoa(int(f.Number())):
+				at("%s.%s is number %d", msg.Name(), m[3], f.Number())
+			case (m[1] != "") != f.IsList():
+				at("%s.%s repeated = %v", msg.Name(), m[3], f.IsList())
+			}
+		} else if m := rpcRe.FindStringSubmatch(line); m != nil && svc != nil {
+			rpcs++
+			md := svc.Methods().ByName(protoreflect.Name(m[1]))
+			switch {
+			case md == nil:
+				at("rpc %s.%s is not served", svc.Name(), m[1])
+			case string(md.Input().Name()) != m[3] || string(md.Output().Name()) != m[5]:
+				at("rpc %s.%s served as (%s) returns (%s)", svc.Name(), m[1], md.Input().Name(), md.Output().Name())
+			case md.IsStreamingClient() != (m[2] != "") || md.IsStreamingServer() != (m[4] != ""):
+				at("rpc %s.%s streaming differs", svc.Name(), m[1])
+			}
+		}
+	}
+	servedFields := 0
+	for i := 0; i < served.Messages().Len(); i++ {
+		servedFields += served.Messages().Get(i).Fields().Len()
+	}
+	servedRPCs := 0
+	for i := 0; i < served.Services().Len(); i++ {
+		servedRPCs += served.Services().Get(i).Methods().Len()
+	}
+	if messages == 0 || messages != served.Messages().Len() || fields != servedFields || rpcs != servedRPCs {
+		t.Errorf("proto declares %d messages, %d fields, %d rpcs; served %d, %d, %d",
+			messages, fields, rpcs, served.Messages().Len(), servedFields, servedRPCs)
+	}
+}
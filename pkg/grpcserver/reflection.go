@@ -0,0 +1,77 @@
+package grpcserver
+
+import (
+	"io"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+// reflect serves grpc.reflection ServerReflectionInfo: service listing and
+// file descriptors by file name or symbol, enough for grpcurl and Postman.
+func (s *Server) reflect(st *Stream) error {
+	for {
+		req, err := st.Recv()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		resp := st.NewResponse()
+		resp.Set(fieldOf(resp, "original_request"), protoreflect.ValueOfMessage(req))
+		Set(resp, "valid_host", getString(req, "host"))
+
+		which := req.WhichOneof(req.Descriptor().Oneofs().ByName("message_request"))
+		var fd protoreflect.FileDescriptor
+		kind := ""
+		if which != nil {
+			kind = string(which.Name())
+		}
+		switch kind {
+		case "":
+			reflectError(resp, InvalidArgument, "empty request")
+		case "list_services":
+			services := dynamicpb.NewMessage(fieldOf(resp, "list_services_response").Message())
+			for _, svc := range s.Services() {
+				Set(Append(services, "service"), "name", svc)
+			}
+			resp.Set(fieldOf(resp, "list_services_response"), protoreflect.ValueOfMessage(services))
+		case "file_by_filename":
+			name := getString(req, kind)
+			if fd, err = Files.FindFileByPath(name); err != nil {
+				reflectError(resp, NotFound, "unknown file "+name)
+			}
+		case "file_containing_symbol":
+			name := getString(req, kind)
+			if d, err := Files.FindDescriptorByName(protoreflect.FullName(name)); err != nil {
+				reflectError(resp, NotFound, "unknown symbol "+name)
+			} else {
+				fd = d.ParentFile()
+			}
+		default:
+			reflectError(resp, Unimplemented, kind+" is not supported")
+		}
+		if fd != nil {
+			b, err := proto.Marshal(protodesc.ToFileDescriptorProto(fd))
+			if err != nil {
+				return err
+			}
+			files := dynamicpb.NewMessage(fieldOf(resp, "file_descriptor_response").Message())
+			files.Mutable(fieldOf(files, "file_descriptor_proto")).List().Append(protoreflect.ValueOfBytes(b))
+			resp.Set(fieldOf(resp, "file_descriptor_response"), protoreflect.ValueOfMessage(files))
+		}
+		if err := st.Send(resp); err != nil {
+			return err
+		}
+	}
+}
+
+func reflectError(resp *dynamicpb.Message, code Code, msg string) {
+	e := dynamicpb.NewMessage(fieldOf(resp, "error_response").Message())
+	e.Set(fieldOf(e, "error_code"), protoreflect.ValueOfInt32(int32(code)))
+	Set(e, "error_message", msg)
+	resp.Set(fieldOf(resp, "error_response"), protoreflect.ValueOfMessage(e))
+}
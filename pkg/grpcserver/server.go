@@ -0,0 +1,254 @@
+// Package grpcserver serves gRPC over cleartext HTTP/2 (h2c) with the
+// standard library: length-prefixed protobuf messages on a POST per call,
+// status in the grpc-status and grpc-message trailers. Messages are dynamic
+// (dynamicpb) over the descriptors in Files, built from the embedded
+// proto/akuai/v1/akuai.proto at startup, so no generated code is needed.
+// Unary, server-streaming and bidirectional methods are supported; compressed
+// messages are not.
+package grpcserver
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+// maxMessageSize caps a received message, as grpc-go does by default.
+const maxMessageSize = 4 << 20
+
+// Handler runs one call. Unary handlers Recv once and Send once.
+type Handler func(s *Stream) error
+
+type method struct {
+	desc    protoreflect.MethodDescriptor
+	handler Handler
+}
+
+// Server routes calls to the registered methods.
+type Server struct {
+	mu       sync.RWMutex
+	methods  map[string]method // "/pkg.Service/Method"
+	services []string
+}
+
+// New returns a server with the health and reflection services registered.
+func New(health *Health) *Server {
+	s := &Server{methods: map[string]method{}}
+	s.mustRegister("grpc.health.v1.Health", map[string]Handler{"Check": health.check, "Watch": health.watch})
+	for _, svc := range []string{"grpc.reflection.v1.ServerReflection", "grpc.reflection.v1alpha.ServerReflection"} {
+		s.mustRegister(svc, map[string]Handler{"ServerReflectionInfo": s.reflect})
+	}
+	return s
+}
+
+// Register adds the handlers of a service in Files. Every method of the
+// service needs a handler.
+func (s *Server) Register(service string, handlers map[string]Handler) error {
+	d, err := Files.FindDescriptorByName(protoreflect.FullName(service))
+	if err != nil {
+		return fmt.Errorf("grpc: unknown service %s: %w", service, err)
+	}
+	sd, ok := d.(protoreflect.ServiceDescriptor)
+	if !ok {
+		return fmt.Errorf("grpc: %s is not a service", service)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := 0; i < sd.Methods().Len(); i++ {
+		md := sd.Methods().Get(i)
+		h, ok := handlers[string(md.Name())]
+		if !ok {
+			return fmt.Errorf("grpc: no handler for %s/%s", service, md.Name())
+		}
+		s.methods["/"+service+"/"+string(md.Name())] = method{desc: md, handler: h}
+	}
+	s.services = append(s.services, service)
+	return nil
+}
+
+func (s *Server) mustRegister(service string, handlers map[string]Handler) {
+	if err := s.Register(service, handlers); err != nil {
+		panic(err)
+	}
+}
+
+// Services lists the registered services.
+func (s *Server) Services() []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return append([]string(nil), s.services...)
+}
+
+// ListenAndServe serves cleartext HTTP/2 on addr until ctx is done.
+func (s *Server) ListenAndServe(ctx context.Context, addr string) error {
+	var protocols http.Protocols
+	protocols.SetUnencryptedHTTP2(true)
+	srv := &http.Server{Addr: addr, Handler: s, Protocols: &protocols, ReadHeaderTimeout: 10 * time.Second}
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	go func() {
+		<-ctx.Done()
+		srv.Close()
+	}()
+	log.Printf("[grpc] ✅ listening on %s services=%s", ln.Addr(), strings.Join(s.Services(), ","))
+	if err := srv.Serve(ln); !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+// ServeHTTP handles one gRPC call.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.ProtoMajor != 2 || !strings.HasPrefix(r.Header.Get("Content-Type"), "application/grpc") {
+		http.Error(w, "gRPC requires HTTP/2 and content-type application/grpc", http.StatusUnsupportedMediaType)
+		return
+	}
+	w.Header().Set("Content-Type", "application/grpc")
+	w.Header().Set("Trailer", "Grpc-Status, Grpc-Message")
+
+	s.mu.RLock()
+	m, ok := s.methods[r.URL.Path]
+	s.mu.RUnlock()
+	if !ok {
+		writeStatus(w, Errorf(Unimplemented, "unknown method %s", r.URL.Path))
+		return
+	}
+
+	ctx := r.Context()
+	if t, ok := parseTimeout(r.Header.Get("Grpc-Timeout")); ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, t)
+		defer cancel()
+	}
+	w.WriteHeader(http.StatusOK)
+	st := &Stream{ctx: ctx, md: r.Header, method: m.desc, body: r.Body, w: w}
+	if f, ok := w.(http.Flusher); ok {
+		st.flush = f.Flush
+	}
+	err := m.handler(st)
+	if err == nil && ctx.Err() == context.DeadlineExceeded {
+		err = Errorf(DeadlineExceeded, "deadline exceeded")
+	}
+	writeStatus(w, err)
+}
+
+func writeStatus(w http.ResponseWriter, err error) {
+	st := StatusOf(err)
+	if st.Code == Unknown || st.Code == Internal {
+		log.Printf("[grpc] ❌ %v", err)
+	}
+	w.Header().Set("Grpc-Status", strconv.Itoa(int(st.Code)))
+	if st.Message != "" {
+		w.Header().Set("Grpc-Message", encodeMessage(st.Message))
+	}
+}
+
+// encodeMessage percent-encodes grpc-message as the protocol requires.
+func encodeMessage(s string) string {
+	return strings.ReplaceAll(url.PathEscape(s), "%20", " ")
+}
+
+// parseTimeout reads grpc-timeout, e.g. "5S" or "200m".
+func parseTimeout(v string) (time.Duration, bool) {
+	if len(v) < 2 {
+		return 0, false
+	}
+	n, err := strconv.ParseInt(v[:len(v)-1], 10, 64)
+	if err != nil || n < 0 {
+		return 0, false
+	}
+	unit := map[byte]time.Duration{'H': time.Hour, 'M': time.Minute, 'S': time.Second, 'm': time.Millisecond, 'u': time.Microsecond, 'n': time.Nanosecond}[v[len(v)-1]]
+	if unit == 0 {
+		return 0, false
+	}
+	return time.Duration(n) * unit, true
+}
+
+// Stream is one call: the request messages and the response writer.
+type Stream struct {
+	ctx    context.Context
+	md     http.Header
+	method protoreflect.MethodDescriptor
+	body   io.Reader
+	w      io.Writer
+	flush  func()
+}
+
+// Context is done when the client cancels or the deadline passes.
+func (s *Stream) Context() context.Context { return s.ctx }
+
+// Metadata returns a request metadata value, e.g. "authorization".
+func (s *Stream) Metadata(key string) string { return s.md.Get(key) }
+
+// NewResponse returns an empty message of the method's output type.
+func (s *Stream) NewResponse() *dynamicpb.Message { return dynamicpb.NewMessage(s.method.Output()) }
+
+// Recv reads the next request message; io.EOF once the client is done.
+func (s *Stream) Recv() (*dynamicpb.Message, error) {
+	var hdr [5]byte
+	if _, err := io.ReadFull(s.body, hdr[:]); err != nil {
+		if err == io.ErrUnexpectedEOF {
+			return nil, Errorf(Internal, "truncated message header")
+		}
+		return nil, err
+	}
+	if hdr[0] != 0 {
+		return nil, Errorf(Unimplemented, "compressed messages are not supported")
+	}
+	n := binary.BigEndian.Uint32(hdr[1:])
+	if n > maxMessageSize {
+		return nil, Errorf(ResourceExhausted, "message of %d bytes exceeds %d", n, maxMessageSize)
+	}
+	buf := make([]byte, n)
+	if _, err := io.ReadFull(s.body, buf); err != nil {
+		return nil, Errorf(Internal, "truncated message")
+	}
+	m := dynamicpb.NewMessage(s.method.Input())
+	if err := proto.Unmarshal(buf, m); err != nil {
+		return nil, Errorf(InvalidArgument, "malformed %s: %v", s.method.Input().FullName(), err)
+	}
+	return m, nil
+}
+
+// RecvOne reads the single request of a unary or server-streaming call.
+func (s *Stream) RecvOne() (*dynamicpb.Message, error) {
+	m, err := s.Recv()
+	if err == io.EOF {
+		return nil, Errorf(InvalidArgument, "missing request message")
+	}
+	return m, err
+}
+
+// Send writes a response message.
+func (s *Stream) Send(m proto.Message) error {
+	b, err := proto.Marshal(m)
+	if err != nil {
+		return err
+	}
+	frame := make([]byte, 5+len(b))
+	binary.BigEndian.PutUint32(frame[1:5], uint32(len(b)))
+	copy(frame[5:], b)
+	if _, err := s.w.Write(frame); err != nil {
+		return err
+	}
+	if s.flush != nil {
+		s.flush()
+	}
+	return nil
+}
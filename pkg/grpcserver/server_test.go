@@ -0,0 +1,171 @@
+package grpcserver
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+func newTestServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	health := NewHealth()
+	s := New(health)
+	get := func(st *Stream) error {
+		req, err := st.RecvOne()
+		if err != nil {
+			return err
+		}
+		if GetString(req, "id") != "ev-1" {
+			return Errorf(NotFound, "event not found: %s", GetString(req, "id"))
+		}
+		resp := st.NewResponse()
+		Set(resp, "id", "ev-1")
+		Set(resp, "title", "Go 101")
+		return st.Send(resp)
+	}
+	if err := s.Register("akuai.v1.UIBEventService", map[string]Handler{"List": get, "Search": get, "Get": get}); err != nil {
+		t.Fatal(err)
+	}
+	health.Set("akuai.v1.UIBEventService", StatusServing)
+
+	ts := httptest.NewUnstartedServer(s)
+	var p http.Protocols
+	p.SetUnencryptedHTTP2(true)
+	ts.Config.Protocols = &p
+	ts.Start()
+	t.Cleanup(ts.Close)
+	return ts
+}
+
+func newMessage(t *testing.T, name string) *dynamicpb.Message {
+	t.Helper()
+	d, err := Files.FindDescriptorByName(protoreflect.FullName(name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return dynamicpb.NewMessage(d.(protoreflect.MessageDescriptor))
+}
+
+// call makes one gRPC call and returns the response messages decoded as
+// outType, and the grpc-status and grpc-message trailers.
+func call(t *testing.T, ts *httptest.Server, path string, req proto.Message, outType string) ([]*dynamicpb.Message, string, string) {
+	t.Helper()
+	b, err := proto.Marshal(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	frame := make([]byte, 5, 5+len(b))
+	binary.BigEndian.PutUint32(frame[1:], uint32(len(b)))
+	var p http.Protocols
+	p.SetUnencryptedHTTP2(true)
+	client := &http.Client{Transport: &http.Transport{Protocols: &p}}
+	hreq, _ := http.NewRequest(http.MethodPost, ts.URL+path, bytes.NewReader(append(frame, b...)))
+	hreq.Header.Set("Content-Type", "application/grpc")
+	hreq.Header.Set("TE", "trailers")
+	resp, err := client.Do(hreq)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	var out []*dynamicpb.Message
+	for len(body) >= 5 {
+		n := binary.BigEndian.Uint32(body[1:5])
+		m := newMessage(t, outType)
+		if err := proto.Unmarshal(body[5:5+n], m); err != nil {
+			t.Fatal(err)
+		}
+		out = append(out, m)
+		body = body[5+n:]
+	}
+	return out, resp.Trailer.Get("Grpc-Status"), resp.Trailer.Get("Grpc-Message")
+}
+
+func TestUnaryCall(t *testing.T) {
+	ts := newTestServer(t)
+	req := newMessage(t, "akuai.v1.GetEventRequest")
+	Set(req, "id", "ev-1")
+	out, status, _ := call(t, ts, "/akuai.v1.UIBEventService/Get", req, "akuai.v1.Event")
+	if status != "0" || len(out) != 1 || GetString(out[0], "title") != "Go 101" {
+		t.Fatalf("status %s, %d messages", status, len(out))
+	}
+
+	Set(req, "id", "nope")
+	out, status, msg := call(t, ts, "/akuai.v1.UIBEventService/Get", req, "akuai.v1.Event")
+	if status != "5" || len(out) != 0 || msg != "event not found: nope" {
+		t.Errorf("missing event: status %s message %q", status, msg)
+	}
+
+	_, status, _ = call(t, ts, "/akuai.v1.ChatService/Ask", newMessage(t, "akuai.v1.AskRequest"), "akuai.v1.AskResponse")
+	if status != "12" {
+		t.Errorf("unregistered method: status %s, want 12", status)
+	}
+}
+
+func TestHealthCheck(t *testing.T) {
+	ts := newTestServer(t)
+	for service, want := range map[string]string{"": "0", "akuai.v1.UIBEventService": "0", "akuai.v1.Missing": "5"} {
+		req := newMessage(t, "grpc.health.v1.HealthCheckRequest")
+		Set(req, "service", service)
+		out, status, _ := call(t, ts, "/grpc.health.v1.Health/Check", req, "grpc.health.v1.HealthCheckResponse")
+		if status != want {
+			t.Errorf("%q: status %s, want %s", service, status, want)
+			continue
+		}
+		if want == "0" {
+			fd := out[0].Descriptor().Fields().ByName("status")
+			if got := out[0].Get(fd).Enum(); got != 1 {
+				t.Errorf("%q: serving status %d, want SERVING", service, got)
+			}
+		}
+	}
+}
+
+func TestReflection(t *testing.T) {
+	ts := newTestServer(t)
+	req := newMessage(t, "grpc.reflection.v1.ServerReflectionRequest")
+	Set(req, "list_services", "*")
+	out, status, _ := call(t, ts, "/grpc.reflection.v1.ServerReflection/ServerReflectionInfo", req, "grpc.reflection.v1.ServerReflectionResponse")
+	if status != "0" || len(out) != 1 {
+		t.Fatalf("list_services: status %s, %d messages", status, len(out))
+	}
+	list := out[0].Get(out[0].Descriptor().Fields().ByName("list_services_response")).Message()
+	services := list.Get(list.Descriptor().Fields().ByName("service")).List()
+	names := map[string]bool{}
+	for i := 0; i < services.Len(); i++ {
+		m := services.Get(i).Message()
+		names[m.Get(m.Descriptor().Fields().ByName("name")).String()] = true
+	}
+	for _, want := range []string{"grpc.health.v1.Health", "grpc.reflection.v1.ServerReflection", "akuai.v1.UIBEventService"} {
+		if !names[want] {
+			t.Errorf("services %v lack %s", names, want)
+		}
+	}
+
+	req = newMessage(t, "grpc.reflection.v1alpha.ServerReflectionRequest")
+	Set(req, "file_containing_symbol", "akuai.v1.ChatService")
+	out, _, _ = call(t, ts, "/grpc.reflection.v1alpha.ServerReflection/ServerReflectionInfo", req, "grpc.reflection.v1alpha.ServerReflectionResponse")
+	if len(out) != 1 {
+		t.Fatalf("file_containing_symbol: %d messages", len(out))
+	}
+	files := out[0].Get(out[0].Descriptor().Fields().ByName("file_descriptor_response")).Message()
+	protos := files.Get(files.Descriptor().Fields().ByName("file_descriptor_proto")).List()
+	if protos.Len() != 1 {
+		t.Fatalf("%d file descriptors", protos.Len())
+	}
+	var fd descriptorpb.FileDescriptorProto
+	if err := proto.Unmarshal(protos.Get(0).Bytes(), &fd); err != nil {
+		t.Fatal(err)
+	}
+	if fd.GetName() != "akuai/v1/akuai.proto" || len(fd.GetService()) != 2 {
+		t.Errorf("descriptor %s with %d services", fd.GetName(), len(fd.GetService()))
+	}
+}
@@ -0,0 +1,57 @@
+package grpcserver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// Code is a gRPC status code.
+type Code int
+
+const (
+	OK                Code = 0
+	Canceled          Code = 1
+	Unknown           Code = 2
+	InvalidArgument   Code = 3
+	DeadlineExceeded  Code = 4
+	NotFound          Code = 5
+	AlreadyExists     Code = 6
+	PermissionDenied  Code = 7
+	ResourceExhausted Code = 8
+	Unimplemented     Code = 12
+	Internal          Code = 13
+	Unavailable       Code = 14
+	Unauthenticated   Code = 16
+)
+
+// Status is an error carrying a gRPC status.
+type Status struct {
+	Code    Code
+	Message string
+}
+
+func (s *Status) Error() string { return fmt.Sprintf("grpc status %d: %s", s.Code, s.Message) }
+
+// Errorf returns a *Status error.
+func Errorf(code Code, format string, args ...any) error {
+	return &Status{Code: code, Message: fmt.Sprintf(format, args...)}
+}
+
+// StatusOf maps err to the status sent to the client: OK for nil, the
+// *Status it wraps, Canceled or DeadlineExceeded for context errors, else
+// Unknown.
+func StatusOf(err error) *Status {
+	var st *Status
+	switch {
+	case err == nil:
+		return &Status{Code: OK}
+	case errors.As(err, &st):
+		return st
+	case errors.Is(err, context.Canceled):
+		return &Status{Code: Canceled, Message: err.Error()}
+	case errors.Is(err, context.DeadlineExceeded):
+		return &Status{Code: DeadlineExceeded, Message: err.Error()}
+	}
+	return &Status{Code: Unknown, Message: err.Error()}
+}
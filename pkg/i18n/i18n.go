@@ -0,0 +1,129 @@
+// Package i18n holds the user-facing strings of the server in Indonesian
+// and English and picks the locale of a request. Texts the server writes
+// itself (placeholder replies, banners) are looked up by key with T; API
+// error messages are English in the code and translated with Error.
+package i18n
+
+import (
+	"AkuAI/pkg/config"
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Supported locales.
+const (
+	ID = "id"
+	EN = "en"
+)
+
+// Supported lists the locales with a bundle, the default first.
+var Supported = []string{ID, EN}
+
+// ContextKey stores the negotiated locale in a gin.Context.
+const ContextKey = "locale"
+
+// Supports reports whether locale has a bundle.
+func Supports(locale string) bool {
+	return slices.Contains(Supported, locale)
+}
+
+// Default is DEFAULT_LOCALE, or Indonesian when that isn't supported.
+func Default() string {
+	if Supports(config.DefaultLocale) {
+		return config.DefaultLocale
+	}
+	return ID
+}
+
+// Negotiate returns the supported locale an Accept-Language header prefers
+// most, matching "en-US" to "en", or "" when it names none of them.
+func Negotiate(header string) string {
+	type pref struct {
+		locale string
+		q      float64
+	}
+	var prefs []pref
+	for _, part := range strings.Split(header, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		q := 1.0
+		if v, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			f, err := strconv.ParseFloat(v, 64)
+			if err != nil {
+				continue
+			}
+			q = f
+		}
+		base, _, _ := strings.Cut(strings.ToLower(strings.TrimSpace(tag)), "-")
+		if base == "in" { // the old code of Indonesian
+			base = ID
+		}
+		if Supports(base) && q > 0 {
+			prefs = append(prefs, pref{base, q})
+		}
+	}
+	if len(prefs) == 0 {
+		return ""
+	}
+	sort.SliceStable(prefs, func(a, b int) bool { return prefs[a].q > prefs[b].q })
+	return prefs[0].locale
+}
+
+type ctxKey struct{}
+
+// WithLocale returns ctx carrying locale.
+func WithLocale(ctx context.Context, locale string) context.Context {
+	return context.WithValue(ctx, ctxKey{}, locale)
+}
+
+// FromContext returns the locale ctx carries, or Default.
+func FromContext(ctx context.Context) string {
+	if locale, ok := ctx.Value(ctxKey{}).(string); ok && locale != "" {
+		return locale
+	}
+	return Default()
+}
+
+// T returns the text of key in locale, formatted with args, falling back to
+// the default locale and then to the key itself.
+func T(locale, key string, args ...any) string {
+	texts, ok := messages[key]
+	if !ok {
+		return key
+	}
+	text, ok := texts[locale]
+	if !ok {
+		text = texts[Default()]
+	}
+	if len(args) > 0 {
+		return fmt.Sprintf(text, args...)
+	}
+	return text
+}
+
+// Is reports whether text is the text of key in any locale, e.g. to
+// recognise a stored placeholder reply.
+func Is(key, text string) bool {
+	for _, t := range messages[key] {
+		if t == text {
+			return true
+		}
+	}
+	return false
+}
+
+// Error translates the English API error message msg to locale. It is
+// returned unchanged for English, for "" (the client stated no locale) and
+// for messages without a translation.
+func Error(locale, msg string) string {
+	if locale == "" || locale == EN {
+		return msg
+	}
+	if t, ok := errorMessages[locale][msg]; ok {
+		return t
+	}
+	return msg
+}
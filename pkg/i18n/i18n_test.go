@@ -0,0 +1,52 @@
+package i18n
+
+import "testing"
+
+func TestNegotiate(t *testing.T) {
+	for header, want := range map[string]string{
+		"":                          "",
+		"en-US,en;q=0.9":            EN,
+		"id-ID,id;q=0.9,en;q=0.8":   ID,
+		"fr-FR, en;q=0.5, id;q=0.7": ID,
+		"in":                        ID,
+		"de, fr;q=0.8":              "",
+		"en;q=0, id;q=0.1":          ID,
+		"EN-gb":                     EN,
+		"en;q=abc, id":              ID,
+	} {
+		if got := Negotiate(header); got != want {
+			t.Errorf("Negotiate(%q) = %q, want %q", header, got, want)
+		}
+	}
+}
+
+func TestTexts(t *testing.T) {
+	for key, texts := range messages {
+		for _, locale := range Supported {
+			if texts[locale] == "" {
+				t.Errorf("%s has no %s text", key, locale)
+			}
+		}
+	}
+	if got := T(EN, TranscriptSubject, "Webinar AI"); got != "AkuAI conversation transcript: Webinar AI" {
+		t.Fatalf("T = %q", got)
+	}
+	if got := T("fr", NoAnswer); got != messages[NoAnswer][Default()] {
+		t.Fatalf("unsupported locale = %q", got)
+	}
+	if !Is(NoAnswer, "Maaf, belum ada jawaban.") || !Is(NoAnswer, "Sorry, there is no answer yet.") || Is(NoAnswer, "Halo") {
+		t.Fatal("Is does not recognise the no-answer texts")
+	}
+
+	if got := Error(ID, "conversation not found"); got != "percakapan tidak ditemukan" {
+		t.Fatalf("Error(id) = %q", got)
+	}
+	for _, locale := range []string{"", EN} {
+		if got := Error(locale, "conversation not found"); got != "conversation not found" {
+			t.Fatalf("Error(%q) = %q", locale, got)
+		}
+	}
+	if got := Error(ID, "some new message"); got != "some new message" {
+		t.Fatalf("untranslated = %q", got)
+	}
+}
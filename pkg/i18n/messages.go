@@ -0,0 +1,98 @@
+package i18n
+
+// Keys of the texts the server writes itself.
+const (
+	NoAnswer          = "chat.no_answer"
+	ServiceDegraded   = "service.degraded_local"
+	ServiceMock       = "service.mock"
+	ServiceOK         = "service.ok"
+	TranscriptTitle   = "transcript.untitled"
+	TranscriptSubject = "transcript.subject"
+	TranscriptMail    = "transcript.body"
+	SummarizeRequest  = "chat.summarize_request"
+)
+
+var messages = map[string]map[string]string{
+	NoAnswer: {
+		ID: "Maaf, belum ada jawaban.",
+		EN: "Sorry, there is no answer yet.",
+	},
+	ServiceDegraded: {
+		ID: "Asisten AI sedang mengalami gangguan. Jawaban sementara disusun dari data lokal dan bisa kurang lengkap.",
+		EN: "The AI assistant is having problems. Answers are put together from local data for now and may be incomplete.",
+	},
+	ServiceMock: {
+		ID: "Asisten AI sedang dalam mode uji coba. Jawaban bukan dari model AI.",
+		EN: "The AI assistant is in test mode. Answers don't come from the AI model.",
+	},
+	ServiceOK: {
+		ID: "Asisten AI kembali normal.",
+		EN: "The AI assistant is back to normal.",
+	},
+	SummarizeRequest: {
+		ID: "Ringkas jawaban terakhir.",
+		EN: "Summarize the last answer.",
+	},
+	TranscriptTitle: {
+		ID: "Percakapan",
+		EN: "Conversation",
+	},
+	TranscriptSubject: {
+		ID: "Transkrip percakapan AkuAI: %s",
+		EN: "AkuAI conversation transcript: %s",
+	},
+	TranscriptMail: {
+		ID: "Halo %s,\n\nTerlampir transkrip percakapan \"%s\" yang Anda minta dari AkuAI.\n\nEmail ini dikirim karena ada permintaan dari akun Anda.",
+		EN: "Hi %s,\n\nAttached is the transcript of the conversation \"%s\" you requested from AkuAI.\n\nThis email was sent because it was requested from your account.",
+	},
+}
+
+// errorMessages translate the API error messages clients see most.
+var errorMessages = map[string]map[string]string{
+	ID: {
+		"db error":                                        "kesalahan basis data",
+		"conversation not found":                          "percakapan tidak ditemukan",
+		"conversation not found in trash":                 "percakapan tidak ditemukan di tempat sampah",
+		"message not found":                               "pesan tidak ditemukan",
+		"folder not found":                                "folder tidak ditemukan",
+		"folder already exists":                           "folder sudah ada",
+		"webhook not found":                               "webhook tidak ditemukan",
+		"registration not found":                          "pendaftaran tidak ditemukan",
+		"image not found":                                 "gambar tidak ditemukan",
+		"delivery not found":                              "pengiriman tidak ditemukan",
+		"User not found":                                  "Pengguna tidak ditemukan",
+		"Not found":                                       "Tidak ditemukan",
+		"Not registered for this event":                   "Tidak terdaftar di acara ini",
+		"invalid id":                                      "id tidak valid",
+		"invalid signature":                               "tanda tangan tidak valid",
+		"request body must be valid JSON":                 "isi permintaan harus JSON yang valid",
+		"Email already exists":                            "Email sudah terdaftar",
+		"Username already exists":                         "Nama pengguna sudah dipakai",
+		"Invalid credentials":                             "Email atau kata sandi salah",
+		"failed to set password":                          "gagal mengatur kata sandi",
+		"failed to update profile":                        "gagal memperbarui profil",
+		"Failed to update profile":                        "Gagal memperbarui profil",
+		"failed to save message":                          "gagal menyimpan pesan",
+		"failed to save bot reply":                        "gagal menyimpan jawaban",
+		"failed to load messages":                         "gagal memuat pesan",
+		"failed to create conversation":                   "gagal membuat percakapan",
+		"failed to update conversation":                   "gagal memperbarui percakapan",
+		"failed to delete conversation":                   "gagal menghapus percakapan",
+		"failed to restore conversation":                  "gagal memulihkan percakapan",
+		"duplicate message":                               "pesan ganda",
+		"limit must be between 1 and 200":                 "limit harus antara 1 dan 200",
+		"limit must be between 1 and 20":                  "limit harus antara 1 dan 20",
+		"limit must be a positive integer":                "limit harus bilangan bulat positif",
+		"from must be YYYY-MM-DD":                         "from harus berformat YYYY-MM-DD",
+		"emoji must be a single emoji":                    "emoji harus satu emoji",
+		"job queue is full, try again later":              "antrean pekerjaan penuh, coba lagi nanti",
+		"stream expired, reload the conversation instead": "stream kedaluwarsa, muat ulang percakapan",
+		"email is not configured":                         "email belum dikonfigurasi",
+		"the email could not be sent, try again later":    "email gagal dikirim, coba lagi nanti",
+		"language must be id or en":                       "language harus id atau en",
+		"verbosity must be short, normal or detailed":     "verbosity harus short, normal, atau detailed",
+		"failed to update preferences":                    "gagal memperbarui preferensi",
+		"conversation has no reply to summarize":          "percakapan belum punya jawaban untuk diringkas",
+		"failed to summarize the reply, try again later":  "gagal meringkas jawaban, coba lagi nanti",
+	},
+}
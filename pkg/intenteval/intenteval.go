@@ -0,0 +1,128 @@
+// Package intenteval scores a query classifier, typically
+// UIBEventService.AnalyzeQueryForUIB, against a labeled corpus of queries
+// (testdata/intent_corpus.json). It backs both the regression test and
+// cmd/intenteval.
+package intenteval
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+)
+
+// DefaultCorpus is the corpus path relative to the repository root.
+const DefaultCorpus = "testdata/intent_corpus.json"
+
+// Case is one labeled query. UIB is whether the query should get the event
+// context; Tag groups similar queries (webinar, academic, greeting, ...).
+type Case struct {
+	Query string `json:"q"`
+	UIB   bool   `json:"uib"`
+	Tag   string `json:"tag"`
+}
+
+// Load reads a corpus file.
+func Load(path string) ([]Case, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var cases []Case
+	if err := json.Unmarshal(data, &cases); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return cases, nil
+}
+
+// Counts is a confusion matrix, UIB being the positive class.
+type Counts struct {
+	TP int `json:"tp"`
+	FP int `json:"fp"`
+	FN int `json:"fn"`
+	TN int `json:"tn"`
+}
+
+func (c Counts) N() int { return c.TP + c.FP + c.FN + c.TN }
+
+// Precision is TP/(TP+FP), 1 when nothing was predicted positive.
+func (c Counts) Precision() float64 { return ratio(c.TP, c.TP+c.FP) }
+
+// Recall is TP/(TP+FN), 1 when there are no positives.
+func (c Counts) Recall() float64 { return ratio(c.TP, c.TP+c.FN) }
+
+func (c Counts) Accuracy() float64 { return ratio(c.TP+c.TN, c.N()) }
+
+func (c Counts) F1() float64 {
+	p, r := c.Precision(), c.Recall()
+	if p+r == 0 {
+		return 0
+	}
+	return 2 * p * r / (p + r)
+}
+
+func ratio(a, b int) float64 {
+	if b == 0 {
+		return 1
+	}
+	return float64(a) / float64(b)
+}
+
+func (c *Counts) add(want, got bool) {
+	switch {
+	case want && got:
+		c.TP++
+	case !want && got:
+		c.FP++
+	case want:
+		c.FN++
+	default:
+		c.TN++
+	}
+}
+
+// Miss is a misclassified case.
+type Miss struct {
+	Case
+	Got bool `json:"got"`
+}
+
+// TagCounts is the confusion matrix of one tag.
+type TagCounts struct {
+	Tag string `json:"tag"`
+	Counts
+}
+
+// Report is the result of Evaluate.
+type Report struct {
+	Counts
+	Precision float64     `json:"precision"`
+	Recall    float64     `json:"recall"`
+	F1        float64     `json:"f1"`
+	Accuracy  float64     `json:"accuracy"`
+	ByTag     []TagCounts `json:"by_tag"`
+	Misses    []Miss      `json:"misses"`
+}
+
+// Evaluate runs classify on every case.
+func Evaluate(cases []Case, classify func(query string) bool) Report {
+	var r Report
+	byTag := map[string]*Counts{}
+	for _, c := range cases {
+		got := classify(c.Query)
+		r.add(c.UIB, got)
+		if byTag[c.Tag] == nil {
+			byTag[c.Tag] = &Counts{}
+		}
+		byTag[c.Tag].add(c.UIB, got)
+		if got != c.UIB {
+			r.Misses = append(r.Misses, Miss{Case: c, Got: got})
+		}
+	}
+	r.Precision, r.Recall, r.F1, r.Accuracy = r.Counts.Precision(), r.Counts.Recall(), r.Counts.F1(), r.Counts.Accuracy()
+	for tag, c := range byTag {
+		r.ByTag = append(r.ByTag, TagCounts{Tag: tag, Counts: *c})
+	}
+	sort.Slice(r.ByTag, func(i, j int) bool { return r.ByTag[i].Tag < r.ByTag[j].Tag })
+	return r
+}
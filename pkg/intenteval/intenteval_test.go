@@ -0,0 +1,55 @@
+package intenteval
+
+import (
+	svc "AkuAI/pkg/services"
+	"testing"
+)
+
+// strictTags must be classified without a single miss: they pin down
+// regressions that were fixed (bare numbers taken for months, greetings and
+// other campuses routed to the UIB context).
+var strictTags = map[string]bool{"month_numeric": true, "numeric_false_positive": true, "greeting": true, "other_campus": true}
+
+func TestAnalyzeQueryForUIBCorpus(t *testing.T) {
+	// data/ and testdata/ paths are relative to the repository root
+	t.Chdir("../..")
+	cases, err := Load(DefaultCorpus)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cases) < 200 {
+		t.Fatalf("corpus has %d queries, want at least 200", len(cases))
+	}
+	campuses, err := svc.NewCampusDataService()
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := Evaluate(cases, func(q string) bool {
+		_, ds := campuses.ForQuery(q)
+		return ds != nil && ds.AnalyzeQueryForUIB(q)
+	})
+	t.Logf("precision=%.3f recall=%.3f f1=%.3f over %d queries", r.Precision, r.Recall, r.F1, r.N())
+
+	for _, m := range r.Misses {
+		if strictTags[m.Tag] {
+			t.Errorf("[%s] %q classified as uib=%v", m.Tag, m.Query, m.Got)
+		}
+	}
+	if r.Precision < 0.95 || r.Recall < 0.9 {
+		t.Errorf("precision %.3f (min 0.95), recall %.3f (min 0.90)", r.Precision, r.Recall)
+		for _, m := range r.Misses {
+			t.Logf("[%s] %q: got uib=%v", m.Tag, m.Query, m.Got)
+		}
+	}
+}
+
+func TestEvaluateCounts(t *testing.T) {
+	cases := []Case{{"a", true, "x"}, {"b", true, "x"}, {"c", false, "y"}, {"d", false, "y"}}
+	r := Evaluate(cases, func(q string) bool { return q == "a" || q == "c" })
+	if r.TP != 1 || r.FN != 1 || r.FP != 1 || r.TN != 1 || r.Precision != 0.5 || r.Recall != 0.5 || len(r.Misses) != 2 {
+		t.Fatalf("report %+v", r)
+	}
+	if len(r.ByTag) != 2 || r.ByTag[0].Tag != "x" || r.ByTag[0].TP != 1 || r.ByTag[0].FN != 1 {
+		t.Fatalf("by tag %+v", r.ByTag)
+	}
+}
@@ -0,0 +1,245 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type Status string
+
+const (
+	StatusQueued  Status = "queued"
+	StatusRunning Status = "running"
+	StatusDone    Status = "done"
+	StatusFailed  Status = "failed"
+)
+
+var ErrQueueFull = errors.New("job queue is full")
+
+// ErrRequeue is returned by a job that can't run yet, such as one whose user
+// has no free slot. It goes back in the queue after requeueDelay instead of
+// holding a worker, and fails once it has waited the queue's timeout.
+var ErrRequeue = errors.New("job can't run yet")
+
+const requeueDelay = 250 * time.Millisecond
+
+// Func is the unit of work executed by a worker. The returned value becomes
+// the job result and must be JSON-serialisable.
+type Func func(ctx context.Context) (any, error)
+
+type Job struct {
+	ID         string     `json:"id"`
+	UserID     string     `json:"-"`
+	Kind       string     `json:"kind"`
+	Status     Status     `json:"status"`
+	Result     any        `json:"result,omitempty"`
+	Error      string     `json:"error,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+	StartedAt  *time.Time `json:"started_at,omitempty"`
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
+}
+
+func (j *Job) finished() bool {
+	return j.Status == StatusDone || j.Status == StatusFailed
+}
+
+type task struct {
+	id string
+	fn Func
+}
+
+// Queue is an in-memory job queue drained by a fixed worker pool. Finished
+// jobs are kept for the retention period so clients can poll for results.
+type Queue struct {
+	mu        sync.RWMutex
+	jobs      map[string]*Job
+	subs      map[string]map[chan Job]struct{}
+	tasks     chan task
+	timeout   time.Duration
+	retention time.Duration
+}
+
+var (
+	defaultQueue *Queue
+	once         sync.Once
+)
+
+// Start creates the default queue. Calls after the first one are ignored.
+func Start(workers, size int, timeout, retention time.Duration) *Queue {
+	once.Do(func() {
+		defaultQueue = NewQueue(workers, size, timeout, retention)
+	})
+	return defaultQueue
+}
+
+func Default() *Queue {
+	return Start(4, 100, 90*time.Second, time.Hour)
+}
+
+func NewQueue(workers, size int, timeout, retention time.Duration) *Queue {
+	if workers <= 0 {
+		workers = 1
+	}
+	if size <= 0 {
+		size = 1
+	}
+	q := &Queue{
+		jobs:      make(map[string]*Job),
+		subs:      make(map[string]map[chan Job]struct{}),
+		tasks:     make(chan task, size),
+		timeout:   timeout,
+		retention: retention,
+	}
+	for i := 0; i < workers; i++ {
+		go q.worker()
+	}
+	go q.janitor(time.Minute)
+	log.Printf("[jobs] queue started workers=%d size=%d timeout=%v", workers, size, timeout)
+	return q
+}
+
+// Submit enqueues fn for userID and returns a snapshot of the queued job.
+func (q *Queue) Submit(userID, kind string, fn Func) (Job, error) {
+	job := &Job{
+		ID:        uuid.NewString(),
+		UserID:    userID,
+		Kind:      kind,
+		Status:    StatusQueued,
+		CreatedAt: time.Now(),
+	}
+	q.mu.Lock()
+	q.jobs[job.ID] = job
+	snapshot := *job
+	q.mu.Unlock()
+
+	select {
+	case q.tasks <- task{id: job.ID, fn: fn}:
+		return snapshot, nil
+	default:
+		q.mu.Lock()
+		delete(q.jobs, job.ID)
+		q.mu.Unlock()
+		return Job{}, ErrQueueFull
+	}
+}
+
+// Get returns the job only when it belongs to userID.
+func (q *Queue) Get(id, userID string) (Job, bool) {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+	job, ok := q.jobs[id]
+	if !ok || job.UserID != userID {
+		return Job{}, false
+	}
+	return *job, true
+}
+
+// Subscribe delivers finished jobs of userID until the returned cancel func is called.
+func (q *Queue) Subscribe(userID string) (<-chan Job, func()) {
+	ch := make(chan Job, 16)
+	q.mu.Lock()
+	if q.subs[userID] == nil {
+		q.subs[userID] = make(map[chan Job]struct{})
+	}
+	q.subs[userID][ch] = struct{}{}
+	q.mu.Unlock()
+
+	var unsubOnce sync.Once
+	return ch, func() {
+		unsubOnce.Do(func() {
+			q.mu.Lock()
+			delete(q.subs[userID], ch)
+			if len(q.subs[userID]) == 0 {
+				delete(q.subs, userID)
+			}
+			q.mu.Unlock()
+		})
+	}
+}
+
+func (q *Queue) worker() {
+	for t := range q.tasks {
+		q.run(t)
+	}
+}
+
+func (q *Queue) run(t task) {
+	now := time.Now()
+	q.mu.Lock()
+	job, ok := q.jobs[t.id]
+	if ok {
+		job.Status = StatusRunning
+		job.StartedAt = &now
+	}
+	q.mu.Unlock()
+	if !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), q.timeout)
+	result, err := safeCall(ctx, t.fn)
+	cancel()
+
+	if errors.Is(err, ErrRequeue) {
+		if time.Since(job.CreatedAt) < q.timeout {
+			q.mu.Lock()
+			job.Status, job.StartedAt = StatusQueued, nil
+			q.mu.Unlock()
+			time.AfterFunc(requeueDelay, func() { q.tasks <- t })
+			return
+		}
+		err = errors.New("timed out waiting to run")
+	}
+
+	finished := time.Now()
+	q.mu.Lock()
+	job.FinishedAt = &finished
+	if err != nil {
+		job.Status = StatusFailed
+		job.Error = err.Error()
+	} else {
+		job.Status = StatusDone
+		job.Result = result
+	}
+	snapshot := *job
+	for ch := range q.subs[job.UserID] {
+		select {
+		case ch <- snapshot:
+		default:
+			log.Printf("[jobs] subscriber for user=%s is slow, dropping event for job=%s", job.UserID, job.ID)
+		}
+	}
+	q.mu.Unlock()
+
+	log.Printf("[jobs] job=%s kind=%s status=%s took=%v", job.ID, job.Kind, snapshot.Status, finished.Sub(now).Round(time.Millisecond))
+}
+
+func safeCall(ctx context.Context, fn Func) (result any, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("[jobs] ❌ job panicked: %v", r)
+			err = errors.New("job panicked")
+		}
+	}()
+	return fn(ctx)
+}
+
+func (q *Queue) janitor(interval time.Duration) {
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for range t.C {
+		cutoff := time.Now().Add(-q.retention)
+		q.mu.Lock()
+		for id, job := range q.jobs {
+			if job.finished() && job.FinishedAt.Before(cutoff) {
+				delete(q.jobs, id)
+			}
+		}
+		q.mu.Unlock()
+	}
+}
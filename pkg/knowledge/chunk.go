@@ -0,0 +1,66 @@
+package knowledge
+
+import (
+	"strings"
+)
+
+// Chunk is one indexed passage of a document.
+type Chunk struct {
+	Heading string
+	Text    string
+}
+
+// ChunkText splits text into passages of roughly maxChars, breaking at
+// Markdown headings first and paragraphs second. Each chunk keeps the
+// nearest heading so a passage read on its own still has its topic.
+func ChunkText(text string, maxChars int) []Chunk {
+	if maxChars <= 0 {
+		maxChars = 800
+	}
+	var chunks []Chunk
+	heading := ""
+	var buf strings.Builder
+	flush := func() {
+		if t := strings.TrimSpace(buf.String()); t != "" {
+			chunks = append(chunks, Chunk{Heading: heading, Text: t})
+		}
+		buf.Reset()
+	}
+
+	for _, para := range strings.Split(text, "\n\n") {
+		para = strings.TrimSpace(para)
+		if para == "" {
+			continue
+		}
+		if strings.HasPrefix(para, "#") {
+			flush()
+			line, rest, _ := strings.Cut(para, "\n")
+			heading = strings.TrimSpace(strings.TrimLeft(line, "#"))
+			if para = strings.TrimSpace(rest); para == "" {
+				continue
+			}
+		}
+		for len(para) > maxChars {
+			cut := strings.LastIndexAny(para[:maxChars], ".\n")
+			if cut < maxChars/2 {
+				cut = strings.LastIndex(para[:maxChars], " ")
+			}
+			if cut <= 0 {
+				cut = maxChars - 1
+			}
+			flush()
+			buf.WriteString(para[:cut+1])
+			flush()
+			para = strings.TrimSpace(para[cut+1:])
+		}
+		if buf.Len() > 0 && buf.Len()+len(para) > maxChars {
+			flush()
+		}
+		if buf.Len() > 0 {
+			buf.WriteString("\n\n")
+		}
+		buf.WriteString(para)
+	}
+	flush()
+	return chunks
+}
@@ -0,0 +1,92 @@
+package knowledge
+
+import (
+	"bytes"
+	"compress/zlib"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+var (
+	ErrUnsupportedType = errors.New("unsupported document type (use .md, .markdown, .txt or .pdf)")
+	ErrNoText          = errors.New("no extractable text found in document")
+)
+
+// SupportedExt reports whether filename has an extension ExtractText handles.
+func SupportedExt(filename string) bool {
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".md", ".markdown", ".txt", ".pdf":
+		return true
+	}
+	return false
+}
+
+// ExtractText returns the plain text of a Markdown, text or PDF document.
+func ExtractText(filename string, data []byte) (string, error) {
+	var text string
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".md", ".markdown", ".txt":
+		if !utf8.Valid(data) {
+			return "", fmt.Errorf("%s is not valid UTF-8", filename)
+		}
+		text = string(data)
+	case ".pdf":
+		text = extractPDFText(data)
+	default:
+		return "", ErrUnsupportedType
+	}
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	if strings.TrimSpace(text) == "" {
+		return "", ErrNoText
+	}
+	return text, nil
+}
+
+var (
+	pdfStreamRe  = regexp.MustCompile(`(?s)stream\r?\n(.*?)\r?\nendstream`)
+	pdfTextOpRe  = regexp.MustCompile(`(?s)\[(.*?)\]\s*TJ|\((.*?[^\\])\)\s*(?:Tj|'|")|(T\*|Td|TD|ET)`)
+	pdfStringsRe = regexp.MustCompile(`\((.*?[^\\])\)`)
+)
+
+// extractPDFText pulls text out of the page content streams of simple,
+// text-based PDFs (uncompressed or Flate). Scanned PDFs and custom font
+// encodings yield little or nothing; convert those to Markdown first.
+func extractPDFText(data []byte) string {
+	var out strings.Builder
+	for _, m := range pdfStreamRe.FindAllSubmatch(data, -1) {
+		content := m[1]
+		if r, err := zlib.NewReader(bytes.NewReader(content)); err == nil {
+			if inflated, err := io.ReadAll(r); err == nil {
+				content = inflated
+			}
+			r.Close()
+		}
+		if !bytes.Contains(content, []byte("BT")) {
+			continue
+		}
+		for _, op := range pdfTextOpRe.FindAllSubmatch(content, -1) {
+			switch {
+			case op[1] != nil:
+				for _, s := range pdfStringsRe.FindAllSubmatch(op[1], -1) {
+					out.WriteString(unescapePDF(s[1]))
+				}
+			case op[2] != nil:
+				out.WriteString(unescapePDF(op[2]))
+			default:
+				out.WriteString("\n")
+			}
+		}
+		out.WriteString("\n")
+	}
+	return out.String()
+}
+
+func unescapePDF(b []byte) string {
+	r := strings.NewReplacer(`\n`, "\n", `\r`, "", `\t`, " ", `\(`, "(", `\)`, ")", `\\`, `\`)
+	return r.Replace(string(b))
+}
@@ -0,0 +1,213 @@
+package knowledge
+
+import (
+	"AkuAI/models"
+	"fmt"
+	"log"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+
+	"gorm.io/gorm"
+)
+
+// Hit is a chunk matching a query.
+type Hit struct {
+	DocumentID uint    `json:"document_id"`
+	ChunkID    uint    `json:"chunk_id"`
+	Seq        int     `json:"seq"`
+	Title      string  `json:"title"`
+	Heading    string  `json:"heading,omitempty"`
+	Text       string  `json:"text"`
+	Score      float64 `json:"score"`
+}
+
+// Marker is the inline citation marker for the hit, e.g. DOC-3-2.
+func (h Hit) Marker() string { return CitationMarker(h.DocumentID, h.Seq) }
+
+func CitationMarker(documentID uint, seq int) string {
+	return fmt.Sprintf("DOC-%d-%d", documentID, seq)
+}
+
+type indexedChunk struct {
+	hit   Hit
+	terms map[string]int
+	size  int
+}
+
+// Index is an in-memory BM25 index over all document chunks. It is small
+// enough (FAQ-sized corpora) to rebuild from the database after every change.
+type Index struct {
+	db *gorm.DB
+
+	mu     sync.RWMutex
+	chunks []indexedChunk
+	df     map[string]int
+	avgLen float64
+}
+
+var (
+	defaultMu    sync.RWMutex
+	defaultIndex *Index
+)
+
+// Init loads all chunks from db and installs the index returned by Default.
+func Init(db *gorm.DB) *Index {
+	idx := &Index{db: db}
+	if err := idx.Reload(); err != nil {
+		log.Printf("[knowledge] ⚠️ failed to load documents: %v", err)
+	}
+	defaultMu.Lock()
+	defaultIndex = idx
+	defaultMu.Unlock()
+	return idx
+}
+
+// Default returns the index installed by Init, or nil.
+func Default() *Index {
+	defaultMu.RLock()
+	defer defaultMu.RUnlock()
+	return defaultIndex
+}
+
+// Reload rebuilds the index from the documents table.
+func (x *Index) Reload() error {
+	var rows []struct {
+		models.DocumentChunk
+		Title string
+	}
+	err := x.db.Model(&models.DocumentChunk{}).
+		Select("document_chunks.*, documents.title AS title").
+		Joins("JOIN documents ON documents.id = document_chunks.document_id AND documents.deleted_at IS NULL").
+		Order("document_chunks.document_id, document_chunks.seq").
+		Scan(&rows).Error
+	if err != nil {
+		return err
+	}
+
+	chunks := make([]indexedChunk, 0, len(rows))
+	df := map[string]int{}
+	total := 0
+	for _, r := range rows {
+		terms := map[string]int{}
+		size := 0
+		for _, t := range tokenize(r.Heading + " " + r.Text) {
+			terms[t]++
+			size++
+		}
+		for t := range terms {
+			df[t]++
+		}
+		total += size
+		chunks = append(chunks, indexedChunk{
+			hit:   Hit{DocumentID: r.DocumentID, ChunkID: r.ID, Seq: r.Seq, Title: r.Title, Heading: r.Heading, Text: r.Text},
+			terms: terms,
+			size:  size,
+		})
+	}
+
+	x.mu.Lock()
+	x.chunks, x.df = chunks, df
+	x.avgLen = 0
+	if len(chunks) > 0 {
+		x.avgLen = float64(total) / float64(len(chunks))
+	}
+	x.mu.Unlock()
+	log.Printf("[knowledge] indexed %d chunks", len(chunks))
+	return nil
+}
+
+// Search returns up to k chunks scoring at least minScore for query.
+func (x *Index) Search(query string, k int, minScore float64) []Hit {
+	if x == nil {
+		return nil
+	}
+	terms := tokenize(query)
+	x.mu.RLock()
+	defer x.mu.RUnlock()
+	if len(terms) == 0 || len(x.chunks) == 0 {
+		return nil
+	}
+
+	const k1, b = 1.2, 0.75
+	n := float64(len(x.chunks))
+	var hits []Hit
+	for _, c := range x.chunks {
+		score := 0.0
+		for _, t := range terms {
+			tf := float64(c.terms[t])
+			if tf == 0 {
+				continue
+			}
+			df := float64(x.df[t])
+			idf := math.Log(1 + (n-df+0.5)/(df+0.5))
+			score += idf * tf * (k1 + 1) / (tf + k1*(1-b+b*float64(c.size)/x.avgLen))
+		}
+		if score >= minScore {
+			h := c.hit
+			h.Score = math.Round(score*100) / 100
+			hits = append(hits, h)
+		}
+	}
+	sort.SliceStable(hits, func(i, j int) bool { return hits[i].Score > hits[j].Score })
+	if k > 0 && len(hits) > k {
+		hits = hits[:k]
+	}
+	return hits
+}
+
+// Lookup resolves a DOC-x-y marker to its chunk.
+func (x *Index) Lookup(marker string) (Hit, bool) {
+	if x == nil {
+		return Hit{}, false
+	}
+	x.mu.RLock()
+	defer x.mu.RUnlock()
+	for _, c := range x.chunks {
+		if c.hit.Marker() == marker {
+			return c.hit, true
+		}
+	}
+	return Hit{}, false
+}
+
+// FormatContext renders hits for the Gemini prompt, each tagged with its
+// citation marker.
+func FormatContext(hits []Hit) string {
+	if len(hits) == 0 {
+		return ""
+	}
+	var b strings.Builder
+	b.WriteString("=== DOKUMEN RESMI UIB (FAQ/PANDUAN) ===\n")
+	for _, h := range hits {
+		b.WriteString(fmt.Sprintf("\n📄 %s", h.Title))
+		if h.Heading != "" {
+			b.WriteString(" — " + h.Heading)
+		}
+		b.WriteString(fmt.Sprintf("\n   🔖 Sumber: [%s]\n", h.Marker()))
+		b.WriteString(h.Text + "\n")
+	}
+	b.WriteString("\nSITASI: kalimat yang memakai isi dokumen di atas WAJIB diakhiri penanda 🔖 Sumber-nya, misalnya [DOC-1-0]\n")
+	b.WriteString("=== AKHIR DOKUMEN ===\n")
+	return b.String()
+}
+
+var stopwords = map[string]bool{
+	"yang": true, "dan": true, "atau": true, "di": true, "ke": true, "dari": true, "untuk": true, "dengan": true,
+	"apa": true, "apakah": true, "bagaimana": true, "cara": true, "ini": true, "itu": true, "ada": true, "saja": true,
+	"the": true, "and": true, "for": true, "how": true, "what": true, "uib": true, "kampus": true, "saya": true,
+}
+
+func tokenize(s string) []string {
+	var out []string
+	for _, f := range strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}) {
+		if len(f) >= 3 && !stopwords[f] {
+			out = append(out, f)
+		}
+	}
+	return out
+}
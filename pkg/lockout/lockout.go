@@ -0,0 +1,262 @@
+// Package lockout protects login against password guessing. It counts failed
+// attempts per account and per client IP, locks a key out for a doubling
+// time after too many failures, and tells when the next attempt of an
+// account needs a CAPTCHA.
+package lockout
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Policy sets the thresholds. A zero count disables that check.
+type Policy struct {
+	MaxFailures   int // failures of one account before it is locked
+	IPMaxFailures int // failures from one IP, over all accounts, before it is locked
+
+	// The first lockout of a key lasts BaseLockout; each further one without
+	// a successful login in between doubles, up to MaxLockout.
+	BaseLockout time.Duration
+	MaxLockout  time.Duration
+
+	// CaptchaAfter failures of an account make its next attempts require a
+	// CAPTCHA, when a CaptchaVerifier is set.
+	CaptchaAfter int
+
+	// A key with no failure for ResetAfter starts over.
+	ResetAfter time.Duration
+}
+
+// DefaultPolicy is used by Default when Start was not called.
+var DefaultPolicy = Policy{
+	MaxFailures:   5,
+	IPMaxFailures: 20,
+	BaseLockout:   time.Minute,
+	MaxLockout:    time.Hour,
+	CaptchaAfter:  3,
+	ResetAfter:    24 * time.Hour,
+}
+
+// Kinds of Lockout keys.
+const (
+	KindAccount = "account"
+	KindIP      = "ip"
+)
+
+// Lockout is the failure state of one account or IP.
+type Lockout struct {
+	Kind        string    `json:"kind"`
+	Key         string    `json:"key"`
+	Failures    int       `json:"failures"` // since the last lockout
+	Lockouts    int       `json:"lockouts"` // in a row
+	LockedUntil time.Time `json:"locked_until,omitempty"`
+	LastFailure time.Time `json:"last_failure"`
+}
+
+type state struct {
+	failures    int
+	lockouts    int
+	lockedUntil time.Time
+	last        time.Time
+}
+
+// Guard keeps the failure state of accounts and IPs in memory.
+type Guard struct {
+	mu        sync.Mutex
+	policy    Policy
+	captcha   CaptchaVerifier
+	states    map[string]map[string]*state // kind -> key
+	lastSweep time.Time
+	now       func() time.Time
+}
+
+var (
+	defaultGuard *Guard
+	once         sync.Once
+)
+
+// Start creates the default guard. Calls after the first one are ignored.
+func Start(p Policy, captcha CaptchaVerifier) *Guard {
+	once.Do(func() {
+		defaultGuard = New(p, captcha)
+	})
+	return defaultGuard
+}
+
+func Default() *Guard {
+	return Start(DefaultPolicy, nil)
+}
+
+// New returns a guard enforcing p; captcha may be nil to never ask for one.
+func New(p Policy, captcha CaptchaVerifier) *Guard {
+	return &Guard{
+		policy:  p,
+		captcha: captcha,
+		states:  map[string]map[string]*state{KindAccount: {}, KindIP: {}},
+		now:     time.Now,
+	}
+}
+
+// AccountKey normalises an account name (the login email).
+func AccountKey(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+// get returns the live state of key, dropping it when it has gone quiet.
+// Callers hold g.mu.
+func (g *Guard) get(kind, key string, now time.Time) *state {
+	s := g.states[kind][key]
+	if s != nil && g.policy.ResetAfter > 0 && now.Sub(s.last) > g.policy.ResetAfter && !now.Before(s.lockedUntil) {
+		delete(g.states[kind], key)
+		return nil
+	}
+	return s
+}
+
+// Locked returns how long the account or the IP stays locked out, 0 when
+// neither is.
+func (g *Guard) Locked(account, ip string) time.Duration {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	now := g.now()
+	var wait time.Duration
+	for kind, key := range map[string]string{KindAccount: AccountKey(account), KindIP: ip} {
+		if s := g.get(kind, key, now); s != nil && s.lockedUntil.After(now) {
+			wait = max(wait, s.lockedUntil.Sub(now))
+		}
+	}
+	return wait
+}
+
+// CaptchaRequired reports whether the next attempt of account must pass a
+// CAPTCHA: after CaptchaAfter failures or any lockout, when a verifier is set.
+func (g *Guard) CaptchaRequired(account string) bool {
+	if g.captcha == nil || g.policy.CaptchaAfter <= 0 {
+		return false
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	s := g.get(KindAccount, AccountKey(account), g.now())
+	return s != nil && (s.failures >= g.policy.CaptchaAfter || s.lockouts > 0)
+}
+
+// VerifyCaptcha checks a CAPTCHA token; without a verifier it passes.
+func (g *Guard) VerifyCaptcha(ctx context.Context, token, ip string) (bool, error) {
+	if g.captcha == nil {
+		return true, nil
+	}
+	if token == "" {
+		return false, nil
+	}
+	return g.captcha.Verify(ctx, token, ip)
+}
+
+// Fail records a failed login of account from ip and returns the lockouts it
+// started.
+func (g *Guard) Fail(account, ip string) []Lockout {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	now := g.now()
+	g.sweep(now)
+	var started []Lockout
+	for _, k := range []struct {
+		kind, key string
+		max       int
+	}{{KindAccount, AccountKey(account), g.policy.MaxFailures}, {KindIP, ip, g.policy.IPMaxFailures}} {
+		if k.key == "" {
+			continue
+		}
+		s := g.get(k.kind, k.key, now)
+		if s == nil {
+			s = &state{}
+			g.states[k.kind][k.key] = s
+		}
+		s.failures++
+		s.last = now
+		if k.max > 0 && s.failures >= k.max && !s.lockedUntil.After(now) {
+			s.lockouts++
+			s.failures = 0
+			s.lockedUntil = now.Add(g.policy.duration(s.lockouts))
+			started = append(started, s.snapshot(k.kind, k.key))
+		}
+	}
+	return started
+}
+
+// duration is how long the n-th lockout in a row lasts.
+func (p Policy) duration(n int) time.Duration {
+	d := p.BaseLockout
+	for i := 1; i < n && (p.MaxLockout <= 0 || d < p.MaxLockout); i++ {
+		d *= 2
+	}
+	if p.MaxLockout > 0 && d > p.MaxLockout {
+		d = p.MaxLockout
+	}
+	return d
+}
+
+// Success clears the account's failures after a correct login. The IP keeps
+// its count, so an attacker can't reset it with an account of their own.
+func (g *Guard) Success(account string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	delete(g.states[KindAccount], AccountKey(account))
+}
+
+// Reset unlocks an account or IP and forgets its failures; it reports whether
+// there was anything to forget.
+func (g *Guard) Reset(kind, key string) bool {
+	if kind == KindAccount {
+		key = AccountKey(key)
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if _, ok := g.states[kind][key]; !ok {
+		return false
+	}
+	delete(g.states[kind], key)
+	return true
+}
+
+// Active returns the accounts and IPs with failures or a lockout, locked ones
+// first.
+func (g *Guard) Active() []Lockout {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	now := g.now()
+	out := []Lockout{}
+	for kind, keys := range g.states {
+		for key := range keys {
+			if s := g.get(kind, key, now); s != nil {
+				out = append(out, s.snapshot(kind, key))
+			}
+		}
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if !out[i].LockedUntil.Equal(out[j].LockedUntil) {
+			return out[i].LockedUntil.After(out[j].LockedUntil)
+		}
+		return out[i].LastFailure.After(out[j].LastFailure)
+	})
+	return out
+}
+
+func (s *state) snapshot(kind, key string) Lockout {
+	return Lockout{Kind: kind, Key: key, Failures: s.failures, Lockouts: s.lockouts, LockedUntil: s.lockedUntil, LastFailure: s.last}
+}
+
+// sweep forgets keys that have gone quiet. Callers hold g.mu.
+func (g *Guard) sweep(now time.Time) {
+	if now.Sub(g.lastSweep) < time.Minute {
+		return
+	}
+	g.lastSweep = now
+	for kind, keys := range g.states {
+		for key := range keys {
+			g.get(kind, key, now)
+		}
+	}
+}
@@ -0,0 +1,130 @@
+package lockout
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+type fakeCaptcha struct{}
+
+func (fakeCaptcha) Verify(_ context.Context, token, _ string) (bool, error) {
+	return token == "ok", nil
+}
+
+func TestAccountLockoutDoubles(t *testing.T) {
+	g := New(Policy{MaxFailures: 3, BaseLockout: time.Minute, MaxLockout: 3 * time.Minute}, nil)
+	now := time.Date(2026, 10, 15, 8, 0, 0, 0, time.UTC)
+	g.now = func() time.Time { return now }
+
+	for i := 0; i < 2; i++ {
+		if got := g.Fail("Ani@Mail.com", "1.1.1.1"); len(got) != 0 {
+			t.Fatalf("failure %d locked: %+v", i+1, got)
+		}
+	}
+	got := g.Fail("ani@mail.com ", "1.1.1.1")
+	if len(got) != 1 || got[0].Kind != KindAccount || got[0].Key != "ani@mail.com" {
+		t.Fatalf("third failure gave %+v, want an account lockout", got)
+	}
+	if wait := g.Locked("ANI@mail.com", "2.2.2.2"); wait != time.Minute {
+		t.Fatalf("locked for %v, want 1m", wait)
+	}
+
+	for _, want := range []time.Duration{2 * time.Minute, 3 * time.Minute, 3 * time.Minute} {
+		now = now.Add(time.Hour)
+		if g.Locked("ani@mail.com", "") != 0 {
+			t.Fatal("lockout did not expire")
+		}
+		for i := 0; i < 3; i++ {
+			g.Fail("ani@mail.com", "")
+		}
+		if wait := g.Locked("ani@mail.com", ""); wait != want {
+			t.Errorf("next lockout %v, want %v", wait, want)
+		}
+	}
+
+	g.Success("ani@mail.com")
+	if g.Locked("ani@mail.com", "") != 0 {
+		t.Error("success did not clear the lockout")
+	}
+}
+
+func TestIPLockoutAndReset(t *testing.T) {
+	g := New(Policy{MaxFailures: 10, IPMaxFailures: 3, BaseLockout: time.Minute, ResetAfter: time.Hour}, nil)
+	now := time.Date(2026, 10, 15, 8, 0, 0, 0, time.UTC)
+	g.now = func() time.Time { return now }
+
+	g.Fail("a@x.id", "9.9.9.9")
+	g.Fail("b@x.id", "9.9.9.9")
+	if got := g.Fail("c@x.id", "9.9.9.9"); len(got) != 1 || got[0].Kind != KindIP {
+		t.Fatalf("got %+v, want an IP lockout", got)
+	}
+	if g.Locked("new@x.id", "9.9.9.9") == 0 {
+		t.Fatal("IP not locked for other accounts")
+	}
+	g.Success("c@x.id")
+	if g.Locked("c@x.id", "9.9.9.9") == 0 {
+		t.Error("a successful login cleared the IP lockout")
+	}
+	if !g.Reset(KindIP, "9.9.9.9") || g.Locked("c@x.id", "9.9.9.9") != 0 {
+		t.Error("reset did not unlock the IP")
+	}
+	if g.Reset(KindIP, "9.9.9.9") {
+		t.Error("second reset reported state")
+	}
+
+	g.Fail("a@x.id", "")
+	now = now.Add(2 * time.Hour)
+	if n := len(g.Active()); n != 0 {
+		t.Errorf("%d entries still active after ResetAfter", n)
+	}
+}
+
+func TestCaptchaRequired(t *testing.T) {
+	p := Policy{MaxFailures: 5, CaptchaAfter: 2, BaseLockout: time.Minute}
+	off := New(p, nil)
+	off.Fail("a@x.id", "")
+	off.Fail("a@x.id", "")
+	if off.CaptchaRequired("a@x.id") {
+		t.Error("CAPTCHA required without a verifier")
+	}
+
+	g := New(p, fakeCaptcha{})
+	g.Fail("a@x.id", "")
+	if g.CaptchaRequired("a@x.id") {
+		t.Error("CAPTCHA required after one failure")
+	}
+	g.Fail("a@x.id", "")
+	if !g.CaptchaRequired("a@x.id") {
+		t.Error("CAPTCHA not required after two failures")
+	}
+	ctx := context.Background()
+	if ok, _ := g.VerifyCaptcha(ctx, "", ""); ok {
+		t.Error("empty token passed")
+	}
+	if ok, _ := g.VerifyCaptcha(ctx, "ok", ""); !ok {
+		t.Error("valid token rejected")
+	}
+}
+
+func TestSiteVerify(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		if r.Form.Get("secret") != "s3cret" || r.Form.Get("remoteip") != "1.2.3.4" {
+			w.Write([]byte(`{"success":false}`))
+			return
+		}
+		w.Write([]byte(`{"success":` + map[bool]string{true: "true", false: "false"}[r.Form.Get("response") == "good"] + `}`))
+	}))
+	defer srv.Close()
+
+	v := SiteVerify{URL: srv.URL, Secret: "s3cret"}
+	if ok, err := v.Verify(context.Background(), "good", "1.2.3.4"); !ok || err != nil {
+		t.Errorf("good token: %v %v", ok, err)
+	}
+	if ok, _ := v.Verify(context.Background(), "bad", "1.2.3.4"); ok {
+		t.Error("bad token passed")
+	}
+}
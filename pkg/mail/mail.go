@@ -0,0 +1,159 @@
+// Package mail sends plain-text email, optionally with attachments, through
+// an SMTP relay.
+package mail
+
+import (
+	"AkuAI/pkg/config"
+	"bytes"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/smtp"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Mailer sends email from one address through one SMTP server.
+type Mailer struct {
+	addr     string
+	host     string
+	auth     smtp.Auth
+	from     string
+	sendMail func(addr string, a smtp.Auth, from string, to []string, msg []byte) error
+}
+
+// New returns a Mailer for host:port. Without a username the relay is used
+// unauthenticated.
+func New(host string, port int, username, password, from string) *Mailer {
+	m := &Mailer{addr: net.JoinHostPort(host, strconv.Itoa(port)), host: host, from: from, sendMail: smtp.SendMail}
+	if username != "" {
+		m.auth = smtp.PlainAuth("", username, password, host)
+	}
+	return m
+}
+
+// Default returns the Mailer configured by SMTP_*, or nil when SMTP_HOST is
+// not set.
+func Default() *Mailer {
+	if config.SMTPHost == "" {
+		return nil
+	}
+	return New(config.SMTPHost, config.SMTPPort, config.SMTPUsername, config.SMTPPassword, config.SMTPFrom)
+}
+
+// Attachment is a file attached to a message.
+type Attachment struct {
+	Filename    string
+	ContentType string
+	Data        []byte
+}
+
+// Send mails body, plain text, to one recipient.
+func (m *Mailer) Send(to, subject, body string) error {
+	return m.SendWithAttachments(to, subject, body)
+}
+
+// SendWithAttachments mails body, plain text, with files attached to one
+// recipient.
+func (m *Mailer) SendWithAttachments(to, subject, body string, files ...Attachment) error {
+	if strings.ContainsAny(to+subject, "\r\n") {
+		return errors.New("mail: line break in header")
+	}
+	msg, err := compose(m.from, to, subject, body, time.Now(), files...)
+	if err != nil {
+		return err
+	}
+	if err := m.sendMail(m.addr, m.auth, m.from, []string{to}, msg); err != nil {
+		return fmt.Errorf("mail to %s: %w", to, err)
+	}
+	return nil
+}
+
+func compose(from, to, subject, body string, now time.Time, files ...Attachment) ([]byte, error) {
+	id := make([]byte, 12)
+	_, _ = rand.Read(id)
+	domain := "akuai.local"
+	if at := strings.LastIndex(from, "@"); at >= 0 {
+		domain = strings.Trim(from[at+1:], "> ")
+	}
+
+	var b bytes.Buffer
+	for _, h := range [][2]string{
+		{"From", from},
+		{"To", to},
+		{"Subject", mime.QEncoding.Encode("utf-8", subject)},
+		{"Date", now.Format(time.RFC1123Z)},
+		{"Message-ID", "<" + hex.EncodeToString(id) + "@" + domain + ">"},
+		{"MIME-Version", "1.0"},
+	} {
+		b.WriteString(h[0] + ": " + h[1] + "\r\n")
+	}
+	text := textproto.MIMEHeader{
+		"Content-Type":              {"text/plain; charset=UTF-8"},
+		"Content-Transfer-Encoding": {"quoted-printable"},
+	}
+	if len(files) == 0 {
+		for _, k := range []string{"Content-Type", "Content-Transfer-Encoding"} {
+			b.WriteString(k + ": " + text.Get(k) + "\r\n")
+		}
+		b.WriteString("\r\n")
+		if err := writeText(&b, body); err != nil {
+			return nil, err
+		}
+		return b.Bytes(), nil
+	}
+
+	mw := multipart.NewWriter(&b)
+	b.WriteString("Content-Type: multipart/mixed; boundary=" + mw.Boundary() + "\r\n\r\n")
+	part, err := mw.CreatePart(text)
+	if err != nil {
+		return nil, err
+	}
+	if err := writeText(part, body); err != nil {
+		return nil, err
+	}
+	for _, f := range files {
+		ctype, params, err := mime.ParseMediaType(f.ContentType)
+		if err != nil {
+			ctype, params = "application/octet-stream", map[string]string{}
+		}
+		params["name"] = f.Filename
+		part, err := mw.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {mime.FormatMediaType(ctype, params)},
+			"Content-Disposition":       {mime.FormatMediaType("attachment", map[string]string{"filename": f.Filename})},
+			"Content-Transfer-Encoding": {"base64"},
+		})
+		if err != nil {
+			return nil, err
+		}
+		// Base64 in lines of 76 characters, as RFC 2045 allows no longer.
+		enc := base64.StdEncoding.EncodeToString(f.Data)
+		for len(enc) > 76 {
+			fmt.Fprint(part, enc[:76]+"\r\n")
+			enc = enc[76:]
+		}
+		fmt.Fprint(part, enc+"\r\n")
+	}
+	if err := mw.Close(); err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
+}
+
+// writeText writes body quoted-printable, with CRLF line breaks.
+func writeText(w io.Writer, body string) error {
+	qp := quotedprintable.NewWriter(w)
+	if _, err := qp.Write([]byte(strings.ReplaceAll(body, "\n", "\r\n"))); err != nil {
+		return err
+	}
+	return qp.Close()
+}
@@ -0,0 +1,104 @@
+package mail
+
+import (
+	"encoding/base64"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"net/smtp"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSend(t *testing.T) {
+	m := New("smtp.example.com", 587, "", "", "AkuAI <noreply@akuai.example>")
+	var got []byte
+	var rcpt []string
+	m.sendMail = func(addr string, a smtp.Auth, from string, to []string, msg []byte) error {
+		if addr != "smtp.example.com:587" || a != nil {
+			t.Fatalf("addr=%s auth=%v", addr, a)
+		}
+		rcpt, got = to, msg
+		return nil
+	}
+	if err := m.Send("budi@example.com", "Ringkasan acara 📬", "Halo Budi,\nacara minggu ini: Webinar AI — gratis"); err != nil {
+		t.Fatal(err)
+	}
+	if len(rcpt) != 1 || rcpt[0] != "budi@example.com" {
+		t.Fatalf("recipients = %v", rcpt)
+	}
+	msg, err := mail.ReadMessage(strings.NewReader(string(got)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	subject, _ := new(mime.WordDecoder).DecodeHeader(msg.Header.Get("Subject"))
+	if subject != "Ringkasan acara 📬" {
+		t.Fatalf("subject = %q", subject)
+	}
+	if _, err := time.Parse(time.RFC1123Z, msg.Header.Get("Date")); err != nil {
+		t.Fatalf("date: %v", err)
+	}
+	body, _ := io.ReadAll(quotedprintable.NewReader(msg.Body))
+	if string(body) != "Halo Budi,\r\nacara minggu ini: Webinar AI — gratis" {
+		t.Fatalf("body = %q", body)
+	}
+
+	if err := m.Send("budi@example.com\r\nBcc: x@example.com", "hi", "x"); err == nil {
+		t.Fatal("header injection accepted")
+	}
+}
+
+func TestSendWithAttachments(t *testing.T) {
+	m := New("smtp.example.com", 587, "", "", "AkuAI <noreply@akuai.example>")
+	var got []byte
+	m.sendMail = func(addr string, a smtp.Auth, from string, to []string, msg []byte) error {
+		got = msg
+		return nil
+	}
+	transcript := strings.Repeat("**Anda** · 15/10/2026 09:58\n\nKapan webinar AI?\n", 20)
+	err := m.SendWithAttachments("budi@example.com", "Transkrip percakapan", "Terlampir transkrip percakapan Anda.",
+		Attachment{Filename: "akuai-1-webinar.md", ContentType: "text/markdown; charset=UTF-8", Data: []byte(transcript)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg, err := mail.ReadMessage(strings.NewReader(string(got)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	mediaType, params, err := mime.ParseMediaType(msg.Header.Get("Content-Type"))
+	if err != nil || mediaType != "multipart/mixed" {
+		t.Fatalf("content type = %q (%v)", msg.Header.Get("Content-Type"), err)
+	}
+	mr := multipart.NewReader(msg.Body, params["boundary"])
+	text, err := mr.NextPart()
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := io.ReadAll(text) // multipart.Reader undoes quoted-printable
+	if string(body) != "Terlampir transkrip percakapan Anda." {
+		t.Fatalf("body = %q", body)
+	}
+	file, err := mr.NextPart()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if file.FileName() != "akuai-1-webinar.md" || file.Header.Get("Content-Type") != "text/markdown; charset=UTF-8; name=akuai-1-webinar.md" {
+		t.Fatalf("filename = %q, content type = %q", file.FileName(), file.Header.Get("Content-Type"))
+	}
+	raw, _ := io.ReadAll(file)
+	for _, line := range strings.Split(strings.TrimSpace(string(raw)), "\r\n") {
+		if len(line) > 76 {
+			t.Fatalf("base64 line of %d characters", len(line))
+		}
+	}
+	data, err := base64.StdEncoding.DecodeString(strings.ReplaceAll(string(raw), "\r\n", ""))
+	if err != nil || string(data) != transcript {
+		t.Fatalf("attachment = %q (%v)", data, err)
+	}
+	if _, err := mr.NextPart(); err != io.EOF {
+		t.Fatalf("extra part: %v", err)
+	}
+}
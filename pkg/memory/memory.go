@@ -0,0 +1,202 @@
+// Package memory keeps a few stable facts about a user - program of study,
+// interests, preferred campus - taken from their chat messages, so the
+// prompts can personalise event recommendations. Facts are only learned for
+// users who turned memory on (models.User.MemoryEnabled).
+package memory
+
+import (
+	"AkuAI/models"
+	"regexp"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// MaxInterests caps the interests kept per user; the oldest are forgotten first.
+const MaxInterests = 5
+
+// Fact is one extracted fact; Kind is models.MemoryProgram, MemoryInterest
+// or MemoryCampus.
+type Fact struct {
+	Kind  string
+	Value string
+}
+
+type rule struct {
+	kind string
+	re   *regexp.Regexp
+}
+
+// rules match first-person statements in Indonesian and English. The value
+// is everything up to the next punctuation and is trimmed by cleanValue.
+var rules = []rule{
+	{models.MemoryProgram, regexp.MustCompile(`\b(?:jurusan|prodi|program studi)\s+(?:saya|aku|ku)\s+(?:adalah\s+|itu\s+|yaitu\s+)?([^,.;!?\n]+)`)},
+	{models.MemoryProgram, regexp.MustCompile(`\b(?:saya|aku)\s+(?:mahasiswa|mahasiswi|anak|kuliah|kuliah di)\s+(?:jurusan|prodi|program studi)\s+([^,.;!?\n]+)`)},
+	{models.MemoryProgram, regexp.MustCompile(`\bmy (?:major|study program|program of study) is ([^,.;!?\n]+)`)},
+	{models.MemoryProgram, regexp.MustCompile(`\bi(?: am|'m) (?:majoring in|studying) ([^,.;!?\n]+)`)},
+	{models.MemoryInterest, regexp.MustCompile(`\b(?:saya|aku)\s+(?:sangat\s+|lagi\s+)?(?:tertarik|berminat)\s+(?:dengan|pada|sama|di|ke|akan)?\s*(?:bidang\s+|topik\s+)?([^,.;!?\n]+(?:,\s*[^,.;!?\n]+)*)`)},
+	{models.MemoryInterest, regexp.MustCompile(`\bminat\s+(?:saya|aku|ku)\s+(?:adalah\s+|di\s+|yaitu\s+)?(?:bidang\s+)?([^.;!?\n]+)`)},
+	{models.MemoryInterest, regexp.MustCompile(`\bi(?: am|'m) (?:really |very )?interested in ([^.;!?\n]+)`)},
+	{models.MemoryCampus, regexp.MustCompile(`\b(?:saya|aku)\s+(?:kuliah|mahasiswa|mahasiswi)\s+di\s+([^,.;!?\n]+)`)},
+	{models.MemoryCampus, regexp.MustCompile(`\bkampus\s+(?:saya|aku|ku)\s+(?:adalah\s+|di\s+)?([^,.;!?\n]+)`)},
+	{models.MemoryCampus, regexp.MustCompile(`\bi study at ([^,.;!?\n]+)`)},
+}
+
+// stopWords end a value: "sistem informasi tapi ..." keeps "sistem informasi".
+var stopWords = map[string]bool{
+	"tapi": true, "karena": true, "jadi": true, "yang": true, "mau": true, "ingin": true, "pengen": true,
+	"apakah": true, "ada": true, "untuk": true, "sehingga": true, "dan": true, "serta": true,
+	"but": true, "because": true, "so": true, "and": true, "which": true, "is": true, "are": true,
+}
+
+// fillers are dropped from the end of a value.
+var fillers = map[string]bool{
+	"sih": true, "nih": true, "ya": true, "dong": true, "kak": true, "deh": true, "kok": true, "loh": true,
+	"juga": true, "banget": true, "ini": true, "itu": true, "tersebut": true,
+}
+
+// generic values say nothing about the user ("saya tertarik dengan acara ini").
+var generic = map[string]bool{
+	"acara": true, "event": true, "webinar": true, "sertifikasi": true, "kegiatan": true, "jurusan": true,
+	"prodi": true, "kampus": true, "sana": true, "situ": true, "itu": true, "ini": true,
+}
+
+var listSep = regexp.MustCompile(`\s*(?:,|&|/|\bdan\b|\bserta\b|\band\b)\s*`)
+
+// Extract returns the facts stated in text, at most one program and one
+// campus.
+func Extract(text string) []Fact {
+	lower := strings.ToLower(text)
+	var out []Fact
+	seen := map[Fact]bool{}
+	for _, r := range rules {
+		for _, m := range r.re.FindAllStringSubmatch(lower, -1) {
+			values := []string{m[1]}
+			if r.kind == models.MemoryInterest {
+				values = listSep.Split(m[1], -1)
+			}
+			for _, v := range values {
+				v = cleanValue(v)
+				if v == "" {
+					continue
+				}
+				if r.kind == models.MemoryCampus && (strings.HasPrefix(v, "jurusan ") || strings.HasPrefix(v, "prodi ")) {
+					continue // "saya kuliah di jurusan ..." names a program
+				}
+				f := Fact{Kind: r.kind, Value: v}
+				if seen[f] || (r.kind != models.MemoryInterest && hasKind(out, r.kind)) {
+					continue
+				}
+				seen[f] = true
+				out = append(out, f)
+			}
+		}
+	}
+	return out
+}
+
+func hasKind(facts []Fact, kind string) bool {
+	for _, f := range facts {
+		if f.Kind == kind {
+			return true
+		}
+	}
+	return false
+}
+
+// cleanValue keeps up to four words before the first stop word and drops
+// trailing fillers.
+func cleanValue(v string) string {
+	var words []string
+	for _, w := range strings.Fields(v) {
+		if stopWords[w] || len(words) == 4 {
+			break
+		}
+		words = append(words, w)
+	}
+	for len(words) > 0 && fillers[words[len(words)-1]] {
+		words = words[:len(words)-1]
+	}
+	v = strings.Join(words, " ")
+	if len(v) < 2 || generic[v] {
+		return ""
+	}
+	return v
+}
+
+// Learn extracts the facts in a user message and stores them. A new program
+// or campus replaces the old one; interests beyond MaxInterests push out the
+// oldest.
+func Learn(db *gorm.DB, userID, messageID uint, text string) ([]Fact, error) {
+	facts := Extract(text)
+	for _, f := range facts {
+		if f.Kind != models.MemoryInterest {
+			if err := db.Unscoped().Where("user_id = ? AND kind = ? AND value <> ?", userID, f.Kind, f.Value).
+				Delete(&models.UserMemory{}).Error; err != nil {
+				return nil, err
+			}
+		}
+		m := models.UserMemory{UserID: userID, Kind: f.Kind, Value: f.Value}
+		if err := db.Where(m).Attrs(models.UserMemory{SourceMessageID: messageID}).FirstOrCreate(&m).Error; err != nil {
+			return nil, err
+		}
+	}
+	if hasKind(facts, models.MemoryInterest) {
+		var ids []uint
+		if err := db.Model(&models.UserMemory{}).Where("user_id = ? AND kind = ?", userID, models.MemoryInterest).
+			Order("id DESC").Offset(MaxInterests).Pluck("id", &ids).Error; err != nil {
+			return nil, err
+		}
+		if len(ids) > 0 {
+			if err := db.Unscoped().Delete(&models.UserMemory{}, ids).Error; err != nil {
+				return nil, err
+			}
+		}
+	}
+	return facts, nil
+}
+
+// Facts lists what is remembered about a user, oldest first.
+func Facts(db *gorm.DB, userID uint) ([]models.UserMemory, error) {
+	var out []models.UserMemory
+	err := db.Where("user_id = ?", userID).Order("id").Find(&out).Error
+	return out, err
+}
+
+// Forget deletes every fact about a user. Facts are always removed for good,
+// not soft-deleted.
+func Forget(db *gorm.DB, userID uint) error {
+	return db.Unscoped().Where("user_id = ?", userID).Delete(&models.UserMemory{}).Error
+}
+
+// Section renders the facts as a short block for the system instruction, or
+// "" when there are none.
+func Section(facts []models.UserMemory) string {
+	var program, campus string
+	var interests []string
+	for _, f := range facts {
+		switch f.Kind {
+		case models.MemoryProgram:
+			program = f.Value
+		case models.MemoryCampus:
+			campus = f.Value
+		case models.MemoryInterest:
+			interests = append(interests, f.Value)
+		}
+	}
+	if program == "" && campus == "" && len(interests) == 0 {
+		return ""
+	}
+	var b strings.Builder
+	b.WriteString("\n\nMEMORI PENGGUNA (disimpan atas izin pengguna; pakai untuk memilih dan mengurutkan rekomendasi acara yang paling cocok, jangan dibahas kecuali ditanya):\n")
+	if program != "" {
+		b.WriteString("- Program studi: " + program + "\n")
+	}
+	if len(interests) > 0 {
+		b.WriteString("- Minat: " + strings.Join(interests, ", ") + "\n")
+	}
+	if campus != "" {
+		b.WriteString("- Kampus: " + campus + "\n")
+	}
+	return b.String()
+}
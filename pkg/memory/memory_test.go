@@ -0,0 +1,45 @@
+package memory
+
+import (
+	"AkuAI/models"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestExtract(t *testing.T) {
+	cases := []struct {
+		text string
+		want []Fact
+	}{
+		{"Halo, saya mahasiswa jurusan Sistem Informasi dan saya tertarik dengan data science, UI/UX.",
+			[]Fact{{models.MemoryProgram, "sistem informasi"}, {models.MemoryInterest, "data science"}, {models.MemoryInterest, "ui"}, {models.MemoryInterest, "ux"}}},
+		{"Aku kuliah di UIB, ada webinar AI bulan ini?", []Fact{{models.MemoryCampus, "uib"}}},
+		{"saya kuliah di jurusan akuntansi", []Fact{{models.MemoryProgram, "akuntansi"}}},
+		{"Minat saya bidang keamanan siber dan cloud computing", []Fact{{models.MemoryInterest, "keamanan siber"}, {models.MemoryInterest, "cloud computing"}}},
+		{"I'm interested in machine learning and robotics", []Fact{{models.MemoryInterest, "machine learning"}, {models.MemoryInterest, "robotics"}}},
+		{"Saya tertarik dengan acara ini", nil},
+		{"Ada sertifikasi apa di bulan November?", nil},
+	}
+	for _, c := range cases {
+		if got := Extract(c.text); !reflect.DeepEqual(got, c.want) {
+			t.Errorf("Extract(%q) = %v, want %v", c.text, got, c.want)
+		}
+	}
+}
+
+func TestSection(t *testing.T) {
+	if Section(nil) != "" {
+		t.Fatal("empty memory should give no section")
+	}
+	s := Section([]models.UserMemory{
+		{Kind: models.MemoryInterest, Value: "data science"},
+		{Kind: models.MemoryProgram, Value: "sistem informasi"},
+		{Kind: models.MemoryInterest, Value: "ui"},
+	})
+	for _, want := range []string{"- Program studi: sistem informasi", "- Minat: data science, ui"} {
+		if !strings.Contains(s, want) {
+			t.Errorf("section missing %q:\n%s", want, s)
+		}
+	}
+}
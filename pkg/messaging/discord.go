@@ -0,0 +1,158 @@
+package messaging
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Discord interaction and response types.
+const (
+	DiscordPing               = 1
+	DiscordApplicationCommand = 2
+
+	DiscordPong                   = 1
+	DiscordChannelMessage         = 4
+	DiscordDeferredChannelMessage = 5
+)
+
+// Option types that nest further options.
+const (
+	discordSubCommand = 1
+	discordGroup      = 2
+)
+
+const discordMaxContent = 2000
+
+// Discord verifies and answers interactions of a Discord application.
+type Discord struct {
+	appID     string
+	publicKey ed25519.PublicKey
+	apiBase   string
+	client    *http.Client
+}
+
+// NewDiscord returns the application appID with its hex-encoded public key.
+// apiBase defaults to https://discord.com/api/v10.
+func NewDiscord(appID, publicKeyHex, apiBase string) (*Discord, error) {
+	key, err := hex.DecodeString(publicKeyHex)
+	if err != nil || len(key) != ed25519.PublicKeySize {
+		return nil, errors.New("discord: public key must be 32 hex-encoded bytes")
+	}
+	if apiBase == "" {
+		apiBase = "https://discord.com/api/v10"
+	}
+	return &Discord{appID: appID, publicKey: key, apiBase: strings.TrimRight(apiBase, "/"),
+		client: &http.Client{Timeout: 15 * time.Second}}, nil
+}
+
+// Verify checks the X-Signature-Ed25519 of a request body sent with
+// X-Signature-Timestamp.
+func (d *Discord) Verify(body []byte, timestamp, signatureHex string) bool {
+	sig, err := hex.DecodeString(signatureHex)
+	if err != nil || len(sig) != ed25519.SignatureSize {
+		return false
+	}
+	return ed25519.Verify(d.publicKey, append([]byte(timestamp), body...), sig)
+}
+
+// Interaction is the part of a Discord interaction the bot uses.
+type Interaction struct {
+	Type      int    `json:"type"`
+	Token     string `json:"token"`
+	ChannelID string `json:"channel_id"`
+	Data      struct {
+		Name    string          `json:"name"`
+		Options []discordOption `json:"options"`
+	} `json:"data"`
+	Member *struct {
+		User discordUser `json:"user"`
+	} `json:"member"`
+	User *discordUser `json:"user"`
+}
+
+type discordUser struct {
+	ID       string `json:"id"`
+	Username string `json:"username"`
+}
+
+type discordOption struct {
+	Name    string          `json:"name"`
+	Type    int             `json:"type"`
+	Value   any             `json:"value"`
+	Options []discordOption `json:"options"`
+}
+
+// Command returns an application command as a SlashCommand. Subcommands and
+// option values are joined into Text in order, so "/uib events
+// month:november" reads like Slack's "/uib events november".
+func (in Interaction) Command() SlashCommand {
+	var words []string
+	var walk func([]discordOption)
+	walk = func(opts []discordOption) {
+		for _, o := range opts {
+			if o.Type == discordSubCommand || o.Type == discordGroup {
+				words = append(words, o.Name)
+				walk(o.Options)
+			} else if o.Value != nil {
+				words = append(words, strings.TrimSpace(fmt.Sprint(o.Value)))
+			}
+		}
+	}
+	walk(in.Data.Options)
+	cmd := SlashCommand{Platform: PlatformDiscord, Command: "/" + in.Data.Name, Text: strings.Join(words, " "), ChannelID: in.ChannelID}
+	if in.Member != nil {
+		cmd.UserID, cmd.UserName = in.Member.User.ID, in.Member.User.Username
+	} else if in.User != nil {
+		cmd.UserID, cmd.UserName = in.User.ID, in.User.Username
+	}
+	return cmd
+}
+
+// DiscordMessage is an interaction response showing text.
+func DiscordMessage(text string) map[string]any {
+	return map[string]any{"type": DiscordChannelMessage, "data": map[string]any{"content": truncateDiscord(text)}}
+}
+
+func truncateDiscord(text string) string {
+	if parts := Split(text, discordMaxContent-2); len(parts) > 1 {
+		return parts[0] + "\n…"
+	}
+	return text
+}
+
+// FollowUp replaces the deferred response of the interaction with token by
+// text, sending any overflow as follow-up messages.
+func (d *Discord) FollowUp(ctx context.Context, token, text string) error {
+	for i, part := range Split(text, discordMaxContent) {
+		method, url := http.MethodPatch, d.apiBase+"/webhooks/"+d.appID+"/"+token+"/messages/@original"
+		if i > 0 {
+			method, url = http.MethodPost, d.apiBase+"/webhooks/"+d.appID+"/"+token
+		}
+		body, _ := json.Marshal(map[string]any{"content": part})
+		req, err := http.NewRequestWithContext(ctx, method, url, bytes.NewReader(body))
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := d.client.Do(req)
+		if err != nil {
+			// The URL contains the interaction token.
+			return fmt.Errorf("discord follow-up: %w", unwrapURLError(err))
+		}
+		data, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+		resp.Body.Close()
+		if resp.StatusCode >= 300 {
+			return fmt.Errorf("discord follow-up: %s %s", resp.Status, strings.TrimSpace(string(data)))
+		}
+	}
+	return nil
+}
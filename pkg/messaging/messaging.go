@@ -0,0 +1,138 @@
+// Package messaging connects the chat bot to messaging apps: it parses the
+// webhook updates of the Telegram Bot API and the WhatsApp Cloud API into
+// Incoming messages and sends replies back, formatted for each app, and
+// verifies and answers the slash commands of Slack and Discord staff
+// channels. Linking chats to users and answering them is done by the
+// gateway in controllers.
+package messaging
+
+import (
+	"AkuAI/models"
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Platforms.
+const (
+	PlatformTelegram = "telegram"
+	PlatformWhatsApp = "whatsapp"
+	PlatformSlack    = "slack"
+	PlatformDiscord  = "discord"
+)
+
+// Incoming is a text message received from a chat.
+type Incoming struct {
+	Platform string
+	ChatID   string // Telegram chat id, WhatsApp phone number
+	Name     string // sender's display name, when known
+	Text     string
+}
+
+// Sender sends replies to one platform.
+type Sender interface {
+	Platform() string
+	// Send delivers text, written in the bot's Markdown subset (**bold**),
+	// to chatID, split into as many messages as the platform needs.
+	Send(ctx context.Context, chatID, text string) error
+}
+
+var boldRe = regexp.MustCompile(`\*\*([^*\n]+)\*\*`)
+
+// Split cuts text into parts of at most max bytes, preferring paragraph and
+// line breaks.
+func Split(text string, max int) []string {
+	var parts []string
+	text = strings.TrimSpace(text)
+	for len(text) > max {
+		cut := strings.LastIndex(text[:max], "\n\n")
+		if cut <= 0 {
+			cut = strings.LastIndex(text[:max], "\n")
+		}
+		if cut <= 0 {
+			cut = strings.LastIndex(text[:max], " ")
+		}
+		if cut <= 0 {
+			cut = max
+			for cut > 0 && !isRuneStart(text[cut]) {
+				cut--
+			}
+		}
+		parts = append(parts, strings.TrimSpace(text[:cut]))
+		text = strings.TrimSpace(text[cut:])
+	}
+	if text != "" {
+		parts = append(parts, text)
+	}
+	return parts
+}
+
+func isRuneStart(b byte) bool { return b&0xC0 != 0x80 }
+
+// FormatEvents lists events for a chat, soonest first, at most limit of them.
+func FormatEvents(heading string, events []models.UIBEvent, limit int) string {
+	if len(events) == 0 {
+		return heading + "\n\nBelum ada acara yang cocok."
+	}
+	events = append([]models.UIBEvent(nil), events...)
+	sort.SliceStable(events, func(i, j int) bool { return events[i].Date < events[j].Date })
+	var b strings.Builder
+	b.WriteString(heading)
+	for i, ev := range events {
+		if i == limit {
+			fmt.Fprintf(&b, "\n\n…dan %d acara lainnya.", len(events)-limit)
+			break
+		}
+		fmt.Fprintf(&b, "\n\n%d. **%s**\n📅 %s", i+1, ev.Title, ev.Date)
+		if ev.Time != "" {
+			b.WriteString(" " + ev.Time)
+		}
+		if place := firstNonEmpty(ev.Location, ev.Platform); place != "" {
+			b.WriteString("\n📍 " + place)
+		}
+		if ev.RegistrationFee != "" {
+			b.WriteString("\n💰 " + ev.FeeText())
+		}
+		if ev.RegistrationLink != "" {
+			b.WriteString("\n🔗 " + ev.RegistrationLink)
+		}
+	}
+	return b.String()
+}
+
+// FormatEvent describes one event in full.
+func FormatEvent(ev models.UIBEvent) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "**%s**\n📅 %s", ev.Title, ev.Date)
+	if ev.Time != "" {
+		b.WriteString(" " + ev.Time)
+	}
+	for _, f := range []struct{ icon, val string }{
+		{"📍", firstNonEmpty(ev.Location, ev.Platform)},
+		{"🏛️", ev.Department},
+		{"🎤", ev.Speaker},
+		{"💰", ev.FeeText()},
+		{"📋", ev.Requirements},
+		{"📞", ev.Contact},
+		{"🔗", ev.RegistrationLink},
+	} {
+		if f.val != "" {
+			b.WriteString("\n" + f.icon + " " + f.val)
+		}
+	}
+	if ev.Description != "" {
+		b.WriteString("\n\n" + ev.Description)
+	}
+	return b.String()
+}
+
+func firstNonEmpty(vals ...string) string {
+	for _, v := range vals {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
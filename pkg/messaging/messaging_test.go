@@ -0,0 +1,95 @@
+package messaging
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSplit(t *testing.T) {
+	text := strings.Repeat("a", 30) + "\n\n" + strings.Repeat("b", 30) + "\n" + strings.Repeat("c", 50)
+	parts := Split(text, 70)
+	if len(parts) != 3 || parts[0] != strings.Repeat("a", 30) || parts[1] != strings.Repeat("b", 30) || parts[2] != strings.Repeat("c", 50) {
+		t.Fatalf("Split = %q", parts)
+	}
+	for _, p := range Split(strings.Repeat("é", 100), 15) {
+		if len(p) > 15 || !strings.HasPrefix(p, "é") {
+			t.Fatalf("part %q cuts a rune or exceeds the limit", p)
+		}
+	}
+}
+
+func TestFormat(t *testing.T) {
+	in := "**Webinar AI** <gratis> & **daring**"
+	if got := FormatTelegram(in); got != "<b>Webinar AI</b> &lt;gratis&gt; &amp; <b>daring</b>" {
+		t.Errorf("FormatTelegram = %q", got)
+	}
+	if got := FormatWhatsApp(in); got != "*Webinar AI* <gratis> & *daring*" {
+		t.Errorf("FormatWhatsApp = %q", got)
+	}
+}
+
+func TestParse(t *testing.T) {
+	tg := NewTelegram("t", "s", "")
+	in, ok := tg.ParseUpdate([]byte(`{"update_id":1,"message":{"text":"/events","chat":{"id":-100123},"from":{"first_name":"Budi"}}}`))
+	if !ok || in.ChatID != "-100123" || in.Text != "/events" || in.Name != "Budi" {
+		t.Fatalf("ParseUpdate = %+v, %v", in, ok)
+	}
+	if _, ok := tg.ParseUpdate([]byte(`{"update_id":2,"edited_message":{"text":"x","chat":{"id":1}}}`)); ok {
+		t.Fatal("edited message parsed")
+	}
+	if tg.VerifySecret("x") || !tg.VerifySecret("s") {
+		t.Fatal("VerifySecret")
+	}
+	if NewTelegram("t", "", "").VerifySecret("") {
+		t.Fatal("update accepted without a webhook secret")
+	}
+
+	wa := NewWhatsApp("t", "1", "v", "", "")
+	msgs := wa.ParseWebhook([]byte(`{"entry":[{"changes":[{"value":{"contacts":[{"wa_id":"62811","profile":{"name":"Sari"}}],
+		"messages":[{"from":"62811","type":"text","text":{"body":"halo"}},{"from":"62811","type":"image"}]}}]}]}`))
+	if len(msgs) != 1 || msgs[0].ChatID != "62811" || msgs[0].Name != "Sari" || msgs[0].Text != "halo" {
+		t.Fatalf("ParseWebhook = %+v", msgs)
+	}
+	if !wa.VerifySubscription("subscribe", "v") || wa.VerifySubscription("subscribe", "x") {
+		t.Fatal("VerifySubscription")
+	}
+	if wa.VerifySignature([]byte(`{}`), "") {
+		t.Fatal("update accepted without an app secret")
+	}
+	signed := NewWhatsApp("t", "1", "v", "app", "")
+	if !signed.VerifySignature([]byte(`{}`), "sha256=1c9f3f02d55b72573bcb2a1106c50e05613b9c44e1a432a9b14b462f5a61800f") || signed.VerifySignature([]byte(`{}`), "sha256=00") {
+		t.Fatal("VerifySignature")
+	}
+}
+
+func TestSlackVerify(t *testing.T) {
+	s := NewSlack("8f742231b10e8888abcd99yyyzzz85a5")
+	s.now = func() time.Time { return time.Unix(1531420618, 0) }
+	// Example request from Slack's "Verifying requests from Slack" guide.
+	body := []byte("token=xyzz0WbapA4vBCDEFasx0q6G&team_id=T1DC2JH3J&team_domain=testteamnow&channel_id=G8PSS9T3V&channel_name=foobar&user_id=U2CERLKJA&user_name=roadrunner&command=%2Fwebhook-collect&text=&response_url=https%3A%2F%2Fhooks.slack.com%2Fcommands%2FT1DC2JH3J%2F397700885554%2F96rGlfmibIGlgcZRskXaIFfN&trigger_id=398738663015.47445629121.803a0bc887a14d10d2c447fce8b6703c")
+	sig := "v0=a2114d57b48eac39b9ad189dd8316235a7b4a8d21a10bd27519666489c69b503"
+	if !s.Verify(body, "1531420618", sig) {
+		t.Fatal("valid signature refused")
+	}
+	if s.Verify(body, "1531420619", sig) {
+		t.Fatal("signature accepted for another timestamp")
+	}
+	s.now = func() time.Time { return time.Unix(1531420618, 0).Add(10 * time.Minute) }
+	if s.Verify(body, "1531420618", sig) {
+		t.Fatal("stale request accepted")
+	}
+}
+
+func TestDiscordCommand(t *testing.T) {
+	var in Interaction
+	if err := json.Unmarshal([]byte(`{"type":2,"channel_id":"C1","member":{"user":{"id":"7","username":"staf"}},
+		"data":{"name":"uib","options":[{"name":"events","type":1,"options":[{"name":"month","type":3,"value":"november"}]}]}}`), &in); err != nil {
+		t.Fatal(err)
+	}
+	cmd := in.Command()
+	if cmd.Command != "/uib" || cmd.Text != "events november" || cmd.ChannelID != "C1" || cmd.UserName != "staf" {
+		t.Fatalf("Command = %+v", cmd)
+	}
+}
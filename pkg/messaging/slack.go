@@ -0,0 +1,117 @@
+package messaging
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// slackMaxSkew is how old a signed Slack request may be, as Slack
+// recommends, to stop replays.
+const slackMaxSkew = 5 * time.Minute
+
+// SlashCommand is a slash command invocation from Slack or Discord, e.g.
+// "/uib events november" with Text "events november".
+type SlashCommand struct {
+	Platform  string
+	Command   string
+	Text      string
+	ChannelID string
+	UserID    string
+	UserName  string
+}
+
+// Slack verifies and answers Slack slash commands.
+type Slack struct {
+	signingSecret string
+	client        *http.Client
+	now           func() time.Time
+}
+
+// NewSlack returns the integration of the Slack app with signingSecret.
+func NewSlack(signingSecret string) *Slack {
+	return &Slack{signingSecret: signingSecret, client: &http.Client{Timeout: 15 * time.Second}, now: time.Now}
+}
+
+// Verify checks the X-Slack-Signature of a request body sent at the
+// X-Slack-Request-Timestamp.
+func (s *Slack) Verify(body []byte, timestamp, signature string) bool {
+	ts, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil || s.signingSecret == "" {
+		return false
+	}
+	if d := s.now().Sub(time.Unix(ts, 0)); d > slackMaxSkew || d < -slackMaxSkew {
+		return false
+	}
+	mac := hmac.New(sha256.New, []byte(s.signingSecret))
+	mac.Write([]byte("v0:" + timestamp + ":"))
+	mac.Write(body)
+	return hmac.Equal([]byte(signature), []byte("v0="+hex.EncodeToString(mac.Sum(nil))))
+}
+
+// ParseCommand reads the form-encoded slash command payload and its
+// response_url.
+func (s *Slack) ParseCommand(body []byte) (SlashCommand, string, error) {
+	v, err := url.ParseQuery(string(body))
+	if err != nil {
+		return SlashCommand{}, "", err
+	}
+	return SlashCommand{
+		Platform:  PlatformSlack,
+		Command:   v.Get("command"),
+		Text:      strings.TrimSpace(v.Get("text")),
+		ChannelID: v.Get("channel_id"),
+		UserID:    v.Get("user_id"),
+		UserName:  v.Get("user_name"),
+	}, v.Get("response_url"), nil
+}
+
+// FormatSlack turns the bot's Markdown into Slack mrkdwn.
+func FormatSlack(text string) string {
+	text = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;").Replace(text)
+	return boldRe.ReplaceAllString(text, "*$1*")
+}
+
+// SlackMessage is the JSON body of a reply, visible to the whole channel
+// unless ephemeral.
+func SlackMessage(text string, ephemeral bool) map[string]any {
+	typ := "in_channel"
+	if ephemeral {
+		typ = "ephemeral"
+	}
+	return map[string]any{"response_type": typ, "text": FormatSlack(text)}
+}
+
+// Respond posts a delayed reply to the command's response_url.
+func (s *Slack) Respond(ctx context.Context, responseURL, text string) error {
+	u, err := url.Parse(responseURL)
+	if err != nil || u.Scheme != "https" && u.Scheme != "http" {
+		return fmt.Errorf("slack: invalid response_url")
+	}
+	body, _ := json.Marshal(SlackMessage(text, false))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, responseURL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("slack response_url: %w", unwrapURLError(err))
+	}
+	data, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("slack response_url: %s %s", resp.Status, strings.TrimSpace(string(data)))
+	}
+	return nil
+}
@@ -0,0 +1,123 @@
+package messaging
+
+import (
+	"bytes"
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// telegramMaxText leaves room below the Bot API's 4096-character limit for
+// the HTML tags added by formatting.
+const telegramMaxText = 3800
+
+// Telegram is a bot of the Telegram Bot API.
+type Telegram struct {
+	token         string
+	webhookSecret string
+	apiBase       string
+	client        *http.Client
+}
+
+// NewTelegram returns the bot with token. webhookSecret is the secret_token
+// given to setWebhook; updates must carry it, and are all refused while it
+// is empty. apiBase
+// defaults to https://api.telegram.org.
+func NewTelegram(token, webhookSecret, apiBase string) *Telegram {
+	if apiBase == "" {
+		apiBase = "https://api.telegram.org"
+	}
+	return &Telegram{token: token, webhookSecret: webhookSecret, apiBase: strings.TrimRight(apiBase, "/"),
+		client: &http.Client{Timeout: 15 * time.Second}}
+}
+
+func (t *Telegram) Platform() string { return PlatformTelegram }
+
+// VerifySecret checks the X-Telegram-Bot-Api-Secret-Token header of an
+// update. Without a configured secret every update is refused.
+func (t *Telegram) VerifySecret(header string) bool {
+	if t.webhookSecret == "" {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(header), []byte(t.webhookSecret)) == 1
+}
+
+// ParseUpdate returns the text message of an update; false for other
+// updates (edits, stickers, channel posts, ...).
+func (t *Telegram) ParseUpdate(body []byte) (Incoming, bool) {
+	var u struct {
+		Message *struct {
+			Text string `json:"text"`
+			Chat struct {
+				ID int64 `json:"id"`
+			} `json:"chat"`
+			From *struct {
+				FirstName string `json:"first_name"`
+				Username  string `json:"username"`
+			} `json:"from"`
+		} `json:"message"`
+	}
+	if err := json.Unmarshal(body, &u); err != nil || u.Message == nil || strings.TrimSpace(u.Message.Text) == "" {
+		return Incoming{}, false
+	}
+	in := Incoming{Platform: PlatformTelegram, ChatID: strconv.FormatInt(u.Message.Chat.ID, 10), Text: u.Message.Text}
+	if f := u.Message.From; f != nil {
+		in.Name = firstNonEmpty(f.FirstName, f.Username)
+	}
+	return in, true
+}
+
+// FormatTelegram turns the bot's Markdown into Telegram HTML.
+func FormatTelegram(text string) string {
+	return boldRe.ReplaceAllString(html.EscapeString(text), "<b>$1</b>")
+}
+
+// Send posts text with sendMessage.
+func (t *Telegram) Send(ctx context.Context, chatID, text string) error {
+	for _, part := range Split(text, telegramMaxText) {
+		body, _ := json.Marshal(map[string]any{
+			"chat_id":                  chatID,
+			"text":                     FormatTelegram(part),
+			"parse_mode":               "HTML",
+			"disable_web_page_preview": true,
+		})
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, t.apiBase+"/bot"+t.token+"/sendMessage", bytes.NewReader(body))
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := t.client.Do(req)
+		if err != nil {
+			// The URL contains the token; keep it out of logs.
+			return fmt.Errorf("telegram sendMessage: %w", unwrapURLError(err))
+		}
+		var out struct {
+			OK          bool   `json:"ok"`
+			Description string `json:"description"`
+		}
+		data, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+		resp.Body.Close()
+		if err := json.Unmarshal(data, &out); err != nil || !out.OK {
+			return fmt.Errorf("telegram sendMessage: %s %s", resp.Status, out.Description)
+		}
+	}
+	return nil
+}
+
+// unwrapURLError drops the request URL from a client error.
+func unwrapURLError(err error) error {
+	var ue *url.Error
+	if errors.As(err, &ue) {
+		return ue.Err
+	}
+	return err
+}
@@ -0,0 +1,139 @@
+package messaging
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const whatsAppMaxText = 4096
+
+// WhatsApp is a business phone number of the WhatsApp Cloud API.
+type WhatsApp struct {
+	token         string
+	phoneNumberID string
+	verifyToken   string
+	appSecret     string
+	apiBase       string
+	client        *http.Client
+}
+
+// NewWhatsApp returns the number phoneNumberID, sending with the access
+// token. verifyToken answers the webhook subscription check; appSecret
+// verifies the X-Hub-Signature-256 of updates, which are all refused while it
+// is empty. apiBase defaults to
+// https://graph.facebook.com/v19.0.
+func NewWhatsApp(token, phoneNumberID, verifyToken, appSecret, apiBase string) *WhatsApp {
+	if apiBase == "" {
+		apiBase = "https://graph.facebook.com/v19.0"
+	}
+	return &WhatsApp{token: token, phoneNumberID: phoneNumberID, verifyToken: verifyToken, appSecret: appSecret,
+		apiBase: strings.TrimRight(apiBase, "/"), client: &http.Client{Timeout: 15 * time.Second}}
+}
+
+func (w *WhatsApp) Platform() string { return PlatformWhatsApp }
+
+// VerifySubscription checks the hub.mode and hub.verify_token of the
+// webhook subscription request.
+func (w *WhatsApp) VerifySubscription(mode, token string) bool {
+	return mode == "subscribe" && w.verifyToken != "" &&
+		subtle.ConstantTimeCompare([]byte(token), []byte(w.verifyToken)) == 1
+}
+
+// VerifySignature checks the X-Hub-Signature-256 header of an update.
+// Without a configured app secret every update is refused.
+func (w *WhatsApp) VerifySignature(body []byte, header string) bool {
+	if w.appSecret == "" {
+		return false
+	}
+	mac := hmac.New(sha256.New, []byte(w.appSecret))
+	mac.Write(body)
+	return hmac.Equal([]byte(header), []byte("sha256="+hex.EncodeToString(mac.Sum(nil))))
+}
+
+// ParseWebhook returns the text messages of an update; statuses and other
+// message types are skipped.
+func (w *WhatsApp) ParseWebhook(body []byte) []Incoming {
+	var u struct {
+		Entry []struct {
+			Changes []struct {
+				Value struct {
+					Contacts []struct {
+						WaID    string `json:"wa_id"`
+						Profile struct {
+							Name string `json:"name"`
+						} `json:"profile"`
+					} `json:"contacts"`
+					Messages []struct {
+						From string `json:"from"`
+						Type string `json:"type"`
+						Text struct {
+							Body string `json:"body"`
+						} `json:"text"`
+					} `json:"messages"`
+				} `json:"value"`
+			} `json:"changes"`
+		} `json:"entry"`
+	}
+	if err := json.Unmarshal(body, &u); err != nil {
+		return nil
+	}
+	var out []Incoming
+	for _, e := range u.Entry {
+		for _, ch := range e.Changes {
+			names := map[string]string{}
+			for _, ct := range ch.Value.Contacts {
+				names[ct.WaID] = ct.Profile.Name
+			}
+			for _, m := range ch.Value.Messages {
+				if m.Type != "text" || strings.TrimSpace(m.Text.Body) == "" {
+					continue
+				}
+				out = append(out, Incoming{Platform: PlatformWhatsApp, ChatID: m.From, Name: names[m.From], Text: m.Text.Body})
+			}
+		}
+	}
+	return out
+}
+
+// FormatWhatsApp turns the bot's Markdown into WhatsApp formatting.
+func FormatWhatsApp(text string) string {
+	return boldRe.ReplaceAllString(text, "*$1*")
+}
+
+// Send posts text messages to the phone number chatID.
+func (w *WhatsApp) Send(ctx context.Context, chatID, text string) error {
+	for _, part := range Split(text, whatsAppMaxText) {
+		body, _ := json.Marshal(map[string]any{
+			"messaging_product": "whatsapp",
+			"to":                chatID,
+			"type":              "text",
+			"text":              map[string]any{"body": FormatWhatsApp(part), "preview_url": false},
+		})
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.apiBase+"/"+w.phoneNumberID+"/messages", bytes.NewReader(body))
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer "+w.token)
+		resp, err := w.client.Do(req)
+		if err != nil {
+			return fmt.Errorf("whatsapp send: %w", err)
+		}
+		data, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			return fmt.Errorf("whatsapp send: %s %s", resp.Status, strings.TrimSpace(string(data)))
+		}
+	}
+	return nil
+}
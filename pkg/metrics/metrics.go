@@ -0,0 +1,118 @@
+package metrics
+
+import (
+	"math"
+	"sort"
+	"strconv"
+	"sync"
+	"sync/atomic"
+)
+
+// Counter is a monotonically increasing value.
+type Counter struct {
+	v atomic.Int64
+}
+
+func (c *Counter) Inc()          { c.v.Add(1) }
+func (c *Counter) Add(n int64)   { c.v.Add(n) }
+func (c *Counter) Value() int64  { return c.v.Load() }
+func (c *Counter) snapshot() any { return c.Value() }
+
+// Histogram tracks the distribution of observed values in fixed buckets.
+type Histogram struct {
+	mu      sync.Mutex
+	buckets []float64
+	counts  []uint64
+	count   uint64
+	sum     float64
+	max     float64
+}
+
+func (h *Histogram) Observe(v float64) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	i := sort.SearchFloat64s(h.buckets, v)
+	h.counts[i]++
+	h.count++
+	h.sum += v
+	h.max = math.Max(h.max, v)
+}
+
+func (h *Histogram) snapshot() any {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	buckets := make(map[string]uint64, len(h.buckets)+1)
+	var cumulative uint64
+	for i, b := range h.buckets {
+		cumulative += h.counts[i]
+		buckets["le_"+formatFloat(b)] = cumulative
+	}
+	buckets["le_inf"] = h.count
+	avg := 0.0
+	if h.count > 0 {
+		avg = h.sum / float64(h.count)
+	}
+	return map[string]any{"count": h.count, "sum": h.sum, "avg": avg, "max": h.max, "buckets": buckets}
+}
+
+type gaugeFunc func() any
+
+func (g gaugeFunc) snapshot() any { return g() }
+
+type metric interface {
+	snapshot() any
+}
+
+var (
+	mu       sync.RWMutex
+	registry = map[string]metric{}
+)
+
+// NewCounter registers a counter under name, returning the existing one when
+// the name is already taken.
+func NewCounter(name string) *Counter {
+	mu.Lock()
+	defer mu.Unlock()
+	if c, ok := registry[name].(*Counter); ok {
+		return c
+	}
+	c := &Counter{}
+	registry[name] = c
+	return c
+}
+
+// NewHistogram registers a histogram with the given upper bucket bounds.
+func NewHistogram(name string, buckets []float64) *Histogram {
+	mu.Lock()
+	defer mu.Unlock()
+	if h, ok := registry[name].(*Histogram); ok {
+		return h
+	}
+	b := append([]float64(nil), buckets...)
+	sort.Float64s(b)
+	h := &Histogram{buckets: b, counts: make([]uint64, len(b)+1)}
+	registry[name] = h
+	return h
+}
+
+// RegisterFunc exposes a value computed on every snapshot, e.g. cache stats.
+func RegisterFunc(name string, fn func() any) {
+	mu.Lock()
+	registry[name] = gaugeFunc(fn)
+	mu.Unlock()
+}
+
+// Snapshot returns the current value of every registered metric.
+func Snapshot() map[string]any {
+	mu.RLock()
+	defer mu.RUnlock()
+	out := make(map[string]any, len(registry))
+	for name, m := range registry {
+		out[name] = m.snapshot()
+	}
+	return out
+}
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
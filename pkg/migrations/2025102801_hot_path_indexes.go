@@ -0,0 +1,45 @@
+package migrations
+
+import (
+	"AkuAI/models"
+
+	"github.com/go-gormigrate/gormigrate/v2"
+	"gorm.io/gorm"
+)
+
+// Composite indexes for loading a conversation's messages in order and for
+// listing a user's conversations by recent activity.
+func init() {
+	indexes := []struct {
+		model any
+		name  string
+	}{
+		{&models.Message{}, "idx_messages_conversation_timestamp"},
+		{&models.Conversation{}, "idx_conversations_user_updated"},
+	}
+	register(&gormigrate.Migration{
+		ID: "2025102801_hot_path_indexes",
+		Migrate: func(tx *gorm.DB) error {
+			for _, ix := range indexes {
+				if tx.Migrator().HasIndex(ix.model, ix.name) {
+					continue
+				}
+				if err := tx.Migrator().CreateIndex(ix.model, ix.name); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+		Rollback: func(tx *gorm.DB) error {
+			for _, ix := range indexes {
+				if !tx.Migrator().HasIndex(ix.model, ix.name) {
+					continue
+				}
+				if err := tx.Migrator().DropIndex(ix.model, ix.name); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	})
+}
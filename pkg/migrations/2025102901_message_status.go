@@ -0,0 +1,28 @@
+package migrations
+
+import (
+	"AkuAI/models"
+
+	"github.com/go-gormigrate/gormigrate/v2"
+	"gorm.io/gorm"
+)
+
+// Status of bot messages, so partial replies are stored as stopped/error
+// instead of looking complete.
+func init() {
+	register(&gormigrate.Migration{
+		ID: "2025102901_message_status",
+		Migrate: func(tx *gorm.DB) error {
+			if tx.Migrator().HasColumn(&models.Message{}, "Status") {
+				return nil
+			}
+			return tx.Migrator().AddColumn(&models.Message{}, "Status")
+		},
+		Rollback: func(tx *gorm.DB) error {
+			if !tx.Migrator().HasColumn(&models.Message{}, "Status") {
+				return nil
+			}
+			return tx.Migrator().DropColumn(&models.Message{}, "Status")
+		},
+	})
+}
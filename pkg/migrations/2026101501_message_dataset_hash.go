@@ -0,0 +1,28 @@
+package migrations
+
+import (
+	"AkuAI/models"
+
+	"github.com/go-gormigrate/gormigrate/v2"
+	"gorm.io/gorm"
+)
+
+// Hash of the event dataset a bot reply was grounded on, so replies produced
+// against an older uib_events.json can be told apart.
+func init() {
+	register(&gormigrate.Migration{
+		ID: "2026101501_message_dataset_hash",
+		Migrate: func(tx *gorm.DB) error {
+			if tx.Migrator().HasColumn(&models.Message{}, "DatasetHash") {
+				return nil
+			}
+			return tx.Migrator().AddColumn(&models.Message{}, "DatasetHash")
+		},
+		Rollback: func(tx *gorm.DB) error {
+			if !tx.Migrator().HasColumn(&models.Message{}, "DatasetHash") {
+				return nil
+			}
+			return tx.Migrator().DropColumn(&models.Message{}, "DatasetHash")
+		},
+	})
+}
@@ -0,0 +1,36 @@
+package migrations
+
+import (
+	"AkuAI/models"
+
+	"github.com/go-gormigrate/gormigrate/v2"
+	"gorm.io/gorm"
+)
+
+// Opt-in chat memory: the users.memory_enabled consent flag and the facts
+// remembered per user.
+func init() {
+	register(&gormigrate.Migration{
+		ID: "2026101502_user_memory",
+		Migrate: func(tx *gorm.DB) error {
+			if !tx.Migrator().HasColumn(&models.User{}, "MemoryEnabled") {
+				if err := tx.Migrator().AddColumn(&models.User{}, "MemoryEnabled"); err != nil {
+					return err
+				}
+			}
+			if tx.Migrator().HasTable(&models.UserMemory{}) {
+				return nil
+			}
+			return tx.Migrator().CreateTable(&models.UserMemory{})
+		},
+		Rollback: func(tx *gorm.DB) error {
+			if err := tx.Migrator().DropTable(&models.UserMemory{}); err != nil {
+				return err
+			}
+			if !tx.Migrator().HasColumn(&models.User{}, "MemoryEnabled") {
+				return nil
+			}
+			return tx.Migrator().DropColumn(&models.User{}, "MemoryEnabled")
+		},
+	})
+}
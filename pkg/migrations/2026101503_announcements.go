@@ -0,0 +1,29 @@
+package migrations
+
+import (
+	"AkuAI/models"
+
+	"github.com/go-gormigrate/gormigrate/v2"
+	"gorm.io/gorm"
+)
+
+// Admin announcements and the receipts of the users who have seen them.
+func init() {
+	register(&gormigrate.Migration{
+		ID: "2026101503_announcements",
+		Migrate: func(tx *gorm.DB) error {
+			for _, m := range []any{&models.Announcement{}, &models.AnnouncementReceipt{}} {
+				if tx.Migrator().HasTable(m) {
+					continue
+				}
+				if err := tx.Migrator().CreateTable(m); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+		Rollback: func(tx *gorm.DB) error {
+			return tx.Migrator().DropTable(&models.AnnouncementReceipt{}, &models.Announcement{})
+		},
+	})
+}
@@ -0,0 +1,24 @@
+package migrations
+
+import (
+	"AkuAI/models"
+
+	"github.com/go-gormigrate/gormigrate/v2"
+	"gorm.io/gorm"
+)
+
+// API keys for service-to-service access to the UIB and image endpoints.
+func init() {
+	register(&gormigrate.Migration{
+		ID: "2026101504_api_keys",
+		Migrate: func(tx *gorm.DB) error {
+			if tx.Migrator().HasTable(&models.APIKey{}) {
+				return nil
+			}
+			return tx.Migrator().CreateTable(&models.APIKey{})
+		},
+		Rollback: func(tx *gorm.DB) error {
+			return tx.Migrator().DropTable(&models.APIKey{})
+		},
+	})
+}
@@ -0,0 +1,24 @@
+package migrations
+
+import (
+	"AkuAI/models"
+
+	"github.com/go-gormigrate/gormigrate/v2"
+	"gorm.io/gorm"
+)
+
+// Audit log of sensitive operations.
+func init() {
+	register(&gormigrate.Migration{
+		ID: "2026101505_audit_logs",
+		Migrate: func(tx *gorm.DB) error {
+			if tx.Migrator().HasTable(&models.AuditLog{}) {
+				return nil
+			}
+			return tx.Migrator().CreateTable(&models.AuditLog{})
+		},
+		Rollback: func(tx *gorm.DB) error {
+			return tx.Migrator().DropTable(&models.AuditLog{})
+		},
+	})
+}
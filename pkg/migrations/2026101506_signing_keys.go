@@ -0,0 +1,24 @@
+package migrations
+
+import (
+	"AkuAI/models"
+
+	"github.com/go-gormigrate/gormigrate/v2"
+	"gorm.io/gorm"
+)
+
+// JWT signing keys created by rotation.
+func init() {
+	register(&gormigrate.Migration{
+		ID: "2026101506_signing_keys",
+		Migrate: func(tx *gorm.DB) error {
+			if tx.Migrator().HasTable(&models.SigningKey{}) {
+				return nil
+			}
+			return tx.Migrator().CreateTable(&models.SigningKey{})
+		},
+		Rollback: func(tx *gorm.DB) error {
+			return tx.Migrator().DropTable(&models.SigningKey{})
+		},
+	})
+}
@@ -0,0 +1,29 @@
+package migrations
+
+import (
+	"AkuAI/models"
+
+	"github.com/go-gormigrate/gormigrate/v2"
+	"gorm.io/gorm"
+)
+
+// Webhooks and the log of their deliveries.
+func init() {
+	register(&gormigrate.Migration{
+		ID: "2026101507_webhooks",
+		Migrate: func(tx *gorm.DB) error {
+			for _, m := range []any{&models.Webhook{}, &models.WebhookDelivery{}} {
+				if tx.Migrator().HasTable(m) {
+					continue
+				}
+				if err := tx.Migrator().CreateTable(m); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+		Rollback: func(tx *gorm.DB) error {
+			return tx.Migrator().DropTable(&models.WebhookDelivery{}, &models.Webhook{})
+		},
+	})
+}
@@ -0,0 +1,29 @@
+package migrations
+
+import (
+	"AkuAI/models"
+
+	"github.com/go-gormigrate/gormigrate/v2"
+	"gorm.io/gorm"
+)
+
+// Messaging-app chats linked to users, and the codes that link them.
+func init() {
+	register(&gormigrate.Migration{
+		ID: "2026101508_chat_links",
+		Migrate: func(tx *gorm.DB) error {
+			for _, m := range []any{&models.ChatLink{}, &models.ChatLinkCode{}} {
+				if tx.Migrator().HasTable(m) {
+					continue
+				}
+				if err := tx.Migrator().CreateTable(m); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+		Rollback: func(tx *gorm.DB) error {
+			return tx.Migrator().DropTable(&models.ChatLinkCode{}, &models.ChatLink{})
+		},
+	})
+}
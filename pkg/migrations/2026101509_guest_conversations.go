@@ -0,0 +1,30 @@
+package migrations
+
+import (
+	"AkuAI/models"
+
+	"github.com/go-gormigrate/gormigrate/v2"
+	"gorm.io/gorm"
+)
+
+// Guest ID of conversations started without an account.
+func init() {
+	register(&gormigrate.Migration{
+		ID: "2026101509_guest_conversations",
+		Migrate: func(tx *gorm.DB) error {
+			if tx.Migrator().HasColumn(&models.Conversation{}, "GuestID") {
+				return nil
+			}
+			if err := tx.Migrator().AddColumn(&models.Conversation{}, "GuestID"); err != nil {
+				return err
+			}
+			return tx.Migrator().CreateIndex(&models.Conversation{}, "GuestID")
+		},
+		Rollback: func(tx *gorm.DB) error {
+			if !tx.Migrator().HasColumn(&models.Conversation{}, "GuestID") {
+				return nil
+			}
+			return tx.Migrator().DropColumn(&models.Conversation{}, "GuestID")
+		},
+	})
+}
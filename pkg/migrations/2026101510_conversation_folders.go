@@ -0,0 +1,45 @@
+package migrations
+
+import (
+	"AkuAI/models"
+
+	"github.com/go-gormigrate/gormigrate/v2"
+	"gorm.io/gorm"
+)
+
+// Folders, and pinning and filing conversations.
+func init() {
+	register(&gormigrate.Migration{
+		ID: "2026101510_conversation_folders",
+		Migrate: func(tx *gorm.DB) error {
+			if !tx.Migrator().HasTable(&models.Folder{}) {
+				if err := tx.Migrator().CreateTable(&models.Folder{}); err != nil {
+					return err
+				}
+			}
+			for _, col := range []string{"Pinned", "PinnedAt", "FolderID"} {
+				if tx.Migrator().HasColumn(&models.Conversation{}, col) {
+					continue
+				}
+				if err := tx.Migrator().AddColumn(&models.Conversation{}, col); err != nil {
+					return err
+				}
+			}
+			if tx.Migrator().HasIndex(&models.Conversation{}, "FolderID") {
+				return nil
+			}
+			return tx.Migrator().CreateIndex(&models.Conversation{}, "FolderID")
+		},
+		Rollback: func(tx *gorm.DB) error {
+			for _, col := range []string{"FolderID", "PinnedAt", "Pinned"} {
+				if !tx.Migrator().HasColumn(&models.Conversation{}, col) {
+					continue
+				}
+				if err := tx.Migrator().DropColumn(&models.Conversation{}, col); err != nil {
+					return err
+				}
+			}
+			return tx.Migrator().DropTable(&models.Folder{})
+		},
+	})
+}
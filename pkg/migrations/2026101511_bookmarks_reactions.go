@@ -0,0 +1,29 @@
+package migrations
+
+import (
+	"AkuAI/models"
+
+	"github.com/go-gormigrate/gormigrate/v2"
+	"gorm.io/gorm"
+)
+
+// Bookmarks of and emoji reactions to bot replies.
+func init() {
+	register(&gormigrate.Migration{
+		ID: "2026101511_bookmarks_reactions",
+		Migrate: func(tx *gorm.DB) error {
+			for _, m := range []any{&models.MessageBookmark{}, &models.MessageReaction{}} {
+				if tx.Migrator().HasTable(m) {
+					continue
+				}
+				if err := tx.Migrator().CreateTable(m); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+		Rollback: func(tx *gorm.DB) error {
+			return tx.Migrator().DropTable(&models.MessageReaction{}, &models.MessageBookmark{})
+		},
+	})
+}
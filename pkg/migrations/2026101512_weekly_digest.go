@@ -0,0 +1,37 @@
+package migrations
+
+import (
+	"AkuAI/models"
+
+	"github.com/go-gormigrate/gormigrate/v2"
+	"gorm.io/gorm"
+)
+
+// Opting in to the weekly event digest.
+func init() {
+	register(&gormigrate.Migration{
+		ID: "2026101512_weekly_digest",
+		Migrate: func(tx *gorm.DB) error {
+			for _, col := range []string{"DigestEnabled", "DigestEmail", "DigestSentAt"} {
+				if tx.Migrator().HasColumn(&models.User{}, col) {
+					continue
+				}
+				if err := tx.Migrator().AddColumn(&models.User{}, col); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+		Rollback: func(tx *gorm.DB) error {
+			for _, col := range []string{"DigestSentAt", "DigestEmail", "DigestEnabled"} {
+				if !tx.Migrator().HasColumn(&models.User{}, col) {
+					continue
+				}
+				if err := tx.Migrator().DropColumn(&models.User{}, col); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	})
+}
@@ -0,0 +1,27 @@
+package migrations
+
+import (
+	"AkuAI/models"
+
+	"github.com/go-gormigrate/gormigrate/v2"
+	"gorm.io/gorm"
+)
+
+// How many model calls a bot reply was stitched from.
+func init() {
+	register(&gormigrate.Migration{
+		ID: "2026101513_message_parts",
+		Migrate: func(tx *gorm.DB) error {
+			if tx.Migrator().HasColumn(&models.Message{}, "Parts") {
+				return nil
+			}
+			return tx.Migrator().AddColumn(&models.Message{}, "Parts")
+		},
+		Rollback: func(tx *gorm.DB) error {
+			if !tx.Migrator().HasColumn(&models.Message{}, "Parts") {
+				return nil
+			}
+			return tx.Migrator().DropColumn(&models.Message{}, "Parts")
+		},
+	})
+}
@@ -0,0 +1,37 @@
+package migrations
+
+import (
+	"AkuAI/models"
+
+	"github.com/go-gormigrate/gormigrate/v2"
+	"gorm.io/gorm"
+)
+
+// Gemini token usage of bot replies.
+func init() {
+	register(&gormigrate.Migration{
+		ID: "2026101514_message_usage",
+		Migrate: func(tx *gorm.DB) error {
+			for _, col := range []string{"PromptTokens", "OutputTokens"} {
+				if tx.Migrator().HasColumn(&models.Message{}, col) {
+					continue
+				}
+				if err := tx.Migrator().AddColumn(&models.Message{}, col); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+		Rollback: func(tx *gorm.DB) error {
+			for _, col := range []string{"OutputTokens", "PromptTokens"} {
+				if !tx.Migrator().HasColumn(&models.Message{}, col) {
+					continue
+				}
+				if err := tx.Migrator().DropColumn(&models.Message{}, col); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	})
+}
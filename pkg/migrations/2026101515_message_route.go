@@ -0,0 +1,27 @@
+package migrations
+
+import (
+	"AkuAI/models"
+
+	"github.com/go-gormigrate/gormigrate/v2"
+	"gorm.io/gorm"
+)
+
+// Model route of bot replies.
+func init() {
+	register(&gormigrate.Migration{
+		ID: "2026101515_message_route",
+		Migrate: func(tx *gorm.DB) error {
+			if tx.Migrator().HasColumn(&models.Message{}, "Route") {
+				return nil
+			}
+			return tx.Migrator().AddColumn(&models.Message{}, "Route")
+		},
+		Rollback: func(tx *gorm.DB) error {
+			if !tx.Migrator().HasColumn(&models.Message{}, "Route") {
+				return nil
+			}
+			return tx.Migrator().DropColumn(&models.Message{}, "Route")
+		},
+	})
+}
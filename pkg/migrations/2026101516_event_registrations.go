@@ -0,0 +1,24 @@
+package migrations
+
+import (
+	"AkuAI/models"
+
+	"github.com/go-gormigrate/gormigrate/v2"
+	"gorm.io/gorm"
+)
+
+// Registrations for events, with their waiting lists.
+func init() {
+	register(&gormigrate.Migration{
+		ID: "2026101516_event_registrations",
+		Migrate: func(tx *gorm.DB) error {
+			if tx.Migrator().HasTable(&models.EventRegistration{}) {
+				return nil
+			}
+			return tx.Migrator().CreateTable(&models.EventRegistration{})
+		},
+		Rollback: func(tx *gorm.DB) error {
+			return tx.Migrator().DropTable(&models.EventRegistration{})
+		},
+	})
+}
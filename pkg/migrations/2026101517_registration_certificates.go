@@ -0,0 +1,37 @@
+package migrations
+
+import (
+	"AkuAI/models"
+
+	"github.com/go-gormigrate/gormigrate/v2"
+	"gorm.io/gorm"
+)
+
+// Check-in time and stored certificate of event registrations.
+func init() {
+	register(&gormigrate.Migration{
+		ID: "2026101517_registration_certificates",
+		Migrate: func(tx *gorm.DB) error {
+			for _, col := range []string{"CheckedInAt", "CertificatePath"} {
+				if tx.Migrator().HasColumn(&models.EventRegistration{}, col) {
+					continue
+				}
+				if err := tx.Migrator().AddColumn(&models.EventRegistration{}, col); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+		Rollback: func(tx *gorm.DB) error {
+			for _, col := range []string{"CheckedInAt", "CertificatePath"} {
+				if !tx.Migrator().HasColumn(&models.EventRegistration{}, col) {
+					continue
+				}
+				if err := tx.Migrator().DropColumn(&models.EventRegistration{}, col); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	})
+}
@@ -0,0 +1,24 @@
+package migrations
+
+import (
+	"AkuAI/models"
+
+	"github.com/go-gormigrate/gormigrate/v2"
+	"gorm.io/gorm"
+)
+
+// Change history of dataset events.
+func init() {
+	register(&gormigrate.Migration{
+		ID: "2026101518_event_revisions",
+		Migrate: func(tx *gorm.DB) error {
+			if tx.Migrator().HasTable(&models.EventRevision{}) {
+				return nil
+			}
+			return tx.Migrator().CreateTable(&models.EventRevision{})
+		},
+		Rollback: func(tx *gorm.DB) error {
+			return tx.Migrator().DropTable(&models.EventRevision{})
+		},
+	})
+}
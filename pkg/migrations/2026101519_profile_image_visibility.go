@@ -0,0 +1,27 @@
+package migrations
+
+import (
+	"AkuAI/models"
+
+	"github.com/go-gormigrate/gormigrate/v2"
+	"gorm.io/gorm"
+)
+
+// Public or private (signed URLs only) profile images.
+func init() {
+	register(&gormigrate.Migration{
+		ID: "2026101519_profile_image_visibility",
+		Migrate: func(tx *gorm.DB) error {
+			if tx.Migrator().HasColumn(&models.User{}, "ProfileImagePublic") {
+				return nil
+			}
+			return tx.Migrator().AddColumn(&models.User{}, "ProfileImagePublic")
+		},
+		Rollback: func(tx *gorm.DB) error {
+			if !tx.Migrator().HasColumn(&models.User{}, "ProfileImagePublic") {
+				return nil
+			}
+			return tx.Migrator().DropColumn(&models.User{}, "ProfileImagePublic")
+		},
+	})
+}
@@ -0,0 +1,33 @@
+package migrations
+
+import (
+	"github.com/go-gormigrate/gormigrate/v2"
+	"gorm.io/gorm"
+)
+
+// userLocale is users.locale, which 2026101521_user_preferences moves to
+// user_preferences.language.
+type userLocale struct {
+	Locale string `gorm:"size:8"`
+}
+
+func (userLocale) TableName() string { return "users" }
+
+// Preferred locale of server texts and errors, from the profile.
+func init() {
+	register(&gormigrate.Migration{
+		ID: "2026101520_user_locale",
+		Migrate: func(tx *gorm.DB) error {
+			if tx.Migrator().HasColumn(&userLocale{}, "Locale") {
+				return nil
+			}
+			return tx.Migrator().AddColumn(&userLocale{}, "Locale")
+		},
+		Rollback: func(tx *gorm.DB) error {
+			if !tx.Migrator().HasColumn(&userLocale{}, "Locale") {
+				return nil
+			}
+			return tx.Migrator().DropColumn(&userLocale{}, "Locale")
+		},
+	})
+}
@@ -0,0 +1,46 @@
+package migrations
+
+import (
+	"AkuAI/models"
+
+	"github.com/go-gormigrate/gormigrate/v2"
+	"gorm.io/gorm"
+)
+
+// Reply preferences per user; the profile locale becomes their language.
+func init() {
+	register(&gormigrate.Migration{
+		ID: "2026101521_user_preferences",
+		Migrate: func(tx *gorm.DB) error {
+			if !tx.Migrator().HasTable(&models.UserPreferences{}) {
+				if err := tx.Migrator().CreateTable(&models.UserPreferences{}); err != nil {
+					return err
+				}
+			}
+			if !tx.Migrator().HasColumn(&userLocale{}, "Locale") {
+				return nil
+			}
+			err := tx.Exec(`INSERT INTO user_preferences (user_id, language, verbosity, markdown, emoji, created_at, updated_at)
+				SELECT id, locale, ?, ?, ?, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP FROM users
+				WHERE locale IS NOT NULL AND locale <> '' AND id NOT IN (SELECT user_id FROM user_preferences)`,
+				models.VerbosityNormal, true, true).Error
+			if err != nil {
+				return err
+			}
+			return tx.Migrator().DropColumn(&userLocale{}, "Locale")
+		},
+		Rollback: func(tx *gorm.DB) error {
+			if !tx.Migrator().HasColumn(&userLocale{}, "Locale") {
+				if err := tx.Migrator().AddColumn(&userLocale{}, "Locale"); err != nil {
+					return err
+				}
+			}
+			err := tx.Exec(`UPDATE users SET locale = (SELECT language FROM user_preferences
+				WHERE user_preferences.user_id = users.id AND user_preferences.deleted_at IS NULL)`).Error
+			if err != nil {
+				return err
+			}
+			return tx.Migrator().DropTable(&models.UserPreferences{})
+		},
+	})
+}
@@ -0,0 +1,30 @@
+package migrations
+
+import (
+	"AkuAI/models"
+
+	"github.com/go-gormigrate/gormigrate/v2"
+	"gorm.io/gorm"
+)
+
+// Privacy mode of conversations whose messages are purged after the session.
+func init() {
+	register(&gormigrate.Migration{
+		ID: "2026101522_conversation_incognito",
+		Migrate: func(tx *gorm.DB) error {
+			if tx.Migrator().HasColumn(&models.Conversation{}, "Incognito") {
+				return nil
+			}
+			if err := tx.Migrator().AddColumn(&models.Conversation{}, "Incognito"); err != nil {
+				return err
+			}
+			return tx.Migrator().CreateIndex(&models.Conversation{}, "Incognito")
+		},
+		Rollback: func(tx *gorm.DB) error {
+			if !tx.Migrator().HasColumn(&models.Conversation{}, "Incognito") {
+				return nil
+			}
+			return tx.Migrator().DropColumn(&models.Conversation{}, "Incognito")
+		},
+	})
+}
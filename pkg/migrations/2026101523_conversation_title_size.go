@@ -0,0 +1,33 @@
+package migrations
+
+import (
+	"github.com/go-gormigrate/gormigrate/v2"
+	"gorm.io/gorm"
+)
+
+// conversationTitle is conversations.title after it grew to hold titles
+// sealed by pkg/fieldcrypt; conversationTitleBefore is the old size.
+type conversationTitle struct {
+	Title string `gorm:"size:512"`
+}
+
+func (conversationTitle) TableName() string { return "conversations" }
+
+type conversationTitleBefore struct {
+	Title string `gorm:"size:200"`
+}
+
+func (conversationTitleBefore) TableName() string { return "conversations" }
+
+// Room for encrypted conversation titles.
+func init() {
+	register(&gormigrate.Migration{
+		ID: "2026101523_conversation_title_size",
+		Migrate: func(tx *gorm.DB) error {
+			return tx.Migrator().AlterColumn(&conversationTitle{}, "Title")
+		},
+		Rollback: func(tx *gorm.DB) error {
+			return tx.Migrator().AlterColumn(&conversationTitleBefore{}, "Title")
+		},
+	})
+}
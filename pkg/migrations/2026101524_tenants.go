@@ -0,0 +1,85 @@
+package migrations
+
+import (
+	"AkuAI/models"
+
+	"github.com/go-gormigrate/gormigrate/v2"
+	"gorm.io/gorm"
+)
+
+// userIdentityBefore is the unique email and username of users before they
+// became unique per tenant.
+type userIdentityBefore struct {
+	Email    string `gorm:"uniqueIndex;size:120;not null"`
+	Username string `gorm:"uniqueIndex;size:80;not null"`
+}
+
+func (userIdentityBefore) TableName() string { return "users" }
+
+// Tenant of users, conversations and event registrations and revisions;
+// emails and usernames are unique per tenant. Existing rows belong to the
+// operator tenant "".
+func init() {
+	tables := []any{&models.User{}, &models.Conversation{}, &models.EventRegistration{}, &models.EventRevision{}}
+	register(&gormigrate.Migration{
+		ID: "2026101524_tenants",
+		Migrate: func(tx *gorm.DB) error {
+			m := tx.Migrator()
+			for _, model := range tables {
+				if m.HasColumn(model, "TenantID") {
+					continue
+				}
+				if err := m.AddColumn(model, "TenantID"); err != nil {
+					return err
+				}
+				if _, user := model.(*models.User); user {
+					continue
+				}
+				if err := m.CreateIndex(model, "TenantID"); err != nil {
+					return err
+				}
+			}
+			for _, name := range []string{"idx_users_email", "idx_users_username"} {
+				if m.HasIndex(&models.User{}, name) {
+					if err := m.DropIndex(&models.User{}, name); err != nil {
+						return err
+					}
+				}
+			}
+			for _, name := range []string{"idx_users_tenant_email", "idx_users_tenant_username"} {
+				if !m.HasIndex(&models.User{}, name) {
+					if err := m.CreateIndex(&models.User{}, name); err != nil {
+						return err
+					}
+				}
+			}
+			return nil
+		},
+		Rollback: func(tx *gorm.DB) error {
+			m := tx.Migrator()
+			for _, name := range []string{"idx_users_tenant_email", "idx_users_tenant_username"} {
+				if m.HasIndex(&models.User{}, name) {
+					if err := m.DropIndex(&models.User{}, name); err != nil {
+						return err
+					}
+				}
+			}
+			for _, model := range tables {
+				if !m.HasColumn(model, "TenantID") {
+					continue
+				}
+				if err := m.DropColumn(model, "TenantID"); err != nil {
+					return err
+				}
+			}
+			for _, field := range []string{"Email", "Username"} {
+				if !m.HasIndex(&userIdentityBefore{}, field) {
+					if err := m.CreateIndex(&userIdentityBefore{}, field); err != nil {
+						return err
+					}
+				}
+			}
+			return nil
+		},
+	})
+}
@@ -0,0 +1,24 @@
+package migrations
+
+import (
+	"AkuAI/models"
+
+	"github.com/go-gormigrate/gormigrate/v2"
+	"gorm.io/gorm"
+)
+
+// Versioned system-instruction blocks per tenant and campus.
+func init() {
+	register(&gormigrate.Migration{
+		ID: "2026101525_prompt_versions",
+		Migrate: func(tx *gorm.DB) error {
+			if tx.Migrator().HasTable(&models.PromptVersion{}) {
+				return nil
+			}
+			return tx.Migrator().CreateTable(&models.PromptVersion{})
+		},
+		Rollback: func(tx *gorm.DB) error {
+			return tx.Migrator().DropTable(&models.PromptVersion{})
+		},
+	})
+}
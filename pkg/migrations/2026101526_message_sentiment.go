@@ -0,0 +1,43 @@
+package migrations
+
+import (
+	"AkuAI/models"
+
+	"github.com/go-gormigrate/gormigrate/v2"
+	"gorm.io/gorm"
+)
+
+// Sentiment of user messages and the frustration flag of conversations.
+func init() {
+	register(&gormigrate.Migration{
+		ID: "2026101526_message_sentiment",
+		Migrate: func(tx *gorm.DB) error {
+			for _, c := range []struct {
+				model any
+				field string
+			}{{&models.Message{}, "Sentiment"}, {&models.Conversation{}, "FrustrationFlaggedAt"}} {
+				if tx.Migrator().HasColumn(c.model, c.field) {
+					continue
+				}
+				if err := tx.Migrator().AddColumn(c.model, c.field); err != nil {
+					return err
+				}
+				if err := tx.Migrator().CreateIndex(c.model, c.field); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+		Rollback: func(tx *gorm.DB) error {
+			if tx.Migrator().HasColumn(&models.Conversation{}, "FrustrationFlaggedAt") {
+				if err := tx.Migrator().DropColumn(&models.Conversation{}, "FrustrationFlaggedAt"); err != nil {
+					return err
+				}
+			}
+			if !tx.Migrator().HasColumn(&models.Message{}, "Sentiment") {
+				return nil
+			}
+			return tx.Migrator().DropColumn(&models.Message{}, "Sentiment")
+		},
+	})
+}
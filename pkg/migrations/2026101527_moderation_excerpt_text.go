@@ -0,0 +1,33 @@
+package migrations
+
+import (
+	"github.com/go-gormigrate/gormigrate/v2"
+	"gorm.io/gorm"
+)
+
+// moderationExcerpt is moderation_events.excerpt after it grew to hold
+// excerpts sealed by pkg/fieldcrypt; moderationExcerptBefore is the old size.
+type moderationExcerpt struct {
+	Excerpt string `gorm:"type:text"`
+}
+
+func (moderationExcerpt) TableName() string { return "moderation_events" }
+
+type moderationExcerptBefore struct {
+	Excerpt string `gorm:"size:255"`
+}
+
+func (moderationExcerptBefore) TableName() string { return "moderation_events" }
+
+// Room for encrypted moderation excerpts.
+func init() {
+	register(&gormigrate.Migration{
+		ID: "2026101527_moderation_excerpt_text",
+		Migrate: func(tx *gorm.DB) error {
+			return tx.Migrator().AlterColumn(&moderationExcerpt{}, "Excerpt")
+		},
+		Rollback: func(tx *gorm.DB) error {
+			return tx.Migrator().AlterColumn(&moderationExcerptBefore{}, "Excerpt")
+		},
+	})
+}
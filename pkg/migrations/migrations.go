@@ -0,0 +1,98 @@
+// Package migrations versions the database schema with gormigrate.
+//
+// A database without migration history (fresh, or created by the old
+// AutoMigrate-on-startup) is brought to the current schema by
+// models.AutoMigrate once and every registered migration is marked as done.
+// Later schema changes that AutoMigrate cannot make safely - renames,
+// indexes, backfills - go into their own file named after the migration ID
+// (YYYYMMDDNN_description.go) that registers it from init().
+package migrations
+
+import (
+	"AkuAI/models"
+	"sort"
+
+	"github.com/go-gormigrate/gormigrate/v2"
+	"gorm.io/gorm"
+)
+
+// TableName records which migrations have run.
+const TableName = "schema_migrations"
+
+// SchemaInitID is the history entry of the initial models.AutoMigrate run.
+const SchemaInitID = "SCHEMA_INIT"
+
+var registry []*gormigrate.Migration
+
+func register(m *gormigrate.Migration) {
+	registry = append(registry, m)
+}
+
+// List returns the registered migrations in ID order.
+func List() []*gormigrate.Migration {
+	out := append([]*gormigrate.Migration(nil), registry...)
+	sort.Slice(out, func(i, j int) bool { return out[i].ID < out[j].ID })
+	return out
+}
+
+func migrator(db *gorm.DB) *gormigrate.Gormigrate {
+	m := gormigrate.New(db, &gormigrate.Options{
+		TableName:    TableName,
+		IDColumnName: "id",
+		IDColumnSize: 255,
+		// MySQL commits DDL implicitly, so a transaction would only wrap the
+		// data changes; SQLite and Postgres roll back a failed migration fully.
+		UseTransaction: db.Dialector.Name() != "mysql",
+	}, List())
+	m.InitSchema(models.AutoMigrate)
+	return m
+}
+
+// Up applies every pending migration.
+func Up(db *gorm.DB) error {
+	return migrator(db).Migrate()
+}
+
+// To applies pending migrations up to and including id.
+func To(db *gorm.DB, id string) error {
+	return migrator(db).MigrateTo(id)
+}
+
+// Down rolls back the last applied migration.
+func Down(db *gorm.DB) error {
+	return migrator(db).RollbackLast()
+}
+
+// Applied returns the IDs recorded in the history table, nil when the table
+// does not exist yet.
+func Applied(db *gorm.DB) ([]string, error) {
+	if !db.Migrator().HasTable(TableName) {
+		return nil, nil
+	}
+	var ids []string
+	err := db.Table(TableName).Order("id").Pluck("id", &ids).Error
+	return ids, err
+}
+
+// Pending returns the migrations Up would run: just SchemaInitID for a
+// database without history, otherwise the registered IDs not applied yet.
+func Pending(db *gorm.DB) ([]string, error) {
+	applied, err := Applied(db)
+	if err != nil {
+		return nil, err
+	}
+	if len(applied) == 0 {
+		return []string{SchemaInitID}, nil
+	}
+	done := make(map[string]bool, len(applied))
+	for _, id := range applied {
+		done[id] = true
+	}
+	var pending []string
+	for _, m := range List() {
+		if !done[m.ID] {
+			pending = append(pending, m.ID)
+		}
+	}
+	return pending, nil
+}
@@ -0,0 +1,153 @@
+package moderation
+
+import (
+	"context"
+	"log"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+type Action string
+
+const (
+	Allow Action = "allow"
+	Flag  Action = "flag"  // let through, but record it
+	Block Action = "block" // refuse without calling the LLM
+)
+
+// Verdict is the outcome of checking one message.
+type Verdict struct {
+	Action   Action `json:"action"`
+	Category string `json:"category,omitempty"`
+	Matched  string `json:"matched,omitempty"`
+	Source   string `json:"source,omitempty"` // keywords | gemini
+}
+
+// SafetyChecker rates text with an external classifier such as Gemini's
+// safety ratings. It is only consulted when the keyword lists allow a message.
+type SafetyChecker interface {
+	CheckSafety(ctx context.Context, text string) (Verdict, error)
+}
+
+// DefaultBlock and DefaultFlag are the built-in keyword lists per category.
+// Phrases match on word boundaries, case-insensitively.
+var (
+	DefaultBlock = map[string][]string{
+		"sexual":           {"bokep", "porn", "pornografi", "video mesum", "open bo"},
+		"violence":         {"cara membuat bom", "cara merakit bom", "rakit bom", "cara membunuh"},
+		"drugs":            {"jual narkoba", "beli narkoba", "beli sabu", "jual sabu"},
+		"academic_fraud":   {"joki skripsi", "jasa joki", "joki tugas", "beli ijazah", "jual ijazah"},
+		"self_harm_method": {"cara bunuh diri"},
+	}
+	DefaultFlag = map[string][]string{
+		"profanity": {"anjing", "bangsat", "goblok", "tolol", "bego", "kampret", "brengsek", "fuck", "shit"},
+	}
+)
+
+type rule struct {
+	category string
+	phrase   string
+	re       *regexp.Regexp
+}
+
+// Moderator checks chat messages against keyword lists and, optionally, a
+// SafetyChecker.
+type Moderator struct {
+	block   []rule
+	flag    []rule
+	checker SafetyChecker
+}
+
+func New(block, flag map[string][]string, checker SafetyChecker) *Moderator {
+	return &Moderator{block: compile(block), flag: compile(flag), checker: checker}
+}
+
+func compile(lists map[string][]string) []rule {
+	var rules []rule
+	for category, phrases := range lists {
+		for _, p := range phrases {
+			p = strings.ToLower(strings.TrimSpace(p))
+			if p == "" {
+				continue
+			}
+			words := strings.Fields(p)
+			for i, w := range words {
+				words[i] = regexp.QuoteMeta(w)
+			}
+			re := regexp.MustCompile(`\b` + strings.Join(words, `\s+`) + `\b`)
+			rules = append(rules, rule{category: category, phrase: p, re: re})
+		}
+	}
+	return rules
+}
+
+// Check returns the verdict for text. Block rules win over flag rules; the
+// SafetyChecker runs last and its errors fail open.
+func (m *Moderator) Check(ctx context.Context, text string) Verdict {
+	if m == nil {
+		return Verdict{Action: Allow}
+	}
+	lower := strings.ToLower(text)
+	for _, r := range m.block {
+		if r.re.MatchString(lower) {
+			return Verdict{Action: Block, Category: r.category, Matched: r.phrase, Source: "keywords"}
+		}
+	}
+	if m.checker != nil {
+		v, err := m.checker.CheckSafety(ctx, text)
+		if err != nil {
+			log.Printf("[moderation] ⚠️ safety check failed, allowing: %v", err)
+		} else if v.Action == Block || v.Action == Flag {
+			return v
+		}
+	}
+	for _, r := range m.flag {
+		if r.re.MatchString(lower) {
+			return Verdict{Action: Flag, Category: r.category, Matched: r.phrase, Source: "keywords"}
+		}
+	}
+	return Verdict{Action: Allow}
+}
+
+// Refusal is the message shown to the user instead of a bot reply.
+func Refusal(v Verdict) string {
+	switch v.Category {
+	case "academic_fraud":
+		return "Maaf, saya tidak dapat membantu permintaan joki atau pemalsuan dokumen akademik. Silakan hubungi dosen pembimbing atau layanan akademik UIB untuk bantuan yang sah."
+	case "self_harm", "self_harm_method":
+		return "Maaf, saya tidak dapat membantu permintaan ini. Jika kamu sedang mengalami masa sulit, kamu tidak sendiri — hubungi layanan konseling kampus atau layanan darurat 119 ext. 8."
+	default:
+		return "Maaf, pesan ini melanggar pedoman penggunaan AkuAI sehingga tidak dapat diproses. Silakan ajukan pertanyaan seputar kampus dan kegiatan UIB."
+	}
+}
+
+var (
+	defaultMu  sync.RWMutex
+	defaultMod *Moderator
+)
+
+// Init installs m as the moderator used by Default. Passing nil disables
+// moderation.
+func Init(m *Moderator) {
+	defaultMu.Lock()
+	defaultMod = m
+	defaultMu.Unlock()
+}
+
+func Default() *Moderator {
+	defaultMu.RLock()
+	defer defaultMu.RUnlock()
+	return defaultMod
+}
+
+// ParseKeywords splits a comma-separated keyword list.
+func ParseKeywords(s string) []string {
+	var out []string
+	for _, k := range strings.Split(s, ",") {
+		if k = strings.TrimSpace(k); k != "" {
+			out = append(out, k)
+		}
+	}
+	return out
+}
@@ -0,0 +1,174 @@
+// Package postprocess cleans up model replies before they reach the user:
+// Unicode and whitespace normalization, repair of URLs and e-mail addresses
+// broken by stray spaces, dictionary-based de-hyphenation of split words and
+// light markdown normalization; replies of users who turned markdown or
+// emoji off also lose those (PlainText, NoEmoji).
+//
+// The same Pipeline is applied to streamed chunks (through a Streamer, which
+// holds text back until a safe boundary) and to the final text that is saved.
+package postprocess
+
+import (
+	"bufio"
+	_ "embed"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+)
+
+//go:embed words.txt
+var defaultWords string
+
+// Dictionary is the set of known words (lower case) and domains used by the
+// urls and words steps.
+type Dictionary struct {
+	words   map[string]bool
+	domains []string
+}
+
+// NewDictionary returns a dictionary with the embedded default entries.
+func NewDictionary() *Dictionary {
+	d := &Dictionary{words: map[string]bool{}}
+	_ = d.Load(strings.NewReader(defaultWords))
+	return d
+}
+
+// Load adds whitespace-separated entries from r; '#' starts a comment and
+// entries containing a dot are domains.
+func (d *Dictionary) Load(r io.Reader) error {
+	sc := bufio.NewScanner(r)
+	for sc.Scan() {
+		line, _, _ := strings.Cut(sc.Text(), "#")
+		for _, w := range strings.Fields(strings.ToLower(line)) {
+			if strings.Contains(w, ".") {
+				d.domains = append(d.domains, w)
+			} else {
+				d.words[w] = true
+			}
+		}
+	}
+	return sc.Err()
+}
+
+// Has reports whether w is a known word, ignoring case.
+func (d *Dictionary) Has(w string) bool {
+	return d.words[strings.ToLower(w)]
+}
+
+// Step is one named transformation. Steps must be idempotent and must not
+// depend on text outside the segment they get, because streamed replies are
+// processed a few sentences at a time.
+type Step struct {
+	Name  string
+	Apply func(string) string
+}
+
+// DefaultSteps is the order steps run in when POSTPROCESS_STEPS is unset.
+var DefaultSteps = []string{"unicode", "urls", "numbers", "words", "whitespace", "markdown"}
+
+// Steps for users who turned markdown or emoji off; see Pipeline.With.
+var (
+	PlainText = Step{"plain", stripMarkdown}
+	NoEmoji   = Step{"noemoji", stripEmoji}
+)
+
+// Pipeline runs steps in order.
+type Pipeline struct {
+	steps []Step
+	dict  *Dictionary
+}
+
+// New builds a pipeline from step names.
+func New(names []string, dict *Dictionary) (*Pipeline, error) {
+	available := map[string]Step{
+		"unicode":    {"unicode", normalizeUnicode},
+		"urls":       {"urls", urlRepair(dict)},
+		"numbers":    {"numbers", repairNumbers},
+		"words":      {"words", wordRepair(dict)},
+		"whitespace": {"whitespace", normalizeWhitespace},
+		"markdown":   {"markdown", normalizeMarkdown},
+		"plain":      PlainText,
+		"noemoji":    NoEmoji,
+	}
+	p := &Pipeline{dict: dict}
+	for _, name := range names {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+		s, ok := available[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown post-processing step %q", name)
+		}
+		p.steps = append(p.steps, s)
+	}
+	return p, nil
+}
+
+// Steps returns the names of the configured steps.
+func (p *Pipeline) Steps() []string {
+	names := make([]string, len(p.steps))
+	for i, s := range p.steps {
+		names[i] = s.Name
+	}
+	return names
+}
+
+// With returns a copy of p that runs steps after its own.
+func (p *Pipeline) With(steps ...Step) *Pipeline {
+	if len(steps) == 0 {
+		return p
+	}
+	return &Pipeline{steps: append(slices.Clip(p.steps), steps...), dict: p.dict}
+}
+
+// Apply processes a complete reply and trims it.
+func (p *Pipeline) Apply(text string) string {
+	return strings.TrimSpace(p.segment(text))
+}
+
+func (p *Pipeline) segment(text string) string {
+	for _, s := range p.steps {
+		text = s.Apply(text)
+	}
+	return text
+}
+
+var (
+	defaultPipeline *Pipeline
+	once            sync.Once
+)
+
+// Init builds the default pipeline from step names and an optional extra
+// dictionary file. Calls after the first one are ignored.
+func Init(steps []string, dictionaryPath string) (*Pipeline, error) {
+	var err error
+	once.Do(func() {
+		dict := NewDictionary()
+		if dictionaryPath != "" {
+			var f *os.File
+			if f, err = os.Open(dictionaryPath); err == nil {
+				err = dict.Load(f)
+				f.Close()
+			}
+			if err != nil {
+				err = fmt.Errorf("load dictionary %s: %w", dictionaryPath, err)
+			}
+		}
+		p, perr := New(steps, dict)
+		if perr != nil {
+			err = perr
+			p, _ = New(DefaultSteps, dict)
+		}
+		defaultPipeline = p
+	})
+	return defaultPipeline, err
+}
+
+func Default() *Pipeline {
+	p, _ := Init(DefaultSteps, "")
+	return p
+}
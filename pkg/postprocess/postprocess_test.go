@@ -0,0 +1,131 @@
+package postprocess
+
+import (
+	"strings"
+	"testing"
+)
+
+var cases = []struct {
+	name, in, want string
+}{
+	{"split words", "Sertifi kasi Microsoft untu k pese rta umum", "Sertifikasi Microsoft untuk peserta umum"},
+	{"split acronym and months", "Webinar U IB bulan No vember", "Webinar UIB bulan November"},
+	{"hyphenated line break", "Informasi Persyara-\ntan lengkap", "Informasi Persyaratan lengkap"},
+	{"hyphen space", "Hubungi Pembic- ara kami", "Hubungi Pembicara kami"},
+	{"real words stay apart", "di atas meja dan ke luar gedung", "di atas meja dan ke luar gedung"},
+	{"unknown words stay apart", "Halo Budi Santoso", "Halo Budi Santoso"},
+	{"glued words", "Terbuka untukumum, info lebihlanjut di webinarUIB", "Terbuka untuk umum, info lebih lanjut di webinar UIB"},
+	{"glued acronym", "UIBDepartemen Akuntansi", "UIB Departemen Akuntansi"},
+	{"dictionary camel case kept", "Tonton di YouTube dan McDonald", "Tonton di YouTube dan McDonald"},
+	{"url scheme", "Daftar di h ttps : // uib.ac.id/daftar", "Daftar di https://uib.ac.id/daftar"},
+	{"domain", "Kunjungi ui b.ac.id atau uib. ac.id atau uib.a c.id", "Kunjungi uib.ac.id atau uib.ac.id atau uib.ac.id"},
+	{"email", "Email info@uib.a c.id atau it-certification @uib.ac.id", "Email info@uib.ac.id atau it-certification@uib.ac.id"},
+	{"email other domain", "Kirim ke panitia@gmail. com ya", "Kirim ke panitia@gmail.com ya"},
+	{"numbers", "Pukul 1 6:00-17:3 0, 2025-10 -18, tahun 2 025, Rp 500.00 0", "Pukul 16:00-17:30, 2025-10-18, tahun 2025, Rp 500.000"},
+	{"not a time", "Sesi 2 9:00 pagi", "Sesi 2 9:00 pagi"},
+	{"whitespace", "Halo\u00a0 \u200bdunia  ,  apa\tkabar   \r\n\r\n\r\n\r\nBaik .", "Halo dunia, apa kabar\n\nBaik."},
+	{"bold across words", "Catatan: ** Wajib membawa KTP ** saat hadir", "Catatan: **Wajib membawa KTP** saat hadir"},
+	{"markdown", "#Jadwal\n• satu\n* dua\n  * nested\n** Penting ** dan **ini**", "# Jadwal\n- satu\n- dua\n  - nested\n**Penting** dan **ini**"},
+}
+
+func TestApply(t *testing.T) {
+	p := Default()
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := p.Apply(tc.in); got != tc.want {
+				t.Errorf("Apply(%q)\n got %q\nwant %q", tc.in, got, tc.want)
+			}
+			if got := p.Apply(tc.want); got != tc.want {
+				t.Errorf("Apply is not idempotent: %q -> %q", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestStreamMatchesApply(t *testing.T) {
+	p := Default()
+	for _, tc := range cases {
+		for _, size := range []int{1, 3, 7, 64} {
+			var out strings.Builder
+			s := p.Stream(func(chunk string) { out.WriteString(chunk) })
+			rs := []rune(tc.in)
+			for i := 0; i < len(rs); i += size {
+				s.Write(string(rs[i:min(i+size, len(rs))]))
+			}
+			s.Flush()
+			if got := out.String(); got != tc.want {
+				t.Errorf("%s, chunks of %d:\n got %q\nwant %q", tc.name, size, got, tc.want)
+			}
+		}
+	}
+}
+
+func TestStreamEmitsBeforeSentenceEnd(t *testing.T) {
+	var out []string
+	s := Default().Stream(func(chunk string) { out = append(out, chunk) })
+	for _, w := range strings.Fields("Ada webinar menarik minggu ini untuk Sertifi kasi") {
+		s.Write(w + " ")
+	}
+	if len(out) == 0 {
+		t.Fatal("nothing emitted before Flush")
+	}
+	if joined := strings.Join(out, ""); strings.Contains(joined, "Sertifi") {
+		t.Errorf("emitted %q before the split word could be repaired", joined)
+	}
+	s.Flush()
+	if got, want := strings.Join(out, ""), "Ada webinar menarik minggu ini untuk Sertifikasi"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestNewUnknownStep(t *testing.T) {
+	if _, err := New([]string{"unicode", "spellcheck"}, NewDictionary()); err == nil {
+		t.Fatal("expected an error for an unknown step")
+	}
+	p, err := New([]string{" Unicode ", "", "markdown"}, NewDictionary())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Join(p.Steps(), ","); got != "unicode,markdown" {
+		t.Errorf("Steps() = %s", got)
+	}
+}
+
+func TestDictionaryLoad(t *testing.T) {
+	d := NewDictionary()
+	if err := d.Load(strings.NewReader("# campus terms\nrektorat kemahasiswaan # inline\nakuai.id\n")); err != nil {
+		t.Fatal(err)
+	}
+	p, _ := New(DefaultSteps, d)
+	got := p.Apply("Ke bagian Rekto rat lewat akuai. id")
+	if want := "Ke bagian Rektorat lewat akuai.id"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestWithPreferenceSteps(t *testing.T) {
+	p := Default().With(PlainText, NoEmoji)
+	in := "## Jadwal 📅\n- **Webinar AI Untuk Semua** 📍 Online [EV-WEB-NOV-001]\n- Daftar di [sini](https://uib.ac.id/daftar) 🎉!\n\nSampai jumpa 👋"
+	want := "Jadwal\n- Webinar AI Untuk Semua Online [EV-WEB-NOV-001]\n- Daftar di sini (https://uib.ac.id/daftar)!\n\nSampai jumpa"
+	if got := p.Apply(in); got != want {
+		t.Fatalf("Apply\n got %q\nwant %q", got, want)
+	}
+	if got := p.Apply(want); got != want {
+		t.Fatalf("not idempotent: %q", got)
+	}
+	for _, size := range []int{1, 3, 7, 64} {
+		var out strings.Builder
+		s := p.Stream(func(chunk string) { out.WriteString(chunk) })
+		rs := []rune(in)
+		for i := 0; i < len(rs); i += size {
+			s.Write(string(rs[i:min(i+size, len(rs))]))
+		}
+		s.Flush()
+		if got := out.String(); got != want {
+			t.Errorf("chunks of %d:\n got %q\nwant %q", size, got, want)
+		}
+	}
+	if got := Default().Apply("**Penting** 📌"); got != "**Penting** 📌" {
+		t.Fatalf("default pipeline changed: %q", got)
+	}
+}
@@ -0,0 +1,271 @@
+package postprocess
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// normalizeUnicode unifies line endings, turns non-breaking spaces and tabs
+// into spaces and drops zero-width characters and invalid UTF-8.
+func normalizeUnicode(s string) string {
+	s = strings.ReplaceAll(s, "\r\n", "\n")
+	s = strings.ReplaceAll(s, "\r", "\n")
+	s = strings.Map(func(r rune) rune {
+		switch r {
+		case '\u200B', '\u200C', '\u200D', '\u2060', '\uFEFF':
+			return -1
+		case '\u00A0', '\t':
+			return ' '
+		}
+		return r
+	}, s)
+	if !utf8.ValidString(s) {
+		s = strings.ToValidUTF8(s, "")
+	}
+	return s
+}
+
+var (
+	brokenScheme    = regexp.MustCompile(`\bh ?t ?t ?p( ?s)?\s*:\s*/\s*/\s*`) // "h ttps : // " -> "https://"
+	spaceBeforeAt   = regexp.MustCompile(`([\w.+-]) +@`)                      // "it-certification @" -> "it-certification@"
+	spaceAfterAt    = regexp.MustCompile(`@ +(\w)`)                           // "info@ uib" -> "info@uib"
+	spaceInLocalTLD = regexp.MustCompile(`(@[\w-]+(?:\.[\w-]+)*)\. +(ac|co|or|go|id|com|org|net|edu)\b`)
+)
+
+// urlRepair removes spaces that split URL schemes, e-mail addresses and the
+// dictionary's domains ("ui b.ac.id", "uib. ac.id", "uib.a c.id").
+func urlRepair(d *Dictionary) func(string) string {
+	var domains []*regexp.Regexp
+	var names []string
+	for _, dom := range d.domains {
+		var b strings.Builder
+		b.WriteString(`(?i)\b`)
+		for i, r := range dom {
+			if i > 0 {
+				if r == '.' {
+					b.WriteString(`\s*`)
+				} else {
+					b.WriteString(` ?`)
+				}
+			}
+			b.WriteString(regexp.QuoteMeta(string(r)))
+			if r == '.' {
+				b.WriteString(`\s*`)
+			}
+		}
+		b.WriteString(`\b`)
+		domains = append(domains, regexp.MustCompile(b.String()))
+		names = append(names, dom)
+	}
+	return func(s string) string {
+		s = brokenScheme.ReplaceAllStringFunc(s, func(m string) string {
+			if strings.Contains(strings.TrimPrefix(m, "h"), "s") {
+				return "https://"
+			}
+			return "http://"
+		})
+		for i, re := range domains {
+			s = re.ReplaceAllString(s, names[i])
+		}
+		s = spaceBeforeAt.ReplaceAllString(s, "$1@")
+		s = spaceAfterAt.ReplaceAllString(s, "@$1")
+		return spaceInLocalTLD.ReplaceAllString(s, "$1.$2")
+	}
+}
+
+var (
+	splitTime      = regexp.MustCompile(`\b([12]) ([0-9]):([0-5]\d)\b`)  // "1 6:00" -> "16:00"
+	splitTimeTail  = regexp.MustCompile(`\b(\d{1,2}:\d) (\d)\b`)         // "17:3 0" -> "17:30"
+	splitYear      = regexp.MustCompile(`\b([12]) ([09]\d{2})\b`)        // "2 025" -> "2025"
+	splitDate      = regexp.MustCompile(`\b(\d{4}-\d{2}) ?- ?(\d{2})\b`) // "2025-10 -18" -> "2025-10-18"
+	splitThousands = regexp.MustCompile(`\b(\d{1,3}\.\d{2}) (\d)\b`)     // "500.00 0" -> "500.000"
+)
+
+// repairNumbers rejoins times, years, dates and amounts split by a space.
+func repairNumbers(s string) string {
+	s = splitTime.ReplaceAllStringFunc(s, func(m string) string {
+		if joined := strings.Replace(m, " ", "", 1); joined < "24" {
+			return joined
+		}
+		return m
+	})
+	s = splitTimeTail.ReplaceAllString(s, "$1$2")
+	s = splitYear.ReplaceAllString(s, "$1$2")
+	s = splitDate.ReplaceAllString(s, "$1-$2")
+	return splitThousands.ReplaceAllString(s, "$1$2")
+}
+
+// wordRepair rejoins words split by a space or a line-break hyphen
+// ("Sertifi kasi", "Sertifi-\nkasi") when the joined word is in the dictionary
+// and at least one piece is not, and splits glued words ("webinarUIB",
+// "lebihlanjut") into dictionary words.
+func wordRepair(d *Dictionary) func(string) string {
+	return func(s string) string {
+		return splitGlued(d, joinSplit(d, s))
+	}
+}
+
+type span struct{ start, end int }
+
+func letterRuns(s string) []span {
+	var out []span
+	start := -1
+	for i, r := range s {
+		if unicode.IsLetter(r) {
+			if start < 0 {
+				start = i
+			}
+		} else if start >= 0 {
+			out = append(out, span{start, i})
+			start = -1
+		}
+	}
+	if start >= 0 {
+		out = append(out, span{start, len(s)})
+	}
+	return out
+}
+
+func joinSplit(d *Dictionary, s string) string {
+	runs := letterRuns(s)
+	if len(runs) < 2 {
+		return s
+	}
+	var b strings.Builder
+	last := 0
+	cur := runs[0]
+	word := s[cur.start:cur.end]
+	for _, next := range runs[1:] {
+		sep := s[cur.end:next.start]
+		piece := s[next.start:next.end]
+		if sep == " " || sep == "- " || sep == "-\n" {
+			if d.Has(word+piece) && (!d.Has(word) || !d.Has(piece)) {
+				b.WriteString(s[last:cur.end])
+				last = next.start
+				word += piece
+				cur.end = next.end
+				continue
+			}
+		}
+		cur = next
+		word = piece
+	}
+	if last == 0 {
+		return s
+	}
+	b.WriteString(s[last:])
+	return b.String()
+}
+
+func splitGlued(d *Dictionary, s string) string {
+	runs := letterRuns(s)
+	var b strings.Builder
+	last := 0
+	for _, r := range runs {
+		word := s[r.start:r.end]
+		if d.Has(word) {
+			continue
+		}
+		if split := splitWord(d, word); split != word {
+			b.WriteString(s[last:r.start])
+			b.WriteString(split)
+			last = r.end
+		}
+	}
+	if last == 0 {
+		return s
+	}
+	b.WriteString(s[last:])
+	return b.String()
+}
+
+// splitWord splits at case changes ("webinarUIB", "UIBDepartemen") when a
+// piece is a known word, or an all-lowercase word into two known words of at
+// least four letters ("untukumum").
+func splitWord(d *Dictionary, w string) string {
+	rs := []rune(w)
+	for i := 1; i < len(rs); i++ {
+		lowerToUpper := unicode.IsLower(rs[i-1]) && unicode.IsUpper(rs[i])
+		acronymEnd := i+1 < len(rs) && unicode.IsUpper(rs[i-1]) && unicode.IsUpper(rs[i]) && unicode.IsLower(rs[i+1]) && i >= 2
+		if !lowerToUpper && !acronymEnd {
+			continue
+		}
+		left, right := string(rs[:i]), string(rs[i:])
+		if d.Has(left) || d.Has(right) {
+			return splitWord(d, left) + " " + splitWord(d, right)
+		}
+	}
+	if w != strings.ToLower(w) || len(rs) < 8 {
+		return w
+	}
+	for i := 4; i <= len(rs)-4; i++ {
+		if left, right := string(rs[:i]), string(rs[i:]); d.Has(left) && d.Has(right) {
+			return left + " " + right
+		}
+	}
+	return w
+}
+
+var (
+	innerSpaces            = regexp.MustCompile(`(\S) {2,}`)
+	spaceBeforeNewline     = regexp.MustCompile(` +\n`)
+	spaceBeforePunctuation = regexp.MustCompile(`(\S) +([,.;:!?])(\s|$)`)
+)
+
+// normalizeWhitespace collapses runs of spaces inside lines (indentation is
+// kept for nested lists), drops trailing spaces and spaces before punctuation.
+func normalizeWhitespace(s string) string {
+	s = innerSpaces.ReplaceAllString(s, "$1 ")
+	s = spaceBeforeNewline.ReplaceAllString(s, "\n")
+	return spaceBeforePunctuation.ReplaceAllString(s, "$1$2$3")
+}
+
+var (
+	bulletMarker  = regexp.MustCompile(`(?m)^( *)[•●▪◦‣*] +`)
+	headingNoSp   = regexp.MustCompile(`(?m)^(#{1,6})([^#\s])`)
+	boldPadding   = regexp.MustCompile(`(^|[\s(])\*\* *([^*\n]+?) *\*\*`)
+	extraNewlines = regexp.MustCompile(`\n{3,}`)
+)
+
+// normalizeMarkdown unifies bullet markers to "- ", adds the space after
+// heading hashes, trims padding inside **bold** and limits blank lines to one.
+func normalizeMarkdown(s string) string {
+	s = bulletMarker.ReplaceAllString(s, "$1- ")
+	s = headingNoSp.ReplaceAllString(s, "$1 $2")
+	s = boldPadding.ReplaceAllString(s, "$1**$2**")
+	return extraNewlines.ReplaceAllString(s, "\n\n")
+}
+
+var (
+	headingMarker = regexp.MustCompile(`(?m)^( *)#{1,6} +`)
+	markdownLink  = regexp.MustCompile(`\[([^\]\n]+)\]\((https?://[^)\s]+)\)`)
+	emphasisMarks = strings.NewReplacer("**", "", "__", "", "`", "")
+)
+
+// stripMarkdown turns markdown into plain text: heading hashes, bold
+// markers and backticks go and links become "text (url)". Bold markers are
+// dropped one by one rather than in pairs, since a stream segment may hold
+// only one of them. "- " bullets read fine as text and stay.
+func stripMarkdown(s string) string {
+	s = headingMarker.ReplaceAllString(s, "$1")
+	s = markdownLink.ReplaceAllString(s, "$1 ($2)")
+	return emphasisMarks.Replace(s)
+}
+
+// emoji matches emoji with their variation selectors, joiners and skin
+// tones; emojiRun takes the space after them too and lineEndEmoji those at
+// the end of a line with the spaces before them.
+const emoji = `[\x{1F000}-\x{1FAFF}\x{2300}-\x{23FF}\x{2600}-\x{27BF}\x{2B00}-\x{2BFF}\x{FE0F}\x{200D}]+`
+
+var (
+	emojiRun     = regexp.MustCompile(emoji + ` ?`)
+	lineEndEmoji = regexp.MustCompile(`(?m) *(?:` + emoji + ` *)+$`)
+)
+
+// stripEmoji drops emoji, so "📅 12 Nov" becomes "12 Nov".
+func stripEmoji(s string) string {
+	s = lineEndEmoji.ReplaceAllString(s, "")
+	return normalizeWhitespace(emojiRun.ReplaceAllString(s, ""))
+}
@@ -0,0 +1,155 @@
+package postprocess
+
+import (
+	"strings"
+	"sync"
+	"unicode"
+	"unicode/utf8"
+)
+
+// streamHoldRunes is how much text a Streamer buffers when it finds no safe
+// boundary before it cuts at a space anyway.
+const streamHoldRunes = 240
+
+// Streamer is the stateful stream normalizer: it applies a pipeline to
+// streamed chunks, holding back the tail of the text until it is safe to
+// process on its own, so words, URLs, numbers and markdown split across
+// chunk boundaries are repaired before they are emitted. The SSE and
+// WebSocket handlers use it for live deltas and cache replays alike.
+type Streamer struct {
+	mu      sync.Mutex
+	p       *Pipeline
+	emit    func(string)
+	pending strings.Builder
+	started bool
+}
+
+// Stream returns a Streamer that passes processed text to emit.
+func (p *Pipeline) Stream(emit func(string)) *Streamer {
+	return &Streamer{p: p, emit: emit}
+}
+
+// Write adds a raw chunk and emits whatever is safe to emit.
+func (s *Streamer) Write(chunk string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.pending.WriteString(chunk)
+	buf := s.pending.String()
+	cut := s.safeCut(buf)
+	if cut <= 0 {
+		return
+	}
+	s.pending.Reset()
+	s.pending.WriteString(buf[cut:])
+	s.send(buf[:cut])
+}
+
+// Flush emits the buffered rest; call it once the model is done.
+func (s *Streamer) Flush() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	buf := s.pending.String()
+	s.pending.Reset()
+	s.send(strings.TrimRight(buf, " \r\n"))
+}
+
+func (s *Streamer) send(raw string) {
+	out := s.p.segment(raw)
+	if !s.started {
+		out = strings.TrimLeft(out, " \n")
+	}
+	if out == "" {
+		return
+	}
+	s.started = true
+	s.emit(out)
+}
+
+// safeCut returns the length of the prefix of buf that can be processed on
+// its own, preferring in turn:
+//   - the last line break (blank lines stay pending so they can be collapsed),
+//     unless it ends a "-\n" hyphenation;
+//   - the last single space between two complete plain words that the
+//     dictionary would not join;
+//   - the last sentence end followed by a capital letter;
+//   - once buf is long, the space before its last two words.
+//
+// Trailing spaces always stay pending.
+func (s *Streamer) safeCut(buf string) int {
+	cut := 0
+	if i := strings.LastIndexByte(buf, '\n'); i > 0 && buf[i-1] != '-' {
+		cut = len(strings.TrimRight(buf[:i+1], " \r\n"))
+	}
+	if cut == 0 {
+		cut = s.wordCut(buf)
+	}
+	if cut == 0 {
+		for i := len(buf) - 3; i >= 0; i-- {
+			if strings.ContainsRune(".!?", rune(buf[i])) && buf[i+1] == ' ' {
+				if r, _ := utf8.DecodeRuneInString(buf[i+2:]); unicode.IsUpper(r) {
+					cut = i + 1
+					break
+				}
+			}
+		}
+	}
+	if cut == 0 && utf8.RuneCountInString(buf) >= streamHoldRunes {
+		spaces := 0
+		for i := len(buf) - 1; i > 0 && cut == 0; i-- {
+			if buf[i] == ' ' {
+				if spaces++; spaces == 2 {
+					cut = i
+				}
+			}
+		}
+	}
+	for cut > 0 && buf[cut-1] == ' ' {
+		cut--
+	}
+	return cut
+}
+
+// wordCut finds the last single space between two plain words where the
+// right word is complete (followed by a space) and the pair is not a split
+// dictionary word. Numbers, URLs, punctuation and markdown markers are never
+// cut around, because the steps may still rejoin them with their neighbours.
+func (s *Streamer) wordCut(buf string) int {
+	if strings.Contains(buf, "\n") {
+		return 0
+	}
+	// the last word may still continue in the next chunk
+	trimmed := strings.TrimRight(buf, " ")
+	end := len(trimmed)
+	if end == len(buf) {
+		end = max(strings.LastIndexByte(trimmed, ' '), 0)
+	}
+	for i := strings.LastIndexByte(buf[:end], ' '); i > 0; i = strings.LastIndexByte(buf[:i], ' ') {
+		if buf[i-1] == ' ' || buf[i+1] == ' ' || strings.Count(buf[:i], "**")%2 == 1 {
+			continue
+		}
+		left := buf[strings.LastIndexByte(buf[:i], ' ')+1 : i]
+		right := buf[i+1:]
+		if j := strings.IndexByte(right, ' '); j >= 0 {
+			right = right[:j]
+		}
+		if i+1+len(right) > end {
+			continue
+		}
+		if plainWord(left) && plainWord(right) && (s.p.dict == nil || !s.p.dict.Has(left+right)) {
+			return i
+		}
+	}
+	return 0
+}
+
+func plainWord(w string) bool {
+	if w == "" {
+		return false
+	}
+	for _, r := range w {
+		if !unicode.IsLetter(r) {
+			return false
+		}
+	}
+	return true
+}
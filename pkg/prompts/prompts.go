@@ -0,0 +1,193 @@
+// Package prompts keeps the system-instruction blocks admins customise per
+// tenant and campus: the assistant's tone and the numbered rules ("INSTRUKSI
+// PENTING") of answers built from event data. Every save is a new version;
+// the newest of a campus, else of the tenant's campus "", is in effect.
+package prompts
+
+import (
+	"AkuAI/models"
+	"encoding/json"
+	"errors"
+	"log"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Blocks are the customisable parts of the system instruction. An empty
+// block falls back to Defaults.
+type Blocks struct {
+	Tone  string   `json:"tone"`
+	Rules []string `json:"rules"`
+}
+
+// Defaults are the built-in blocks, written for UIB; the services localise
+// them to the campus being asked about.
+var Defaults = Blocks{
+	Tone: "Kamu adalah asisten AI untuk Universitas Internasional Batam (UIB). Jawab pertanyaan menggunakan data resmi UIB yang disediakan di bawah ini.",
+	Rules: []string{
+		"PENTING: Hari ini adalah 4 Oktober 2025, jadi semua acara Oktober-Desember 2025 adalah SAAT INI atau AKAN DATANG",
+		"LANGSUNG berikan SEMUA data yang tersedia sesuai pertanyaan - JANGAN tanya balik atau minta klarifikasi",
+		"Jika ditanya tentang sertifikasi/webinar/seminar/workshop/bootcamp/lomba/kuliah umum per bulan, tampilkan SEMUA yang ada di bulan tersebut",
+		"SELALU gunakan data UIB yang disediakan di atas sebagai sumber utama",
+		`Format jawaban dengan struktur jelas. Untuk setiap item tampilkan: Nama acara, Tanggal, Waktu, Lokasi, Biaya, Kontak. Jika tautan pendaftaran tidak tersedia, tulis: "tautan tidak tersedia dalam data".`,
+		"SELALU sebutkan bahwa ini adalah data resmi UIB (UIB_OFFICIAL)",
+		"Jika tidak ada data untuk bulan yang ditanyakan, baru katakan tidak tersedia",
+		`JANGAN katakan "memerlukan informasi lebih lanjut" - langsung berikan semua yang ada`,
+		"Jika pertanyaan meminta beberapa jenis acara sekaligus (misalnya webinar dan sertifikasi), tampilkan SEMUANYA.",
+		`Untuk frasa relatif seperti "minggu depan", artikan sebagai rentang Senin–Minggu pekan depan berdasarkan tanggal di atas.`,
+		`Gunakan format: "Berikut [jenis acara] UIB untuk [bulan/rentang]:" lalu list semua`,
+		"Akhiri setiap baris yang menyebut acara dengan penanda sumbernya dari data, contoh: [EV-CERT-NOV-001]. Jangan membuat penanda yang tidak ada di data.",
+	},
+}
+
+// BlocksOf returns the blocks saved in v.
+func BlocksOf(v models.PromptVersion) Blocks {
+	b := Blocks{Tone: v.Tone}
+	if v.Rules != "" {
+		if err := json.Unmarshal([]byte(v.Rules), &b.Rules); err != nil {
+			log.Printf("[prompts] ⚠️ version %d of %q/%q has unreadable rules: %v", v.Version, v.TenantID, v.Campus, err)
+		}
+	}
+	return b
+}
+
+// mu serialises saves, so two admins can't both take the next version.
+var mu sync.Mutex
+
+// Save stores b as the next version for campus of tenantID and makes it the
+// one in effect.
+func Save(db *gorm.DB, tenantID, campus string, b Blocks, authorID uint, note string) (models.PromptVersion, error) {
+	v := models.PromptVersion{TenantID: tenantID, Campus: campus, Tone: b.Tone, Note: note, AuthorID: authorID}
+	if len(b.Rules) > 0 {
+		rules, err := json.Marshal(b.Rules)
+		if err != nil {
+			return v, err
+		}
+		v.Rules = string(rules)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var last int
+		if err := tx.Model(&models.PromptVersion{}).Where("tenant_id = ? AND campus = ?", tenantID, campus).
+			Select("COALESCE(MAX(version), 0)").Scan(&last).Error; err != nil {
+			return err
+		}
+		v.Version = last + 1
+		return tx.Create(&v).Error
+	})
+	if err != nil {
+		return v, err
+	}
+	if s := Default(); s != nil {
+		if err := s.Reload(); err != nil {
+			log.Printf("[prompts] ⚠️ reload after saving failed: %v", err)
+		}
+	}
+	return v, nil
+}
+
+// History returns the versions for campus of tenantID, newest first.
+func History(db *gorm.DB, tenantID, campus string, limit int) ([]models.PromptVersion, error) {
+	var out []models.PromptVersion
+	err := db.Where("tenant_id = ? AND campus = ?", tenantID, campus).Order("version DESC").Limit(limit).Find(&out).Error
+	return out, err
+}
+
+// ErrNoVersion is returned by Find for a version that was never saved.
+var ErrNoVersion = errors.New("no such prompt version")
+
+// Find returns version n for campus of tenantID.
+func Find(db *gorm.DB, tenantID, campus string, n int) (models.PromptVersion, error) {
+	var v models.PromptVersion
+	err := db.Where("tenant_id = ? AND campus = ? AND version = ?", tenantID, campus, n).First(&v).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return v, ErrNoVersion
+	}
+	return v, err
+}
+
+// refreshAfter bounds how long a save made by another instance takes to
+// reach this one; saves made here apply at once.
+const refreshAfter = 30 * time.Second
+
+// Store holds the versions in effect, read on every event answer.
+type Store struct {
+	db *gorm.DB
+
+	mu     sync.Mutex
+	active map[[2]string]models.PromptVersion
+	loaded time.Time
+}
+
+var (
+	defaultMu    sync.RWMutex
+	defaultStore *Store
+)
+
+// Init loads the versions in effect from db and installs the store returned
+// by Default.
+func Init(db *gorm.DB) *Store {
+	s := &Store{db: db}
+	if err := s.Reload(); err != nil {
+		log.Printf("[prompts] ⚠️ failed to load prompt versions: %v", err)
+	}
+	defaultMu.Lock()
+	defaultStore = s
+	defaultMu.Unlock()
+	return s
+}
+
+// Default returns the store installed by Init, or nil.
+func Default() *Store {
+	defaultMu.RLock()
+	defer defaultMu.RUnlock()
+	return defaultStore
+}
+
+// Reload reads the newest version of every scope.
+func (s *Store) Reload() error {
+	var rows []models.PromptVersion
+	err := s.db.Where("id IN (?)", s.db.Model(&models.PromptVersion{}).Select("MAX(id)").Group("tenant_id, campus")).
+		Find(&rows).Error
+	if err != nil {
+		return err
+	}
+	active := make(map[[2]string]models.PromptVersion, len(rows))
+	for _, v := range rows {
+		active[[2]string{v.TenantID, v.Campus}] = v
+	}
+	s.mu.Lock()
+	s.active, s.loaded = active, time.Now()
+	s.mu.Unlock()
+	return nil
+}
+
+// For returns the version in effect for campus of tenantID: the campus's
+// own, else the tenant's for all campuses. ok is false when neither was
+// saved, and the Defaults apply.
+func (s *Store) For(tenantID, campus string) (v models.PromptVersion, ok bool) {
+	if s == nil {
+		return v, false
+	}
+	s.mu.Lock()
+	stale := time.Since(s.loaded) > refreshAfter
+	s.mu.Unlock()
+	if stale {
+		if err := s.Reload(); err != nil {
+			log.Printf("[prompts] ⚠️ failed to refresh prompt versions: %v", err)
+			s.mu.Lock()
+			s.loaded = time.Now() // retry after refreshAfter, not on every answer
+			s.mu.Unlock()
+		}
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if v, ok = s.active[[2]string{tenantID, campus}]; ok {
+		return v, true
+	}
+	v, ok = s.active[[2]string{tenantID, ""}]
+	return v, ok
+}
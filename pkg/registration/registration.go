@@ -0,0 +1,154 @@
+// Package registration keeps event registrations: a confirmed seat while
+// the event has capacity left, a place on its waiting list otherwise, and
+// promotion from the waiting list when a confirmed participant cancels.
+package registration
+
+import (
+	"AkuAI/models"
+	"errors"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ErrNotRegistered is returned by Cancel when the user holds no active
+// registration.
+var ErrNotRegistered = errors.New("not registered for this event")
+
+// mu serialises seat allocation, so two registrations can't both take the
+// last seat.
+var mu sync.Mutex
+
+// Event identifies an event and its capacity; Capacity 0 is unlimited.
+// Tenants sharing a dataset still fill the seats of their own copy of an
+// event.
+type Event struct {
+	Tenant   string
+	Campus   string
+	ID       string
+	Capacity int
+}
+
+func (e Event) scope(db *gorm.DB) *gorm.DB {
+	return db.Model(&models.EventRegistration{}).Where("tenant_id = ? AND campus = ? AND event_id = ?", e.Tenant, e.Campus, e.ID)
+}
+
+// Counts returns the confirmed and waitlisted registrations of e.
+func Counts(db *gorm.DB, e Event) (confirmed, waitlisted int64, err error) {
+	if err = e.scope(db).Where("status = ?", models.RegistrationConfirmed).Count(&confirmed).Error; err != nil {
+		return
+	}
+	err = e.scope(db).Where("status = ?", models.RegistrationWaitlisted).Count(&waitlisted).Error
+	return
+}
+
+// Register registers userID for e: confirmed when a seat is free, else
+// waitlisted. created is false when the user already held an active
+// registration, which is returned unchanged.
+func Register(db *gorm.DB, e Event, userID uint) (r models.EventRegistration, created bool, err error) {
+	mu.Lock()
+	defer mu.Unlock()
+	err = db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Where("campus = ? AND event_id = ? AND user_id = ?", e.Campus, e.ID, userID).First(&r).Error
+		switch {
+		case err == nil && r.Active():
+			return nil
+		case err != nil && !errors.Is(err, gorm.ErrRecordNotFound):
+			return err
+		}
+		var confirmed int64
+		if err := e.scope(tx).Where("status = ?", models.RegistrationConfirmed).Count(&confirmed).Error; err != nil {
+			return err
+		}
+		now := time.Now()
+		r.TenantID, r.Campus, r.EventID, r.UserID = e.Tenant, e.Campus, e.ID, userID
+		r.QueuedAt, r.CancelledAt, r.ConfirmedAt = now, nil, nil
+		r.Status = models.RegistrationWaitlisted
+		if e.Capacity == 0 || confirmed < int64(e.Capacity) {
+			r.Status, r.ConfirmedAt = models.RegistrationConfirmed, &now
+		}
+		created = true
+		return tx.Save(&r).Error
+	})
+	return r, created, err
+}
+
+// Cancel cancels the active registration of userID for e and, when it held
+// a seat, promotes the first waitlisted registrations into the free seats.
+func Cancel(db *gorm.DB, e Event, userID uint) (cancelled models.EventRegistration, promoted []models.EventRegistration, err error) {
+	mu.Lock()
+	defer mu.Unlock()
+	err = db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Where("campus = ? AND event_id = ? AND user_id = ? AND status IN ?", e.Campus, e.ID, userID,
+			[]string{models.RegistrationConfirmed, models.RegistrationWaitlisted}).First(&cancelled).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrNotRegistered
+		} else if err != nil {
+			return err
+		}
+		now := time.Now()
+		wasConfirmed := cancelled.Status == models.RegistrationConfirmed
+		cancelled.Status, cancelled.CancelledAt = models.RegistrationCancelled, &now
+		if err := tx.Save(&cancelled).Error; err != nil {
+			return err
+		}
+		if !wasConfirmed {
+			return nil
+		}
+		promoted, err = promote(tx, e, now)
+		return err
+	})
+	return cancelled, promoted, err
+}
+
+// promote confirms waitlisted registrations of e, first queued first, while
+// seats are free.
+func promote(tx *gorm.DB, e Event, now time.Time) ([]models.EventRegistration, error) {
+	var confirmed int64
+	if err := e.scope(tx).Where("status = ?", models.RegistrationConfirmed).Count(&confirmed).Error; err != nil {
+		return nil, err
+	}
+	q := e.scope(tx).Where("status = ?", models.RegistrationWaitlisted).Order("queued_at, id")
+	if e.Capacity != 0 {
+		free := int64(e.Capacity) - confirmed
+		if free <= 0 {
+			return nil, nil
+		}
+		q = q.Limit(int(free))
+	}
+	var next []models.EventRegistration
+	if err := q.Find(&next).Error; err != nil {
+		return nil, err
+	}
+	for i := range next {
+		next[i].Status, next[i].ConfirmedAt = models.RegistrationConfirmed, &now
+		if err := tx.Save(&next[i]).Error; err != nil {
+			return nil, err
+		}
+	}
+	return next, nil
+}
+
+// Position is the 1-based place of r on its waiting list, 0 when it isn't
+// waitlisted.
+func Position(db *gorm.DB, r models.EventRegistration) int {
+	if r.Status != models.RegistrationWaitlisted {
+		return 0
+	}
+	var ahead int64
+	(Event{Tenant: r.TenantID, Campus: r.Campus, ID: r.EventID}).scope(db).
+		Where("status = ? AND (queued_at < ? OR (queued_at = ? AND id < ?))", models.RegistrationWaitlisted, r.QueuedAt, r.QueuedAt, r.ID).
+		Count(&ahead)
+	return int(ahead) + 1
+}
+
+// Roster returns the confirmed registrations of e in order of confirmation
+// and the waiting list in order.
+func Roster(db *gorm.DB, e Event) (confirmed, waitlisted []models.EventRegistration, err error) {
+	if err = e.scope(db).Where("status = ?", models.RegistrationConfirmed).Order("confirmed_at, id").Find(&confirmed).Error; err != nil {
+		return
+	}
+	err = e.scope(db).Where("status = ?", models.RegistrationWaitlisted).Order("queued_at, id").Find(&waitlisted).Error
+	return
+}
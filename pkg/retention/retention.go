@@ -0,0 +1,294 @@
+package retention
+
+import (
+	"AkuAI/models"
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Policy describes when conversations are archived and purged. A zero
+// duration disables that rule.
+type Policy struct {
+	ArchiveAfterInactive time.Duration `json:"archive_after_inactive"`
+	DeleteAfter          time.Duration `json:"delete_after"`
+	// TrashRetention is how long soft-deleted conversations stay restorable.
+	TrashRetention time.Duration `json:"trash_retention"`
+	// GuestTTL is how long guest conversations are kept after their last
+	// message unless claimed by an account.
+	GuestTTL time.Duration `json:"guest_ttl"`
+	// IncognitoTTL is how long incognito conversations are kept after their
+	// last message; logging out purges them at once (PurgeIncognito).
+	IncognitoTTL time.Duration `json:"incognito_ttl"`
+	DryRun       bool          `json:"dry_run"`
+}
+
+func (p Policy) Enabled() bool {
+	return p.ArchiveAfterInactive > 0 || p.DeleteAfter > 0 || p.TrashRetention > 0 || p.GuestTTL > 0 || p.IncognitoTTL > 0
+}
+
+type Result struct {
+	RunID    string    `json:"run_id"`
+	Archived int       `json:"archived"`
+	Purged   int       `json:"purged"`
+	Emptied  int       `json:"trash_purged"`
+	Guests   int       `json:"guest_purged"`
+	Private  int       `json:"incognito_purged"`
+	DryRun   bool      `json:"dry_run"`
+	Started  time.Time `json:"started"`
+	Took     string    `json:"took"`
+}
+
+const batchSize = 500
+
+var defaultEngine *Engine
+
+// Init sets the process-wide engine used by the admin API.
+func Init(db *gorm.DB, policy Policy) *Engine {
+	defaultEngine = NewEngine(db, policy)
+	return defaultEngine
+}
+
+// Default returns the engine set by Init, or nil.
+func Default() *Engine { return defaultEngine }
+
+// Engine applies a Policy to the conversations table. Runs are serialised so
+// a manual trigger never overlaps the scheduled one.
+type Engine struct {
+	db     *gorm.DB
+	policy Policy
+	runMu  sync.Mutex
+
+	lastMu sync.RWMutex
+	last   *Result
+}
+
+func NewEngine(db *gorm.DB, policy Policy) *Engine {
+	return &Engine{db: db, policy: policy}
+}
+
+func (e *Engine) Policy() Policy { return e.policy }
+
+func (e *Engine) LastResult() *Result {
+	e.lastMu.RLock()
+	defer e.lastMu.RUnlock()
+	return e.last
+}
+
+// Start runs the policy every interval until ctx is done.
+func (e *Engine) Start(ctx context.Context, interval time.Duration) {
+	if !e.policy.Enabled() {
+		log.Printf("[retention] policy disabled, scheduler not started")
+		return
+	}
+	go func() {
+		t := time.NewTicker(interval)
+		defer t.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-t.C:
+				if _, err := e.Run(ctx, e.policy.DryRun); err != nil {
+					log.Printf("[retention] ❌ scheduled run failed: %v", err)
+				}
+			}
+		}
+	}()
+	log.Printf("[retention] scheduler started interval=%v archiveAfter=%v deleteAfter=%v dryRun=%v",
+		interval, e.policy.ArchiveAfterInactive, e.policy.DeleteAfter, e.policy.DryRun)
+}
+
+// Run applies the policy once. With dryRun set, affected conversations are
+// only recorded in the audit table.
+func (e *Engine) Run(ctx context.Context, dryRun bool) (Result, error) {
+	e.runMu.Lock()
+	defer e.runMu.Unlock()
+
+	res := Result{RunID: uuid.NewString(), DryRun: dryRun, Started: time.Now()}
+	db := e.db.WithContext(ctx)
+
+	if e.policy.TrashRetention > 0 {
+		cutoff := time.Now().Add(-e.policy.TrashRetention)
+		n, err := purge(db, res.RunID, "purge_trash", fmt.Sprintf("in trash since before %s", cutoff.Format(time.RFC3339)),
+			db.Unscoped().Where("deleted_at IS NOT NULL AND deleted_at < ?", cutoff), dryRun)
+		res.Emptied = n
+		if err != nil {
+			return res, fmt.Errorf("purge trash: %w", err)
+		}
+	}
+	if e.policy.GuestTTL > 0 {
+		cutoff := time.Now().Add(-e.policy.GuestTTL)
+		n, err := purge(db, res.RunID, "purge_guest", fmt.Sprintf("guest conversation inactive since before %s", cutoff.Format(time.RFC3339)),
+			db.Unscoped().Where("guest_id IS NOT NULL").Where(lastActivity+" < ?", cutoff), dryRun)
+		res.Guests = n
+		if err != nil {
+			return res, fmt.Errorf("purge guests: %w", err)
+		}
+	}
+	if e.policy.IncognitoTTL > 0 {
+		cutoff := time.Now().Add(-e.policy.IncognitoTTL)
+		n, err := purge(db, res.RunID, "purge_incognito", fmt.Sprintf("incognito conversation inactive since before %s", cutoff.Format(time.RFC3339)),
+			db.Unscoped().Where("incognito = ?", true).Where(lastActivity+" < ?", cutoff), dryRun)
+		res.Private = n
+		if err != nil {
+			return res, fmt.Errorf("purge incognito: %w", err)
+		}
+	}
+	if e.policy.DeleteAfter > 0 {
+		cutoff := time.Now().Add(-e.policy.DeleteAfter)
+		n, err := purge(db, res.RunID, "purge", fmt.Sprintf("created before %s", cutoff.Format(time.RFC3339)),
+			db.Unscoped().Where("created_at < ?", cutoff), dryRun)
+		res.Purged = n
+		if err != nil {
+			return res, fmt.Errorf("purge: %w", err)
+		}
+	}
+	if e.policy.ArchiveAfterInactive > 0 {
+		n, err := e.archive(db, res.RunID, time.Now().Add(-e.policy.ArchiveAfterInactive), dryRun)
+		res.Archived = n
+		if err != nil {
+			return res, fmt.Errorf("archive: %w", err)
+		}
+	}
+
+	res.Took = time.Since(res.Started).Round(time.Millisecond).String()
+	log.Printf("[retention] run=%s archived=%d purged=%d trashPurged=%d guestPurged=%d incognitoPurged=%d dryRun=%v took=%s",
+		res.RunID, res.Archived, res.Purged, res.Emptied, res.Guests, res.Private, dryRun, res.Took)
+
+	e.lastMu.Lock()
+	e.last = &res
+	e.lastMu.Unlock()
+	return res, nil
+}
+
+// PurgeIncognito removes the incognito conversations of userID with their
+// messages when the user's session ends.
+func PurgeIncognito(db *gorm.DB, userID uint) (int, error) {
+	return purge(db, uuid.NewString(), "purge_incognito", "session ended",
+		db.Unscoped().Where("incognito = ? AND user_id = ?", true, userID), false)
+}
+
+// lastActivity is the newest message timestamp, falling back to creation time
+// for conversations without messages.
+const lastActivity = "COALESCE((SELECT MAX(m.timestamp) FROM messages m WHERE m.conversation_id = conversations.id AND m.deleted_at IS NULL), conversations.created_at)"
+
+func (e *Engine) archive(db *gorm.DB, runID string, cutoff time.Time, dryRun bool) (int, error) {
+	var convs []models.Conversation
+	if err := db.Select("id", "user_id").
+		Where("archived = ?", false).
+		Where(lastActivity+" < ?", cutoff).
+		Limit(batchSize * 10).
+		Find(&convs).Error; err != nil {
+		return 0, err
+	}
+	if len(convs) == 0 {
+		return 0, nil
+	}
+
+	reason := fmt.Sprintf("inactive since before %s", cutoff.Format(time.RFC3339))
+	if err := audit(db, runID, "archive", convs, reason, dryRun); err != nil {
+		return 0, err
+	}
+	if dryRun {
+		return len(convs), nil
+	}
+
+	now := time.Now()
+	for _, ids := range chunks(ids(convs)) {
+		if err := db.Model(&models.Conversation{}).Where("id IN ?", ids).
+			Updates(map[string]any{"archived": true, "archived_at": now}).Error; err != nil {
+			return 0, err
+		}
+	}
+	return len(convs), nil
+}
+
+// purge permanently removes the conversations matched by scope together with
+// their messages and the rows that point at them: bookmarks, reactions and
+// citations are deleted, memories and chat links are unlinked.
+func purge(db *gorm.DB, runID, action, reason string, scope *gorm.DB, dryRun bool) (int, error) {
+	var convs []models.Conversation
+	if err := scope.Select("id", "user_id").
+		Limit(batchSize * 10).
+		Find(&convs).Error; err != nil {
+		return 0, err
+	}
+	if len(convs) == 0 {
+		return 0, nil
+	}
+
+	if err := audit(db, runID, action, convs, reason, dryRun); err != nil {
+		return 0, err
+	}
+	if dryRun {
+		return len(convs), nil
+	}
+
+	for _, ids := range chunks(ids(convs)) {
+		err := db.Transaction(func(tx *gorm.DB) error {
+			msgs := tx.Unscoped().Model(&models.Message{}).Select("id").Where("conversation_id IN ?", ids)
+			for _, dep := range []any{&models.MessageBookmark{}, &models.MessageReaction{}, &models.MessageCitation{}} {
+				if err := tx.Unscoped().Where("message_id IN (?)", msgs).Delete(dep).Error; err != nil {
+					return err
+				}
+			}
+			if err := tx.Unscoped().Model(&models.UserMemory{}).Where("source_message_id IN (?)", msgs).
+				Update("source_message_id", 0).Error; err != nil {
+				return err
+			}
+			if err := tx.Model(&models.ChatLink{}).Where("conversation_id IN ?", ids).
+				Update("conversation_id", nil).Error; err != nil {
+				return err
+			}
+			if err := tx.Unscoped().Where("conversation_id IN ?", ids).Delete(&models.Message{}).Error; err != nil {
+				return err
+			}
+			return tx.Unscoped().Where("id IN ?", ids).Delete(&models.Conversation{}).Error
+		})
+		if err != nil {
+			return 0, err
+		}
+	}
+	return len(convs), nil
+}
+
+func audit(db *gorm.DB, runID, action string, convs []models.Conversation, reason string, dryRun bool) error {
+	events := make([]models.RetentionEvent, 0, len(convs))
+	for _, c := range convs {
+		events = append(events, models.RetentionEvent{
+			RunID:          runID,
+			Action:         action,
+			ConversationID: c.ID,
+			UserID:         c.UserID,
+			Reason:         reason,
+			DryRun:         dryRun,
+		})
+	}
+	return db.CreateInBatches(events, batchSize).Error
+}
+
+func ids(convs []models.Conversation) []uint {
+	out := make([]uint, 0, len(convs))
+	for _, c := range convs {
+		out = append(out, c.ID)
+	}
+	return out
+}
+
+func chunks(all []uint) [][]uint {
+	var out [][]uint
+	for len(all) > batchSize {
+		out = append(out, all[:batchSize])
+		all = all[batchSize:]
+	}
+	if len(all) > 0 {
+		out = append(out, all)
+	}
+	return out
+}
@@ -0,0 +1,133 @@
+package services
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"unicode"
+)
+
+// eventsTag tags answers to event questions that name no month; any new or
+// moved event may belong in them.
+const eventsTag = "events"
+
+func eventTag(id string) string { return "event:" + id }
+
+// promptTag tags every answer to an event question, and promptTag plus
+// ":" and the campus those about one campus, so a prompt change drops them.
+const promptTag = "prompt"
+
+// PromptTags returns the tags of the cached answers a prompt change for
+// campus affects; "" is every campus.
+func PromptTags(campus string) []string {
+	if campus == "" {
+		return []string{promptTag}
+	}
+	return []string{promptTag + ":" + campus}
+}
+
+// dateTags are the month tags of a YYYY-MM-DD date: "month:2025-11", and
+// "month:11" for questions that name no year.
+func dateTags(date string) []string {
+	if len(date) < 7 {
+		return nil
+	}
+	return []string{"month:" + date[:7], "month:" + date[5:7]}
+}
+
+// EventCacheTags returns the tags a cached answer to question is filed
+// under: the events retrieved for it and those reply cites, and the months
+// question names ("events" when it names none), and the prompt tags of its
+// campus. EventChangeTags and PromptTags give the tags to purge when events
+// or prompts change.
+func EventCacheTags(question, reply string) []string {
+	var tags []string
+	seen := map[string]bool{}
+	add := func(ts ...string) {
+		for _, t := range ts {
+			if !seen[t] {
+				seen[t] = true
+				tags = append(tags, t)
+			}
+		}
+	}
+	if _, uib := defaultCampusData().ForQuery(question); uib != nil && uib.AnalyzeQueryForUIB(question) {
+		refs := parseMonthRefs(strings.ToLower(question))
+		for _, r := range refs {
+			if r.Year != 0 {
+				add(fmt.Sprintf("month:%d-%02d", r.Year, r.Month))
+			} else {
+				add(fmt.Sprintf("month:%02d", r.Month))
+			}
+		}
+		if len(refs) == 0 {
+			add(eventsTag)
+		}
+		add(promptTag, promptTag+":"+uib.Institution())
+		for _, ev := range uib.GetRelevantEventsForQuery(question) {
+			add(eventTag(ev.ID))
+		}
+	}
+	_, citations := ResolveCitations(reply)
+	for _, c := range citations {
+		if c.EventID != "" {
+			add(eventTag(c.EventID))
+		}
+	}
+	return tags
+}
+
+// SemanticFacets returns what a near-duplicate question must share with a
+// cached one to reuse its answer: the months and years, event types and
+// campus question names, e.g. "m=2025-11;t=webinar;c=uib". Embeddings score
+// "webinar bulan November" and "webinar bulan Desember" as near-identical,
+// so the semantic cache keys its scopes with these.
+func SemanticFacets(question string) string {
+	lower := strings.ToLower(question)
+	var periods []string
+	for _, r := range parseMonthRefs(lower) {
+		if r.Year != 0 {
+			periods = append(periods, fmt.Sprintf("%d-%02d", r.Year, r.Month))
+		} else {
+			periods = append(periods, fmt.Sprintf("%02d", r.Month))
+		}
+	}
+	for _, tok := range strings.FieldsFunc(lower, func(r rune) bool { return !unicode.IsDigit(r) }) {
+		if y := parseYear(tok); y != 0 && !slices.ContainsFunc(periods, func(p string) bool { return strings.HasPrefix(p, tok) }) {
+			periods = append(periods, tok)
+		}
+	}
+	slices.Sort(periods)
+	campus, _ := defaultCampusData().ForQuery(question)
+	return "m=" + strings.Join(periods, ",") + ";t=" + strings.Join(detectEventTypes(lower), ",") + ";c=" + strings.ToLower(campus)
+}
+
+// EventChangeTags returns the cache tags answers affected by changes are
+// filed under: each changed event, its month, and for new events and new
+// dates the month it left and the answers that name no month.
+func EventChangeTags(changes []EventChange) []string {
+	var tags []string
+	seen := map[string]bool{}
+	add := func(ts ...string) {
+		for _, t := range ts {
+			if !seen[t] {
+				seen[t] = true
+				tags = append(tags, t)
+			}
+		}
+	}
+	for _, c := range changes {
+		add(eventTag(c.EventID))
+		add(dateTags(c.date)...)
+		for _, f := range c.Fields {
+			if f.Field != "date" {
+				continue
+			}
+			if before, ok := f.Before.(string); ok {
+				add(dateTags(before)...)
+			}
+			add(eventsTag)
+		}
+	}
+	return tags
+}
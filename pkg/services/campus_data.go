@@ -0,0 +1,248 @@
+package services
+
+import (
+	"AkuAI/pkg/config"
+	"AkuAI/pkg/tenant"
+	"log"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+const (
+	defaultCampusDataset = "data/uib_events.json"
+	defaultCampusName    = "Universitas Internasional Batam"
+)
+
+var campusShortNameRe = regexp.MustCompile(`\(([^)]+)\)\s*$`)
+
+// Institution is the canonical campus name, resolved through
+// universityAliasMap (e.g. "Universitas Internasional Batam").
+func (s *UIBEventService) Institution() string {
+	raw := strings.TrimSpace(s.eventsData.Metadata.Institution)
+	if raw == "" {
+		return defaultCampusName
+	}
+	if alias := resolveUniversityAlias(strings.ToUpper(raw)); alias != "" {
+		return alias
+	}
+	bare := strings.TrimSpace(campusShortNameRe.ReplaceAllString(raw, ""))
+	if alias := resolveUniversityAlias(strings.ToUpper(bare)); alias != "" {
+		return alias
+	}
+	return bare
+}
+
+// ShortName is the abbreviation in the metadata institution, e.g. "UIB" for
+// "Universitas Internasional Batam (UIB)", or the full name when there is none.
+func (s *UIBEventService) ShortName() string {
+	if m := campusShortNameRe.FindStringSubmatch(s.eventsData.Metadata.Institution); m != nil {
+		return strings.TrimSpace(m[1])
+	}
+	return s.Institution()
+}
+
+// IsUIB reports whether this is the built-in UIB dataset.
+func (s *UIBEventService) IsUIB() bool {
+	return s.Institution() == defaultCampusName
+}
+
+// Aliases returns the lower-cased names that refer to this campus: the
+// canonical and short names, universityAliasMap keys and metadata aliases.
+func (s *UIBEventService) Aliases() []string {
+	name := s.Institution()
+	seen := map[string]bool{}
+	var out []string
+	add := func(a string) {
+		a = strings.ToLower(strings.TrimSpace(a))
+		if a != "" && !seen[a] {
+			seen[a] = true
+			out = append(out, a)
+		}
+	}
+	add(name)
+	add(s.ShortName())
+	for key, val := range universityAliasMap {
+		if val == name {
+			add(key)
+		}
+	}
+	for _, a := range s.eventsData.Metadata.Aliases {
+		add(a)
+	}
+	// Longest first so "universitas internasional batam" wins over "uib"
+	sort.Slice(out, func(i, j int) bool { return len(out[i]) > len(out[j]) })
+	return out
+}
+
+func (s *UIBEventService) mentionedIn(queryLower string) bool {
+	words := " " + strings.Join(searchTermTokens(queryLower), " ") + " "
+	for _, a := range s.Aliases() {
+		if strings.Contains(words, " "+a+" ") || (strings.Contains(a, " ") && strings.Contains(queryLower, a)) {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *UIBEventService) isOwnAlias(hint string) bool {
+	hint = strings.TrimSpace(hint)
+	for _, a := range s.Aliases() {
+		if a == hint || strings.Contains(a, hint) {
+			return true
+		}
+	}
+	return false
+}
+
+// Localize rewrites UIB-specific wording in a prompt or context block for
+// this dataset's campus. The UIB dataset is returned unchanged.
+func (s *UIBEventService) Localize(text string) string {
+	if s.IsUIB() {
+		return text
+	}
+	md := s.eventsData.Metadata
+	pairs := []string{"Universitas Internasional Batam (UIB)", s.Institution() + " (" + s.ShortName() + ")",
+		"UNIVERSITAS INTERNASIONAL BATAM (UIB)", strings.ToUpper(s.Institution()) + " (" + s.ShortName() + ")",
+		"Universitas Internasional Batam", s.Institution()}
+	if md.ContactGeneral != "" {
+		pairs = append(pairs, "info@uib.ac.id", md.ContactGeneral)
+	}
+	if md.Website != "" {
+		pairs = append(pairs, "https://uib.ac.id", md.Website)
+	}
+	pairs = append(pairs, "UIB", s.ShortName())
+	return strings.NewReplacer(pairs...).Replace(text)
+}
+
+// Source is the file name the dataset was loaded from.
+func (s *UIBEventService) Source() string { return s.source }
+
+// Path is the file the dataset was loaded from.
+func (s *UIBEventService) Path() string { return s.path }
+
+// LastUpdated is the dataset's metadata.last_updated.
+func (s *UIBEventService) LastUpdated() string { return s.eventsData.Metadata.LastUpdated }
+
+// Version is the dataset's metadata.version, or last_updated for files that
+// do not carry one.
+func (s *UIBEventService) Version() string {
+	if v := strings.TrimSpace(s.eventsData.Metadata.Version); v != "" {
+		return v
+	}
+	return s.eventsData.Metadata.LastUpdated
+}
+
+// Hash is the SHA-256 of the dataset file as loaded, so answers can be tied
+// to the exact revision they were grounded on. "" for a nil dataset.
+func (s *UIBEventService) Hash() string {
+	if s == nil {
+		return ""
+	}
+	return s.hash
+}
+
+// CampusDataService holds the event datasets of every loaded campus, keyed
+// by canonical institution name. data/uib_events.json is always the default;
+// additional datasets are read from config.CampusDataDir/*.json using the
+// same schema.
+type CampusDataService struct {
+	datasets map[string]*UIBEventService
+	names    []string
+	def      *UIBEventService
+}
+
+func NewCampusDataService() (*CampusDataService, error) {
+	return loadCampusData(defaultCampusDataset, config.CampusDataDir)
+}
+
+// NewCampusDataServiceFor loads the datasets of tenant t: its dataset and
+// campus_data_dir, each falling back to the deployment's when not set.
+func NewCampusDataServiceFor(t *tenant.Tenant) (*CampusDataService, error) {
+	defPath, dir := defaultCampusDataset, config.CampusDataDir
+	if t.Dataset != "" {
+		defPath = t.Dataset
+	}
+	if t.CampusDataDir != "" {
+		dir = t.CampusDataDir
+	}
+	return loadCampusData(defPath, dir)
+}
+
+func loadCampusData(defPath, dir string) (*CampusDataService, error) {
+	def, err := newEventDataset(defPath)
+	if err != nil {
+		return nil, err
+	}
+	c := &CampusDataService{datasets: map[string]*UIBEventService{}, def: def}
+	c.add(def)
+
+	paths, _ := filepath.Glob(filepath.Join(dir, "*.json"))
+	for _, p := range paths {
+		ds, err := newEventDataset(p)
+		if err != nil {
+			log.Printf("[campus] ⚠️ skipping %s: %v", p, err)
+			continue
+		}
+		if _, dup := c.datasets[ds.Institution()]; dup {
+			log.Printf("[campus] ⚠️ skipping %s: %s is already loaded", p, ds.Institution())
+			continue
+		}
+		c.add(ds)
+		log.Printf("[campus] ✅ loaded %d events for %s from %s", len(ds.GetAllEvents()), ds.Institution(), p)
+	}
+	return c, nil
+}
+
+func (c *CampusDataService) add(ds *UIBEventService) {
+	c.datasets[ds.Institution()] = ds
+	c.names = append(c.names, ds.Institution())
+}
+
+// Default returns the UIB dataset.
+func (c *CampusDataService) Default() *UIBEventService { return c.def }
+
+// Campuses lists the loaded institutions, default first.
+func (c *CampusDataService) Campuses() []string { return append([]string(nil), c.names...) }
+
+// Dataset returns the dataset for an institution name or alias, or nil.
+func (c *CampusDataService) Dataset(name string) *UIBEventService {
+	if ds, ok := c.datasets[name]; ok {
+		return ds
+	}
+	if alias := resolveUniversityAlias(strings.ToUpper(strings.TrimSpace(name))); alias != "" {
+		return c.datasets[alias]
+	}
+	lower := strings.ToLower(strings.TrimSpace(name))
+	for _, n := range c.names {
+		for _, a := range c.datasets[n].Aliases() {
+			if a == lower {
+				return c.datasets[n]
+			}
+		}
+	}
+	return nil
+}
+
+// ForQuery detects the campus a query is about. It returns the matching
+// dataset, the default dataset when no campus is named, or nil when the query
+// names a campus in universityAliasMap that has no dataset loaded.
+func (c *CampusDataService) ForQuery(query string) (string, *UIBEventService) {
+	if c == nil {
+		return "", nil
+	}
+	lower := strings.ToLower(query)
+	for _, n := range c.names {
+		if c.datasets[n].mentionedIn(lower) {
+			return n, c.datasets[n]
+		}
+	}
+	upper := " " + strings.Join(searchTermTokens(strings.ToUpper(query)), " ") + " "
+	for key, val := range universityAliasMap {
+		if strings.Contains(upper, " "+key+" ") {
+			return val, c.datasets[val]
+		}
+	}
+	return c.def.Institution(), c.def
+}
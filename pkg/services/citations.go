@@ -0,0 +1,66 @@
+package services
+
+import (
+	"AkuAI/pkg/knowledge"
+	"regexp"
+	"strings"
+)
+
+var citationMarkerRe = regexp.MustCompile(`\s?\[((?:EV|DOC)-[A-Z0-9-]+)\]`)
+
+// Citation links one [EV-xxx] or [DOC-x-y] marker in a bot reply to the UIB
+// event or document chunk it cites.
+type Citation struct {
+	Marker     string `json:"marker"`
+	EventID    string `json:"event_id,omitempty"`
+	DocumentID uint   `json:"document_id,omitempty"`
+	Title      string `json:"title"`
+	Line       int    `json:"line"` // 1-based line of the reply containing the marker
+}
+
+// CitationMarker returns the inline source marker for an event ID,
+// e.g. uib_cert_oct_001 -> EV-CERT-OCT-001.
+func CitationMarker(eventID string) string {
+	id := strings.TrimPrefix(strings.ToLower(eventID), "uib_")
+	return "EV-" + strings.ToUpper(strings.ReplaceAll(id, "_", "-"))
+}
+
+// ResolveCitations maps the source markers in text to UIB events and
+// document chunks. Markers that don't belong to a known source are dropped from the text so invented
+// sources never reach the client.
+func ResolveCitations(text string) (string, []Citation) {
+	if !strings.Contains(text, "[EV-") && !strings.Contains(text, "[DOC-") {
+		return text, nil
+	}
+	byMarker := make(map[string]Citation)
+	for _, ev := range knownEvents() {
+		byMarker[CitationMarker(ev.ID)] = Citation{EventID: ev.ID, Title: ev.Title}
+	}
+	docs := knowledge.Default()
+
+	var citations []Citation
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		seen := make(map[string]bool)
+		lines[i] = citationMarkerRe.ReplaceAllStringFunc(line, func(m string) string {
+			marker := citationMarkerRe.FindStringSubmatch(m)[1]
+			src, ok := byMarker[marker]
+			if !ok && strings.HasPrefix(marker, "DOC-") {
+				var hit knowledge.Hit
+				if hit, ok = docs.Lookup(marker); ok {
+					src = Citation{DocumentID: hit.DocumentID, Title: hit.Title}
+				}
+			}
+			if !ok {
+				return ""
+			}
+			if !seen[marker] {
+				seen[marker] = true
+				src.Marker, src.Line = marker, i+1
+				citations = append(citations, src)
+			}
+			return m
+		})
+	}
+	return strings.Join(lines, "\n"), citations
+}
@@ -0,0 +1,122 @@
+package services
+
+import (
+	"AkuAI/models"
+	"AkuAI/pkg/config"
+	"math"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// UncertaintyDisclaimer is prepended to replies whose confidence falls below
+// config.ConfidenceLowThreshold.
+const UncertaintyDisclaimer = "⚠️ Saya tidak yakin sepenuhnya dengan jawaban ini. Mohon verifikasi ke sumber resmi UIB (info@uib.ac.id) sebelum mengandalkannya.\n\n"
+
+var (
+	replyEmailRe = regexp.MustCompile(`[A-Za-z0-9._%+\-]+@[A-Za-z0-9.\-]+\.[A-Za-z]{2,}`)
+	replyURLRe   = regexp.MustCompile(`https?://[^\s)\]>"']+`)
+)
+
+// Confidence is the estimated reliability of a bot reply.
+type Confidence struct {
+	Score   float64  `json:"score"`
+	Level   string   `json:"level"` // high | medium | low
+	Low     bool     `json:"low_confidence"`
+	Reasons []string `json:"reasons,omitempty"`
+}
+
+// EstimateConfidence scores reply from retrieval coverage for question, the
+// model finish reason and a fabrication check of contacts, links and
+// citation markers against the UIB event data.
+func EstimateConfidence(question, reply string, info *GenerationInfo) Confidence {
+	score := 1.0
+	var reasons []string
+	penalize := func(by float64, reason string) {
+		score -= by
+		reasons = append(reasons, reason)
+	}
+
+	var relevant []models.UIBEvent
+	_, uib := defaultCampusData().ForQuery(question)
+	uibQuery := uib != nil && uib.AnalyzeQueryForUIB(question)
+	if uibQuery {
+		relevant = uib.GetRelevantEventsForQuery(question)
+		if len(relevant) == 0 && len(searchDocuments(question)) == 0 {
+			penalize(0.4, "no_retrieval_hits")
+		}
+	}
+
+	switch reason := info.FinishReason(); reason {
+	case "", "STOP":
+	case "MAX_TOKENS":
+		penalize(0.2, "truncated")
+	default:
+		penalize(0.4, "finish_"+strings.ToLower(reason))
+	}
+
+	if uibQuery && fabricatedContact(reply, relevant) {
+		penalize(0.35, "fabricated_contact")
+	}
+	if unverifiedLink(reply) {
+		penalize(0.2, "unverified_link")
+	}
+	if _, citations := ResolveCitations(reply); len(citations) < len(citationMarkerRe.FindAllString(reply, -1)) {
+		penalize(0.2, "unknown_citation")
+	}
+
+	score = math.Max(0, math.Round(score*100)/100)
+	c := Confidence{Score: score, Reasons: reasons}
+	switch {
+	case score < config.ConfidenceLowThreshold:
+		c.Level, c.Low = "low", true
+	case score < 0.8:
+		c.Level = "medium"
+	default:
+		c.Level = "high"
+	}
+	return c
+}
+
+// fabricatedContact reports emails in reply that belong to none of the
+// relevant events (the general UIB address is always allowed).
+func fabricatedContact(reply string, relevant []models.UIBEvent) bool {
+	allowed := map[string]bool{"info@uib.ac.id": true}
+	for _, ev := range relevant {
+		for _, e := range replyEmailRe.FindAllString(ev.Contact, -1) {
+			allowed[strings.ToLower(e)] = true
+		}
+	}
+	for _, e := range replyEmailRe.FindAllString(reply, -1) {
+		if !allowed[strings.ToLower(e)] {
+			return true
+		}
+	}
+	return false
+}
+
+// unverifiedLink reports URLs outside uib.ac.id that aren't a known
+// registration link.
+func unverifiedLink(reply string) bool {
+	known := map[string]bool{}
+	for _, ev := range knownEvents() {
+		if ev.RegistrationLink != "" {
+			known[strings.TrimRight(ev.RegistrationLink, "/")] = true
+		}
+	}
+	for _, raw := range replyURLRe.FindAllString(reply, -1) {
+		raw = strings.TrimRight(raw, ".,;:/")
+		if known[raw] {
+			continue
+		}
+		u, err := url.Parse(raw)
+		if err != nil {
+			return true
+		}
+		host := strings.ToLower(u.Hostname())
+		if host != "uib.ac.id" && !strings.HasSuffix(host, ".uib.ac.id") {
+			return true
+		}
+	}
+	return false
+}
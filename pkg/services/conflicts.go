@@ -0,0 +1,135 @@
+package services
+
+import (
+	"AkuAI/models"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Conflict is a pair of events whose times overlap, from Start to End.
+// Approximate is set when one of them has no end time and is taken to last
+// until the end of its day.
+type Conflict struct {
+	A           models.UIBEvent `json:"a"`
+	B           models.UIBEvent `json:"b"`
+	Start       time.Time       `json:"start"`
+	End         time.Time       `json:"end"`
+	Approximate bool            `json:"approximate"`
+}
+
+// eventSpan is when ev takes place, approximate when its end is assumed.
+func eventSpan(ev models.UIBEvent) (start, end time.Time, approximate, ok bool) {
+	start, end, _, ok = eventTimes(ev)
+	if !ok {
+		return
+	}
+	if end.IsZero() {
+		end, _ = EventEnd(ev)
+		approximate = true
+	}
+	return start, end, approximate, true
+}
+
+// FindConflicts returns the pairs of events whose times overlap, in order of
+// the overlap's start. Events whose date doesn't parse are skipped.
+func FindConflicts(events []models.UIBEvent) []Conflict {
+	type span struct {
+		ev         models.UIBEvent
+		start, end time.Time
+		approx     bool
+	}
+	var spans []span
+	for _, ev := range events {
+		if start, end, approx, ok := eventSpan(ev); ok {
+			spans = append(spans, span{ev, start, end, approx})
+		}
+	}
+	sort.SliceStable(spans, func(i, j int) bool { return spans[i].start.Before(spans[j].start) })
+
+	var out []Conflict
+	for i, a := range spans {
+		for _, b := range spans[i+1:] {
+			if !b.start.Before(a.end) {
+				continue
+			}
+			end := a.end
+			if b.end.Before(end) {
+				end = b.end
+			}
+			out = append(out, Conflict{A: a.ev, B: b.ev, Start: b.start, End: end, Approximate: a.approx || b.approx})
+		}
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[i].Start.Before(out[j].Start) })
+	return out
+}
+
+// EventsByID returns the events of ids in order, and the ids not found.
+func (s *UIBEventService) EventsByID(ids []string) (events []models.UIBEvent, missing []string) {
+	for _, id := range ids {
+		ev, err := s.GetEventByID(id)
+		if err != nil {
+			missing = append(missing, id)
+			continue
+		}
+		events = append(events, *ev)
+	}
+	return events, missing
+}
+
+var conflictWords = []string{"bentrok", "tabrakan", "bertabrakan", "bersamaan", "jadwal yang sama", "waktu yang sama",
+	"conflict", "overlap", "clash"}
+
+// IsConflictQuery reports whether text asks about clashing events ("apakah
+// ada acara yang bentrok?").
+func IsConflictQuery(text string) bool {
+	lower := strings.ToLower(text)
+	for _, w := range conflictWords {
+		if strings.Contains(lower, w) {
+			return true
+		}
+	}
+	return false
+}
+
+// ConflictSection renders the conflicts among checked events for the system
+// instruction of a conflict question.
+func ConflictSection(checked int, conflicts []Conflict) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "\n\nPEMERIKSAAN JADWAL BENTROK (dihitung server dari %d acara; gunakan hasil ini, jangan menghitung sendiri):\n", checked)
+	if len(conflicts) == 0 {
+		b.WriteString("Tidak ada acara yang waktunya bentrok.\n")
+		return b.String()
+	}
+	loc := EventLocation()
+	for i, c := range conflicts {
+		fmt.Fprintf(&b, "%d. %s [%s] dan %s [%s] bentrok pada %s %s-%s", i+1, c.A.Title, CitationMarker(c.A.ID),
+			c.B.Title, CitationMarker(c.B.ID), IndonesianDate(c.Start.In(loc).Format("2006-01-02")),
+			c.Start.In(loc).Format("15:04"), c.End.In(loc).Format("15:04 MST"))
+		if c.Approximate {
+			b.WriteString(" (perkiraan: jam selesai salah satu acara tidak tercantum)")
+		}
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+// ChatConflicts returns the ConflictSection for a chat question: the
+// conflicts among the events it is about, by default the upcoming ones, in
+// the dataset of the campus it names.
+func ChatConflicts(question string) string {
+	campuses := defaultCampusData()
+	if campuses == nil {
+		return ""
+	}
+	_, ds := campuses.ForQuery(question)
+	if ds == nil {
+		return ""
+	}
+	events := ds.GetRelevantEventsForQuery(question)
+	if len(events) == 0 {
+		events = ds.GetUpcomingEvents()
+	}
+	return ConflictSection(len(events), FindConflicts(events))
+}
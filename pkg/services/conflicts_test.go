@@ -0,0 +1,43 @@
+package services
+
+import (
+	"AkuAI/models"
+	"strings"
+	"testing"
+)
+
+func TestFindConflicts(t *testing.T) {
+	data := &models.UIBEventsData{}
+	data.UIBEvents.November2025 = []models.UIBEvent{
+		{ID: "a", Title: "Sertifikasi A", Date: "2025-11-12", Time: "09:00-12:00"},
+		{ID: "b", Title: "Webinar B", Date: "2025-11-12", Time: "11:00-13:00"},
+		{ID: "c", Title: "Webinar C", Date: "2025-11-12", Time: "13:00-15:00"},
+		{ID: "d", Title: "Lomba D", Date: "2025-11-13", Time: "19:00"},
+		{ID: "e", Title: "Bootcamp E", Date: "2025-11-13", Time: "20:00-22:00"},
+	}
+	s := &UIBEventService{eventsData: data}
+
+	got := FindConflicts(s.GetAllEvents())
+	if len(got) != 2 {
+		t.Fatalf("conflicts = %+v", got)
+	}
+	if got[0].A.ID != "a" || got[0].B.ID != "b" || got[0].Start.Hour() != 11 || got[0].End.Hour() != 12 || got[0].Approximate {
+		t.Errorf("first conflict = %+v", got[0])
+	}
+	if got[1].A.ID != "d" || got[1].B.ID != "e" || !got[1].Approximate {
+		t.Errorf("open-ended conflict = %+v", got[1])
+	}
+
+	events, missing := s.EventsByID([]string{"b", "c", "zz"})
+	if len(FindConflicts(events)) != 0 || len(missing) != 1 {
+		t.Errorf("back-to-back events conflict, or missing = %v", missing)
+	}
+
+	section := ConflictSection(5, got)
+	if !strings.Contains(section, "[EV-A]") || !strings.Contains(section, "11:00-12:00") {
+		t.Errorf("section = %s", section)
+	}
+	if !IsConflictQuery("Apakah ada acara yang bentrok?") || IsConflictQuery("acara bulan november") {
+		t.Error("IsConflictQuery")
+	}
+}
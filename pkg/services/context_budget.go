@@ -0,0 +1,118 @@
+package services
+
+import (
+	"AkuAI/models"
+	"AkuAI/pkg/config"
+	"log"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// contextCharsPerToken is the rough chars/token ratio used to estimate the
+// size of the event context (abscore uses the same default).
+const contextCharsPerToken = 4
+
+// contextBudget is what happened to the events of one context.
+type contextBudget struct {
+	Tokens    int      // estimated tokens of the formatted context
+	Kept      int      // events in the context
+	Dropped   []string // IDs of events left out, least relevant last
+	Truncated int      // descriptions that were shortened
+}
+
+func estimateContextTokens(s string) int {
+	return (utf8.RuneCountInString(s) + contextCharsPerToken - 1) / contextCharsPerToken
+}
+
+// EventContext is the event context given to Gemini for query: events are
+// ranked by relevance to query, long descriptions are cut to
+// config.EventContextDescChars and the least relevant events are dropped until
+// the context fits config.EventContextMaxTokens. The most relevant event is
+// always kept. A limit of 0 disables that step.
+func (s *UIBEventService) EventContext(query string, events []models.UIBEvent) string {
+	ctx, b := s.budgetEventContext(query, events, config.EventContextMaxTokens, config.EventContextDescChars)
+	if len(b.Dropped) > 0 || b.Truncated > 0 {
+		log.Printf("[context] ✂️ ~%d/%d tokens: kept %d of %d events, dropped %s, %d descriptions truncated",
+			b.Tokens, config.EventContextMaxTokens, b.Kept, b.Kept+len(b.Dropped), strings.Join(b.Dropped, ","), b.Truncated)
+	}
+	return ctx
+}
+
+func (s *UIBEventService) budgetEventContext(query string, events []models.UIBEvent, maxTokens, descChars int) (string, contextBudget) {
+	ranked := rankEventsForQuery(query, events)
+	var b contextBudget
+	if descChars > 0 {
+		for i := range ranked {
+			if d, cut := truncateDescription(ranked[i].Description, descChars); cut {
+				ranked[i].Description = d
+				b.Truncated++
+			}
+		}
+	}
+
+	kept := ranked
+	out := s.FormatEventsForGemini(kept)
+	if maxTokens > 0 && estimateContextTokens(out) > maxTokens {
+		kept = nil
+		for _, ev := range ranked {
+			try := s.FormatEventsForGemini(append(kept, ev))
+			if len(kept) == 0 || estimateContextTokens(try) <= maxTokens {
+				kept, out = append(kept, ev), try
+			} else {
+				b.Dropped = append(b.Dropped, ev.ID)
+			}
+		}
+	}
+	b.Tokens, b.Kept = estimateContextTokens(out), len(kept)
+	return out, b
+}
+
+// rankEventsForQuery orders events by how many of the query's significant
+// words they contain: a title match counts 3, type, department or speaker 2
+// and the description 1. Ties keep the retrieval order.
+func rankEventsForQuery(query string, events []models.UIBEvent) []models.UIBEvent {
+	var words []string
+	for _, t := range searchTermTokens(strings.ToLower(query)) {
+		if len(t) >= 3 && !searchTermStopwords[t] {
+			words = append(words, t)
+		}
+	}
+	score := func(ev models.UIBEvent) int {
+		title := strings.ToLower(ev.Title)
+		meta := strings.ToLower(ev.Type + " " + ev.Department + " " + ev.Speaker)
+		desc := strings.ToLower(ev.Description)
+		n := 0
+		for _, w := range words {
+			switch {
+			case strings.Contains(title, w):
+				n += 3
+			case strings.Contains(meta, w):
+				n += 2
+			case strings.Contains(desc, w):
+				n++
+			}
+		}
+		return n
+	}
+	ranked := append([]models.UIBEvent(nil), events...)
+	scores := make(map[string]int, len(ranked))
+	for _, ev := range ranked {
+		scores[ev.ID] = score(ev)
+	}
+	sort.SliceStable(ranked, func(i, j int) bool { return scores[ranked[i].ID] > scores[ranked[j].ID] })
+	return ranked
+}
+
+// truncateDescription cuts s to at most n runes at a word boundary.
+func truncateDescription(s string, n int) (string, bool) {
+	if utf8.RuneCountInString(s) <= n {
+		return s, false
+	}
+	r := []rune(s)[:n]
+	cut := string(r)
+	if i := strings.LastIndexByte(cut, ' '); i > len(cut)/2 {
+		cut = cut[:i]
+	}
+	return strings.TrimRight(cut, " ,.;:") + "…", true
+}
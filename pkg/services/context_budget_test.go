@@ -0,0 +1,68 @@
+package services
+
+import (
+	"AkuAI/models"
+	"strings"
+	"testing"
+)
+
+func budgetTestEvents() []models.UIBEvent {
+	long := strings.Repeat("Pelatihan intensif dengan studi kasus industri. ", 20)
+	return []models.UIBEvent{
+		{ID: "cert_oct_001", Type: "certification", Title: "Sertifikasi Jaringan Dasar", Date: "2025-10-20", Department: "Teknik", Description: long},
+		{ID: "web_nov_001", Type: "webinar", Title: "Webinar Keamanan Siber", Date: "2025-11-05", Department: "Sistem Informasi", Description: "Ancaman siber terkini."},
+		{ID: "cert_nov_002", Type: "certification", Title: "Sertifikasi Cloud Practitioner", Date: "2025-11-12", Department: "Teknik", Description: long},
+	}
+}
+
+func TestRankEventsForQuery(t *testing.T) {
+	got := rankEventsForQuery("webinar keamanan siber bulan november", budgetTestEvents())
+	if got[0].ID != "web_nov_001" {
+		t.Fatalf("first event %s, want web_nov_001", got[0].ID)
+	}
+	// no significant words: retrieval order is kept
+	got = rankEventsForQuery("apa saja?", budgetTestEvents())
+	for i, want := range []string{"cert_oct_001", "web_nov_001", "cert_nov_002"} {
+		if got[i].ID != want {
+			t.Errorf("rank %d: %s, want %s", i, got[i].ID, want)
+		}
+	}
+}
+
+func TestBudgetEventContext(t *testing.T) {
+	s := &UIBEventService{eventsData: &models.UIBEventsData{}}
+	events := budgetTestEvents()
+
+	full, b := s.budgetEventContext("sertifikasi cloud", events, 0, 0)
+	if b.Kept != 3 || len(b.Dropped) != 0 || b.Truncated != 0 {
+		t.Fatalf("unlimited budget: %+v", b)
+	}
+
+	ctx, b := s.budgetEventContext("sertifikasi cloud", events, 0, 100)
+	if b.Truncated != 2 || !strings.Contains(ctx, "…") || estimateContextTokens(ctx) >= estimateContextTokens(full) {
+		t.Fatalf("description limit: %+v", b)
+	}
+
+	ctx, b = s.budgetEventContext("sertifikasi cloud", events, 1, 0)
+	if b.Kept != 1 || len(b.Dropped) != 2 || !strings.Contains(ctx, "Cloud Practitioner") {
+		t.Fatalf("tiny budget keeps only the most relevant event: %+v", b)
+	}
+
+	ctx, b = s.budgetEventContext("sertifikasi cloud", events, estimateContextTokens(full)-1, 100)
+	if b.Kept != 3 || b.Tokens > estimateContextTokens(full)-1 {
+		t.Fatalf("truncation alone fits the budget: %+v", b)
+	}
+	if strings.Index(ctx, "Cloud Practitioner") > strings.Index(ctx, "Jaringan Dasar") {
+		t.Error("most relevant event should come first")
+	}
+}
+
+func TestTruncateDescription(t *testing.T) {
+	if s, cut := truncateDescription("pendek", 10); cut || s != "pendek" {
+		t.Errorf("short description changed: %q", s)
+	}
+	s, cut := truncateDescription("Workshop tentang desain antarmuka pengguna modern", 20)
+	if !cut || s != "Workshop tentang…" {
+		t.Errorf("got %q", s)
+	}
+}
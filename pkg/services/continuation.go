@@ -0,0 +1,135 @@
+package services
+
+import (
+	"AkuAI/pkg/config"
+	"context"
+	"encoding/json"
+	"log"
+	"strings"
+	"unicode/utf8"
+)
+
+// ContinueInstruction asks the model to pick up a reply that was cut off,
+// which is passed back as the previous model turn.
+const ContinueInstruction = "Lanjutkan jawaban Anda sebelumnya tepat dari bagian terakhir yang terpotong. " +
+	"Jangan mengulang bagian yang sudah ditulis dan jangan menambahkan pembuka."
+
+// A continuation that starts by repeating the end of the reply is trimmed.
+// Only the last overlapWindow bytes are compared, and overlaps shorter than
+// minOverlap are taken as chance.
+const (
+	overlapWindow = 300
+	minOverlap    = 12
+)
+
+// withContinuation returns the request body with reply as the last model
+// turn followed by ContinueInstruction.
+func withContinuation(body []byte, reply string) ([]byte, error) {
+	var req map[string]any
+	if err := json.Unmarshal(body, &req); err != nil {
+		return nil, err
+	}
+	contents, _ := req["contents"].([]any)
+	req["contents"] = append(contents,
+		map[string]any{"role": "model", "parts": []any{map[string]any{"text": reply}}},
+		map[string]any{"role": "user", "parts": []any{map[string]any{"text": ContinueInstruction}}},
+	)
+	return json.Marshal(req)
+}
+
+// trimOverlap drops the start of next that repeats the end of prev.
+func trimOverlap(prev, next string) string {
+	for k := min(len(prev), len(next), overlapWindow); k >= minOverlap; k-- {
+		if (k == len(next) || utf8.RuneStart(next[k])) && strings.HasSuffix(prev, next[:k]) {
+			return next[k:]
+		}
+	}
+	return next
+}
+
+// continues reports whether a reply stopped at maxOutputTokens should be
+// continued after n continuations.
+func continues(text, reason string, n int) bool {
+	return reason == "MAX_TOKENS" && strings.TrimSpace(text) != "" && n < config.GeminiMaxContinuations
+}
+
+// callGenerateContentWithBody generates a reply and, while it stops at
+// maxOutputTokens, asks the model to continue it, up to
+// GEMINI_MAX_CONTINUATIONS times. The parts are stitched into one reply; a
+// failed continuation leaves the reply as far as it got.
+func (s *GeminiService) callGenerateContentWithBody(ctx context.Context, model string, body []byte) (string, error) {
+	text, reason, err := s.generateContentOnce(ctx, model, body)
+	for n := 0; err == nil && continues(text, reason, n); n++ {
+		next, jerr := withContinuation(body, text)
+		if jerr != nil {
+			break
+		}
+		var part string
+		var cerr error
+		if part, reason, cerr = s.generateContentOnce(ctx, model, next); cerr != nil {
+			log.Printf("[gemini] ⚠️ continuation %d of %s failed: %v", n+1, model, cerr)
+			break
+		}
+		text += trimOverlap(text, part)
+		recordContinuation(ctx)
+	}
+	return text, err
+}
+
+// callStreamGenerateContentWithBody is callGenerateContentWithBody for
+// streams: the continuations are streamed to onDelta after the first part,
+// minus any repeated text, so clients see one answer.
+func (s *GeminiService) callStreamGenerateContentWithBody(ctx context.Context, model string, body []byte, onDelta func(string)) (string, error) {
+	text, reason, err := s.streamGenerateContentOnce(ctx, model, body, onDelta)
+	for n := 0; err == nil && continues(text, reason, n); n++ {
+		next, jerr := withContinuation(body, text)
+		if jerr != nil {
+			break
+		}
+		w := &overlapWriter{prev: text, onDelta: onDelta}
+		part, r, cerr := s.streamGenerateContentOnce(ctx, model, next, w.write)
+		w.flush()
+		reason = r
+		if rest := trimOverlap(text, part); rest != "" {
+			text += rest
+			recordContinuation(ctx)
+		}
+		if cerr != nil {
+			log.Printf("[gemini] ⚠️ stream continuation %d of %s failed: %v", n+1, model, cerr)
+			break
+		}
+	}
+	return text, err
+}
+
+// overlapWriter holds back the start of a streamed continuation until it
+// can tell how much of it repeats prev.
+type overlapWriter struct {
+	prev    string
+	pending strings.Builder
+	flushed bool
+	onDelta func(string)
+}
+
+func (w *overlapWriter) write(delta string) {
+	if w.flushed {
+		if w.onDelta != nil {
+			w.onDelta(delta)
+		}
+		return
+	}
+	w.pending.WriteString(delta)
+	if w.pending.Len() >= overlapWindow {
+		w.flush()
+	}
+}
+
+func (w *overlapWriter) flush() {
+	if w.flushed {
+		return
+	}
+	w.flushed = true
+	if rest := trimOverlap(w.prev, w.pending.String()); rest != "" && w.onDelta != nil {
+		w.onDelta(rest)
+	}
+}
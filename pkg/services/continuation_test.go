@@ -0,0 +1,131 @@
+package services
+
+import (
+	"AkuAI/pkg/config"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+// fakeGemini answers each request with the next reply, as a generateContent
+// response or, for streams, one SSE chunk per word. usage, when set, is the
+// usageMetadata of each reply, which streams repeat on every chunk.
+type fakeGemini struct {
+	replies []struct{ text, reason string }
+	usage   []map[string]any
+	bodies  []map[string]any
+}
+
+func (f *fakeGemini) RoundTrip(req *http.Request) (*http.Response, error) {
+	var body map[string]any
+	_ = json.NewDecoder(req.Body).Decode(&body)
+	f.bodies = append(f.bodies, body)
+	r := f.replies[len(f.bodies)-1]
+	var usage map[string]any
+	if len(f.usage) >= len(f.bodies) {
+		usage = f.usage[len(f.bodies)-1]
+	}
+	chunk := func(text, reason string) string {
+		cand := map[string]any{"content": map[string]any{"parts": []any{map[string]any{"text": text}}}}
+		if reason != "" {
+			cand["finishReason"] = reason
+		}
+		resp := map[string]any{"candidates": []any{cand}}
+		if usage != nil {
+			resp["usageMetadata"] = usage
+		}
+		b, _ := json.Marshal(resp)
+		return string(b)
+	}
+	var out string
+	if strings.Contains(req.URL.Path, ":streamGenerateContent") {
+		words := strings.SplitAfter(r.text, " ")
+		for i, w := range words {
+			reason := ""
+			if i == len(words)-1 {
+				reason = r.reason
+			}
+			out += "data: " + chunk(w, reason) + "\n\n"
+		}
+	} else {
+		out = chunk(r.text, r.reason)
+	}
+	return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader(out)), Header: http.Header{}}, nil
+}
+
+func withFakeGemini(t *testing.T, replies ...string) *fakeGemini {
+	f := &fakeGemini{}
+	for i := 0; i+1 < len(replies); i += 2 {
+		f.replies = append(f.replies, struct{ text, reason string }{replies[i], replies[i+1]})
+	}
+	prev, prevMax := http.DefaultClient.Transport, config.GeminiMaxContinuations
+	http.DefaultClient.Transport, config.GeminiMaxContinuations = f, 2
+	t.Cleanup(func() { http.DefaultClient.Transport, config.GeminiMaxContinuations = prev, prevMax })
+	return f
+}
+
+func TestContinuationStitchesParts(t *testing.T) {
+	f := withFakeGemini(t,
+		"1. Webinar Data Science, 12 Nov", "MAX_TOKENS",
+		"Data Science, 12 November 2025\n2. Sertifikasi", "MAX_TOKENS",
+		" MOS, 20 November 2025", "STOP",
+	)
+	ctx, info := WithGenerationInfo(context.Background())
+	s := &GeminiService{apiKey: "k"}
+	body := []byte(`{"contents":[{"role":"user","parts":[{"text":"webinar november"}]}]}`)
+	got, err := s.callGenerateContentWithBody(ctx, "m", body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "1. Webinar Data Science, 12 November 2025\n2. Sertifikasi MOS, 20 November 2025"
+	if got != want {
+		t.Errorf("stitched = %q, want %q", got, want)
+	}
+	if info.Parts() != 3 || info.FinishReason() != "STOP" {
+		t.Errorf("parts = %d, finish = %s", info.Parts(), info.FinishReason())
+	}
+	contents := f.bodies[2]["contents"].([]any)
+	if len(contents) != 3 {
+		t.Fatalf("continuation contents = %v", contents)
+	}
+	model := contents[1].(map[string]any)["parts"].([]any)[0].(map[string]any)["text"]
+	if model != "1. Webinar Data Science, 12 November 2025\n2. Sertifikasi" {
+		t.Errorf("model turn = %q", model)
+	}
+	if ask := contents[2].(map[string]any)["parts"].([]any)[0].(map[string]any)["text"]; ask != ContinueInstruction {
+		t.Errorf("continuation ask = %q", ask)
+	}
+}
+
+func TestContinuationStreamsOnce(t *testing.T) {
+	withFakeGemini(t,
+		"Berikut webinar UIB bulan November: Data", "MAX_TOKENS",
+		"webinar UIB bulan November: Data Science dan Cloud.", "STOP",
+	)
+	ctx, info := WithGenerationInfo(context.Background())
+	var streamed strings.Builder
+	s := &GeminiService{apiKey: "k"}
+	got, err := s.callStreamGenerateContentWithBody(ctx, "m", []byte(`{"contents":[]}`), func(d string) { streamed.WriteString(d) })
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "Berikut webinar UIB bulan November: Data Science dan Cloud."
+	if got != want || streamed.String() != want {
+		t.Errorf("reply = %q, streamed = %q, want %q", got, streamed.String(), want)
+	}
+	if info.Parts() != 2 {
+		t.Errorf("parts = %d", info.Parts())
+	}
+}
+
+func TestContinuationLimit(t *testing.T) {
+	withFakeGemini(t, "a b", "MAX_TOKENS", " c", "MAX_TOKENS", " d", "MAX_TOKENS", " e", "STOP")
+	ctx, info := WithGenerationInfo(context.Background())
+	got, err := (&GeminiService{apiKey: "k"}).callGenerateContentWithBody(ctx, "m", []byte(`{}`))
+	if err != nil || got != "a b c d" || info.Parts() != 3 || info.FinishReason() != "MAX_TOKENS" {
+		t.Errorf("got %q, %v, parts %d, finish %s", got, err, info.Parts(), info.FinishReason())
+	}
+}
@@ -0,0 +1,136 @@
+package services
+
+import (
+	"AkuAI/models"
+	"sort"
+	"strings"
+)
+
+// EventFaculty is the faculty an event belongs to: its faculty field, or
+// its department when that is a faculty itself. Events of other units
+// (language center, career center, ...) have none.
+func EventFaculty(ev models.UIBEvent) string {
+	if ev.Faculty != "" {
+		return ev.Faculty
+	}
+	if strings.HasPrefix(strings.ToLower(ev.Department), "fakultas") {
+		return ev.Department
+	}
+	return ""
+}
+
+// containsFold reports whether filter occurs in value, case-insensitively,
+// the way the department filters match.
+func containsFold(value, filter string) bool {
+	return strings.Contains(strings.ToLower(value), strings.ToLower(filter))
+}
+
+// InUnit reports whether ev matches the department and faculty filters of
+// the search endpoints; empty filters match every event.
+func InUnit(ev models.UIBEvent, department, faculty string) bool {
+	if department != "" && !containsFold(ev.Department, department) {
+		return false
+	}
+	return faculty == "" || containsFold(EventFaculty(ev), faculty)
+}
+
+// Departments returns the distinct departments with their event counts,
+// most events first.
+func (s *UIBEventService) Departments() []models.DepartmentCount {
+	index := map[string]int{}
+	var out []models.DepartmentCount
+	for _, ev := range s.GetAllEvents() {
+		if ev.Department == "" {
+			continue
+		}
+		i, ok := index[ev.Department]
+		if !ok {
+			i = len(out)
+			index[ev.Department] = i
+			out = append(out, models.DepartmentCount{Department: ev.Department, Faculty: EventFaculty(ev)})
+		}
+		out[i].Events++
+	}
+	sort.SliceStable(out, func(i, j int) bool {
+		if out[i].Events != out[j].Events {
+			return out[i].Events > out[j].Events
+		}
+		return out[i].Department < out[j].Department
+	})
+	return out
+}
+
+// Faculties groups the departments of Departments by faculty, most events
+// first. Departments without a faculty are left out.
+func (s *UIBEventService) Faculties() []models.FacultyCount {
+	index := map[string]int{}
+	var out []models.FacultyCount
+	for _, d := range s.Departments() {
+		if d.Faculty == "" {
+			continue
+		}
+		i, ok := index[d.Faculty]
+		if !ok {
+			i = len(out)
+			index[d.Faculty] = i
+			out = append(out, models.FacultyCount{Faculty: d.Faculty})
+		}
+		out[i].Events += d.Events
+		out[i].Departments = append(out[i].Departments, d.Department)
+	}
+	sort.SliceStable(out, func(i, j int) bool {
+		if out[i].Events != out[j].Events {
+			return out[i].Events > out[j].Events
+		}
+		return out[i].Faculty < out[j].Faculty
+	})
+	return out
+}
+
+// unitMarkers introduce a faculty or department in a question ("acara
+// dari fakultas ekonomi", "prodi akuntansi").
+var unitMarkers = []string{"fakultas", "prodi", "program studi", "jurusan", "departemen", "pusat"}
+
+// unitFillers are the words of unit names that don't tell units apart.
+var unitFillers = map[string]bool{"fakultas": true, "program": true, "studi": true, "pusat": true, "uib": true, "dan": true}
+
+// namesUnit reports whether queryLower names the unit: its full name, or a
+// marker followed by the first distinctive word of the name ("fakultas
+// ekonomi" for "Fakultas Ekonomi dan Bisnis").
+func namesUnit(queryLower, name string) bool {
+	lower := strings.ToLower(name)
+	if strings.Contains(queryLower, lower) {
+		return true
+	}
+	for _, w := range strings.Fields(lower) {
+		if unitFillers[w] {
+			continue
+		}
+		for _, m := range unitMarkers {
+			if strings.Contains(queryLower, m+" "+w) {
+				return true
+			}
+		}
+		return false
+	}
+	return false
+}
+
+// unitsInQuery returns the departments and faculties of the dataset that
+// queryLower names.
+func (s *UIBEventService) unitsInQuery(queryLower string) map[string]bool {
+	units := map[string]bool{}
+	for _, ev := range s.GetAllEvents() {
+		for _, name := range []string{ev.Department, EventFaculty(ev)} {
+			if name != "" && namesUnit(queryLower, name) {
+				units[name] = true
+			}
+		}
+	}
+	return units
+}
+
+// inUnits reports whether ev belongs to one of units, or units is empty.
+func inUnits(units map[string]bool, ev models.UIBEvent) bool {
+	return len(units) == 0 || units[ev.Department] || units[EventFaculty(ev)]
+}
@@ -0,0 +1,53 @@
+package services
+
+import (
+	"AkuAI/models"
+	"slices"
+	"testing"
+)
+
+func TestDepartments(t *testing.T) {
+	data := &models.UIBEventsData{}
+	data.UIBEvents.November2025 = []models.UIBEvent{
+		{ID: "a", Type: "certification", Date: "2025-11-03", Department: "Program Studi Akuntansi", Faculty: "Fakultas Ekonomi dan Bisnis"},
+		{ID: "b", Type: "webinar", Date: "2025-11-10", Department: "Fakultas Ekonomi dan Bisnis"},
+		{ID: "c", Type: "certification", Date: "2025-11-12", Department: "Program Studi Akuntansi", Faculty: "Fakultas Ekonomi dan Bisnis"},
+		{ID: "d", Type: "webinar", Date: "2025-11-20", Department: "Pusat Bahasa UIB"},
+		{ID: "e", Type: "certification", Date: "2025-11-25", Department: "Program Studi Teknik Informatika", Faculty: "Fakultas Teknik dan Informatika"},
+	}
+	s := &UIBEventService{eventsData: data}
+
+	deps := s.Departments()
+	if len(deps) != 4 || deps[0].Department != "Program Studi Akuntansi" || deps[0].Events != 2 {
+		t.Fatalf("Departments = %+v", deps)
+	}
+	facs := s.Faculties()
+	if len(facs) != 2 || facs[0].Faculty != "Fakultas Ekonomi dan Bisnis" || facs[0].Events != 3 || len(facs[0].Departments) != 2 {
+		t.Fatalf("Faculties = %+v", facs)
+	}
+
+	ids := func(evs []models.UIBEvent) []string {
+		var out []string
+		for _, ev := range evs {
+			out = append(out, ev.ID)
+		}
+		return out
+	}
+	if got := ids(s.SearchEvents(models.EventSearchCriteria{Faculty: "ekonomi"})); !slices.Equal(got, []string{"a", "b", "c"}) {
+		t.Errorf("faculty search = %v", got)
+	}
+
+	q := "acara dari fakultas ekonomi"
+	if !s.AnalyzeQueryForUIB(q) {
+		t.Fatalf("%q not detected as an event query", q)
+	}
+	if got := ids(s.GetRelevantEventsForQuery(q)); !slices.Equal(got, []string{"a", "b", "c"}) {
+		t.Errorf("relevant events of %q = %v", q, got)
+	}
+	if got := ids(s.GetRelevantEventsForQuery("sertifikasi prodi akuntansi")); !slices.Equal(got, []string{"a", "c"}) {
+		t.Errorf("relevant events of a study program = %v", got)
+	}
+	if s.AnalyzeQueryForUIB("jurusan akuntansi belajar apa saja?") {
+		t.Error("a study program question without an event word was detected")
+	}
+}
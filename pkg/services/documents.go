@@ -0,0 +1,27 @@
+package services
+
+import (
+	"AkuAI/pkg/config"
+	"AkuAI/pkg/knowledge"
+	"log"
+)
+
+// searchDocuments returns the uploaded FAQ/guide chunks relevant to question.
+func searchDocuments(question string) []knowledge.Hit {
+	return knowledge.Default().Search(question, config.KnowledgeTopK, config.KnowledgeMinScore)
+}
+
+// documentContext renders the relevant document chunks as an extra block for
+// the system instruction, or "" when nothing matches or s is not for the
+// operator.
+func (s *GeminiService) documentContext(question string) string {
+	if !s.documents {
+		return ""
+	}
+	hits := searchDocuments(question)
+	if len(hits) == 0 {
+		return ""
+	}
+	log.Printf("[gemini] 📄 Adding %d document chunks to context", len(hits))
+	return "\n\n" + knowledge.FormatContext(hits)
+}
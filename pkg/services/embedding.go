@@ -0,0 +1,65 @@
+package services
+
+import (
+	"AkuAI/pkg/config"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// GeminiEmbedder implements cache.Embedder with the Gemini embedContent API.
+type GeminiEmbedder struct {
+	apiKey string
+	model  string
+}
+
+func NewGeminiEmbedder() *GeminiEmbedder {
+	return &GeminiEmbedder{apiKey: config.GeminiAPIKey, model: config.GeminiEmbeddingModel}
+}
+
+func (e *GeminiEmbedder) Embed(ctx context.Context, text string) ([]float32, error) {
+	if !config.IsGeminiEnabled || e.apiKey == "" {
+		return nil, ErrGeminiDisabled
+	}
+	body, _ := json.Marshal(map[string]any{
+		"model":   "models/" + e.model,
+		"content": map[string]any{"parts": []map[string]string{{"text": text}}},
+	})
+	url := fmt.Sprintf("https://generativelanguage.googleapis.com/v1beta/models/%s:embedContent?key=%s", e.model, e.apiKey)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("http error: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBytes, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("read error: %w", err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("status %d: %s", resp.StatusCode, strings.TrimSpace(string(respBytes)))
+	}
+
+	var parsed struct {
+		Embedding struct {
+			Values []float32 `json:"values"`
+		} `json:"embedding"`
+	}
+	if err := json.Unmarshal(respBytes, &parsed); err != nil {
+		return nil, fmt.Errorf("decode error: %w", err)
+	}
+	if len(parsed.Embedding.Values) == 0 {
+		return nil, fmt.Errorf("empty embedding")
+	}
+	return parsed.Embedding.Values, nil
+}
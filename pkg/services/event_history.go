@@ -0,0 +1,85 @@
+package services
+
+import (
+	"AkuAI/models"
+	"encoding/json"
+	"reflect"
+	"sort"
+	"sync"
+)
+
+// FieldChange is a changed field of an event, by its JSON name; Before is
+// nil for a field the event didn't have.
+type FieldChange struct {
+	Field  string `json:"field"`
+	Before any    `json:"before"`
+	After  any    `json:"after"`
+}
+
+// EventChange is a created or updated event of a dataset.
+type EventChange struct {
+	Campus  string        `json:"campus"`
+	EventID string        `json:"event_id"`
+	Action  string        `json:"action"` // create, update
+	Fields  []FieldChange `json:"fields"`
+
+	date string // of the event after the change
+}
+
+// derivedEventFields are set when the data is loaded, not stored.
+var derivedEventFields = map[string]bool{"starts_at": true, "ends_at": true, "all_day": true, "fee": true}
+
+// DiffEvents returns the fields that differ between before and after, in
+// name order. Fields derived when the data is loaded are left out.
+func DiffEvents(before, after models.UIBEvent) []FieldChange {
+	b, a := eventFields(before), eventFields(after)
+	names := map[string]bool{}
+	for k := range b {
+		names[k] = true
+	}
+	for k := range a {
+		names[k] = true
+	}
+	var changes []FieldChange
+	for k := range names {
+		if derivedEventFields[k] || reflect.DeepEqual(b[k], a[k]) {
+			continue
+		}
+		changes = append(changes, FieldChange{Field: k, Before: b[k], After: a[k]})
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Field < changes[j].Field })
+	return changes
+}
+
+func eventFields(ev models.UIBEvent) map[string]any {
+	raw, _ := json.Marshal(ev)
+	var m map[string]any
+	_ = json.Unmarshal(raw, &m)
+	return m
+}
+
+var (
+	eventHooksMu sync.Mutex
+	eventHooks   []func([]EventChange)
+)
+
+// OnEventsChanged registers fn to be called after events change, e.g. to
+// drop cached answers about them (see EventChangeTags).
+func OnEventsChanged(fn func(changes []EventChange)) {
+	eventHooksMu.Lock()
+	defer eventHooksMu.Unlock()
+	eventHooks = append(eventHooks, fn)
+}
+
+// eventsChanged runs the OnEventsChanged hooks.
+func eventsChanged(changes []EventChange) {
+	if len(changes) == 0 {
+		return
+	}
+	eventHooksMu.Lock()
+	hooks := append([]func([]EventChange){}, eventHooks...)
+	eventHooksMu.Unlock()
+	for _, fn := range hooks {
+		fn(changes)
+	}
+}
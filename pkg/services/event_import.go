@@ -0,0 +1,301 @@
+package services
+
+import (
+	"AkuAI/models"
+	"AkuAI/pkg/eventimport"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// datasetFiles tracks the loaded datasets by file, so every copy is
+// reloaded after an import writes the file, and serialises imports.
+var datasetFiles struct {
+	sync.Mutex
+	loaded map[string][]*UIBEventService
+}
+
+func trackDataset(s *UIBEventService) {
+	datasetFiles.Lock()
+	defer datasetFiles.Unlock()
+	if datasetFiles.loaded == nil {
+		datasetFiles.loaded = map[string][]*UIBEventService{}
+	}
+	key, _ := filepath.Abs(s.path)
+	datasetFiles.loaded[key] = append(datasetFiles.loaded[key], s)
+}
+
+// ImportIssue is a validation error of an import, for the row on Line of the
+// file.
+type ImportIssue struct {
+	Line    int    `json:"row"`
+	EventID string `json:"event_id,omitempty"`
+	Field   string `json:"field,omitempty"`
+	Message string `json:"message"`
+}
+
+// ImportReport is the outcome of ImportEvents: the IDs created, updated and
+// left unchanged, or that would be on a dry run, with the field changes,
+// and the row errors. Nothing is written when there are errors.
+type ImportReport struct {
+	DryRun    bool          `json:"dry_run"`
+	Rows      int           `json:"rows"`
+	Created   []string      `json:"created"`
+	Updated   []string      `json:"updated"`
+	Unchanged []string      `json:"unchanged"`
+	Changes   []EventChange `json:"changes"`
+	Errors    []ImportIssue `json:"errors"`
+}
+
+// ErrNotImportable is returned by ImportEvents for datasets that weren't
+// loaded from a file.
+var ErrNotImportable = errors.New("dataset has no file to import into")
+
+// ImportEvents upserts rows by event ID into the dataset file and reloads
+// every loaded copy of it. Values of a row replace those of the existing
+// event; fields a row leaves empty are kept. New events need a title, a
+// type and a date. When any row is invalid, or on a dry run, the file is
+// left as is.
+func (s *UIBEventService) ImportEvents(rows []eventimport.Row, dryRun bool) (ImportReport, error) {
+	report := ImportReport{DryRun: dryRun, Rows: len(rows), Created: []string{}, Updated: []string{}, Unchanged: []string{},
+		Changes: []EventChange{}, Errors: []ImportIssue{}}
+	if s.path == "" {
+		return report, ErrNotImportable
+	}
+	datasetFiles.Lock()
+	defer datasetFiles.Unlock()
+
+	raw, err := os.ReadFile(s.path)
+	if err != nil {
+		return report, fmt.Errorf("error reading events file: %w", err)
+	}
+	var data models.UIBEventsData
+	if err := json.Unmarshal(raw, &data); err != nil {
+		return report, fmt.Errorf("error parsing events JSON: %w", err)
+	}
+	type at struct {
+		list *[]models.UIBEvent
+		i    int
+	}
+	existing := map[string]at{}
+	for _, list := range data.Lists() {
+		for i, ev := range *list {
+			existing[ev.ID] = at{list, i}
+		}
+	}
+	mark := ""
+	if all := s.GetAllEvents(); len(all) > 0 {
+		mark = all[0].Mark
+	}
+
+	type upsert struct {
+		ev  models.UIBEvent
+		old *at
+	}
+	var upserts []upsert
+	lineOf := map[string]int{}
+	for _, row := range rows {
+		id := row.Fields["id"]
+		issue := func(field, msg string) {
+			report.Errors = append(report.Errors, ImportIssue{Line: row.Line, EventID: id, Field: field, Message: msg})
+		}
+		if id == "" {
+			issue("id", "id is required")
+			continue
+		}
+		if prev, dup := lineOf[id]; dup {
+			issue("id", fmt.Sprintf("duplicate of row %d", prev))
+			continue
+		}
+		lineOf[id] = row.Line
+
+		u := upsert{ev: models.UIBEvent{ID: id, Institution: data.Metadata.Institution, Mark: mark}}
+		if loc, ok := existing[id]; ok {
+			u.ev, u.old = (*loc.list)[loc.i], &loc
+		}
+		invalid := map[string]bool{}
+		for _, field := range eventimport.Fields {
+			if v, ok := row.Fields[field]; ok {
+				if err := setEventField(&u.ev, field, v); err != nil {
+					issue(field, err.Error())
+					invalid[field] = true
+				}
+			}
+		}
+		for _, req := range [][2]string{{"title", u.ev.Title}, {"type", u.ev.Type}, {"date", u.ev.Date}} {
+			if field, v := req[0], req[1]; v == "" && !invalid[field] {
+				issue(field, field+" is required")
+				invalid[field] = true
+			}
+		}
+		if len(invalid) == 0 {
+			upserts = append(upserts, u)
+		}
+	}
+	for _, u := range upserts {
+		change := EventChange{Campus: s.Institution(), EventID: u.ev.ID, Action: "create", date: u.ev.Date}
+		if u.old != nil {
+			change.Action = "update"
+			change.Fields = DiffEvents((*u.old.list)[u.old.i], u.ev)
+		} else {
+			change.Fields = DiffEvents(models.UIBEvent{}, u.ev)
+		}
+		switch {
+		case u.old == nil:
+			report.Created = append(report.Created, u.ev.ID)
+		case len(change.Fields) > 0:
+			report.Updated = append(report.Updated, u.ev.ID)
+		default:
+			report.Unchanged = append(report.Unchanged, u.ev.ID)
+			continue
+		}
+		report.Changes = append(report.Changes, change)
+	}
+	if len(report.Errors) > 0 || dryRun || len(report.Changes) == 0 {
+		return report, nil
+	}
+
+	// Updates in place first, so indexes into the lists stay valid, then
+	// moves to another month and new events.
+	var moved []models.UIBEvent
+	for _, u := range upserts {
+		if u.old != nil {
+			(*u.old.list)[u.old.i] = u.ev
+		}
+	}
+	for _, list := range data.Lists() {
+		kept := (*list)[:0]
+		for _, ev := range *list {
+			if data.ListFor(ev.Date) == list {
+				kept = append(kept, ev)
+			} else {
+				moved = append(moved, ev)
+			}
+		}
+		*list = kept
+	}
+	for _, u := range upserts {
+		if u.old == nil {
+			moved = append(moved, u.ev)
+		}
+	}
+	for _, ev := range moved {
+		list := data.ListFor(ev.Date)
+		*list = append(*list, ev)
+	}
+	total := 0
+	for _, list := range data.Lists() {
+		total += len(*list)
+	}
+	data.Metadata.TotalEvents = total
+	data.Metadata.LastUpdated = time.Now().In(EventLocation()).Format("2006-01-02")
+
+	if err := writeDataset(s.path, raw, &data); err != nil {
+		return report, err
+	}
+	reloadDataset(s.path)
+	eventsChanged(report.Changes)
+	return report, nil
+}
+
+// setEventField sets field of ev from an imported value.
+func setEventField(ev *models.UIBEvent, field, v string) error {
+	switch field {
+	case "id":
+		// matched, not set
+	case "type":
+		t, ok := models.NormalizeEventType(v)
+		if !ok {
+			return fmt.Errorf("unknown type %q", v)
+		}
+		ev.Type = t
+	case "title":
+		ev.Title = v
+	case "date":
+		if _, err := time.Parse("2006-01-02", v); err != nil {
+			return fmt.Errorf("date %q is not YYYY-MM-DD or DD/MM/YYYY", v)
+		}
+		ev.Date = v
+	case "time":
+		ev.Time = v
+	case "location":
+		ev.Location = v
+	case "platform":
+		ev.Platform = v
+	case "department":
+		ev.Department = v
+	case "faculty":
+		ev.Faculty = v
+	case "description":
+		ev.Description = v
+	case "speaker":
+		ev.Speaker = v
+	case "requirements":
+		ev.Requirements = v
+	case "registration_fee":
+		if _, ok := ParseFee(v); !ok {
+			return fmt.Errorf("fee %q names no price (use e.g. Gratis or Rp 50.000)", v)
+		}
+		ev.RegistrationFee = v
+	case "contact":
+		ev.Contact = v
+	case "registration_link":
+		ev.RegistrationLink = v
+	case "registration_deadline":
+		ev.RegistrationDeadline = v
+	case "certificate":
+		ev.Certificate = v
+	case "capacity":
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			return fmt.Errorf("capacity %q is not a whole number", v)
+		}
+		ev.Capacity = n
+	case "mark":
+		ev.Mark = v
+	default:
+		return fmt.Errorf("unknown field %q", field)
+	}
+	return nil
+}
+
+// writeDataset replaces the file at path with data, keeping the line
+// endings of its previous contents raw.
+func writeDataset(path string, raw []byte, data *models.UIBEventsData) error {
+	out, err := json.MarshalIndent(data, "", "  ")
+	if err != nil {
+		return err
+	}
+	if bytes.Contains(raw, []byte("\r\n")) {
+		out = bytes.ReplaceAll(out, []byte("\n"), []byte("\r\n"))
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, out, 0644); err != nil {
+		return fmt.Errorf("error writing events file: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("error writing events file: %w", err)
+	}
+	return nil
+}
+
+// reloadDataset reloads every loaded copy of the dataset at path.
+func reloadDataset(path string) {
+	key, _ := filepath.Abs(path)
+	for _, s := range datasetFiles.loaded[key] {
+		fresh := &UIBEventService{}
+		if err := fresh.loadEventsData(s.path); err != nil {
+			log.Printf("[uib-service] ⚠️ reload of %s failed: %v", path, err)
+			continue
+		}
+		s.eventsData, s.hash = fresh.eventsData, fresh.hash
+	}
+}
@@ -0,0 +1,103 @@
+package services
+
+import (
+	"AkuAI/pkg/eventimport"
+	"bytes"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestImportEvents(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "campus.json")
+	seed := "{\r\n  \"uib_events\": {\r\n    \"november_2025\": [\r\n" +
+		"      {\"id\": \"a\", \"type\": \"webinar\", \"title\": \"Webinar A\", \"date\": \"2025-11-03\", \"institution\": \"Kampus\", \"mark\": \"OFFICIAL\"}\r\n" +
+		"    ]\r\n  },\r\n  \"metadata\": {\"institution\": \"Kampus\", \"total_events\": 1}\r\n}\r\n"
+	if err := os.WriteFile(path, []byte(seed), 0644); err != nil {
+		t.Fatal(err)
+	}
+	s, err := newEventDataset(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, _ := newEventDataset(path)
+	row := func(line int, kv ...string) eventimport.Row {
+		r := eventimport.Row{Line: line, Fields: map[string]string{}}
+		for i := 0; i < len(kv); i += 2 {
+			r.Fields[kv[i]] = kv[i+1]
+		}
+		return r
+	}
+
+	bad := []eventimport.Row{
+		row(2, "id", "b", "title", "Lomba B", "type", "olahraga", "date", "2025-12-01"),
+		row(3, "id", "c", "title", "Tanpa tanggal", "type", "seminar"),
+		row(4, "id", "a", "capacity", "banyak"),
+		row(5, "id", "b", "title", "Lagi"),
+	}
+	report, err := s.ImportEvents(bad, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Errors) != 4 || report.Errors[0].Field != "type" || report.Errors[3].Message != "duplicate of row 2" {
+		t.Fatalf("errors = %+v", report.Errors)
+	}
+	if after, _ := os.ReadFile(path); string(after) != seed {
+		t.Fatal("invalid import wrote the file")
+	}
+
+	good := []eventimport.Row{
+		row(2, "id", "a", "date", "2025-12-05", "capacity", "30"),
+		row(3, "id", "b", "title", "Lomba Desain", "type", "lomba", "date", "2026-02-01", "registration_fee", "Rp 50.000"),
+	}
+	report, err = s.ImportEvents(good, true)
+	if err != nil || len(report.Errors) != 0 || len(report.Created) != 1 || len(report.Updated) != 1 {
+		t.Fatalf("dry run = %+v, %v", report, err)
+	}
+	if len(s.GetAllEvents()) != 1 {
+		t.Fatal("dry run changed the dataset")
+	}
+
+	var notified []EventChange
+	OnEventsChanged(func(changes []EventChange) { notified = changes })
+	report, err = s.ImportEvents(good, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(notified) != 2 {
+		t.Fatalf("notified %+v", notified)
+	}
+	want := []string{"event:a", "month:2025-12", "month:12", "month:2025-11", "month:11", "events", "event:b", "month:2026-02", "month:02"}
+	if tags := EventChangeTags(notified); !slices.Equal(tags, want) {
+		t.Errorf("change tags = %v, want %v", tags, want)
+	}
+	if ch := report.Changes[0]; ch.EventID != "a" || ch.Action != "update" || len(ch.Fields) != 2 ||
+		ch.Fields[0].Field != "capacity" || ch.Fields[1].Before != "2025-11-03" || ch.Fields[1].After != "2025-12-05" {
+		t.Errorf("changes of a = %+v", ch)
+	}
+	report, err = s.ImportEvents(good[:1], false)
+	if err != nil || len(report.Unchanged) != 1 || len(report.Changes) != 0 {
+		t.Errorf("reimport = %+v, %v", report, err)
+	}
+	for _, ds := range []*UIBEventService{s, other} {
+		if n := len(ds.GetEventsByMonth("november")); n != 0 {
+			t.Errorf("november still has %d events", n)
+		}
+		a, err := ds.GetEventByID("a")
+		if err != nil || a.Date != "2025-12-05" || a.Capacity != 30 || a.Title != "Webinar A" {
+			t.Errorf("updated a = %+v, %v", a, err)
+		}
+		b, err := ds.GetEventByID("b")
+		if err != nil || b.Type != "competition" || b.Mark != "OFFICIAL" || b.Institution != "Kampus" || b.Fee == nil || b.Fee.Min != 50000 {
+			t.Errorf("created b = %+v, %v", b, err)
+		}
+	}
+	written, _ := os.ReadFile(path)
+	if !bytes.Contains(written, []byte("\"other_events\": [\r\n")) || !bytes.Contains(written, []byte("\"total_events\": 2")) {
+		t.Errorf("written file:\n%s", written)
+	}
+	if bytes.Contains(written, []byte("starts_at")) {
+		t.Error("parsed fields were written back")
+	}
+}
@@ -0,0 +1,143 @@
+package services
+
+import (
+	"AkuAI/models"
+	"AkuAI/pkg/config"
+	"log"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+var eventLoc struct {
+	once sync.Once
+	loc  *time.Location
+}
+
+// EventLocation is the zone of event times that don't name one:
+// EVENT_TIMEZONE, Asia/Jakarta by default. Without a zone database it
+// falls back to the fixed WIB offset, which is exact as WIB has no DST.
+func EventLocation() *time.Location {
+	eventLoc.once.Do(func() {
+		loc, err := time.LoadLocation(config.EventTimezone)
+		if err != nil {
+			log.Printf("[uib-service] ⚠️ time zone %q unavailable, using WIB (UTC+7): %v", config.EventTimezone, err)
+			loc = time.FixedZone("WIB", 7*60*60)
+		}
+		eventLoc.loc = loc
+	})
+	return eventLoc.loc
+}
+
+// indonesianZones are the zone names event times are written with.
+var indonesianZones = map[string]*time.Location{
+	"wib":  time.FixedZone("WIB", 7*60*60),
+	"wita": time.FixedZone("WITA", 8*60*60),
+	"wit":  time.FixedZone("WIT", 9*60*60),
+}
+
+// eventClockRe matches "09:00", "09.00-16.00", "13:00 - selesai" and
+// "19.00 s.d. 21.00".
+var eventClockRe = regexp.MustCompile(`(\d{1,2})[:.](\d{2})(?:\s*(?:-|–|s\.?\s?d\.?|sampai)\s*(?:(\d{1,2})[:.](\d{2}))?)?`)
+
+// ParseEventTimes parses an event's date ("2025-11-12") and free-text time
+// ("09:00-16:00", "19.00 WITA") into its start and end, in the zone the
+// time names or EventLocation. end is zero when the time names no end, and
+// allDay is true when there is no time at all. ok is false when the date
+// doesn't parse.
+func ParseEventTimes(date, clock string) (start, end time.Time, allDay, ok bool) {
+	loc := EventLocation()
+	for _, w := range strings.FieldsFunc(strings.ToLower(clock), func(r rune) bool { return !('a' <= r && r <= 'z') }) {
+		if z, found := indonesianZones[w]; found {
+			loc = z
+			break
+		}
+	}
+	day, err := time.ParseInLocation("2006-01-02", date, loc)
+	if err != nil {
+		return time.Time{}, time.Time{}, false, false
+	}
+	m := eventClockRe.FindStringSubmatch(clock)
+	if m == nil {
+		return day, time.Time{}, true, true
+	}
+	at := func(h, min string) (time.Time, bool) {
+		hh, _ := strconv.Atoi(h)
+		mm, _ := strconv.Atoi(min)
+		if hh > 24 || mm > 59 {
+			return time.Time{}, false
+		}
+		return day.Add(time.Duration(hh)*time.Hour + time.Duration(mm)*time.Minute), true
+	}
+	start, ok = at(m[1], m[2])
+	if !ok {
+		return day, time.Time{}, true, true
+	}
+	if m[3] != "" {
+		if end, ok = at(m[3], m[4]); ok && end.Before(start) {
+			end = end.AddDate(0, 0, 1) // "20:00-01:00" ends the next day
+		} else if !ok {
+			end = time.Time{}
+		}
+	}
+	return start, end, false, true
+}
+
+// eventTimes is ParseEventTimes of ev, from the fields set when the data was
+// loaded when there are.
+func eventTimes(ev models.UIBEvent) (start, end time.Time, allDay, ok bool) {
+	if ev.StartsAt != nil {
+		if ev.EndsAt != nil {
+			end = *ev.EndsAt
+		}
+		return *ev.StartsAt, end, ev.AllDay, true
+	}
+	return ParseEventTimes(ev.Date, ev.Time)
+}
+
+// EventEnd is when ev is over: its end, or the end of its day when the time
+// names none. ok is false when its date doesn't parse.
+func EventEnd(ev models.UIBEvent) (time.Time, bool) {
+	start, end, _, ok := eventTimes(ev)
+	if !ok {
+		return time.Time{}, false
+	}
+	if end.IsZero() {
+		y, m, d := start.Date()
+		end = time.Date(y, m, d+1, 0, 0, 0, 0, start.Location())
+	}
+	return end, true
+}
+
+// EventDay is the day of ev at midnight in EventLocation, for comparing
+// with days such as "today".
+func EventDay(ev models.UIBEvent) (time.Time, bool) {
+	start, _, _, ok := eventTimes(ev)
+	if !ok {
+		return time.Time{}, false
+	}
+	return StartOfEventDay(start), true
+}
+
+// StartOfEventDay is midnight of the day t falls on in EventLocation.
+func StartOfEventDay(t time.Time) time.Time {
+	y, m, d := t.In(EventLocation()).Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, EventLocation())
+}
+
+// parseEventTimes sets StartsAt, EndsAt and AllDay of every event with a
+// date that parses.
+func parseEventTimes(events []models.UIBEvent) {
+	for i := range events {
+		start, end, allDay, ok := ParseEventTimes(events[i].Date, events[i].Time)
+		if !ok {
+			continue
+		}
+		events[i].StartsAt, events[i].AllDay = &start, allDay
+		if !end.IsZero() {
+			events[i].EndsAt = &end
+		}
+	}
+}
@@ -0,0 +1,56 @@
+package services
+
+import (
+	"AkuAI/models"
+	"testing"
+	"time"
+)
+
+func TestParseEventTimes(t *testing.T) {
+	start, end, allDay, ok := ParseEventTimes("2025-11-12", "09:00-16:00")
+	if !ok || allDay || start.Format(time.RFC3339) != "2025-11-12T09:00:00+07:00" || end.Format(time.RFC3339) != "2025-11-12T16:00:00+07:00" {
+		t.Fatalf("09:00-16:00: %v %v %v %v", start, end, allDay, ok)
+	}
+	if start, _, _, _ := ParseEventTimes("2025-11-12", "19.00 WITA"); start.Format(time.RFC3339) != "2025-11-12T19:00:00+08:00" {
+		t.Errorf("WITA: %v", start)
+	}
+	if _, end, _, _ := ParseEventTimes("2025-11-12", "20:00-01:00"); end.Format(time.RFC3339) != "2025-11-13T01:00:00+07:00" {
+		t.Errorf("past midnight: %v", end)
+	}
+	if _, end, _, _ := ParseEventTimes("2025-11-12", "13:00 - selesai"); !end.IsZero() {
+		t.Errorf("open end: %v", end)
+	}
+	if start, _, allDay, ok := ParseEventTimes("2025-11-12", ""); !ok || !allDay || start.Hour() != 0 {
+		t.Errorf("no time: %v %v %v", start, allDay, ok)
+	}
+	if _, _, _, ok := ParseEventTimes("12 November", "09:00"); ok {
+		t.Error("bad date parsed")
+	}
+}
+
+func TestUpcomingUsesEventZone(t *testing.T) {
+	now := time.Now().In(EventLocation())
+	today := now.Format("2006-01-02")
+	earlier := now.Add(-2 * time.Hour)
+	data := &models.UIBEventsData{}
+	data.UIBEvents.November2025 = []models.UIBEvent{
+		// ended two hours ago; shortly after midnight the range wraps to
+		// the next day, so it is only checked later in the day
+		{ID: "over", Date: today, Time: earlier.Add(-time.Hour).Format("15:04") + "-" + earlier.Format("15:04")},
+		{ID: "later", Date: today, Time: "23:59"},
+		{ID: "allday", Date: today},
+	}
+	parseEventTimes(data.UIBEvents.November2025)
+	s := &UIBEventService{eventsData: data}
+
+	got := map[string]bool{}
+	for _, ev := range s.GetUpcomingEvents() {
+		got[ev.ID] = true
+	}
+	if !got["later"] || !got["allday"] {
+		t.Errorf("upcoming = %v", got)
+	}
+	if got["over"] && earlier.Day() == now.Day() && earlier.Hour() >= 1 {
+		t.Errorf("an event that ended at %s is upcoming", earlier.Format("15:04"))
+	}
+}
@@ -0,0 +1,74 @@
+package services
+
+import (
+	"AkuAI/models"
+	"slices"
+	"strings"
+)
+
+// eventTypeKeywords are the words a query names each event type by.
+var eventTypeKeywords = map[string][]string{
+	models.EventTypeCertification: {"sertifikasi", "certification", "certificate", "pelatihan"},
+	models.EventTypeWebinar:       {"webinar"},
+	models.EventTypeSeminar:       {"seminar", "talkshow"},
+	models.EventTypeWorkshop:      {"workshop", "lokakarya"},
+	models.EventTypeBootcamp:      {"bootcamp"},
+	models.EventTypeCompetition:   {"lomba", "kompetisi", "competition", "hackathon"},
+	models.EventTypePublicLecture: {"kuliah umum", "kuliah tamu", "public lecture", "guest lecture", "stadium generale"},
+}
+
+// relatedEventTypes are the types also held under another type's name: a
+// seminar is often run online as a webinar, a kuliah umum as either, and
+// workshops and bootcamps usually end in a certification.
+var relatedEventTypes = map[string][]string{
+	models.EventTypeSeminar:       {models.EventTypeWebinar},
+	models.EventTypePublicLecture: {models.EventTypeSeminar, models.EventTypeWebinar},
+	models.EventTypeWorkshop:      {models.EventTypeCertification},
+	models.EventTypeBootcamp:      {models.EventTypeCertification},
+}
+
+var eventTypeLabels = map[string]string{
+	models.EventTypeCertification: "sertifikasi",
+	models.EventTypeCompetition:   "lomba",
+	models.EventTypePublicLecture: "kuliah umum",
+}
+
+// EventTypeLabel is the Indonesian name of an event type ("sertifikasi",
+// "lomba", "kuliah umum", ...).
+func EventTypeLabel(t string) string {
+	if l, ok := eventTypeLabels[t]; ok {
+		return l
+	}
+	return t
+}
+
+// detectEventTypes returns the event types queryLower names, in
+// models.EventTypes order.
+func detectEventTypes(queryLower string) []string {
+	var types []string
+	for _, t := range models.EventTypes {
+		for _, kw := range eventTypeKeywords[t] {
+			if strings.Contains(queryLower, kw) {
+				types = append(types, t)
+				break
+			}
+		}
+	}
+	return types
+}
+
+// eventTypeWanted reports whether an event of type t answers a query naming
+// types: one of them or a type related to one, or any type when types is
+// empty.
+func eventTypeWanted(types []string, t string) bool {
+	if len(types) == 0 {
+		return true
+	}
+	t = strings.ToLower(t)
+	for _, want := range types {
+		if want == t || slices.Contains(relatedEventTypes[want], t) {
+			return true
+		}
+	}
+	return false
+}
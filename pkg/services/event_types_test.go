@@ -0,0 +1,57 @@
+package services
+
+import (
+	"AkuAI/models"
+	"slices"
+	"testing"
+)
+
+func TestDetectEventTypes(t *testing.T) {
+	cases := []struct {
+		q    string
+		want []string
+	}{
+		{"ada lomba apa bulan ini?", []string{models.EventTypeCompetition}},
+		{"jadwal kuliah umum desember", []string{models.EventTypePublicLecture}},
+		{"workshop dan bootcamp", []string{models.EventTypeWorkshop, models.EventTypeBootcamp}},
+		{"webinar dan sertifikasi november", []string{models.EventTypeCertification, models.EventTypeWebinar}},
+		{"seminar nasional", []string{models.EventTypeSeminar}},
+		{"jam buka perpustakaan", nil},
+	}
+	for _, c := range cases {
+		if got := detectEventTypes(c.q); !slices.Equal(got, c.want) {
+			t.Errorf("detectEventTypes(%q) = %v, want %v", c.q, got, c.want)
+		}
+	}
+
+	if !eventTypeWanted([]string{models.EventTypeSeminar}, "Webinar") {
+		t.Error("a seminar question should keep webinars")
+	}
+	if eventTypeWanted([]string{models.EventTypeWebinar}, models.EventTypeSeminar) {
+		t.Error("a webinar question kept a seminar")
+	}
+	if !eventTypeWanted(nil, models.EventTypeCompetition) {
+		t.Error("no type named should keep every event")
+	}
+
+	if got := EventTopic("ada hackathon?"); got != models.EventTypeCompetition {
+		t.Errorf("EventTopic = %q", got)
+	}
+	if got := EventTopic("webinar atau workshop?"); got != "event" {
+		t.Errorf("EventTopic of two types = %q", got)
+	}
+}
+
+func TestNormalizeEventType(t *testing.T) {
+	for in, want := range map[string]string{
+		"webinar": models.EventTypeWebinar, "Sertifikasi": models.EventTypeCertification,
+		"lomba": models.EventTypeCompetition, "kuliah_umum": models.EventTypePublicLecture,
+	} {
+		if got, ok := models.NormalizeEventType(in); !ok || got != want {
+			t.Errorf("NormalizeEventType(%q) = %q, %v", in, got, ok)
+		}
+	}
+	if _, ok := models.NormalizeEventType("konser"); ok {
+		t.Error("konser accepted")
+	}
+}
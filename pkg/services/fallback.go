@@ -0,0 +1,73 @@
+package services
+
+import (
+	"AkuAI/pkg/config"
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+)
+
+// retryPause is the wait before retrying a model that was rate limited or
+// unavailable.
+const retryPause = 2 * time.Second
+
+// attemptAllowed reports whether another model call fits the request, and
+// why not. The first call always goes out. Retries and fallback models each
+// spend one of the GEMINI_RETRY_BUDGET calls the request may add, across
+// every service method it goes through, and are skipped when less than
+// GEMINI_MIN_ATTEMPT_SECONDS would be left before ctx's deadline after
+// waiting wait.
+func attemptAllowed(ctx context.Context, wait time.Duration) (bool, string) {
+	info := generationInfo(ctx)
+	if info.Attempts() > 0 {
+		if dl, ok := ctx.Deadline(); ok {
+			if left := time.Until(dl) - wait; left < time.Duration(config.GeminiMinAttemptSeconds)*time.Second {
+				return false, fmt.Sprintf("skipped with %s left before the deadline", left.Round(time.Second))
+			}
+		}
+	}
+	if !info.takeAttempt(config.GeminiRetryBudget) {
+		return false, "skipped, retry budget spent"
+	}
+	return true, ""
+}
+
+// tryModels asks each generation model in turn until one answers, retrying
+// a model once after retryPause when it was rate limited or unavailable.
+// kind ("" or "stream ") labels logs and the error, which lists what
+// happened to every model.
+func tryModels(ctx context.Context, kind string, call func(model string) (string, error)) (string, error) {
+	if generationInfo(ctx) == nil {
+		ctx, _ = WithGenerationInfo(ctx)
+	}
+	var failures []string
+	for _, m := range generationModels(ctx) {
+		if strings.TrimSpace(m) == "" {
+			continue
+		}
+		for try := 0; try < 2; try++ {
+			wait := time.Duration(try) * retryPause
+			if ok, why := attemptAllowed(ctx, wait); !ok {
+				log.Printf("[gemini] %smodel %s %s", kind, m, why)
+				failures = append(failures, m+" -> "+why)
+				break
+			}
+			sleepWithContext(ctx, wait)
+			text, err := call(m)
+			if err == nil {
+				if strings.TrimSpace(text) != "" {
+					return strings.TrimSpace(text), nil
+				}
+				break
+			}
+			log.Printf("[gemini] %smodel %s failed: %v", kind, m, err)
+			failures = append(failures, fmt.Sprintf("%s -> %v", m, err))
+			if !isRetriable(err) {
+				break
+			}
+		}
+	}
+	return "", fmt.Errorf("all gemini %smodels failed: %s", kind, strings.Join(failures, "; "))
+}
@@ -0,0 +1,59 @@
+package services
+
+import (
+	"AkuAI/pkg/config"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTryModelsBudgetAndDeadline(t *testing.T) {
+	prevModel, prevBudget, prevMin := config.GeminiModel, config.GeminiRetryBudget, config.GeminiMinAttemptSeconds
+	t.Cleanup(func() {
+		config.GeminiModel, config.GeminiRetryBudget, config.GeminiMinAttemptSeconds = prevModel, prevBudget, prevMin
+	})
+	config.GeminiModel, config.GeminiMinAttemptSeconds = "gemini-2.5-pro", 10
+
+	var called []string
+	failFirst := func(m string) (string, error) {
+		called = append(called, m)
+		if m == "gemini-2.5-pro" {
+			return "", errors.New("status 500: internal")
+		}
+		return " jawaban ", nil
+	}
+
+	// The fallback answers within the budget.
+	config.GeminiRetryBudget = 2
+	ctx, info := WithGenerationInfo(context.Background())
+	got, err := tryModels(ctx, "", failFirst)
+	if err != nil || got != "jawaban" || info.Attempts() != 2 {
+		t.Fatalf("fallback: %q, %v, attempts %d", got, err, info.Attempts())
+	}
+
+	// The budget is shared by every call of the request.
+	config.GeminiRetryBudget = 1
+	ctx, _ = WithGenerationInfo(context.Background())
+	called = nil
+	if _, err := tryModels(ctx, "", failFirst); err != nil {
+		t.Fatal(err)
+	}
+	_, err = tryModels(ctx, "", failFirst)
+	if err == nil || !strings.Contains(err.Error(), "retry budget spent") || len(called) != 2 {
+		t.Fatalf("second call: %v, called %v", err, called)
+	}
+
+	// Fallbacks are skipped when the deadline is too close; the first call
+	// still goes out.
+	config.GeminiRetryBudget = 2
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+	ctx, info = WithGenerationInfo(ctx)
+	called = nil
+	_, err = tryModels(ctx, "", failFirst)
+	if err == nil || !strings.Contains(err.Error(), "before the deadline") || len(called) != 1 || info.Attempts() != 1 {
+		t.Fatalf("near deadline: %v, called %v", err, called)
+	}
+}
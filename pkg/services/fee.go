@@ -0,0 +1,105 @@
+package services
+
+import (
+	"AkuAI/models"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// feeAmountRe matches an amount of a fee text: "Rp 500.000", "Rp500,000",
+// "IDR 750000", "1,2 juta", "500rb", "150k".
+var feeAmountRe = regexp.MustCompile(`(rp\.?|idr)?\s*(\d{1,3}(?:[.,]\d{3})+|\d+(?:[.,]\d+)?)\s*(juta|jt|ribu|rb|k)?\b`)
+
+var feeMultipliers = map[string]float64{"juta": 1e6, "jt": 1e6, "ribu": 1e3, "rb": 1e3, "k": 1e3}
+
+// ParseFee parses a free-text registration fee ("Rp 500.000", "Gratis",
+// "Gratis untuk mahasiswa, Rp 50.000 untuk umum", "Rp 100rb - 250rb").
+// Numbers below 10000 without a currency or multiplier are not amounts, so
+// "2 sesi", "kuota 50" or "2025" don't count. ok is false when fee names no
+// price.
+func ParseFee(fee string) (models.Fee, bool) {
+	lower := strings.ToLower(fee)
+	free := strings.Contains(lower, "gratis") || strings.Contains(lower, "free")
+
+	var amounts []int64
+	for _, m := range feeAmountRe.FindAllStringSubmatch(lower, -1) {
+		n, ok := feeNumber(m[2])
+		if !ok {
+			continue
+		}
+		if mult, has := feeMultipliers[m[3]]; has {
+			n *= mult
+		} else if m[1] == "" && n < 10000 && n != 0 {
+			continue
+		}
+		amounts = append(amounts, int64(n))
+	}
+
+	if len(amounts) == 0 {
+		if free {
+			return models.Fee{Free: true}, true
+		}
+		return models.Fee{}, false
+	}
+	f := models.Fee{Min: amounts[0], Max: amounts[0]}
+	for _, a := range amounts[1:] {
+		f.Min, f.Max = min(f.Min, a), max(f.Max, a)
+	}
+	if free {
+		f.Min = 0
+	}
+	f.Free = f.Max == 0
+	return f, true
+}
+
+// feeNumber parses "500.000" and "500,000" as thousands and "1,2" or "1.5"
+// as decimals.
+func feeNumber(s string) (float64, bool) {
+	if len(s) > 4 && (s[len(s)-4] == '.' || s[len(s)-4] == ',') {
+		s = strings.NewReplacer(".", "", ",", "").Replace(s)
+	} else {
+		s = strings.Replace(s, ",", ".", 1)
+	}
+	n, err := strconv.ParseFloat(s, 64)
+	return n, err == nil
+}
+
+// eventFee is the parsed fee of ev: its Fee, else parsed from
+// RegistrationFee for events that didn't come from loadEventsData. An
+// event without a fee text is free.
+func eventFee(ev models.UIBEvent) (models.Fee, bool) {
+	if ev.Fee != nil {
+		return *ev.Fee, true
+	}
+	if strings.TrimSpace(ev.RegistrationFee) == "" {
+		return models.Fee{Free: true}, true
+	}
+	return ParseFee(ev.RegistrationFee)
+}
+
+// inFeeRange reports whether the fee range of ev overlaps [minFee, maxFee],
+// a bound of 0 being open. Events whose fee doesn't parse match only when
+// there are no bounds.
+func inFeeRange(ev models.UIBEvent, minFee, maxFee int64) bool {
+	if minFee == 0 && maxFee == 0 {
+		return true
+	}
+	f, ok := eventFee(ev)
+	if !ok {
+		return false
+	}
+	return (minFee == 0 || f.Max >= minFee) && (maxFee == 0 || f.Min <= maxFee)
+}
+
+// parseEventFees sets the Fee of every event with a fee text that parses.
+func parseEventFees(events []models.UIBEvent) {
+	for i := range events {
+		if strings.TrimSpace(events[i].RegistrationFee) == "" {
+			continue
+		}
+		if f, ok := ParseFee(events[i].RegistrationFee); ok {
+			events[i].Fee = &f
+		}
+	}
+}
@@ -0,0 +1,65 @@
+package services
+
+import (
+	"AkuAI/models"
+	"testing"
+)
+
+func TestParseFee(t *testing.T) {
+	cases := []struct {
+		in   string
+		want models.Fee
+		ok   bool
+	}{
+		{"Rp 500.000", models.Fee{Min: 500000, Max: 500000}, true},
+		{"Rp1,200,000", models.Fee{Min: 1200000, Max: 1200000}, true},
+		{"IDR 750000", models.Fee{Min: 750000, Max: 750000}, true},
+		{"Rp 1,2 juta", models.Fee{Min: 1200000, Max: 1200000}, true},
+		{"100rb - 250rb", models.Fee{Min: 100000, Max: 250000}, true},
+		{"Gratis", models.Fee{Free: true}, true},
+		{"Gratis untuk mahasiswa, Rp 50.000 untuk umum", models.Fee{Min: 0, Max: 50000}, true},
+		{"Rp 300.000 (early bird s.d. 10 Oktober 2025)", models.Fee{Min: 300000, Max: 300000}, true},
+		{"Hubungi panitia", models.Fee{}, false},
+	}
+	for _, c := range cases {
+		got, ok := ParseFee(c.in)
+		if ok != c.ok || got != c.want {
+			t.Errorf("ParseFee(%q) = %+v, %v; want %+v, %v", c.in, got, ok, c.want, c.ok)
+		}
+	}
+
+	if s := (models.Fee{Min: 0, Max: 50000}).String(); s != "Gratis - Rp 50.000" {
+		t.Errorf("String = %q", s)
+	}
+	if s := (models.Fee{Min: 1200000, Max: 1200000}).String(); s != "Rp 1.200.000" {
+		t.Errorf("String = %q", s)
+	}
+}
+
+func TestSearchEventsFeeRange(t *testing.T) {
+	data := &models.UIBEventsData{}
+	data.UIBEvents.November2025 = []models.UIBEvent{
+		{ID: "a", Date: "2025-11-03", RegistrationFee: "Rp 300.000"},
+		{ID: "b", Date: "2025-11-10", RegistrationFee: "Gratis"},
+		{ID: "c", Date: "2025-11-12", RegistrationFee: "Rp 900.000"},
+		{ID: "d", Date: "2025-11-20", RegistrationFee: "Hubungi panitia"},
+	}
+	parseEventFees(data.UIBEvents.November2025)
+	s := &UIBEventService{eventsData: data}
+
+	ids := func(c models.EventSearchCriteria) (out []string) {
+		for _, ev := range s.SearchEvents(c) {
+			out = append(out, ev.ID)
+		}
+		return out
+	}
+	if got := ids(models.EventSearchCriteria{MaxFee: 500000}); len(got) != 2 || got[0] != "a" || got[1] != "b" {
+		t.Errorf("max_fee 500000: %v", got)
+	}
+	if got := ids(models.EventSearchCriteria{MinFee: 500000}); len(got) != 1 || got[0] != "c" {
+		t.Errorf("min_fee 500000: %v", got)
+	}
+	if got := ids(models.EventSearchCriteria{FreeOnly: true}); len(got) != 1 || got[0] != "b" {
+		t.Errorf("free: %v", got)
+	}
+}
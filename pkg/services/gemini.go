@@ -14,8 +14,10 @@ import (
 	"regexp"
 	"strings"
 	"time"
+	"unicode/utf8"
 
 	"AkuAI/pkg/config"
+	"AkuAI/pkg/tenant"
 	"crypto/sha256"
 	"encoding/hex"
 	"path/filepath"
@@ -24,9 +26,11 @@ import (
 // imports for prompt logging
 
 type GeminiService struct {
-	apiKey     string
-	enabled    bool
-	uibService *UIBEventService
+	apiKey    string
+	enabled   bool
+	campuses  *CampusDataService
+	mock      *MockLLM // set when MOCK_LLM_FIXTURES is configured
+	documents bool     // retrieve uploaded documents into the prompt
 }
 
 var universityAliasMap = map[string]string{
@@ -50,32 +54,69 @@ var (
 )
 
 func NewGeminiService() *GeminiService {
-	uibService, err := NewUIBEventService()
+	return NewGeminiServiceFor(context.Background())
+}
+
+// NewGeminiServiceFor is NewGeminiService for the tenant of ctx: its Gemini
+// key and event datasets where it has its own, and no uploaded documents,
+// which belong to the operator.
+func NewGeminiServiceFor(ctx context.Context) *GeminiService {
+	t := tenant.FromContext(ctx)
+	campuses, err := NewCampusDataServiceFor(t)
 	if err != nil {
 		log.Printf("[gemini] ❌ CRITICAL: Failed to initialize UIB service: %v", err)
 		log.Printf("[gemini] ❌ UIB queries will NOT work properly!")
 		// Continue without UIB service - not critical
 	} else {
-		log.Printf("[gemini] ✅ UIB service initialized successfully")
+		log.Printf("[gemini] ✅ UIB service initialized successfully (%d campuses)", len(campuses.Campuses()))
+		uibService := campuses.Default()
 		allEvents := uibService.GetAllEvents()
 		log.Printf("[gemini] ✅ UIB service loaded %d events total", len(allEvents))
 		novEvents := uibService.GetEventsByMonth("november")
 		log.Printf("[gemini] ✅ UIB service has %d November events", len(novEvents))
 	}
 
+	var mock *MockLLM
+	if config.MockLLMFixtures != "" {
+		if mock, err = NewMockLLM(config.MockLLMFixtures); err != nil {
+			log.Printf("[gemini] ❌ Failed to load mock LLM fixtures from %s: %v", config.MockLLMFixtures, err)
+		} else {
+			log.Printf("[gemini] ⚠️ MOCK LLM: answering from %d fixtures in %s", len(mock.fixtures), config.MockLLMFixtures)
+		}
+	}
+
+	apiKey := config.GeminiAPIKey
+	if k := t.GeminiAPIKey(); k != "" {
+		apiKey = k
+	}
 	return &GeminiService{
-		apiKey:     config.GeminiAPIKey,
-		enabled:    config.IsGeminiEnabled,
-		uibService: uibService,
+		apiKey:    apiKey,
+		enabled:   config.IsGeminiEnabled,
+		campuses:  campuses,
+		mock:      mock,
+		documents: t.IsOperator(),
 	}
 }
 
+// eventData returns the event dataset of the campus question is about, or
+// nil when it names a campus without loaded data.
+func (s *GeminiService) eventData(question string) *UIBEventService {
+	name, ds := s.campuses.ForQuery(question)
+	if ds != nil && !ds.IsUIB() {
+		log.Printf("[gemini] 🏫 Campus detected: %s", name)
+	}
+	return ds
+}
+
 type ChatMessage struct {
 	Role string
 	Text string
 }
 
 func (s *GeminiService) AskCampus(ctx context.Context, question string) (string, error) {
+	if text, ok, err := s.mockReply(ctx, question, nil); ok {
+		return text, err
+	}
 	// Mock logic: always mock if staging, or if production but disabled
 	if (config.IsStaging && os.Getenv("ABTEST_FORCE_REAL") != "1") || (config.IsProduction && !config.IsGeminiEnabled) {
 		log.Printf("[gemini] MOCK MODE: returning mock chat response")
@@ -89,6 +130,7 @@ func (s *GeminiService) AskCampus(ctx context.Context, question string) (string,
 		log.Printf("[gemini] GEMINI_API_KEY is not set")
 		return "", fmt.Errorf("GEMINI_API_KEY is not set")
 	}
+	ctx = s.routed(ctx, question)
 
 	// Check if question is related to UIB and add context if available
 	var prompt string
@@ -97,45 +139,28 @@ func (s *GeminiService) AskCampus(ctx context.Context, question string) (string,
 	var uibContext string
 	log.Printf("[gemini] 🔍 DEBUGGING - Question: %s", question)
 
-	if s.uibService == nil {
+	uib := s.eventData(question)
+	if uib == nil {
 		log.Printf("[gemini] ❌ UIB service is nil - UIB features disabled")
 	} else {
-		uibDetected = s.uibService.AnalyzeQueryForUIB(question)
+		uibDetected = uib.AnalyzeQueryForUIB(question)
 		log.Printf("[gemini] 🔍 UIB detection result: %t", uibDetected)
 	}
 
-	if s.uibService != nil && uibDetected {
+	if uib != nil && uibDetected {
 		log.Printf("[gemini] ✅ UIB-RELATED QUERY DETECTED! Adding UIB context")
 
-		relevantEvents := s.uibService.GetRelevantEventsForQuery(question)
+		relevantEvents := uib.GetRelevantEventsForQuery(question)
 		relevantCount = len(relevantEvents)
 		log.Printf("[gemini] Found %d relevant UIB events", relevantCount)
-		uibContext = s.uibService.FormatEventsForGemini(relevantEvents)
-
-		prompt = fmt.Sprintf(`TANGGAL HARI INI: 4 Oktober 2025
-
-Kamu adalah asisten AI untuk Universitas Internasional Batam (UIB). Jawab pertanyaan menggunakan data resmi UIB yang disediakan di bawah ini.
-
-%s
+		uibContext = uib.EventContext(question, relevantEvents)
 
-INSTRUKSI PENTING:
-1. PENTING: Hari ini adalah 4 Oktober 2025, jadi semua acara Oktober-Desember 2025 adalah SAAT INI atau AKAN DATANG
-2. LANGSUNG berikan SEMUA data yang tersedia sesuai pertanyaan - JANGAN tanya balik atau minta klarifikasi
-3. Jika ditanya tentang sertifikasi/webinar per bulan, tampilkan SEMUA yang ada di bulan tersebut
-4. SELALU gunakan data UIB yang disediakan di atas sebagai sumber utama
-5. Format jawaban dengan struktur jelas. Untuk setiap item tampilkan: Nama acara, Tanggal, Waktu, Lokasi, Biaya, Kontak. Jika tautan pendaftaran tidak tersedia, tulis: "tautan tidak tersedia dalam data".
-6. SELALU sebutkan bahwa ini adalah data resmi UIB (UIB_OFFICIAL) 
-7. Jika tidak ada data untuk bulan yang ditanyakan, baru katakan tidak tersedia
-8. JANGAN katakan "memerlukan informasi lebih lanjut" - langsung berikan semua yang ada
-9. Jika pertanyaan meminta webinar dan sertifikasi sekaligus, tampilkan KEDUANYA.
-10. Untuk frasa relatif seperti "minggu depan", artikan sebagai rentang Senin–Minggu pekan depan berdasarkan tanggal di atas.
-11. Gunakan format: "Berikut sertifikasi/webinar UIB untuk [bulan/rentang]:" lalu list semua
-
-Pertanyaan: %s`, uibContext, question)
+		prompt = eventInstruction(ctx, uib, uibContext) + "\n\nPertanyaan: " + question
 	} else {
 		log.Printf("[gemini] ❌ NON-UIB QUERY - Using default prompt")
 		prompt = fmt.Sprintf("Jawab secara terstruktur dan ringkas tentang informasi kampus. Gunakan Bahasa Indonesia yang jelas dengan poin-poin. Hindari paragraf panjang yang generik. Sertakan langkah/tautan jika relevan. Jika ada ketidakpastian, sebutkan asumsi singkat. Pertanyaan: %s", question)
 	}
+	recordPrompt(ctx, promptTemplateFor("askcampus", uibDetected), uibContext, uib)
 
 	// Prompt logging for reproducibility
 	runID, _ := ctx.Value("abtest_run_id").(string)
@@ -144,22 +169,20 @@ Pertanyaan: %s`, uibContext, question)
 	logFull, _ := ctx.Value("abtest_log_full").(string)
 	if strings.TrimSpace(logFile) != "" {
 		// choose template id/version based on branch
-		promptTemplateID := "askcampus_generic_v1"
-		if uibDetected {
-			promptTemplateID = "askcampus_uib_v1"
-		}
-		promptTemplateVer := "2025.10.26"
+		promptTemplateID := promptTemplateFor("askcampus", uibDetected)
+		promptTemplateVer := PromptTemplateVersion
 		entry := map[string]any{
 			"timestamp":               time.Now().Format(time.RFC3339),
 			"run_id":                  runID,
 			"mode":                    mode,
 			"function":                "AskCampus",
-			"model":                   config.GeminiModel,
-			"temperature":             0.4,
+			"model":                   generationModels(ctx)[0],
+			"temperature":             generationConfig(ctx)["temperature"],
 			"uib_detected":            uibDetected,
 			"relevant_events_count":   relevantCount,
 			"question":                question,
 			"prompt_id":               shaHex(prompt),
+			"prompt_chars":            utf8.RuneCountInString(prompt),
 			"context_hash":            shaHex(uibContext),
 			"prompt_template_id":      promptTemplateID,
 			"prompt_template_version": promptTemplateVer,
@@ -168,44 +191,19 @@ Pertanyaan: %s`, uibContext, question)
 			entry["prompt"] = prompt
 			entry["context_snapshot"] = uibContext
 		}
+		redactPromptLog(ctx, entry)
 		_ = appendPromptLog(logFile, entry)
 	}
 
-	models := []string{config.GeminiModel, "gemini-2.0-flash"}
-	tried := make(map[string]error)
-
-	for _, m := range models {
-		if strings.TrimSpace(m) == "" {
-			continue
-		}
-		text, err := s.callGenerateContent(ctx, m, prompt)
-		if err != nil && isRetriable(err) {
-			sleepWithContext(ctx, 2*time.Second)
-			text, err = s.callGenerateContent(ctx, m, prompt)
-		}
-		if err == nil && strings.TrimSpace(text) != "" {
-			return strings.TrimSpace(text), nil
-		}
-		if err != nil {
-			tried[m] = err
-			log.Printf("[gemini] model %s failed: %v", m, err)
-		}
-	}
-
-	var b strings.Builder
-	b.WriteString("all gemini models failed: ")
-	first := true
-	for m, e := range tried {
-		if !first {
-			b.WriteString("; ")
-		}
-		first = false
-		b.WriteString(fmt.Sprintf("%s -> %v", m, e))
-	}
-	return "", errors.New(b.String())
+	return tryModels(ctx, "", func(m string) (string, error) {
+		return s.callGenerateContent(ctx, m, prompt)
+	})
 }
 
 func (s *GeminiService) AskCampusWithChat(ctx context.Context, chat []ChatMessage) (string, error) {
+	if text, ok, err := s.mockReply(ctx, lastUserText(chat), nil); ok {
+		return text, err
+	}
 	if !s.enabled {
 		log.Printf("[gemini] disabled via config (IsGeminiEnabled=false)")
 		return "", ErrGeminiDisabled
@@ -214,9 +212,7 @@ func (s *GeminiService) AskCampusWithChat(ctx context.Context, chat []ChatMessag
 		log.Printf("[gemini] GEMINI_API_KEY is not set")
 		return "", fmt.Errorf("GEMINI_API_KEY is not set")
 	}
-
-	models := []string{config.GeminiModel, "gemini-2.0-flash"}
-	tried := make(map[string]error)
+	ctx = s.routed(ctx, lastUserText(chat))
 
 	// Extract the latest user question for UIB context detection
 	var latestUserQuestion string
@@ -232,37 +228,24 @@ func (s *GeminiService) AskCampusWithChat(ctx context.Context, chat []ChatMessag
 	var uibDetected bool
 	var relevantCount int
 	var uibContext string
-	if s.uibService != nil && s.uibService.AnalyzeQueryForUIB(latestUserQuestion) {
+	uib := s.eventData(latestUserQuestion)
+	if uib != nil && uib.AnalyzeQueryForUIB(latestUserQuestion) {
 		log.Printf("[gemini] ✅ UIB-RELATED CHAT QUERY DETECTED! Adding UIB context")
 		uibDetected = true
-		relevantEvents := s.uibService.GetRelevantEventsForQuery(latestUserQuestion)
+		relevantEvents := uib.GetRelevantEventsForQuery(latestUserQuestion)
 		relevantCount = len(relevantEvents)
 		log.Printf("[gemini] Found %d relevant UIB events for chat", relevantCount)
-		uibContext = s.uibService.FormatEventsForGemini(relevantEvents)
-
-		systemInstruction = fmt.Sprintf(`TANGGAL HARI INI: 4 Oktober 2025
-
-Kamu adalah asisten AI untuk Universitas Internasional Batam (UIB). Jawab pertanyaan menggunakan data resmi UIB yang disediakan di bawah ini.
-
-%s
-
-INSTRUKSI PENTING:
-1. PENTING: Hari ini adalah 4 Oktober 2025, jadi semua acara Oktober-Desember 2025 adalah SAAT INI atau AKAN DATANG
-2. LANGSUNG berikan SEMUA data yang tersedia sesuai pertanyaan - JANGAN tanya balik atau minta klarifikasi
-3. Jika ditanya tentang sertifikasi/webinar per bulan, tampilkan SEMUA yang ada di bulan tersebut
-4. SELALU gunakan data UIB yang disediakan di atas sebagai sumber utama
-5. Format jawaban dengan struktur jelas. Untuk setiap item tampilkan: Nama acara, Tanggal, Waktu, Lokasi, Biaya, Kontak. Jika tautan pendaftaran tidak tersedia, tulis: "tautan tidak tersedia dalam data".
-6. SELALU sebutkan bahwa ini adalah data resmi UIB (UIB_OFFICIAL) 
-7. Jika tidak ada data untuk bulan yang ditanyakan, baru katakan tidak tersedia
-8. JANGAN katakan "memerlukan informasi lebih lanjut" - langsung berikan semua yang ada
-9. Jika pertanyaan meminta webinar dan sertifikasi sekaligus, tampilkan KEDUANYA.
-10. Untuk frasa relatif seperti "minggu depan", artikan sebagai rentang Senin–Minggu pekan depan berdasarkan tanggal di atas.
-11. Gunakan format: "Berikut sertifikasi/webinar UIB untuk [bulan/rentang]:" lalu list semua
-10. Jawab dalam Bahasa Indonesia yang jelas dan terstruktur`, uibContext)
+		uibContext = uib.EventContext(latestUserQuestion, relevantEvents)
+
+		systemInstruction = eventInstruction(ctx, uib, uibContext, chatLanguageRule)
 	} else {
-		log.Printf("[gemini] ❌ NON-UIB CHAT QUERY - Using default system instruction")
-		systemInstruction = "Anda adalah asisten kampus yang sangat membantu. Jawab secara rinci, terstruktur (gunakan poin-poin atau langkah), dan jelas dalam Bahasa Indonesia. Jika konteks tidak cukup, minta klarifikasi singkat. Tetap fokus pada topik akademik/kampus."
+		label := s.ClassifyQuery(ctx, latestUserQuestion)
+		log.Printf("[gemini] ❌ NON-UIB CHAT QUERY - Using %s system instruction", label)
+		systemInstruction = topicSystemInstruction(label)
 	}
+	systemInstruction += s.documentContext(latestUserQuestion)
+	systemInstruction += userMemoryContext(ctx) + preferencesContext(ctx)
+	recordPrompt(ctx, promptTemplateFor("askcampus_chat", uibDetected), uibContext, uib)
 
 	payloadBuilder := func() ([]byte, error) {
 		contents := make([]any, 0, len(chat))
@@ -281,13 +264,8 @@ INSTRUKSI PENTING:
 				"parts": []any{map[string]any{"text": systemInstruction}},
 			},
 			"contents": contents,
-			"generationConfig": map[string]any{
-				"temperature":     0.4,
-				"maxOutputTokens": 2048,
-				"topK":            40,
-				"topP":            0.9,
-			},
 		}
+		withGenerationSettings(ctx, reqBody)
 		return json.Marshal(reqBody)
 	}
 
@@ -297,24 +275,22 @@ INSTRUKSI PENTING:
 	logFile, _ := ctx.Value("abtest_log_file").(string)
 	logFull, _ := ctx.Value("abtest_log_full").(string)
 	if strings.TrimSpace(logFile) != "" {
-		promptTemplateID := "askcampus_chat_generic_v1"
-		if uibDetected {
-			promptTemplateID = "askcampus_chat_uib_v1"
-		}
-		promptTemplateVer := "2025.10.26"
+		promptTemplateID := promptTemplateFor("askcampus_chat", uibDetected)
+		promptTemplateVer := PromptTemplateVersion
 		entry := map[string]any{
 			"timestamp":               time.Now().Format(time.RFC3339),
 			"run_id":                  runID,
 			"mode":                    mode,
 			"function":                "AskCampusWithChat",
-			"model":                   config.GeminiModel,
-			"temperature":             0.4,
+			"model":                   generationModels(ctx)[0],
+			"temperature":             generationConfig(ctx)["temperature"],
 			"uib_detected":            uibDetected,
 			"relevant_events_count":   relevantCount,
 			"latest_user_question":    latestUserQuestion,
 			"system_instruction_hash": shaHex(systemInstruction),
 			"context_hash":            shaHex(uibContext),
 			"prompt_id":               shaHex(systemInstruction),
+			"prompt_chars":            chatPromptChars(systemInstruction, chat),
 			"prompt_template_id":      promptTemplateID,
 			"prompt_template_version": promptTemplateVer,
 		}
@@ -322,38 +298,14 @@ INSTRUKSI PENTING:
 			entry["system_instruction"] = systemInstruction
 			entry["context_snapshot"] = uibContext
 		}
+		redactPromptLog(ctx, entry)
 		_ = appendPromptLog(logFile, entry)
 	}
 
-	for _, m := range models {
-		if strings.TrimSpace(m) == "" {
-			continue
-		}
+	return tryModels(ctx, "", func(m string) (string, error) {
 		bodyBytes, _ := payloadBuilder()
-		text, err := s.callGenerateContentWithBody(ctx, m, bodyBytes)
-		if err != nil && isRetriable(err) {
-			sleepWithContext(ctx, 2*time.Second)
-			text, err = s.callGenerateContentWithBody(ctx, m, bodyBytes)
-		}
-		if err == nil && strings.TrimSpace(text) != "" {
-			return strings.TrimSpace(text), nil
-		}
-		if err != nil {
-			tried[m] = err
-			log.Printf("[gemini] model %s failed: %v", m, err)
-		}
-	}
-	var b strings.Builder
-	b.WriteString("all gemini models failed: ")
-	first := true
-	for m, e := range tried {
-		if !first {
-			b.WriteString("; ")
-		}
-		first = false
-		b.WriteString(fmt.Sprintf("%s -> %v", m, e))
-	}
-	return "", errors.New(b.String())
+		return s.callGenerateContentWithBody(ctx, m, bodyBytes)
+	})
 }
 
 // Utility: hex-encoded SHA-256 of a string
@@ -362,6 +314,16 @@ func shaHex(s string) string {
 	return hex.EncodeToString(h[:])
 }
 
+// chatPromptChars is the size of a chat request: system instruction plus
+// every turn.
+func chatPromptChars(systemInstruction string, chat []ChatMessage) int {
+	n := utf8.RuneCountInString(systemInstruction)
+	for _, m := range chat {
+		n += utf8.RuneCountInString(m.Text)
+	}
+	return n
+}
+
 // Append a JSON object as one line into a log file (creates directories as needed)
 func appendPromptLog(path string, entry map[string]any) error {
 	if strings.TrimSpace(path) == "" {
@@ -386,6 +348,9 @@ func appendPromptLog(path string, entry map[string]any) error {
 }
 
 func (s *GeminiService) StreamCampus(ctx context.Context, question string, onDelta func(string)) (string, error) {
+	if text, ok, err := s.mockReply(ctx, question, onDelta); ok {
+		return text, err
+	}
 	if !s.enabled {
 		log.Printf("[gemini] disabled via config (IsGeminiEnabled=false)")
 		return "", ErrGeminiDisabled
@@ -394,51 +359,27 @@ func (s *GeminiService) StreamCampus(ctx context.Context, question string, onDel
 		log.Printf("[gemini] GEMINI_API_KEY is not set")
 		return "", fmt.Errorf("GEMINI_API_KEY is not set")
 	}
+	ctx = s.routed(ctx, question)
 
 	prompt := fmt.Sprintf("Jawab secara rinci, terstruktur, dan mudah dipahami tentang informasi kampus. Gunakan Bahasa Indonesia yang jelas. Sertakan poin-poin penting, contoh jika relevan, dan langkah-langkah praktis. Jika ada ketidakpastian, sebutkan asumsi atau saran lanjutan. Pertanyaan: %s", question)
 
-	models := []string{config.GeminiModel, "gemini-2.0-flash"}
-	tried := make(map[string]error)
-
-	for _, m := range models {
-		if strings.TrimSpace(m) == "" {
-			continue
-		}
+	return tryModels(ctx, "stream ", func(m string) (string, error) {
 		text, err := s.callStreamGenerateContent(ctx, m, prompt, onDelta)
-		if err != nil && isRetriable(err) {
-			sleepWithContext(ctx, 2*time.Second)
-			text, err = s.callStreamGenerateContent(ctx, m, prompt, onDelta)
-		}
-		if err == nil {
-			if strings.TrimSpace(text) != "" {
-				return strings.TrimSpace(text), nil
-			}
-			if full, gerr := s.callGenerateContent(ctx, m, prompt); gerr == nil && strings.TrimSpace(full) != "" {
-				if onDelta != nil {
-					onDelta(full)
-				}
-				return strings.TrimSpace(full), nil
-			}
-		}
-		if err != nil {
-			tried[m] = err
-			log.Printf("[gemini] stream model %s failed: %v", m, err)
+		if err != nil || strings.TrimSpace(text) != "" {
+			return text, err
 		}
-	}
-	var b strings.Builder
-	b.WriteString("all gemini stream models failed: ")
-	first := true
-	for m, e := range tried {
-		if !first {
-			b.WriteString("; ")
+		full, err := s.callGenerateContent(ctx, m, prompt)
+		if err == nil && strings.TrimSpace(full) != "" && onDelta != nil {
+			onDelta(full)
 		}
-		first = false
-		b.WriteString(fmt.Sprintf("%s -> %v", m, e))
-	}
-	return "", errors.New(b.String())
+		return full, err
+	})
 }
 
 func (s *GeminiService) StreamCampusWithChat(ctx context.Context, chat []ChatMessage, onDelta func(string)) (string, error) {
+	if text, ok, err := s.mockReply(ctx, lastUserText(chat), onDelta); ok {
+		return text, err
+	}
 	if !s.enabled {
 		log.Printf("[gemini] disabled via config (IsGeminiEnabled=false)")
 		return "", ErrGeminiDisabled
@@ -447,9 +388,7 @@ func (s *GeminiService) StreamCampusWithChat(ctx context.Context, chat []ChatMes
 		log.Printf("[gemini] GEMINI_API_KEY is not set")
 		return "", fmt.Errorf("GEMINI_API_KEY is not set")
 	}
-
-	models := []string{config.GeminiModel, "gemini-2.0-flash"}
-	tried := make(map[string]error)
+	ctx = s.routed(ctx, lastUserText(chat))
 
 	// Extract the latest user question for UIB context detection
 	var latestUserQuestion string
@@ -462,35 +401,23 @@ func (s *GeminiService) StreamCampusWithChat(ctx context.Context, chat []ChatMes
 
 	// Check for UIB context and build system instruction
 	var systemInstruction string
-	if s.uibService != nil && s.uibService.AnalyzeQueryForUIB(latestUserQuestion) {
+	var uibContext string
+	uib := s.eventData(latestUserQuestion)
+	if uib != nil && uib.AnalyzeQueryForUIB(latestUserQuestion) {
 		log.Printf("[gemini] ✅ UIB-RELATED STREAM QUERY DETECTED! Adding UIB context")
-		relevantEvents := s.uibService.GetRelevantEventsForQuery(latestUserQuestion)
+		relevantEvents := uib.GetRelevantEventsForQuery(latestUserQuestion)
 		log.Printf("[gemini] Found %d relevant UIB events for streaming", len(relevantEvents))
-		uibContext := s.uibService.FormatEventsForGemini(relevantEvents)
-
-		systemInstruction = fmt.Sprintf(`TANGGAL HARI INI: 4 Oktober 2025
-
-Kamu adalah asisten AI untuk Universitas Internasional Batam (UIB). Jawab pertanyaan menggunakan data resmi UIB yang disediakan di bawah ini.
-
-%s
-
-INSTRUKSI PENTING:
-1. PENTING: Hari ini adalah 4 Oktober 2025, jadi semua acara Oktober-Desember 2025 adalah SAAT INI atau AKAN DATANG
-2. LANGSUNG berikan SEMUA data yang tersedia sesuai pertanyaan - JANGAN tanya balik atau minta klarifikasi
-3. Jika ditanya tentang sertifikasi/webinar per bulan, tampilkan SEMUA yang ada di bulan tersebut
-4. SELALU gunakan data UIB yang disediakan di atas sebagai sumber utama
-5. Format jawaban dengan struktur jelas. Untuk setiap item tampilkan: Nama acara, Tanggal, Waktu, Lokasi, Biaya, Kontak. Jika tautan pendaftaran tidak tersedia, tulis: "tautan tidak tersedia dalam data".
-6. SELALU sebutkan bahwa ini adalah data resmi UIB (UIB_OFFICIAL) 
-7. Jika tidak ada data untuk bulan yang ditanyakan, baru katakan tidak tersedia
-8. JANGAN katakan "memerlukan informasi lebih lanjut" - langsung berikan semua yang ada
-9. Jika pertanyaan meminta webinar dan sertifikasi sekaligus, tampilkan KEDUANYA.
-10. Untuk frasa relatif seperti "minggu depan", artikan sebagai rentang Senin–Minggu pekan depan berdasarkan tanggal di atas.
-11. Gunakan format: "Berikut sertifikasi/webinar UIB untuk [bulan/rentang]:" lalu list semua
-10. Jawab dalam Bahasa Indonesia yang jelas dan terstruktur`, uibContext)
+		uibContext = uib.EventContext(latestUserQuestion, relevantEvents)
+
+		systemInstruction = eventInstruction(ctx, uib, uibContext, chatLanguageRule)
 	} else {
-		log.Printf("[gemini] ❌ NON-UIB STREAM QUERY - Using default system instruction")
-		systemInstruction = "Anda adalah asisten kampus yang sangat membantu. Jawab secara rinci, terstruktur (gunakan poin-poin atau langkah), dan jelas dalam Bahasa Indonesia. Jika konteks tidak cukup, minta klarifikasi singkat. Tetap fokus pada topik akademik/kampus."
+		label := s.ClassifyQuery(ctx, latestUserQuestion)
+		log.Printf("[gemini] ❌ NON-UIB STREAM QUERY - Using %s system instruction", label)
+		systemInstruction = topicSystemInstruction(label)
 	}
+	systemInstruction += s.documentContext(latestUserQuestion)
+	systemInstruction += userMemoryContext(ctx) + preferencesContext(ctx)
+	recordPrompt(ctx, promptTemplateFor("streamcampus_chat", uibContext != ""), uibContext, uib)
 
 	payloadBuilder := func() ([]byte, error) {
 		contents := make([]any, 0, len(chat))
@@ -509,53 +436,23 @@ INSTRUKSI PENTING:
 				"parts": []any{map[string]any{"text": systemInstruction}},
 			},
 			"contents": contents,
-			"generationConfig": map[string]any{
-				"temperature":     0.4,
-				"maxOutputTokens": 2048,
-				"topK":            40,
-				"topP":            0.9,
-			},
 		}
+		withGenerationSettings(ctx, reqBody)
 		return json.Marshal(reqBody)
 	}
 
-	for _, m := range models {
-		if strings.TrimSpace(m) == "" {
-			continue
-		}
+	return tryModels(ctx, "stream ", func(m string) (string, error) {
 		bodyBytes, _ := payloadBuilder()
 		text, err := s.callStreamGenerateContentWithBody(ctx, m, bodyBytes, onDelta)
-		if err != nil && isRetriable(err) {
-			sleepWithContext(ctx, 2*time.Second)
-			text, err = s.callStreamGenerateContentWithBody(ctx, m, bodyBytes, onDelta)
-		}
-		if err == nil {
-			if strings.TrimSpace(text) != "" {
-				return strings.TrimSpace(text), nil
-			}
-			if full, gerr := s.callGenerateContentWithBody(ctx, m, bodyBytes); gerr == nil && strings.TrimSpace(full) != "" {
-				if onDelta != nil {
-					onDelta(full)
-				}
-				return strings.TrimSpace(full), nil
-			}
-		}
-		if err != nil {
-			tried[m] = err
-			log.Printf("[gemini] stream model %s failed: %v", m, err)
+		if err != nil || strings.TrimSpace(text) != "" {
+			return text, err
 		}
-	}
-	var b strings.Builder
-	b.WriteString("all gemini stream models failed: ")
-	first := true
-	for m, e := range tried {
-		if !first {
-			b.WriteString("; ")
+		full, err := s.callGenerateContentWithBody(ctx, m, bodyBytes)
+		if err == nil && strings.TrimSpace(full) != "" && onDelta != nil {
+			onDelta(full)
 		}
-		first = false
-		b.WriteString(fmt.Sprintf("%s -> %v", m, e))
-	}
-	return "", errors.New(b.String())
+		return full, err
+	})
 }
 
 func (s *GeminiService) callGenerateContent(ctx context.Context, model, prompt string) (string, error) {
@@ -566,13 +463,8 @@ func (s *GeminiService) callGenerateContent(ctx context.Context, model, prompt s
 				"parts": []any{map[string]any{"text": prompt}},
 			},
 		},
-		"generationConfig": map[string]any{
-			"temperature":     0.4,
-			"maxOutputTokens": 1024,
-			"topK":            40,
-			"topP":            0.9,
-		},
 	}
+	withGenerationSettings(ctx, reqBody)
 	bodyBytes, _ := json.Marshal(reqBody)
 
 	url := fmt.Sprintf("https://generativelanguage.googleapis.com/v1beta/models/%s:generateContent?key=%s", model, s.apiKey)
@@ -605,8 +497,10 @@ func (s *GeminiService) callGenerateContent(ctx context.Context, model, prompt s
 	if err := json.Unmarshal(respBytes, &parsed); err != nil {
 		return strings.TrimSpace(string(respBytes)), nil
 	}
+	recordUsage(ctx, parseUsage(parsed["usageMetadata"]))
 	if cands, ok := parsed["candidates"].([]any); ok && len(cands) > 0 {
 		if first, ok := cands[0].(map[string]any); ok {
+			recordCandidate(ctx, model, first)
 			if content, ok := first["content"].(map[string]any); ok {
 				if parts, ok := content["parts"].([]any); ok {
 					for _, p := range parts {
@@ -631,44 +525,49 @@ func (s *GeminiService) callGenerateContent(ctx context.Context, model, prompt s
 	return strings.TrimSpace(string(respBytes)), nil
 }
 
-func (s *GeminiService) callGenerateContentWithBody(ctx context.Context, model string, body []byte) (string, error) {
+// generateContentOnce is one generateContent call; reason is the
+// candidate's finishReason.
+func (s *GeminiService) generateContentOnce(ctx context.Context, model string, body []byte) (text, reason string, err error) {
 	url := fmt.Sprintf("https://generativelanguage.googleapis.com/v1beta/models/%s:generateContent?key=%s", model, s.apiKey)
 	log.Printf("[gemini] using model %s", model)
 	log.Printf("[gemini] POST %s", url)
 
 	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
 	if err != nil {
-		return "", err
+		return "", "", err
 	}
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("Accept", "application/json")
 
 	resp, err := http.DefaultClient.Do(req)
 	if err != nil {
-		return "", fmt.Errorf("http error: %w", err)
+		return "", "", fmt.Errorf("http error: %w", err)
 	}
 	defer resp.Body.Close()
 
 	respBytes, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return "", fmt.Errorf("read error: %w", err)
+		return "", "", fmt.Errorf("read error: %w", err)
 	}
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		return "", fmt.Errorf("status %d: %s", resp.StatusCode, strings.TrimSpace(string(respBytes)))
+		return "", "", fmt.Errorf("status %d: %s", resp.StatusCode, strings.TrimSpace(string(respBytes)))
 	}
 
 	var parsed map[string]any
 	if err := json.Unmarshal(respBytes, &parsed); err != nil {
-		return strings.TrimSpace(string(respBytes)), nil
+		return strings.TrimSpace(string(respBytes)), "", nil
 	}
+	recordUsage(ctx, parseUsage(parsed["usageMetadata"]))
 	if cands, ok := parsed["candidates"].([]any); ok && len(cands) > 0 {
 		if first, ok := cands[0].(map[string]any); ok {
+			recordCandidate(ctx, model, first)
+			reason, _ = first["finishReason"].(string)
 			if content, ok := first["content"].(map[string]any); ok {
 				if parts, ok := content["parts"].([]any); ok {
 					for _, p := range parts {
 						if pm, ok := p.(map[string]any); ok {
 							if txt, ok := pm["text"].(string); ok && strings.TrimSpace(txt) != "" {
-								return txt, nil
+								return txt, reason, nil
 							}
 						}
 					}
@@ -676,7 +575,7 @@ func (s *GeminiService) callGenerateContentWithBody(ctx context.Context, model s
 			}
 		}
 	}
-	return strings.TrimSpace(string(respBytes)), nil
+	return strings.TrimSpace(string(respBytes)), "", nil
 }
 
 func (s *GeminiService) callStreamGenerateContent(ctx context.Context, model, prompt string, onDelta func(string)) (string, error) {
@@ -687,13 +586,8 @@ func (s *GeminiService) callStreamGenerateContent(ctx context.Context, model, pr
 				"parts": []any{map[string]any{"text": prompt}},
 			},
 		},
-		"generationConfig": map[string]any{
-			"temperature":     0.4,
-			"maxOutputTokens": 1024,
-			"topK":            40,
-			"topP":            0.9,
-		},
 	}
+	withGenerationSettings(ctx, reqBody)
 	bodyBytes, _ := json.Marshal(reqBody)
 
 	url := fmt.Sprintf("https://generativelanguage.googleapis.com/v1beta/models/%s:streamGenerateContent?key=%s", model, s.apiKey)
@@ -719,6 +613,7 @@ func (s *GeminiService) callStreamGenerateContent(ctx context.Context, model, pr
 	}
 
 	full := strings.Builder{}
+	var usage Usage // cumulative; the last chunk has the totals
 	scanner := bufio.NewScanner(resp.Body)
 	buf := make([]byte, 0, 64*1024)
 	scanner.Buffer(buf, 1024*1024)
@@ -734,8 +629,12 @@ func (s *GeminiService) callStreamGenerateContent(ctx context.Context, model, pr
 		if err := json.Unmarshal([]byte(line), &obj); err != nil {
 			continue
 		}
+		if u, ok := obj["usageMetadata"]; ok {
+			usage = parseUsage(u)
+		}
 		if cands, ok := obj["candidates"].([]any); ok && len(cands) > 0 {
 			if first, ok := cands[0].(map[string]any); ok {
+				recordCandidate(ctx, model, first)
 				if content, ok := first["content"].(map[string]any); ok {
 					if parts, ok := content["parts"].([]any); ok {
 						for _, p := range parts {
@@ -753,36 +652,40 @@ func (s *GeminiService) callStreamGenerateContent(ctx context.Context, model, pr
 			}
 		}
 	}
+	recordUsage(ctx, usage)
 	if err := scanner.Err(); err != nil {
 		return full.String(), fmt.Errorf("stream read error: %w", err)
 	}
 	return full.String(), nil
 }
 
-func (s *GeminiService) callStreamGenerateContentWithBody(ctx context.Context, model string, body []byte, onDelta func(string)) (string, error) {
+// streamGenerateContentOnce is one streamGenerateContent call; reason is
+// the finishReason of the last chunk.
+func (s *GeminiService) streamGenerateContentOnce(ctx context.Context, model string, body []byte, onDelta func(string)) (text, reason string, err error) {
 	url := fmt.Sprintf("https://generativelanguage.googleapis.com/v1beta/models/%s:streamGenerateContent?key=%s", model, s.apiKey)
 	log.Printf("[gemini] streaming model %s", model)
 	log.Printf("[gemini] POST %s", url)
 
 	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
 	if err != nil {
-		return "", err
+		return "", "", err
 	}
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("Accept", "text/event-stream")
 
 	resp, err := http.DefaultClient.Do(req)
 	if err != nil {
-		return "", fmt.Errorf("http error: %w", err)
+		return "", "", fmt.Errorf("http error: %w", err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
 		b, _ := io.ReadAll(resp.Body)
-		return "", fmt.Errorf("status %d: %s", resp.StatusCode, strings.TrimSpace(string(b)))
+		return "", "", fmt.Errorf("status %d: %s", resp.StatusCode, strings.TrimSpace(string(b)))
 	}
 
 	full := strings.Builder{}
+	var usage Usage // cumulative; the last chunk has the totals
 	scanner := bufio.NewScanner(resp.Body)
 	buf := make([]byte, 0, 64*1024)
 	scanner.Buffer(buf, 1024*1024)
@@ -798,8 +701,15 @@ func (s *GeminiService) callStreamGenerateContentWithBody(ctx context.Context, m
 		if err := json.Unmarshal([]byte(line), &obj); err != nil {
 			continue
 		}
+		if u, ok := obj["usageMetadata"]; ok {
+			usage = parseUsage(u)
+		}
 		if cands, ok := obj["candidates"].([]any); ok && len(cands) > 0 {
 			if first, ok := cands[0].(map[string]any); ok {
+				recordCandidate(ctx, model, first)
+				if r, _ := first["finishReason"].(string); r != "" {
+					reason = r
+				}
 				if content, ok := first["content"].(map[string]any); ok {
 					if parts, ok := content["parts"].([]any); ok {
 						for _, p := range parts {
@@ -817,10 +727,11 @@ func (s *GeminiService) callStreamGenerateContentWithBody(ctx context.Context, m
 			}
 		}
 	}
+	recordUsage(ctx, usage)
 	if err := scanner.Err(); err != nil {
-		return full.String(), fmt.Errorf("stream read error: %w", err)
+		return full.String(), reason, fmt.Errorf("stream read error: %w", err)
 	}
-	return full.String(), nil
+	return full.String(), reason, nil
 }
 
 func isRetriable(err error) bool {
@@ -981,7 +892,7 @@ Jika tidak ada universitas spesifik, gunakan:
 Percakapan:
 %s`, convoBuilder.String())
 
-	models := []string{config.GeminiModel, "gemini-2.0-flash"}
+	models := generationModels(ctx)
 	for _, model := range models {
 		if strings.TrimSpace(model) == "" {
 			continue
@@ -1006,6 +917,9 @@ Percakapan:
 
 // AskCampusWithUIBContext asks Gemini with enhanced UIB context for better UIB-related responses
 func (s *GeminiService) AskCampusWithUIBContext(ctx context.Context, chat []ChatMessage) (string, error) {
+	if text, ok, err := s.mockReply(ctx, lastUserText(chat), nil); ok {
+		return text, err
+	}
 	if !s.enabled {
 		log.Printf("[gemini] disabled via config (IsGeminiEnabled=false)")
 		return "", ErrGeminiDisabled
@@ -1014,9 +928,7 @@ func (s *GeminiService) AskCampusWithUIBContext(ctx context.Context, chat []Chat
 		log.Printf("[gemini] GEMINI_API_KEY is not set")
 		return "", fmt.Errorf("GEMINI_API_KEY is not set")
 	}
-
-	models := []string{config.GeminiModel, "gemini-2.0-flash"}
-	tried := make(map[string]error)
+	ctx = s.routed(ctx, lastUserText(chat))
 
 	payloadBuilder := func() ([]byte, error) {
 		contents := make([]any, 0, len(chat)+2) // +2 for potential UIB context
@@ -1028,23 +940,27 @@ func (s *GeminiService) AskCampusWithUIBContext(ctx context.Context, chat []Chat
 		for _, m := range chat {
 			if strings.ToLower(strings.TrimSpace(m.Role)) == "user" {
 				latestUserMessage = m.Text
-				if s.uibService != nil && s.uibService.AnalyzeQueryForUIB(m.Text) {
-					isUIBRelated = true
-				}
+			}
+		}
+		uib := s.eventData(latestUserMessage)
+		for _, m := range chat {
+			if strings.ToLower(strings.TrimSpace(m.Role)) == "user" && uib != nil && uib.AnalyzeQueryForUIB(m.Text) {
+				isUIBRelated = true
 			}
 		}
 
 		// Add UIB context at the beginning if UIB-related
-		if isUIBRelated && s.uibService != nil {
+		var uibContext string
+		if isUIBRelated && uib != nil {
 			log.Printf("[gemini] ✅ CHAT: UIB context detected! Latest message: %s", latestUserMessage)
-			relevantEvents := s.uibService.GetRelevantEventsForQuery(latestUserMessage)
+			relevantEvents := uib.GetRelevantEventsForQuery(latestUserMessage)
 			log.Printf("[gemini] CHAT: Found %d relevant UIB events for context", len(relevantEvents))
-			uibContext := s.uibService.FormatEventsForGemini(relevantEvents)
+			uibContext = uib.EventContext(latestUserMessage, relevantEvents)
 
 			// Add UIB context as system message
 			contents = append(contents, map[string]any{
 				"role":  "model",
-				"parts": []any{map[string]any{"text": "Saya memiliki akses ke data resmi UIB terbaru untuk tahun 2025. Hari ini tanggal 4 Oktober 2025. Berikut adalah data yang relevan:"}},
+				"parts": []any{map[string]any{"text": uib.Localize("Saya memiliki akses ke data resmi UIB terbaru untuk tahun 2025. Hari ini tanggal 4 Oktober 2025. Berikut adalah data yang relevan:")}},
 			})
 			contents = append(contents, map[string]any{
 				"role":  "user",
@@ -1052,7 +968,7 @@ func (s *GeminiService) AskCampusWithUIBContext(ctx context.Context, chat []Chat
 			})
 			contents = append(contents, map[string]any{
 				"role":  "model",
-				"parts": []any{map[string]any{"text": "Data UIB lengkap untuk Oktober-Desember 2025 telah dimuat dengan mark UIB_OFFICIAL. Saya akan langsung memberikan SEMUA data yang tersedia tanpa meminta klarifikasi tambahan. Semua acara bisa didaftarkan sekarang."}},
+				"parts": []any{map[string]any{"text": uib.Localize("Data UIB lengkap untuk Oktober-Desember 2025 telah dimuat dengan mark UIB_OFFICIAL. Saya akan langsung memberikan SEMUA data yang tersedia tanpa meminta klarifikasi tambahan. Semua acara bisa didaftarkan sekarang.")}},
 			})
 		}
 
@@ -1068,74 +984,31 @@ func (s *GeminiService) AskCampusWithUIBContext(ctx context.Context, chat []Chat
 			})
 		}
 
-		systemInstruction := "Anda adalah asisten kampus yang sangat membantu. Jawab secara rinci, terstruktur (gunakan poin-poin atau langkah), dan jelas dalam Bahasa Indonesia. Jika konteks tidak cukup, minta klarifikasi singkat. Tetap fokus pada topik akademik/kampus."
+		systemInstruction := topicSystemInstruction(s.ClassifyQuery(ctx, latestUserMessage))
 
 		if isUIBRelated {
-			systemInstruction = `TANGGAL HARI INI: 4 Oktober 2025
-
-Anda adalah asisten resmi Universitas Internasional Batam (UIB). 
-
-INSTRUKSI KHUSUS UIB:
-1. PENTING: Hari ini adalah 4 Oktober 2025, jadi semua acara Oktober-Desember 2025 adalah SAAT INI atau AKAN DATANG
-2. LANGSUNG berikan SEMUA data yang tersedia - JANGAN tanya balik atau minta klarifikasi
-3. Jika ditanya tentang sertifikasi/webinar per bulan, tampilkan SEMUA yang ada di bulan tersebut
-4. WAJIB gunakan data UIB yang telah disediakan sebagai sumber utama
-5. Format: "Berikut sertifikasi UIB untuk [bulan]:" lalu list semua dengan detail lengkap
-6. SELALU sebutkan bahwa informasi berasal dari data resmi UIB (UIB_OFFICIAL)
-7. Sertakan detail lengkap: tanggal, waktu, lokasi, biaya, kontak jika tersedia
-8. JANGAN katakan "memerlukan informasi lebih lanjut" - langsung berikan semua yang ada
-9. Format jawaban dengan emoji dan struktur yang menarik
-10. Untuk pendaftaran, selalu sertakan informasi kontak dan deadline jika ada
-
-Prioritas jawaban: Data UIB lengkap → Informasi umum kampus → Saran kontak UIB`
+			// the event data went in as turns above
+			systemInstruction = eventInstruction(ctx, uib, "")
 		}
+		systemInstruction += s.documentContext(latestUserMessage)
+		systemInstruction += userMemoryContext(ctx) + preferencesContext(ctx)
+		recordPrompt(ctx, promptTemplateFor("askcampus_uibctx", isUIBRelated), uibContext, uib)
 
 		reqBody := map[string]any{
 			"systemInstruction": map[string]any{
 				"parts": []any{map[string]any{"text": systemInstruction}},
 			},
 			"contents": contents,
-			"generationConfig": map[string]any{
-				"temperature":     0.4,
-				"maxOutputTokens": 2048,
-				"topK":            40,
-				"topP":            0.9,
-			},
 		}
+		withGenerationSettings(ctx, reqBody)
 		return json.Marshal(reqBody)
 	}
 
-	for _, m := range models {
-		if strings.TrimSpace(m) == "" {
-			continue
-		}
+	return tryModels(ctx, "", func(m string) (string, error) {
 		payload, err := payloadBuilder()
 		if err != nil {
 			return "", fmt.Errorf("failed to build payload: %w", err)
 		}
-		text, err := s.callGenerateContentWithBody(ctx, m, payload)
-		if err != nil && isRetriable(err) {
-			sleepWithContext(ctx, 2*time.Second)
-			text, err = s.callGenerateContentWithBody(ctx, m, payload)
-		}
-		if err == nil && strings.TrimSpace(text) != "" {
-			return strings.TrimSpace(text), nil
-		}
-		if err != nil {
-			tried[m] = err
-			log.Printf("[gemini] model %s failed: %v", m, err)
-		}
-	}
-
-	var b strings.Builder
-	b.WriteString("all gemini models failed: ")
-	first := true
-	for m, e := range tried {
-		if !first {
-			b.WriteString("; ")
-		}
-		first = false
-		b.WriteString(fmt.Sprintf("%s -> %v", m, e))
-	}
-	return "", errors.New(b.String())
+		return s.callGenerateContentWithBody(ctx, m, payload)
+	})
 }
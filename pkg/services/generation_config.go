@@ -0,0 +1,184 @@
+package services
+
+import (
+	"AkuAI/models"
+	"AkuAI/pkg/config"
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// GenerationOverride replaces parts of the configured generationConfig, and
+// optionally the model, for one request. Attach it with WithGenerationOverride;
+// unset fields keep the GEMINI_* defaults.
+type GenerationOverride struct {
+	Model           string   `json:"model,omitempty"`
+	Temperature     *float64 `json:"temperature,omitempty"`
+	TopK            *int     `json:"topK,omitempty"`
+	TopP            *float64 `json:"topP,omitempty"`
+	MaxOutputTokens *int     `json:"maxOutputTokens,omitempty"`
+}
+
+// Validate checks the ranges the Gemini API accepts.
+func (o GenerationOverride) Validate() error {
+	switch {
+	case o.Model != "" && !validModelName(o.Model):
+		return fmt.Errorf("invalid model %q", o.Model)
+	case o.Temperature != nil && (*o.Temperature < 0 || *o.Temperature > 2):
+		return errors.New("temperature must be between 0 and 2")
+	case o.TopP != nil && (*o.TopP < 0 || *o.TopP > 1):
+		return errors.New("topP must be between 0 and 1")
+	case o.TopK != nil && *o.TopK < 1:
+		return errors.New("topK must be at least 1")
+	case o.MaxOutputTokens != nil && (*o.MaxOutputTokens < 1 || *o.MaxOutputTokens > 8192):
+		return errors.New("maxOutputTokens must be between 1 and 8192")
+	}
+	return nil
+}
+
+type generationOverrideKey struct{}
+
+func WithGenerationOverride(ctx context.Context, o GenerationOverride) context.Context {
+	return context.WithValue(ctx, generationOverrideKey{}, o)
+}
+
+// HasGenerationOverride reports whether ctx carries an override. Callers skip
+// the reply caches for such requests so a sweep sees fresh generations.
+func HasGenerationOverride(ctx context.Context) bool {
+	_, ok := ctx.Value(generationOverrideKey{}).(GenerationOverride)
+	return ok
+}
+
+// generationConfig is the generationConfig block for a request: the GEMINI_*
+// settings, maxOutputTokens for the verbosity in ctx, with the context's
+// override applied.
+func generationConfig(ctx context.Context) map[string]any {
+	temp, topK, topP, maxTokens := config.GeminiTemperature, config.GeminiTopK, config.GeminiTopP, config.GeminiMaxOutputTokens
+	switch PreferencesFrom(ctx).Verbosity {
+	case models.VerbosityShort:
+		maxTokens = config.GeminiShortMaxOutputTokens
+	case models.VerbosityDetailed:
+		maxTokens = config.GeminiDetailedMaxOutputTokens
+	}
+	if o, ok := ctx.Value(generationOverrideKey{}).(GenerationOverride); ok {
+		if o.Temperature != nil {
+			temp = *o.Temperature
+		}
+		if o.TopK != nil {
+			topK = *o.TopK
+		}
+		if o.TopP != nil {
+			topP = *o.TopP
+		}
+		if o.MaxOutputTokens != nil {
+			maxTokens = *o.MaxOutputTokens
+		}
+	}
+	return map[string]any{
+		"temperature":     temp,
+		"maxOutputTokens": maxTokens,
+		"topK":            topK,
+		"topP":            topP,
+	}
+}
+
+// generationModels lists the models to try in order. An overridden model is
+// the only candidate, so a sweep never silently measures the fallback; a
+// routed request tries its route's model first.
+func generationModels(ctx context.Context) []string {
+	if o, ok := ctx.Value(generationOverrideKey{}).(GenerationOverride); ok && o.Model != "" {
+		return []string{o.Model}
+	}
+	r, ok := routeFrom(ctx)
+	if !ok {
+		return []string{config.GeminiModel, "gemini-2.0-flash"}
+	}
+	models := make([]string, 0, 3)
+	for _, m := range []string{routeModel(r), config.GeminiModel, "gemini-2.0-flash"} {
+		if m != "" && !slices.Contains(models, m) {
+			models = append(models, m)
+		}
+	}
+	return models
+}
+
+func validModelName(m string) bool {
+	for _, r := range m {
+		if !(r >= 'a' && r <= 'z' || r >= '0' && r <= '9' || r == '-' || r == '.' || r == '_') {
+			return false
+		}
+	}
+	return true
+}
+
+// harmCategories are the categories GEMINI_SAFETY_SETTINGS may name, with or
+// without the HARM_CATEGORY_ prefix; "*" sets all of them.
+var harmCategories = []string{
+	"HARM_CATEGORY_HARASSMENT",
+	"HARM_CATEGORY_HATE_SPEECH",
+	"HARM_CATEGORY_SEXUALLY_EXPLICIT",
+	"HARM_CATEGORY_DANGEROUS_CONTENT",
+}
+
+var harmThresholds = map[string]bool{
+	"BLOCK_NONE":             true,
+	"BLOCK_ONLY_HIGH":        true,
+	"BLOCK_MEDIUM_AND_ABOVE": true,
+	"BLOCK_LOW_AND_ABOVE":    true,
+	"OFF":                    true,
+}
+
+// ParseSafetySettings parses "CATEGORY=THRESHOLD,..." (e.g.
+// "*=BLOCK_ONLY_HIGH,HARASSMENT=BLOCK_MEDIUM_AND_ABOVE") into the
+// safetySettings array of a Gemini request. Later entries win.
+func ParseSafetySettings(s string) ([]any, error) {
+	thresholds := map[string]string{}
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		cat, th, ok := strings.Cut(part, "=")
+		cat = strings.ToUpper(strings.TrimSpace(cat))
+		th = strings.ToUpper(strings.TrimSpace(th))
+		if !ok || !harmThresholds[th] {
+			return nil, fmt.Errorf("invalid safety setting %q", part)
+		}
+		if cat == "*" {
+			for _, c := range harmCategories {
+				thresholds[c] = th
+			}
+			continue
+		}
+		if !strings.HasPrefix(cat, "HARM_CATEGORY_") {
+			cat = "HARM_CATEGORY_" + cat
+		}
+		known := false
+		for _, c := range harmCategories {
+			known = known || c == cat
+		}
+		if !known {
+			return nil, fmt.Errorf("unknown harm category in %q", part)
+		}
+		thresholds[cat] = th
+	}
+	var out []any
+	for _, c := range harmCategories {
+		if th, ok := thresholds[c]; ok {
+			out = append(out, map[string]any{"category": c, "threshold": th})
+		}
+	}
+	return out, nil
+}
+
+// withGenerationSettings adds generationConfig and, when configured,
+// safetySettings to a Gemini request body.
+func withGenerationSettings(ctx context.Context, body map[string]any) map[string]any {
+	body["generationConfig"] = generationConfig(ctx)
+	if safety, err := ParseSafetySettings(config.GeminiSafetySettings); err == nil && len(safety) > 0 {
+		body["safetySettings"] = safety
+	}
+	return body
+}
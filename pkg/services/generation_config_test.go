@@ -0,0 +1,73 @@
+package services
+
+import (
+	"AkuAI/models"
+	"AkuAI/pkg/config"
+	"context"
+	"testing"
+)
+
+func TestParseSafetySettings(t *testing.T) {
+	got, err := ParseSafetySettings("*=block_only_high, HARASSMENT=BLOCK_MEDIUM_AND_ABOVE")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 4 {
+		t.Fatalf("got %d settings, want 4", len(got))
+	}
+	want := map[string]string{
+		"HARM_CATEGORY_HARASSMENT":        "BLOCK_MEDIUM_AND_ABOVE",
+		"HARM_CATEGORY_HATE_SPEECH":       "BLOCK_ONLY_HIGH",
+		"HARM_CATEGORY_SEXUALLY_EXPLICIT": "BLOCK_ONLY_HIGH",
+		"HARM_CATEGORY_DANGEROUS_CONTENT": "BLOCK_ONLY_HIGH",
+	}
+	for _, s := range got {
+		m := s.(map[string]any)
+		if want[m["category"].(string)] != m["threshold"] {
+			t.Errorf("%v: threshold %v", m["category"], m["threshold"])
+		}
+	}
+	for _, bad := range []string{"HARASSMENT", "VIOLENCE=BLOCK_NONE", "HATE_SPEECH=SOMETIMES"} {
+		if _, err := ParseSafetySettings(bad); err == nil {
+			t.Errorf("ParseSafetySettings(%q): expected an error", bad)
+		}
+	}
+}
+
+func TestGenerationOverride(t *testing.T) {
+	temp, topK := 1.1, 8
+	ctx := WithGenerationOverride(context.Background(), GenerationOverride{Temperature: &temp, TopK: &topK})
+	if !HasGenerationOverride(ctx) || HasGenerationOverride(context.Background()) {
+		t.Fatal("HasGenerationOverride mismatch")
+	}
+	cfg := generationConfig(ctx)
+	if cfg["temperature"] != 1.1 || cfg["topK"] != 8 {
+		t.Errorf("override not applied: %v", cfg)
+	}
+	if def := generationConfig(context.Background()); def["topP"] != cfg["topP"] {
+		t.Errorf("unset fields should keep defaults: %v vs %v", def, cfg)
+	}
+
+	bad := 3.0
+	if err := (GenerationOverride{Temperature: &bad}).Validate(); err == nil {
+		t.Error("expected temperature 3 to be rejected")
+	}
+}
+
+func TestVerbosityMaxOutputTokens(t *testing.T) {
+	ctx := WithVerbosity(context.Background(), models.VerbosityShort)
+	if got := generationConfig(ctx)["maxOutputTokens"]; got != config.GeminiShortMaxOutputTokens {
+		t.Errorf("short: maxOutputTokens = %v", got)
+	}
+	ctx = WithVerbosity(ctx, models.VerbosityDetailed)
+	if got := generationConfig(ctx)["maxOutputTokens"]; got != config.GeminiDetailedMaxOutputTokens {
+		t.Errorf("detailed: maxOutputTokens = %v", got)
+	}
+	if got := generationConfig(WithVerbosity(ctx, "")); got["maxOutputTokens"] != config.GeminiDetailedMaxOutputTokens {
+		t.Errorf(`"" should keep the verbosity: %v`, got)
+	}
+	max := 100
+	if got := generationConfig(WithGenerationOverride(ctx, GenerationOverride{MaxOutputTokens: &max})); got["maxOutputTokens"] != 100 {
+		t.Errorf("override should win: %v", got)
+	}
+}
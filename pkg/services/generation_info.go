@@ -0,0 +1,212 @@
+package services
+
+import (
+	"context"
+	"sync"
+)
+
+// GenerationInfo collects facts about a single generation that the Gemini
+// client learns along the way: the model that answered, its finish reason,
+// the prompt template, a hash of the retrieval context and the revision of
+// the event dataset it came from. Attach it with
+// WithGenerationInfo before calling the service.
+type GenerationInfo struct {
+	mu            sync.Mutex
+	finishReason  string
+	model         string
+	templateID    string
+	contextHash   string
+	datasetHash   string
+	cached        bool
+	continuations int
+	usage         Usage
+	attempts      int
+	route         Route
+}
+
+// Usage is the token count Gemini reports in usageMetadata, summed over
+// every call that went into a reply.
+type Usage struct {
+	PromptTokens int `json:"prompt_tokens"`
+	OutputTokens int `json:"output_tokens"`
+}
+
+// parseUsage reads a usageMetadata object. Thinking tokens are billed as
+// output, so they count as such.
+func parseUsage(v any) Usage {
+	m, _ := v.(map[string]any)
+	n := func(key string) int {
+		f, _ := m[key].(float64)
+		return int(f)
+	}
+	return Usage{PromptTokens: n("promptTokenCount"), OutputTokens: n("candidatesTokenCount") + n("thoughtsTokenCount")}
+}
+
+type generationInfoKey struct{}
+
+func WithGenerationInfo(ctx context.Context) (context.Context, *GenerationInfo) {
+	info := &GenerationInfo{}
+	return context.WithValue(ctx, generationInfoKey{}, info), info
+}
+
+func generationInfo(ctx context.Context) *GenerationInfo {
+	info, _ := ctx.Value(generationInfoKey{}).(*GenerationInfo)
+	return info
+}
+
+func (g *GenerationInfo) read(f func(*GenerationInfo) string) string {
+	if g == nil {
+		return ""
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return f(g)
+}
+
+func (g *GenerationInfo) FinishReason() string {
+	return g.read(func(g *GenerationInfo) string { return g.finishReason })
+}
+
+// Model is the Gemini model that produced the reply, "local" for the local
+// responder, or "" when unknown (e.g. served from cache).
+func (g *GenerationInfo) Model() string {
+	return g.read(func(g *GenerationInfo) string { return g.model })
+}
+
+// Route is the route GEMINI_ROUTING picked for the query, "" when off.
+func (g *GenerationInfo) Route() Route {
+	return Route(g.read(func(g *GenerationInfo) string { return string(g.route) }))
+}
+
+func (g *GenerationInfo) TemplateID() string {
+	return g.read(func(g *GenerationInfo) string { return g.templateID })
+}
+
+// ContextHash is the SHA-256 of the event context given to the model (the
+// context_hash of abtest prompt logs), "" when the prompt had none.
+func (g *GenerationInfo) ContextHash() string {
+	return g.read(func(g *GenerationInfo) string { return g.contextHash })
+}
+
+// DatasetHash is the UIBEventService.Hash of the dataset the context was
+// drawn from, "" when the prompt had no event context.
+func (g *GenerationInfo) DatasetHash() string {
+	return g.read(func(g *GenerationInfo) string { return g.datasetHash })
+}
+
+// Parts is how many model calls the reply was stitched from: more than 1
+// when it hit maxOutputTokens and was continued.
+func (g *GenerationInfo) Parts() int {
+	if g == nil {
+		return 1
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.continuations + 1
+}
+
+// Usage is the tokens spent on the reply, zero when Gemini reported none.
+func (g *GenerationInfo) Usage() Usage {
+	if g == nil {
+		return Usage{}
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.usage
+}
+
+// Attempts is how many model calls the request made, retries and fallback
+// models included.
+func (g *GenerationInfo) Attempts() int {
+	if g == nil {
+		return 0
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.attempts
+}
+
+// takeAttempt counts a model call unless the request already spent its
+// first call and budget retries.
+func (g *GenerationInfo) takeAttempt(budget int) bool {
+	if g == nil {
+		return true
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.attempts > budget {
+		return false
+	}
+	g.attempts++
+	return true
+}
+
+func (g *GenerationInfo) Cached() bool {
+	if g == nil {
+		return false
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.cached
+}
+
+func (g *GenerationInfo) update(f func(*GenerationInfo)) {
+	if g == nil {
+		return
+	}
+	g.mu.Lock()
+	f(g)
+	g.mu.Unlock()
+}
+
+// recordCandidate stores the model and candidate.finishReason on the
+// GenerationInfo in ctx, if any. The last candidate seen wins, which for
+// fallbacks is the reply that was actually returned.
+func recordCandidate(ctx context.Context, model string, candidate map[string]any) {
+	reason, _ := candidate["finishReason"].(string)
+	generationInfo(ctx).update(func(g *GenerationInfo) {
+		g.model = model
+		if reason != "" {
+			g.finishReason = reason
+		}
+	})
+}
+
+// recordPrompt stores the template a generation uses, the hash of its
+// retrieval context and the dataset that context was built from.
+func recordPrompt(ctx context.Context, templateID, uibContext string, dataset *UIBEventService) {
+	hash, dsHash := "", ""
+	if uibContext != "" {
+		hash, dsHash = shaHex(uibContext), dataset.Hash()
+	}
+	generationInfo(ctx).update(func(g *GenerationInfo) {
+		g.templateID, g.contextHash, g.datasetHash = templateID, hash, dsHash
+	})
+}
+
+// recordUsage adds the tokens of one call.
+func recordUsage(ctx context.Context, u Usage) {
+	generationInfo(ctx).update(func(g *GenerationInfo) {
+		g.usage.PromptTokens += u.PromptTokens
+		g.usage.OutputTokens += u.OutputTokens
+	})
+}
+
+// recordContinuation counts a continuation appended to the reply.
+func recordContinuation(ctx context.Context) {
+	generationInfo(ctx).update(func(g *GenerationInfo) { g.continuations++ })
+}
+
+// MarkCached records that the reply was served from a cache instead of
+// being generated.
+func MarkCached(ctx context.Context) {
+	generationInfo(ctx).update(func(g *GenerationInfo) { g.cached = true })
+}
+
+// MarkLocal records that the reply came from the local fallback responder.
+func MarkLocal(ctx context.Context) {
+	generationInfo(ctx).update(func(g *GenerationInfo) {
+		g.model, g.finishReason, g.templateID, g.contextHash, g.datasetHash = "local", "", "", "", ""
+		g.continuations = 0
+	})
+}
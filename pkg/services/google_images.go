@@ -68,13 +68,12 @@ func validateImageURL(client *http.Client, imageURL string) bool {
 	return strings.HasPrefix(http.DetectContentType(buf[:n]), "image/")
 }
 
-func fetchRawGoogleImages(query, apiKey, cx string, numToFetch, startIndex int) ([]struct {
-	Link string `json:"link"`
-}, error) {
+func fetchRawGoogleImages(query, apiKey, cx string, numToFetch, startIndex int) ([]googleImageItem, error) {
 	if numToFetch <= 0 {
-		return []struct {
-			Link string `json:"link"`
-		}{}, nil
+		return []googleImageItem{}, nil
+	}
+	if !quota.reserve(config.GoogleAPIDailyQuota) {
+		return nil, ErrGoogleQuotaExhausted
 	}
 	apiURL := fmt.Sprintf("https://www.googleapis.com/customsearch/v1?q=%s&key=%s&cx=%s&searchType=image&num=%d&start=%d",
 		url.QueryEscape(query), apiKey, cx, numToFetch, startIndex)
@@ -96,9 +95,7 @@ func fetchRawGoogleImages(query, apiKey, cx string, numToFetch, startIndex int)
 	}
 
 	var googleResp struct {
-		Items []struct {
-			Link string `json:"link"`
-		} `json:"items"`
+		Items []googleImageItem `json:"items"`
 	}
 
 	if err := json.Unmarshal(body, &googleResp); err != nil {
@@ -245,167 +242,30 @@ func GetGoogleImagesPlaces(query string) ([]string, error) {
 
 // return 4 image URLs dengan retry mechanism yang agresif
 func GetGoogleImages4(query string) ([]string, error) {
-	apiKey := config.GoogleAPIKey
-	cx := config.GoogleAPI_CX
-
-	if apiKey == "" || cx == "" {
-		return nil, fmt.Errorf("google API Key atau CX tidak ditemukan")
-	}
-
-	client := &http.Client{
-		Timeout: 8 * time.Second,
-	}
-	validImageURLs := []string{}
-	const (
-		targetCount = 4
-		maxAttempts = 10 // Increase attempts untuk dapat 4 gambar
-		baseFetch   = 5  // Fetch more images per attempt
-	)
-
-	for attempt := 0; attempt < maxAttempts && len(validImageURLs) < targetCount; attempt++ {
-		numToFetch := baseFetch + attempt
-		if numToFetch > 10 {
-			numToFetch = 10
-		}
-
-		// Use different start index to get variety
-		startIndex := 1 + (attempt * 3)
-		if startIndex > 90 {
-			startIndex = 1
-		}
-
-		items, err := fetchRawGoogleImages(query, apiKey, cx, numToFetch, startIndex)
-		if err != nil || len(items) < 1 {
-			continue // Try next attempt
-		}
-
-		links := make([]string, len(items))
-		for i, itm := range items {
-			links[i] = itm.Link
-		}
-		fmt.Printf("[Attempt %d] fetched %d links from start %d: %v\n", attempt+1, len(links), startIndex, links)
-
-		// Process all items, not just tail
-		for _, item := range items {
-			if validateImageURL(client, item.Link) {
-				dup := false
-				for _, u := range validImageURLs {
-					if u == item.Link {
-						dup = true
-						break
-					}
-				}
-				if !dup {
-					validImageURLs = append(validImageURLs, item.Link)
-					fmt.Printf("✅ Valid image %d: %s\n", len(validImageURLs), item.Link)
-					if len(validImageURLs) == targetCount {
-						break
-					}
-				}
-			} else {
-				fmt.Printf("❌ Invalid image rejected: %s\n", item.Link)
-			}
-		}
+	hits, err := collectGoogleImages(query, 4, 10, 5)
+	if err != nil {
+		return nil, err
 	}
+	return hits.URLs(), nil
+}
 
-	if len(validImageURLs) < targetCount {
-		fmt.Printf("⚠️ Only found %d valid images out of target %d after %d attempts\n", len(validImageURLs), targetCount, maxAttempts)
-		// Return what we have instead of error
-		if len(validImageURLs) == 0 {
-			return nil, fmt.Errorf("gagal menemukan gambar valid untuk '%s' setelah %d percobaan", query, maxAttempts)
-		}
+// return 3 image URLs dengan retry mechanism yang agresif, hasil di-cache per query
+func GetGoogleImages3(query string) ([]string, error) {
+	hits, err := googleImageHits3(query)
+	if err != nil {
+		return nil, err
 	}
-	return validImageURLs, nil
+	return hits.URLs(), nil
 }
 
-// return 3 image URLs dengan retry mechanism yang agresif
-func GetGoogleImages3(query string) ([]string, error) {
+func googleImageHits3(query string) (imageHits, error) {
 	// Mock logic: always mock if staging, or if production but disabled
 	if config.IsStaging || (config.IsProduction && !config.IsGoogleAPIEnabled) {
-		key := strings.ToLower(strings.TrimSpace(query))
-		if key == "" {
-			key = "kampus"
-		}
-		if images, ok := mockImageCatalog[key]; ok {
-			return images, nil
-		}
-		if images, ok := mockImageCatalog["kampus"]; ok {
-			return images, nil
-		}
-		return []string{}, nil
+		return hitsFromURLs(mockImagesFor(query)), nil
 	}
-
-	apiKey := config.GoogleAPIKey
-	cx := config.GoogleAPI_CX
-
-	if apiKey == "" || cx == "" {
-		return nil, fmt.Errorf("google API Key atau CX tidak ditemukan")
-	}
-
-	client := &http.Client{
-		Timeout: 8 * time.Second,
-	}
-	validImageURLs := []string{}
-	const (
-		targetCount = 3
-		maxAttempts = 10 // Increase attempts untuk dapat 3 gambar
-		baseFetch   = 5  // Fetch more images per attempt
-	)
-
-	for attempt := 0; attempt < maxAttempts && len(validImageURLs) < targetCount; attempt++ {
-		numToFetch := baseFetch + attempt
-		if numToFetch > 10 {
-			numToFetch = 10
-		}
-
-		// Use different start index to get variety
-		startIndex := 1 + (attempt * 3)
-		if startIndex > 90 {
-			startIndex = 1
-		}
-
-		items, err := fetchRawGoogleImages(query, apiKey, cx, numToFetch, startIndex)
-		if err != nil || len(items) < 1 {
-			continue // Try next attempt
-		}
-
-		links := make([]string, len(items))
-		for i, itm := range items {
-			links[i] = itm.Link
-		}
-		fmt.Printf("[Attempt %d] fetched %d links from start %d: %v\n", attempt+1, len(links), startIndex, links)
-
-		// Process all items, not just tail
-		for _, item := range items {
-			if validateImageURL(client, item.Link) {
-				dup := false
-				for _, u := range validImageURLs {
-					if u == item.Link {
-						dup = true
-						break
-					}
-				}
-				if !dup {
-					validImageURLs = append(validImageURLs, item.Link)
-					fmt.Printf("✅ Valid image %d: %s\n", len(validImageURLs), item.Link)
-					if len(validImageURLs) == targetCount {
-						break
-					}
-				}
-			} else {
-				fmt.Printf("❌ Invalid image rejected: %s\n", item.Link)
-			}
-		}
-	}
-
-	if len(validImageURLs) < targetCount {
-		fmt.Printf("⚠️ Only found %d valid images out of target %d after %d attempts\n", len(validImageURLs), targetCount, maxAttempts)
-		// Return what we have instead of error
-		if len(validImageURLs) == 0 {
-			return nil, fmt.Errorf("gagal menemukan gambar valid untuk '%s' setelah %d percobaan", query, maxAttempts)
-		}
-	}
-	return validImageURLs, nil
+	return cachedGoogleImages(query, func(q string) (imageHits, error) {
+		return collectGoogleImages(q, 3, 10, 5)
+	})
 }
 
 // Struct yang diperlukan untuk compatibility dengan system yang ada
@@ -453,25 +313,11 @@ func (s *GoogleImageService) SearchImagesForChat(ctx interface{}, searchTerm str
 	}
 
 	// Menggunakan GetGoogleImages3 untuk mendapatkan 3 gambar sesuai query
-	urls, err := GetGoogleImages3(term)
+	hits, err := googleImageHits3(term)
 	if err != nil {
 		return nil, err
 	}
-
-	// Convert ke format ImageSearchResult
-	results := make([]ImageSearchResult, len(urls))
-	for i, url := range urls {
-		results[i] = ImageSearchResult{
-			Title:        fmt.Sprintf("%s #%d", humanizeQuery(term), i+1),
-			ImageURL:     url,
-			ThumbnailURL: url,
-			SourceURL:    url,
-			Width:        800,
-			Height:       600,
-		}
-	}
-
-	return results, nil
+	return hits.results(term), nil
 }
 
 // SearchImages method untuk images controller - return 3 gambar
@@ -486,30 +332,37 @@ func (s *GoogleImageService) SearchImages(ctx interface{}, query string, maxResu
 	}
 
 	// Menggunakan GetGoogleImages3 untuk mendapatkan gambar sesuai query
-	urls, err := GetGoogleImages3(term)
+	hits, err := googleImageHits3(term)
 	if err != nil {
 		return nil, err
 	}
 
 	// Limit results jika diminta
-	if maxResults > 0 && len(urls) > maxResults {
-		urls = urls[:maxResults]
+	if maxResults > 0 && len(hits) > maxResults {
+		hits = hits[:maxResults]
 	}
+	return hits.results(term), nil
+}
 
-	// Convert ke format ImageSearchResult
-	results := make([]ImageSearchResult, len(urls))
-	for i, url := range urls {
+// results converts hits to ImageSearchResult, using Google's thumbnail so
+// clients don't have to download the full image for previews.
+func (h imageHits) results(term string) []ImageSearchResult {
+	results := make([]ImageSearchResult, len(h))
+	for i, hit := range h {
+		width, height := hit.Width, hit.Height
+		if width == 0 || height == 0 {
+			width, height = 800, 600
+		}
 		results[i] = ImageSearchResult{
 			Title:        fmt.Sprintf("%s #%d", humanizeQuery(term), i+1),
-			ImageURL:     url,
-			ThumbnailURL: url,
-			SourceURL:    url,
-			Width:        800,
-			Height:       600,
+			ImageURL:     hit.URL,
+			ThumbnailURL: hit.Thumbnail,
+			SourceURL:    hit.Source,
+			Width:        width,
+			Height:       height,
 		}
 	}
-
-	return results, nil
+	return results
 }
 
 func humanizeQuery(query string) string {
@@ -534,8 +387,11 @@ func humanizeQuery(query string) string {
 	return strings.Join(words, " ")
 }
 
-// ExtractSearchTermFromContext method untuk images controller
+// ExtractSearchTermFromContext method untuk images controller - ambil kata kunci dari pesan,
+// fallback ke UIB bila pesan tidak mengandung kata kunci yang berguna
 func (s *GoogleImageService) ExtractSearchTermFromContext(message string) string {
-	// Selalu return UIB query untuk konsistensi dengan example project
-	return "University International Batam"
+	if term := ExtractImageSearchTerm(message); term != "" {
+		return term
+	}
+	return "Universitas Internasional Batam"
 }
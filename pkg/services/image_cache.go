@@ -0,0 +1,128 @@
+package services
+
+import (
+	"AkuAI/pkg/cache"
+	"AkuAI/pkg/config"
+	"AkuAI/pkg/metrics"
+	"errors"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+)
+
+var ErrGoogleQuotaExhausted = errors.New("google custom search daily quota exhausted")
+
+// googleQuota counts Custom Search API calls per calendar day (UTC, matching
+// Google's reset) so the service can stop before requests start failing.
+type googleQuota struct {
+	mu   sync.Mutex
+	day  string
+	used int
+}
+
+var (
+	quota       googleQuota
+	imageFlight singleflight.Group
+
+	imageCacheHits   = metrics.NewCounter("image_cache_hits_total")
+	imageCacheMisses = metrics.NewCounter("image_cache_misses_total")
+	imageMockServed  = metrics.NewCounter("image_quota_fallback_total")
+)
+
+func init() {
+	metrics.RegisterFunc("google_images_quota", func() any { return GoogleQuotaUsage() })
+}
+
+// reserve takes one call from today's quota. A limit <= 0 means unlimited.
+func (q *googleQuota) reserve(limit int) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.rollover()
+	if limit > 0 && q.used >= limit {
+		return false
+	}
+	q.used++
+	return true
+}
+
+func (q *googleQuota) rollover() {
+	if today := time.Now().UTC().Format("2006-01-02"); q.day != today {
+		q.day, q.used = today, 0
+	}
+}
+
+// GoogleQuotaUsage reports today's Custom Search usage.
+func GoogleQuotaUsage() map[string]any {
+	quota.mu.Lock()
+	defer quota.mu.Unlock()
+	quota.rollover()
+	return map[string]any{
+		"day":       quota.day,
+		"used":      quota.used,
+		"limit":     config.GoogleAPIDailyQuota,
+		"exhausted": config.GoogleAPIDailyQuota > 0 && quota.used >= config.GoogleAPIDailyQuota,
+	}
+}
+
+func normalizeImageQuery(query string) string {
+	q := strings.Join(strings.Fields(strings.ToLower(query)), " ")
+	if q == "" {
+		q = "kampus"
+	}
+	return q
+}
+
+func imageCacheKey(query string) string {
+	return cache.NamespacedKey("images-v1", normalizeImageQuery(query))
+}
+
+// mockImagesFor returns catalog images for query, preferring the entry whose
+// name appears in the query, then the generic campus set.
+func mockImagesFor(query string) []string {
+	key := normalizeImageQuery(query)
+	if images, ok := mockImageCatalog[key]; ok {
+		return images
+	}
+	for name, images := range mockImageCatalog {
+		if name != "kampus" && strings.Contains(key, name) {
+			return images
+		}
+	}
+	if images, ok := mockImageCatalog["kampus"]; ok {
+		return images
+	}
+	return []string{}
+}
+
+// cachedGoogleImages serves validated image URLs from the shared cache,
+// coalesces concurrent fetches of the same query and falls back to the mock
+// catalog when the daily quota is exhausted.
+func cachedGoogleImages(query string, fetch func(string) (imageHits, error)) (imageHits, error) {
+	key := imageCacheKey(query)
+	if v, ok := cache.Default().Get(key); ok {
+		if hits, ok := v.(imageHits); ok && len(hits) > 0 {
+			imageCacheHits.Inc()
+			log.Printf("[images] cache HIT query=%q (%d urls)", normalizeImageQuery(query), len(hits))
+			return hits, nil
+		}
+	}
+	imageCacheMisses.Inc()
+
+	v, err, _ := imageFlight.Do(key, func() (any, error) {
+		hits, err := fetch(query)
+		if err == nil && len(hits) > 0 {
+			cache.Default().Set(key, hits, time.Duration(config.ImageCacheTTLSeconds)*time.Second)
+		}
+		return hits, err
+	})
+	hits, _ := v.(imageHits)
+	if errors.Is(err, ErrGoogleQuotaExhausted) && len(hits) == 0 {
+		imageMockServed.Inc()
+		log.Printf("[images] ⚠️ Google quota exhausted, serving mock catalog for %q", query)
+		return hitsFromURLs(mockImagesFor(query)), nil
+	}
+	return hits, err
+}
@@ -0,0 +1,132 @@
+package services
+
+import (
+	"AkuAI/pkg/config"
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// ImageIntent is the result of deciding whether a chat message asks for pictures.
+type ImageIntent struct {
+	Requested bool   `json:"requested"`
+	Term      string `json:"term"`   // what to search for, empty when only the intent is known
+	Source    string `json:"source"` // heuristic | gemini
+}
+
+// imageIntentPatterns capture the phrase after the trigger in the last group.
+var imageIntentPatterns = []*regexp.Regexp{
+	regexp.MustCompile(`(?i)\b(?:tampilkan|tunjukkan|tunjukin|perlihatkan|kirim(?:kan|in)?|carikan|cariin|cari|lihat|liat|minta|ada)\s+(?:dong\s+|aja\s+)?(?:gambar|foto|potret|image|picture)(?:-(?:gambar|foto))?(?:\s+(?:dari|tentang|untuk|soal))?\b(.*)`),
+	regexp.MustCompile(`(?i)\b(?:gambar|foto)(?:-(?:gambar|foto))?\s+((?:kampus|gedung|universitas|fasilitas|lokasi|suasana|ruang|perpustakaan|lab)\b.*)`),
+	regexp.MustCompile(`(?i)\b(?:show|send|find)\s+(?:me\s+)?(?:some\s+|a\s+)?(?:pictures?|photos?|images?)(?:\s+of)?\b(.*)`),
+	regexp.MustCompile(`(?i)\bseperti apa\s+(?:bentuk|tampilan|suasana|wujud)\b(.*)`),
+}
+
+// weakImageSignals hint at a visual request without matching a pattern; only
+// these messages are worth an extra Gemini call.
+var weakImageSignals = []string{"gambar", "foto", "visual", "penampakan", "picture", "photo"}
+
+var imageTermFillers = map[string]bool{
+	"dong": true, "ya": true, "yah": true, "nya": true, "deh": true, "sih": true, "aja": true, "saja": true,
+	"tolong": true, "please": true, "the": true, "of": true, "dari": true, "tentang": true, "soal": true,
+	"untuk": true, "yang": true, "ada": true, "di": true,
+}
+
+// DetectImageIntent recognises explicit picture requests such as
+// "tampilkan gambar kampus UIB" or "foto kampus" without calling Gemini.
+func DetectImageIntent(message string) ImageIntent {
+	for _, re := range imageIntentPatterns {
+		if m := re.FindStringSubmatch(message); m != nil {
+			return ImageIntent{Requested: true, Term: cleanImageTerm(m[len(m)-1]), Source: "heuristic"}
+		}
+	}
+	return ImageIntent{}
+}
+
+func cleanImageTerm(raw string) string {
+	raw = strings.TrimSpace(raw)
+	if idx := strings.IndexAny(raw, ".?!\n"); idx >= 0 {
+		raw = raw[:idx]
+	}
+	words := strings.FieldsFunc(raw, func(r rune) bool {
+		return r == ' ' || r == ',' || r == ';' || r == ':' || r == '"' || r == '\''
+	})
+	kept := words[:0]
+	for _, w := range words {
+		if !imageTermFillers[strings.ToLower(w)] {
+			kept = append(kept, w)
+		}
+	}
+	if len(kept) > 8 {
+		kept = kept[:8]
+	}
+	return strings.Join(kept, " ")
+}
+
+func hasWeakImageSignal(message string) bool {
+	lower := strings.ToLower(message)
+	for _, s := range weakImageSignals {
+		if strings.Contains(lower, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// DetectImageIntent uses the heuristic first and asks Gemini only for
+// ambiguous messages that mention pictures without an explicit request.
+func (s *GeminiService) DetectImageIntent(ctx context.Context, message string) ImageIntent {
+	if intent := DetectImageIntent(message); intent.Requested || !hasWeakImageSignal(message) {
+		return intent
+	}
+	if !config.ImageIntentGemini || !s.enabled || strings.TrimSpace(s.apiKey) == "" {
+		return ImageIntent{}
+	}
+	if config.IsStaging || (config.IsProduction && !config.IsGeminiEnabled) {
+		return ImageIntent{}
+	}
+
+	prompt := fmt.Sprintf(`Apakah pesan pengguna berikut meminta untuk ditampilkan gambar/foto? Jika ya, tentukan kata kunci pencarian gambar yang paling tepat (nama tempat/universitas/objek).
+
+Balas dalam format JSON satu baris:
+{"images": true, "term": "kata kunci"}
+atau
+{"images": false, "term": ""}
+
+Pesan: %s`, message)
+
+	ctx, cancel := context.WithTimeout(ctx, 8*time.Second)
+	defer cancel()
+	for _, model := range []string{config.GeminiModel, "gemini-2.0-flash"} {
+		if strings.TrimSpace(model) == "" {
+			continue
+		}
+		response, err := s.callGenerateContent(ctx, model, prompt)
+		if err != nil {
+			continue
+		}
+		stripped := strings.Trim(strings.TrimSpace(response), "`")
+		start, end := strings.Index(stripped, "{"), strings.LastIndex(stripped, "}")
+		if start < 0 || end <= start {
+			continue
+		}
+		var payload struct {
+			Images bool   `json:"images"`
+			Term   string `json:"term"`
+		}
+		if err := json.Unmarshal([]byte(stripped[start:end+1]), &payload); err != nil {
+			continue
+		}
+		return ImageIntent{Requested: payload.Images, Term: cleanImageTerm(payload.Term), Source: "gemini"}
+	}
+	return ImageIntent{}
+}
+
+// ExtractUniversityName returns the university named in text, if any, using
+// the alias table and pattern matching only.
+func ExtractUniversityName(text string) string {
+	return extractUniversityHeuristic(text)
+}
@@ -0,0 +1,229 @@
+package services
+
+import (
+	"AkuAI/pkg/config"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// googleImageItem is one Custom Search image result. Image carries Google's own
+// thumbnail, which is always reachable and far smaller than the original.
+type googleImageItem struct {
+	Link  string `json:"link"`
+	Mime  string `json:"mime"`
+	Image struct {
+		ContextLink   string `json:"contextLink"`
+		ThumbnailLink string `json:"thumbnailLink"`
+		Width         int    `json:"width"`
+		Height        int    `json:"height"`
+	} `json:"image"`
+}
+
+// imageHit is a validated image with the thumbnail clients should render first.
+type imageHit struct {
+	URL       string
+	Thumbnail string
+	Source    string
+	Width     int
+	Height    int
+}
+
+type imageHits []imageHit
+
+func (h imageHits) URLs() []string {
+	urls := make([]string, len(h))
+	for i, hit := range h {
+		urls[i] = hit.URL
+	}
+	return urls
+}
+
+func (h imageHits) CacheSize() int {
+	n := 0
+	for _, hit := range h {
+		n += len(hit.URL) + len(hit.Thumbnail) + len(hit.Source) + 16
+	}
+	return n
+}
+
+func hitsFromURLs(urls []string) imageHits {
+	hits := make(imageHits, len(urls))
+	for i, u := range urls {
+		hits[i] = imageHit{URL: u, Thumbnail: u, Source: u, Width: 800, Height: 600}
+	}
+	return hits
+}
+
+func newValidationClient() *http.Client {
+	return &http.Client{
+		Timeout: 8 * time.Second,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
+// probeImageURL checks imageURL with a HEAD request, falling back to a ranged
+// GET of the first 512 bytes for hosts that reject HEAD.
+func probeImageURL(ctx context.Context, client *http.Client, imageURL string) bool {
+	if !(strings.HasPrefix(imageURL, "http://") || strings.HasPrefix(imageURL, "https://")) {
+		return false
+	}
+	if req, err := http.NewRequestWithContext(ctx, http.MethodHead, imageURL, nil); err == nil {
+		if res, err := client.Do(req); err == nil {
+			res.Body.Close()
+			if res.StatusCode == http.StatusOK && strings.HasPrefix(res.Header.Get("Content-Type"), "image/") {
+				return true
+			}
+			if res.StatusCode == http.StatusNotFound || res.StatusCode == http.StatusGone {
+				return false
+			}
+		}
+	}
+	if ctx.Err() != nil {
+		return false
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, imageURL, nil)
+	if err != nil {
+		return false
+	}
+	req.Header.Set("Range", "bytes=0-511")
+	resp, err := client.Do(req)
+	if err != nil {
+		return false
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusPartialContent {
+		return false
+	}
+	buf := make([]byte, 512)
+	n, _ := io.ReadFull(resp.Body, buf)
+	return strings.HasPrefix(http.DetectContentType(buf[:n]), "image/")
+}
+
+// validateImageItems probes items concurrently with at most workers requests
+// in flight and returns the valid ones in their original (relevance) order.
+func validateImageItems(ctx context.Context, client *http.Client, items []googleImageItem, workers int) []googleImageItem {
+	if workers < 1 {
+		workers = 1
+	}
+	ok := make([]bool, len(items))
+	idx := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers && w < len(items); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range idx {
+				ok[i] = probeImageURL(ctx, client, items[i].Link)
+			}
+		}()
+	}
+feed:
+	for i := range items {
+		select {
+		case idx <- i:
+		case <-ctx.Done():
+			break feed
+		}
+	}
+	close(idx)
+	wg.Wait()
+
+	valid := make([]googleImageItem, 0, len(items))
+	for i, item := range items {
+		if ok[i] {
+			valid = append(valid, item)
+		} else {
+			fmt.Printf("❌ Invalid image rejected: %s\n", item.Link)
+		}
+	}
+	return valid
+}
+
+// collectGoogleImages pages through Custom Search until targetCount images
+// validate, maxAttempts pages were fetched, or the validation deadline passes.
+func collectGoogleImages(query string, targetCount, maxAttempts, baseFetch int) (imageHits, error) {
+	apiKey := config.GoogleAPIKey
+	cx := config.GoogleAPI_CX
+
+	if apiKey == "" || cx == "" {
+		return nil, fmt.Errorf("google API Key atau CX tidak ditemukan")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(config.ImageValidateDeadlineSeconds)*time.Second)
+	defer cancel()
+
+	client := newValidationClient()
+	hits := imageHits{}
+	seen := map[string]bool{}
+
+	for attempt := 0; attempt < maxAttempts && len(hits) < targetCount && ctx.Err() == nil; attempt++ {
+		numToFetch := baseFetch + attempt
+		if numToFetch > 10 {
+			numToFetch = 10
+		}
+
+		// Use different start index to get variety
+		startIndex := 1 + (attempt * 3)
+		if startIndex > 90 {
+			startIndex = 1
+		}
+
+		items, err := fetchRawGoogleImages(query, apiKey, cx, numToFetch, startIndex)
+		if errors.Is(err, ErrGoogleQuotaExhausted) {
+			// Stop burning attempts; return what was validated so far
+			if len(hits) == 0 {
+				return nil, err
+			}
+			break
+		}
+		if err != nil || len(items) < 1 {
+			continue // Try next attempt
+		}
+
+		fresh := items[:0]
+		for _, item := range items {
+			if !seen[item.Link] {
+				seen[item.Link] = true
+				fresh = append(fresh, item)
+			}
+		}
+		fmt.Printf("[Attempt %d] fetched %d links from start %d, %d new\n", attempt+1, len(items), startIndex, len(fresh))
+
+		for _, item := range validateImageItems(ctx, client, fresh, config.ImageValidateWorkers) {
+			hit := imageHit{URL: item.Link, Thumbnail: item.Image.ThumbnailLink, Source: item.Image.ContextLink,
+				Width: item.Image.Width, Height: item.Image.Height}
+			if hit.Thumbnail == "" {
+				hit.Thumbnail = hit.URL
+			}
+			if hit.Source == "" {
+				hit.Source = hit.URL
+			}
+			hits = append(hits, hit)
+			fmt.Printf("✅ Valid image %d: %s\n", len(hits), item.Link)
+			if len(hits) == targetCount {
+				break
+			}
+		}
+	}
+
+	if len(hits) < targetCount {
+		if ctx.Err() != nil {
+			log.Printf("[images] ⏱️ validation deadline reached for %q with %d/%d images", query, len(hits), targetCount)
+		}
+		fmt.Printf("⚠️ Only found %d valid images out of target %d after %d attempts\n", len(hits), targetCount, maxAttempts)
+		// Return what we have instead of error
+		if len(hits) == 0 {
+			return nil, fmt.Errorf("gagal menemukan gambar valid untuk '%s' setelah %d percobaan", query, maxAttempts)
+		}
+	}
+	return hits, nil
+}
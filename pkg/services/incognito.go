@@ -0,0 +1,44 @@
+package services
+
+import "context"
+
+type incognitoKey struct{}
+
+// WithIncognito marks ctx as serving an incognito conversation when on:
+// its replies skip the reply caches and the prompt logs redact its content.
+func WithIncognito(ctx context.Context, on bool) context.Context {
+	if !on {
+		return ctx
+	}
+	return context.WithValue(ctx, incognitoKey{}, true)
+}
+
+// IsIncognito reports whether ctx serves an incognito conversation.
+func IsIncognito(ctx context.Context) bool {
+	on, _ := ctx.Value(incognitoKey{}).(bool)
+	return on
+}
+
+// Redact returns s for logging, or "[redacted]" for incognito requests.
+func Redact(ctx context.Context, s string) string {
+	if IsIncognito(ctx) {
+		return "[redacted]"
+	}
+	return s
+}
+
+// promptLogContent are the prompt log fields that carry what the user wrote.
+var promptLogContent = []string{"question", "latest_user_question", "prompt", "system_instruction", "context_snapshot"}
+
+// redactPromptLog blanks the content of a prompt log entry of an incognito
+// request, keeping its hashes and counts.
+func redactPromptLog(ctx context.Context, entry map[string]any) {
+	if !IsIncognito(ctx) {
+		return
+	}
+	for _, k := range promptLogContent {
+		if _, ok := entry[k]; ok {
+			entry[k] = "[redacted]"
+		}
+	}
+}
@@ -0,0 +1,126 @@
+package services
+
+import (
+	"AkuAI/models"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// localAnswerLimit is how many events a local answer lists in full.
+const localAnswerLimit = 15
+
+var indonesianMonths = [...]string{"Januari", "Februari", "Maret", "April", "Mei", "Juni", "Juli", "Agustus",
+	"September", "Oktober", "November", "Desember"}
+
+// IndonesianDate renders "2025-11-12" as "12 November 2025", or returns
+// date unchanged when it doesn't parse.
+func IndonesianDate(date string) string {
+	t, err := time.Parse("2006-01-02", date)
+	if err != nil {
+		return date
+	}
+	return fmt.Sprintf("%d %s %d", t.Day(), indonesianMonths[t.Month()-1], t.Year())
+}
+
+// eventKind names the event type of a query in the heading of a local answer.
+func eventKind(queryLower string) string {
+	if types := detectEventTypes(queryLower); len(types) == 1 {
+		return EventTypeLabel(types[0])
+	}
+	return "acara"
+}
+
+// monthsLabel lists the months of events, in date order: "November 2025"
+// or "Oktober 2025 dan November 2025".
+func monthsLabel(events []models.UIBEvent) string {
+	var months []string
+	for _, ev := range events {
+		t, err := time.Parse("2006-01-02", ev.Date)
+		if err != nil {
+			continue
+		}
+		m := indonesianMonths[t.Month()-1] + " " + fmt.Sprint(t.Year())
+		if len(months) == 0 || months[len(months)-1] != m {
+			months = append(months, m)
+		}
+	}
+	switch len(months) {
+	case 0:
+		return ""
+	case 1:
+		return months[0]
+	}
+	return strings.Join(months[:len(months)-1], ", ") + " dan " + months[len(months)-1]
+}
+
+// matchingFilters keeps the events of the months, types and departments the
+// query names.
+// GetRelevantEventsForQuery falls back to the upcoming events when nothing
+// matches, which a local answer must not present as the requested ones.
+func (s *UIBEventService) matchingFilters(events []models.UIBEvent, queryLower string) []models.UIBEvent {
+	months, types, units := s.monthPrefixes(queryLower), detectEventTypes(queryLower), s.unitsInQuery(queryLower)
+	out := make([]models.UIBEvent, 0, len(events))
+	for _, ev := range events {
+		if len(months) > 0 && (len(ev.Date) < 7 || !months[ev.Date[:7]]) {
+			continue
+		}
+		if !eventTypeWanted(types, ev.Type) || !inUnits(units, ev) {
+			continue
+		}
+		out = append(out, ev)
+	}
+	return out
+}
+
+// LocalAnswer answers an event question from the dataset alone, for when
+// Gemini is unavailable: the events matching the question's month and type
+// filters in the format the prompts ask Gemini for, each with its citation
+// marker. ok is false when the question isn't about events.
+func (s *UIBEventService) LocalAnswer(question string) (answer string, ok bool) {
+	if s == nil || !s.AnalyzeQueryForUIB(question) {
+		return "", false
+	}
+	lower := strings.ToLower(question)
+	kind := eventKind(lower)
+	events := s.matchingFilters(s.GetRelevantEventsForQuery(question), lower)
+	sort.SliceStable(events, func(i, j int) bool { return events[i].Date < events[j].Date })
+
+	var b strings.Builder
+	if len(events) == 0 {
+		fmt.Fprintf(&b, "Belum ada %s UIB yang cocok dengan pertanyaan Anda dalam data resmi UIB (UIB_OFFICIAL).\n", kind)
+		if upcoming := s.GetUpcomingEvents(); len(upcoming) > 0 {
+			events = upcoming
+			kind = "acara"
+			b.WriteString("\nSebagai gantinya, berikut acara UIB yang akan datang:\n")
+		}
+	} else {
+		fmt.Fprintf(&b, "Berikut %s UIB untuk %s (Data resmi UIB_OFFICIAL):\n", kind, monthsLabel(events))
+	}
+	for i, ev := range events {
+		if i == localAnswerLimit {
+			fmt.Fprintf(&b, "\n…dan %d %s lainnya. Sebutkan bulan atau jenis acara untuk mempersempit daftar.\n", len(events)-i, kind)
+			break
+		}
+		fmt.Fprintf(&b, "\n%d. **%s** - 📅 %s", i+1, ev.Title, IndonesianDate(ev.Date))
+		if ev.Time != "" {
+			b.WriteString(", ⏰ " + ev.Time)
+		}
+		if ev.Location != "" {
+			b.WriteString(" - 📍 " + ev.Location)
+		} else if ev.Platform != "" {
+			b.WriteString(" - 💻 " + ev.Platform)
+		}
+		if ev.RegistrationFee != "" {
+			b.WriteString(" - 💰 " + ev.FeeText())
+		}
+		if ev.Contact != "" {
+			b.WriteString(" - 📞 " + ev.Contact)
+		}
+		fmt.Fprintf(&b, " [%s]", CitationMarker(ev.ID))
+	}
+	b.WriteString("\n\n📞 Kontak Umum UIB: info@uib.ac.id\n🌐 Website: https://uib.ac.id\n")
+	b.WriteString("\n_Asisten AI sedang tidak tersedia; jawaban ini disusun langsung dari data acara resmi UIB._")
+	return s.Localize(b.String()), true
+}
@@ -0,0 +1,43 @@
+package services
+
+import (
+	"AkuAI/models"
+	"strings"
+	"testing"
+)
+
+func TestLocalAnswer(t *testing.T) {
+	data := &models.UIBEventsData{}
+	data.UIBEvents.October2025 = []models.UIBEvent{
+		{ID: "web_oct_001", Type: "webinar", Title: "Webinar Data Science untuk Pemula", Date: "2025-10-02", Platform: "Zoom"},
+	}
+	data.UIBEvents.November2025 = []models.UIBEvent{
+		{ID: "cert_nov_001", Type: "certification", Title: "Sertifikasi Cloud Practitioner", Date: "2025-11-12", Time: "09:00",
+			Location: "Gedung A", RegistrationFee: "Rp 500.000", Contact: "cloud@uib.ac.id"},
+		{ID: "web_nov_001", Type: "webinar", Title: "Webinar Data Science Lanjutan", Date: "2025-11-20"},
+	}
+	s := &UIBEventService{eventsData: data}
+
+	got, ok := s.LocalAnswer("sertifikasi bulan november apa saja?")
+	if !ok {
+		t.Fatal("event question not answered locally")
+	}
+	for _, want := range []string{"Berikut sertifikasi UIB untuk November 2025", "**Sertifikasi Cloud Practitioner** - 📅 12 November 2025, ⏰ 09:00",
+		"📍 Gedung A", "💰 Rp 500.000", "[EV-CERT-NOV-001]"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("answer lacks %q:\n%s", want, got)
+		}
+	}
+	if strings.Contains(got, "Webinar") {
+		t.Errorf("type filter ignored:\n%s", got)
+	}
+
+	got, _ = s.LocalAnswer("webinar desember ada?")
+	if !strings.HasPrefix(got, "Belum ada webinar UIB") || strings.Contains(got, "Data Science") {
+		t.Errorf("empty month:\n%s", got)
+	}
+
+	if _, ok := s.LocalAnswer("apa saja jurusan di fakultas hukum?"); ok {
+		t.Error("non-event question answered from event data")
+	}
+}
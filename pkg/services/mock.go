@@ -8,10 +8,15 @@ import (
 	"time"
 )
 
+// AskCampusWithChatLocal answers without Gemini. Event questions are
+// answered from the event data of the campus they name (see LocalAnswer);
+// anything else gets a generic outline.
 func AskCampusWithChatLocal(ctx context.Context, chat []ChatMessage) string {
-	var last string
-	if len(chat) > 0 {
-		last = strings.TrimSpace(chat[len(chat)-1].Text)
+	last := strings.TrimSpace(lastUserText(chat))
+	if _, ds := defaultCampusData().ForQuery(last); ds != nil && last != "" {
+		if answer, ok := ds.LocalAnswer(last); ok {
+			return answer
+		}
 	}
 	if last == "" {
 		last = "pertanyaan Anda"
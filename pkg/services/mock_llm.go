@@ -0,0 +1,183 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/goccy/go-yaml"
+)
+
+// ErrNoFixture is returned by MockLLM when no fixture matches a query and the
+// fixture files define no default response.
+var ErrNoFixture = errors.New("mock llm: no fixture matches query")
+
+// MockFixture maps a query to a canned response. A fixture matches when the
+// lower-cased query contains every word of Match, or when Pattern matches it.
+type MockFixture struct {
+	Name      string `yaml:"name"`
+	Match     string `yaml:"match"`
+	Pattern   string `yaml:"pattern"`
+	Response  string `yaml:"response"`
+	LatencyMs int    `yaml:"latency_ms"`
+	Error     string `yaml:"error"`      // returned instead of Response
+	FailTimes int    `yaml:"fail_times"` // with Error: fail only the first N calls, then respond
+
+	re    *regexp.Regexp
+	words []string
+}
+
+type mockFixtureFile struct {
+	Default   string        `yaml:"default"`
+	LatencyMs int           `yaml:"latency_ms"`
+	Fixtures  []MockFixture `yaml:"fixtures"`
+}
+
+// MockLLM answers Gemini calls from YAML fixture files so handler and abtest
+// runs are deterministic and need no API key. Files are read in name order
+// and the first matching fixture wins:
+//
+//	default: "Maaf, data tidak tersedia."
+//	fixtures:
+//	  - match: "webinar november"
+//	    response: "Berikut webinar UIB untuk November: ..."
+//	    latency_ms: 200
+//	  - pattern: "(?i)sertifikasi"
+//	    error: "upstream timeout"
+//	    fail_times: 1
+type MockLLM struct {
+	fixtures  []MockFixture
+	def       string
+	latencyMs int
+
+	mu    sync.Mutex
+	calls map[int]int // fixture index -> times matched
+}
+
+// NewMockLLM loads every *.yaml / *.yml file in dir.
+func NewMockLLM(dir string) (*MockLLM, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.y*ml"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(paths)
+	m := &MockLLM{calls: map[int]int{}}
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		// Fixtures edited on Windows would otherwise keep \r in block responses
+		data = bytes.ReplaceAll(data, []byte("\r\n"), []byte("\n"))
+		var file mockFixtureFile
+		if err := yaml.Unmarshal(data, &file); err != nil {
+			return nil, fmt.Errorf("%s: %w", filepath.Base(path), err)
+		}
+		if m.def == "" {
+			m.def, m.latencyMs = file.Default, file.LatencyMs
+		}
+		for i, f := range file.Fixtures {
+			if f.Pattern != "" {
+				if f.re, err = regexp.Compile(f.Pattern); err != nil {
+					return nil, fmt.Errorf("%s fixture %d: %w", filepath.Base(path), i, err)
+				}
+			}
+			f.words = strings.Fields(strings.ToLower(f.Match))
+			if f.re == nil && len(f.words) == 0 {
+				return nil, fmt.Errorf("%s fixture %d: match or pattern is required", filepath.Base(path), i)
+			}
+			if f.LatencyMs == 0 {
+				f.LatencyMs = file.LatencyMs
+			}
+			m.fixtures = append(m.fixtures, f)
+		}
+	}
+	if len(m.fixtures) == 0 && m.def == "" {
+		return nil, fmt.Errorf("no fixtures found in %s", dir)
+	}
+	return m, nil
+}
+
+func (f *MockFixture) matches(query string) bool {
+	if f.re != nil {
+		return f.re.MatchString(query)
+	}
+	lower := strings.ToLower(query)
+	for _, w := range f.words {
+		if !strings.Contains(lower, w) {
+			return false
+		}
+	}
+	return true
+}
+
+// Respond returns the response of the first fixture matching query, after
+// its latency has passed.
+func (m *MockLLM) Respond(ctx context.Context, query string) (string, error) {
+	for i := range m.fixtures {
+		f := &m.fixtures[i]
+		if !f.matches(query) {
+			continue
+		}
+		m.mu.Lock()
+		m.calls[i]++
+		n := m.calls[i]
+		m.mu.Unlock()
+
+		sleepWithContext(ctx, time.Duration(f.LatencyMs)*time.Millisecond)
+		if err := ctx.Err(); err != nil {
+			return "", err
+		}
+		if f.Error != "" && (f.FailTimes == 0 || n <= f.FailTimes) {
+			return "", errors.New(f.Error)
+		}
+		return f.Response, nil
+	}
+	if m.def == "" {
+		return "", ErrNoFixture
+	}
+	sleepWithContext(ctx, time.Duration(m.latencyMs)*time.Millisecond)
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+	return m.def, nil
+}
+
+// mockReply answers question from the fixture MockLLM when MOCK_LLM_FIXTURES
+// is configured; ok is false when the real model should be called.
+func (s *GeminiService) mockReply(ctx context.Context, question string, onDelta func(string)) (text string, ok bool, err error) {
+	if s.mock == nil {
+		return "", false, nil
+	}
+	text, err = s.mock.Respond(ctx, question)
+	if err != nil {
+		log.Printf("[gemini] MOCK LLM: %v", err)
+		return "", true, err
+	}
+	recordCandidate(ctx, "mock", map[string]any{"finishReason": "STOP"})
+	if onDelta != nil {
+		runes := []rune(text)
+		for i := 0; i < len(runes); i += 28 {
+			onDelta(string(runes[i:min(i+28, len(runes))]))
+		}
+	}
+	return text, true, nil
+}
+
+func lastUserText(chat []ChatMessage) string {
+	for i := len(chat) - 1; i >= 0; i-- {
+		if strings.EqualFold(strings.TrimSpace(chat[i].Role), "user") {
+			return chat[i].Text
+		}
+	}
+	return ""
+}
@@ -0,0 +1,66 @@
+package services
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeFixtures(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, body := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(body), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestMockLLMMatchesFirstFixture(t *testing.T) {
+	dir := writeFixtures(t, map[string]string{
+		"a.yaml": "fixtures:\n  - match: \"webinar november\"\n    response: \"A\"\n",
+		"b.yaml": "default: \"fallback\"\nfixtures:\n  - pattern: \"(?i)webinar\"\n    response: \"B\"\n",
+	})
+	m, err := NewMockLLM(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cases := map[string]string{
+		"Ada Webinar apa di bulan November?": "A",
+		"webinar minggu depan":               "B",
+		"jadwal wisuda":                      "fallback",
+	}
+	for q, want := range cases {
+		if got, err := m.Respond(context.Background(), q); err != nil || got != want {
+			t.Errorf("Respond(%q) = %q, %v; want %q", q, got, err, want)
+		}
+	}
+}
+
+func TestMockLLMErrorInjection(t *testing.T) {
+	dir := writeFixtures(t, map[string]string{
+		"f.yaml": "fixtures:\n  - match: kontak\n    error: boom\n    fail_times: 1\n    response: ok\n",
+	})
+	m, err := NewMockLLM(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.Respond(context.Background(), "kontak UIB"); err == nil || err.Error() != "boom" {
+		t.Fatalf("first call err = %v, want boom", err)
+	}
+	if got, err := m.Respond(context.Background(), "kontak UIB"); err != nil || got != "ok" {
+		t.Fatalf("second call = %q, %v; want ok", got, err)
+	}
+	if _, err := m.Respond(context.Background(), "jadwal"); err != ErrNoFixture {
+		t.Fatalf("unmatched err = %v, want ErrNoFixture", err)
+	}
+}
+
+func TestMockLLMRejectsEmptyFixture(t *testing.T) {
+	dir := writeFixtures(t, map[string]string{"f.yaml": "fixtures:\n  - response: x\n"})
+	if _, err := NewMockLLM(dir); err == nil {
+		t.Fatal("expected error for fixture without match or pattern")
+	}
+}
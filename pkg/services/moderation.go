@@ -0,0 +1,92 @@
+package services
+
+import (
+	"AkuAI/pkg/config"
+	"AkuAI/pkg/moderation"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// CheckSafety asks Gemini to rate text without generating a real answer and
+// maps blocked prompts and HIGH/MEDIUM safety ratings to a moderation verdict.
+func (s *GeminiService) CheckSafety(ctx context.Context, text string) (moderation.Verdict, error) {
+	allow := moderation.Verdict{Action: moderation.Allow}
+	if config.IsStaging || (config.IsProduction && !config.IsGeminiEnabled) || !s.enabled || strings.TrimSpace(s.apiKey) == "" {
+		return allow, ErrGeminiDisabled
+	}
+
+	reqBody := map[string]any{
+		"contents": []any{map[string]any{"role": "user", "parts": []any{map[string]any{"text": text}}}},
+		"safetySettings": []any{
+			map[string]any{"category": "HARM_CATEGORY_HARASSMENT", "threshold": "BLOCK_MEDIUM_AND_ABOVE"},
+			map[string]any{"category": "HARM_CATEGORY_HATE_SPEECH", "threshold": "BLOCK_MEDIUM_AND_ABOVE"},
+			map[string]any{"category": "HARM_CATEGORY_SEXUALLY_EXPLICIT", "threshold": "BLOCK_MEDIUM_AND_ABOVE"},
+			map[string]any{"category": "HARM_CATEGORY_DANGEROUS_CONTENT", "threshold": "BLOCK_MEDIUM_AND_ABOVE"},
+		},
+		"generationConfig": map[string]any{"maxOutputTokens": 1, "temperature": 0},
+	}
+	bodyBytes, _ := json.Marshal(reqBody)
+	url := fmt.Sprintf("https://generativelanguage.googleapis.com/v1beta/models/%s:generateContent?key=%s", config.GeminiModel, s.apiKey)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(bodyBytes))
+	if err != nil {
+		return allow, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return allow, fmt.Errorf("http error: %w", err)
+	}
+	defer resp.Body.Close()
+	respBytes, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return allow, fmt.Errorf("status %d: %s", resp.StatusCode, strings.TrimSpace(string(respBytes)))
+	}
+
+	var parsed struct {
+		PromptFeedback struct {
+			BlockReason   string         `json:"blockReason"`
+			SafetyRatings []safetyRating `json:"safetyRatings"`
+		} `json:"promptFeedback"`
+	}
+	if err := json.Unmarshal(respBytes, &parsed); err != nil {
+		return allow, err
+	}
+	fb := parsed.PromptFeedback
+	worst := worstRating(fb.SafetyRatings)
+	switch {
+	case fb.BlockReason != "" || worst.Probability == "HIGH":
+		return moderation.Verdict{Action: moderation.Block, Category: worst.category(fb.BlockReason), Source: "gemini"}, nil
+	case worst.Probability == "MEDIUM":
+		return moderation.Verdict{Action: moderation.Flag, Category: worst.category(""), Source: "gemini"}, nil
+	}
+	return allow, nil
+}
+
+type safetyRating struct {
+	Category    string `json:"category"`
+	Probability string `json:"probability"` // NEGLIGIBLE | LOW | MEDIUM | HIGH
+}
+
+func (r safetyRating) category(fallback string) string {
+	if r.Category == "" {
+		return strings.ToLower(fallback)
+	}
+	return strings.ToLower(strings.TrimPrefix(r.Category, "HARM_CATEGORY_"))
+}
+
+func worstRating(ratings []safetyRating) safetyRating {
+	rank := map[string]int{"NEGLIGIBLE": 0, "LOW": 1, "MEDIUM": 2, "HIGH": 3}
+	var worst safetyRating
+	for _, r := range ratings {
+		if worst.Probability == "" || rank[r.Probability] > rank[worst.Probability] {
+			worst = r
+		}
+	}
+	return worst
+}
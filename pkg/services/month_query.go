@@ -0,0 +1,169 @@
+package services
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// monthRef is a month a query mentions; Year is 0 when it names none.
+type monthRef struct {
+	Month time.Month
+	Year  int
+}
+
+// monthNames maps month names and abbreviations, Indonesian and English,
+// to months. "may" and "mar" are left out: they are common words.
+var monthNames = map[string]time.Month{
+	"januari": time.January, "january": time.January, "jan": time.January,
+	"februari": time.February, "february": time.February, "feb": time.February, "peb": time.February,
+	"maret": time.March, "march": time.March,
+	"april": time.April, "apr": time.April,
+	"mei":  time.May,
+	"juni": time.June, "june": time.June, "jun": time.June,
+	"juli": time.July, "july": time.July, "jul": time.July,
+	"agustus": time.August, "august": time.August, "agu": time.August, "agt": time.August, "aug": time.August,
+	"september": time.September, "sept": time.September, "sep": time.September,
+	"oktober": time.October, "october": time.October, "okt": time.October, "oct": time.October,
+	"november": time.November, "nov": time.November,
+	"desember": time.December, "december": time.December, "des": time.December, "dec": time.December,
+}
+
+var (
+	// 2025-11 and 2025-11-12
+	isoMonthRe = regexp.MustCompile(`\b(20\d{2})-(\d{1,2})(?:-\d{1,2})?\b`)
+	// 11/2025, 12/11/2025, 12-11-2025 and 12.11.2025
+	dmyMonthRe = regexp.MustCompile(`\b(?:\d{1,2}[/.-])?(\d{1,2})[/.-](20\d{2})\b`)
+)
+
+func parseYear(tok string) int {
+	if len(tok) != 4 || !strings.HasPrefix(tok, "20") {
+		return 0
+	}
+	y, err := strconv.Atoi(tok)
+	if err != nil {
+		return 0
+	}
+	return y
+}
+
+func parseMonthNumber(tok string) time.Month {
+	n, err := strconv.Atoi(tok)
+	if err != nil || len(tok) > 2 || n < 1 || n > 12 {
+		return 0
+	}
+	return time.Month(n)
+}
+
+// parseMonthRefs finds the months queryLower asks about: month names
+// ("november", "nov 2025"), numbers after "bulan"/"bln" ("bulan 11"), a
+// number followed by a year ("11 2025") and dates ("12/11/2025",
+// "2025-11"). A bare number is not a month, so "ruang 11" or "11 orang"
+// match nothing.
+func parseMonthRefs(queryLower string) []monthRef {
+	var refs []monthRef
+	add := func(m time.Month, y int) {
+		if r := (monthRef{Month: m, Year: y}); !slices.Contains(refs, r) {
+			refs = append(refs, r)
+		}
+	}
+
+	rest := queryLower
+	for _, re := range []*regexp.Regexp{isoMonthRe, dmyMonthRe} {
+		for _, m := range re.FindAllStringSubmatch(rest, -1) {
+			month, year := m[2], m[1]
+			if re == dmyMonthRe {
+				month, year = m[1], m[2]
+			}
+			if mo := parseMonthNumber(month); mo != 0 {
+				add(mo, parseYear(year))
+			}
+		}
+		rest = re.ReplaceAllString(rest, " ")
+	}
+
+	tokens := strings.FieldsFunc(rest, func(r rune) bool { return !unicode.IsLetter(r) && !unicode.IsDigit(r) })
+	yearAfter := func(i int) int {
+		if i+1 < len(tokens) {
+			return parseYear(tokens[i+1])
+		}
+		return 0
+	}
+	for i, tok := range tokens {
+		if m, ok := monthNames[tok]; ok {
+			add(m, yearAfter(i))
+			continue
+		}
+		if (tok == "bulan" || tok == "bln" || tok == "month") && i+1 < len(tokens) {
+			if m := parseMonthNumber(tokens[i+1]); m != 0 {
+				add(m, yearAfter(i+1))
+			}
+			continue
+		}
+		if m := parseMonthNumber(tok); m != 0 && yearAfter(i) != 0 {
+			add(m, yearAfter(i))
+		}
+	}
+	return refs
+}
+
+// eventMonths is the set of "YYYY-MM" months the dataset has events in.
+func (s *UIBEventService) eventMonths() map[string]bool {
+	months := make(map[string]bool)
+	for _, ev := range s.GetAllEvents() {
+		if len(ev.Date) >= 7 {
+			months[ev.Date[:7]] = true
+		}
+	}
+	return months
+}
+
+// monthPrefixes resolves the months queryLower asks about to "YYYY-MM"
+// date prefixes. A month without a year is taken in every year the dataset
+// has events in that month, else at its next occurrence from now.
+func (s *UIBEventService) monthPrefixes(queryLower string) map[string]bool {
+	refs := parseMonthRefs(queryLower)
+	result := make(map[string]bool, len(refs))
+	if len(refs) == 0 {
+		return result
+	}
+	have := s.eventMonths()
+	for _, r := range refs {
+		if r.Year != 0 {
+			result[fmt.Sprintf("%04d-%02d", r.Year, r.Month)] = true
+			continue
+		}
+		suffix := fmt.Sprintf("-%02d", r.Month)
+		found := false
+		for m := range have {
+			if strings.HasSuffix(m, suffix) {
+				result[m], found = true, true
+			}
+		}
+		if !found {
+			now := time.Now()
+			y := now.Year()
+			if r.Month < now.Month() {
+				y++
+			}
+			result[fmt.Sprintf("%04d%s", y, suffix)] = true
+		}
+	}
+	return result
+}
+
+// asksAboutEventMonth reports whether queryLower names a month the dataset
+// has events in.
+func (s *UIBEventService) asksAboutEventMonth(queryLower string) bool {
+	have := s.eventMonths()
+	for m := range s.monthPrefixes(queryLower) {
+		if have[m] {
+			return true
+		}
+	}
+	return false
+}
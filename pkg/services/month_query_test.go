@@ -0,0 +1,68 @@
+package services
+
+import (
+	"AkuAI/models"
+	"maps"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestParseMonthRefs(t *testing.T) {
+	cases := []struct {
+		q    string
+		want []monthRef
+	}{
+		{"ruang 11 di gedung a di mana?", nil},
+		{"kelas untuk 11 orang", nil},
+		{"jam 10 pagi buka?", nil},
+		{"kursus desain grafis", nil},
+		{"acara bulan 11", []monthRef{{time.November, 0}}},
+		{"bln 12 2026 ada apa", []monthRef{{time.December, 2026}}},
+		{"webinar 11 2025", []monthRef{{time.November, 2025}}},
+		{"jadwal 11/2025", []monthRef{{time.November, 2025}}},
+		{"acara tanggal 12-11-2025", []monthRef{{time.November, 2025}}},
+		{"acara 2025-12-03", []monthRef{{time.December, 2025}}},
+		{"sertifikasi nov 2026 dan desember", []monthRef{{time.November, 2026}, {time.December, 0}}},
+	}
+	for _, c := range cases {
+		if got := parseMonthRefs(c.q); !slices.Equal(got, c.want) {
+			t.Errorf("parseMonthRefs(%q) = %v, want %v", c.q, got, c.want)
+		}
+	}
+}
+
+func TestMonthPrefixes(t *testing.T) {
+	data := &models.UIBEventsData{}
+	data.UIBEvents.November2025 = []models.UIBEvent{{ID: "web_nov_001", Type: "webinar", Title: "Webinar", Date: "2025-11-20"}}
+	s := &UIBEventService{eventsData: data}
+
+	keys := func(m map[string]bool) []string { return slices.Sorted(maps.Keys(m)) }
+	if got := keys(s.monthPrefixes("webinar november")); !slices.Equal(got, []string{"2025-11"}) {
+		t.Errorf("month of the data: %v", got)
+	}
+	if got := keys(s.monthPrefixes("webinar november 2026")); !slices.Equal(got, []string{"2026-11"}) {
+		t.Errorf("explicit year: %v", got)
+	}
+
+	if !s.AnalyzeQueryForUIB("ada apa bulan 11?") {
+		t.Error("bulan 11 not detected")
+	}
+	for _, q := range []string{"ruang 11 di mana?", "meja untuk 11 orang", "ada apa di november 2026?"} {
+		if s.AnalyzeQueryForUIB(q) {
+			t.Errorf("%q detected as an event query", q)
+		}
+	}
+}
+
+func TestSemanticFacets(t *testing.T) {
+	nov := SemanticFacets("Ada webinar apa bulan November 2025?")
+	if nov != SemanticFacets("webinar di nov 2025") {
+		t.Errorf("rephrasing changed the facets: %q", nov)
+	}
+	for _, other := range []string{"Ada webinar apa bulan Desember 2025?", "Ada seminar apa bulan November 2025?", "Ada webinar apa bulan November 2026?"} {
+		if SemanticFacets(other) == nov {
+			t.Errorf("SemanticFacets(%q) = %q, same as November's", other, nov)
+		}
+	}
+}
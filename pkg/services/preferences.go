@@ -0,0 +1,75 @@
+package services
+
+import (
+	"AkuAI/models"
+	"context"
+	"fmt"
+	"strings"
+)
+
+type preferencesKey struct{}
+
+// WithPreferences attaches the reply preferences of the asking user; the
+// chat prompts add instructions for those that differ from the defaults.
+func WithPreferences(ctx context.Context, p models.UserPreferences) context.Context {
+	return context.WithValue(ctx, preferencesKey{}, p)
+}
+
+// WithVerbosity overrides the verbosity of the preferences in ctx for one
+// request; "" keeps it.
+func WithVerbosity(ctx context.Context, verbosity string) context.Context {
+	if verbosity == "" {
+		return ctx
+	}
+	p := PreferencesFrom(ctx)
+	p.Verbosity = verbosity
+	return WithPreferences(ctx, p)
+}
+
+// PreferencesFrom returns the preferences attached to ctx, or the defaults.
+func PreferencesFrom(ctx context.Context) models.UserPreferences {
+	if p, ok := ctx.Value(preferencesKey{}).(models.UserPreferences); ok {
+		return p
+	}
+	return models.DefaultPreferences(0)
+}
+
+// PreferencesKey tells apart cached replies generated under different
+// preferences; it is "" for the defaults.
+func PreferencesKey(ctx context.Context) string {
+	p := PreferencesFrom(ctx)
+	if p.IsDefault() {
+		return ""
+	}
+	return fmt.Sprintf("lang=%s;verbosity=%s;markdown=%t;emoji=%t", p.Language, p.Verbosity, p.Markdown, p.Emoji)
+}
+
+// preferencesContext is the prompt section asking for the reply the user
+// prefers, or "" for the defaults.
+func preferencesContext(ctx context.Context) string {
+	p := PreferencesFrom(ctx)
+	if p.IsDefault() {
+		return ""
+	}
+	var b strings.Builder
+	b.WriteString("\n\nPREFERENSI JAWABAN PENGGUNA (ikuti selama tidak bertentangan dengan aturan data di atas; penanda sumber [EV-...] tetap ditulis):\n")
+	switch p.Language {
+	case "en":
+		b.WriteString("- Jawab dalam Bahasa Inggris, termasuk kalimat pembuka yang diminta format di atas.\n")
+	case "id":
+		b.WriteString("- Jawab dalam Bahasa Indonesia, apa pun bahasa pertanyaannya.\n")
+	}
+	switch p.Verbosity {
+	case models.VerbosityShort:
+		b.WriteString("- Jawab singkat: paling banyak 3-4 kalimat atau butir, hanya informasi terpenting.\n")
+	case models.VerbosityDetailed:
+		b.WriteString("- Jawab lengkap dan rinci, sertakan semua detail yang tersedia beserta penjelasannya.\n")
+	}
+	if !p.Markdown {
+		b.WriteString("- Tulis teks biasa tanpa format Markdown: tanpa **tebal**, judul #, tabel, atau blok kode.\n")
+	}
+	if !p.Emoji {
+		b.WriteString("- Jangan gunakan emoji.\n")
+	}
+	return b.String()
+}
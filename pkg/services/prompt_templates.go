@@ -0,0 +1,116 @@
+package services
+
+import (
+	"AkuAI/pkg/prompts"
+	"AkuAI/pkg/tenant"
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// PromptTemplateVersion versions the prompt templates below and the
+// built-in blocks of pkg/prompts; bump it when their wording changes so
+// logged A/B results stay comparable.
+const PromptTemplateVersion = "2026.10.15"
+
+// chatLanguageRule closes the rules of chat answers.
+const chatLanguageRule = "Jawab dalam Bahasa Indonesia yang jelas dan terstruktur"
+
+// eventInstruction is the system instruction for answering from the event
+// data of uib: today's date, the tone and rules in effect for its campus in
+// the tenant of ctx (see pkg/prompts), then eventContext, if any, and
+// extraRules after the campus's rules. The built-in blocks are localised to
+// the campus; saved ones are used as written.
+func eventInstruction(ctx context.Context, uib *UIBEventService, eventContext string, extraRules ...string) string {
+	var b prompts.Blocks
+	if v, ok := prompts.Default().For(tenant.ID(ctx), uib.Institution()); ok {
+		b = prompts.BlocksOf(v)
+	}
+	if b.Tone == "" {
+		b.Tone = uib.Localize(prompts.Defaults.Tone)
+	}
+	if len(b.Rules) == 0 {
+		for _, r := range prompts.Defaults.Rules {
+			b.Rules = append(b.Rules, uib.Localize(r))
+		}
+	}
+
+	var sb strings.Builder
+	sb.WriteString("TANGGAL HARI INI: 4 Oktober 2025\n\n" + b.Tone)
+	if eventContext != "" {
+		sb.WriteString("\n\n" + eventContext)
+	}
+	sb.WriteString("\n\nINSTRUKSI PENTING:")
+	for i, r := range append(b.Rules, extraRules...) {
+		fmt.Fprintf(&sb, "\n%d. %s", i+1, r)
+	}
+	return sb.String()
+}
+
+// EventInstructionPreview is the system instruction chats about ds get in
+// the tenant of ctx, with a placeholder where the retrieved events go.
+func EventInstructionPreview(ctx context.Context, ds *UIBEventService) string {
+	return eventInstruction(ctx, ds, "[DATA ACARA YANG RELEVAN]", chatLanguageRule)
+}
+
+func promptTemplateFor(base string, uibDetected bool) string {
+	if uibDetected {
+		return base + "_uib_v1"
+	}
+	return base + "_generic_v1"
+}
+
+// PromptTemplateID returns the template the given entry point (AskCampus,
+// AskCampusWithChat or AskCampusWithUIBContext) uses to answer question.
+func (s *GeminiService) PromptTemplateID(function, question string) string {
+	uib := s.eventData(question)
+	detected := uib != nil && uib.AnalyzeQueryForUIB(question)
+	switch function {
+	case "AskCampus":
+		return promptTemplateFor("askcampus", detected)
+	case "AskCampusWithUIBContext":
+		return promptTemplateFor("askcampus_uibctx", detected)
+	default:
+		return promptTemplateFor("askcampus_chat", detected)
+	}
+}
+
+// RelevantEventIDs returns the IDs of the events retrieved for question, the
+// ones a good answer is expected to mention.
+func (s *GeminiService) RelevantEventIDs(question string) []string {
+	uib := s.eventData(question)
+	if uib == nil || !uib.AnalyzeQueryForUIB(question) {
+		return []string{}
+	}
+	ids := []string{}
+	for _, ev := range uib.GetRelevantEventsForQuery(question) {
+		ids = append(ids, ev.ID)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// MentionedEventIDs returns the sorted IDs of the events a reply refers to,
+// either through a citation marker or by quoting the event title.
+func MentionedEventIDs(reply string) []string {
+	seen := map[string]bool{}
+	_, citations := ResolveCitations(reply)
+	for _, ct := range citations {
+		if ct.EventID != "" {
+			seen[ct.EventID] = true
+		}
+	}
+	lower := strings.ToLower(reply)
+	for _, ev := range knownEvents() {
+		if t := strings.ToLower(strings.TrimSpace(ev.Title)); t != "" && strings.Contains(lower, t) {
+			seen[ev.ID] = true
+		}
+	}
+	ids := make([]string, 0, len(seen))
+	for id := range seen {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
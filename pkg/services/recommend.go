@@ -0,0 +1,174 @@
+package services
+
+import (
+	"AkuAI/models"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// RecommendProfile is what is known about a user when recommending events:
+// the remembered program and interests (pkg/memory) and their past questions.
+type RecommendProfile struct {
+	Program     string
+	Interests   []string
+	PastQueries []string
+	PastTopics  []string // Message.Topic of the past questions: certification, webinar, ...
+}
+
+// Recommendation is one upcoming event with the reasons it suits the user.
+type Recommendation struct {
+	Event   models.UIBEvent `json:"event"`
+	Score   float64         `json:"score"`
+	Reasons []string        `json:"reasons"`
+}
+
+// recommendQuery matches "acara apa yang cocok untuk saya?" and similar.
+var recommendQuery = regexp.MustCompile(`(?i)\b(?:cocok|pas|sesuai|relevan)\s+(?:untuk|buat|bagi|sama|dengan)\s+(?:saya|aku|gue|gw|ku)\b|\brekomendasi(?:kan|in)?\s+(?:acara|event|webinar|sertifikasi|kegiatan)|\bevents?\s+for\s+me\b|\brecommend(?:ed)?\s+(?:me\s+)?(?:an?\s+|some\s+)?(?:events?|webinars?|certifications?)`)
+
+// IsRecommendationQuery reports whether the user asks which events suit them.
+func IsRecommendationQuery(text string) bool {
+	return recommendQuery.MatchString(text)
+}
+
+// RecommendEvents ranks the events on or after from for p, best first, and
+// returns at most limit of them (all when limit <= 0). An interest found in an
+// event counts 3, the program in its department 3 or elsewhere 1.5, each past
+// question about the same event type 1 (up to 2) and each word of a past
+// question in its title 1 (up to 2); being free or within two weeks adds 0.5.
+// Events that match nothing about the user are left out.
+func (s *UIBEventService) RecommendEvents(p RecommendProfile, from time.Time, limit int) []Recommendation {
+	from = StartOfEventDay(from)
+	typeCount := map[string]int{}
+	for _, t := range p.PastTopics {
+		typeCount[t]++
+	}
+	queryWords := map[string]bool{}
+	for _, q := range p.PastQueries {
+		for _, w := range searchTermTokens(strings.ToLower(q)) {
+			if len(w) >= 4 && !searchTermStopwords[w] && !genericEventWords[w] {
+				queryWords[w] = true
+			}
+		}
+	}
+
+	var out []Recommendation
+	for _, ev := range s.GetAllEvents() {
+		date, ok := EventDay(ev)
+		if !ok || date.Before(from) {
+			continue
+		}
+		title := strings.ToLower(ev.Title)
+		dept := strings.ToLower(ev.Department)
+		text := title + " " + strings.ToLower(ev.Description+" "+ev.TechStack+" "+ev.Speaker)
+
+		var r Recommendation
+		for _, in := range p.Interests {
+			if containsAllWords(text+" "+dept, in) {
+				r.Score += 3
+				r.Reasons = append(r.Reasons, fmt.Sprintf("cocok dengan minat \"%s\"", in))
+			}
+		}
+		if prog := strings.ToLower(p.Program); prog != "" {
+			switch {
+			case containsAllWords(dept, prog):
+				r.Score += 3
+				r.Reasons = append(r.Reasons, "diadakan untuk jurusan "+ev.Department)
+			case containsAllWords(text, prog):
+				r.Score += 1.5
+				r.Reasons = append(r.Reasons, "berkaitan dengan jurusan "+p.Program)
+			}
+		}
+		if n := min(typeCount[ev.Type], 2); n > 0 {
+			r.Score += float64(n)
+			r.Reasons = append(r.Reasons, "kamu sering menanyakan "+EventTypeLabel(ev.Type))
+		}
+		var hits []string
+		for w := range queryWords {
+			if strings.Contains(title, w) {
+				hits = append(hits, w)
+			}
+		}
+		if len(hits) > 0 {
+			sort.Strings(hits)
+			hits = hits[:min(len(hits), 2)]
+			r.Score += float64(len(hits))
+			r.Reasons = append(r.Reasons, "terkait pertanyaanmu sebelumnya tentang "+strings.Join(hits, ", "))
+		}
+		if r.Score == 0 {
+			continue
+		}
+		if s.isFreeEvent(ev) {
+			r.Score += 0.5
+			r.Reasons = append(r.Reasons, "gratis")
+		}
+		if date.Sub(from) < 14*24*time.Hour {
+			r.Score += 0.5
+			r.Reasons = append(r.Reasons, "segera berlangsung ("+ev.Date+")")
+		}
+		r.Event = ev
+		out = append(out, r)
+	}
+	sort.SliceStable(out, func(i, j int) bool {
+		if out[i].Score != out[j].Score {
+			return out[i].Score > out[j].Score
+		}
+		return out[i].Event.Date < out[j].Event.Date
+	})
+	if limit > 0 && len(out) > limit {
+		out = out[:limit]
+	}
+	return out
+}
+
+// genericEventWords say nothing about what a past question was about.
+var genericEventWords = map[string]bool{
+	"acara": true, "event": true, "events": true, "webinar": true, "sertifikasi": true, "kegiatan": true,
+	"bulan": true, "minggu": true, "depan": true, "tanggal": true, "oktober": true, "november": true,
+	"desember": true, "gratis": true, "daftar": true, "cocok": true, "rekomendasi": true, "uib": true,
+}
+
+// containsAllWords reports whether every word of phrase occurs in text.
+func containsAllWords(text, phrase string) bool {
+	words := strings.Fields(strings.ToLower(phrase))
+	for _, w := range words {
+		if !strings.Contains(text, w) {
+			return false
+		}
+	}
+	return len(words) > 0
+}
+
+// RecommendationSection renders recommendations for the system instruction of
+// a "acara apa yang cocok untuk saya?" reply, or "" when there are none.
+func RecommendationSection(recs []Recommendation) string {
+	if len(recs) == 0 {
+		return ""
+	}
+	var b strings.Builder
+	b.WriteString("\n\nREKOMENDASI ACARA UNTUK PENGGUNA INI (sudah diurutkan dari yang paling cocok; sebutkan alasannya singkat):\n")
+	for i, r := range recs {
+		fmt.Fprintf(&b, "%d. %s (%s, %s) [%s] - alasan: %s\n", i+1, r.Event.Title, EventTypeLabel(r.Event.Type), r.Event.Date, CitationMarker(r.Event.ID), strings.Join(r.Reasons, "; "))
+	}
+	return b.String()
+}
+
+// ChatRecommendations returns the RecommendationSection for a chat question,
+// drawn from the dataset of campus (the user's remembered campus) or else the
+// campus the question names.
+func ChatRecommendations(p RecommendProfile, campus, question string) string {
+	campuses := defaultCampusData()
+	if campuses == nil {
+		return ""
+	}
+	ds := campuses.Dataset(campus)
+	if ds == nil {
+		_, ds = campuses.ForQuery(question)
+	}
+	if ds == nil {
+		return ""
+	}
+	return RecommendationSection(ds.RecommendEvents(p, time.Now(), 5))
+}
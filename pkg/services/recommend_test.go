@@ -0,0 +1,65 @@
+package services
+
+import (
+	"AkuAI/models"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRecommendEvents(t *testing.T) {
+	data := &models.UIBEventsData{}
+	data.UIBEvents.October2025 = []models.UIBEvent{
+		{ID: "web_oct_001", Type: "webinar", Title: "Webinar Data Science untuk Pemula", Date: "2025-10-02", Department: "Sistem Informasi"},
+	}
+	data.UIBEvents.November2025 = []models.UIBEvent{
+		{ID: "cert_nov_001", Type: "certification", Title: "Sertifikasi Cloud Practitioner", Date: "2025-11-12", Department: "Teknik", RegistrationFee: "Rp 500.000"},
+		{ID: "web_nov_001", Type: "webinar", Title: "Webinar Data Science Lanjutan", Date: "2025-11-20", Department: "Sistem Informasi", Description: "Machine learning dengan Python."},
+		{ID: "web_nov_002", Type: "webinar", Title: "Webinar Akuntansi Digital", Date: "2025-11-25", Department: "Akuntansi", RegistrationFee: "Rp 50.000"},
+	}
+	s := &UIBEventService{eventsData: data}
+	from := time.Date(2025, 10, 4, 0, 0, 0, 0, time.UTC)
+
+	p := RecommendProfile{
+		Program:     "Sistem Informasi",
+		Interests:   []string{"data science"},
+		PastQueries: []string{"ada sertifikasi cloud bulan november?"},
+		PastTopics:  []string{"webinar"},
+	}
+	got := s.RecommendEvents(p, from, 0)
+	if len(got) != 3 {
+		t.Fatalf("got %d recommendations, want 3 (past and unrelated events left out)", len(got))
+	}
+	if got[0].Event.ID != "web_nov_001" {
+		t.Fatalf("first recommendation %s, want web_nov_001", got[0].Event.ID)
+	}
+	reasons := strings.Join(got[0].Reasons, "; ")
+	for _, want := range []string{`minat "data science"`, "jurusan Sistem Informasi", "menanyakan webinar"} {
+		if !strings.Contains(reasons, want) {
+			t.Errorf("reasons %q lack %q", reasons, want)
+		}
+	}
+	if got[1].Event.ID != "cert_nov_001" || !strings.Contains(strings.Join(got[1].Reasons, ";"), "cloud") {
+		t.Errorf("second recommendation %s %v, want cert_nov_001 for the past question", got[1].Event.ID, got[1].Reasons)
+	}
+
+	if got := s.RecommendEvents(p, from, 1); len(got) != 1 {
+		t.Errorf("limit 1 gave %d", len(got))
+	}
+	if got := s.RecommendEvents(RecommendProfile{}, from, 0); len(got) != 0 {
+		t.Errorf("empty profile gave %d recommendations", len(got))
+	}
+}
+
+func TestIsRecommendationQuery(t *testing.T) {
+	for _, q := range []string{"acara apa yang cocok untuk saya?", "Ada webinar yang pas buat aku?", "rekomendasikan acara dong", "any events for me?"} {
+		if !IsRecommendationQuery(q) {
+			t.Errorf("%q not detected", q)
+		}
+	}
+	for _, q := range []string{"webinar bulan november", "sertifikasi cloud cocok untuk pemula?"} {
+		if IsRecommendationQuery(q) {
+			t.Errorf("%q detected", q)
+		}
+	}
+}
@@ -0,0 +1,123 @@
+package services
+
+import (
+	"AkuAI/pkg/config"
+	"context"
+	"log"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Route is the model tier a query is sent to when GEMINI_ROUTING is on.
+type Route string
+
+const (
+	// RouteSimple goes to GEMINI_FLASH_MODEL: greetings, thanks and short
+	// questions outside the campus topics.
+	RouteSimple Route = "simple"
+	// RouteStandard goes to GEMINI_MODEL.
+	RouteStandard Route = "standard"
+	// RouteComplex goes to GEMINI_PRO_MODEL: long or comparative questions
+	// about UIB events.
+	RouteComplex Route = "complex"
+)
+
+var (
+	greetingRe  = regexp.MustCompile(`(?i)^\s*(h+a+l+o+|h+a+i+|hello|hi|hey|pagi|siang|sore|malam|selamat (pagi|siang|sore|malam)|assalamu'?alaikum|terima ?kasih|makasih|thanks?( you)?|thx|ok(e|ay)?|sip|mantap)( (kak|min|bot|ya|banyak|semua))*\s*[!.?🙏😊👍]*\s*$`)
+	reasoningRe = regexp.MustCompile(`(?i)\b(bandingkan|perbandingan|dibandingkan|mana yang (lebih|paling)|lebih (cocok|baik|bagus|murah|mahal)|kenapa|mengapa|jelaskan|rekomendasi|rekomendasikan|sarankan|compare|which is better|why)\b`)
+)
+
+// routeFor picks the route of question, given its topic label and whether
+// it is about UIB events.
+func routeFor(question string, label QueryLabel, uib bool) Route {
+	n := utf8.RuneCountInString(strings.TrimSpace(question))
+	switch {
+	case greetingRe.MatchString(question):
+		return RouteSimple
+	case uib && (n >= config.GeminiRouteComplexChars || reasoningRe.MatchString(question)):
+		return RouteComplex
+	case !uib && label == LabelOther && n < config.GeminiRouteSimpleChars:
+		return RouteSimple
+	}
+	return RouteStandard
+}
+
+func routeModel(r Route) string {
+	switch r {
+	case RouteSimple:
+		return config.GeminiFlashModel
+	case RouteComplex:
+		return config.GeminiProModel
+	}
+	return config.GeminiModel
+}
+
+type routeKey struct{}
+
+// routed attaches the route of question to ctx so generationModels tries
+// its model first. A generation override keeps its model, so sweeps are
+// never rerouted.
+func (s *GeminiService) routed(ctx context.Context, question string) context.Context {
+	if !config.GeminiRouting || strings.TrimSpace(question) == "" {
+		return ctx
+	}
+	if o, ok := ctx.Value(generationOverrideKey{}).(GenerationOverride); ok && o.Model != "" {
+		return ctx
+	}
+	uib := false
+	if ds := s.eventData(question); ds != nil {
+		uib = ds.AnalyzeQueryForUIB(question)
+	}
+	r := routeFor(question, s.ClassifyQuery(ctx, question), uib)
+	log.Printf("[gemini] 🧭 route=%s model=%s", r, routeModel(r))
+	generationInfo(ctx).update(func(g *GenerationInfo) { g.route = r })
+	return context.WithValue(ctx, routeKey{}, r)
+}
+
+func routeFrom(ctx context.Context) (Route, bool) {
+	r, ok := ctx.Value(routeKey{}).(Route)
+	return r, ok
+}
+
+// defaultPrices are the list prices in USD per million prompt and output
+// tokens; GEMINI_PRICES adds to or replaces them.
+var defaultPrices = map[string][2]float64{
+	"gemini-2.0-flash":      {0.10, 0.40},
+	"gemini-2.0-flash-lite": {0.075, 0.30},
+	"gemini-2.5-flash":      {0.30, 2.50},
+	"gemini-2.5-pro":        {1.25, 10.00},
+}
+
+// parsePrices parses "model=in/out,..." on top of defaultPrices, skipping
+// malformed entries.
+func parsePrices(s string) map[string][2]float64 {
+	prices := make(map[string][2]float64, len(defaultPrices))
+	for m, p := range defaultPrices {
+		prices[m] = p
+	}
+	for _, part := range strings.Split(s, ",") {
+		model, price, ok := strings.Cut(strings.TrimSpace(part), "=")
+		in, out, ok2 := strings.Cut(price, "/")
+		if !ok || !ok2 {
+			continue
+		}
+		pin, err1 := strconv.ParseFloat(strings.TrimSpace(in), 64)
+		pout, err2 := strconv.ParseFloat(strings.TrimSpace(out), 64)
+		if err1 != nil || err2 != nil {
+			continue
+		}
+		prices[strings.TrimSpace(model)] = [2]float64{pin, pout}
+	}
+	return prices
+}
+
+// TokenCost is what u cost on model in USD, 0 for models without a price.
+func TokenCost(model string, u Usage) float64 {
+	p, ok := parsePrices(config.GeminiPrices)[model]
+	if !ok {
+		return 0
+	}
+	return (float64(u.PromptTokens)*p[0] + float64(u.OutputTokens)*p[1]) / 1e6
+}
@@ -0,0 +1,69 @@
+package services
+
+import (
+	"AkuAI/pkg/config"
+	"context"
+	"math"
+	"slices"
+	"testing"
+)
+
+func TestRouteFor(t *testing.T) {
+	prevSimple, prevComplex := config.GeminiRouteSimpleChars, config.GeminiRouteComplexChars
+	t.Cleanup(func() { config.GeminiRouteSimpleChars, config.GeminiRouteComplexChars = prevSimple, prevComplex })
+	config.GeminiRouteSimpleChars, config.GeminiRouteComplexChars = 40, 160
+
+	long := "Saya mahasiswa semester tiga dan ingin ikut sertifikasi di bulan November, tetapi jadwal kuliah saya padat setiap Selasa dan Kamis, jadi acara apa saja yang bisa saya ikuti?"
+	cases := []struct {
+		q     string
+		label QueryLabel
+		uib   bool
+		want  Route
+	}{
+		{"Halo kak!", LabelOther, false, RouteSimple},
+		{"terima kasih banyak 🙏", LabelOther, false, RouteSimple},
+		{"siapa kamu?", LabelOther, false, RouteSimple},
+		{"apa syarat ikut KRS?", LabelAcademics, false, RouteStandard},
+		{"webinar November apa saja?", LabelEvents, true, RouteStandard},
+		{"bandingkan webinar AI dan sertifikasi cloud", LabelEvents, true, RouteComplex},
+		{long, LabelEvents, true, RouteComplex},
+		{long, LabelAcademics, false, RouteStandard},
+	}
+	for _, c := range cases {
+		if got := routeFor(c.q, c.label, c.uib); got != c.want {
+			t.Errorf("routeFor(%q) = %s, want %s", c.q, got, c.want)
+		}
+	}
+}
+
+func TestGenerationModelsRouted(t *testing.T) {
+	prev, prevPro := config.GeminiModel, config.GeminiProModel
+	t.Cleanup(func() { config.GeminiModel, config.GeminiProModel = prev, prevPro })
+	config.GeminiModel, config.GeminiProModel = "gemini-2.0-flash", "gemini-2.5-pro"
+
+	ctx := context.WithValue(context.Background(), routeKey{}, RouteComplex)
+	if got := generationModels(ctx); !slices.Equal(got, []string{"gemini-2.5-pro", "gemini-2.0-flash"}) {
+		t.Fatalf("complex: %v", got)
+	}
+	ctx = WithGenerationOverride(ctx, GenerationOverride{Model: "gemini-x"})
+	if got := generationModels(ctx); !slices.Equal(got, []string{"gemini-x"}) {
+		t.Fatalf("override: %v", got)
+	}
+}
+
+func TestTokenCost(t *testing.T) {
+	prev := config.GeminiPrices
+	t.Cleanup(func() { config.GeminiPrices = prev })
+
+	config.GeminiPrices = "my-model=1/2, bad, gemini-2.5-pro=x/1"
+	u := Usage{PromptTokens: 1_000_000, OutputTokens: 500_000}
+	if got := TokenCost("my-model", u); math.Abs(got-2) > 1e-9 {
+		t.Errorf("my-model: %v", got)
+	}
+	if got := TokenCost("gemini-2.5-pro", u); math.Abs(got-6.25) > 1e-9 {
+		t.Errorf("gemini-2.5-pro keeps its default price: %v", got)
+	}
+	if got := TokenCost("local", u); got != 0 {
+		t.Errorf("local: %v", got)
+	}
+}
@@ -0,0 +1,157 @@
+package services
+
+import (
+	"AkuAI/models"
+	"log"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// searchTermStopwords are dropped when building a noun phrase from a chat
+// message: question words, fillers and the image request itself.
+var searchTermStopwords = map[string]bool{
+	"apa": true, "apakah": true, "siapa": true, "dimana": true, "di": true, "mana": true, "kapan": true,
+	"bagaimana": true, "gimana": true, "kenapa": true, "berapa": true, "yang": true, "dan": true, "atau": true,
+	"ini": true, "itu": true, "ada": true, "adalah": true, "dong": true, "ya": true, "nya": true, "sih": true,
+	"aja": true, "saja": true, "deh": true, "tolong": true, "mohon": true, "bisa": true, "boleh": true,
+	"saya": true, "aku": true, "kamu": true, "mau": true, "ingin": true, "pengen": true, "minta": true,
+	"tampilkan": true, "tunjukkan": true, "tunjukin": true, "perlihatkan": true, "kirim": true, "kirimkan": true,
+	"cari": true, "carikan": true, "cariin": true, "lihat": true, "liat": true, "gambar": true, "foto": true,
+	"image": true, "images": true, "picture": true, "pictures": true, "photo": true, "photos": true,
+	"dari": true, "ke": true, "untuk": true, "tentang": true, "soal": true, "dengan": true, "seperti": true,
+	"show": true, "me": true, "of": true, "the": true, "a": true, "an": true, "please": true, "what": true,
+	"bentuk": true, "tampilan": true, "wujud": true,
+}
+
+var (
+	campusDefaultOnce sync.Once
+	campusDefault     *CampusDataService
+)
+
+// defaultCampusData returns shared campus datasets for helpers that only read
+// event data, or nil when data/uib_events.json can't be loaded.
+func defaultCampusData() *CampusDataService {
+	campusDefaultOnce.Do(func() {
+		svc, err := NewCampusDataService()
+		if err != nil {
+			log.Printf("[uib] ⚠️ event data unavailable: %v", err)
+			return
+		}
+		campusDefault = svc
+	})
+	return campusDefault
+}
+
+// defaultUIBService returns the shared UIB dataset, or nil.
+func defaultUIBService() *UIBEventService {
+	if c := defaultCampusData(); c != nil {
+		return c.Default()
+	}
+	return nil
+}
+
+// knownEvents returns the events of every loaded campus.
+func knownEvents() []models.UIBEvent {
+	c := defaultCampusData()
+	if c == nil {
+		return nil
+	}
+	var events []models.UIBEvent
+	for _, name := range c.Campuses() {
+		events = append(events, c.Dataset(name).GetAllEvents()...)
+	}
+	return events
+}
+
+func searchTermTokens(text string) []string {
+	return strings.FieldsFunc(text, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '-'
+	})
+}
+
+// matchEventTitle returns the UIB event whose title shares most of its
+// significant words with message.
+func matchEventTitle(message string) *models.UIBEvent {
+	msgWords := map[string]bool{}
+	for _, t := range searchTermTokens(strings.ToLower(message)) {
+		msgWords[t] = true
+	}
+
+	var best *models.UIBEvent
+	bestScore := 0.0
+	events := knownEvents()
+	for i := range events {
+		var significant, matched int
+		for _, t := range searchTermTokens(strings.ToLower(events[i].Title)) {
+			if len(t) < 3 || searchTermStopwords[t] {
+				continue
+			}
+			significant++
+			if msgWords[t] {
+				matched++
+			}
+		}
+		if significant == 0 || matched < 2 {
+			continue
+		}
+		if score := float64(matched) / float64(significant); score >= 0.6 && score > bestScore {
+			best, bestScore = &events[i], score
+		}
+	}
+	return best
+}
+
+// nounPhrase keeps the content words of message in order, skipping
+// stopwords and the words that make up the detected university.
+func nounPhrase(message, university string) string {
+	uniWords := map[string]bool{}
+	for _, t := range searchTermTokens(strings.ToLower(university)) {
+		uniWords[t] = true
+	}
+
+	var kept []string
+	for _, t := range searchTermTokens(message) {
+		lower := strings.ToLower(t)
+		if searchTermStopwords[lower] || uniWords[lower] {
+			continue
+		}
+		if university != "" && resolveUniversityAlias(strings.ToUpper(t)) == university {
+			continue
+		}
+		kept = append(kept, t)
+		if len(kept) == 4 {
+			break
+		}
+	}
+	return strings.Join(kept, " ")
+}
+
+// ExtractImageSearchTerm builds an image search query from a chat message:
+// a UIB event title when one is mentioned, otherwise the detected university
+// followed by the remaining noun phrase. It returns "" when nothing useful is left.
+func ExtractImageSearchTerm(message string) string {
+	msg := strings.TrimSpace(message)
+	if msg == "" {
+		return ""
+	}
+
+	if ev := matchEventTitle(msg); ev != nil {
+		institution := ev.Institution
+		if institution == "" {
+			institution = "Universitas Internasional Batam"
+		}
+		return ev.Title + " " + institution
+	}
+
+	university := extractUniversityHeuristic(msg)
+	phrase := nounPhrase(msg, university)
+	switch {
+	case university != "" && phrase != "":
+		return university + " " + phrase
+	case university != "":
+		return university
+	default:
+		return phrase
+	}
+}
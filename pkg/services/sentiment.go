@@ -0,0 +1,81 @@
+package services
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Sentiment is the tone of a user message, a quality signal for analytics
+// and the prompt A/B test.
+type Sentiment string
+
+const (
+	SentimentPositive   Sentiment = "positive"
+	SentimentNeutral    Sentiment = "neutral"
+	SentimentFrustrated Sentiment = "frustrated"
+)
+
+// Phrases match on word boundaries of the lower-cased text.
+var (
+	frustrationPhrases = []string{"tidak membantu", "gak membantu", "ga membantu", "nggak membantu", "tidak jelas",
+		"gak jelas", "ga jelas", "nggak jelas", "salah lagi", "masih salah", "kok salah", "jawabannya salah", "bukan itu",
+		"sudah saya bilang", "udah saya bilang", "udah dibilang", "sudah dibilang", "dari tadi", "percuma", "kesal", "kesel",
+		"kecewa", "frustasi", "frustrasi", "capek", "lelet", "ngawur", "payah", "nyebelin", "menyebalkan", "tidak nyambung",
+		"gak nyambung", "ga nyambung", "not helpful", "useless", "wrong again", "still wrong", "that's wrong", "annoying",
+		"frustrated", "frustrating", "ugh"}
+	positivePhrases = []string{"terima kasih", "terimakasih", "makasih", "thanks", "thank you", "thx", "mantap", "keren",
+		"bagus", "sangat membantu", "membantu sekali", "jelas sekali", "sip", "oke sip", "helpful", "great", "perfect", "nice",
+		"awesome"}
+	positiveEmoji = []string{"👍", "🙏", "😊", "🙂", "❤", "😄"}
+)
+
+// ClassifySentiment rates text with keyword rules. Shouting (a mostly
+// upper-case message) and runs of "?!" count as a frustration phrase;
+// frustration wins a tie with positive phrases ("makasih, tapi masih
+// salah").
+func ClassifySentiment(text string) Sentiment {
+	words := " " + strings.Join(strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '\''
+	}), " ") + " "
+	frustrated, positive := 0, 0
+	for _, p := range frustrationPhrases {
+		if strings.Contains(words, " "+p+" ") {
+			frustrated++
+		}
+	}
+	for _, p := range positivePhrases {
+		if strings.Contains(words, " "+p+" ") {
+			positive++
+		}
+	}
+	for _, e := range positiveEmoji {
+		if strings.Contains(text, e) {
+			positive++
+		}
+	}
+	if shouting(text) || strings.Contains(text, "!!!") || (strings.Contains(text, "??") && strings.Contains(text, "!")) {
+		frustrated++
+	}
+	switch {
+	case frustrated > 0 && frustrated >= positive:
+		return SentimentFrustrated
+	case positive > 0:
+		return SentimentPositive
+	}
+	return SentimentNeutral
+}
+
+// shouting reports whether text has at least 12 letters, nearly all of them
+// upper case.
+func shouting(text string) bool {
+	letters, upper := 0, 0
+	for _, r := range text {
+		if unicode.IsLetter(r) {
+			letters++
+			if unicode.IsUpper(r) {
+				upper++
+			}
+		}
+	}
+	return letters >= 12 && upper*10 >= letters*9
+}
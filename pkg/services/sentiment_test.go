@@ -0,0 +1,24 @@
+package services
+
+import "testing"
+
+func TestClassifySentiment(t *testing.T) {
+	cases := []struct {
+		text string
+		want Sentiment
+	}{
+		{"Ada webinar apa bulan November?", SentimentNeutral},
+		{"Makasih, sangat membantu 👍", SentimentPositive},
+		{"jawabannya gak nyambung, percuma", SentimentFrustrated},
+		{"Makasih, tapi masih salah", SentimentFrustrated},
+		{"KENAPA TIDAK ADA JADWALNYA", SentimentFrustrated},
+		{"kok begitu??!", SentimentFrustrated},
+		{"UIB", SentimentNeutral},
+		{"sipil atau arsitektur?", SentimentNeutral},
+	}
+	for _, c := range cases {
+		if got := ClassifySentiment(c.text); got != c.want {
+			t.Errorf("ClassifySentiment(%q) = %s, want %s", c.text, got, c.want)
+		}
+	}
+}
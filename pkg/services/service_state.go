@@ -0,0 +1,106 @@
+package services
+
+import (
+	"AkuAI/pkg/config"
+	"AkuAI/pkg/i18n"
+	"sync"
+	"time"
+)
+
+// ServiceState is where chat replies currently come from, so clients can
+// tell users when answers are not Gemini's.
+type ServiceState string
+
+const (
+	// StateGeminiOK: Gemini answers.
+	StateGeminiOK ServiceState = "gemini_ok"
+	// StateDegradedLocal: Gemini failed and the local responder answers.
+	StateDegradedLocal ServiceState = "degraded_local"
+	// StateMock: Gemini is switched off by config or replaced by mock
+	// fixtures, so local or mock answers are expected.
+	StateMock ServiceState = "mock"
+)
+
+// StateChange is a transition of the service state.
+type StateChange struct {
+	State    ServiceState `json:"state"`
+	Previous ServiceState `json:"previous"`
+	Since    time.Time    `json:"since"`
+	Message  string       `json:"message"`
+}
+
+var serviceState struct {
+	mu    sync.Mutex
+	state ServiceState
+	since time.Time
+}
+
+// geminiSwitchedOff reports whether config keeps chat away from Gemini.
+func geminiSwitchedOff() bool {
+	return config.MockLLMFixtures != "" || !config.IsGeminiEnabled || config.IsStaging
+}
+
+func configuredState() ServiceState {
+	if geminiSwitchedOff() {
+		return StateMock
+	}
+	return StateGeminiOK
+}
+
+// stateOf is the state a reply shows, false when it shows none (cached or
+// unknown origin).
+func stateOf(info *GenerationInfo) (ServiceState, bool) {
+	switch info.Model() {
+	case "":
+		return "", false
+	case "mock":
+		return StateMock, true
+	case "local":
+		if geminiSwitchedOff() {
+			return StateMock, true
+		}
+		return StateDegradedLocal, true
+	}
+	return StateGeminiOK, true
+}
+
+// StateMessage is the banner text clients show for state, in locale.
+func StateMessage(locale string, state ServiceState) string {
+	switch state {
+	case StateDegradedLocal:
+		return i18n.T(locale, i18n.ServiceDegraded)
+	case StateMock:
+		return i18n.T(locale, i18n.ServiceMock)
+	}
+	return i18n.T(locale, i18n.ServiceOK)
+}
+
+// CurrentServiceState returns the state and since when it holds. Before
+// the first reply it is what the configuration implies.
+func CurrentServiceState() (ServiceState, time.Time) {
+	serviceState.mu.Lock()
+	defer serviceState.mu.Unlock()
+	if serviceState.state == "" {
+		serviceState.state, serviceState.since = configuredState(), time.Now()
+	}
+	return serviceState.state, serviceState.since
+}
+
+// ObserveReply moves the service state to the one the reply of info shows
+// and returns the current state, with the transition when it changed.
+// Cached replies leave it as it is.
+func ObserveReply(info *GenerationInfo) (ServiceState, *StateChange) {
+	current, _ := CurrentServiceState()
+	next, ok := stateOf(info)
+	if !ok {
+		return current, nil
+	}
+	serviceState.mu.Lock()
+	defer serviceState.mu.Unlock()
+	if serviceState.state == next {
+		return next, nil
+	}
+	change := &StateChange{State: next, Previous: serviceState.state, Since: time.Now(), Message: StateMessage(i18n.Default(), next)}
+	serviceState.state, serviceState.since = next, change.Since
+	return next, change
+}
@@ -0,0 +1,49 @@
+package services
+
+import (
+	"AkuAI/pkg/config"
+	"context"
+	"testing"
+)
+
+func TestObserveReply(t *testing.T) {
+	prevEnabled, prevStaging, prevMock := config.IsGeminiEnabled, config.IsStaging, config.MockLLMFixtures
+	t.Cleanup(func() {
+		config.IsGeminiEnabled, config.IsStaging, config.MockLLMFixtures = prevEnabled, prevStaging, prevMock
+		serviceState.state = ""
+	})
+	config.IsGeminiEnabled, config.IsStaging, config.MockLLMFixtures = true, false, ""
+	serviceState.state = ""
+
+	reply := func(model string) *GenerationInfo {
+		ctx, info := WithGenerationInfo(context.Background())
+		switch model {
+		case "local":
+			MarkLocal(ctx)
+		case "":
+			MarkCached(ctx)
+		default:
+			recordCandidate(ctx, model, map[string]any{"finishReason": "STOP"})
+		}
+		return info
+	}
+
+	if state, change := ObserveReply(reply("gemini-2.0-flash")); state != StateGeminiOK || change != nil {
+		t.Fatalf("gemini reply: %s, %+v", state, change)
+	}
+	state, change := ObserveReply(reply("local"))
+	if state != StateDegradedLocal || change == nil || change.Previous != StateGeminiOK || change.Message == "" {
+		t.Fatalf("local reply: %s, %+v", state, change)
+	}
+	if state, change := ObserveReply(reply("")); state != StateDegradedLocal || change != nil {
+		t.Fatalf("cached reply changed the state: %s, %+v", state, change)
+	}
+	if _, change := ObserveReply(reply("gemini-2.0-flash")); change == nil || change.State != StateGeminiOK {
+		t.Fatalf("recovery: %+v", change)
+	}
+
+	config.IsGeminiEnabled = false
+	if state, _ := ObserveReply(reply("local")); state != StateMock {
+		t.Fatalf("local reply with Gemini off: %s", state)
+	}
+}
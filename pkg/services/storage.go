@@ -1,15 +1,22 @@
 package services
 
 import (
+	"AkuAI/pkg/config"
+	"AkuAI/pkg/virusscan"
+	"context"
 	"crypto/hmac"
 	"crypto/sha256"
 	"encoding/hex"
+	"errors"
 	"fmt"
 	"io"
+	"io/fs"
 	"log"
 	"mime/multipart"
+	"net/http"
 	"os"
 	"path/filepath"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
@@ -19,6 +26,25 @@ type ObjectStorageService struct {
 	basePath  string
 	baseURL   string
 	secretKey string
+	scanner   virusscan.Scanner
+}
+
+// Reasons SaveUploadedImage rejects an image, besides an invalid token.
+var (
+	ErrImageTooLarge      = errors.New("file too large")
+	ErrImageType          = errors.New("invalid file type. Only JPG, PNG, GIF, WEBP allowed")
+	ErrStorageQuota       = errors.New("storage quota exceeded")
+	ErrImageInfected      = errors.New("file rejected by virus scan")
+	ErrScannerUnavailable = errors.New("virus scan unavailable, try again later")
+)
+
+// imageTypes maps the content types sniffed from an image's first bytes to
+// the extension it is stored with and those it may be uploaded with.
+var imageTypes = map[string][]string{
+	"image/jpeg": {".jpg", ".jpeg"},
+	"image/png":  {".png"},
+	"image/gif":  {".gif"},
+	"image/webp": {".webp"},
 }
 
 func NewObjectStorageService() *ObjectStorageService {
@@ -33,11 +59,15 @@ func NewObjectStorageService() *ObjectStorageService {
 
 	os.MkdirAll(basePath, 0755)
 
-	return &ObjectStorageService{
+	s := &ObjectStorageService{
 		basePath:  basePath,
 		baseURL:   baseURL,
 		secretKey: secretKey,
 	}
+	if config.ClamAVAddress != "" {
+		s.scanner = virusscan.ClamAV{Address: config.ClamAVAddress}
+	}
+	return s
 }
 
 func (s *ObjectStorageService) GenerateUploadToken(userID uint, fileExtension string) (*UploadTokenResponse, error) {
@@ -53,54 +83,129 @@ func (s *ObjectStorageService) GenerateUploadToken(userID uint, fileExtension st
 	}, nil
 }
 
+// SaveUploadedImage stores a profile image after checking that its content,
+// not just its name, is a JPG, PNG, GIF or WEBP image of at most
+// UPLOAD_MAX_MB, that it fits the user's UPLOAD_QUOTA_MB and, with
+// CLAMAV_ADDRESS set, that clamd finds nothing in it.
 func (s *ObjectStorageService) SaveUploadedImage(userID uint, file multipart.File, header *multipart.FileHeader, token string) (*SaveImageResponse, error) {
 	if !s.validateUploadToken(token, userID) {
 		return nil, fmt.Errorf("invalid upload token")
 	}
 
 	if !s.isValidImageType(header.Filename) {
-		return nil, fmt.Errorf("invalid file type. Only JPG, PNG, GIF, WEBP allowed")
+		return nil, ErrImageType
+	}
+
+	maxBytes := int64(config.UploadMaxMB) << 20
+	tooLarge := fmt.Errorf("%w. Maximum size is %dMB", ErrImageTooLarge, config.UploadMaxMB)
+	if header.Size > maxBytes {
+		return nil, tooLarge
+	}
+	data, err := io.ReadAll(io.LimitReader(file, maxBytes+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file: %w", err)
+	}
+	if int64(len(data)) > maxBytes {
+		return nil, tooLarge
+	}
+
+	contentType := http.DetectContentType(data)
+	exts, ok := imageTypes[contentType]
+	if !ok {
+		return nil, fmt.Errorf("%w (content is %s)", ErrImageType, contentType)
+	}
+	if !slices.Contains(exts, strings.ToLower(filepath.Ext(header.Filename))) {
+		return nil, fmt.Errorf("%w (content is %s, not %s)", ErrImageType, contentType, filepath.Ext(header.Filename))
 	}
 
-	if header.Size > 5*1024*1024 {
-		return nil, fmt.Errorf("file too large. Maximum size is 5MB")
+	quota := int64(config.UploadQuotaMB) << 20
+	if used, err := s.UsedBytes(userID); err != nil {
+		return nil, fmt.Errorf("failed to check storage quota: %w", err)
+	} else if quota > 0 && used+int64(len(data)) > quota {
+		return nil, fmt.Errorf("%w (%d of %d MB used)", ErrStorageQuota, used>>20, config.UploadQuotaMB)
+	}
+
+	if s.scanner != nil {
+		res, err := s.scanner.Scan(context.Background(), header.Filename, data)
+		if err != nil {
+			log.Printf("[storage] ⚠️ virus scan of upload by user %d failed: %v", userID, err)
+			return nil, ErrScannerUnavailable
+		}
+		if res.Infected {
+			log.Printf("[storage] 🚫 upload by user %d rejected: %s", userID, res.Signature)
+			return nil, fmt.Errorf("%w: %s", ErrImageInfected, res.Signature)
+		}
 	}
 
 	userDir := filepath.Join(s.basePath, strconv.Itoa(int(userID)))
 	os.MkdirAll(userDir, 0755)
 
 	timestamp := time.Now().Unix()
-	ext := filepath.Ext(header.Filename)
-	filename := fmt.Sprintf("avatar_%d%s", timestamp, ext)
+	filename := fmt.Sprintf("avatar_%d%s", timestamp, exts[0])
 	filePath := filepath.Join(userDir, filename)
 
-	dst, err := os.Create(filePath)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create file: %w", err)
-	}
-	defer dst.Close()
-
-	_, err = io.Copy(dst, file)
-	if err != nil {
+	if err := os.WriteFile(filePath, data, 0644); err != nil {
 		return nil, fmt.Errorf("failed to save file: %w", err)
 	}
 
 	relativePath := fmt.Sprintf("%d/%s", userID, filename)
-	publicURL := fmt.Sprintf("%s/%s", s.baseURL, relativePath)
 
 	return &SaveImageResponse{
-		Filename:  filename,
-		FilePath:  relativePath,
-		PublicURL: publicURL,
-		FileSize:  header.Size,
+		Filename: filename,
+		FilePath: relativePath,
+		FileSize: int64(len(data)),
 	}, nil
 }
 
-func (s *ObjectStorageService) GenerateImageURL(imagePath string) string {
+// UsedBytes is the size of the images a user has stored.
+func (s *ObjectStorageService) UsedBytes(userID uint) (int64, error) {
+	var used int64
+	err := filepath.WalkDir(filepath.Join(s.basePath, strconv.Itoa(int(userID))), func(_ string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				return nil
+			}
+			return err
+		}
+		if d.Type().IsRegular() {
+			info, err := d.Info()
+			if err != nil {
+				return err
+			}
+			used += info.Size()
+		}
+		return nil
+	})
+	return used, err
+}
+
+// GenerateImageURL returns the URL of a stored profile image. Public images
+// get a plain URL; private ones a URL signed with STORAGE_SIGNING_KEY that
+// expires after IMAGE_URL_TTL_MINUTES, returned with its expiry.
+func (s *ObjectStorageService) GenerateImageURL(imagePath string, public bool) (string, time.Time) {
 	if imagePath == "" {
-		return ""
+		return "", time.Time{}
+	}
+	if public {
+		return fmt.Sprintf("%s/%s", s.baseURL, imagePath), time.Time{}
 	}
-	return fmt.Sprintf("%s/%s", s.baseURL, imagePath)
+	expires := time.Now().Add(time.Duration(config.ImageURLTTLMinutes) * time.Minute)
+	sig := s.downloadSignature("profiles/"+imagePath, expires.Unix())
+	return fmt.Sprintf("%s/%s?expires=%d&signature=%s", s.baseURL, imagePath, expires.Unix(), sig), expires
+}
+
+// OpenImage returns the file of a stored profile image. A signature is
+// checked like OpenCertificate does; without one the image is only opened
+// when public is true.
+func (s *ObjectStorageService) OpenImage(relPath, expires, signature string, public bool) (string, error) {
+	if signature != "" || !public {
+		exp, err := strconv.ParseInt(expires, 10, 64)
+		if err != nil || time.Now().Unix() > exp ||
+			!hmac.Equal([]byte(signature), []byte(s.downloadSignature("profiles/"+relPath, exp))) {
+			return "", ErrInvalidSignature
+		}
+	}
+	return storedFile(s.basePath, relPath)
 }
 
 func (s *ObjectStorageService) DeleteImage(imagePath string) error {
@@ -116,6 +221,81 @@ func (s *ObjectStorageService) DeleteImage(imagePath string) error {
 	return os.Remove(fullPath)
 }
 
+// certificatesPath holds generated certificates. It is outside ./uploads,
+// which is served publicly, so certificates are only reachable through
+// signed URLs.
+const certificatesPath = "./storage/certificates"
+
+// ErrInvalidSignature is returned by OpenCertificate for a tampered or
+// expired download URL.
+var ErrInvalidSignature = errors.New("invalid or expired signature")
+
+// SaveCertificate stores the certificate PDF of a registration and returns
+// its path relative to the certificate store.
+func (s *ObjectStorageService) SaveCertificate(userID, registrationID uint, pdf []byte) (string, error) {
+	userDir := filepath.Join(certificatesPath, strconv.Itoa(int(userID)))
+	if err := os.MkdirAll(userDir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create certificate directory: %w", err)
+	}
+	filename := fmt.Sprintf("certificate_%d.pdf", registrationID)
+	if err := os.WriteFile(filepath.Join(userDir, filename), pdf, 0644); err != nil {
+		return "", fmt.Errorf("failed to save certificate: %w", err)
+	}
+	return fmt.Sprintf("%d/%s", userID, filename), nil
+}
+
+// HasCertificate reports whether the certificate at relPath is stored.
+func (s *ObjectStorageService) HasCertificate(relPath string) bool {
+	if relPath == "" {
+		return false
+	}
+	_, err := os.Stat(filepath.Join(certificatesPath, filepath.FromSlash(relPath)))
+	return err == nil
+}
+
+// SignedCertificateURL returns a download URL for the certificate at
+// relPath that is valid for ttl.
+func (s *ObjectStorageService) SignedCertificateURL(relPath string, ttl time.Duration) (string, time.Time) {
+	expires := time.Now().Add(ttl)
+	sig := s.downloadSignature(relPath, expires.Unix())
+	base := strings.TrimSuffix(s.baseURL, "/uploads/profiles")
+	return fmt.Sprintf("%s/files/certificates/%s?expires=%d&signature=%s", base, relPath, expires.Unix(), sig), expires
+}
+
+// OpenCertificate checks the signature of a certificate download URL and
+// returns the file to serve.
+func (s *ObjectStorageService) OpenCertificate(relPath, expires, signature string) (string, error) {
+	exp, err := strconv.ParseInt(expires, 10, 64)
+	if err != nil || time.Now().Unix() > exp ||
+		!hmac.Equal([]byte(signature), []byte(s.downloadSignature(relPath, exp))) {
+		return "", ErrInvalidSignature
+	}
+	return storedFile(certificatesPath, relPath)
+}
+
+// storedFile is the file at relPath under base, which relPath may not leave.
+func storedFile(base, relPath string) (string, error) {
+	clean := filepath.Clean(filepath.FromSlash(relPath))
+	if filepath.IsAbs(clean) || strings.HasPrefix(clean, "..") {
+		return "", ErrInvalidSignature
+	}
+	full := filepath.Join(base, clean)
+	if info, err := os.Stat(full); err != nil {
+		return "", err
+	} else if !info.Mode().IsRegular() {
+		return "", fs.ErrNotExist
+	}
+	return full, nil
+}
+
+// downloadSignature signs a download path and its expiry with
+// STORAGE_SIGNING_KEY.
+func (s *ObjectStorageService) downloadSignature(relPath string, expires int64) string {
+	h := hmac.New(sha256.New, []byte("storage-download:"+config.StorageSigningKey))
+	fmt.Fprintf(h, "%s:%d", relPath, expires)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
 func (s *ObjectStorageService) generateSimpleSignedToken(userID uint, timestamp int64) string {
 	message := fmt.Sprintf("%d:%d", userID, timestamp)
 	h := hmac.New(sha256.New, []byte(s.secretKey))
@@ -185,10 +365,9 @@ type UploadTokenResponse struct {
 }
 
 type SaveImageResponse struct {
-	Filename  string `json:"filename"`
-	FilePath  string `json:"file_path"`
-	PublicURL string `json:"public_url"`
-	FileSize  int64  `json:"file_size"`
+	Filename string `json:"filename"`
+	FilePath string `json:"file_path"`
+	FileSize int64  `json:"file_size"`
 }
 
 type ProfileImageResponse struct {
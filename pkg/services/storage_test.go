@@ -0,0 +1,75 @@
+package services
+
+import (
+	"AkuAI/pkg/config"
+	"AkuAI/pkg/virusscan"
+	"bytes"
+	"context"
+	"errors"
+	"mime/multipart"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+type memFile struct{ *bytes.Reader }
+
+func (memFile) Close() error { return nil }
+
+type fakeScanner struct {
+	res virusscan.Result
+	err error
+}
+
+func (f fakeScanner) Scan(context.Context, string, []byte) (virusscan.Result, error) {
+	return f.res, f.err
+}
+
+func TestSaveUploadedImage(t *testing.T) {
+	prevMax, prevQuota := config.UploadMaxMB, config.UploadQuotaMB
+	t.Cleanup(func() { config.UploadMaxMB, config.UploadQuotaMB = prevMax, prevQuota })
+	config.UploadMaxMB, config.UploadQuotaMB = 1, 2
+
+	s := &ObjectStorageService{basePath: t.TempDir(), baseURL: "http://localhost/uploads/profiles", secretKey: "k"}
+	png := append([]byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR"), make([]byte, 64)...)
+	upload := func(name string, data []byte) (*SaveImageResponse, error) {
+		token, _ := s.GenerateUploadToken(7, filepath.Ext(name))
+		return s.SaveUploadedImage(7, memFile{bytes.NewReader(data)}, &multipart.FileHeader{Filename: name, Size: int64(len(data))}, token.UploadToken)
+	}
+
+	res, err := upload("me.PNG", png)
+	if err != nil || filepath.Ext(res.Filename) != ".png" || res.FileSize != int64(len(png)) {
+		t.Fatalf("png upload = %+v, %v", res, err)
+	}
+	for name, data := range map[string][]byte{
+		"script.png": []byte("<?php system($_GET['c']); ?>"),
+		"me.gif":     png,
+	} {
+		if _, err := upload(name, data); !errors.Is(err, ErrImageType) {
+			t.Errorf("%s: err = %v, want ErrImageType", name, err)
+		}
+	}
+	if _, err := upload("big.png", append(png, make([]byte, 1<<20)...)); !errors.Is(err, ErrImageTooLarge) {
+		t.Errorf("oversized upload: err = %v", err)
+	}
+
+	if err := os.WriteFile(filepath.Join(s.basePath, "7", "old.png"), make([]byte, 2<<20-100), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := upload("me.png", png); !errors.Is(err, ErrStorageQuota) {
+		t.Errorf("upload over quota: err = %v", err)
+	}
+	os.Remove(filepath.Join(s.basePath, "7", "old.png"))
+
+	s.scanner = fakeScanner{res: virusscan.Result{Infected: true, Signature: "Eicar-Test-Signature"}}
+	if _, err := upload("me.png", png); !errors.Is(err, ErrImageInfected) {
+		t.Errorf("infected upload: err = %v", err)
+	}
+	s.scanner = fakeScanner{err: errors.New("connection refused")}
+	if _, err := upload("me.png", png); !errors.Is(err, ErrScannerUnavailable) {
+		t.Errorf("upload without scanner: err = %v", err)
+	}
+	if used, _ := s.UsedBytes(7); used != int64(len(png)) {
+		t.Errorf("used = %d, want only the first upload", used)
+	}
+}
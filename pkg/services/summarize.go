@@ -0,0 +1,6 @@
+package services
+
+// SummarizeInstruction asks the model to condense its previous reply, which
+// is passed back as the previous model turn.
+const SummarizeInstruction = "Ringkas jawaban Anda sebelumnya menjadi paling banyak 3-4 kalimat atau butir berisi informasi terpentingnya. " +
+	"Jangan menambahkan informasi baru dan pertahankan penanda sumber [EV-...] yang dipakai."
@@ -0,0 +1,137 @@
+package services
+
+import (
+	"AkuAI/pkg/cache"
+	"AkuAI/pkg/config"
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+)
+
+// QueryLabel is the coarse topic of a user query, used to pick the prompt
+// template and reported in analytics.
+type QueryLabel string
+
+const (
+	LabelEvents     QueryLabel = "events"
+	LabelAcademics  QueryLabel = "academics"
+	LabelAdmissions QueryLabel = "admissions"
+	LabelFacilities QueryLabel = "facilities"
+	LabelOther      QueryLabel = "other"
+)
+
+// queryLabelOrder breaks ties between labels with the same number of hits.
+var queryLabelOrder = []QueryLabel{LabelEvents, LabelAdmissions, LabelAcademics, LabelFacilities}
+
+var queryLabelKeywords = map[QueryLabel][]string{
+	LabelEvents: {"acara", "event", "kegiatan", "webinar", "seminar", "sertifikasi", "workshop", "pelatihan",
+		"bootcamp", "talkshow", "lomba", "kompetisi", "kuliah umum"},
+	LabelAdmissions: {"pmb", "mahasiswa baru", "maba", "daftar kuliah", "pendaftaran kuliah", "jalur masuk", "syarat masuk",
+		"tes masuk", "ujian masuk", "admission", "biaya kuliah", "uang kuliah", "ukt", "spp", "beasiswa", "registrasi ulang",
+		"her registrasi", "kuota penerimaan"},
+	LabelAcademics: {"jurusan", "prodi", "program studi", "fakultas", "kurikulum", "mata kuliah", "matkul", "sks", "krs",
+		"khs", "ipk", "transkrip", "skripsi", "tugas akhir", "dosen", "jadwal kuliah", "ujian", "uts", "uas", "semester",
+		"akreditasi", "wisuda", "cuti akademik", "magang", "kalender akademik"},
+	LabelFacilities: {"perpustakaan", "library", "laboratorium", "lab ", "parkir", "kantin", "wifi", "asrama", "gedung",
+		"ruang kelas", "masjid", "mushola", "klinik", "gym", "lapangan", "fasilitas", "shuttle", "alamat kampus",
+		"lokasi kampus", "auditorium"},
+}
+
+// ClassifyQueryRules labels text using keyword hits only. weak is true when
+// no label matched or two labels matched equally often.
+func ClassifyQueryRules(text string) (label QueryLabel, weak bool) {
+	lower := " " + strings.ToLower(text) + " "
+	best, bestHits, tie := LabelOther, 0, false
+	for _, l := range queryLabelOrder {
+		hits := 0
+		for _, kw := range queryLabelKeywords[l] {
+			if strings.Contains(lower, kw) {
+				hits++
+			}
+		}
+		if l == LabelEvents && hits == 0 && len(detectEventTypes(strings.ToLower(text))) > 0 {
+			hits = 1
+		}
+		switch {
+		case hits > bestHits:
+			best, bestHits, tie = l, hits, false
+		case hits > 0 && hits == bestHits:
+			tie = true
+		}
+	}
+	return best, bestHits == 0 || tie
+}
+
+func parseQueryLabel(s string) (QueryLabel, bool) {
+	s = strings.ToLower(strings.Trim(strings.TrimSpace(s), "`\"'."))
+	for _, l := range append(queryLabelOrder, LabelOther) {
+		if strings.Contains(s, string(l)) {
+			return l, true
+		}
+	}
+	return LabelOther, false
+}
+
+// ClassifyQuery labels text with the keyword rules and, when they are
+// inconclusive and TOPIC_CLASSIFIER_GEMINI is on, asks Gemini. Results are
+// cached so routing and persistence of the same query agree.
+func (s *GeminiService) ClassifyQuery(ctx context.Context, text string) QueryLabel {
+	key := cache.NamespacedKey("query-label-v1", strings.ToLower(strings.TrimSpace(text)))
+	if v, ok := cache.Default().Get(key); ok {
+		if l, ok := v.(QueryLabel); ok {
+			return l
+		}
+	}
+
+	label, weak := ClassifyQueryRules(text)
+	if weak && config.TopicClassifierGemini && s != nil && s.enabled && strings.TrimSpace(s.apiKey) != "" &&
+		!(config.IsStaging || (config.IsProduction && !config.IsGeminiEnabled)) {
+		if l, ok := s.classifyQueryWithGemini(ctx, text); ok {
+			label = l
+		}
+	}
+	cache.Default().Set(key, label, time.Hour)
+	return label
+}
+
+func (s *GeminiService) classifyQueryWithGemini(ctx context.Context, text string) (QueryLabel, bool) {
+	prompt := fmt.Sprintf(`Klasifikasikan pertanyaan mahasiswa berikut ke SATU label: events (acara, webinar, sertifikasi), academics (jurusan, kuliah, nilai, dosen), admissions (pendaftaran mahasiswa baru, biaya, beasiswa), facilities (gedung, perpustakaan, fasilitas kampus), atau other.
+
+Balas hanya dengan labelnya.
+
+Pertanyaan: %s`, text)
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	for _, model := range []string{config.GeminiModel, "gemini-2.0-flash"} {
+		if strings.TrimSpace(model) == "" {
+			continue
+		}
+		response, err := s.callGenerateContent(ctx, model, prompt)
+		if err != nil {
+			continue
+		}
+		if l, ok := parseQueryLabel(response); ok {
+			log.Printf("[classifier] gemini labelled %.40q as %s", text, l)
+			return l, true
+		}
+	}
+	return LabelOther, false
+}
+
+// topicSystemInstruction returns the system instruction for non-event
+// queries, focused on the query's label.
+func topicSystemInstruction(label QueryLabel) string {
+	const base = "Anda adalah asisten kampus yang sangat membantu. Jawab secara rinci, terstruktur (gunakan poin-poin atau langkah), dan jelas dalam Bahasa Indonesia. Jika konteks tidak cukup, minta klarifikasi singkat. Tetap fokus pada topik akademik/kampus."
+	switch label {
+	case LabelAcademics:
+		return base + " Pertanyaan ini tentang akademik: jelaskan prosedur (KRS, SKS, ujian, skripsi, wisuda) langkah demi langkah dan sarankan untuk mengonfirmasi ke program studi atau BAAK karena aturan dapat berbeda per fakultas."
+	case LabelAdmissions:
+		return base + " Pertanyaan ini tentang penerimaan mahasiswa baru: uraikan jalur masuk, syarat, dan tahapan pendaftaran. Jangan menyebut nominal biaya atau tanggal yang tidak pasti; arahkan ke laman PMB resmi untuk angka terbaru."
+	case LabelFacilities:
+		return base + " Pertanyaan ini tentang fasilitas kampus: sebutkan nama fasilitas, fungsi, dan cara mengaksesnya (jam layanan, syarat) jika diketahui; bila tidak yakin lokasinya, katakan demikian."
+	}
+	return base
+}
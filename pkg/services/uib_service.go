@@ -12,36 +12,49 @@ import (
 	"AkuAI/models"
 )
 
+// UIBEventService holds the event dataset of one campus. The UIB dataset is
+// the default; other campuses are loaded through CampusDataService.
 type UIBEventService struct {
 	eventsData *models.UIBEventsData
+	source     string
+	path       string // file the dataset was loaded from, written by ImportEvents
+	hash       string // sha256 of the file as loaded
 }
 
 func NewUIBEventService() (*UIBEventService, error) {
-	service := &UIBEventService{}
-	err := service.loadEventsData()
+	return newEventDataset(defaultCampusDataset)
+}
+
+func newEventDataset(dataPath string) (*UIBEventService, error) {
+	service := &UIBEventService{source: filepath.Base(dataPath), path: dataPath}
+	err := service.loadEventsData(dataPath)
 	if err != nil {
-		return nil, fmt.Errorf("failed to load UIB events data: %w", err)
+		return nil, fmt.Errorf("failed to load events data %s: %w", dataPath, err)
 	}
+	trackDataset(service)
 	return service, nil
 }
 
-// loadEventsData loads the UIB events from JSON file
-func (s *UIBEventService) loadEventsData() error {
-	// Get the path to the data directory
-	dataPath := filepath.Join("data", "uib_events.json")
-
+// loadEventsData loads the campus events from a JSON file
+func (s *UIBEventService) loadEventsData(dataPath string) error {
 	// Read the JSON file
 	data, err := os.ReadFile(dataPath)
 	if err != nil {
 		return fmt.Errorf("error reading UIB events file: %w", err)
 	}
 
+	s.hash = shaHex(string(data))
+
 	// Parse JSON
 	s.eventsData = &models.UIBEventsData{}
 	err = json.Unmarshal(data, s.eventsData)
 	if err != nil {
 		return fmt.Errorf("error parsing UIB events JSON: %w", err)
 	}
+	for _, events := range s.eventsData.Lists() {
+		parseEventFees(*events)
+		parseEventTimes(*events)
+	}
 
 	return nil
 }
@@ -50,9 +63,9 @@ func (s *UIBEventService) loadEventsData() error {
 func (s *UIBEventService) GetAllEvents() []models.UIBEvent {
 	var allEvents []models.UIBEvent
 
-	allEvents = append(allEvents, s.eventsData.UIBEvents.October2025...)
-	allEvents = append(allEvents, s.eventsData.UIBEvents.November2025...)
-	allEvents = append(allEvents, s.eventsData.UIBEvents.December2025...)
+	for _, events := range s.eventsData.Lists() {
+		allEvents = append(allEvents, *events...)
+	}
 
 	return allEvents
 }
@@ -64,7 +77,7 @@ func (s *UIBEventService) SearchEvents(criteria models.EventSearchCriteria) []mo
 
 	for _, event := range allEvents {
 		// Filter by type
-		if criteria.EventType != "" && event.Type != criteria.EventType {
+		if criteria.EventType != "" && !strings.EqualFold(event.Type, criteria.EventType) {
 			continue
 		}
 
@@ -80,9 +93,8 @@ func (s *UIBEventService) SearchEvents(criteria models.EventSearchCriteria) []mo
 			}
 		}
 
-		// Filter by department
-		if criteria.Department != "" &&
-			!strings.Contains(strings.ToLower(event.Department), strings.ToLower(criteria.Department)) {
+		// Filter by department and faculty
+		if !InUnit(event, criteria.Department, criteria.Faculty) {
 			continue
 		}
 
@@ -93,6 +105,11 @@ func (s *UIBEventService) SearchEvents(criteria models.EventSearchCriteria) []mo
 			}
 		}
 
+		// Filter by price range
+		if !inFeeRange(event, criteria.MinFee, criteria.MaxFee) {
+			continue
+		}
+
 		filteredEvents = append(filteredEvents, event)
 	}
 
@@ -134,7 +151,7 @@ func (s *UIBEventService) GetEventsByType(eventType string) []models.UIBEvent {
 	var filteredEvents []models.UIBEvent
 
 	for _, event := range allEvents {
-		if event.Type == eventType {
+		if strings.EqualFold(event.Type, eventType) {
 			filteredEvents = append(filteredEvents, event)
 		}
 	}
@@ -142,19 +159,15 @@ func (s *UIBEventService) GetEventsByType(eventType string) []models.UIBEvent {
 	return filteredEvents
 }
 
-// GetUpcomingEvents returns events from today onwards
+// GetUpcomingEvents returns events that are not over yet
 func (s *UIBEventService) GetUpcomingEvents() []models.UIBEvent {
 	allEvents := s.GetAllEvents()
 	var upcomingEvents []models.UIBEvent
 	now := time.Now()
 
 	for _, event := range allEvents {
-		eventDate, err := time.Parse("2006-01-02", event.Date)
-		if err != nil {
-			continue
-		}
-
-		if eventDate.After(now) || eventDate.Equal(now.Truncate(24*time.Hour)) {
+		end, ok := EventEnd(event)
+		if ok && end.After(now) {
 			upcomingEvents = append(upcomingEvents, event)
 		}
 	}
@@ -164,18 +177,26 @@ func (s *UIBEventService) GetUpcomingEvents() []models.UIBEvent {
 
 // GetEventSummaries returns summary of all events
 func (s *UIBEventService) GetEventSummaries() []models.EventSummary {
-	allEvents := s.GetAllEvents()
+	return s.SummarizeEvents(s.GetAllEvents())
+}
+
+// SummarizeEvents returns the summaries of events
+func (s *UIBEventService) SummarizeEvents(events []models.UIBEvent) []models.EventSummary {
 	var summaries []models.EventSummary
 
-	for _, event := range allEvents {
+	for _, event := range events {
 		summary := models.EventSummary{
 			ID:         event.ID,
 			Type:       event.Type,
 			Title:      event.Title,
 			Date:       event.Date,
 			Time:       event.Time,
+			StartsAt:   event.StartsAt,
+			EndsAt:     event.EndsAt,
 			Department: event.Department,
+			Faculty:    EventFaculty(event),
 			IsFree:     s.isFreeEvent(event),
+			Fee:        event.Fee,
 			Mark:       event.Mark,
 		}
 		summaries = append(summaries, summary)
@@ -196,52 +217,29 @@ func (s *UIBEventService) FormatEventsForGemini(events []models.UIBEvent) string
 	formatted.WriteString("TANGGAL SEKARANG: 4 Oktober 2025\n")
 	formatted.WriteString("INSTRUKSI: Langsung berikan SEMUA data yang tersedia, jangan tanya balik\n\n")
 
-	// Group by month
+	// Group by month, months in order of their first event so the most
+	// relevant events (see EventContext) come first and the context hashes
+	// the same on every call
 	monthEvents := make(map[string][]models.UIBEvent)
+	var months []string
 	for _, event := range events {
 		eventDate, err := time.Parse("2006-01-02", event.Date)
 		if err != nil {
 			continue
 		}
 		monthName := strings.ToUpper(eventDate.Format("January 2006"))
+		if _, ok := monthEvents[monthName]; !ok {
+			months = append(months, monthName)
+		}
 		monthEvents[monthName] = append(monthEvents[monthName], event)
 	}
 
-	for month, monthEventsList := range monthEvents {
+	for _, month := range months {
+		monthEventsList := monthEvents[month]
 		formatted.WriteString(fmt.Sprintf("📅 %s:\n", month))
 
 		for _, event := range monthEventsList {
-			formatted.WriteString(fmt.Sprintf("\n🎯 %s - %s\n", strings.ToUpper(event.Type), event.Title))
-			formatted.WriteString(fmt.Sprintf("   📍 Tanggal: %s", event.Date))
-			if event.Time != "" {
-				formatted.WriteString(fmt.Sprintf(" | ⏰ Waktu: %s", event.Time))
-			}
-			formatted.WriteString("\n")
-
-			if event.Location != "" {
-				formatted.WriteString(fmt.Sprintf("   🏢 Lokasi: %s\n", event.Location))
-			}
-			if event.Platform != "" {
-				formatted.WriteString(fmt.Sprintf("   💻 Platform: %s\n", event.Platform))
-			}
-
-			formatted.WriteString(fmt.Sprintf("   🏛️  Departemen: %s\n", event.Department))
-			formatted.WriteString(fmt.Sprintf("   📋 Deskripsi: %s\n", event.Description))
-
-			if event.Speaker != "" {
-				formatted.WriteString(fmt.Sprintf("   🎤 Pembicara: %s\n", event.Speaker))
-			}
-			if event.Requirements != "" {
-				formatted.WriteString(fmt.Sprintf("   📋 Persyaratan: %s\n", event.Requirements))
-			}
-			if event.RegistrationFee != "" {
-				formatted.WriteString(fmt.Sprintf("   💰 Biaya: %s\n", event.RegistrationFee))
-			}
-			if event.Contact != "" {
-				formatted.WriteString(fmt.Sprintf("   📞 Kontak: %s\n", event.Contact))
-			}
-
-			formatted.WriteString("   ✅ STATUS: UIB_OFFICIAL (Data Resmi UIB)\n")
+			writeEventBlock(&formatted, event)
 		}
 		formatted.WriteString("\n")
 	}
@@ -251,18 +249,67 @@ func (s *UIBEventService) FormatEventsForGemini(events []models.UIBEvent) string
 	formatted.WriteString("\n=== CONTOH FORMAT JAWABAN YANG DIINGINKAN ===\n")
 	formatted.WriteString("Contoh: Jika ditanya 'sertifikasi November 2025 UIB?'\n")
 	formatted.WriteString("Jawab: 'Berikut sertifikasi UIB untuk November 2025 (Data resmi UIB_OFFICIAL):'\n")
-	formatted.WriteString("1. [Nama Sertifikasi] - [Tanggal] - [Biaya] - [Kontak]'\n")
+	formatted.WriteString("1. [Nama Sertifikasi] - [Tanggal] - [Biaya] - [Kontak] [EV-CERT-NOV-001]'\n")
 	formatted.WriteString("2. [dst...] - LANGSUNG berikan semua, jangan tanya balik!\n")
+	formatted.WriteString("SITASI: setiap baris tentang acara WAJIB diakhiri penanda 🔖 Sumber acara tersebut, misalnya [EV-CERT-NOV-001]\n")
 	formatted.WriteString("\n=== AKHIR DATA UIB ===\n")
 
-	return formatted.String()
+	return s.Localize(formatted.String())
+}
+
+// writeEventBlock writes one event of the context built by
+// FormatEventsForGemini.
+func writeEventBlock(b *strings.Builder, event models.UIBEvent) {
+	b.WriteString(fmt.Sprintf("\n🎯 %s - %s\n", strings.ToUpper(EventTypeLabel(event.Type)), event.Title))
+	b.WriteString(fmt.Sprintf("   🔖 Sumber: [%s]\n", CitationMarker(event.ID)))
+	b.WriteString(fmt.Sprintf("   📍 Tanggal: %s", event.Date))
+	if event.Time != "" {
+		b.WriteString(fmt.Sprintf(" | ⏰ Waktu: %s", event.Time))
+	}
+	b.WriteString("\n")
+
+	if event.Location != "" {
+		b.WriteString(fmt.Sprintf("   🏢 Lokasi: %s\n", event.Location))
+	}
+	if event.Platform != "" {
+		b.WriteString(fmt.Sprintf("   💻 Platform: %s\n", event.Platform))
+	}
+
+	b.WriteString(fmt.Sprintf("   🏛️  Departemen: %s\n", event.Department))
+	b.WriteString(fmt.Sprintf("   📋 Deskripsi: %s\n", event.Description))
+
+	if event.Speaker != "" {
+		b.WriteString(fmt.Sprintf("   🎤 Pembicara: %s\n", event.Speaker))
+	}
+	if event.Requirements != "" {
+		b.WriteString(fmt.Sprintf("   📋 Persyaratan: %s\n", event.Requirements))
+	}
+	if event.RegistrationFee != "" {
+		b.WriteString(fmt.Sprintf("   💰 Biaya: %s\n", event.FeeText()))
+	}
+	if event.Contact != "" {
+		b.WriteString(fmt.Sprintf("   📞 Kontak: %s\n", event.Contact))
+	}
+
+	b.WriteString("   ✅ STATUS: UIB_OFFICIAL (Data Resmi UIB)\n")
 }
 
 // AnalyzeQueryForUIB analyzes if a query is related to UIB EVENTS/ACTIVITIES (not general info like jurusan)
 func (s *UIBEventService) AnalyzeQueryForUIB(query string) bool {
 	queryLower := strings.ToLower(query)
 
-	// If query is about jurusan/fakultas/program studi, use pure Gemini instead
+	genericEventKeys := []string{"acara", "event", "seminar", "webinar", "sertifikasi", "pelatihan", "workshop"}
+	hasEventWord := false
+	for _, k := range genericEventKeys {
+		if strings.Contains(queryLower, k) {
+			hasEventWord = true
+			break
+		}
+	}
+
+	// If query is about jurusan/fakultas/program studi, use pure Gemini instead,
+	// unless it asks for the events of one we have ("acara dari fakultas ekonomi")
+	unitEvents := hasEventWord && len(s.unitsInQuery(queryLower)) > 0
 	jurusanKeywords := []string{
 		"jurusan", "fakultas", "program studi", "prodi",
 		"teknik informatika", "sistem informasi", "manajemen",
@@ -271,7 +318,7 @@ func (s *UIBEventService) AnalyzeQueryForUIB(query string) bool {
 	}
 
 	for _, keyword := range jurusanKeywords {
-		if strings.Contains(queryLower, keyword) {
+		if strings.Contains(queryLower, keyword) && !unitEvents {
 			log.Printf("[uib-service] 🎓 Jurusan query detected - using pure Gemini: %s", query)
 			return false // Use pure Gemini for academic program info
 		}
@@ -302,47 +349,24 @@ func (s *UIBEventService) AnalyzeQueryForUIB(query string) bool {
 		}
 	}
 
-	// Also detect general webinar/sertifikasi queries for Oct–Dec 2025
-	// since we have UIB data for that period
-	monthEventPatterns := []string{
-		"webinar november", "sertifikasi november",
-		"acara november", "event november",
-		"seminar november", "workshop november", "nov ",
-		"webinar oktober", "sertifikasi oktober",
-		"webinar desember", "sertifikasi desember", "okt ", "des ",
-		"november 2025", "oktober 2025", "desember 2025",
-	}
-
-	for _, pattern := range monthEventPatterns {
-		if strings.Contains(queryLower, pattern) {
-			return true
-		}
-	}
-
-	// Numeric month detection (10,11,12) optionally with year 2025
-	// e.g., "bulan 11", "bln 10", "11 2025", "nov 2025" handled above; here we catch pure numeric
-	if containsNumericMonth(queryLower) {
+	// A month we have event data for ("november", "bulan 11", "11/2025")
+	if s.asksAboutEventMonth(queryLower) {
 		return true
 	}
 
+	// Non-UIB datasets: the campus name plus an event word is enough
+	mentionsSelf := !s.IsUIB() && s.mentionedIn(queryLower)
+
 	// Heuristic: default to UIB if query talks about events and no other university is explicitly mentioned
-	genericEventKeys := []string{"acara", "event", "seminar", "webinar", "sertifikasi", "pelatihan", "workshop"}
 	otherCampusHints := []string{"universitas indonesia", "ui ", "ugm", "gadjah mada", "itb", "ipb", "airlangga", "binus"}
-	hasEventWord := false
-	for _, k := range genericEventKeys {
-		if strings.Contains(queryLower, k) {
-			hasEventWord = true
-			break
-		}
-	}
 	mentionsOther := false
 	for _, o := range otherCampusHints {
-		if strings.Contains(queryLower, o) {
+		if strings.Contains(queryLower, o) && !s.isOwnAlias(o) {
 			mentionsOther = true
 			break
 		}
 	}
-	if hasEventWord && !mentionsOther {
+	if hasEventWord && (!mentionsOther || mentionsSelf) {
 		return true
 	}
 
@@ -367,16 +391,17 @@ func (s *UIBEventService) GetRelevantEventsForQuery(query string) []models.UIBEv
 	allEvents := s.GetAllEvents()
 	var relevantEvents []models.UIBEvent
 
-	monthPrefixes := detectMonthPrefixes(queryLower)
-	requiredType := detectEventType(queryLower)
+	monthPrefixes := s.monthPrefixes(queryLower)
+	types := detectEventTypes(queryLower)
+	units := s.unitsInQuery(queryLower)
 
 	// Relative range detection (e.g., minggu depan)
-	if start, end, ok := detectRelativeRange(queryLower, time.Now()); ok {
+	if start, end, ok := detectRelativeRange(queryLower, time.Now().In(EventLocation())); ok {
 		// prefilter by date range
 		ranged := make([]models.UIBEvent, 0)
 		for _, ev := range allEvents {
-			evDate, err := time.Parse("2006-01-02", ev.Date)
-			if err != nil {
+			evDate, ok := EventDay(ev)
+			if !ok {
 				continue
 			}
 			if (evDate.Equal(start) || evDate.After(start)) && (evDate.Equal(end) || evDate.Before(end)) {
@@ -394,8 +419,7 @@ func (s *UIBEventService) GetRelevantEventsForQuery(query string) []models.UIBEv
 		if len(monthPrefixes) > 0 && (datePrefix == "" || !monthPrefixes[datePrefix]) {
 			continue
 		}
-		// If both webinar and certification are requested, don't filter by single type
-		if requiredType != "" && requiredType != "both" && !strings.EqualFold(event.Type, requiredType) {
+		if !eventTypeWanted(types, event.Type) || !inUnits(units, event) {
 			continue
 		}
 		if s.isEventRelevantToQuery(event, queryLower) {
@@ -412,10 +436,10 @@ func (s *UIBEventService) GetRelevantEventsForQuery(query string) []models.UIBEv
 			if len(monthPrefixes) > 0 && (datePrefix == "" || !monthPrefixes[datePrefix]) {
 				continue
 			}
-			if requiredType != "" && requiredType != "both" && !strings.EqualFold(event.Type, requiredType) {
+			if !eventTypeWanted(types, event.Type) || !inUnits(units, event) {
 				continue
 			}
-			if len(monthPrefixes) > 0 || requiredType != "" {
+			if len(monthPrefixes) > 0 || len(types) > 0 || len(units) > 0 {
 				relevantEvents = append(relevantEvents, event)
 			}
 		}
@@ -428,14 +452,10 @@ func (s *UIBEventService) GetRelevantEventsForQuery(query string) []models.UIBEv
 	return relevantEvents
 }
 
-// Helper function to check if event is free
+// Helper function to check if event is free, for some participants at least
 func (s *UIBEventService) isFreeEvent(event models.UIBEvent) bool {
-	if event.RegistrationFee == "" {
-		return true
-	}
-
-	fee := strings.ToLower(event.RegistrationFee)
-	return strings.Contains(fee, "gratis") || strings.Contains(fee, "free") || fee == "0" || fee == "rp 0"
+	fee, ok := eventFee(event)
+	return ok && fee.Min == 0
 }
 
 // Helper function to check if event is relevant to query
@@ -473,98 +493,21 @@ func (s *UIBEventService) isEventRelevantToQuery(event models.UIBEvent, queryLow
 	return false
 }
 
-func detectMonthPrefixes(queryLower string) map[string]bool {
-	monthMap := map[string][]string{
-		"2025-10": {"okt", "oktober", "october"},
-		"2025-11": {"nov", "november"},
-		"2025-12": {"des", "desember", "december"},
-	}
-
-	result := make(map[string]bool)
-	for prefix, keywords := range monthMap {
-		for _, keyword := range keywords {
-			if strings.Contains(queryLower, keyword) {
-				result[strings.ToLower(prefix)] = true
-				break
-			}
-		}
-	}
-
-	// Numeric month handling: 10, 11, 12 (optionally preceded by "bulan"/"bln")
-	// We keep it simple and robust for Indonesian queries
-	if containsNumericMonth(queryLower) {
-		if strings.Contains(queryLower, "10") {
-			result["2025-10"] = true
-		}
-		if strings.Contains(queryLower, "11") {
-			result["2025-11"] = true
-		}
-		if strings.Contains(queryLower, "12") {
-			result["2025-12"] = true
-		}
-	}
-
-	return result
-}
-
-func containsNumericMonth(s string) bool {
-	// normalize whitespace
-	s = strings.ReplaceAll(s, "\t", " ")
-	s = strings.ReplaceAll(s, "\n", " ")
-	// split and strip trailing punctuation from tokens
-	tokens := strings.Fields(s)
-	clean := func(tok string) string {
-		tok = strings.TrimSpace(tok)
-		// strip common trailing punctuation
-		tok = strings.Trim(tok, "?!,:.;()[]{}")
-		return tok
-	}
-	for i, tok := range tokens {
-		ct := clean(tok)
-		if ct == "bulan" || ct == "bln" {
-			if i+1 < len(tokens) {
-				nt := clean(tokens[i+1])
-				if nt == "10" || nt == "11" || nt == "12" {
-					return true
-				}
-			}
-		}
-		if ct == "10" || ct == "11" || ct == "12" { // tolerate bare numeric month
-			return true
-		}
-	}
-	return false
-}
-
-func detectEventType(queryLower string) string {
-	hasWeb := strings.Contains(queryLower, "webinar") || strings.Contains(queryLower, "seminar") || strings.Contains(queryLower, "talkshow") || strings.Contains(queryLower, "kuliah umum")
-
-	certKeywords := []string{
-		"sertifikasi",
-		"certification",
-		"certificate",
-		"pelatihan",
-		"workshop",
-		"bootcamp",
-	}
-	hasCert := false
-	for _, keyword := range certKeywords {
-		if strings.Contains(queryLower, keyword) {
-			hasCert = true
-			break
-		}
-	}
-
-	if hasWeb && hasCert {
-		return "both"
-	}
-	if hasWeb {
-		return "webinar"
+// EventTopic classifies a question by the UIB event type it asks about: one
+// of models.EventTypes, event (several types or events in general) or
+// general.
+func EventTopic(text string) string {
+	switch types := detectEventTypes(strings.ToLower(text)); len(types) {
+	case 0:
+	case 1:
+		return types[0]
+	default:
+		return "event"
 	}
-	if hasCert {
-		return "certification"
+	if _, svc := defaultCampusData().ForQuery(text); svc != nil && svc.AnalyzeQueryForUIB(text) {
+		return "event"
 	}
-	return ""
+	return "general"
 }
 
 // detectRelativeRange recognizes phrases like "minggu depan" and returns an inclusive [start, end] range
@@ -0,0 +1,33 @@
+package services
+
+import (
+	"context"
+	"testing"
+)
+
+func TestUsageSummedOverContinuations(t *testing.T) {
+	f := withFakeGemini(t, "Daftar webinar: satu, dua", "MAX_TOKENS", ", tiga.", "STOP")
+	f.usage = []map[string]any{
+		{"promptTokenCount": 120.0, "candidatesTokenCount": 1024.0, "totalTokenCount": 1144.0},
+		{"promptTokenCount": 1150.0, "candidatesTokenCount": 40.0, "thoughtsTokenCount": 10.0},
+	}
+	ctx, info := WithGenerationInfo(context.Background())
+	if _, err := (&GeminiService{apiKey: "k"}).callGenerateContentWithBody(ctx, "m", []byte(`{}`)); err != nil {
+		t.Fatal(err)
+	}
+	if got := info.Usage(); got != (Usage{PromptTokens: 1270, OutputTokens: 1074}) {
+		t.Errorf("usage = %+v", got)
+	}
+}
+
+func TestStreamUsageTakesLastChunk(t *testing.T) {
+	f := withFakeGemini(t, "satu dua tiga", "STOP")
+	f.usage = []map[string]any{{"promptTokenCount": 50.0, "candidatesTokenCount": 3.0}}
+	ctx, info := WithGenerationInfo(context.Background())
+	if _, err := (&GeminiService{apiKey: "k"}).callStreamGenerateContentWithBody(ctx, "m", []byte(`{}`), nil); err != nil {
+		t.Fatal(err)
+	}
+	if got := info.Usage(); got != (Usage{PromptTokens: 50, OutputTokens: 3}) {
+		t.Errorf("usage = %+v", got)
+	}
+}
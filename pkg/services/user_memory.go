@@ -0,0 +1,26 @@
+package services
+
+import "context"
+
+type userMemoryKey struct{}
+
+// WithUserMemory attaches the memory section of the asking user (see
+// pkg/memory) and any RecommendationSection; the chat prompts append it to
+// their system instruction.
+func WithUserMemory(ctx context.Context, section string) context.Context {
+	if section == "" {
+		return ctx
+	}
+	return context.WithValue(ctx, userMemoryKey{}, section)
+}
+
+// HasUserMemory reports whether a reply is personalised, in which case it
+// must not be shared with other users through the caches.
+func HasUserMemory(ctx context.Context) bool {
+	return userMemoryContext(ctx) != ""
+}
+
+func userMemoryContext(ctx context.Context) string {
+	s, _ := ctx.Value(userMemoryKey{}).(string)
+	return s
+}
@@ -0,0 +1,123 @@
+// Package spa serves a built single-page frontend: files of the bundle as
+// they are, and index.html for any other page path so client-side routing
+// works on reload and deep links.
+//
+// Cache headers follow the usual bundler layout: fingerprinted assets (under
+// an immutable/ or assets/ directory) are cached for a year, index.html is
+// revalidated on every load, and everything else for an hour. Every file
+// carries a content ETag.
+package spa
+
+import (
+	"AkuAI/pkg/apierror"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io/fs"
+	"net/http"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	cacheImmutable = "public, max-age=31536000, immutable"
+	cacheIndex     = "no-cache"
+	cacheDefault   = "public, max-age=3600"
+)
+
+// ErrNoIndex is returned by New for a bundle without index.html.
+var ErrNoIndex = errors.New("frontend bundle has no index.html")
+
+type file struct {
+	data []byte
+	etag string
+}
+
+// Handler serves one bundle, loaded in memory.
+type Handler struct {
+	files    map[string]file
+	skip     []string
+	modified time.Time
+}
+
+// New loads the bundle in fsys. Requests under one of the skip prefixes
+// (e.g. /api/) never fall back to index.html.
+func New(fsys fs.FS, skip ...string) (*Handler, error) {
+	h := &Handler{files: map[string]file{}, skip: skip, modified: time.Now()}
+	err := fs.WalkDir(fsys, ".", func(p string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		data, err := fs.ReadFile(fsys, p)
+		if err != nil {
+			return err
+		}
+		sum := sha256.Sum256(data)
+		h.files[p] = file{data: data, etag: `"` + hex.EncodeToString(sum[:8]) + `"`}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := h.files["index.html"]; !ok {
+		return nil, ErrNoIndex
+	}
+	return h, nil
+}
+
+// Files returns how many files the bundle has.
+func (h *Handler) Files() int { return len(h.files) }
+
+// Serve is meant for gin's NoRoute. It answers GET and HEAD with a bundle
+// file, or index.html for extension-less page paths; anything else is a 404.
+func (h *Handler) Serve(c *gin.Context) {
+	p := c.Request.URL.Path
+	if c.Request.Method != http.MethodGet && c.Request.Method != http.MethodHead || h.skipped(p) {
+		apierror.Respond(c, http.StatusNotFound, "Not found")
+		return
+	}
+	name := strings.TrimPrefix(path.Clean("/"+p), "/")
+	if name == "" {
+		name = "index.html"
+	}
+	f, ok := h.files[name]
+	if !ok {
+		if _, ok := h.files[name+"/index.html"]; ok {
+			name += "/index.html" // prerendered page
+		} else if path.Ext(name) != "" {
+			apierror.Respond(c, http.StatusNotFound, "Not found")
+			return
+		} else {
+			name = "index.html"
+		}
+		f = h.files[name]
+	}
+
+	w := c.Writer
+	w.Header().Set("Cache-Control", cacheControl(name))
+	w.Header().Set("ETag", f.etag)
+	http.ServeContent(w, c.Request, name, h.modified, bytes.NewReader(f.data))
+}
+
+func (h *Handler) skipped(p string) bool {
+	for _, s := range h.skip {
+		if strings.HasPrefix(p, s) {
+			return true
+		}
+	}
+	return false
+}
+
+func cacheControl(name string) string {
+	switch {
+	case path.Ext(name) == ".html":
+		return cacheIndex
+	case strings.Contains("/"+name, "/immutable/"), strings.HasPrefix(name, "assets/"):
+		return cacheImmutable
+	}
+	return cacheDefault
+}
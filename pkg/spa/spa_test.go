@@ -0,0 +1,84 @@
+package spa
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"testing/fstest"
+
+	"github.com/gin-gonic/gin"
+)
+
+func serve(t *testing.T, method, target string, header map[string]string) *httptest.ResponseRecorder {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	h, err := New(fstest.MapFS{
+		"index.html":                  {Data: []byte("<html>app</html>")},
+		"favicon.png":                 {Data: []byte("png")},
+		"_app/immutable/entry/app.js": {Data: []byte("console.log(1)")},
+		"about/index.html":            {Data: []byte("<html>about</html>")},
+		"_app/version.json":           {Data: []byte(`{"version":"1"}`)},
+	}, "/api/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := gin.New()
+	r.GET("/api/v1/ping", func(c *gin.Context) { c.String(http.StatusOK, "pong") })
+	r.NoRoute(h.Serve)
+	req := httptest.NewRequest(method, target, nil)
+	for k, v := range header {
+		req.Header.Set(k, v)
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestServe(t *testing.T) {
+	cases := []struct {
+		target, body, cache string
+		status              int
+	}{
+		{"/", "<html>app</html>", cacheIndex, 200},
+		{"/chat/42", "<html>app</html>", cacheIndex, 200},
+		{"/about", "<html>about</html>", cacheIndex, 200},
+		{"/_app/immutable/entry/app.js", "console.log(1)", cacheImmutable, 200},
+		{"/favicon.png", "png", cacheDefault, 200},
+		{"/missing.js", "", "", 404},
+		{"/api/v1/unknown", "", "", 404},
+		{"/api/v1/ping", "pong", "", 200},
+	}
+	for _, tc := range cases {
+		w := serve(t, http.MethodGet, tc.target, nil)
+		if w.Code != tc.status {
+			t.Errorf("%s: status %d, want %d", tc.target, w.Code, tc.status)
+			continue
+		}
+		if tc.body != "" && w.Body.String() != tc.body {
+			t.Errorf("%s: body %q, want %q", tc.target, w.Body.String(), tc.body)
+		}
+		if got := w.Header().Get("Cache-Control"); got != tc.cache {
+			t.Errorf("%s: Cache-Control %q, want %q", tc.target, got, tc.cache)
+		}
+	}
+}
+
+func TestServeETagAndMethods(t *testing.T) {
+	w := serve(t, http.MethodGet, "/favicon.png", nil)
+	etag := w.Header().Get("ETag")
+	if etag == "" {
+		t.Fatal("no ETag")
+	}
+	if w := serve(t, http.MethodGet, "/favicon.png", map[string]string{"If-None-Match": etag}); w.Code != http.StatusNotModified {
+		t.Errorf("If-None-Match: status %d, want 304", w.Code)
+	}
+	if w := serve(t, http.MethodPost, "/chat", nil); w.Code != http.StatusNotFound {
+		t.Errorf("POST: status %d, want 404", w.Code)
+	}
+}
+
+func TestNewWithoutIndex(t *testing.T) {
+	if _, err := New(fstest.MapFS{"app.js": {Data: []byte("x")}}); err != ErrNoIndex {
+		t.Errorf("err = %v, want ErrNoIndex", err)
+	}
+}
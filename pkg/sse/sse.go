@@ -0,0 +1,294 @@
+// Package sse writes Server-Sent Events and keeps a short replay buffer per
+// stream so a client that loses the connection can reconnect with
+// Last-Event-ID and pick up where it stopped, even mid-answer.
+//
+// Every event is written as
+//
+//	id: <stream id>:<seq>
+//	event: <type>
+//	data: <JSON>
+//
+// JSON encoding keeps each payload on a single data line, so text with
+// newlines needs no escaping scheme of its own.
+package sse
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var ErrStreamingUnsupported = errors.New("streaming unsupported")
+
+// Event is one recorded event of a stream.
+type Event struct {
+	Seq  int
+	Type string
+	Data []byte
+}
+
+// Stream is the replay buffer of one response. It is kept after the response
+// ends until the registry TTL expires.
+type Stream struct {
+	ID    string
+	Owner string
+
+	mu      sync.Mutex
+	events  []Event
+	done    bool
+	changed chan struct{} // closed and replaced on every append or close
+	expires time.Time
+}
+
+func (s *Stream) append(typ string, data []byte) Event {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	ev := Event{Seq: len(s.events) + 1, Type: typ, Data: data}
+	s.events = append(s.events, ev)
+	close(s.changed)
+	s.changed = make(chan struct{})
+	return ev
+}
+
+// Close marks the stream as finished; followers stop after the last event.
+func (s *Stream) Close() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.done {
+		s.done = true
+		close(s.changed)
+		s.changed = make(chan struct{})
+	}
+}
+
+// Since returns the events after seq, whether the stream is finished and a
+// channel that is closed when either changes.
+func (s *Stream) Since(seq int) ([]Event, bool, <-chan struct{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var out []Event
+	if seq < len(s.events) {
+		out = append(out, s.events[max(seq, 0):]...)
+	}
+	return out, s.done, s.changed
+}
+
+// EventID is the id line written for ev.
+func (s *Stream) EventID(ev Event) string {
+	return s.ID + ":" + strconv.Itoa(ev.Seq)
+}
+
+// ParseEventID splits a Last-Event-ID value into stream ID and sequence.
+func ParseEventID(id string) (streamID string, seq int, ok bool) {
+	i := strings.LastIndexByte(id, ':')
+	if i <= 0 {
+		return "", 0, false
+	}
+	n, err := strconv.Atoi(id[i+1:])
+	if err != nil || n < 0 {
+		return "", 0, false
+	}
+	return id[:i], n, true
+}
+
+// Registry holds the replay buffers of recent streams.
+type Registry struct {
+	mu      sync.Mutex
+	streams map[string]*Stream
+	ttl     time.Duration
+}
+
+var (
+	defaultRegistry *Registry
+	once            sync.Once
+)
+
+// Start creates the default registry. Calls after the first one are ignored.
+func Start(ttl time.Duration) *Registry {
+	once.Do(func() {
+		defaultRegistry = NewRegistry(ttl)
+	})
+	return defaultRegistry
+}
+
+func Default() *Registry {
+	return Start(5 * time.Minute)
+}
+
+func NewRegistry(ttl time.Duration) *Registry {
+	return &Registry{streams: map[string]*Stream{}, ttl: ttl}
+}
+
+// Open registers a new stream owned by owner (a user ID).
+func (r *Registry) Open(owner string) *Stream {
+	s := &Stream{ID: uuid.NewString(), Owner: owner, changed: make(chan struct{})}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	now := time.Now()
+	for id, old := range r.streams {
+		old.mu.Lock()
+		expired := old.done && now.After(old.expires)
+		old.mu.Unlock()
+		if expired {
+			delete(r.streams, id)
+		}
+	}
+	s.expires = now.Add(r.ttl)
+	r.streams[s.ID] = s
+	return s
+}
+
+// Finish closes s and keeps it for replay for the registry TTL.
+func (r *Registry) Finish(s *Stream) {
+	s.mu.Lock()
+	s.expires = time.Now().Add(r.ttl)
+	s.mu.Unlock()
+	s.Close()
+}
+
+// Get returns a stream that has not expired yet.
+func (r *Registry) Get(id string) (*Stream, bool) {
+	r.mu.Lock()
+	s, ok := r.streams[id]
+	r.mu.Unlock()
+	if !ok {
+		return nil, false
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.done && time.Now().After(s.expires) {
+		return nil, false
+	}
+	return s, true
+}
+
+// Writer writes events to an HTTP response. It is safe for concurrent use,
+// so the heartbeat can run alongside the handler.
+type Writer struct {
+	mu     sync.Mutex
+	w      http.ResponseWriter
+	f      http.Flusher
+	stream *Stream
+	gone   bool // the client disconnected; events are still recorded
+}
+
+// NewWriter sets the event-stream headers on w.
+func NewWriter(w http.ResponseWriter) (*Writer, error) {
+	f, ok := w.(http.Flusher)
+	if !ok {
+		return nil, ErrStreamingUnsupported
+	}
+	h := w.Header()
+	h.Set("Content-Type", "text/event-stream")
+	h.Set("Cache-Control", "no-cache")
+	h.Set("Connection", "keep-alive")
+	h.Set("X-Accel-Buffering", "no")
+	return &Writer{w: w, f: f}, nil
+}
+
+// Attach records every following event in s and tags it with an id. The
+// retry field tells EventSource clients how soon to reconnect.
+func (w *Writer) Attach(s *Stream, retry time.Duration) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.stream = s
+	w.write(fmt.Sprintf("retry: %d\n\n", retry.Milliseconds()))
+}
+
+// Send JSON-encodes v and writes it as an event of type typ.
+func (w *Writer) Send(typ string, v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	id := ""
+	if w.stream != nil {
+		id = w.stream.EventID(w.stream.append(typ, data))
+	}
+	w.write(format(id, typ, data))
+	return nil
+}
+
+// Comment writes a comment line, which clients ignore.
+func (w *Writer) Comment(text string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.write(": " + text + "\n\n")
+}
+
+// Heartbeat writes a ping comment every interval so proxies don't close an
+// idle connection while the model is thinking. Call the returned func to stop.
+func (w *Writer) Heartbeat(interval time.Duration) func() {
+	if interval <= 0 {
+		return func() {}
+	}
+	stop := make(chan struct{})
+	go func() {
+		t := time.NewTicker(interval)
+		defer t.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-t.C:
+				w.Comment("ping")
+			}
+		}
+	}()
+	var once sync.Once
+	return func() { once.Do(func() { close(stop) }) }
+}
+
+// Replay writes the events of s after seq, then keeps following it until the
+// stream finishes or done is closed.
+func (w *Writer) Replay(s *Stream, seq int, done <-chan struct{}) {
+	for {
+		events, finished, changed := s.Since(seq)
+		for _, ev := range events {
+			w.mu.Lock()
+			w.write(format(s.EventID(ev), ev.Type, ev.Data))
+			w.mu.Unlock()
+			seq = ev.Seq
+		}
+		if finished {
+			return
+		}
+		select {
+		case <-changed:
+		case <-done:
+			return
+		}
+	}
+}
+
+func (w *Writer) write(s string) {
+	if w.gone {
+		return
+	}
+	if _, err := w.w.Write([]byte(s)); err != nil {
+		w.gone = true
+		return
+	}
+	w.f.Flush()
+}
+
+func format(id, typ string, data []byte) string {
+	var b strings.Builder
+	if id != "" {
+		b.WriteString("id: " + id + "\n")
+	}
+	b.WriteString("event: " + typ + "\n")
+	b.WriteString("data: ")
+	b.Write(data)
+	b.WriteString("\n\n")
+	return b.String()
+}
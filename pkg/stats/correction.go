@@ -0,0 +1,74 @@
+package stats
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Multiple-comparison corrections accepted by ParseCorrection.
+const (
+	CorrectionHolm = "holm"
+	CorrectionBH   = "bh"
+	CorrectionNone = "none"
+)
+
+// ParseCorrection normalizes a correction name. Holm is the default because it
+// controls the family-wise error rate without assuming independent tests.
+func ParseCorrection(s string) (string, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "", "holm", "holm-bonferroni":
+		return CorrectionHolm, nil
+	case "bh", "benjamini-hochberg", "fdr":
+		return CorrectionBH, nil
+	case "none", "off", "0":
+		return CorrectionNone, nil
+	}
+	return "", fmt.Errorf("unknown correction %q (holm | bh | none)", s)
+}
+
+// AdjustPValues returns p-values adjusted for the whole family, in input order
+// (R's p.adjust with method "holm" or "BH").
+//
+//	holm: step-down, p_(i) * (m-i+1), made monotone increasing
+//	bh:   step-up,   p_(i) * m/i,     made monotone decreasing from the top
+func AdjustPValues(ps []float64, method string) []float64 {
+	m := len(ps)
+	adj := make([]float64, m)
+	order := make([]int, m)
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool { return ps[order[a]] < ps[order[b]] })
+	switch method {
+	case CorrectionHolm:
+		running := 0.0
+		for rank, i := range order {
+			v := max(running, min(1.0, float64(m-rank)*ps[i]))
+			running = v
+			adj[i] = v
+		}
+	case CorrectionBH:
+		running := 1.0
+		for rank := m - 1; rank >= 0; rank-- {
+			i := order[rank]
+			v := min(running, 1.0, float64(m)/float64(rank+1)*ps[i])
+			running = v
+			adj[i] = v
+		}
+	default:
+		copy(adj, ps)
+	}
+	return adj
+}
+
+// CorrectionLabel is the human-readable name used in reports.
+func CorrectionLabel(method string) string {
+	switch method {
+	case CorrectionHolm:
+		return "Holm–Bonferroni"
+	case CorrectionBH:
+		return "Benjamini–Hochberg (FDR)"
+	}
+	return "none"
+}
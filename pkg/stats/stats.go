@@ -0,0 +1,150 @@
+// Package stats holds the paired significance tests shared by the A/B
+// evaluation commands (abscore, abjudge). Results follow R's wilcox.test
+// (exact=FALSE, correct=FALSE), mcnemar.test, binom.test and p.adjust.
+package stats
+
+import (
+	"math"
+	"sort"
+)
+
+// NormalCDF is the standard normal cumulative distribution function.
+func NormalCDF(z float64) float64 {
+	return 0.5 * math.Erfc(-z/math.Sqrt2)
+}
+
+// WilcoxonSignedRank runs the two-sided Wilcoxon signed-rank test on paired
+// samples using the normal approximation. Differences are engineered minus
+// baseline; zero differences are dropped and ties get average ranks with the
+// matching variance correction. wPlus is the sum of positive ranks (R's V).
+func WilcoxonSignedRank(baseline, engineered []float64) (wPlus, z, p float64, n int) {
+	type pair struct{ d, a float64 }
+	arr := []pair{}
+	for i := range baseline {
+		d := engineered[i] - baseline[i]
+		if d == 0 {
+			continue
+		}
+		arr = append(arr, pair{d: d, a: math.Abs(d)})
+	}
+	sort.Slice(arr, func(i, j int) bool { return arr[i].a < arr[j].a })
+	ranks := make([]float64, len(arr))
+	ties := 0.0
+	for i := 0; i < len(arr); {
+		j := i + 1
+		for j < len(arr) && arr[j].a == arr[i].a {
+			j++
+		}
+		rank := (float64(i+1) + float64(j)) / 2.0
+		for k := i; k < j; k++ {
+			ranks[k] = rank
+		}
+		t := float64(j - i)
+		ties += t*t*t - t
+		i = j
+	}
+	for idx, pr := range arr {
+		if pr.d > 0 {
+			wPlus += ranks[idx]
+		}
+	}
+	n = len(arr)
+	if n == 0 {
+		return 0, 0, 1, 0
+	}
+	nf := float64(n)
+	mu := nf * (nf + 1) / 4.0
+	variance := nf*(nf+1)*(2*nf+1)/24.0 - ties/48.0
+	if variance <= 0 {
+		return wPlus, 0, 1, n
+	}
+	z = (wPlus - mu) / math.Sqrt(variance)
+	p = 2 * (1 - NormalCDF(math.Abs(z)))
+	return wPlus, z, p, n
+}
+
+// McNemarTest compares paired failures: b counts baseline-fail/engineered-pass
+// and c the reverse. chi2 uses the continuity correction (df=1).
+func McNemarTest(baselineFail, engineeredFail []bool) (b, c int, chi2, p float64) {
+	for i := range baselineFail {
+		bf, ef := baselineFail[i], engineeredFail[i]
+		if bf && !ef {
+			b++
+		}
+		if !bf && ef {
+			c++
+		}
+	}
+	denom := float64(b + c)
+	if denom == 0 {
+		return b, c, 0, 1
+	}
+	chi2 = math.Pow(math.Abs(float64(b-c))-1, 2) / denom
+	p = 2 * (1 - NormalCDF(math.Sqrt(chi2)))
+	return b, c, chi2, p
+}
+
+// McNemarExact is the exact two-sided McNemar p-value, i.e. a binomial test of
+// min(b, c) successes in b+c trials with probability 0.5.
+func McNemarExact(b, c int) float64 {
+	n := b + c
+	if n == 0 {
+		return 1
+	}
+	return math.Min(1, 2*BinomCDF(n, min(b, c)))
+}
+
+// BinomPMF is P[X = k] for X ~ Binomial(n, 0.5).
+func BinomPMF(n, k int) float64 {
+	if k < 0 || k > n {
+		return 0
+	}
+	ln, _ := math.Lgamma(float64(n + 1))
+	lk, _ := math.Lgamma(float64(k + 1))
+	lnk, _ := math.Lgamma(float64(n - k + 1))
+	return math.Exp(ln - lk - lnk - float64(n)*math.Ln2)
+}
+
+// BinomCDF is P[X <= k] for X ~ Binomial(n, 0.5).
+func BinomCDF(n, k int) float64 {
+	if k < 0 {
+		return 0
+	}
+	if k >= n {
+		return 1
+	}
+	var s float64
+	for i := 0; i <= k; i++ {
+		s += BinomPMF(n, i)
+	}
+	return s
+}
+
+// Mean is the arithmetic mean, 0 for an empty slice.
+func Mean(xs []float64) float64 {
+	if len(xs) == 0 {
+		return 0
+	}
+	var s float64
+	for _, x := range xs {
+		s += x
+	}
+	return s / float64(len(xs))
+}
+
+// Percentile returns the q-th percentile (0-100) by linear interpolation
+// between closest ranks (R's quantile type 7, numpy's default); 0 for an empty
+// slice. xs is not modified.
+func Percentile(xs []float64, q float64) float64 {
+	if len(xs) == 0 {
+		return 0
+	}
+	s := append([]float64(nil), xs...)
+	sort.Float64s(s)
+	h := (float64(len(s)) - 1) * q / 100
+	lo := math.Floor(h)
+	if int(lo) >= len(s)-1 {
+		return s[len(s)-1]
+	}
+	return s[int(lo)] + (h-lo)*(s[int(lo)+1]-s[int(lo)])
+}
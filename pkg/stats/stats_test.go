@@ -0,0 +1,149 @@
+package stats
+
+import (
+	"math"
+	"testing"
+)
+
+// Reference values from R: wilcox.test(e, b, paired = TRUE, exact = FALSE,
+// correct = FALSE), mcnemar.test, binom.test and p.adjust.
+
+func near(a, b, tol float64) bool { return math.Abs(a-b) <= tol }
+
+func TestWilcoxonSignedRank(t *testing.T) {
+	cases := []struct {
+		name      string
+		base, eng []float64
+		w, z, p   float64
+		n         int
+	}{
+		{
+			// Hollander & Wolfe depression scores (R ?wilcox.test example)
+			name: "no ties",
+			base: []float64{0.878, 0.647, 0.598, 2.05, 1.06, 1.29, 1.06, 3.14, 1.29},
+			eng:  []float64{1.83, 0.50, 1.62, 2.48, 1.68, 1.88, 1.55, 3.06, 1.30},
+			w:    40, z: 2.073221, p: 0.038152, n: 9,
+		},
+		{
+			name: "likert with ties and zeros",
+			base: []float64{3, 4, 2, 5, 3, 4, 2, 3, 4, 5, 1, 3},
+			eng:  []float64{4, 5, 2, 5, 4, 4, 3, 5, 4, 4, 3, 4},
+			w:    32.5, z: 2.126383, p: 0.033471, n: 8,
+		},
+		{name: "all equal", base: []float64{1, 2}, eng: []float64{1, 2}, w: 0, z: 0, p: 1, n: 0},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			w, z, p, n := WilcoxonSignedRank(tc.base, tc.eng)
+			if w != tc.w || n != tc.n || !near(z, tc.z, 1e-5) || !near(p, tc.p, 1e-5) {
+				t.Fatalf("got W+=%v z=%.6f p=%.6f n=%d, want W+=%v z=%.6f p=%.6f n=%d", w, z, p, n, tc.w, tc.z, tc.p, tc.n)
+			}
+		})
+	}
+}
+
+func TestMcNemar(t *testing.T) {
+	fails := func(b, c, both int) (base, eng []bool) {
+		for i := 0; i < b; i++ {
+			base, eng = append(base, true), append(eng, false)
+		}
+		for i := 0; i < c; i++ {
+			base, eng = append(base, false), append(eng, true)
+		}
+		for i := 0; i < both; i++ {
+			base, eng = append(base, true), append(eng, true)
+		}
+		return
+	}
+	cases := []struct {
+		b, c    int
+		chi2, p float64
+	}{
+		{150, 86, 16.817797, 4.114562e-05}, // R ?mcnemar.test approval ratings
+		{3, 12, 4.266667, 0.03886710},
+		{0, 0, 0, 1},
+	}
+	for _, tc := range cases {
+		base, eng := fails(tc.b, tc.c, 5)
+		b, c, chi2, p := McNemarTest(base, eng)
+		if b != tc.b || c != tc.c || !near(chi2, tc.chi2, 1e-5) || !near(p, tc.p, 1e-8) {
+			t.Errorf("McNemarTest(b=%d,c=%d) = %d,%d,%.6f,%.7g; want chi2=%.6f p=%.7g", tc.b, tc.c, b, c, chi2, p, tc.chi2, tc.p)
+		}
+	}
+}
+
+func TestMcNemarExact(t *testing.T) {
+	cases := []struct {
+		b, c int
+		p    float64
+	}{
+		{3, 12, 0.03515625}, // binom.test(3, 15)
+		{1, 5, 0.21875},
+		{4, 4, 1},
+		{0, 0, 1},
+	}
+	for _, tc := range cases {
+		if p := McNemarExact(tc.b, tc.c); !near(p, tc.p, 1e-9) {
+			t.Errorf("McNemarExact(%d,%d) = %.10f, want %.10f", tc.b, tc.c, p, tc.p)
+		}
+	}
+}
+
+func TestAdjustPValues(t *testing.T) {
+	ps := []float64{0.01, 0.04, 0.03, 0.005, 0.5}
+	cases := []struct {
+		method string
+		want   []float64
+	}{
+		{CorrectionHolm, []float64{0.04, 0.09, 0.09, 0.025, 0.5}},
+		{CorrectionBH, []float64{0.025, 0.05, 0.05, 0.025, 0.5}},
+		{CorrectionNone, ps},
+	}
+	for _, tc := range cases {
+		got := AdjustPValues(ps, tc.method)
+		for i := range got {
+			if !near(got[i], tc.want[i], 1e-12) {
+				t.Errorf("%s: got %v, want %v", tc.method, got, tc.want)
+				break
+			}
+		}
+	}
+	if got := AdjustPValues([]float64{0.3, 0.4}, CorrectionHolm); got[0] != 0.6 || got[1] != 0.6 {
+		t.Errorf("holm must stay monotone, got %v", got)
+	}
+}
+
+func TestParseCorrection(t *testing.T) {
+	for in, want := range map[string]string{"": "holm", "Holm": "holm", "fdr": "bh", "BH": "bh", "none": "none"} {
+		if got, err := ParseCorrection(in); err != nil || got != want {
+			t.Errorf("ParseCorrection(%q) = %q, %v; want %q", in, got, err, want)
+		}
+	}
+	if _, err := ParseCorrection("bonferroni"); err == nil {
+		t.Error("expected error for unknown correction")
+	}
+}
+
+func TestPercentile(t *testing.T) {
+	xs := []float64{120, 80, 300, 95, 110, 2000, 130, 90, 105, 140}
+	cases := []struct{ q, want float64 }{
+		{50, 115},  // quantile(xs, 0.5)
+		{95, 1235}, // quantile(xs, 0.95)
+		{0, 80},
+		{100, 2000},
+	}
+	for _, tc := range cases {
+		if got := Percentile(xs, tc.q); !near(got, tc.want, 1e-6) {
+			t.Errorf("Percentile(%v) = %v, want %v", tc.q, got, tc.want)
+		}
+	}
+	if xs[0] != 120 {
+		t.Error("Percentile must not reorder its input")
+	}
+	if Percentile(nil, 95) != 0 || Mean(nil) != 0 {
+		t.Error("empty input must give 0")
+	}
+	if got := Mean(xs); got != 317 {
+		t.Errorf("Mean = %v, want 317", got)
+	}
+}
@@ -0,0 +1,260 @@
+// Package tenant runs several institutions on one deployment. Every tenant
+// has its own users, conversations and event registrations, is picked by
+// the host name of a request or its X-Tenant-ID header, and may override
+// the branding, event datasets and Gemini API key of the deployment.
+// Requests no tenant claims belong to the operator tenant "", which is the
+// deployment as configured.
+package tenant
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Header names the tenant of a request that comes in on a shared host name.
+const Header = "X-Tenant-ID"
+
+// ErrUnknown is returned by Resolve for a header naming no tenant, or a
+// tenant other than the one the host name belongs to.
+var ErrUnknown = errors.New("unknown tenant")
+
+var idRe = regexp.MustCompile(`^[a-z0-9][a-z0-9-]{0,63}$`)
+
+// Branding is what the frontend shows of a tenant. Empty fields fall back to
+// DefaultBranding.
+type Branding struct {
+	AppName       string `json:"app_name,omitempty"`
+	AssistantName string `json:"assistant_name,omitempty"`
+	LogoURL       string `json:"logo_url,omitempty"`
+	PrimaryColor  string `json:"primary_color,omitempty"`
+}
+
+// DefaultBranding is the branding of the operator tenant.
+var DefaultBranding = Branding{AppName: "AkuAI", AssistantName: "AkuAI"}
+
+// Tenant is one entry of TENANTS_FILE.
+type Tenant struct {
+	ID       string   `json:"id"`
+	Name     string   `json:"name"`
+	Hosts    []string `json:"hosts"`
+	Branding Branding `json:"branding"`
+	// Event data replacing data/uib_events.json and CAMPUS_DATA_DIR; the
+	// deployment's when empty.
+	Dataset       string `json:"dataset,omitempty"`
+	CampusDataDir string `json:"campus_data_dir,omitempty"`
+	// Name of the secret holding the tenant's Gemini API key, looked up like
+	// GEMINI_API_KEY; the deployment's key when empty.
+	GeminiAPIKeySecret string `json:"gemini_api_key_secret,omitempty"`
+
+	geminiAPIKey string
+}
+
+// Operator is the tenant of requests no tenant claims.
+var Operator = &Tenant{}
+
+// IsOperator reports whether t is the operator tenant.
+func (t *Tenant) IsOperator() bool { return t == nil || t.ID == "" }
+
+// GeminiAPIKey is the tenant's own Gemini key, "" to use the deployment's.
+func (t *Tenant) GeminiAPIKey() string {
+	if t == nil {
+		return ""
+	}
+	return t.geminiAPIKey
+}
+
+// Brand returns the tenant's branding with DefaultBranding filled in.
+func (t *Tenant) Brand() Branding {
+	b := DefaultBranding
+	if t == nil {
+		return b
+	}
+	or := func(v, def string) string {
+		if v != "" {
+			return v
+		}
+		return def
+	}
+	b.AppName = or(t.Branding.AppName, b.AppName)
+	b.AssistantName = or(t.Branding.AssistantName, b.AssistantName)
+	b.LogoURL = or(t.Branding.LogoURL, b.LogoURL)
+	b.PrimaryColor = or(t.Branding.PrimaryColor, b.PrimaryColor)
+	return b
+}
+
+// Registry holds the configured tenants. The zero Registry has none, so
+// every request belongs to the operator.
+type Registry struct {
+	byID   map[string]*Tenant
+	byHost map[string]*Tenant
+}
+
+// Parse reads a JSON list of tenants. lookup resolves their
+// gemini_api_key_secret names; a name it does not know is an error.
+func Parse(data []byte, lookup func(name string) (string, bool)) (*Registry, error) {
+	var list []*Tenant
+	if err := json.Unmarshal(data, &list); err != nil {
+		return nil, err
+	}
+	r := &Registry{byID: map[string]*Tenant{}, byHost: map[string]*Tenant{}}
+	for _, t := range list {
+		if !idRe.MatchString(t.ID) {
+			return nil, fmt.Errorf("tenant id %q must be lowercase letters, digits and dashes", t.ID)
+		}
+		if _, dup := r.byID[t.ID]; dup {
+			return nil, fmt.Errorf("tenant %s given twice", t.ID)
+		}
+		for _, h := range t.Hosts {
+			h = normalizeHost(h)
+			if other, dup := r.byHost[h]; dup {
+				return nil, fmt.Errorf("host %s belongs to tenants %s and %s", h, other.ID, t.ID)
+			}
+			r.byHost[h] = t
+		}
+		if name := t.GeminiAPIKeySecret; name != "" {
+			key, ok := lookup(name)
+			if !ok || key == "" {
+				return nil, fmt.Errorf("tenant %s: secret %s is not set", t.ID, name)
+			}
+			t.geminiAPIKey = key
+		}
+		r.byID[t.ID] = t
+	}
+	return r, nil
+}
+
+// Load reads the registry from the JSON file at path; "" is no tenants.
+func Load(path string, lookup func(name string) (string, bool)) (*Registry, error) {
+	if path == "" {
+		return &Registry{}, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return Parse(data, lookup)
+}
+
+// Len is the number of configured tenants.
+func (r *Registry) Len() int {
+	if r == nil {
+		return 0
+	}
+	return len(r.byID)
+}
+
+// Tenants lists the configured tenants by ID.
+func (r *Registry) Tenants() []*Tenant {
+	if r == nil {
+		return nil
+	}
+	out := make([]*Tenant, 0, len(r.byID))
+	for _, t := range r.byID {
+		out = append(out, t)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].ID < out[j].ID })
+	return out
+}
+
+// Get returns tenant id; "" is the operator.
+func (r *Registry) Get(id string) (*Tenant, bool) {
+	if id == "" {
+		return Operator, true
+	}
+	if r == nil {
+		return nil, false
+	}
+	t, ok := r.byID[id]
+	return t, ok
+}
+
+// Resolve picks the tenant of a request from its Host and X-Tenant-ID
+// header. A host name listed by a tenant decides; the header may only
+// repeat it. On other host names the header picks the tenant, and without
+// one the request belongs to the operator.
+func (r *Registry) Resolve(host, header string) (*Tenant, error) {
+	header = strings.TrimSpace(header)
+	var byHost *Tenant
+	if r != nil {
+		byHost = r.byHost[normalizeHost(host)]
+	}
+	switch {
+	case byHost != nil && (header == "" || header == byHost.ID):
+		return byHost, nil
+	case byHost != nil:
+		return nil, ErrUnknown
+	case header == "":
+		return Operator, nil
+	}
+	if t, ok := r.Get(header); ok {
+		return t, nil
+	}
+	return nil, ErrUnknown
+}
+
+// normalizeHost lowercases host and drops its port.
+func normalizeHost(host string) string {
+	host = strings.ToLower(strings.TrimSpace(host))
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	return strings.TrimSuffix(host, ".")
+}
+
+var (
+	mu       sync.RWMutex
+	registry = &Registry{}
+)
+
+// Configure makes the tenants in the file at path (see Load) the default
+// registry.
+func Configure(path string, lookup func(name string) (string, bool)) error {
+	r, err := Load(path, lookup)
+	if err != nil {
+		return err
+	}
+	Use(r)
+	return nil
+}
+
+// Use makes r the default registry.
+func Use(r *Registry) {
+	mu.Lock()
+	registry = r
+	mu.Unlock()
+}
+
+// Default returns the default registry.
+func Default() *Registry {
+	mu.RLock()
+	defer mu.RUnlock()
+	return registry
+}
+
+type ctxKey struct{}
+
+// WithTenant attaches the tenant of a request to ctx.
+func WithTenant(ctx context.Context, t *Tenant) context.Context {
+	return context.WithValue(ctx, ctxKey{}, t)
+}
+
+// FromContext returns the tenant attached to ctx, or the operator.
+func FromContext(ctx context.Context) *Tenant {
+	if t, ok := ctx.Value(ctxKey{}).(*Tenant); ok && t != nil {
+		return t
+	}
+	return Operator
+}
+
+// ID returns the id of the tenant attached to ctx, "" for the operator.
+func ID(ctx context.Context) string {
+	return FromContext(ctx).ID
+}
@@ -0,0 +1,84 @@
+package tenant
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func lookup(name string) (string, bool) {
+	if name == "ACME_GEMINI_KEY" {
+		return "acme-key", true
+	}
+	return "", false
+}
+
+func TestParse(t *testing.T) {
+	r, err := Parse([]byte(`[
+		{"id": "acme", "name": "Acme", "hosts": ["chat.acme.edu"], "gemini_api_key_secret": "ACME_GEMINI_KEY"},
+		{"id": "beta", "name": "Beta"}
+	]`), lookup)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Len() != 2 || r.Tenants()[0].ID != "acme" {
+		t.Fatalf("tenants = %+v", r.Tenants())
+	}
+	if acme, ok := r.Get("acme"); !ok || acme.GeminiAPIKey() != "acme-key" {
+		t.Fatalf("Get(acme) = %+v, %v", acme, ok)
+	}
+	if op, ok := r.Get(""); !ok || !op.IsOperator() {
+		t.Fatal("Get(\"\") is not the operator")
+	}
+
+	for _, bad := range []string{
+		`[{"id": "Acme"}]`,
+		`[{"id": ""}]`,
+		`[{"id": "acme"}, {"id": "acme"}]`,
+		`[{"id": "a", "hosts": ["x.edu"]}, {"id": "b", "hosts": ["X.edu:443"]}]`,
+		`[{"id": "acme", "gemini_api_key_secret": "MISSING"}]`,
+	} {
+		if _, err := Parse([]byte(bad), lookup); err == nil {
+			t.Errorf("Parse(%s) accepted", bad)
+		}
+	}
+}
+
+func TestResolve(t *testing.T) {
+	r, err := Parse([]byte(`[{"id": "acme", "hosts": ["chat.acme.edu"]}, {"id": "beta"}]`), lookup)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tc := range []struct {
+		host, header, want string
+		err                error
+	}{
+		{"chat.acme.edu", "", "acme", nil},
+		{"Chat.Acme.edu:8080", "acme", "acme", nil},
+		{"chat.acme.edu", "beta", "", ErrUnknown},
+		{"akuai.example", "", "", nil},
+		{"akuai.example", "beta", "beta", nil},
+		{"akuai.example", "gamma", "", ErrUnknown},
+	} {
+		got, err := r.Resolve(tc.host, tc.header)
+		if !errors.Is(err, tc.err) || (err == nil && got.ID != tc.want) {
+			t.Errorf("Resolve(%q, %q) = %+v, %v; want %q, %v", tc.host, tc.header, got, err, tc.want, tc.err)
+		}
+	}
+	if got, err := (&Registry{}).Resolve("chat.acme.edu", ""); err != nil || !got.IsOperator() {
+		t.Errorf("empty registry = %+v, %v", got, err)
+	}
+}
+
+func TestBrandAndContext(t *testing.T) {
+	acme := &Tenant{ID: "acme", Branding: Branding{AppName: "AcmeAI", LogoURL: "/acme.png"}}
+	if b := acme.Brand(); b.AppName != "AcmeAI" || b.AssistantName != DefaultBranding.AssistantName || b.LogoURL != "/acme.png" {
+		t.Errorf("Brand = %+v", b)
+	}
+	if b := Operator.Brand(); b != DefaultBranding {
+		t.Errorf("operator Brand = %+v", b)
+	}
+	if ID(context.Background()) != "" || ID(WithTenant(context.Background(), acme)) != "acme" {
+		t.Error("tenant not carried by the context")
+	}
+}
@@ -0,0 +1,364 @@
+package tokenstore
+
+import (
+	"AkuAI/models"
+	"AkuAI/pkg/config"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// EnvKeyID is the kid of the key configured with JWT_SECRET_KEY. Tokens
+// without a kid are checked against it.
+const EnvKeyID = "env"
+
+// ErrRevoked is returned by Verify for tokens revoked by logout.
+var ErrRevoked = errors.New("token has been revoked")
+
+// reloadEvery is how often the keyring picks up keys another instance
+// rotated in: on the next Issue, or at once for an unknown kid.
+const reloadEvery = 30 * time.Second
+
+// JWTConfig sets the claims of issued tokens.
+type JWTConfig struct {
+	Issuer   string
+	Audience string
+	TTL      time.Duration
+}
+
+// Claims are what Verify returns of a valid token.
+type Claims struct {
+	UserID    string
+	TenantID  string // "tid", "" for the operator tenant
+	JTI       string
+	KeyID     string
+	ExpiresAt time.Time
+}
+
+// KeyInfo describes a signing key without its secret.
+type KeyInfo struct {
+	KeyID       string     `json:"kid"`
+	Alg         string     `json:"alg"`
+	Use         string     `json:"use"`
+	Status      string     `json:"status"` // current | retired
+	CreatedAt   time.Time  `json:"created_at"`
+	RetiredAt   *time.Time `json:"retired_at,omitempty"`
+	VerifyUntil *time.Time `json:"verify_until,omitempty"` // retired keys stop verifying then
+}
+
+type signingKey struct {
+	id        string
+	secret    []byte
+	createdAt time.Time
+	retiredAt *time.Time
+}
+
+// Keyring signs tokens with the current key and verifies them with any key
+// that may still have live tokens. Rotated keys are stored in the database
+// so every instance, and restarts, know them.
+type Keyring struct {
+	mu         sync.RWMutex
+	cfg        JWTConfig
+	envSecret  []byte
+	keys       map[string]*signingKey
+	current    string
+	db         *gorm.DB
+	lastReload time.Time
+	now        func() time.Time
+}
+
+var (
+	keysMu      sync.Mutex
+	defaultKeys *Keyring
+)
+
+// InitKeys builds the default keyring from the JWT_SECRET_KEY secret and the
+// keys rotated into db.
+func InitKeys(db *gorm.DB, cfg JWTConfig, envSecret string) (*Keyring, error) {
+	k := NewKeyring(db, cfg, envSecret)
+	if err := k.reload(); err != nil {
+		return nil, err
+	}
+	keysMu.Lock()
+	defaultKeys = k
+	keysMu.Unlock()
+	log.Printf("[jwt] keyring loaded current=%s keys=%d", k.currentID(), len(k.Keys()))
+	return k, nil
+}
+
+// Keys returns the default keyring; without InitKeys it only has the
+// configured secret.
+func Keys() *Keyring {
+	keysMu.Lock()
+	defer keysMu.Unlock()
+	if defaultKeys == nil {
+		defaultKeys = NewKeyring(nil, JWTConfig{
+			Issuer:   config.JWTIssuer,
+			Audience: config.JWTAudience,
+			TTL:      time.Duration(config.JWTExpiryMinutes) * time.Minute,
+		}, config.JWTSecret)
+	}
+	return defaultKeys
+}
+
+// NewKeyring returns a keyring signing with envSecret until a key is rotated
+// in. db may be nil to keep rotated keys in memory only.
+func NewKeyring(db *gorm.DB, cfg JWTConfig, envSecret string) *Keyring {
+	k := &Keyring{cfg: cfg, envSecret: []byte(envSecret), db: db, now: time.Now}
+	k.keys = map[string]*signingKey{EnvKeyID: {id: EnvKeyID, secret: k.envSecret}}
+	k.current = EnvKeyID
+	return k
+}
+
+// reload replaces the rotated keys with those in the database.
+func (k *Keyring) reload() error {
+	if k.db == nil {
+		return nil
+	}
+	var rows []models.SigningKey
+	if err := k.db.Order("created_at").Find(&rows).Error; err != nil {
+		return err
+	}
+	keys := map[string]*signingKey{EnvKeyID: {id: EnvKeyID, secret: k.envSecret}}
+	current := EnvKeyID
+	for _, r := range rows {
+		if r.ID == EnvKeyID {
+			keys[EnvKeyID].retiredAt = r.RetiredAt
+			continue
+		}
+		secret, err := base64.StdEncoding.DecodeString(r.Secret)
+		if err != nil {
+			log.Printf("[jwt] ⚠️ skipping signing key %s: %v", r.ID, err)
+			continue
+		}
+		keys[r.ID] = &signingKey{id: r.ID, secret: secret, createdAt: r.CreatedAt, retiredAt: r.RetiredAt}
+		if r.RetiredAt == nil {
+			current = r.ID
+		}
+	}
+	k.mu.Lock()
+	k.keys, k.current, k.lastReload = keys, current, k.now()
+	k.mu.Unlock()
+	return nil
+}
+
+func (k *Keyring) currentID() string {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	return k.current
+}
+
+// stale reports whether the keys are due for a reload from the database.
+func (k *Keyring) stale() bool {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	return k.db != nil && k.now().Sub(k.lastReload) > reloadEvery
+}
+
+// Issue signs a token for userID of the operator tenant with the current
+// key.
+func (k *Keyring) Issue(userID string) (string, Claims, error) {
+	return k.IssueFor(userID, "")
+}
+
+// IssueFor signs a token for userID of tenantID, which only verifies on
+// requests for that tenant.
+func (k *Keyring) IssueFor(userID, tenantID string) (string, Claims, error) {
+	if k.stale() {
+		if err := k.reload(); err != nil {
+			log.Printf("[jwt] ⚠️ failed to reload signing keys: %v", err)
+		}
+	}
+	k.mu.RLock()
+	key := k.keys[k.current]
+	k.mu.RUnlock()
+	if len(key.secret) == 0 {
+		return "", Claims{}, errors.New("no JWT signing secret configured")
+	}
+	now := k.now()
+	cl := Claims{UserID: userID, TenantID: tenantID, JTI: uuid.NewString(), KeyID: key.id, ExpiresAt: now.Add(k.cfg.TTL)}
+	mc := jwt.MapClaims{
+		"sub": userID,
+		"iss": k.cfg.Issuer,
+		"aud": k.cfg.Audience,
+		"iat": now.Unix(),
+		"exp": cl.ExpiresAt.Unix(),
+		"jti": cl.JTI,
+	}
+	if tenantID != "" {
+		mc["tid"] = tenantID
+	}
+	t := jwt.NewWithClaims(jwt.SigningMethodHS256, mc)
+	t.Header["kid"] = key.id
+	s, err := t.SignedString(key.secret)
+	return s, cl, err
+}
+
+// verifyKey returns the secret for kid if it may still verify tokens,
+// reloading the keys once when another instance may have rotated it in.
+func (k *Keyring) verifyKey(kid string) ([]byte, error) {
+	if kid == "" {
+		kid = EnvKeyID
+	}
+	for attempt := 0; ; attempt++ {
+		k.mu.RLock()
+		key, ok := k.keys[kid]
+		k.mu.RUnlock()
+		if ok {
+			if until := k.verifyUntil(key); until != nil && !k.now().Before(*until) {
+				return nil, fmt.Errorf("signing key %s retired", kid)
+			}
+			if len(key.secret) == 0 {
+				return nil, errors.New("no JWT signing secret configured")
+			}
+			return key.secret, nil
+		}
+		if attempt > 0 || !k.stale() {
+			return nil, fmt.Errorf("unknown signing key %s", kid)
+		}
+		if err := k.reload(); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// verifyUntil is when a retired key stops verifying: the last token it signed
+// has expired by then.
+func (k *Keyring) verifyUntil(key *signingKey) *time.Time {
+	if key.retiredAt == nil {
+		return nil
+	}
+	t := key.retiredAt.Add(k.cfg.TTL)
+	return &t
+}
+
+// Verify checks the signature, kid, iss, aud and expiry of a token and that
+// it was not revoked.
+func (k *Keyring) Verify(tokenStr string) (Claims, error) {
+	var kid string
+	t, err := jwt.Parse(tokenStr, func(t *jwt.Token) (any, error) {
+		kid, _ = t.Header["kid"].(string)
+		return k.verifyKey(kid)
+	},
+		jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg(), jwt.SigningMethodHS384.Alg(), jwt.SigningMethodHS512.Alg()}),
+		jwt.WithIssuer(k.cfg.Issuer),
+		jwt.WithAudience(k.cfg.Audience),
+		jwt.WithExpirationRequired(),
+		jwt.WithTimeFunc(k.now),
+	)
+	if err != nil {
+		return Claims{}, err
+	}
+	mc, ok := t.Claims.(jwt.MapClaims)
+	if !ok {
+		return Claims{}, errors.New("invalid token claims")
+	}
+	cl := Claims{KeyID: kid}
+	cl.JTI, _ = mc["jti"].(string)
+	cl.TenantID, _ = mc["tid"].(string)
+	if IsRevoked(cl.JTI) {
+		return Claims{}, ErrRevoked
+	}
+	switch sub := mc["sub"].(type) {
+	case string:
+		cl.UserID = sub
+	case float64:
+		cl.UserID = strconv.Itoa(int(sub))
+	}
+	if cl.UserID == "" {
+		return Claims{}, errors.New("invalid subject in token")
+	}
+	if exp, err := mc.GetExpirationTime(); err == nil && exp != nil {
+		cl.ExpiresAt = exp.Time
+	}
+	return cl, nil
+}
+
+// Rotate makes a new random key current and retires the previous one, which
+// keeps verifying its tokens until they expire. Keys past that are removed.
+func (k *Keyring) Rotate() (KeyInfo, error) {
+	id := make([]byte, 8)
+	secret := make([]byte, 32)
+	if _, err := rand.Read(id); err != nil {
+		return KeyInfo{}, err
+	}
+	if _, err := rand.Read(secret); err != nil {
+		return KeyInfo{}, err
+	}
+	now := k.now()
+	nk := &signingKey{id: hex.EncodeToString(id), secret: secret, createdAt: now}
+
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	prev := k.keys[k.current]
+	if k.db != nil {
+		err := k.db.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Create(&models.SigningKey{ID: nk.id, Secret: base64.StdEncoding.EncodeToString(secret), CreatedAt: now}).Error; err != nil {
+				return err
+			}
+			if prev.id == EnvKeyID {
+				return tx.Save(&models.SigningKey{ID: EnvKeyID, CreatedAt: now, RetiredAt: &now}).Error
+			}
+			return tx.Model(&models.SigningKey{}).Where("id = ?", prev.id).Update("retired_at", now).Error
+		})
+		if err != nil {
+			return KeyInfo{}, err
+		}
+		if err := k.db.Where("id <> ? AND retired_at < ?", EnvKeyID, now.Add(-k.cfg.TTL)).Delete(&models.SigningKey{}).Error; err != nil {
+			log.Printf("[jwt] ⚠️ failed to prune expired signing keys: %v", err)
+		}
+	}
+	retired := *prev
+	retired.retiredAt = &now
+	k.keys[prev.id] = &retired
+	k.keys[nk.id] = nk
+	k.current = nk.id
+	for id, key := range k.keys {
+		if until := k.verifyUntil(key); id != EnvKeyID && until != nil && !now.Before(*until) {
+			delete(k.keys, id)
+		}
+	}
+	log.Printf("[jwt] 🔄 rotated signing key %s -> %s", prev.id, nk.id)
+	return k.info(nk), nil
+}
+
+func (k *Keyring) info(key *signingKey) KeyInfo {
+	ki := KeyInfo{KeyID: key.id, Alg: jwt.SigningMethodHS256.Alg(), Use: "sig", Status: "current", CreatedAt: key.createdAt, RetiredAt: key.retiredAt}
+	if key.retiredAt != nil {
+		ki.Status = "retired"
+		ki.VerifyUntil = k.verifyUntil(key)
+	}
+	return ki
+}
+
+// Keys lists the keys that sign or still verify tokens, current first.
+func (k *Keyring) Keys() []KeyInfo {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	now := k.now()
+	var out []KeyInfo
+	for _, key := range k.keys {
+		if until := k.verifyUntil(key); until != nil && !now.Before(*until) {
+			continue
+		}
+		out = append(out, k.info(key))
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if (out[i].Status == "current") != (out[j].Status == "current") {
+			return out[i].Status == "current"
+		}
+		return out[i].CreatedAt.After(out[j].CreatedAt)
+	})
+	return out
+}
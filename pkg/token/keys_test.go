@@ -0,0 +1,82 @@
+package tokenstore
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+func TestKeyringIssueVerify(t *testing.T) {
+	cfg := JWTConfig{Issuer: "akuai", Audience: "akuai-api", TTL: time.Hour}
+	k := NewKeyring(nil, cfg, "s3cret")
+	tok, cl, err := k.Issue("42")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := k.Verify(tok)
+	if err != nil || got.UserID != "42" || got.TenantID != "" || got.JTI != cl.JTI || got.KeyID != EnvKeyID {
+		t.Fatalf("Verify = %+v, %v", got, err)
+	}
+	tenantTok, _, err := k.IssueFor("43", "acme")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, err := k.Verify(tenantTok); err != nil || got.UserID != "43" || got.TenantID != "acme" {
+		t.Fatalf("Verify of a tenant token = %+v, %v", got, err)
+	}
+
+	other := NewKeyring(nil, JWTConfig{Issuer: "akuai", Audience: "other", TTL: time.Hour}, "s3cret")
+	if _, err := other.Verify(tok); err == nil {
+		t.Error("token accepted for another audience")
+	}
+	if _, err := NewKeyring(nil, cfg, "wrong").Verify(tok); err == nil {
+		t.Error("token accepted with another secret")
+	}
+
+	// Tokens signed before iss/aud existed are rejected.
+	legacy, _ := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{"sub": "42", "exp": time.Now().Add(time.Hour).Unix()}).SignedString([]byte("s3cret"))
+	if _, err := k.Verify(legacy); err == nil {
+		t.Error("token without iss/aud accepted")
+	}
+
+	RevokeToken(cl.JTI)
+	if _, err := k.Verify(tok); !errors.Is(err, ErrRevoked) {
+		t.Errorf("revoked token gave %v", err)
+	}
+}
+
+func TestKeyringRotation(t *testing.T) {
+	now := time.Now()
+	k := NewKeyring(nil, JWTConfig{Issuer: "akuai", Audience: "akuai-api", TTL: time.Hour}, "s3cret")
+	k.now = func() time.Time { return now }
+
+	old, _, _ := k.Issue("1")
+	key, err := k.Rotate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	fresh, cl, _ := k.Issue("2")
+	if cl.KeyID != key.KeyID || key.Status != "current" {
+		t.Fatalf("new tokens signed with %s, want %s", cl.KeyID, key.KeyID)
+	}
+	if _, err := k.Verify(old); err != nil {
+		t.Errorf("token of the retired key rejected right after rotation: %v", err)
+	}
+	keys := k.Keys()
+	if len(keys) != 2 || keys[0].KeyID != key.KeyID || keys[1].Status != "retired" || keys[1].VerifyUntil == nil {
+		t.Fatalf("keys %+v", keys)
+	}
+
+	now = now.Add(61 * time.Minute)
+	if _, err := k.Verify(old); err == nil {
+		t.Error("token of the retired key accepted after the TTL")
+	}
+	if _, err := k.Verify(fresh); err == nil {
+		t.Error("expired token accepted")
+	}
+	if n := len(k.Keys()); n != 1 {
+		t.Errorf("%d keys listed after the retired one expired", n)
+	}
+}
@@ -0,0 +1,91 @@
+// Package virusscan checks uploaded files for malware before they are
+// stored. Scanner is the hook; ClamAV talks to a clamd daemon.
+package virusscan
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"time"
+)
+
+// Result is the verdict on one file; Signature names what was found.
+type Result struct {
+	Infected  bool   `json:"infected"`
+	Signature string `json:"signature,omitempty"`
+}
+
+// Scanner scans the contents of an uploaded file.
+type Scanner interface {
+	Scan(ctx context.Context, name string, data []byte) (Result, error)
+}
+
+// ClamAV scans with clamd's INSTREAM command. Address is host:port for TCP
+// or a socket path for Unix sockets.
+type ClamAV struct {
+	Address string
+	Timeout time.Duration
+}
+
+// chunkSize stays below clamd's default StreamMaxLength chunking.
+const chunkSize = 64 << 10
+
+func (c ClamAV) Scan(ctx context.Context, name string, data []byte) (Result, error) {
+	network := "tcp"
+	if strings.HasPrefix(c.Address, "/") {
+		network = "unix"
+	}
+	timeout := c.Timeout
+	if timeout <= 0 {
+		timeout = 10 * time.Second
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, network, c.Address)
+	if err != nil {
+		return Result{}, fmt.Errorf("clamd: %w", err)
+	}
+	defer conn.Close()
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+
+	if _, err := conn.Write([]byte("zINSTREAM\x00")); err != nil {
+		return Result{}, fmt.Errorf("clamd: %w", err)
+	}
+	var size [4]byte
+	for rest := data; len(rest) > 0; {
+		n := min(len(rest), chunkSize)
+		binary.BigEndian.PutUint32(size[:], uint32(n))
+		if _, err := conn.Write(append(size[:], rest[:n]...)); err != nil {
+			return Result{}, fmt.Errorf("clamd: %w", err)
+		}
+		rest = rest[n:]
+	}
+	if _, err := conn.Write([]byte{0, 0, 0, 0}); err != nil {
+		return Result{}, fmt.Errorf("clamd: %w", err)
+	}
+	reply, err := io.ReadAll(io.LimitReader(conn, 4096))
+	if err != nil {
+		return Result{}, fmt.Errorf("clamd: %w", err)
+	}
+	return parseReply(string(bytes.TrimRight(reply, "\x00\n")))
+}
+
+// parseReply reads "stream: OK" and "stream: Eicar-Signature FOUND".
+func parseReply(reply string) (Result, error) {
+	_, verdict, _ := strings.Cut(reply, ": ")
+	switch {
+	case verdict == "OK":
+		return Result{}, nil
+	case strings.HasSuffix(verdict, " FOUND"):
+		return Result{Infected: true, Signature: strings.TrimSuffix(verdict, " FOUND")}, nil
+	}
+	return Result{}, errors.New("clamd: " + reply)
+}
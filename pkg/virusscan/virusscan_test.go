@@ -0,0 +1,76 @@
+package virusscan
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"io"
+	"net"
+	"testing"
+)
+
+// fakeClamd answers INSTREAM requests, finding a signature in streams that
+// contain "EICAR".
+func fakeClamd(t *testing.T) string {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Skipf("cannot listen: %v", err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func(conn net.Conn) {
+				defer conn.Close()
+				cmd := make([]byte, len("zINSTREAM\x00"))
+				if _, err := io.ReadFull(conn, cmd); err != nil || string(cmd) != "zINSTREAM\x00" {
+					conn.Write([]byte("UNKNOWN COMMAND\x00"))
+					return
+				}
+				var stream []byte
+				for {
+					var size uint32
+					if err := binary.Read(conn, binary.BigEndian, &size); err != nil {
+						return
+					}
+					if size == 0 {
+						break
+					}
+					chunk := make([]byte, size)
+					if _, err := io.ReadFull(conn, chunk); err != nil {
+						return
+					}
+					stream = append(stream, chunk...)
+				}
+				if bytes.Contains(stream, []byte("EICAR")) {
+					conn.Write([]byte("stream: Eicar-Test-Signature FOUND\x00"))
+				} else {
+					conn.Write([]byte("stream: OK\x00"))
+				}
+			}(conn)
+		}
+	}()
+	return ln.Addr().String()
+}
+
+func TestClamAVScan(t *testing.T) {
+	scanner := ClamAV{Address: fakeClamd(t)}
+	clean := bytes.Repeat([]byte("x"), 3*chunkSize+17)
+	if res, err := scanner.Scan(context.Background(), "clean.png", clean); err != nil || res.Infected {
+		t.Fatalf("clean file = %+v, %v", res, err)
+	}
+	infected := append(bytes.Repeat([]byte("x"), chunkSize), []byte("EICAR")...)
+	res, err := scanner.Scan(context.Background(), "virus.png", infected)
+	if err != nil || !res.Infected || res.Signature != "Eicar-Test-Signature" {
+		t.Fatalf("infected file = %+v, %v", res, err)
+	}
+}
+
+func TestParseReply(t *testing.T) {
+	if _, err := parseReply("INSTREAM size limit exceeded. ERROR"); err == nil {
+		t.Fatal("expected an error reply to fail")
+	}
+}
@@ -0,0 +1,373 @@
+// Package webhook delivers events to the callback URLs users and admins
+// register (models.Webhook). Emit queues one models.WebhookDelivery per
+// subscribed webhook; the Dispatcher posts them, signed with the webhook's
+// secret, and retries failures with exponential backoff. The queue lives in
+// the database, so deliveries survive restarts.
+//
+// Every delivery is a POST of
+//
+//	{"event": "message.completed", "created_at": "...", "data": {...}}
+//
+// with the headers X-AkuAI-Event, X-AkuAI-Delivery (the delivery id),
+// X-AkuAI-Timestamp (Unix seconds) and X-AkuAI-Signature, which is
+// "sha256=" and the hex HMAC-SHA256 of "<timestamp>.<body>" keyed with the
+// secret.
+package webhook
+
+import (
+	"AkuAI/models"
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+	"syscall"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Events.
+const (
+	EventMessageCompleted       = "message.completed"
+	EventEventCreated           = "event.created"
+	EventRegistrationConfirmed  = "registration.confirmed"
+	EventRegistrationWaitlisted = "registration.waitlisted"
+	EventRegistrationCancelled  = "registration.cancelled"
+)
+
+// Events lists the events webhooks can subscribe to; "*" subscribes to all.
+func Events() []string {
+	return []string{EventMessageCompleted, EventEventCreated, EventRegistrationConfirmed, EventRegistrationWaitlisted,
+		EventRegistrationCancelled}
+}
+
+// KnownEvent reports whether e can be subscribed to.
+func KnownEvent(e string) bool {
+	if e == "*" {
+		return true
+	}
+	for _, k := range Events() {
+		if k == e {
+			return true
+		}
+	}
+	return false
+}
+
+// NewSecret returns a random signing secret.
+func NewSecret() (string, error) {
+	b := make([]byte, 24)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return "whsec_" + hex.EncodeToString(b), nil
+}
+
+// Sign returns the X-AkuAI-Signature of body sent at timestamp.
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10) + "."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// ValidateURL checks a callback URL before it is registered. Private and
+// loopback addresses are also refused when the delivery connects, unless
+// allowPrivate is set.
+func ValidateURL(raw string, allowPrivate bool) error {
+	u, err := url.Parse(raw)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return errors.New("url must be an absolute http or https URL")
+	}
+	if ip := net.ParseIP(u.Hostname()); ip != nil && !allowPrivate && !publicIP(ip) {
+		return errors.New("url must not point to a private or loopback address")
+	}
+	return nil
+}
+
+func publicIP(ip net.IP) bool {
+	return !(ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsUnspecified() || ip.IsMulticast())
+}
+
+// Emit queues event for the active webhooks subscribed to it: those of
+// userID (0 for events that belong to no user) and the admin webhooks. Like
+// audit records, failures are only logged.
+func Emit(db *gorm.DB, event string, userID uint, data any) {
+	var hooks []models.Webhook
+	q := db.Where("active = ?", true)
+	if userID != 0 {
+		q = q.Where("user_id IS NULL OR user_id = ?", userID)
+	} else {
+		q = q.Where("user_id IS NULL")
+	}
+	if err := q.Find(&hooks).Error; err != nil {
+		log.Printf("[webhook] ⚠️ failed to load webhooks for %s: %v", event, err)
+		return
+	}
+	var payload []byte
+	now := time.Now()
+	queued := 0
+	for _, h := range hooks {
+		if !h.Subscribes(event) {
+			continue
+		}
+		if payload == nil {
+			b, err := json.Marshal(map[string]any{"event": event, "created_at": now.UTC(), "data": data})
+			if err != nil {
+				log.Printf("[webhook] ⚠️ %s payload not serialisable: %v", event, err)
+				return
+			}
+			payload = b
+		}
+		d := models.WebhookDelivery{WebhookID: h.ID, Event: event, Payload: string(payload), Status: models.DeliveryPending, NextAttemptAt: now}
+		if err := db.Create(&d).Error; err != nil {
+			log.Printf("[webhook] ⚠️ failed to queue %s for webhook %d: %v", event, h.ID, err)
+			continue
+		}
+		queued++
+	}
+	if queued > 0 {
+		wake()
+	}
+}
+
+// Redeliver queues a copy of delivery id to be sent again.
+func Redeliver(db *gorm.DB, id uint) (models.WebhookDelivery, error) {
+	var d models.WebhookDelivery
+	if err := db.First(&d, id).Error; err != nil {
+		return d, err
+	}
+	cp := models.WebhookDelivery{WebhookID: d.WebhookID, Event: d.Event, Payload: d.Payload, Status: models.DeliveryPending, NextAttemptAt: time.Now()}
+	if err := db.Create(&cp).Error; err != nil {
+		return cp, err
+	}
+	wake()
+	return cp, nil
+}
+
+// Policy configures delivery.
+type Policy struct {
+	MaxAttempts  int           // attempts before a delivery fails
+	BaseBackoff  time.Duration // wait after the first failed attempt, doubled after each further one
+	MaxBackoff   time.Duration
+	Timeout      time.Duration // per attempt
+	PollInterval time.Duration // how often due retries are looked for
+	AllowPrivate bool          // allow callbacks to private and loopback addresses
+}
+
+var DefaultPolicy = Policy{
+	MaxAttempts:  6,
+	BaseBackoff:  30 * time.Second,
+	MaxBackoff:   time.Hour,
+	Timeout:      10 * time.Second,
+	PollInterval: 5 * time.Second,
+}
+
+// Backoff is the wait after the attempts-th failed attempt.
+func (p Policy) Backoff(attempts int) time.Duration {
+	d := p.BaseBackoff
+	for i := 1; i < attempts && d < p.MaxBackoff; i++ {
+		d *= 2
+	}
+	if p.MaxBackoff > 0 && d > p.MaxBackoff {
+		d = p.MaxBackoff
+	}
+	return d
+}
+
+// Dispatcher sends the queued deliveries.
+type Dispatcher struct {
+	db     *gorm.DB
+	policy Policy
+	client *http.Client
+	wake   chan struct{}
+	now    func() time.Time
+}
+
+var (
+	defaultDispatcher *Dispatcher
+	once              sync.Once
+)
+
+// Start creates the default dispatcher and delivers until ctx is done.
+// Calls after the first one are ignored.
+func Start(ctx context.Context, db *gorm.DB, p Policy) *Dispatcher {
+	once.Do(func() {
+		defaultDispatcher = New(db, p)
+		go defaultDispatcher.run(ctx)
+		log.Printf("[webhook] dispatcher started maxAttempts=%d backoff=%v..%v", p.MaxAttempts, p.BaseBackoff, p.MaxBackoff)
+	})
+	return defaultDispatcher
+}
+
+// Default returns the dispatcher started by Start, or nil.
+func Default() *Dispatcher { return defaultDispatcher }
+
+func wake() {
+	if d := defaultDispatcher; d != nil {
+		select {
+		case d.wake <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// New returns a dispatcher; its HTTP client refuses private addresses unless
+// p.AllowPrivate. Deliveries never go through HTTP_PROXY: the guard would
+// only see the proxy's address, not the target's.
+func New(db *gorm.DB, p Policy) *Dispatcher {
+	dialer := &net.Dialer{Timeout: p.Timeout}
+	if !p.AllowPrivate {
+		dialer.Control = func(network, address string, _ syscall.RawConn) error {
+			host, _, _ := net.SplitHostPort(address)
+			if ip := net.ParseIP(host); ip != nil && !publicIP(ip) {
+				return fmt.Errorf("refusing to deliver to private address %s", host)
+			}
+			return nil
+		}
+	}
+	transport := &http.Transport{DialContext: dialer.DialContext, Proxy: nil}
+	return &Dispatcher{
+		db:     db,
+		policy: p,
+		client: &http.Client{Timeout: p.Timeout, Transport: transport},
+		wake:   make(chan struct{}, 1),
+		now:    time.Now,
+	}
+}
+
+func (d *Dispatcher) run(ctx context.Context) {
+	interval := d.policy.PollInterval
+	if interval <= 0 {
+		interval = DefaultPolicy.PollInterval
+	}
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for {
+		if _, err := d.DeliverDue(ctx); err != nil {
+			log.Printf("[webhook] ❌ delivery run failed: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		case <-d.wake:
+		}
+	}
+}
+
+// DeliverDue attempts the pending deliveries that are due and returns how
+// many it attempted.
+func (d *Dispatcher) DeliverDue(ctx context.Context) (int, error) {
+	var due []models.WebhookDelivery
+	if err := d.db.Where("status = ? AND next_attempt_at <= ?", models.DeliveryPending, d.now()).
+		Order("next_attempt_at, id").Limit(50).Find(&due).Error; err != nil {
+		return 0, err
+	}
+	n := 0
+	for _, del := range due {
+		if ctx.Err() != nil {
+			break
+		}
+		// Claim the delivery so another instance polling the same table
+		// skips it while it is in flight.
+		lease := d.now().Add(2 * d.policy.Timeout)
+		res := d.db.Model(&models.WebhookDelivery{}).
+			Where("id = ? AND status = ? AND next_attempt_at = ?", del.ID, models.DeliveryPending, del.NextAttemptAt).
+			Update("next_attempt_at", lease)
+		if res.Error != nil || res.RowsAffected != 1 {
+			continue
+		}
+		d.attempt(ctx, del)
+		n++
+	}
+	return n, nil
+}
+
+// attempt posts del once and records the outcome.
+func (d *Dispatcher) attempt(ctx context.Context, del models.WebhookDelivery) {
+	var hook models.Webhook
+	updates := map[string]any{"attempts": del.Attempts + 1}
+	err := d.db.First(&hook, del.WebhookID).Error
+	if err == nil && !hook.Active {
+		err = errors.New("webhook disabled")
+	}
+	if err != nil {
+		updates["status"] = models.DeliveryFailed
+		updates["error"] = "webhook unavailable: " + err.Error()
+		d.save(del.ID, updates)
+		return
+	}
+
+	code, err := d.post(ctx, hook, del)
+	updates["response_code"] = code
+	now := d.now()
+	switch {
+	case err == nil:
+		updates["status"] = models.DeliverySucceeded
+		updates["error"] = ""
+		updates["delivered_at"] = now
+		log.Printf("[webhook] ✅ delivered %s #%d to webhook %d (%d)", del.Event, del.ID, hook.ID, code)
+	case del.Attempts+1 >= d.policy.MaxAttempts:
+		updates["status"] = models.DeliveryFailed
+		updates["error"] = truncate(err.Error(), 500)
+		log.Printf("[webhook] ❌ giving up on %s #%d to webhook %d after %d attempts: %v", del.Event, del.ID, hook.ID, del.Attempts+1, err)
+	default:
+		updates["error"] = truncate(err.Error(), 500)
+		updates["next_attempt_at"] = now.Add(d.policy.Backoff(del.Attempts + 1))
+		log.Printf("[webhook] ⚠️ %s #%d to webhook %d failed (attempt %d): %v", del.Event, del.ID, hook.ID, del.Attempts+1, err)
+	}
+	d.save(del.ID, updates)
+}
+
+func (d *Dispatcher) save(id uint, updates map[string]any) {
+	if err := d.db.Model(&models.WebhookDelivery{}).Where("id = ?", id).Updates(updates).Error; err != nil {
+		log.Printf("[webhook] ⚠️ failed to record delivery %d: %v", id, err)
+	}
+}
+
+// post sends del and returns the response status; non-2xx is an error.
+func (d *Dispatcher) post(ctx context.Context, hook models.Webhook, del models.WebhookDelivery) (int, error) {
+	body := []byte(del.Payload)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, hook.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	ts := d.now().Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "AkuAI-Webhook/1")
+	req.Header.Set("X-AkuAI-Event", del.Event)
+	req.Header.Set("X-AkuAI-Delivery", strconv.FormatUint(uint64(del.ID), 10))
+	req.Header.Set("X-AkuAI-Timestamp", strconv.FormatInt(ts, 10))
+	req.Header.Set("X-AkuAI-Signature", Sign(hook.Secret, ts, body))
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("callback answered %s", resp.Status)
+	}
+	return resp.StatusCode, nil
+}
+
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return s[:n]
+}
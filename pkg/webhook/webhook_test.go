@@ -0,0 +1,67 @@
+package webhook
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestSign(t *testing.T) {
+	// echo -n '1700000000.{"a":1}' | openssl dgst -sha256 -hmac whsec_test
+	want := "sha256=38877139021993b830af32feea6e18a8da83eb2f6e49ee50bd9e4cf4ca4d3789"
+	if got := Sign("whsec_test", 1700000000, []byte(`{"a":1}`)); got != want {
+		t.Fatalf("Sign = %s, want %s", got, want)
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	p := Policy{BaseBackoff: 30 * time.Second, MaxBackoff: 5 * time.Minute}
+	for attempts, want := range map[int]time.Duration{1: 30 * time.Second, 2: time.Minute, 3: 2 * time.Minute, 4: 4 * time.Minute, 5: 5 * time.Minute, 20: 5 * time.Minute} {
+		if got := p.Backoff(attempts); got != want {
+			t.Errorf("Backoff(%d) = %v, want %v", attempts, got, want)
+		}
+	}
+}
+
+func TestDeliveryIgnoresProxy(t *testing.T) {
+	var proxied atomic.Int32
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		proxied.Add(1)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer proxy.Close()
+	t.Setenv("HTTP_PROXY", proxy.URL)
+	t.Setenv("http_proxy", proxy.URL)
+
+	d := New(nil, Policy{Timeout: 2 * time.Second})
+	_, err := d.client.Post("http://10.1.2.3/hook", "application/json", strings.NewReader(`{}`))
+	if err == nil || !strings.Contains(err.Error(), "private address 10.1.2.3") {
+		t.Fatalf("delivery to a private target through HTTP_PROXY: %v", err)
+	}
+	if proxied.Load() != 0 {
+		t.Fatal("delivery went through HTTP_PROXY")
+	}
+}
+
+func TestValidateURL(t *testing.T) {
+	for raw, ok := range map[string]bool{
+		"https://example.com/hook": true,
+		"http://203.0.113.7/hook":  true,
+		"ftp://example.com/hook":   false,
+		"/relative":                false,
+		"http://127.0.0.1:8080/":   false,
+		"http://10.1.2.3/":         false,
+		"http://169.254.169.254/":  false,
+		"http://[::1]/":            false,
+	} {
+		if err := ValidateURL(raw, false); (err == nil) != ok {
+			t.Errorf("ValidateURL(%q) = %v, want ok=%v", raw, err, ok)
+		}
+	}
+	if err := ValidateURL("http://127.0.0.1:8080/", true); err != nil {
+		t.Errorf("private URL refused with allowPrivate: %v", err)
+	}
+}
@@ -0,0 +1,193 @@
+// Package wshub tracks the open WebSocket connections of every user, caps
+// how many a user may hold and how fast they reconnect and send messages, and
+// delivers server-initiated events (announcements, notifications) to the
+// connections that listen for them.
+package wshub
+
+import (
+	"errors"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ErrTooManyConnections is returned by Register when the user already holds
+// the maximum number of connections.
+var ErrTooManyConnections = errors.New("too many open WebSocket connections")
+
+// Conn is one registered connection. Events is nil unless it was registered
+// to listen; the handler writes what it receives to the socket.
+type Conn struct {
+	UserID string
+	Kind   string // chat | events
+	Opened time.Time
+	events chan any
+}
+
+// Events delivers the messages sent to this connection.
+func (c *Conn) Events() <-chan any { return c.events }
+
+// Stats is the snapshot exposed on /admin/metrics.
+type Stats struct {
+	Connections int            `json:"connections"`
+	Users       int            `json:"users"`
+	ByKind      map[string]int `json:"by_kind"`
+	MaxPerUser  int            `json:"max_per_user"`
+	Rejected    int64          `json:"rejected"`   // handshakes over MaxPerUser
+	BackedOff   int64          `json:"backed_off"` // handshakes refused by the reconnect backoff
+	Throttled   int64          `json:"throttled"`  // messages over the message rate
+	Dropped     int64          `json:"dropped"`    // events not delivered to a full connection
+}
+
+// Hub is the registry of open connections.
+type Hub struct {
+	mu         sync.Mutex
+	conns      map[string]map[*Conn]struct{}
+	limits     Limits
+	reconnects map[string]*reconnectState
+	messages   map[string]*messageWindow
+	lastSweep  time.Time
+	now        func() time.Time
+	rejected   atomic.Int64
+	backedOff  atomic.Int64
+	throttled  atomic.Int64
+	dropped    atomic.Int64
+}
+
+var (
+	defaultHub *Hub
+	once       sync.Once
+)
+
+// Start creates the default hub. Calls after the first one are ignored.
+func Start(limits Limits) *Hub {
+	once.Do(func() {
+		defaultHub = New(limits)
+	})
+	return defaultHub
+}
+
+func Default() *Hub {
+	return Start(DefaultLimits)
+}
+
+// New returns a hub enforcing limits.
+func New(limits Limits) *Hub {
+	return &Hub{
+		conns:      map[string]map[*Conn]struct{}{},
+		limits:     limits,
+		reconnects: map[string]*reconnectState{},
+		messages:   map[string]*messageWindow{},
+		now:        time.Now,
+	}
+}
+
+// Register adds a connection of userID, or fails with ErrTooManyConnections
+// or a *BackoffError. With listen set it receives the events sent to the user
+// and broadcasts.
+func (h *Hub) Register(userID, kind string, listen bool) (*Conn, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.limits.MaxPerUser > 0 && len(h.conns[userID]) >= h.limits.MaxPerUser {
+		h.rejected.Add(1)
+		return nil, ErrTooManyConnections
+	}
+	now := h.now()
+	if wait := h.reconnectWait(userID, now); wait > 0 {
+		h.backedOff.Add(1)
+		return nil, &BackoffError{RetryAfter: wait}
+	}
+	c := &Conn{UserID: userID, Kind: kind, Opened: now}
+	if listen {
+		c.events = make(chan any, 16)
+	}
+	if h.conns[userID] == nil {
+		h.conns[userID] = map[*Conn]struct{}{}
+	}
+	h.conns[userID][c] = struct{}{}
+	return c, nil
+}
+
+// Unregister removes a connection; calling it twice is harmless.
+func (h *Hub) Unregister(c *Conn) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	delete(h.conns[c.UserID], c)
+	if len(h.conns[c.UserID]) == 0 {
+		delete(h.conns, c.UserID)
+	}
+}
+
+// SendUser delivers msg to the listening connections of userID and returns
+// how many got it. Full connections miss it.
+func (h *Hub) SendUser(userID string, msg any) int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.send(h.conns[userID], msg)
+}
+
+// Broadcast delivers msg to every listening connection.
+func (h *Hub) Broadcast(msg any) int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	n := 0
+	for _, conns := range h.conns {
+		n += h.send(conns, msg)
+	}
+	return n
+}
+
+func (h *Hub) send(conns map[*Conn]struct{}, msg any) int {
+	n := 0
+	for c := range conns {
+		if c.events == nil {
+			continue
+		}
+		select {
+		case c.events <- msg:
+			n++
+		default:
+			h.dropped.Add(1)
+		}
+	}
+	return n
+}
+
+// Online returns how many connections userID holds.
+func (h *Hub) Online(userID string) int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return len(h.conns[userID])
+}
+
+// Listeners returns the users with at least one listening connection, sorted.
+func (h *Hub) Listeners() []string {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	var out []string
+	for u, conns := range h.conns {
+		for c := range conns {
+			if c.events != nil {
+				out = append(out, u)
+				break
+			}
+		}
+	}
+	sort.Strings(out)
+	return out
+}
+
+func (h *Hub) Stats() Stats {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	s := Stats{Users: len(h.conns), ByKind: map[string]int{}, MaxPerUser: h.limits.MaxPerUser,
+		Rejected: h.rejected.Load(), BackedOff: h.backedOff.Load(), Throttled: h.throttled.Load(), Dropped: h.dropped.Load()}
+	for _, conns := range h.conns {
+		for c := range conns {
+			s.Connections++
+			s.ByKind[c.Kind]++
+		}
+	}
+	return s
+}
@@ -0,0 +1,58 @@
+package wshub
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestRegisterCapsConnectionsPerUser(t *testing.T) {
+	h := New(Limits{MaxPerUser: 2})
+	a, _ := h.Register("1", "chat", false)
+	if _, err := h.Register("1", "events", true); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := h.Register("1", "chat", false); !errors.Is(err, ErrTooManyConnections) {
+		t.Fatalf("third connection: %v", err)
+	}
+	if _, err := h.Register("2", "chat", false); err != nil {
+		t.Fatalf("other user: %v", err)
+	}
+	h.Unregister(a)
+	h.Unregister(a)
+	if _, err := h.Register("1", "chat", false); err != nil {
+		t.Fatalf("after unregister: %v", err)
+	}
+	s := h.Stats()
+	if s.Connections != 3 || s.Users != 2 || s.ByKind["chat"] != 2 || s.Rejected != 1 {
+		t.Errorf("stats %+v", s)
+	}
+}
+
+func TestSendReachesListenersOnly(t *testing.T) {
+	h := New(Limits{})
+	chat, _ := h.Register("1", "chat", false)
+	ev, _ := h.Register("1", "events", true)
+	other, _ := h.Register("2", "events", true)
+
+	if n := h.SendUser("1", "hello"); n != 1 || <-ev.Events() != "hello" {
+		t.Fatalf("SendUser reached %d", n)
+	}
+	if chat.Events() != nil {
+		t.Error("chat connection has an event channel")
+	}
+	if n := h.Broadcast("all"); n != 2 {
+		t.Fatalf("Broadcast reached %d", n)
+	}
+	<-ev.Events()
+	<-other.Events()
+	if got := h.Listeners(); len(got) != 2 || got[0] != "1" {
+		t.Errorf("listeners %v", got)
+	}
+
+	for i := 0; i < cap(ev.events); i++ {
+		h.SendUser("1", i)
+	}
+	if n := h.SendUser("1", "dropped"); n != 0 || h.Stats().Dropped != 1 {
+		t.Errorf("full connection: sent %d, stats %+v", n, h.Stats())
+	}
+}
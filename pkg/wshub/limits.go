@@ -0,0 +1,135 @@
+package wshub
+
+import (
+	"fmt"
+	"time"
+)
+
+// Limits bound how many connections a user holds, how fast they reconnect
+// and how many messages they send. A zero value disables that limit.
+type Limits struct {
+	MaxPerUser int
+
+	// MessagesPerWindow messages are allowed per MessageWindow, counted over
+	// all connections of the user.
+	MessagesPerWindow int
+	MessageWindow     time.Duration
+
+	// The first FreeReconnects handshakes in a row are not delayed; after
+	// that each one must wait BackoffBase, doubling up to BackoffMax, since
+	// the previous one. A pause longer than ReconnectWindow resets the streak.
+	FreeReconnects  int
+	ReconnectWindow time.Duration
+	BackoffBase     time.Duration
+	BackoffMax      time.Duration
+}
+
+// DefaultLimits is used by Default when Start was not called.
+var DefaultLimits = Limits{
+	MaxPerUser:        5,
+	MessagesPerWindow: 20,
+	MessageWindow:     10 * time.Second,
+	FreeReconnects:    5,
+	ReconnectWindow:   30 * time.Second,
+	BackoffBase:       time.Second,
+	BackoffMax:        time.Minute,
+}
+
+// BackoffError is returned by Register when the user reconnects faster than
+// the backoff allows.
+type BackoffError struct {
+	RetryAfter time.Duration
+}
+
+func (e *BackoffError) Error() string {
+	return fmt.Sprintf("reconnecting too fast, retry in %s", e.RetryAfter.Round(time.Millisecond))
+}
+
+type reconnectState struct {
+	last   time.Time
+	streak int // handshakes accepted in a row without a ReconnectWindow pause
+}
+
+type messageWindow struct {
+	start time.Time
+	n     int
+}
+
+// backoff is the gap required before the next handshake after streak ones.
+func (l Limits) backoff(streak int) time.Duration {
+	if l.BackoffBase <= 0 || streak < l.FreeReconnects {
+		return 0
+	}
+	d := l.BackoffBase
+	for i := l.FreeReconnects; i < streak && (l.BackoffMax <= 0 || d < l.BackoffMax); i++ {
+		d *= 2
+	}
+	if l.BackoffMax > 0 && d > l.BackoffMax {
+		d = l.BackoffMax
+	}
+	return d
+}
+
+// sweep forgets reconnect streaks and message windows that have expired.
+// Callers hold h.mu.
+func (h *Hub) sweep(now time.Time) {
+	if now.Sub(h.lastSweep) < time.Minute {
+		return
+	}
+	for u, r := range h.reconnects {
+		if now.Sub(r.last) > h.limits.ReconnectWindow {
+			delete(h.reconnects, u)
+		}
+	}
+	for u, w := range h.messages {
+		if now.Sub(w.start) >= h.limits.MessageWindow {
+			delete(h.messages, u)
+		}
+	}
+	h.lastSweep = now
+}
+
+// reconnectWait returns how long userID must still wait before connecting,
+// or records the handshake and returns 0. Callers hold h.mu.
+func (h *Hub) reconnectWait(userID string, now time.Time) time.Duration {
+	h.sweep(now)
+	if h.limits.BackoffBase <= 0 {
+		return 0
+	}
+	r := h.reconnects[userID]
+	if r == nil {
+		r = &reconnectState{}
+		h.reconnects[userID] = r
+	} else if now.Sub(r.last) > h.limits.ReconnectWindow {
+		r.streak = 0
+	}
+	if wait := h.limits.backoff(r.streak) - now.Sub(r.last); r.streak > 0 && wait > 0 {
+		return wait
+	}
+	r.last = now
+	r.streak++
+	return 0
+}
+
+// AllowMessage counts one message from userID and reports whether it is
+// within the message rate; when it is not, it also returns how long until
+// the window resets.
+func (h *Hub) AllowMessage(userID string) (time.Duration, bool) {
+	if h.limits.MessagesPerWindow <= 0 || h.limits.MessageWindow <= 0 {
+		return 0, true
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	now := h.now()
+	w := h.messages[userID]
+	if w == nil || now.Sub(w.start) >= h.limits.MessageWindow {
+		w = &messageWindow{start: now}
+		h.messages[userID] = w
+	}
+	if w.n >= h.limits.MessagesPerWindow {
+		h.throttled.Add(1)
+		return h.limits.MessageWindow - now.Sub(w.start), false
+	}
+	w.n++
+	return 0, true
+}
@@ -0,0 +1,78 @@
+package wshub
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func fakeClock(h *Hub) *time.Time {
+	now := time.Date(2025, 10, 4, 9, 0, 0, 0, time.UTC)
+	h.now = func() time.Time { return now }
+	return &now
+}
+
+func TestReconnectBackoff(t *testing.T) {
+	h := New(Limits{FreeReconnects: 2, ReconnectWindow: 30 * time.Second, BackoffBase: time.Second, BackoffMax: 4 * time.Second})
+	now := fakeClock(h)
+	connect := func() error {
+		c, err := h.Register("1", "chat", false)
+		if err == nil {
+			h.Unregister(c)
+		}
+		return err
+	}
+
+	for i := 0; i < 2; i++ {
+		if err := connect(); err != nil {
+			t.Fatalf("free reconnect %d: %v", i, err)
+		}
+	}
+	var backoff *BackoffError
+	if err := connect(); !errors.As(err, &backoff) || backoff.RetryAfter != time.Second {
+		t.Fatalf("third reconnect: %v", err)
+	}
+	// the wait doubles with every accepted reconnect, up to BackoffMax
+	for _, wait := range []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 4 * time.Second} {
+		*now = now.Add(wait - time.Millisecond)
+		if err := connect(); err == nil {
+			t.Fatalf("reconnect before %v accepted", wait)
+		}
+		*now = now.Add(time.Millisecond)
+		if err := connect(); err != nil {
+			t.Fatalf("reconnect after %v: %v", wait, err)
+		}
+	}
+	// a quiet window resets the streak
+	*now = now.Add(31 * time.Second)
+	if err := connect(); err != nil {
+		t.Fatalf("after a pause: %v", err)
+	}
+	if err := connect(); err != nil {
+		t.Fatalf("second after a pause: %v", err)
+	}
+	if h.Stats().BackedOff != 5 {
+		t.Errorf("backed off %d, want 5", h.Stats().BackedOff)
+	}
+}
+
+func TestAllowMessage(t *testing.T) {
+	h := New(Limits{MessagesPerWindow: 3, MessageWindow: 10 * time.Second})
+	now := fakeClock(h)
+	for i := 0; i < 3; i++ {
+		if _, ok := h.AllowMessage("1"); !ok {
+			t.Fatalf("message %d throttled", i)
+		}
+	}
+	*now = now.Add(4 * time.Second)
+	if retry, ok := h.AllowMessage("1"); ok || retry != 6*time.Second {
+		t.Fatalf("fourth message: ok=%v retry=%v", ok, retry)
+	}
+	if _, ok := h.AllowMessage("2"); !ok {
+		t.Error("other user throttled")
+	}
+	*now = now.Add(6 * time.Second)
+	if _, ok := h.AllowMessage("1"); !ok {
+		t.Error("new window throttled")
+	}
+}
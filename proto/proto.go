@@ -0,0 +1,11 @@
+// Package proto embeds the API definitions, so the gRPC server builds its
+// descriptors from the same files clients compile.
+package proto
+
+import "embed"
+
+// Files holds every .proto under this directory, by path relative to it
+// (e.g. "akuai/v1/akuai.proto").
+//
+//go:embed akuai/v1/*.proto
+var Files embed.FS
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"AkuAI/pkg/fieldcrypt"
+	"errors"
+	"fmt"
+
+	"gorm.io/gorm"
+)
+
+const reencryptUsage = "usage: AkuAI reencrypt [--dry-run]"
+
+// encryptedColumns are the columns with the encrypted serializer.
+var encryptedColumns = []fieldcrypt.Column{
+	{Table: "messages", Name: "text"},
+	{Table: "conversations", Name: "title"},
+	{Table: "webhook_deliveries", Name: "payload"},
+	{Table: "moderation_events", Name: "excerpt"},
+}
+
+// runReencrypt implements the reencrypt subcommand: after a key rotation
+// (a new key put first in FIELD_ENCRYPTION_KEYS, the old ones kept after
+// it) or when encryption is first turned on, it seals every stored value
+// with the current key. Old keys can be dropped once it has run.
+func runReencrypt(db *gorm.DB, args []string) error {
+	dryRun := false
+	for _, a := range args {
+		if a != "--dry-run" {
+			return errors.New(reencryptUsage)
+		}
+		dryRun = true
+	}
+	k := fieldcrypt.Default()
+	if !k.Enabled() {
+		return errors.New("FIELD_ENCRYPTION_KEYS is not set")
+	}
+	verb := "re-encrypted"
+	if dryRun {
+		verb = "would re-encrypt"
+	}
+	for _, col := range encryptedColumns {
+		n, err := fieldcrypt.Reencrypt(db, k, col, dryRun)
+		if err != nil {
+			return fmt.Errorf("%s: %w (after %d rows)", col, err, n)
+		}
+		fmt.Printf("[reencrypt] ✅ %s %d rows of %s with key %s\n", verb, n, col, k.CurrentID())
+	}
+	return nil
+}
@@ -0,0 +1,50 @@
+package admin
+
+import (
+	"AkuAI/controllers"
+	"AkuAI/middleware"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+func Register(g *gin.RouterGroup, db *gorm.DB) {
+	adminGroup := g.Group("/admin", middleware.AdminMiddleware(db))
+	{
+		adminGroup.GET("/moderation", controllers.ListModerationEvents(db))
+		adminGroup.GET("/frustration", controllers.ListFrustratedConversations(db))
+		adminGroup.POST("/frustration/:conversation_id/review", controllers.ReviewFrustratedConversation(db))
+		adminGroup.GET("/prompts", controllers.GetPrompt(db))
+		adminGroup.PUT("/prompts", controllers.SavePrompt(db))
+		adminGroup.POST("/prompts/versions/:version/restore", controllers.RestorePrompt(db))
+	}
+	// Settings and data of the whole deployment, kept from tenant admins.
+	operatorGroup := adminGroup.Group("", middleware.OperatorOnly())
+	{
+		operatorGroup.GET("/metrics", controllers.GetMetrics())
+		operatorGroup.GET("/slots", controllers.GetSlotSettings())
+		operatorGroup.PUT("/slots", controllers.UpdateSlotSettings(db))
+		operatorGroup.GET("/retention", controllers.GetRetention(db))
+		operatorGroup.POST("/retention/run", controllers.RunRetention(db))
+		operatorGroup.POST("/digest/run", controllers.RunDigest(db))
+		operatorGroup.GET("/documents", controllers.ListDocuments(db))
+		operatorGroup.POST("/documents", controllers.UploadDocument(db))
+		operatorGroup.DELETE("/documents/:id", controllers.DeleteDocument(db))
+		operatorGroup.GET("/announcements", controllers.ListAnnouncements(db))
+		operatorGroup.POST("/announcements", controllers.CreateAnnouncement(db))
+		operatorGroup.DELETE("/announcements/:id", controllers.DeleteAnnouncement(db))
+		operatorGroup.GET("/api-keys", controllers.ListAPIKeys(db))
+		operatorGroup.POST("/api-keys", controllers.CreateAPIKey(db))
+		operatorGroup.DELETE("/api-keys/:id", controllers.RevokeAPIKey(db))
+		operatorGroup.GET("/audit", controllers.ListAuditLogs(db))
+		operatorGroup.GET("/jwt/keys", controllers.ListSigningKeys())
+		operatorGroup.POST("/jwt/rotate", controllers.RotateSigningKey(db))
+		operatorGroup.GET("/lockouts", controllers.ListLockouts())
+		operatorGroup.POST("/lockouts/reset", controllers.ResetLockout(db))
+		operatorGroup.GET("/webhooks", controllers.AdminListWebhooks(db))
+		operatorGroup.POST("/webhooks", controllers.AdminCreateWebhook(db))
+		operatorGroup.DELETE("/webhooks/:id", controllers.AdminDeleteWebhook(db))
+		operatorGroup.GET("/webhooks/deliveries", controllers.AdminListWebhookDeliveries(db))
+		operatorGroup.POST("/webhooks/deliveries/:id/redeliver", controllers.RedeliverWebhook(db))
+	}
+}
@@ -0,0 +1,15 @@
+package analytics
+
+import (
+	"AkuAI/controllers"
+	"AkuAI/middleware"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+func Register(g *gin.RouterGroup, db *gorm.DB) {
+	g.GET("/analytics/me", controllers.MyAnalytics(db))
+	g.GET("/analytics/global", middleware.AdminMiddleware(db), controllers.GlobalAnalytics(db))
+	g.GET("/analytics/prompt-ab", middleware.AdminMiddleware(db), middleware.OperatorOnly(), controllers.PromptABReport(db))
+}
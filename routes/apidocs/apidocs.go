@@ -0,0 +1,27 @@
+package apidocs
+
+import (
+	_ "embed"
+	"net/http"
+
+	"AkuAI/pkg/apidocs"
+	"AkuAI/pkg/config"
+
+	"github.com/gin-gonic/gin"
+)
+
+//go:embed swagger.html
+var swaggerHTML []byte
+
+func Register(r *gin.Engine) {
+	if !config.APIDocsEnabled {
+		return
+	}
+
+	r.GET("/api/docs", func(c *gin.Context) {
+		c.Data(http.StatusOK, "text/html; charset=utf-8", swaggerHTML)
+	})
+	r.GET("/api/docs/openapi.json", func(c *gin.Context) {
+		c.JSON(http.StatusOK, apidocs.Spec("AkuAI Core API", "1.0.0"))
+	})
+}
@@ -7,11 +7,12 @@ import (
 	"gorm.io/gorm"
 )
 
-func RegisterPublic(r *gin.Engine, db *gorm.DB) {
-	r.POST("/register", controllers.Register(db))
-	r.POST("/login", controllers.Login(db))
+func RegisterPublic(g *gin.RouterGroup, db *gorm.DB) {
+	g.POST("/register", controllers.Register(db))
+	g.POST("/login", controllers.Login(db))
 }
 
 func RegisterProtected(g *gin.RouterGroup, db *gorm.DB) {
-	g.POST("/logout", controllers.Logout())
+	g.POST("/logout", controllers.Logout(db))
+	g.POST("/auth/claim", controllers.ClaimGuestConversations(db))
 }
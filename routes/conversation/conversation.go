@@ -8,12 +8,36 @@ import (
 	"gorm.io/gorm"
 )
 
-func Register(g *gin.RouterGroup, db *gorm.DB) {
-	g.POST("/conversations", middleware.RateLimit(), controllers.CreateOrAddMessage(db))
-	g.POST("/conversations/stream", middleware.RateLimit(), controllers.CreateOrAddMessageStream(db))
-	g.POST("/conversations/compare", middleware.RateLimit(), controllers.ComparePromptModes())
+// Register mounts the conversation routes on g; apiPrefix is where the
+// current API version lives, for links to routes without a legacy alias.
+func Register(g *gin.RouterGroup, db *gorm.DB, apiPrefix string) {
+	g.POST("/conversations", middleware.RateLimit(), middleware.Moderation(db), middleware.Idempotency(), middleware.GenerationOverride(db), controllers.CreateOrAddMessage(db, apiPrefix))
+	g.POST("/conversations/stream", middleware.RateLimit(), middleware.Moderation(db), middleware.Idempotency(), middleware.GenerationOverride(db), controllers.CreateOrAddMessageStream(db))
+	g.GET("/conversations/stream/resume", controllers.ResumeStream())
+	g.POST("/conversations/compare", middleware.RateLimit(), middleware.Moderation(db), middleware.GenerationOverride(db), controllers.ComparePromptModes())
 	g.GET("/conversations", controllers.ListConversations(db))
+	g.GET("/conversations/trash", controllers.ListTrash(db))
+	g.POST("/conversations/:conversation_id/restore", controllers.RestoreConversation(db))
 	g.GET("/conversations/:conversation_id", controllers.GetConversation(db))
+	g.GET("/conversations/:conversation_id/messages", controllers.ListMessages(db))
+	g.POST("/conversations/:conversation_id/email", middleware.TranscriptEmailRateLimit(), controllers.EmailConversation(db))
+	g.POST("/conversations/:conversation_id/continue", middleware.RateLimit(), middleware.GenerationOverride(db), controllers.ContinueMessage(db))
+	g.POST("/conversations/:conversation_id/summarize", middleware.RateLimit(), middleware.GenerationOverride(db), controllers.SummarizeLastAnswer(db))
 	g.DELETE("/conversations/:conversation_id", controllers.DeleteConversation(db))
+	g.POST("/conversations/:conversation_id/archive", controllers.ArchiveConversation(db, true))
+	g.POST("/conversations/:conversation_id/unarchive", controllers.ArchiveConversation(db, false))
+	g.POST("/conversations/:conversation_id/pin", controllers.PinConversation(db, true))
+	g.POST("/conversations/:conversation_id/unpin", controllers.PinConversation(db, false))
+	g.PUT("/conversations/:conversation_id/folder", controllers.MoveConversation(db))
+	g.PUT("/conversations/:conversation_id/messages/:message_id/feedback", controllers.SetMessageFeedback(db))
+	g.PUT("/conversations/:conversation_id/messages/:message_id/bookmark", controllers.BookmarkMessage(db))
+	g.DELETE("/conversations/:conversation_id/messages/:message_id/bookmark", controllers.UnbookmarkMessage(db))
+	g.POST("/conversations/:conversation_id/messages/:message_id/reactions", controllers.AddReaction(db))
+	g.DELETE("/conversations/:conversation_id/messages/:message_id/reactions/:emoji", controllers.RemoveReaction(db))
 	g.DELETE("/conversations", controllers.DeleteAllConversations(db))
+	g.GET("/bookmarks", controllers.ListBookmarks(db))
+	g.GET("/folders", controllers.ListFolders(db))
+	g.POST("/folders", controllers.CreateFolder(db))
+	g.PUT("/folders/:id", controllers.RenameFolder(db))
+	g.DELETE("/folders/:id", controllers.DeleteFolder(db))
 }
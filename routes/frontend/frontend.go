@@ -0,0 +1,46 @@
+package frontend
+
+import (
+	"embed"
+	"io/fs"
+	"log"
+	"os"
+
+	"AkuAI/pkg/config"
+	"AkuAI/pkg/spa"
+
+	"github.com/gin-gonic/gin"
+)
+
+// dist is the built frontend compiled into the binary. Copy the bundle here
+// before `go build` (e.g. cp -r ../views/build/. routes/frontend/dist/).
+//
+//go:embed all:dist
+var dist embed.FS
+
+// Register serves the frontend for every path no route matched when
+// FRONTEND_ENABLED=1, from FRONTEND_DIR or else the embedded bundle. It
+// reports whether it did; the caller then leaves "/" to the frontend.
+func Register(r *gin.Engine, apiPrefixes ...string) bool {
+	if !config.FrontendEnabled {
+		return false
+	}
+	var fsys fs.FS
+	source := "embedded"
+	if config.FrontendDir != "" {
+		fsys, source = os.DirFS(config.FrontendDir), config.FrontendDir
+	} else {
+		fsys, _ = fs.Sub(dist, "dist")
+	}
+	h, err := spa.New(fsys, apiPrefixes...)
+	if err != nil {
+		log.Printf("[frontend] ⚠️ not serving the %s frontend: %v", source, err)
+		return false
+	}
+	if config.LegacyRoutesEnabled {
+		log.Printf("[frontend] ⚠️ legacy routes are enabled and shadow frontend paths such as /conversations; set LEGACY_ROUTES_ENABLED=0")
+	}
+	r.NoRoute(h.Serve)
+	log.Printf("[frontend] ✅ serving %s frontend (%d files)", source, h.Files())
+	return true
+}
@@ -0,0 +1,16 @@
+package guest
+
+import (
+	"AkuAI/controllers"
+	"AkuAI/middleware"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// Register mounts guest chat, identified by the guest cookie instead of a JWT.
+func Register(g *gin.RouterGroup, db *gorm.DB) {
+	g.POST("/guest/conversations", middleware.GuestSession(), middleware.GuestRateLimit(), middleware.Moderation(db), controllers.GuestChat(db))
+	g.GET("/guest/conversations", middleware.GuestSession(), controllers.ListGuestConversations(db))
+	g.GET("/guest/conversations/:conversation_id", middleware.GuestSession(), controllers.GetGuestConversation(db))
+}
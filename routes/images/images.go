@@ -11,7 +11,7 @@ func Register(r *gin.RouterGroup, db *gorm.DB) {
 	imageController := controllers.NewImageController()
 
 	// Image API routes
-	apiGroup := r.Group("/api/images")
+	apiGroup := r.Group("/images")
 	{
 		apiGroup.GET("/health", imageController.HealthCheck)
 		apiGroup.POST("/search", imageController.SearchImages)
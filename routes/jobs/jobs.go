@@ -0,0 +1,12 @@
+package jobs
+
+import (
+	"AkuAI/controllers"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+func Register(g *gin.RouterGroup, db *gorm.DB) {
+	g.GET("/jobs/:id", controllers.GetJob())
+}
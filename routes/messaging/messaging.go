@@ -0,0 +1,25 @@
+package messaging
+
+import (
+	"AkuAI/controllers"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// RegisterPublic mounts the webhooks called by Telegram, WhatsApp, Slack and
+// Discord; they authenticate with the platform's secret or signature instead
+// of a JWT.
+func RegisterPublic(g *gin.RouterGroup, db *gorm.DB) {
+	g.POST("/messaging/telegram/webhook", controllers.TelegramWebhook(db))
+	g.GET("/messaging/whatsapp/webhook", controllers.WhatsAppVerify())
+	g.POST("/messaging/whatsapp/webhook", controllers.WhatsAppWebhook(db))
+	g.POST("/messaging/slack/command", controllers.SlackCommand())
+	g.POST("/messaging/discord/interactions", controllers.DiscordInteraction())
+}
+
+func RegisterProtected(g *gin.RouterGroup, db *gorm.DB) {
+	g.POST("/messaging/link-code", controllers.CreateChatLinkCode(db))
+	g.GET("/messaging/links", controllers.ListChatLinks(db))
+	g.DELETE("/messaging/links/:id", controllers.DeleteChatLink(db))
+}
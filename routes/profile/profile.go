@@ -2,6 +2,7 @@ package profile
 
 import (
 	"AkuAI/controllers"
+	"AkuAI/middleware"
 
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
@@ -11,7 +12,17 @@ func Register(g *gin.RouterGroup, db *gorm.DB) {
 	g.GET("/profile", controllers.Profile(db))
 	g.PUT("/profile", controllers.Profile(db))
 	g.POST("/profile/image/token", controllers.ProfileImageUploadToken(db))
-	g.POST("/profile/image/upload", controllers.ProfileImageUpload(db))
+	g.POST("/profile/image/upload", middleware.UploadRateLimit(), controllers.ProfileImageUpload(db))
 	g.GET("/profile/image", controllers.ProfileImageURL(db))
+	g.PUT("/profile/image/visibility", controllers.ProfileImageVisibility(db))
 	g.DELETE("/profile/image", controllers.DeleteProfileImage(db))
+	g.GET("/profile/preferences", controllers.Preferences(db))
+	g.PUT("/profile/preferences", controllers.Preferences(db))
+	g.GET("/profile/memory", controllers.Memory(db))
+	g.PUT("/profile/memory", controllers.Memory(db))
+	g.DELETE("/profile/memory", controllers.ForgetMemory(db))
+	g.DELETE("/profile/memory/:id", controllers.ForgetMemory(db))
+	g.GET("/profile/digest", controllers.Digest(db))
+	g.PUT("/profile/digest", controllers.Digest(db))
+	g.GET("/profile/digest/preview", controllers.PreviewDigest(db))
 }
@@ -2,38 +2,108 @@ package routes
 
 import (
 	"AkuAI/middleware"
+	"AkuAI/pkg/apikey"
+	"AkuAI/pkg/config"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
 
+	adminRoutes "AkuAI/routes/admin"
+	analyticsRoutes "AkuAI/routes/analytics"
+	apidocsRoutes "AkuAI/routes/apidocs"
 	authRoutes "AkuAI/routes/auth"
 	convRoutes "AkuAI/routes/conversation"
+	frontendRoutes "AkuAI/routes/frontend"
+	guestRoutes "AkuAI/routes/guest"
 	imageRoutes "AkuAI/routes/images"
+	jobRoutes "AkuAI/routes/jobs"
+	messagingRoutes "AkuAI/routes/messaging"
 	profileRoutes "AkuAI/routes/profile"
+	tenantRoutes "AkuAI/routes/tenant"
 	uibRoutes "AkuAI/routes/uib"
 	uploadsRoutes "AkuAI/routes/uploads"
+	webhookRoutes "AkuAI/routes/webhooks"
 	websocketRoutes "AkuAI/routes/websocket"
 )
 
+// APIV1Prefix is the mount point for the current API version.
+const APIV1Prefix = "/api/v1"
+
+// module is one entry of the route registration table. Every module is
+// mounted under APIV1Prefix; legacyPrefix is where it lived before versioning
+// and is kept as a deprecated alias while legacy routes are enabled. Modules
+// added after versioning leave legacyPrefix empty. Protected modules with an
+// apiKeyScope also accept API keys granted that scope.
+type module struct {
+	name         string
+	legacyPrefix string
+	protected    bool
+	apiKeyScope  string
+	register     func(g *gin.RouterGroup, db *gorm.DB)
+}
+
+func (m module) auth(db *gorm.DB) gin.HandlerFunc {
+	if m.apiKeyScope != "" {
+		return middleware.APIKeyAuth(db, m.apiKeyScope)
+	}
+	return middleware.AuthMiddleware()
+}
+
+var modules = []module{
+	{name: "auth-public", legacyPrefix: "/", register: authRoutes.RegisterPublic},
+	{name: "tenant", register: tenantRoutes.Register},
+	{name: "websocket", legacyPrefix: "/", register: websocketRoutes.Register},
+	{name: "auth", legacyPrefix: "/", protected: true, register: authRoutes.RegisterProtected},
+	{name: "profile", legacyPrefix: "/", protected: true, register: profileRoutes.Register},
+	{name: "conversation", legacyPrefix: "/", protected: true, register: func(g *gin.RouterGroup, db *gorm.DB) {
+		convRoutes.Register(g, db, APIV1Prefix)
+	}},
+	{name: "guest", register: guestRoutes.Register},
+	{name: "jobs", protected: true, register: jobRoutes.Register},
+	// UIB routes - accessible to all authenticated users and uib:read API keys
+	{name: "uib", legacyPrefix: "/api", protected: true, apiKeyScope: apikey.ScopeUIBRead, register: uibRoutes.Register},
+	// Image search routes - accessible to all authenticated users and images:search API keys
+	{name: "images", legacyPrefix: "/api", protected: true, apiKeyScope: apikey.ScopeImagesSearch, register: imageRoutes.Register},
+	{name: "analytics", protected: true, register: analyticsRoutes.Register},
+	{name: "webhooks", protected: true, register: webhookRoutes.Register},
+	{name: "messaging-public", register: messagingRoutes.RegisterPublic},
+	{name: "messaging", protected: true, register: messagingRoutes.RegisterProtected},
+	{name: "admin", protected: true, register: adminRoutes.Register},
+}
+
 func RegisterRoutes(r *gin.Engine, db *gorm.DB) {
-	r.GET("/", func(c *gin.Context) {
-		c.JSON(http.StatusOK, gin.H{"msg": "Go auth + chat backend running"})
-	})
+	if !frontendRoutes.Register(r, "/api/", "/uploads/", "/files/", "/ws/") {
+		r.GET("/", func(c *gin.Context) {
+			c.JSON(http.StatusOK, gin.H{"msg": "Go auth + chat backend running", "api": APIV1Prefix})
+		})
+	}
 
+	apidocsRoutes.Register(r)
 	uploadsRoutes.Register(r, db)
-	websocketRoutes.Register(r, db)
-	authRoutes.RegisterPublic(r, db)
-
-	protected := r.Group("/")
-	protected.Use(middleware.AuthMiddleware())
-	authRoutes.RegisterProtected(protected, db)
-	profileRoutes.Register(protected, db)
-	convRoutes.Register(protected, db)
 
-	// UIB routes - accessible to all authenticated users
-	uibRoutes.Register(protected, db)
+	v1 := r.Group(APIV1Prefix)
+	for _, m := range modules {
+		g := v1.Group("", middleware.Tenant())
+		if m.protected {
+			g.Use(m.auth(db))
+		}
+		g.Use(middleware.Locale(db))
+		m.register(g, db)
+	}
 
-	// Image search routes - accessible to all authenticated users
-	imageRoutes.Register(protected, db)
+	if !config.LegacyRoutesEnabled {
+		return
+	}
+	for _, m := range modules {
+		if m.legacyPrefix == "" {
+			continue
+		}
+		g := r.Group(m.legacyPrefix, middleware.Deprecated(m.legacyPrefix, APIV1Prefix, config.LegacyRoutesSunset), middleware.Tenant())
+		if m.protected {
+			g.Use(m.auth(db))
+		}
+		g.Use(middleware.Locale(db))
+		m.register(g, db)
+	}
 }
@@ -0,0 +1,13 @@
+package tenant
+
+import (
+	"AkuAI/controllers"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// Register mounts the public branding of the request's tenant.
+func Register(g *gin.RouterGroup, db *gorm.DB) {
+	g.GET("/tenant", controllers.GetTenant())
+}
@@ -5,6 +5,7 @@ import (
 	"net/http"
 
 	"AkuAI/controllers"
+	"AkuAI/middleware"
 
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
@@ -16,7 +17,7 @@ func Register(r *gin.RouterGroup, db *gorm.DB) {
 	if err != nil {
 		log.Printf("Failed to initialize UIB controller: %v", err)
 		// Register a fallback handler
-		r.GET("/api/uib/health", func(c *gin.Context) {
+		r.GET("/uib/health", func(c *gin.Context) {
 			c.JSON(http.StatusServiceUnavailable, gin.H{
 				"success": false,
 				"message": "UIB service is unavailable: " + err.Error(),
@@ -26,20 +27,35 @@ func Register(r *gin.RouterGroup, db *gorm.DB) {
 	}
 
 	// UIB API routes group
-	uibGroup := r.Group("/api/uib")
+	uibGroup := r.Group("/uib")
 	{
 		// Health check endpoint
 		uibGroup.GET("/health", uibController.HealthCheck)
+		uibGroup.GET("/campuses", uibController.ListCampuses)
+		uibGroup.GET("/departments", uibController.GetDepartments)
 
 		// Events endpoints
 		uibGroup.GET("/events", uibController.GetAllEvents)
 		uibGroup.GET("/events/month/:month", uibController.GetEventsByMonth)
 		uibGroup.GET("/events/type/:type", uibController.GetEventsByType)
 		uibGroup.GET("/events/upcoming", uibController.GetUpcomingEvents)
+		uibGroup.GET("/events/recommended", uibController.RecommendedEvents(db))
 		uibGroup.GET("/events/summaries", uibController.GetEventSummaries)
 		uibGroup.GET("/events/search", uibController.SearchEvents)
+		uibGroup.GET("/events/conflicts", uibController.GetEventConflicts)
+		uibGroup.POST("/events/import", middleware.AdminMiddleware(db), uibController.ImportEvents(db))
 		uibGroup.GET("/events/:id", uibController.GetEventByID)
 
+		// Registration endpoints
+		uibGroup.POST("/events/:id/register", uibController.RegisterForEvent(db))
+		uibGroup.GET("/events/:id/register", uibController.GetEventRegistration(db))
+		uibGroup.DELETE("/events/:id/register", uibController.CancelEventRegistration(db))
+		uibGroup.GET("/events/:id/roster", middleware.AdminMiddleware(db), uibController.EventRoster(db))
+		uibGroup.GET("/events/:id/history", middleware.AdminMiddleware(db), uibController.EventHistory(db))
+		uibGroup.GET("/registrations", controllers.ListRegistrations(db))
+		uibGroup.GET("/registrations/:id/certificate", uibController.RegistrationCertificate(db))
+		uibGroup.POST("/registrations/:id/check-in", middleware.AdminMiddleware(db), controllers.CheckInRegistration(db))
+
 		// Query endpoints
 		uibGroup.POST("/query", uibController.QueryUIBEvents)
 		uibGroup.POST("/context", uibController.GetUIBContext)
@@ -1,10 +1,15 @@
 package uploads
 
 import (
+	"AkuAI/controllers"
+
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
 )
 
 func Register(r *gin.Engine, db *gorm.DB) {
-	r.Static("/uploads", "./uploads")
+	// Private profile images and certificates are served from links that
+	// carry an expiring signature
+	r.GET("/uploads/profiles/*path", controllers.ServeProfileImage(db))
+	r.GET("/files/certificates/*path", controllers.DownloadCertificate)
 }
@@ -0,0 +1,15 @@
+package webhooks
+
+import (
+	"AkuAI/controllers"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+func Register(g *gin.RouterGroup, db *gorm.DB) {
+	g.GET("/webhooks", controllers.ListWebhooks(db))
+	g.POST("/webhooks", controllers.CreateWebhook(db))
+	g.DELETE("/webhooks/:id", controllers.DeleteWebhook(db))
+	g.GET("/webhooks/:id/deliveries", controllers.ListWebhookDeliveries(db))
+}
@@ -8,6 +8,7 @@ import (
 	"gorm.io/gorm"
 )
 
-func Register(r *gin.Engine, db *gorm.DB) {
-	r.GET("/ws/chat", middleware.RateLimit(), controllers.ChatWS(db))
+func Register(g *gin.RouterGroup, db *gorm.DB) {
+	g.GET("/ws/chat", middleware.RateLimit(), middleware.GenerationOverride(db), controllers.ChatWS(db))
+	g.GET("/ws/jobs", controllers.JobsWS())
 }